@@ -211,14 +211,23 @@ func (a *App) PrintPosReceipt(input PrintPosReceiptInput) error {
 		}
 	}
 
+	printer, err := pos.PrinterFromName(input.PrinterName)
+	if err != nil {
+		return err
+	}
 	raw := pos.BuildReceipt(receipt)
-	return pos.RawPrint(input.PrinterName, raw)
+	return printer.Write(raw)
 }
 
-// OpenCashDrawer ouvre le tiroir caisse via commande ESC/POS
+// OpenCashDrawer ouvre le tiroir caisse via commande ESC/POS.
+// ⚠️ Ouverture non journalisée : le front passe par /api/pos/drawer/open,
+// qui l'enregistre dans cash_drawer_events.
 func (a *App) OpenCashDrawer(input OpenCashDrawerInput) error {
-	cmd := pos.OpenDrawerCmd()
-	return pos.RawPrint(input.PrinterName, cmd)
+	printer, err := pos.PrinterFromName(input.PrinterName)
+	if err != nil {
+		return err
+	}
+	return printer.Write(pos.OpenDrawerCmd())
 }
 
 // ============================================
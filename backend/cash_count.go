@@ -0,0 +1,112 @@
+// backend/cash_count.go
+// ═══════════════════════════════════════════════════════════════════════════
+// COMPTAGE DE CAISSE PAR COUPURE
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : cash_routes.go (ouverture / fermeture de session)
+//
+// Principe : le caissier saisit un nombre de pièces et de billets par valeur,
+// le serveur recalcule le total (en centimes, pour éviter les erreurs de
+// virgule flottante) et stocke le détail sur la session.
+
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EuroDenominations liste les coupures acceptées (billets puis pièces)
+var EuroDenominations = []float64{
+	500, 200, 100, 50, 20, 10, 5,
+	2, 1, 0.50, 0.20, 0.10, 0.05, 0.02, 0.01,
+}
+
+// DefaultCashDiscrepancyThreshold est le seuil d'écart (en €) au-delà duquel
+// une justification responsable est exigée, si la caisse n'en définit pas.
+const DefaultCashDiscrepancyThreshold = 5.0
+
+// Statuts d'écart de caisse (cash_sessions.discrepancy_status)
+const (
+	DiscrepancyNone                 = "none"
+	DiscrepancyWithinTolerance      = "within_tolerance"
+	DiscrepancyPendingJustification = "pending_justification"
+	DiscrepancyJustified            = "justified"
+)
+
+// CashCountLine représente une ligne de comptage : une coupure et sa quantité
+type CashCountLine struct {
+	Denomination float64 `json:"denomination"`
+	Quantity     int     `json:"quantity"`
+	Amount       float64 `json:"amount"`
+}
+
+// CashCount représente un comptage complet, tel que stocké sur la session
+type CashCount struct {
+	Lines []CashCountLine `json:"lines"`
+	Total float64         `json:"total"`
+}
+
+// NormalizeCashCount valide les lignes saisies, fusionne les doublons,
+// écarte les quantités nulles et recalcule montants et total.
+func NormalizeCashCount(lines []CashCountLine) (*CashCount, error) {
+	byCents := make(map[int64]int)
+
+	for i, l := range lines {
+		cents := int64(math.Round(l.Denomination * 100))
+		if !isEuroDenomination(cents) {
+			return nil, fmt.Errorf("lines[%d]: coupure invalide (%.2f€)", i, l.Denomination)
+		}
+		if l.Quantity < 0 {
+			return nil, fmt.Errorf("lines[%d]: quantité négative", i)
+		}
+		byCents[cents] += l.Quantity
+	}
+
+	denoms := make([]int64, 0, len(byCents))
+	for cents, qty := range byCents {
+		if qty > 0 {
+			denoms = append(denoms, cents)
+		}
+	}
+	// Ordre d'affichage : de la plus grosse coupure à la plus petite
+	sort.Slice(denoms, func(i, j int) bool { return denoms[i] > denoms[j] })
+
+	count := &CashCount{Lines: make([]CashCountLine, 0, len(denoms))}
+	var totalCents int64
+	for _, cents := range denoms {
+		qty := byCents[cents]
+		amountCents := cents * int64(qty)
+		totalCents += amountCents
+		count.Lines = append(count.Lines, CashCountLine{
+			Denomination: float64(cents) / 100,
+			Quantity:     qty,
+			Amount:       float64(amountCents) / 100,
+		})
+	}
+	count.Total = float64(totalCents) / 100
+
+	return count, nil
+}
+
+func isEuroDenomination(cents int64) bool {
+	for _, d := range EuroDenominations {
+		if int64(math.Round(d*100)) == cents {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyCashDiscrepancy détermine le statut d'un écart compté − attendu
+func ClassifyCashDiscrepancy(difference, threshold float64) string {
+	diff := math.Abs(roundAmount(difference))
+	switch {
+	case diff < 0.005:
+		return DiscrepancyNone
+	case diff <= threshold+0.005:
+		return DiscrepancyWithinTolerance
+	default:
+		return DiscrepancyPendingJustification
+	}
+}
@@ -0,0 +1,51 @@
+package backend
+
+import "testing"
+
+// Le total d'un comptage se calcule en centimes : 3 × 0,10 € doit faire
+// 0,30 €, pas 0,30000000000000004 €, sinon un tiroir juste affiche un écart.
+func TestNormalizeCashCountTotalEnCentimes(t *testing.T) {
+	count, err := NormalizeCashCount([]CashCountLine{
+		{Denomination: 0.10, Quantity: 3},
+		{Denomination: 20, Quantity: 2},
+		{Denomination: 0.10, Quantity: 1}, // doublon : fusionné
+		{Denomination: 50, Quantity: 0},   // quantité nulle : écartée
+	})
+	if err != nil {
+		t.Fatalf("comptage: %v", err)
+	}
+
+	if count.Total != 40.40 {
+		t.Errorf("total : attendu 40.40, obtenu %v", count.Total)
+	}
+	if len(count.Lines) != 2 || count.Lines[0].Denomination != 20 || count.Lines[1].Quantity != 4 {
+		t.Errorf("lignes inattendues : %+v", count.Lines)
+	}
+}
+
+func TestNormalizeCashCountRefuseUneCoupureInconnue(t *testing.T) {
+	if _, err := NormalizeCashCount([]CashCountLine{{Denomination: 3, Quantity: 1}}); err == nil {
+		t.Error("une pièce de 3 € n'existe pas, le comptage doit être refusé")
+	}
+	if _, err := NormalizeCashCount([]CashCountLine{{Denomination: 5, Quantity: -1}}); err == nil {
+		t.Error("une quantité négative doit être refusée")
+	}
+}
+
+func TestClassifyCashDiscrepancy(t *testing.T) {
+	cas := []struct {
+		ecart   float64
+		attendu string
+	}{
+		{0, DiscrepancyNone},
+		{-2.5, DiscrepancyWithinTolerance},
+		// Le seuil lui-même est toléré : on justifie au-delà, pas à partir de.
+		{5, DiscrepancyWithinTolerance},
+		{-5.01, DiscrepancyPendingJustification},
+	}
+	for _, c := range cas {
+		if got := ClassifyCashDiscrepancy(c.ecart, 5); got != c.attendu {
+			t.Errorf("écart %v : attendu %s, obtenu %s", c.ecart, c.attendu, got)
+		}
+	}
+}
@@ -145,9 +145,11 @@ func Inspect(dao *daos.Dao) (Findings, error) {
 		}
 	}
 
-	// Les quatre motifs de `stock-adjust.ts`, plus l'ancien `manual`. On ne
+	// Les quatre motifs de `stock-adjust.ts`, plus l'ancien `manual`, qui
+	// porte aussi les fiches modifiées dans l'application, `admin` (tableau
+	// de bord PocketBase) et `system` (écriture serveur sans origine). On ne
 	// compte PAS `import` ni `apppos_sync` : ceux-là se reconstruisent.
-	for _, source := range []string{"sale", "inventory_session", "return", "manual"} {
+	for _, source := range []string{"sale", "inventory_session", "return", "manual", "admin", "system"} {
 		n, err := countRows(dao, "product_events", dbx.NewExp("source = {:s}", dbx.Params{"s": source}))
 		if err != nil {
 			return f, err
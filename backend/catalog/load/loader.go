@@ -40,6 +40,7 @@ import (
 	"github.com/pocketbase/pocketbase/models"
 	"github.com/pocketbase/pocketbase/tools/filesystem"
 
+	"pocket-react/backend"
 	"pocket-react/backend/catalog/normalize"
 )
 
@@ -532,6 +533,9 @@ func loadProducts(tx *daos.Dao, cat *normalize.Catalog, res *Result, companyID s
 		r.Set("legacy_id", p.LegacyID)
 		r.Set("company", companyID)
 
+		// Journalisé `import` par les hooks du journal produit, s'ils sont
+		// enregistrés (cmd/catalog-import le fait)
+		backend.SetProductEventOrigin(r, backend.ProductEventOrigin{Source: "import", Operator: "catalog-import"})
 		if err := tx.SaveRecord(r); err != nil {
 			return fmt.Errorf("products/%s (%s): %w", p.LegacyID, p.Name, err)
 		}
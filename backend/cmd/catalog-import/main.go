@@ -33,6 +33,7 @@ import (
 	"pocket-react/backend/catalog/load"
 	"pocket-react/backend/catalog/nedb"
 	"pocket-react/backend/catalog/normalize"
+	"pocket-react/backend/hooks"
 	"pocket-react/backend/migrations"
 )
 
@@ -261,6 +262,10 @@ func doLoad(cat *normalize.Catalog, rep *normalize.Report, opts options, nedbDir
 		return fmt.Errorf("mise à niveau du schéma: %w", err)
 	}
 
+	// Les fiches chargées entrent au journal produit (source `import`),
+	// comme une fiche écrite par l'application
+	hooks.RegisterProductEventHooks(app)
+
 	res, err := load.Run(app, cat, rep, nedbDir, load.Options{ForcePurge: opts.forcePurge})
 	if err != nil {
 		// La transaction a été annulée : les collections sont restées vides
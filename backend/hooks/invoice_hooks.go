@@ -13,8 +13,10 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
 
+	"pocket-react/backend"
 	"pocket-react/backend/hash"
 )
 
@@ -613,6 +615,13 @@ func RegisterInvoiceHooks(app *pocketbase.PocketBase) {
 			}
 		}
 
+		// ---------------------------------------------------------------------
+		// 🎁 Fidélité (gain sur facture validée, annulation sur avoir)
+		// ---------------------------------------------------------------------
+		if err := backend.ApplyLoyaltyForDocument(app.Dao(), e.Record); err != nil {
+			log.Printf("⚠️ Fidélité non mise à jour pour %s: %v", e.Record.GetString("number"), err)
+		}
+
 		// Audit log
 		return createAuditLog(app, e.HttpContext, AuditLogParams{
 			Action:       getAuditAction(e.Record, "created"),
@@ -757,6 +766,13 @@ func RegisterInvoiceHooks(app *pocketbase.PocketBase) {
 		oldIsPaid := original.GetBool("is_paid")
 		newIsPaid := updated.GetBool("is_paid")
 
+		// 🎁 Fidélité : un brouillon qui passe en validé rapporte ses points
+		if oldStatus == "draft" && newStatus != "draft" {
+			if err := backend.ApplyLoyaltyForDocument(app.Dao(), updated); err != nil {
+				log.Printf("⚠️ Fidélité non mise à jour pour %s: %v", updated.GetString("number"), err)
+			}
+		}
+
 		var baseAction string
 		if oldStatus != newStatus {
 			switch newStatus {
@@ -1069,7 +1085,7 @@ func RegisterAuditLogHooks(app *pocketbase.PocketBase) {
 	app.OnRecordBeforeCreateRequest("audit_logs").Add(func(e *core.RecordCreateEvent) error {
 		record := e.Record
 
-		lastLog, err := getLastAuditLog(app, record.GetString("owner_company"))
+		lastLog, err := getLastAuditLog(app.Dao(), record.GetString("owner_company"))
 
 		var previousHash string
 		if err != nil || lastLog == nil {
@@ -1250,6 +1266,11 @@ func RegisterQuoteHooks(app *pocketbase.PocketBase) {
 	app.OnRecordBeforeCreateRequest("quotes").Add(func(e *core.RecordCreateEvent) error {
 		record := e.Record
 
+		// ✅ ÉTAPE 0: Prix des listes de prix (client / type de client / paliers)
+		if _, err := backend.RepriceDocumentItems(app.Dao(), record); err != nil {
+			log.Printf("⚠️ Listes de prix non appliquées au devis: %v", err)
+		}
+
 		// ✅ ÉTAPE 1: Normaliser le vat_breakdown AVANT l'arrondi
 		// Cela garantit que la structure est identique aux factures
 		normalizeQuoteVatBreakdown(record)
@@ -1286,6 +1307,13 @@ func RegisterQuoteHooks(app *pocketbase.PocketBase) {
 	app.OnRecordBeforeUpdateRequest("quotes").Add(func(e *core.RecordUpdateEvent) error {
 		record := e.Record
 
+		// Un devis envoyé est un engagement : seul le brouillon est retarifé
+		if record.GetString("status") == "draft" {
+			if _, err := backend.RepriceDocumentItems(app.Dao(), record); err != nil {
+				log.Printf("⚠️ Listes de prix non appliquées au devis %s: %v", record.Id, err)
+			}
+		}
+
 		// Si vat_breakdown est modifié, le normaliser
 		normalizeQuoteVatBreakdown(record)
 
@@ -1344,8 +1372,8 @@ func getLastInvoice(app *pocketbase.PocketBase, ownerCompany string) (*models.Re
 	return records[0], nil
 }
 
-func getLastAuditLog(app *pocketbase.PocketBase, ownerCompany string) (*models.Record, error) {
-	records, err := app.Dao().FindRecordsByFilter(
+func getLastAuditLog(dao *daos.Dao, ownerCompany string) (*models.Record, error) {
+	records, err := dao.FindRecordsByFilter(
 		"audit_logs",
 		fmt.Sprintf("owner_company = '%s'", ownerCompany),
 		"-created",
@@ -1402,8 +1430,24 @@ type AuditLogParams struct {
 	NewValues      map[string]interface{}
 }
 
+// CreateAuditLog expose createAuditLog aux routes métier qui écrivent sans
+// passer par les hooks REST (clôture de caisse, justification d'écart…).
+func CreateAuditLog(app *pocketbase.PocketBase, ctx echo.Context, params AuditLogParams) error {
+	return createAuditLog(app, ctx, params)
+}
+
+// CreateAuditLogTx écrit la trace dans la transaction de l'appelant : l'action
+// et son audit sont enregistrés ensemble, ou pas du tout
+func CreateAuditLogTx(dao *daos.Dao, ctx echo.Context, params AuditLogParams) error {
+	return writeAuditLog(dao, ctx, params)
+}
+
 func createAuditLog(app *pocketbase.PocketBase, ctx echo.Context, params AuditLogParams) error {
-	collection, err := app.Dao().FindCollectionByNameOrId("audit_logs")
+	return writeAuditLog(app.Dao(), ctx, params)
+}
+
+func writeAuditLog(dao *daos.Dao, ctx echo.Context, params AuditLogParams) error {
+	collection, err := dao.FindCollectionByNameOrId("audit_logs")
 	if err != nil {
 		return nil
 	}
@@ -1441,7 +1485,7 @@ func createAuditLog(app *pocketbase.PocketBase, ctx echo.Context, params AuditLo
 	}
 
 	// Chaînage
-	lastLog, _ := getLastAuditLog(app, params.OwnerCompany)
+	lastLog, _ := getLastAuditLog(dao, params.OwnerCompany)
 	var previousHash string
 	if lastLog == nil {
 		previousHash = GENESIS_HASH
@@ -1469,7 +1513,7 @@ func createAuditLog(app *pocketbase.PocketBase, ctx echo.Context, params AuditLo
 		record.Set("hash", auditHash)
 	}
 
-	return app.Dao().SaveRecord(record)
+	return dao.SaveRecord(record)
 }
 
 // ============================================================================
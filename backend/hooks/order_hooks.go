@@ -1,6 +1,10 @@
 // backend/hooks/order_hooks.go
 // Hook PocketBase pour les bons de commande.
-// Génère automatiquement le numéro BC-YYYY-XXXX avant chaque création.
+// Génère automatiquement le numéro BC-YYYY-XXXX avant chaque création,
+// applique les listes de prix aux lignes produit des BC en brouillon, et
+// tient les réservations de stock d'un BC confirmé (backend/reservation.go).
+// Les réservations suivent des hooks de modèle : toute écriture d'un BC, REST
+// ou serveur, les aligne dans la même écriture, et un échec la refuse.
 
 package hooks
 
@@ -10,16 +14,84 @@ import (
 	"strings"
 	"time"
 
+	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+
+	"pocket-react/backend"
 )
 
 // RegisterOrderHooks enregistre tous les hooks liés aux bons de commande.
 // À appeler dans main.go après hooks.RegisterAllHooks(pb).
 func RegisterOrderHooks(pb *pocketbase.PocketBase) {
 	pb.OnRecordBeforeCreateRequest("orders").Add(func(e *core.RecordCreateEvent) error {
+		if _, err := backend.RepriceDocumentItems(pb.Dao(), e.Record); err != nil {
+			log.Printf("⚠️ Listes de prix non appliquées au BC: %v", err)
+		}
 		return generateOrderNumber(pb, e)
 	})
+
+	// Seul un BC en brouillon est retarifé : confirmé, le contrat est formé
+	pb.OnRecordBeforeUpdateRequest("orders").Add(func(e *core.RecordUpdateEvent) error {
+		if e.Record.GetString("status") != "draft" {
+			return nil
+		}
+		if _, err := backend.RepriceDocumentItems(pb.Dao(), e.Record); err != nil {
+			log.Printf("⚠️ Listes de prix non appliquées au BC %s: %v", e.Record.Id, err)
+		}
+		return nil
+	})
+
+	// L'auteur d'une requête REST suit le BC jusqu'au hook de modèle
+	pb.OnRecordBeforeCreateRequest("orders").Add(func(e *core.RecordCreateEvent) error {
+		e.Record.Set(orderActorKey, requestUserID(e.HttpContext))
+		return nil
+	})
+	pb.OnRecordBeforeUpdateRequest("orders").Add(func(e *core.RecordUpdateEvent) error {
+		e.Record.Set(orderActorKey, requestUserID(e.HttpContext))
+		return nil
+	})
+
+	// Réservations : avant l'écriture, avec le Dao de l'écriture — dans la
+	// transaction de l'appelant s'il en a une. Une réservation qui ne
+	// s'enregistre pas refuse le BC au lieu de le laisser sans elle.
+	pb.OnModelBeforeCreate("orders").Add(func(e *core.ModelEvent) error {
+		return syncOrderReservations(e.Dao, e.Model)
+	})
+	pb.OnModelBeforeUpdate("orders").Add(func(e *core.ModelEvent) error {
+		return syncOrderReservations(e.Dao, e.Model)
+	})
+	pb.OnModelBeforeDelete("orders").Add(func(e *core.ModelEvent) error {
+		return backend.ReleaseOrderReservations(e.Dao, e.Model.GetId(), "cancelled")
+	})
+}
+
+// orderActorKey : utilisateur de la requête, donnée hors schéma du BC
+const orderActorKey = "@reservation_user"
+
+func requestUserID(c echo.Context) string {
+	if c == nil {
+		return ""
+	}
+	if user, ok := c.Get(apis.ContextAuthRecordKey).(*models.Record); ok && user != nil {
+		return user.Id
+	}
+	return ""
+}
+
+func syncOrderReservations(dao *daos.Dao, model models.Model) error {
+	order, ok := model.(*models.Record)
+	if !ok {
+		return nil
+	}
+	if err := backend.SyncOrderReservations(dao, order, order.GetString(orderActorKey)); err != nil {
+		log.Printf("❌ Réservations du BC %s non mises à jour, BC refusé: %v", order.GetString("number"), err)
+		return apis.NewBadRequestError("Réservations de stock non enregistrées : "+err.Error(), nil)
+	}
+	return nil
 }
 
 // generateOrderNumber génère le prochain numéro BC-YYYY-XXXX pour le bon de commande.
@@ -0,0 +1,168 @@
+// backend/hooks/product_event_hooks.go
+// Journal produit (product_events) écrit par le serveur pour chaque fiche
+// créée ou modifiée, quel que soit le chemin : API REST (écran produit,
+// caisse, tableau de bord PocketBase), import du catalogue, Dao().SaveRecord
+// d'une route ou d'un hook. Ce sont des hooks de modèle : ils voient toutes
+// les écritures, après validation de la transaction.
+//   - une modification est comparée à l'état chargé (backend.DiffProduct) et
+//     donne un événement typé par changement : prix, nom, code-barres, stock…
+//   - l'origine (source, opérateur, route) est attachée à la fiche avant
+//     l'écriture (backend.SetProductEventOrigin) ; une requête REST la reçoit
+//     ici, de son contexte. Sans origine : `system`
+//   - la source d'une requête vient de sa route, puis de l'appelant :
+//     `manual` pour un utilisateur de l'application, `admin` pour le tableau
+//     de bord
+// Les routes serveur qui journalisent déjà (vente, réception, inventaire,
+// transfert, RMA) marquent leurs fiches (backend.MarkProductJournaled) et ne
+// sont pas doublées.
+// Le journal est best-effort : un événement raté est loggé, la fiche reste
+// enregistrée.
+
+package hooks
+
+import (
+	"log"
+	"strings"
+
+	"pocket-react/backend"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// RegisterProductEventHooks enregistre les hooks du journal produit.
+// À appeler dans main.go après hooks.RegisterAllHooks(pb), et par tout
+// outil qui écrit des fiches (cmd/catalog-import).
+func RegisterProductEventHooks(pb *pocketbase.PocketBase) {
+	// Une requête REST porte son origine jusqu'au hook de modèle
+	pb.OnRecordBeforeCreateRequest("products").Add(func(e *core.RecordCreateEvent) error {
+		backend.SetProductEventOrigin(e.Record, productEventOrigin(e.HttpContext))
+		return nil
+	})
+	pb.OnRecordBeforeUpdateRequest("products").Add(func(e *core.RecordUpdateEvent) error {
+		backend.SetProductEventOrigin(e.Record, productEventOrigin(e.HttpContext))
+		return nil
+	})
+
+	pb.OnModelAfterCreate("products").Add(func(e *core.ModelEvent) error {
+		record, ok := e.Model.(*models.Record)
+		if !ok {
+			return nil
+		}
+		origin := takeProductEventOrigin(record)
+		if origin.Journaled {
+			return nil
+		}
+		writeProductEvents(e.Dao, origin, record, []backend.ProductChange{{
+			EventType: "product_created",
+			After: map[string]any{
+				"name":      record.GetString("name"),
+				"sku":       record.GetString("sku"),
+				"barcode":   record.GetString("barcode"),
+				"price_ttc": record.GetFloat("price_ttc"),
+				"stock":     record.GetFloat("stock"),
+			},
+		}})
+		return nil
+	})
+
+	pb.OnModelAfterUpdate("products").Add(func(e *core.ModelEvent) error {
+		record, ok := e.Model.(*models.Record)
+		if !ok {
+			return nil
+		}
+		origin := takeProductEventOrigin(record)
+		if origin.Journaled {
+			return nil
+		}
+		if changes := backend.DiffProduct(record.OriginalCopy(), record); len(changes) > 0 {
+			writeProductEvents(e.Dao, origin, record, changes)
+		}
+		return nil
+	})
+}
+
+// takeProductEventOrigin : l'origine attachée à la fiche, `system` à défaut
+func takeProductEventOrigin(record *models.Record) backend.ProductEventOrigin {
+	origin, ok := backend.TakeProductEventOrigin(record)
+	if !ok || origin.Source == "" {
+		origin.Source = "system"
+	}
+	return origin
+}
+
+func writeProductEvents(dao *daos.Dao, origin backend.ProductEventOrigin, product *models.Record, changes []backend.ProductChange) {
+	eventsCol, err := dao.FindCollectionByNameOrId("product_events")
+	if err != nil {
+		return // pas encore migré
+	}
+
+	now := types.NowDateTime()
+	metadata := map[string]any{}
+	if origin.Route != "" {
+		metadata["route"] = origin.Route
+	}
+
+	for _, change := range changes {
+		event := models.NewRecord(eventsCol)
+		event.Set("product_id", product.Id)
+		event.Set("product_name_snapshot", product.GetString("name"))
+		event.Set("product_sku_snapshot", product.GetString("sku"))
+		event.Set("event_type", change.EventType)
+		event.Set("source", origin.Source)
+		event.Set("operator", origin.Operator)
+		event.Set("occurred_at", now)
+		event.Set("before", change.Before)
+		event.Set("after", change.After)
+		event.Set("delta", change.Delta)
+		event.Set("metadata", metadata)
+		if err := dao.SaveRecord(event); err != nil {
+			log.Printf("⚠️ Journal produit %q (%s) non écrit : %v",
+				product.GetString("name"), change.EventType, err)
+		}
+	}
+}
+
+// productRouteSources : la source d'une écriture faite sous une route
+// serveur, si la route ne journalise pas elle-même
+var productRouteSources = []struct{ prefix, source string }{
+	{"/api/pos/", "sale"},
+	{"/api/purchase-orders/", "purchase_receipt"},
+	{"/api/inventory/", "inventory_session"},
+	{"/api/stock/transfers", "stock_transfer"},
+	{"/api/rma", "rma"},
+}
+
+// productEventOrigin rend l'origine d'une requête : la source d'après la
+// route, puis d'après l'appelant ; l'opérateur d'après le jeton
+func productEventOrigin(c echo.Context) backend.ProductEventOrigin {
+	if c == nil {
+		return backend.ProductEventOrigin{Source: "system"}
+	}
+	origin := backend.ProductEventOrigin{
+		Source: "manual",
+		Route:  c.Request().Method + " " + c.Request().URL.Path,
+	}
+	if admin, ok := c.Get(apis.ContextAdminKey).(*models.Admin); ok && admin != nil {
+		origin.Source, origin.Operator = "admin", admin.Email
+	} else if user, ok := c.Get(apis.ContextAuthRecordKey).(*models.Record); ok && user != nil {
+		for _, field := range []string{"name", "username", "email"} {
+			if v := strings.TrimSpace(user.GetString(field)); v != "" {
+				origin.Operator = v
+				break
+			}
+		}
+	}
+	for _, r := range productRouteSources {
+		if strings.HasPrefix(c.Request().URL.Path, r.prefix) {
+			origin.Source = r.source
+			break
+		}
+	}
+	return origin
+}
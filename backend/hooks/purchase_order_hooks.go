@@ -0,0 +1,61 @@
+// backend/hooks/purchase_order_hooks.go
+// Hook PocketBase pour les commandes fournisseur (purchase_orders).
+// Génère automatiquement le numéro CF-YYYY-XXXX avant chaque création.
+// Les changements de statut passent par /api/purchase-orders/:id/… (routes).
+
+package hooks
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterPurchaseOrderHooks enregistre les hooks des commandes fournisseur.
+// À appeler dans main.go après hooks.RegisterAllHooks(pb).
+func RegisterPurchaseOrderHooks(pb *pocketbase.PocketBase) {
+	pb.OnRecordBeforeCreateRequest("purchase_orders").Add(func(e *core.RecordCreateEvent) error {
+		return generatePurchaseOrderNumber(pb, e)
+	})
+}
+
+// generatePurchaseOrderNumber génère le prochain numéro CF-YYYY-XXXX.
+// Même logique que la numérotation des bons de commande client (BC-).
+func generatePurchaseOrderNumber(pb *pocketbase.PocketBase, e *core.RecordCreateEvent) error {
+	if e.Record.GetString("number") != "" {
+		return nil
+	}
+
+	fiscalYear := time.Now().Year()
+	ownerCompany := e.Record.GetString("owner_company")
+	prefix := fmt.Sprintf("CF-%d-", fiscalYear)
+
+	records, err := pb.Dao().FindRecordsByFilter(
+		"purchase_orders",
+		"owner_company = {:company} && fiscal_year = {:year}",
+		"-number",
+		1,
+		0,
+		dbx.Params{"company": ownerCompany, "year": fiscalYear},
+	)
+
+	nextSeq := 1
+	if err == nil && len(records) > 0 {
+		// "CF-2026-0042" → 42
+		var seq int
+		fmt.Sscanf(strings.TrimPrefix(records[0].GetString("number"), prefix), "%d", &seq)
+		nextSeq = seq + 1
+	}
+
+	number := fmt.Sprintf("%s%04d", prefix, nextSeq)
+	e.Record.Set("number", number)
+	e.Record.Set("fiscal_year", fiscalYear)
+
+	log.Printf("✅ Numéro de commande fournisseur généré : %s (company: %s)", number, ownerCompany)
+	return nil
+}
@@ -0,0 +1,66 @@
+// backend/hooks/serial_hooks.go
+// Hooks PocketBase des numéros de série (product_serials), pour les unités
+// saisies à la main — celles déjà en boutique avant le suivi :
+//   - le numéro est normalisé (backend.NormalizeSerial) et unique par produit
+//   - le produit doit être suivi par numéro de série et de la même société
+//   - le nom du produit est repris sur l'unité
+// La réception et la vente écrivent leurs unités elles-mêmes, dans
+// backend/routes/serial_routes.go ; ces hooks ne les voient pas.
+
+package hooks
+
+import (
+	"fmt"
+
+	"pocket-react/backend"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// RegisterSerialHooks enregistre les hooks des numéros de série.
+// À appeler dans main.go après hooks.RegisterAllHooks(pb).
+func RegisterSerialHooks(pb *pocketbase.PocketBase) {
+	pb.OnRecordBeforeCreateRequest("product_serials").Add(func(e *core.RecordCreateEvent) error {
+		product, err := pb.Dao().FindRecordById("products", e.Record.GetString("product"))
+		if err != nil {
+			return apis.NewBadRequestError("Produit introuvable", nil)
+		}
+		if !product.GetBool("serialized") {
+			return apis.NewBadRequestError(
+				fmt.Sprintf("« %s » n'est pas suivi par numéro de série", product.GetString("name")), nil)
+		}
+		if product.GetString("company") != e.Record.GetString("owner_company") {
+			return apis.NewBadRequestError("Le produit appartient à une autre société", nil)
+		}
+		e.Record.Set("product_name", product.GetString("name"))
+		return checkManualSerial(pb, e.Record)
+	})
+
+	pb.OnRecordBeforeUpdateRequest("product_serials").Add(func(e *core.RecordUpdateEvent) error {
+		return checkManualSerial(pb, e.Record)
+	})
+}
+
+// checkManualSerial normalise le numéro saisi et refuse un doublon avec un
+// message lisible, avant que l'index unique ne le fasse
+func checkManualSerial(pb *pocketbase.PocketBase, unit *models.Record) error {
+	serial := backend.NormalizeSerial(unit.GetString("serial_number"))
+	if serial == "" {
+		return apis.NewBadRequestError("Numéro de série requis", nil)
+	}
+	unit.Set("serial_number", serial)
+
+	existing, err := pb.Dao().FindFirstRecordByFilter(
+		"product_serials", "product = {:product} && serial_number = {:serial} && id != {:id}",
+		dbx.Params{"product": unit.GetString("product"), "serial": serial, "id": unit.Id},
+	)
+	if err == nil && existing != nil {
+		return apis.NewBadRequestError(
+			fmt.Sprintf("Le numéro %s est déjà enregistré pour ce produit", serial), nil)
+	}
+	return nil
+}
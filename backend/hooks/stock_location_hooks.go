@@ -0,0 +1,146 @@
+// backend/hooks/stock_location_hooks.go
+// Hooks PocketBase des emplacements de stock (stock_locations).
+//   - le premier emplacement d'une société devient son emplacement par défaut ;
+//     les suivants ne le deviennent que par /api/stock/locations/:id/default
+//   - un emplacement par défaut ne s'archive pas ; ni lui ni un emplacement
+//     qui porte encore du stock ne se supprime
+//   - une modification de products.stock par l'API REST (fiche produit) est
+//     reportée sur l'emplacement par défaut, pour que le total reste la somme
+//     des emplacements ; le report suit l'enregistrement de la fiche, et s'il
+//     échoue la fiche reprend son ancien stock
+// Les mouvements eux-mêmes sont dans backend/routes/location_routes.go. Les
+// refus sont des erreurs d'API : leur message s'affiche tel quel dans l'écran
+// des emplacements.
+
+package hooks
+
+import (
+	"fmt"
+	"log"
+
+	"pocket-react/backend"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// RegisterStockLocationHooks enregistre les hooks des emplacements de stock.
+// À appeler dans main.go après hooks.RegisterAllHooks(pb).
+func RegisterStockLocationHooks(pb *pocketbase.PocketBase) {
+	pb.OnRecordBeforeCreateRequest("stock_locations").Add(func(e *core.RecordCreateEvent) error {
+		others, err := pb.Dao().FindRecordsByFilter(
+			"stock_locations", "owner_company = {:company}", "", 1, 0,
+			dbx.Params{"company": e.Record.GetString("owner_company")},
+		)
+		if err != nil {
+			return err
+		}
+		e.Record.Set("is_default", len(others) == 0)
+		e.Record.Set("archived", false)
+		return nil
+	})
+
+	pb.OnRecordBeforeUpdateRequest("stock_locations").Add(func(e *core.RecordUpdateEvent) error {
+		// Les ventes sans caisse attitrée y tombent : il reste ouvert
+		if e.Record.GetBool("is_default") && e.Record.GetBool("archived") {
+			return apis.NewBadRequestError("Archivage interdit : choisissez d'abord un autre emplacement par défaut", nil)
+		}
+		return nil
+	})
+
+	pb.OnRecordBeforeDeleteRequest("stock_locations").Add(func(e *core.RecordDeleteEvent) error {
+		if e.Record.GetBool("is_default") {
+			return apis.NewBadRequestError("Suppression interdite : choisissez d'abord un autre emplacement par défaut", nil)
+		}
+		stocked, err := pb.Dao().FindRecordsByFilter(
+			"stock_levels", "location = {:location} && quantity != 0", "", 1, 0,
+			dbx.Params{"location": e.Record.Id},
+		)
+		if err != nil {
+			return err
+		}
+		if len(stocked) > 0 {
+			return apis.NewBadRequestError("Suppression interdite : l'emplacement porte encore du stock, transférez-le ou archivez l'emplacement", nil)
+		}
+		return nil
+	})
+
+	// Après l'enregistrement : un report écrit avant une fiche refusée
+	// laisserait les emplacements au-dessus du total
+	pb.OnRecordAfterUpdateRequest("products").Add(func(e *core.RecordUpdateEvent) error {
+		err := reportStockEditToDefaultLocation(pb, e.Record)
+		if err == nil {
+			return nil
+		}
+		log.Printf("❌ Stock de %q non reporté sur l'emplacement par défaut : %v", e.Record.GetString("name"), err)
+		// Rechargée : le journal ne voit que le retour du stock
+		before := e.Record.OriginalCopy().GetFloat("stock")
+		product, restoreErr := pb.Dao().FindRecordById("products", e.Record.Id)
+		if restoreErr == nil {
+			product.Set("stock", before)
+			backend.SetProductEventOrigin(product, productEventOrigin(e.HttpContext))
+			restoreErr = pb.Dao().SaveRecord(product)
+		}
+		if restoreErr != nil {
+			log.Printf("⚠️ Stock de %q non rétabli à %g : %v", e.Record.GetString("name"), before, restoreErr)
+		}
+		return apis.NewBadRequestError("Stock non enregistré : "+err.Error(), nil)
+	})
+}
+
+// reportStockEditToDefaultLocation : l'écart saisi sur la fiche va à
+// l'emplacement par défaut. Un produit qui n'a encore aucune quantité par
+// emplacement n'a rien à reporter : tout son stock y est déjà compté.
+func reportStockEditToDefaultLocation(pb *pocketbase.PocketBase, product *models.Record) error {
+	before := product.OriginalCopy().GetFloat("stock")
+	after := product.GetFloat("stock")
+	if before == after {
+		return nil
+	}
+
+	return pb.Dao().RunInTransaction(func(tx *daos.Dao) error {
+		levelsCol, err := tx.FindCollectionByNameOrId("stock_levels")
+		if err != nil {
+			return nil // pas encore migré : rien à tenir
+		}
+		levels, err := tx.FindRecordsByFilter(
+			"stock_levels", "product = {:product}", "", 0, 0, dbx.Params{"product": product.Id})
+		if err != nil || len(levels) == 0 {
+			return err
+		}
+		defaults, err := tx.FindRecordsByFilter(
+			"stock_locations", "owner_company = {:company} && is_default = true", "", 1, 0,
+			dbx.Params{"company": product.GetString("company")},
+		)
+		if err != nil {
+			return err
+		}
+		if len(defaults) == 0 {
+			return fmt.Errorf("aucun emplacement par défaut pour reporter le stock saisi")
+		}
+
+		var level *models.Record
+		for _, l := range levels {
+			if l.GetString("location") == defaults[0].Id {
+				level = l
+			}
+		}
+		if level == nil {
+			level = models.NewRecord(levelsCol)
+			level.Set("product", product.Id)
+			level.Set("location", defaults[0].Id)
+		}
+		level.Set("quantity", level.GetFloat("quantity")+after-before)
+		if err := tx.SaveRecord(level); err != nil {
+			return err
+		}
+
+		log.Printf("📍 Stock de %q saisi sur la fiche : %+g à %s",
+			product.GetString("name"), after-before, defaults[0].GetString("name"))
+		return nil
+	})
+}
@@ -0,0 +1,589 @@
+// backend/loyalty.go
+// Programme de fidélité client.
+//
+// Principe :
+//   - un ticket POS / une facture validé(e) fait gagner des points (earn)
+//   - un avoir annule les points gagnés au prorata (reverse)
+//   - les points s'utilisent en caisse, en remise ou en bon d'achat (redeem)
+//   - chaque mouvement est écrit dans loyalty_ledger ; customers.loyalty_points
+//     n'est qu'un cache, reconstructible à tout moment depuis le journal, et le
+//     journal lui-même est reconstructible depuis les documents (RebuildCustomerLoyalty).
+//
+// Toutes les écritures sont idempotentes par (document, type) : rejouer un
+// document ne crée jamais deux fois le même mouvement.
+
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// ============================================================================
+// CONSTANTES
+// ============================================================================
+
+const (
+	LoyaltyEntryEarn    = "earn"
+	LoyaltyEntryReverse = "reverse"
+	LoyaltyEntryRedeem  = "redeem"
+	LoyaltyEntryAdjust  = "adjust"
+
+	// Moyen de paiement utilisé quand les points sont convertis en bon d'achat
+	LoyaltyPaymentMethodCode  = "fidelite"
+	LoyaltyPaymentMethodLabel = "Bon fidélité"
+
+	// Valeurs par défaut si la société n'a rien paramétré
+	defaultLoyaltyPointsPerEuro = 1.0
+	defaultLoyaltyPointValue    = 0.05
+)
+
+// ============================================================================
+// PARAMÈTRES
+// ============================================================================
+
+// LoyaltyTier représente un palier de fidélité (atteint selon le cumul de points)
+type LoyaltyTier struct {
+	Code      string `json:"code"`
+	Label     string `json:"label"`
+	MinPoints int    `json:"min_points"`
+}
+
+// LoyaltySettings regroupe le paramétrage fidélité d'une société
+type LoyaltySettings struct {
+	Enabled       bool          `json:"enabled"`
+	PointsPerEuro float64       `json:"points_per_euro"`
+	PointValue    float64       `json:"point_value"`
+	Tiers         []LoyaltyTier `json:"tiers"`
+}
+
+// DefaultLoyaltyTiers est utilisé si companies.loyalty_tiers est vide
+var DefaultLoyaltyTiers = []LoyaltyTier{
+	{Code: "bronze", Label: "Bronze", MinPoints: 0},
+	{Code: "silver", Label: "Argent", MinPoints: 500},
+	{Code: "gold", Label: "Or", MinPoints: 2000},
+}
+
+// GetLoyaltySettings lit le paramétrage fidélité de la société
+func GetLoyaltySettings(dao *daos.Dao, companyID string) (LoyaltySettings, error) {
+	company, err := dao.FindRecordById("companies", companyID)
+	if err != nil || company == nil {
+		return LoyaltySettings{}, fmt.Errorf("société introuvable: %v", err)
+	}
+
+	settings := LoyaltySettings{
+		Enabled:       company.GetBool("loyalty_enabled"),
+		PointsPerEuro: company.GetFloat("loyalty_points_per_euro"),
+		PointValue:    company.GetFloat("loyalty_point_value"),
+	}
+	if settings.PointsPerEuro <= 0 {
+		settings.PointsPerEuro = defaultLoyaltyPointsPerEuro
+	}
+	if settings.PointValue <= 0 {
+		settings.PointValue = defaultLoyaltyPointValue
+	}
+
+	if raw := company.GetString("loyalty_tiers"); raw != "" && raw != "null" {
+		var tiers []LoyaltyTier
+		if err := json.Unmarshal([]byte(raw), &tiers); err != nil {
+			log.Printf("⚠️ loyalty_tiers invalide pour la société %s: %v", companyID, err)
+		} else {
+			settings.Tiers = tiers
+		}
+	}
+	if len(settings.Tiers) == 0 {
+		settings.Tiers = DefaultLoyaltyTiers
+	}
+	sort.Slice(settings.Tiers, func(i, j int) bool {
+		return settings.Tiers[i].MinPoints < settings.Tiers[j].MinPoints
+	})
+
+	return settings, nil
+}
+
+// TierFor retourne le palier correspondant à un cumul de points
+func (s LoyaltySettings) TierFor(lifetimePoints int) LoyaltyTier {
+	var tier LoyaltyTier
+	for _, t := range s.Tiers {
+		if lifetimePoints >= t.MinPoints {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// PointsToAmount convertit des points en euros TTC
+func (s LoyaltySettings) PointsToAmount(points int) float64 {
+	return roundAmount(float64(points) * s.PointValue)
+}
+
+// EarnedPoints calcule les points gagnés pour un montant TTC (arrondi inférieur)
+func (s LoyaltySettings) EarnedPoints(amountTTC float64) int {
+	if amountTTC <= 0 {
+		return 0
+	}
+	// Le epsilon évite de perdre un point sur 19.99999999 → 19
+	return int(math.Floor(amountTTC*s.PointsPerEuro + 1e-6))
+}
+
+// ============================================================================
+// SOLDE CLIENT
+// ============================================================================
+
+// LoyaltyBalance représente l'état fidélité d'un client
+type LoyaltyBalance struct {
+	CustomerID     string       `json:"customer_id"`
+	Points         int          `json:"points"`
+	LifetimePoints int          `json:"lifetime_points"`
+	Tier           LoyaltyTier  `json:"tier"`
+	NextTier       *LoyaltyTier `json:"next_tier,omitempty"`
+	Value          float64      `json:"value"` // contre-valeur en euros des points disponibles
+}
+
+// GetLoyaltyBalance retourne le solde (cache customers) et le palier d'un client
+func GetLoyaltyBalance(dao *daos.Dao, customerID string) (*LoyaltyBalance, error) {
+	customer, err := dao.FindRecordById("customers", customerID)
+	if err != nil || customer == nil {
+		return nil, fmt.Errorf("client introuvable: %v", err)
+	}
+
+	settings, err := GetLoyaltySettings(dao, customer.GetString("owner_company"))
+	if err != nil {
+		return nil, err
+	}
+
+	return buildLoyaltyBalance(settings, customer), nil
+}
+
+func buildLoyaltyBalance(settings LoyaltySettings, customer *models.Record) *LoyaltyBalance {
+	points := customer.GetInt("loyalty_points")
+	lifetime := customer.GetInt("loyalty_lifetime_points")
+
+	balance := &LoyaltyBalance{
+		CustomerID:     customer.Id,
+		Points:         points,
+		LifetimePoints: lifetime,
+		Tier:           settings.TierFor(lifetime),
+		Value:          settings.PointsToAmount(points),
+	}
+	for _, t := range settings.Tiers {
+		if t.MinPoints > lifetime {
+			next := t
+			balance.NextTier = &next
+			break
+		}
+	}
+	return balance
+}
+
+// ============================================================================
+// ÉCRITURE DANS LE JOURNAL
+// ============================================================================
+
+// LoyaltyEntryParams regroupe les paramètres d'un mouvement de points
+type LoyaltyEntryParams struct {
+	CustomerID    string
+	EntryType     string // earn | reverse | redeem | adjust
+	Points        int    // signé
+	SourceInvoice *models.Record
+	Reason        string
+	CreatedBy     string
+	Meta          map[string]any
+}
+
+// ErrLoyaltyBalance : une utilisation de points dépasserait le solde du client
+var ErrLoyaltyBalance = errors.New("solde fidélité insuffisant")
+
+// AddLoyaltyEntry écrit un mouvement dans loyalty_ledger et met à jour le
+// cache du client (solde, cumul, palier) dans la même transaction. Une
+// utilisation qui rendrait le solde négatif est refusée (ErrLoyaltyBalance) :
+// le solde est relu dans la transaction, deux tickets simultanés ne
+// dépensent pas les mêmes points.
+func AddLoyaltyEntry(dao *daos.Dao, params LoyaltyEntryParams) (*models.Record, error) {
+	if params.Points == 0 {
+		return nil, nil
+	}
+
+	var entry *models.Record
+
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		customer, err := txDao.FindRecordById("customers", params.CustomerID)
+		if err != nil || customer == nil {
+			return fmt.Errorf("client introuvable: %v", err)
+		}
+
+		ownerCompany := customer.GetString("owner_company")
+		settings, err := GetLoyaltySettings(txDao, ownerCompany)
+		if err != nil {
+			return err
+		}
+
+		newBalance := customer.GetInt("loyalty_points") + params.Points
+		if params.EntryType == LoyaltyEntryRedeem && newBalance < 0 {
+			return fmt.Errorf("%w : %d points disponibles", ErrLoyaltyBalance, customer.GetInt("loyalty_points"))
+		}
+		lifetime := customer.GetInt("loyalty_lifetime_points")
+		// Le cumul (qui détermine le palier) ne compte que les gains nets :
+		// une utilisation de points ne fait pas redescendre de palier.
+		if params.EntryType == LoyaltyEntryEarn || params.EntryType == LoyaltyEntryReverse {
+			lifetime += params.Points
+			if lifetime < 0 {
+				lifetime = 0
+			}
+		}
+
+		col, err := txDao.FindCollectionByNameOrId("loyalty_ledger")
+		if err != nil {
+			return fmt.Errorf("collection loyalty_ledger introuvable: %v", err)
+		}
+
+		entry = models.NewRecord(col)
+		entry.Set("owner_company", ownerCompany)
+		entry.Set("customer", customer.Id)
+		entry.Set("entry_type", params.EntryType)
+		entry.Set("points", params.Points)
+		entry.Set("balance_after", newBalance)
+		entry.Set("reason", params.Reason)
+		if params.SourceInvoice != nil {
+			entry.Set("source_invoice", params.SourceInvoice.Id)
+			entry.Set("source_number", params.SourceInvoice.GetString("number"))
+		}
+		if params.CreatedBy != "" {
+			entry.Set("created_by", params.CreatedBy)
+		}
+		if params.Meta != nil {
+			entry.Set("meta", params.Meta)
+		}
+
+		if err := txDao.SaveRecord(entry); err != nil {
+			return fmt.Errorf("impossible d'écrire le mouvement de points: %v", err)
+		}
+
+		customer.Set("loyalty_points", newBalance)
+		customer.Set("loyalty_lifetime_points", lifetime)
+		customer.Set("loyalty_tier", settings.TierFor(lifetime).Code)
+
+		return txDao.SaveRecord(customer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎁 Fidélité %s: %+d pts (client %s, solde %d)",
+		params.EntryType, params.Points, params.CustomerID, entry.GetInt("balance_after"))
+	return entry, nil
+}
+
+// findLoyaltyEntries retourne les mouvements d'un type pour un document
+func findLoyaltyEntries(dao *daos.Dao, invoiceID, entryType string) []*models.Record {
+	records, err := dao.FindRecordsByFilter(
+		"loyalty_ledger",
+		"source_invoice = {:invoice} && entry_type = {:type}",
+		"created",
+		0,
+		0,
+		dbx.Params{"invoice": invoiceID, "type": entryType},
+	)
+	if err != nil {
+		return nil
+	}
+	return records
+}
+
+func sumLoyaltyPoints(records []*models.Record) int {
+	total := 0
+	for _, r := range records {
+		total += r.GetInt("points")
+	}
+	return total
+}
+
+// ============================================================================
+// APPLICATION AUX DOCUMENTS
+// ============================================================================
+
+// ApplyLoyaltyForDocument crée les mouvements de points associés à un document
+// (gain, annulation sur avoir, utilisation en caisse). Idempotent : sans effet
+// si les mouvements existent déjà, si le document est un brouillon ou si la
+// fidélité est désactivée pour la société.
+func ApplyLoyaltyForDocument(dao *daos.Dao, doc *models.Record) error {
+	customerID := doc.GetString("customer")
+	if customerID == "" || doc.GetString("status") == "draft" {
+		return nil
+	}
+
+	settings, err := GetLoyaltySettings(dao, doc.GetString("owner_company"))
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	switch doc.GetString("invoice_type") {
+	case "invoice":
+		if err := applyLoyaltyRedeem(dao, doc, settings); err != nil {
+			return err
+		}
+		return applyLoyaltyEarn(dao, doc, settings)
+	case "credit_note":
+		return applyLoyaltyReverse(dao, doc)
+	}
+
+	// Les acomptes ne rapportent pas de points : la facture parente s'en charge.
+	return nil
+}
+
+// RedeemLoyaltyForDocument écrit l'utilisation de points d'un ticket. La
+// caisse l'appelle dans la transaction du ticket : sans points, pas de
+// ticket. Idempotent, comme ApplyLoyaltyForDocument qui passe ensuite.
+func RedeemLoyaltyForDocument(dao *daos.Dao, doc *models.Record) error {
+	if doc.GetInt("loyalty_points_redeemed") <= 0 || doc.GetString("customer") == "" {
+		return nil
+	}
+	settings, err := GetLoyaltySettings(dao, doc.GetString("owner_company"))
+	if err != nil {
+		return err
+	}
+	return applyLoyaltyRedeem(dao, doc, settings)
+}
+
+// loyaltyEarnBase retourne le montant TTC qui ouvre droit à des points :
+// le total du document, hors part réglée en bon fidélité.
+func loyaltyEarnBase(doc *models.Record) float64 {
+	base := doc.GetFloat("total_ttc")
+
+	payments, _ := ParseItemsFromRecord(doc, "payments")
+	for _, p := range payments {
+		if code, _ := p["method_code"].(string); code == LoyaltyPaymentMethodCode {
+			amount, _ := p["amount"].(float64)
+			base -= amount
+		}
+	}
+
+	return roundAmount(math.Max(0, base))
+}
+
+func applyLoyaltyEarn(dao *daos.Dao, doc *models.Record, settings LoyaltySettings) error {
+	// Facture de solde ou facture issue d'un ticket : les points ont déjà été
+	// attribués sur la facture parente / le ticket d'origine.
+	if doc.GetString("original_invoice_id") != "" {
+		return nil
+	}
+	if len(findLoyaltyEntries(dao, doc.Id, LoyaltyEntryEarn)) > 0 {
+		return nil
+	}
+
+	base := loyaltyEarnBase(doc)
+	points := settings.EarnedPoints(base)
+	if points <= 0 {
+		return nil
+	}
+
+	_, err := AddLoyaltyEntry(dao, LoyaltyEntryParams{
+		CustomerID:    doc.GetString("customer"),
+		EntryType:     LoyaltyEntryEarn,
+		Points:        points,
+		SourceInvoice: doc,
+		Reason:        fmt.Sprintf("Achat %s", doc.GetString("number")),
+		CreatedBy:     doc.GetString("sold_by"),
+		Meta: map[string]any{
+			"base_ttc":        base,
+			"points_per_euro": settings.PointsPerEuro,
+		},
+	})
+	return err
+}
+
+func applyLoyaltyRedeem(dao *daos.Dao, doc *models.Record, settings LoyaltySettings) error {
+	points := doc.GetInt("loyalty_points_redeemed")
+	if points <= 0 {
+		return nil
+	}
+	if len(findLoyaltyEntries(dao, doc.Id, LoyaltyEntryRedeem)) > 0 {
+		return nil
+	}
+
+	_, err := AddLoyaltyEntry(dao, LoyaltyEntryParams{
+		CustomerID:    doc.GetString("customer"),
+		EntryType:     LoyaltyEntryRedeem,
+		Points:        -points,
+		SourceInvoice: doc,
+		Reason:        fmt.Sprintf("Utilisation sur %s", doc.GetString("number")),
+		CreatedBy:     doc.GetString("sold_by"),
+		Meta: map[string]any{
+			"amount_ttc":  doc.GetFloat("loyalty_discount_ttc"),
+			"point_value": settings.PointValue,
+		},
+	})
+	return err
+}
+
+// applyLoyaltyReverse annule, au prorata du montant remboursé, les points gagnés
+// sur le document d'origine. Les points déjà utilisés ne sont pas recrédités.
+func applyLoyaltyReverse(dao *daos.Dao, credit *models.Record) error {
+	originalID := credit.GetString("original_invoice_id")
+	if originalID == "" {
+		return nil
+	}
+	if len(findLoyaltyEntries(dao, credit.Id, LoyaltyEntryReverse)) > 0 {
+		return nil
+	}
+
+	earnEntries := findLoyaltyEntries(dao, originalID, LoyaltyEntryEarn)
+	if len(earnEntries) == 0 {
+		return nil
+	}
+	earned := sumLoyaltyPoints(earnEntries)
+
+	var base float64
+	var meta map[string]any
+	if b, err := json.Marshal(earnEntries[0].Get("meta")); err == nil && json.Unmarshal(b, &meta) == nil {
+		base, _ = meta["base_ttc"].(float64)
+	}
+	if base <= 0 {
+		return nil
+	}
+
+	// Déjà annulé par les avoirs précédents sur le même document
+	alreadyReversed := 0
+	previousCredits, _ := dao.FindRecordsByFilter(
+		"invoices",
+		"invoice_type = 'credit_note' && original_invoice_id = {:orig} && id != {:id}",
+		"",
+		0,
+		0,
+		dbx.Params{"orig": originalID, "id": credit.Id},
+	)
+	for _, pc := range previousCredits {
+		alreadyReversed += -sumLoyaltyPoints(findLoyaltyEntries(dao, pc.Id, LoyaltyEntryReverse))
+	}
+
+	refunded := math.Abs(credit.GetFloat("total_ttc"))
+	points := int(math.Round(float64(earned) * math.Min(1, refunded/base)))
+	if points > earned-alreadyReversed {
+		points = earned - alreadyReversed
+	}
+	if points <= 0 {
+		return nil
+	}
+
+	_, err := AddLoyaltyEntry(dao, LoyaltyEntryParams{
+		CustomerID:    credit.GetString("customer"),
+		EntryType:     LoyaltyEntryReverse,
+		Points:        -points,
+		SourceInvoice: credit,
+		Reason:        fmt.Sprintf("Avoir %s", credit.GetString("number")),
+		CreatedBy:     credit.GetString("sold_by"),
+		Meta: map[string]any{
+			"original_invoice_id": originalID,
+			"refunded_ttc":        roundAmount(refunded),
+			"earned_points":       earned,
+		},
+	})
+	return err
+}
+
+// ============================================================================
+// RECONSTRUCTION
+// ============================================================================
+
+// LoyaltyRebuildResult représente le résultat d'une reconstruction
+type LoyaltyRebuildResult struct {
+	Balance          *LoyaltyBalance `json:"balance"`
+	PreviousPoints   int             `json:"previous_points"`
+	DocumentsScanned int             `json:"documents_scanned"`
+	EntriesCreated   int             `json:"entries_created"`
+}
+
+// RebuildCustomerLoyalty rejoue tous les documents du client pour créer les
+// mouvements manquants, puis recalcule le solde et le cumul à partir du journal.
+// Le journal n'est jamais réécrit : la reconstruction ne fait qu'ajouter.
+func RebuildCustomerLoyalty(dao *daos.Dao, customerID string) (*LoyaltyRebuildResult, error) {
+	customer, err := dao.FindRecordById("customers", customerID)
+	if err != nil || customer == nil {
+		return nil, fmt.Errorf("client introuvable: %v", err)
+	}
+
+	result := &LoyaltyRebuildResult{PreviousPoints: customer.GetInt("loyalty_points")}
+
+	countEntries := func() int {
+		records, _ := dao.FindRecordsByFilter(
+			"loyalty_ledger", "customer = {:c}", "", 0, 0, dbx.Params{"c": customerID},
+		)
+		return len(records)
+	}
+	before := countEntries()
+
+	// Ordre chronologique : un avoir doit passer après son document d'origine
+	docs, err := dao.FindRecordsByFilter(
+		"invoices",
+		"customer = {:c} && status != 'draft'",
+		"created",
+		0,
+		0,
+		dbx.Params{"c": customerID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erreur chargement documents: %v", err)
+	}
+	for _, doc := range docs {
+		if err := ApplyLoyaltyForDocument(dao, doc); err != nil {
+			log.Printf("⚠️ Fidélité: document %s ignoré: %v", doc.GetString("number"), err)
+		}
+	}
+	result.DocumentsScanned = len(docs)
+	result.EntriesCreated = countEntries() - before
+
+	// Recalcul du cache depuis le journal
+	entries, err := dao.FindRecordsByFilter(
+		"loyalty_ledger", "customer = {:c}", "created", 0, 0, dbx.Params{"c": customerID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erreur chargement journal: %v", err)
+	}
+
+	points, lifetime := 0, 0
+	for _, e := range entries {
+		p := e.GetInt("points")
+		points += p
+		switch e.GetString("entry_type") {
+		case LoyaltyEntryEarn, LoyaltyEntryReverse:
+			lifetime += p
+		}
+	}
+	if lifetime < 0 {
+		lifetime = 0
+	}
+
+	settings, err := GetLoyaltySettings(dao, customer.GetString("owner_company"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Recharger : ApplyLoyaltyForDocument a pu modifier la fiche entre-temps
+	customer, err = dao.FindRecordById("customers", customerID)
+	if err != nil {
+		return nil, err
+	}
+	customer.Set("loyalty_points", points)
+	customer.Set("loyalty_lifetime_points", lifetime)
+	customer.Set("loyalty_tier", settings.TierFor(lifetime).Code)
+	if err := dao.SaveRecord(customer); err != nil {
+		return nil, fmt.Errorf("impossible de mettre à jour le client: %v", err)
+	}
+
+	result.Balance = buildLoyaltyBalance(settings, customer)
+
+	log.Printf("🎁 Fidélité reconstruite pour %s: %d → %d pts (%d mouvement(s) ajouté(s))",
+		customerID, result.PreviousPoints, points, result.EntriesCreated)
+	return result, nil
+}
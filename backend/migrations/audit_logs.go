@@ -280,3 +280,51 @@ func GetAuditAction(record *models.Record, baseAction string) string {
 
 	return "invoice_" + baseAction
 }
+
+// ═══════════════════════════════════════════════════════════════════════════
+// AJOUT DE VALEURS (actions / entités des nouveaux flux métier)
+// ═══════════════════════════════════════════════════════════════════════════
+
+// addAuditLogValues ajoute des valeurs aux selects action et entity_type de
+// audit_logs. Idempotent : les valeurs déjà présentes sont ignorées.
+func addAuditLogValues(app *pocketbase.PocketBase, actions []string, entityTypes []string) error {
+	collection, err := app.Dao().FindCollectionByNameOrId("audit_logs")
+	if err != nil {
+		log.Println("⚠️ Collection audit_logs introuvable, ajout de valeurs ignoré")
+		return nil
+	}
+
+	modified := false
+	appendValues := func(fieldName string, values []string) {
+		field := collection.Schema.GetFieldByName(fieldName)
+		if field == nil {
+			return
+		}
+		options, ok := field.Options.(*schema.SelectOptions)
+		if !ok {
+			return
+		}
+		for _, v := range values {
+			found := false
+			for _, existing := range options.Values {
+				if existing == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				options.Values = append(options.Values, v)
+				modified = true
+				log.Printf("   ✅ '%s' ajouté à %s", v, fieldName)
+			}
+		}
+	}
+
+	appendValues("action", actions)
+	appendValues("entity_type", entityTypes)
+
+	if !modified {
+		return nil
+	}
+	return app.Dao().SaveCollection(collection)
+}
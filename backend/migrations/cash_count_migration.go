@@ -0,0 +1,96 @@
+// backend/migrations/cash_count_migration.go
+// Migration du comptage de caisse par coupure et du circuit de justification
+// des écarts :
+//   - cash_sessions : comptages d'ouverture / fermeture, statut et motif d'écart
+//   - cash_registers : seuil d'écart au-delà duquel un responsable doit justifier
+//   - audit_logs : actions de session et de justification
+// ⚠️  Safe pour les clients en prod : uniquement des ajouts de champs nullable.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// AddCashCountFields ajoute les champs de comptage et d'écart de caisse.
+func AddCashCountFields(app *pocketbase.PocketBase) error {
+	log.Println("💶 Migration: AddCashCountFields...")
+
+	dao := app.Dao()
+
+	sessionsCol, err := dao.FindCollectionByNameOrId("cash_sessions")
+	if err != nil {
+		return err
+	}
+
+	sessionFields := []*schema.SchemaField{
+		// Détail par coupure : {"lines":[{"denomination":20,"quantity":3,"amount":60}],"total":60}
+		{Name: "opening_count", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 10240}},
+		{Name: "closing_count", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 10240}},
+		{
+			Name: "discrepancy_status",
+			Type: schema.FieldTypeSelect,
+			Options: &schema.SelectOptions{
+				MaxSelect: 1,
+				Values:    []string{"none", "within_tolerance", "pending_justification", "justified"},
+			},
+		},
+		{Name: "discrepancy_threshold", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+		{Name: "discrepancy_reason", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+		{
+			Name: "discrepancy_justified_by",
+			Type: schema.FieldTypeRelation,
+			Options: &schema.RelationOptions{
+				CollectionId:  "_pb_users_auth_",
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: false,
+			},
+		},
+		{Name: "discrepancy_justified_at", Type: schema.FieldTypeDate},
+	}
+
+	changed := false
+	for _, f := range sessionFields {
+		if sessionsCol.Schema.GetFieldByName(f.Name) != nil {
+			continue
+		}
+		sessionsCol.Schema.AddField(f)
+		changed = true
+		log.Printf("  ✅ Champ %s ajouté à cash_sessions", f.Name)
+	}
+	if changed {
+		if err := dao.SaveCollection(sessionsCol); err != nil {
+			return err
+		}
+	}
+
+	registersCol, err := dao.FindCollectionByNameOrId("cash_registers")
+	if err != nil {
+		return err
+	}
+	if registersCol.Schema.GetFieldByName("cash_discrepancy_threshold") == nil {
+		registersCol.Schema.AddField(&schema.SchemaField{
+			Name:    "cash_discrepancy_threshold",
+			Type:    schema.FieldTypeNumber,
+			Options: &schema.NumberOptions{Min: types.Pointer(0.0)},
+		})
+		if err := dao.SaveCollection(registersCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champ cash_discrepancy_threshold ajouté à cash_registers")
+	}
+
+	if err := addAuditLogValues(app,
+		[]string{"cash_session_opened", "cash_session_closed", "cash_discrepancy_justified"},
+		[]string{"cash_session"},
+	); err != nil {
+		return err
+	}
+
+	log.Println("✅ Champs de comptage de caisse OK")
+	return nil
+}
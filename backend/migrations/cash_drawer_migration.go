@@ -0,0 +1,124 @@
+// backend/migrations/cash_drawer_migration.go
+// Migration du journal d'ouverture du tiroir-caisse :
+//   - cash_drawer_events : une ligne par impulsion d'ouverture (qui, quand,
+//     pourquoi, sur quelle session)
+//   - cash_registers.no_sale_min_role : rôle minimum pour une ouverture
+//     « sans vente » (vide = manager)
+// ⚠️  Safe pour les clients en prod : une collection neuve et un champ nullable.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureCashDrawerEventsCollection crée cash_drawer_events et le réglage
+// no_sale_min_role des caisses
+func ensureCashDrawerEventsCollection(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	registersCol, err := dao.FindCollectionByNameOrId("cash_registers")
+	if err != nil {
+		return err
+	}
+
+	if registersCol.Schema.GetFieldByName("no_sale_min_role") == nil {
+		registersCol.Schema.AddField(&schema.SchemaField{
+			Name: "no_sale_min_role",
+			Type: schema.FieldTypeSelect,
+			Options: &schema.SelectOptions{
+				MaxSelect: 1,
+				Values:    []string{"caissier", "manager", "admin"},
+			},
+		})
+		if err := dao.SaveCollection(registersCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champ no_sale_min_role ajouté à cash_registers")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("cash_drawer_events"); err == nil {
+		log.Println("📦 Collection 'cash_drawer_events' existe déjà")
+		return nil
+	}
+
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	sessionsCol, err := dao.FindCollectionByNameOrId("cash_sessions")
+	if err != nil {
+		return err
+	}
+	invoicesCol, err := dao.FindCollectionByNameOrId("invoices")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'cash_drawer_events'...")
+
+	relation := func(name, collectionID string, required bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId:  collectionID,
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: false,
+			},
+		}
+	}
+
+	collection := &models.Collection{
+		Name:       "cash_drawer_events",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer("@request.auth.id != ''"),
+		ViewRule:   types.Pointer("@request.auth.id != ''"),
+		CreateRule: nil, // écrit uniquement par /api/pos/drawer/open
+		UpdateRule: nil, // journal : ni modifié ni supprimé
+		DeleteRule: nil,
+		Schema: schema.NewSchema(
+			// --- Contexte ---
+			relation("owner_company", companiesCol.Id, true),
+			relation("cash_register", registersCol.Id, false),
+			// vide si le tiroir est ouvert hors session (test matériel)
+			relation("session", sessionsCol.Id, false),
+			relation("created_by", "_pb_users_auth_", true),
+
+			// --- Motif ---
+			&schema.SchemaField{
+				Name:     "reason",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values: []string{
+						"sale", "refund", "cash_movement",
+						"session_open", "session_close", "no_sale", "test",
+					},
+				},
+			},
+			// obligatoire côté métier pour no_sale
+			&schema.SchemaField{Name: "note", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+			relation("related_invoice", invoicesCol.Id, false),
+			&schema.SchemaField{Name: "printer", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_cash_drawer_events_session ON cash_drawer_events (session, reason)",
+			"CREATE INDEX idx_cash_drawer_events_user ON cash_drawer_events (created_by, created)",
+		},
+	}
+
+	if err := dao.SaveCollection(collection); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'cash_drawer_events' créée")
+	return nil
+}
@@ -0,0 +1,134 @@
+// backend/migrations/cash_shifts.go
+// Migration des relèves de caissier (cash_shifts).
+// Une session de caisse appartient à la caisse ; un shift appartient au
+// caissier qui tient le tiroir entre deux comptages (ouverture, relève, fermeture).
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureCashShiftsCollection crée la collection cash_shifts si elle n'existe pas
+func ensureCashShiftsCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("cash_shifts"); err == nil {
+		log.Println("📦 Collection 'cash_shifts' existe déjà")
+		return nil
+	}
+
+	companiesCol, err := app.Dao().FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	sessionsCol, err := app.Dao().FindCollectionByNameOrId("cash_sessions")
+	if err != nil {
+		return err
+	}
+	registersCol, err := app.Dao().FindCollectionByNameOrId("cash_registers")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'cash_shifts'...")
+
+	userRelation := func(name string) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name: name,
+			Type: schema.FieldTypeRelation,
+			Options: &schema.RelationOptions{
+				CollectionId:  "_pb_users_auth_",
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: false,
+			},
+		}
+	}
+
+	collection := &models.Collection{
+		Name:       "cash_shifts",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer("@request.auth.id != ''"),
+		ViewRule:   types.Pointer("@request.auth.id != ''"),
+		CreateRule: nil, // écrit uniquement par les routes /api/cash
+		UpdateRule: nil,
+		DeleteRule: nil,
+		Schema: schema.NewSchema(
+			// --- Contexte ---
+			&schema.SchemaField{
+				Name:     "owner_company",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  companiesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:     "session",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  sessionsCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name: "cash_register",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  registersCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			userRelation("cashier"),
+			userRelation("handed_over_to"),
+
+			// --- Statut & dates ---
+			&schema.SchemaField{
+				Name:     "status",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"open", "closed"},
+				},
+			},
+			&schema.SchemaField{Name: "started_at", Type: schema.FieldTypeDate, Required: true},
+			&schema.SchemaField{Name: "ended_at", Type: schema.FieldTypeDate},
+
+			// --- Espèces ---
+			// start_cash = espèces reprises (fond de caisse ou comptage de relève)
+			&schema.SchemaField{Name: "start_cash", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "start_count", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 10240}},
+			// counted = false : session fermée sans comptage, écart inconnu
+			&schema.SchemaField{Name: "counted", Type: schema.FieldTypeBool},
+			&schema.SchemaField{Name: "expected_cash", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "counted_cash", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "cash_difference", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "end_count", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 10240}},
+			&schema.SchemaField{Name: "note", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_cash_shifts_session ON cash_shifts (session, started_at)",
+			"CREATE INDEX idx_cash_shifts_cashier ON cash_shifts (cashier, ended_at)",
+		},
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+
+	if err := addAuditLogValues(app, []string{"cash_session_handover"}, nil); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'cash_shifts' créée")
+	return nil
+}
@@ -0,0 +1,130 @@
+// backend/migrations/inventory_engine_migration.go
+// Migration du moteur d'inventaire côté serveur (routes /api/inventory/:id/…) :
+//   - inventory_sessions.owner_company : société dont le catalogue est gelé
+//   - inventory_sessions.count_policy  : scans de plusieurs postes additionnés
+//     (sum) ou dernier comptage retenu (overwrite) — vide = sum
+//   - inventory_entries.scan_count / counted_by : combien de saisies, et de
+//     quel poste vient la dernière
+//   - inventory_entries.stock_theorique : plus de minimum à 0. Un stock négatif
+//     au gel est une information, et il faisait échouer la création de l'entrée.
+//   - inventory_entries.sales_delta : ventes et retours entre le gel et le
+//     comptage (AddInventorySalesDeltaField)
+// ⚠️  Safe pour les clients en prod : champs nullables, aucune donnée réécrite.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// AddInventoryEngineFields prépare les deux collections d'inventaire au
+// moteur serveur
+func AddInventoryEngineFields(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	sessionsCol, err := dao.FindCollectionByNameOrId("inventory_sessions")
+	if err != nil {
+		return err
+	}
+	entriesCol, err := dao.FindCollectionByNameOrId("inventory_entries")
+	if err != nil {
+		return err
+	}
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+
+	sessionsChanged := false
+	if sessionsCol.Schema.GetFieldByName("owner_company") == nil {
+		sessionsCol.Schema.AddField(&schema.SchemaField{
+			Name: "owner_company",
+			Type: schema.FieldTypeRelation,
+			Options: &schema.RelationOptions{
+				CollectionId:  companiesCol.Id,
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: false,
+			},
+		})
+		sessionsChanged = true
+	}
+	if sessionsCol.Schema.GetFieldByName("count_policy") == nil {
+		sessionsCol.Schema.AddField(&schema.SchemaField{
+			Name: "count_policy",
+			Type: schema.FieldTypeSelect,
+			Options: &schema.SelectOptions{
+				MaxSelect: 1,
+				Values:    []string{"sum", "overwrite"},
+			},
+		})
+		sessionsChanged = true
+	}
+	if sessionsChanged {
+		if err := dao.SaveCollection(sessionsCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champs owner_company et count_policy ajoutés à inventory_sessions")
+	}
+
+	entriesChanged := false
+	if entriesCol.Schema.GetFieldByName("scan_count") == nil {
+		entriesCol.Schema.AddField(&schema.SchemaField{
+			Name:    "scan_count",
+			Type:    schema.FieldTypeNumber,
+			Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true},
+		})
+		entriesChanged = true
+	}
+	if entriesCol.Schema.GetFieldByName("counted_by") == nil {
+		entriesCol.Schema.AddField(&schema.SchemaField{
+			Name:    "counted_by",
+			Type:    schema.FieldTypeText,
+			Options: &schema.TextOptions{Max: types.Pointer(255)},
+		})
+		entriesChanged = true
+	}
+	if f := entriesCol.Schema.GetFieldByName("stock_theorique"); f != nil {
+		if opts, ok := f.Options.(*schema.NumberOptions); ok && opts.Min != nil {
+			opts.Min = nil
+			entriesChanged = true
+		}
+	}
+	if entriesChanged {
+		if err := dao.SaveCollection(entriesCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champs scan_count et counted_by ajoutés à inventory_entries")
+	}
+
+	return nil
+}
+
+// AddInventorySalesDeltaField ajoute inventory_entries.sales_delta : le
+// mouvement net des ventes et retours entre le gel et le comptage de
+// l'entrée (négatif quand il s'est vendu). Stock attendu au comptage =
+// stock_theorique + sales_delta.
+func AddInventorySalesDeltaField(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	entriesCol, err := dao.FindCollectionByNameOrId("inventory_entries")
+	if err != nil {
+		return err
+	}
+	if entriesCol.Schema.GetFieldByName("sales_delta") != nil {
+		return nil
+	}
+
+	entriesCol.Schema.AddField(&schema.SchemaField{
+		Name: "sales_delta",
+		Type: schema.FieldTypeNumber,
+	})
+	if err := dao.SaveCollection(entriesCol); err != nil {
+		return err
+	}
+	log.Println("  ✅ Champ sales_delta ajouté à inventory_entries")
+	return nil
+}
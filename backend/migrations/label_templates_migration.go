@@ -0,0 +1,105 @@
+// backend/migrations/label_templates_migration.go
+// Migration des modèles d'étiquettes :
+//   - label_templates : format d'une étiquette produit (ZPL, EPL, ESC/POS ou
+//     planche A4), ses dimensions et les champs imprimés. Sur une planche A4,
+//     columns × rows étiquettes, avec marges et espacements.
+// Le rendu est dans backend/pos/labels.go, la sélection des produits dans
+// backend/routes/label_routes.go.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureLabelTemplatesCollection crée la collection label_templates si elle n'existe pas
+func ensureLabelTemplatesCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("label_templates"); err == nil {
+		log.Println("📦 Collection 'label_templates' existe déjà")
+		return nil
+	}
+
+	companiesCol, err := app.Dao().FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'label_templates'...")
+
+	mm := func(name string) *schema.SchemaField {
+		return &schema.SchemaField{Name: name, Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}}
+	}
+
+	collection := &models.Collection{
+		Name:       "label_templates",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: types.Pointer(authRule),
+		UpdateRule: types.Pointer(authRule),
+		DeleteRule: types.Pointer(authRule),
+		Schema: schema.NewSchema(
+			&schema.SchemaField{
+				Name:     "owner_company",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  companiesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:        "name",
+				Type:        schema.FieldTypeText,
+				Required:    true,
+				Presentable: true,
+				Options:     &schema.TextOptions{Max: types.Pointer(255)},
+			},
+			&schema.SchemaField{
+				Name:     "format",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"zpl", "epl", "escpos", "sheet"},
+				},
+			},
+			&schema.SchemaField{Name: "is_default", Type: schema.FieldTypeBool},
+
+			// --- Étiquette (mm) ; dpi pour ZPL/EPL : 203 ou 300 ---
+			mm("width_mm"),
+			mm("height_mm"),
+			&schema.SchemaField{Name: "dpi", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true}},
+
+			// --- Planche A4 ---
+			&schema.SchemaField{Name: "columns", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true}},
+			&schema.SchemaField{Name: "rows", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true}},
+			mm("margin_top_mm"),
+			mm("margin_left_mm"),
+			mm("gap_x_mm"),
+			mm("gap_y_mm"),
+
+			// --- Champs imprimés (le prix l'est toujours) ---
+			&schema.SchemaField{
+				Name: "fields",
+				Type: schema.FieldTypeSelect,
+				Options: &schema.SelectOptions{
+					MaxSelect: 4,
+					Values:    []string{"name", "brand", "sku", "barcode"},
+				},
+			},
+		),
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+	log.Println("✅ Collection 'label_templates' créée")
+	return nil
+}
@@ -0,0 +1,211 @@
+// backend/migrations/loyalty_migration.go
+// Migration du programme de fidélité :
+//   - paramètres de fidélité sur companies (taux, valeur du point, paliers)
+//   - solde / cumul / palier sur customers
+//   - points utilisés / remise fidélité sur invoices (tickets POS)
+//   - collection loyalty_ledger (journal des mouvements de points)
+// ⚠️  Safe pour les clients en prod : uniquement des ajouts de champs nullable.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// AddLoyaltyFields ajoute les champs fidélité sur companies, customers et invoices.
+func AddLoyaltyFields(app *pocketbase.PocketBase) error {
+	log.Println("🎁 Migration: AddLoyaltyFields...")
+
+	dao := app.Dao()
+
+	targets := []struct {
+		collection string
+		fields     []*schema.SchemaField
+	}{
+		{
+			collection: "companies",
+			fields: []*schema.SchemaField{
+				{Name: "loyalty_enabled", Type: schema.FieldTypeBool},
+				// Points gagnés par euro TTC encaissé (ex: 1 → 1 point / €)
+				{Name: "loyalty_points_per_euro", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+				// Valeur d'un point à l'utilisation (ex: 0.05 → 100 points = 5 €)
+				{Name: "loyalty_point_value", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+				// Paliers : [{"code":"silver","label":"Argent","min_points":500}, ...]
+				{Name: "loyalty_tiers", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 10240}},
+			},
+		},
+		{
+			collection: "customers",
+			fields: []*schema.SchemaField{
+				{Name: "loyalty_points", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+				{Name: "loyalty_lifetime_points", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+				{Name: "loyalty_tier", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(50)}},
+			},
+		},
+		{
+			collection: "invoices",
+			fields: []*schema.SchemaField{
+				{Name: "loyalty_points_redeemed", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+				{Name: "loyalty_discount_ttc", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			},
+		},
+	}
+
+	for _, t := range targets {
+		col, err := dao.FindCollectionByNameOrId(t.collection)
+		if err != nil {
+			log.Printf("⚠️ Collection '%s' introuvable, skip", t.collection)
+			continue
+		}
+
+		changed := false
+		for _, f := range t.fields {
+			if col.Schema.GetFieldByName(f.Name) != nil {
+				continue
+			}
+			col.Schema.AddField(f)
+			changed = true
+			log.Printf("  ✅ Champ %s ajouté à %s", f.Name, t.collection)
+		}
+
+		if !changed {
+			log.Printf("  ℹ️  Champs fidélité déjà présents sur %s", t.collection)
+			continue
+		}
+		if err := dao.SaveCollection(col); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✅ Champs fidélité OK")
+	return nil
+}
+
+// ensureLoyaltyLedgerCollection crée la collection loyalty_ledger.
+// Le journal est en lecture seule côté REST : seules les routes /api/loyalty
+// et la logique serveur (tickets, factures, avoirs) y écrivent.
+func ensureLoyaltyLedgerCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("loyalty_ledger"); err == nil {
+		log.Println("📦 Collection 'loyalty_ledger' existe déjà")
+		return nil
+	}
+
+	companiesCol, err := app.Dao().FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	customersCol, err := app.Dao().FindCollectionByNameOrId("customers")
+	if err != nil {
+		return err
+	}
+	invoicesCol, err := app.Dao().FindCollectionByNameOrId("invoices")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'loyalty_ledger'...")
+
+	collection := &models.Collection{
+		Name:       "loyalty_ledger",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer("@request.auth.id != ''"),
+		ViewRule:   types.Pointer("@request.auth.id != ''"),
+		CreateRule: nil,
+		UpdateRule: nil,
+		DeleteRule: nil,
+		Schema: schema.NewSchema(
+			&schema.SchemaField{
+				Name:     "owner_company",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  companiesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:     "customer",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  customersCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			// earn = gain sur ticket/facture, reverse = annulation sur avoir,
+			// redeem = utilisation en caisse, adjust = correction manuelle
+			&schema.SchemaField{
+				Name:     "entry_type",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"earn", "reverse", "redeem", "adjust"},
+				},
+			},
+			// Signé : positif pour un gain, négatif pour une annulation/utilisation
+			&schema.SchemaField{
+				Name:    "points",
+				Type:    schema.FieldTypeNumber,
+				Options: &schema.NumberOptions{NoDecimal: true},
+			},
+			&schema.SchemaField{
+				Name:    "balance_after",
+				Type:    schema.FieldTypeNumber,
+				Options: &schema.NumberOptions{NoDecimal: true},
+			},
+			// Document à l'origine du mouvement (vide pour un ajustement)
+			&schema.SchemaField{
+				Name: "source_invoice",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  invoicesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:    "source_number",
+				Type:    schema.FieldTypeText,
+				Options: &schema.TextOptions{Max: types.Pointer(50)},
+			},
+			&schema.SchemaField{
+				Name:    "reason",
+				Type:    schema.FieldTypeText,
+				Options: &schema.TextOptions{Max: types.Pointer(255)},
+			},
+			&schema.SchemaField{
+				Name: "created_by",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  "_pb_users_auth_",
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:    "meta",
+				Type:    schema.FieldTypeJson,
+				Options: &schema.JsonOptions{MaxSize: 10240},
+			},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_loyalty_ledger_customer ON loyalty_ledger (customer, created)",
+			"CREATE INDEX idx_loyalty_ledger_source ON loyalty_ledger (source_invoice, entry_type)",
+		},
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'loyalty_ledger' créée")
+	return nil
+}
@@ -90,6 +90,72 @@ func RunMigrations(app *pocketbase.PocketBase) error {
 		// MaxSize, donc à 0, ce qui rendait TOUTE mise à jour d'un fournisseur
 		// impossible. DOIT rester après MigrateCatalogV2, qui crée la collection.
 		FixSupplierJsonMaxSize,
+
+		// 14. Programme de fidélité (dépend de companies + customers + invoices)
+		AddLoyaltyFields,
+		ensureLoyaltyLedgerCollection,
+
+		// 15. Comptage de caisse par coupure + justification des écarts
+		// (dépend de cash_sessions + cash_registers + audit_logs)
+		AddCashCountFields,
+
+		// 16. Relèves de caissier (dépend de cash_sessions + cash_registers)
+		ensureCashShiftsCollection,
+
+		// 17. Tickets rejoués depuis un poste hors-ligne (dépend de invoices)
+		AddOfflinePosFields,
+
+		// 18. Listes de prix (dépend de companies + customers + catalogue v2)
+		ensurePriceListsCollection,
+		ensurePriceListItemsCollection,
+
+		// 19. File d'impression (dépend de cash_registers + invoices)
+		ensurePrintJobsCollection,
+
+		// 20. Duplicatas de tickets (dépend de invoices + audit_logs)
+		AddReceiptReprintFields,
+
+		// 21. Modèles d'étiquettes produit (dépend de companies)
+		ensureLabelTemplatesCollection,
+
+		// 22. Journal d'ouverture du tiroir-caisse (dépend de cash_sessions +
+		// cash_registers + invoices)
+		ensureCashDrawerEventsCollection,
+
+		// 23. Moteur d'inventaire côté serveur (dépend de inventory_sessions +
+		// inventory_entries + companies)
+		AddInventoryEngineFields,
+
+		// 24. Ventes pendant l'inventaire (dépend de inventory_entries)
+		AddInventorySalesDeltaField,
+
+		// 25. Achats fournisseurs (dépend de companies + suppliers + products +
+		// product_events)
+		ensurePurchasingCollections,
+
+		// 26. Stock multi-emplacements (dépend de companies + products +
+		// cash_registers + inventory_sessions + goods_receipts + product_events)
+		ensureStockLocationCollections,
+
+		// 27. Numéros de série (dépend de companies + products + invoices +
+		// customers + goods_receipts)
+		ensureProductSerialsCollection,
+
+		// 28. Journal produit écrit par le serveur (dépend de product_events)
+		AddProductEventsAdminSource,
+
+		// 29. Réservations de stock (dépend de companies + products + orders +
+		// cash_registers)
+		ensureStockReservationsCollection,
+
+		// 30. Retours fournisseur et réparations (dépend de companies +
+		// suppliers + products + product_serials + customers + invoices +
+		// stock_locations + product_events)
+		ensureRmaCollection,
+
+		// 31. Journal produit par hooks de modèle : source `system` (dépend de
+		// product_events)
+		AddProductEventsSystemSource,
 	}
 
 	for _, migrate := range migrations {
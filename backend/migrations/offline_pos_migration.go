@@ -0,0 +1,71 @@
+// backend/migrations/offline_pos_migration.go
+// Migration du mode hors-ligne des postes navigateur :
+//   - offline_id : identifiant provisoire attribué par le poste pendant la coupure
+//   - offline_created_at : heure réelle de la vente sur le poste
+//   - offline_workstation : poste d'origine
+// L'index unique sur (owner_company, offline_id) rend le rejeu idempotent :
+// un ticket rejoué deux fois ne peut pas être numéroté ni chaîné deux fois.
+// ⚠️  Safe pour les clients en prod : uniquement des ajouts de champs nullable.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const offlineIdIndex = "CREATE UNIQUE INDEX idx_invoices_offline_id ON invoices (owner_company, offline_id) WHERE offline_id != ''"
+
+// AddOfflinePosFields ajoute les champs de rejeu hors-ligne sur invoices.
+func AddOfflinePosFields(app *pocketbase.PocketBase) error {
+	log.Println("📴 Migration: AddOfflinePosFields...")
+
+	dao := app.Dao()
+	col, err := dao.FindCollectionByNameOrId("invoices")
+	if err != nil {
+		log.Println("⚠️ Collection 'invoices' introuvable, skip")
+		return nil
+	}
+
+	fields := []*schema.SchemaField{
+		{Name: "offline_id", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+		{Name: "offline_created_at", Type: schema.FieldTypeDate},
+		{Name: "offline_workstation", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+	}
+
+	changed := false
+	for _, f := range fields {
+		if col.Schema.GetFieldByName(f.Name) != nil {
+			continue
+		}
+		col.Schema.AddField(f)
+		changed = true
+		log.Printf("  ✅ Champ %s ajouté à invoices", f.Name)
+	}
+
+	hasIndex := false
+	for _, idx := range col.Indexes {
+		if idx == offlineIdIndex {
+			hasIndex = true
+			break
+		}
+	}
+	if !hasIndex {
+		col.Indexes = append(col.Indexes, offlineIdIndex)
+		changed = true
+	}
+
+	if !changed {
+		log.Println("  ℹ️  Champs hors-ligne déjà présents sur invoices")
+		return nil
+	}
+	if err := dao.SaveCollection(col); err != nil {
+		return err
+	}
+
+	log.Println("✅ Champs hors-ligne OK")
+	return nil
+}
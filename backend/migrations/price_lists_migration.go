@@ -0,0 +1,193 @@
+// backend/migrations/price_lists_migration.go
+// Migration des listes de prix :
+//   - price_lists : une liste (tarif pro, tarif association, packs de cordes…),
+//     ses dates de validité et à qui elle s'applique (clients et/ou types de client,
+//     personne = tout le monde)
+//   - price_list_items : les règles de la liste, par produit OU par catégorie,
+//     prix fixe ou remise en %, à partir d'une quantité minimale (paliers)
+// La résolution du prix effectif est dans backend/pricing.go.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensurePriceListsCollection crée la collection price_lists si elle n'existe pas
+func ensurePriceListsCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("price_lists"); err == nil {
+		log.Println("📦 Collection 'price_lists' existe déjà")
+		return nil
+	}
+
+	companiesCol, err := app.Dao().FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	customersCol, err := app.Dao().FindCollectionByNameOrId("customers")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'price_lists'...")
+
+	collection := &models.Collection{
+		Name:       "price_lists",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: types.Pointer(authRule),
+		UpdateRule: types.Pointer(authRule),
+		DeleteRule: types.Pointer(authRule),
+		Schema: schema.NewSchema(
+			&schema.SchemaField{
+				Name:     "owner_company",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  companiesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{
+				Name:        "name",
+				Type:        schema.FieldTypeText,
+				Required:    true,
+				Presentable: true,
+				Options:     &schema.TextOptions{Max: types.Pointer(255)},
+			},
+			&schema.SchemaField{Name: "active", Type: schema.FieldTypeBool},
+
+			// --- Affectation (vide des deux côtés = tous les clients) ---
+			&schema.SchemaField{
+				Name: "customer_types",
+				Type: schema.FieldTypeSelect,
+				Options: &schema.SelectOptions{
+					MaxSelect: 4,
+					Values:    []string{"individual", "professional", "administration", "association"},
+				},
+			},
+			&schema.SchemaField{
+				Name: "customers",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  customersCol.Id,
+					MaxSelect:     nil,
+					CascadeDelete: false,
+				},
+			},
+
+			// --- Validité (bornes incluses, vides = sans limite) ---
+			&schema.SchemaField{Name: "valid_from", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "valid_until", Type: schema.FieldTypeDate},
+
+			&schema.SchemaField{Name: "notes", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_price_lists_company ON price_lists (owner_company, active)",
+		},
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'price_lists' créée")
+	return nil
+}
+
+// ensurePriceListItemsCollection crée la collection price_list_items
+func ensurePriceListItemsCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("price_list_items"); err == nil {
+		log.Println("📦 Collection 'price_list_items' existe déjà")
+		return nil
+	}
+
+	priceListsCol, err := app.Dao().FindCollectionByNameOrId("price_lists")
+	if err != nil {
+		return err
+	}
+	productsCol, err := app.Dao().FindCollectionByNameOrId("products")
+	if err != nil {
+		return err
+	}
+	categoriesCol, err := app.Dao().FindCollectionByNameOrId("categories")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'price_list_items'...")
+
+	collection := &models.Collection{
+		Name:       "price_list_items",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: types.Pointer(authRule),
+		UpdateRule: types.Pointer(authRule),
+		DeleteRule: types.Pointer(authRule),
+		Schema: schema.NewSchema(
+			&schema.SchemaField{
+				Name:     "price_list",
+				Type:     schema.FieldTypeRelation,
+				Required: true,
+				Options: &schema.RelationOptions{
+					CollectionId:  priceListsCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: true,
+				},
+			},
+			// Cible : un produit OU une catégorie (le produit l'emporte à prix égal)
+			&schema.SchemaField{
+				Name: "product",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  productsCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: true,
+				},
+			},
+			&schema.SchemaField{
+				Name: "category",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  categoriesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: true,
+				},
+			},
+			// fixed : price_ttc remplace le prix catalogue
+			// percent : discount_percent est retiré du prix catalogue
+			&schema.SchemaField{
+				Name:     "mode",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"fixed", "percent"},
+				},
+			},
+			&schema.SchemaField{Name: "price_ttc", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+			&schema.SchemaField{Name: "discount_percent", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0), Max: types.Pointer(100.0)}},
+			// Palier : la règle s'applique à partir de cette quantité (vide = 1)
+			&schema.SchemaField{Name: "min_quantity", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_price_list_items_list ON price_list_items (price_list)",
+			"CREATE INDEX idx_price_list_items_product ON price_list_items (product)",
+		},
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'price_list_items' créée")
+	return nil
+}
@@ -0,0 +1,100 @@
+// backend/migrations/print_jobs_migration.go
+// Migration de la file d'impression :
+//   - print_jobs : un ticket ESC/POS à imprimer, conservé tant qu'il n'est pas
+//     sorti (papier épuisé, imprimante éteinte, redémarrage du serveur…)
+//   - print_jobs.status : `failed`, un ticket abandonné après trop d'échecs,
+//     qui attend une relance manuelle
+// Le traitement et les relances sont dans backend/routes/print_queue.go.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensurePrintJobsCollection crée la collection print_jobs si elle n'existe pas
+func ensurePrintJobsCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.Dao().FindCollectionByNameOrId("print_jobs"); err == nil {
+		log.Println("📦 Collection 'print_jobs' existe déjà")
+		return addSelectValues(app, "print_jobs", map[string]string{"status": "failed"})
+	}
+
+	registersCol, err := app.Dao().FindCollectionByNameOrId("cash_registers")
+	if err != nil {
+		return err
+	}
+	invoicesCol, err := app.Dao().FindCollectionByNameOrId("invoices")
+	if err != nil {
+		return err
+	}
+
+	log.Println("📦 Création de la collection 'print_jobs'...")
+
+	collection := &models.Collection{
+		Name: "print_jobs",
+		Type: models.CollectionTypeBase,
+		// Lecture seule côté API : seul le serveur crée et fait avancer les
+		// travaux (relance et annulation passent par /api/pos/print-jobs)
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: nil,
+		UpdateRule: nil,
+		DeleteRule: nil,
+		Schema: schema.NewSchema(
+			// --- Cible (résolue à chaque tentative, comme /api/pos/print) ---
+			&schema.SchemaField{
+				Name: "cash_register",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  registersCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{Name: "printer_name", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+			&schema.SchemaField{
+				Name: "ticket",
+				Type: schema.FieldTypeRelation,
+				Options: &schema.RelationOptions{
+					CollectionId:  invoicesCol.Id,
+					MaxSelect:     types.Pointer(1),
+					CascadeDelete: false,
+				},
+			},
+			&schema.SchemaField{Name: "label", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+
+			// --- Contenu : octets ESC/POS en base64 ---
+			&schema.SchemaField{Name: "payload", Type: schema.FieldTypeText, Required: true},
+
+			// --- Suivi ---
+			&schema.SchemaField{
+				Name:     "status",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"pending", "printed", "failed", "cancelled"},
+				},
+			},
+			&schema.SchemaField{Name: "attempts", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+			&schema.SchemaField{Name: "last_error", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+			&schema.SchemaField{Name: "next_attempt_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "printed_at", Type: schema.FieldTypeDate},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_print_jobs_status ON print_jobs (status, next_attempt_at)",
+		},
+	}
+
+	if err := app.Dao().SaveCollection(collection); err != nil {
+		return err
+	}
+
+	log.Println("✅ Collection 'print_jobs' créée")
+	return nil
+}
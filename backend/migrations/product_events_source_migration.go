@@ -0,0 +1,29 @@
+// backend/migrations/product_events_source_migration.go
+// Migration du journal produit écrit par le serveur
+// (backend/hooks/product_event_hooks.go) :
+//   - product_events.source : valeur `admin`, pour une fiche modifiée depuis
+//     le tableau de bord PocketBase ; l'application garde `manual`
+//   - product_events.source : valeur `system`, pour une fiche écrite par le
+//     serveur sans origine connue (hook, tâche de fond)
+// ⚠️  Safe pour les clients en prod : une valeur de select ajoutée, aucune
+// donnée réécrite.
+
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase"
+)
+
+// AddProductEventsAdminSource ajoute la source `admin` à product_events
+func AddProductEventsAdminSource(app *pocketbase.PocketBase) error {
+	return addSelectValues(app, "product_events", map[string]string{
+		"source": "admin",
+	})
+}
+
+// AddProductEventsSystemSource ajoute la source `system` à product_events
+func AddProductEventsSystemSource(app *pocketbase.PocketBase) error {
+	return addSelectValues(app, "product_events", map[string]string{
+		"source": "system",
+	})
+}
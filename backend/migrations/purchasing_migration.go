@@ -0,0 +1,252 @@
+// backend/migrations/purchasing_migration.go
+// Migration des achats fournisseurs :
+//   - purchase_orders : commande fournisseur (draft → sent →
+//     partially_received → received, ou cancelled)
+//   - purchase_order_lines : une ligne par produit commandé, avec ce qui en a
+//     déjà été reçu
+//   - goods_receipts : une réception, postée par /api/purchase-orders/:id/receive
+//     (journal : ni modifiée ni supprimée)
+//   - companies.purchase_cost_method : mise à jour de purchase_price_ht à la
+//     réception — dernier prix (vide) ou coût moyen pondéré (wac)
+//   - product_events : type `stock_received` et source `purchase_receipt`
+// Le traitement est dans backend/routes/purchase_routes.go.
+// ⚠️  Safe pour les clients en prod : collections neuves et champs nullables.
+
+package migrations
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensurePurchasingCollections crée les trois collections d'achat et les
+// réglages qui vont avec
+func ensurePurchasingCollections(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	suppliersCol, err := dao.FindCollectionByNameOrId("suppliers")
+	if err != nil {
+		return err
+	}
+	productsCol, err := dao.FindCollectionByNameOrId("products")
+	if err != nil {
+		return err
+	}
+
+	if companiesCol.Schema.GetFieldByName("purchase_cost_method") == nil {
+		companiesCol.Schema.AddField(&schema.SchemaField{
+			Name: "purchase_cost_method",
+			Type: schema.FieldTypeSelect,
+			Options: &schema.SelectOptions{
+				MaxSelect: 1,
+				Values:    []string{"last", "wac"},
+			},
+		})
+		if err := dao.SaveCollection(companiesCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champ purchase_cost_method ajouté à companies")
+	}
+
+	if err := addPurchaseReceiptEventValues(app); err != nil {
+		return err
+	}
+
+	relation := func(name, collectionID string, required, cascade bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId:  collectionID,
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: cascade,
+			},
+		}
+	}
+
+	ordersCol, err := dao.FindCollectionByNameOrId("purchase_orders")
+	if err != nil {
+		log.Println("📦 Création de la collection 'purchase_orders'...")
+
+		// Le statut ne se change que par les routes (send, cancel, receive) :
+		// une commande envoyée n'est plus modifiable par l'API REST.
+		draftRule := "@request.auth.id != '' && status = 'draft'"
+		ordersCol = &models.Collection{
+			Name:       "purchase_orders",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: types.Pointer(authRule + " && @request.data.status = 'draft'"),
+			UpdateRule: types.Pointer(draftRule + " && (@request.data.status:isset = false || @request.data.status = 'draft')"),
+			DeleteRule: types.Pointer(draftRule),
+			Schema: schema.NewSchema(
+				relation("owner_company", companiesCol.Id, true, false),
+				// CF-YYYY-XXXX, posé par le hook à la création
+				&schema.SchemaField{Name: "number", Type: schema.FieldTypeText, Presentable: true, Options: &schema.TextOptions{Max: types.Pointer(50)}},
+				&schema.SchemaField{Name: "fiscal_year", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+				relation("supplier", suppliersCol.Id, true, false),
+				&schema.SchemaField{
+					Name:     "status",
+					Type:     schema.FieldTypeSelect,
+					Required: true,
+					Options: &schema.SelectOptions{
+						MaxSelect: 1,
+						Values:    []string{"draft", "sent", "partially_received", "received", "cancelled"},
+					},
+				},
+				// Référence de la commande chez le fournisseur (confirmation, devis)
+				&schema.SchemaField{Name: "supplier_reference", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+				&schema.SchemaField{Name: "expected_at", Type: schema.FieldTypeDate},
+				&schema.SchemaField{Name: "sent_at", Type: schema.FieldTypeDate},
+				&schema.SchemaField{Name: "received_at", Type: schema.FieldTypeDate},
+				&schema.SchemaField{Name: "cancelled_at", Type: schema.FieldTypeDate},
+				// Recalculé par les routes à partir des lignes
+				&schema.SchemaField{Name: "total_ht", Type: schema.FieldTypeNumber},
+				relation("created_by", "_pb_users_auth_", false, false),
+				&schema.SchemaField{Name: "notes", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(2000)}},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE INDEX idx_purchase_orders_company ON purchase_orders (owner_company, status)",
+				"CREATE INDEX idx_purchase_orders_supplier ON purchase_orders (supplier)",
+			},
+		}
+		if err := dao.SaveCollection(ordersCol); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'purchase_orders' créée")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("purchase_order_lines"); err != nil {
+		log.Println("📦 Création de la collection 'purchase_order_lines'...")
+
+		// Les lignes suivent leur commande : éditables tant qu'elle est en
+		// brouillon, et jamais sur la quantité reçue, que seule la réception écrit.
+		draftLineRule := "@request.auth.id != '' && purchase_order.status = 'draft'"
+		collection := &models.Collection{
+			Name:       "purchase_order_lines",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: types.Pointer("@request.auth.id != '' && @request.data.purchase_order.status = 'draft' && @request.data.quantity_received:isset = false"),
+			UpdateRule: types.Pointer(draftLineRule + " && @request.data.quantity_received:isset = false"),
+			DeleteRule: types.Pointer(draftLineRule),
+			Schema: schema.NewSchema(
+				relation("purchase_order", ordersCol.Id, true, true),
+				relation("product", productsCol.Id, true, false),
+				// Instantanés : la commande reste lisible si la fiche change
+				&schema.SchemaField{Name: "product_name", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+				&schema.SchemaField{Name: "supplier_sku", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+				&schema.SchemaField{Name: "quantity_ordered", Type: schema.FieldTypeNumber, Required: true, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+				&schema.SchemaField{Name: "quantity_received", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+				&schema.SchemaField{Name: "unit_price_ht", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE INDEX idx_purchase_order_lines_order ON purchase_order_lines (purchase_order)",
+				"CREATE INDEX idx_purchase_order_lines_product ON purchase_order_lines (product)",
+			},
+		}
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'purchase_order_lines' créée")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("goods_receipts"); err != nil {
+		log.Println("📦 Création de la collection 'goods_receipts'...")
+
+		collection := &models.Collection{
+			Name:       "goods_receipts",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: nil, // écrit uniquement par /api/purchase-orders/:id/receive
+			UpdateRule: nil, // journal : ni modifié ni supprimé
+			DeleteRule: nil,
+			Schema: schema.NewSchema(
+				relation("owner_company", companiesCol.Id, true, false),
+				relation("purchase_order", ordersCol.Id, true, false),
+				relation("supplier", suppliersCol.Id, false, false),
+				relation("received_by", "_pb_users_auth_", false, false),
+				&schema.SchemaField{Name: "received_at", Type: schema.FieldTypeDate, Required: true},
+				// Bon de livraison du fournisseur
+				&schema.SchemaField{Name: "delivery_note", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+				// [{line_id, product_id, product_name, quantity, unit_price_ht,
+				//   stock_before, stock_after, cost_before, cost_after}]
+				&schema.SchemaField{Name: "lines", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 1048576}},
+				&schema.SchemaField{Name: "total_ht", Type: schema.FieldTypeNumber},
+				&schema.SchemaField{Name: "notes", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE INDEX idx_goods_receipts_order ON goods_receipts (purchase_order, received_at)",
+			},
+		}
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'goods_receipts' créée")
+	}
+
+	return nil
+}
+
+// addPurchaseReceiptEventValues ajoute le type `stock_received` et la source
+// `purchase_receipt` aux selects de product_events
+func addPurchaseReceiptEventValues(app *pocketbase.PocketBase) error {
+	return addSelectValues(app, "product_events", map[string]string{
+		"event_type": "stock_received",
+		"source":     "purchase_receipt",
+	})
+}
+
+// addSelectValues ajoute une valeur à chaque select nommé de la collection,
+// si elle n'y est pas déjà
+func addSelectValues(app *pocketbase.PocketBase, collection string, values map[string]string) error {
+	col, err := app.Dao().FindCollectionByNameOrId(collection)
+	if err != nil {
+		return err
+	}
+
+	added := []string{}
+	for field, value := range values {
+		f := col.Schema.GetFieldByName(field)
+		if f == nil {
+			continue
+		}
+		opts, ok := f.Options.(*schema.SelectOptions)
+		if !ok {
+			continue
+		}
+		known := false
+		for _, v := range opts.Values {
+			if v == value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			opts.Values = append(opts.Values, value)
+			added = append(added, field+" "+value)
+		}
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+	if err := app.Dao().SaveCollection(col); err != nil {
+		return err
+	}
+	sort.Strings(added)
+	log.Printf("  ✅ %s : %s ajouté(s)", collection, strings.Join(added, ", "))
+	return nil
+}
@@ -0,0 +1,55 @@
+// backend/migrations/receipt_reprint_migration.go
+// Migration des duplicatas de tickets (NF525) :
+//   - invoices : compteur de réimpressions et date de la dernière
+//   - audit_logs : action receipt_reprinted
+// ⚠️  Safe pour les clients en prod : uniquement des ajouts de champs nullable.
+// Les deux champs ne font pas partie du hash : réimprimer ne casse pas la chaîne.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// AddReceiptReprintFields ajoute le suivi des réimpressions aux tickets
+func AddReceiptReprintFields(app *pocketbase.PocketBase) error {
+	log.Println("🧾 Migration: AddReceiptReprintFields...")
+
+	dao := app.Dao()
+
+	invoicesCol, err := dao.FindCollectionByNameOrId("invoices")
+	if err != nil {
+		return err
+	}
+
+	fields := []*schema.SchemaField{
+		{Name: "reprint_count", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+		{Name: "last_reprinted_at", Type: schema.FieldTypeDate},
+	}
+
+	changed := false
+	for _, f := range fields {
+		if invoicesCol.Schema.GetFieldByName(f.Name) != nil {
+			continue
+		}
+		invoicesCol.Schema.AddField(f)
+		changed = true
+		log.Printf("  ✅ Champ %s ajouté à invoices", f.Name)
+	}
+	if changed {
+		if err := dao.SaveCollection(invoicesCol); err != nil {
+			return err
+		}
+	}
+
+	if err := addAuditLogValues(app, []string{"receipt_reprinted"}, nil); err != nil {
+		return err
+	}
+
+	log.Println("✅ Champs de réimpression OK")
+	return nil
+}
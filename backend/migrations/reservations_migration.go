@@ -0,0 +1,180 @@
+// backend/migrations/reservations_migration.go
+// Migration des réservations de stock :
+//   - stock_reservations : une quantité d'un produit promise à un bon de
+//     commande confirmé, ou à un panier mis en attente en caisse ; écrite
+//     par le serveur seul (hooks des bons de commande, /api/stock/reservations)
+//   - companies.reservation_policy : la caisse avertit (warn, défaut) ou
+//     refuse (block) la vente d'unités réservées
+//   - companies.reservation_order_days : durée d'une réservation de bon de
+//     commande ; 0 = jusqu'à la livraison, la facturation ou l'annulation
+//   - companies.reservation_cart_minutes : durée d'une réservation de panier
+//     en attente ; 0 = les paniers en attente ne réservent pas
+// Le traitement est dans backend/reservation.go et
+// backend/routes/reservation_routes.go.
+// ⚠️  Safe pour les clients en prod : collection neuve et champs nullables.
+// Tant qu'aucun bon de commande n'est confirmé, rien ne change.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureStockReservationsCollection crée les réservations de stock et leurs
+// réglages par société
+func ensureStockReservationsCollection(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if companiesCol.Schema.GetFieldByName("reservation_policy") == nil {
+		companiesCol.Schema.AddField(&schema.SchemaField{
+			Name: "reservation_policy",
+			Type: schema.FieldTypeSelect,
+			Options: &schema.SelectOptions{
+				MaxSelect: 1,
+				Values:    []string{"warn", "block"},
+			},
+		})
+		changed = true
+	}
+	for _, name := range []string{"reservation_order_days", "reservation_cart_minutes"} {
+		if companiesCol.Schema.GetFieldByName(name) == nil {
+			companiesCol.Schema.AddField(&schema.SchemaField{
+				Name:    name,
+				Type:    schema.FieldTypeNumber,
+				Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true},
+			})
+			changed = true
+		}
+	}
+	if changed {
+		if err := dao.SaveCollection(companiesCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Réglages de réservation ajoutés à companies")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("stock_reservations"); err == nil {
+		return nil
+	}
+	log.Println("📦 Création de la collection 'stock_reservations'...")
+
+	productsCol, err := dao.FindCollectionByNameOrId("products")
+	if err != nil {
+		return err
+	}
+	ordersCol, err := dao.FindCollectionByNameOrId("orders")
+	if err != nil {
+		return err
+	}
+	registersCol, err := dao.FindCollectionByNameOrId("cash_registers")
+	if err != nil {
+		return err
+	}
+
+	relation := func(name, collectionID string, required bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId: collectionID,
+				MaxSelect:    types.Pointer(1),
+			},
+		}
+	}
+	text := func(name string, max int) *schema.SchemaField {
+		return &schema.SchemaField{Name: name, Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(max)}}
+	}
+
+	// Lecture seule par l'API REST : une réservation suit son bon de commande
+	// ou son panier, elle ne se crée ni ne se libère à la main autrement que
+	// par /api/stock/reservations/:id/release.
+	collection := &models.Collection{
+		Name:       "stock_reservations",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: nil,
+		UpdateRule: nil,
+		DeleteRule: nil,
+		Schema: schema.NewSchema(
+			relation("owner_company", companiesCol.Id, true),
+			relation("product", productsCol.Id, true),
+			text("product_name", 255),
+			&schema.SchemaField{
+				Name:     "quantity",
+				Type:     schema.FieldTypeNumber,
+				Required: true,
+				Options:  &schema.NumberOptions{Min: types.Pointer(0.0)},
+			},
+			&schema.SchemaField{
+				Name:     "kind",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"order", "parked_cart"},
+				},
+			},
+			// Vidé par PocketBase si le bon de commande est supprimé ; la
+			// réservation, elle, est libérée avant par le hook
+			relation("order", ordersCol.Id, false),
+			text("order_number", 50),
+			// Identifiant du panier en attente, attribué par le poste
+			text("cart_ref", 100),
+			relation("cash_register", registersCol.Id, false),
+			// Client du bon de commande, ou libellé du panier
+			text("label", 255),
+			&schema.SchemaField{
+				Name:     "status",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"active", "released"},
+				},
+			},
+			&schema.SchemaField{Name: "expires_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "released_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{
+				Name: "release_reason",
+				Type: schema.FieldTypeSelect,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values: []string{
+						"delivered", // bon de commande livré
+						"billed",    // bon de commande facturé
+						"cancelled", // bon de commande annulé ou supprimé
+						"reopened",  // bon de commande repassé en brouillon
+						"changed",   // produit retiré du bon de commande
+						"expired",   // délai de la société dépassé
+						"unparked",  // panier repris ou abandonné en caisse
+						"manual",    // libérée depuis l'écran des réservations
+					},
+				},
+			},
+			relation("created_by", "_pb_users_auth_", false),
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_stock_reservations_product ON stock_reservations (product, status)",
+			"CREATE INDEX idx_stock_reservations_order ON stock_reservations (`order`)",
+			"CREATE INDEX idx_stock_reservations_cart ON stock_reservations (cart_ref)",
+		},
+	}
+	if err := dao.SaveCollection(collection); err != nil {
+		return err
+	}
+	log.Println("✅ Collection 'stock_reservations' créée")
+	return nil
+}
@@ -0,0 +1,163 @@
+// backend/migrations/rma_migration.go
+// Migration des retours fournisseur et réparations :
+//   - rma : un article défectueux renvoyé au fournisseur ou en réparation,
+//     avec son fournisseur, son produit, son numéro de série, son client et
+//     sa facture d'origine ; ses étapes passent par /api/rma
+//   - stock_locations.kind : `rma`, le bac où l'article attend
+//   - product_serials.status : `rma`, une unité sortie du stock vendable
+//   - product_events : type `stock_rma` et source `rma`
+// Le traitement est dans backend/rma.go et backend/routes/rma_routes.go.
+// ⚠️  Safe pour les clients en prod : collection neuve et valeurs ajoutées.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureRmaCollection crée les RMA et ajoute les valeurs qu'ils utilisent
+func ensureRmaCollection(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	for collection, values := range map[string]map[string]string{
+		"stock_locations": {"kind": "rma"},
+		"product_serials": {"status": "rma"},
+		"product_events":  {"event_type": "stock_rma", "source": "rma"},
+	} {
+		if err := addSelectValues(app, collection, values); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("rma"); err == nil {
+		return nil
+	}
+	log.Println("📦 Création de la collection 'rma'...")
+
+	ids := map[string]string{"users": "_pb_users_auth_"}
+	for _, name := range []string{
+		"companies", "suppliers", "products", "product_serials",
+		"customers", "invoices", "stock_locations",
+	} {
+		col, err := dao.FindCollectionByNameOrId(name)
+		if err != nil {
+			return err
+		}
+		ids[name] = col.Id
+	}
+
+	relation := func(name, collection string, required bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId: ids[collection],
+				MaxSelect:    types.Pointer(1),
+			},
+		}
+	}
+	text := func(name string, max int) *schema.SchemaField {
+		return &schema.SchemaField{Name: name, Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(max)}}
+	}
+	selectField := func(name string, values ...string) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeSelect,
+			Required: true,
+			Options:  &schema.SelectOptions{MaxSelect: 1, Values: values},
+		}
+	}
+
+	// Création et étapes par /api/rma, qui déplacent le stock dans la même
+	// transaction. L'API REST ne modifie que le reste : fournisseur, accord,
+	// suivi, avoir et notes.
+	locked := []string{
+		"owner_company", "number", "fiscal_year", "origin", "status", "product",
+		"product_name", "quantity", "serial", "location", "rma_location", "history",
+	}
+	updateRule := authRule
+	for _, field := range locked {
+		updateRule += " && @request.data." + field + ":isset = false"
+	}
+
+	collection := &models.Collection{
+		Name:       "rma",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: nil,
+		UpdateRule: types.Pointer(updateRule),
+		DeleteRule: nil, // journal : un RMA s'annule, il ne se supprime pas
+		Schema: schema.NewSchema(
+			relation("owner_company", "companies", true),
+			// RMA-YYYY-XXXX
+			&schema.SchemaField{Name: "number", Type: schema.FieldTypeText, Presentable: true, Options: &schema.TextOptions{Max: types.Pointer(50)}},
+			&schema.SchemaField{Name: "fiscal_year", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+			// Renvoi au fournisseur (garantie) ou réparation chez un tiers
+			selectField("kind", "supplier_return", "repair"),
+			// D'où vient l'article, et où il retourne
+			selectField("origin", "customer", "stock"),
+			selectField("status", "opened", "shipped", "repaired", "replaced", "credited", "returned", "cancelled"),
+			relation("supplier", "suppliers", false),
+			relation("product", "products", true),
+			text("product_name", 255),
+			&schema.SchemaField{
+				Name:     "quantity",
+				Type:     schema.FieldTypeNumber,
+				Required: true,
+				Options:  &schema.NumberOptions{Min: types.Pointer(0.0)},
+			},
+			relation("serial", "product_serials", false),
+			text("serial_number", 100),
+			relation("customer", "customers", false),
+			relation("invoice", "invoices", false),
+			text("invoice_number", 50),
+			// Emplacement d'où l'article a été sorti (origine `stock`)
+			relation("location", "stock_locations", false),
+			// Le bac RMA utilisé ; vide pour une société sans emplacement
+			relation("rma_location", "stock_locations", false),
+			text("fault", 2000),
+			// Numéro d'accord donné par le fournisseur
+			text("supplier_reference", 100),
+			text("tracking_number", 100),
+			// Numéro de l'unité de remplacement, le cas échéant
+			text("replacement_serial", 100),
+			// Avoir fournisseur reçu
+			text("credit_note_number", 100),
+			&schema.SchemaField{Name: "credit_note_amount_ht", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: types.Pointer(0.0)}},
+			&schema.SchemaField{Name: "credit_note_date", Type: schema.FieldTypeDate},
+			&schema.SchemaField{
+				Name: "credit_note_file",
+				Type: schema.FieldTypeFile,
+				Options: &schema.FileOptions{
+					MaxSelect: 1,
+					MaxSize:   10485760,
+					MimeTypes: []string{"application/pdf", "image/jpeg", "image/png"},
+				},
+			},
+			&schema.SchemaField{Name: "opened_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "shipped_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "resolved_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "closed_at", Type: schema.FieldTypeDate},
+			// [{action, status, at, operator, note}]
+			&schema.SchemaField{Name: "history", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 1048576}},
+			text("notes", 2000),
+			relation("created_by", "users", false),
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE INDEX idx_rma_company ON rma (owner_company, status)",
+			"CREATE INDEX idx_rma_serial ON rma (serial)",
+		},
+	}
+	if err := dao.SaveCollection(collection); err != nil {
+		return err
+	}
+	log.Println("✅ Collection 'rma' créée")
+	return nil
+}
@@ -0,0 +1,155 @@
+// backend/migrations/serials_migration.go
+// Migration du suivi par numéro de série (guitares, amplis, pianos) :
+//   - product_serials : une unité physique d'un produit, de sa réception à sa
+//     vente ; écrite par la réception fournisseur et par /api/pos/ticket
+//   - products.serialized : le produit se vend unité par unité
+//   - products.warranty_months / companies.warranty_months : durée de
+//     garantie, celle du produit prime ; 0 partout = garantie légale (24 mois)
+// Le traitement est dans backend/routes/serial_routes.go.
+// ⚠️  Safe pour les clients en prod : collection neuve et champs nullables.
+// Tant qu'aucun produit n'est coché « serialized », rien ne change.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureProductSerialsCollection crée les unités suivies par numéro de série
+// et les réglages de garantie
+func ensureProductSerialsCollection(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	productsCol, err := dao.FindCollectionByNameOrId("products")
+	if err != nil {
+		return err
+	}
+
+	months := func(name string) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:    name,
+			Type:    schema.FieldTypeNumber,
+			Options: &schema.NumberOptions{Min: types.Pointer(0.0), NoDecimal: true},
+		}
+	}
+
+	if companiesCol.Schema.GetFieldByName("warranty_months") == nil {
+		companiesCol.Schema.AddField(months("warranty_months"))
+		if err := dao.SaveCollection(companiesCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champ warranty_months ajouté à companies")
+	}
+
+	changed := false
+	if productsCol.Schema.GetFieldByName("serialized") == nil {
+		productsCol.Schema.AddField(&schema.SchemaField{Name: "serialized", Type: schema.FieldTypeBool})
+		changed = true
+	}
+	if productsCol.Schema.GetFieldByName("warranty_months") == nil {
+		productsCol.Schema.AddField(months("warranty_months"))
+		changed = true
+	}
+	if changed {
+		if err := dao.SaveCollection(productsCol); err != nil {
+			return err
+		}
+		log.Println("  ✅ Champs serialized / warranty_months ajoutés à products")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("product_serials"); err == nil {
+		return nil
+	}
+	log.Println("📦 Création de la collection 'product_serials'...")
+
+	invoicesCol, err := dao.FindCollectionByNameOrId("invoices")
+	if err != nil {
+		return err
+	}
+	customersCol, err := dao.FindCollectionByNameOrId("customers")
+	if err != nil {
+		return err
+	}
+	receiptsCol, err := dao.FindCollectionByNameOrId("goods_receipts")
+	if err != nil {
+		return err
+	}
+
+	relation := func(name, collectionID string, required bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId: collectionID,
+				MaxSelect:    types.Pointer(1),
+			},
+		}
+	}
+
+	// Une unité déjà en boutique avant le suivi se saisit à la main, en stock
+	// et sans vente. Ensuite seule la vente (/api/pos/ticket) et le retour
+	// (/api/serials/:id/return) changent son statut : le numéro d'une unité
+	// vendue ne se corrige plus, c'est lui qu'on montre à l'assureur.
+	collection := &models.Collection{
+		Name:       "product_serials",
+		Type:       models.CollectionTypeBase,
+		ListRule:   types.Pointer(authRule),
+		ViewRule:   types.Pointer(authRule),
+		CreateRule: types.Pointer(authRule + " && @request.data.status = 'in_stock' && @request.data.invoice:isset = false && @request.data.goods_receipt:isset = false"),
+		UpdateRule: types.Pointer(authRule + " && status = 'in_stock' && @request.data.status:isset = false && @request.data.product:isset = false && @request.data.owner_company:isset = false && @request.data.invoice:isset = false && @request.data.customer:isset = false && @request.data.sold_at:isset = false && @request.data.warranty_until:isset = false && @request.data.returned_at:isset = false && @request.data.history:isset = false"),
+		DeleteRule: types.Pointer(authRule + " && status = 'in_stock' && goods_receipt = '' && returned_at = ''"),
+		Schema: schema.NewSchema(
+			relation("owner_company", companiesCol.Id, true),
+			relation("product", productsCol.Id, true),
+			// Repris de la fiche : l'unité reste lisible si le produit disparaît
+			&schema.SchemaField{Name: "product_name", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(255)}},
+			// En majuscules, sans espaces (NormalizeSerial)
+			&schema.SchemaField{Name: "serial_number", Type: schema.FieldTypeText, Required: true, Presentable: true, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+			// Lot de fabrication, tel qu'imprimé sur le bon de livraison
+			&schema.SchemaField{Name: "lot_number", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+			&schema.SchemaField{
+				Name:     "status",
+				Type:     schema.FieldTypeSelect,
+				Required: true,
+				Options: &schema.SelectOptions{
+					MaxSelect: 1,
+					Values:    []string{"in_stock", "sold"},
+				},
+			},
+			relation("goods_receipt", receiptsCol.Id, false),
+			&schema.SchemaField{Name: "received_at", Type: schema.FieldTypeDate},
+			// La dernière vente ; les précédentes sont dans `history`
+			relation("invoice", invoicesCol.Id, false),
+			&schema.SchemaField{Name: "invoice_number", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(50)}},
+			relation("customer", customersCol.Id, false),
+			&schema.SchemaField{Name: "sold_at", Type: schema.FieldTypeDate},
+			&schema.SchemaField{Name: "warranty_until", Type: schema.FieldTypeDate},
+			// Le dernier retour en stock après une vente
+			&schema.SchemaField{Name: "returned_at", Type: schema.FieldTypeDate},
+			// [{invoice, invoice_number, customer, sold_at, warranty_until,
+			//   returned_at, reason}] — une entrée par vente suivie d'un retour
+			&schema.SchemaField{Name: "history", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 65536}},
+			&schema.SchemaField{Name: "notes", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+		),
+		Indexes: types.JsonArray[string]{
+			"CREATE UNIQUE INDEX idx_product_serials_product_serial ON product_serials (product, serial_number)",
+			"CREATE INDEX idx_product_serials_serial ON product_serials (serial_number)",
+			"CREATE INDEX idx_product_serials_invoice ON product_serials (invoice)",
+		},
+	}
+	if err := dao.SaveCollection(collection); err != nil {
+		return err
+	}
+	log.Println("✅ Collection 'product_serials' créée")
+	return nil
+}
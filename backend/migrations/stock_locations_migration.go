@@ -0,0 +1,189 @@
+// backend/migrations/stock_locations_migration.go
+// Migration du stock multi-emplacements :
+//   - stock_locations : boutique, réserve, atelier… une par lieu physique,
+//     dont une seule par société est l'emplacement par défaut
+//   - stock_levels : la quantité d'un produit à un emplacement ;
+//     products.stock en est le total
+//   - stock_transfers : un transfert entre deux emplacements, posté par
+//     /api/stock/transfers (journal : ni modifié ni supprimé)
+//   - cash_registers.default_location : où la caisse prend ce qu'elle vend
+//   - inventory_sessions.location : l'emplacement compté
+//   - goods_receipts.location : où la réception est entrée
+//   - product_events : type `stock_transferred` et source `stock_transfer`
+// Le traitement est dans backend/routes/location_routes.go.
+// ⚠️  Safe pour les clients en prod : collections neuves et champs nullables.
+// Tant qu'une société n'a aucun emplacement, rien ne change pour elle.
+
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ensureStockLocationCollections crée les emplacements, les quantités par
+// emplacement et les transferts, puis rattache caisses, inventaires et
+// réceptions à un emplacement
+func ensureStockLocationCollections(app *pocketbase.PocketBase) error {
+	dao := app.Dao()
+
+	companiesCol, err := dao.FindCollectionByNameOrId("companies")
+	if err != nil {
+		return err
+	}
+	productsCol, err := dao.FindCollectionByNameOrId("products")
+	if err != nil {
+		return err
+	}
+
+	if err := addSelectValues(app, "product_events", map[string]string{
+		"event_type": "stock_transferred",
+		"source":     "stock_transfer",
+	}); err != nil {
+		return err
+	}
+
+	relation := func(name, collectionID string, required, cascade bool) *schema.SchemaField {
+		return &schema.SchemaField{
+			Name:     name,
+			Type:     schema.FieldTypeRelation,
+			Required: required,
+			Options: &schema.RelationOptions{
+				CollectionId:  collectionID,
+				MaxSelect:     types.Pointer(1),
+				CascadeDelete: cascade,
+			},
+		}
+	}
+
+	locationsCol, err := dao.FindCollectionByNameOrId("stock_locations")
+	if err != nil {
+		log.Println("📦 Création de la collection 'stock_locations'...")
+
+		// L'emplacement par défaut ne se change que par
+		// /api/stock/locations/:id/default : le stock des produits qui n'ont
+		// pas encore de quantité par emplacement y est compté.
+		locationsCol = &models.Collection{
+			Name:       "stock_locations",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: types.Pointer(authRule),
+			UpdateRule: types.Pointer(authRule + " && @request.data.is_default:isset = false && @request.data.owner_company:isset = false"),
+			DeleteRule: types.Pointer(authRule),
+			Schema: schema.NewSchema(
+				relation("owner_company", companiesCol.Id, true, false),
+				&schema.SchemaField{Name: "name", Type: schema.FieldTypeText, Required: true, Presentable: true, Options: &schema.TextOptions{Max: types.Pointer(100)}},
+				// Code court, imprimé sur les bons de transfert
+				&schema.SchemaField{Name: "code", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(20)}},
+				&schema.SchemaField{
+					Name: "kind",
+					Type: schema.FieldTypeSelect,
+					Options: &schema.SelectOptions{
+						MaxSelect: 1,
+						Values:    []string{"shop", "storage", "workshop"},
+					},
+				},
+				// Posé par le hook sur le premier emplacement de la société
+				&schema.SchemaField{Name: "is_default", Type: schema.FieldTypeBool},
+				// Un emplacement archivé garde son stock mais ne reçoit plus rien
+				&schema.SchemaField{Name: "archived", Type: schema.FieldTypeBool},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE INDEX idx_stock_locations_company ON stock_locations (owner_company)",
+			},
+		}
+		if err := dao.SaveCollection(locationsCol); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'stock_locations' créée")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("stock_levels"); err != nil {
+		log.Println("📦 Création de la collection 'stock_levels'...")
+
+		collection := &models.Collection{
+			Name:       "stock_levels",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: nil, // écrit uniquement par les mouvements de stock
+			UpdateRule: nil,
+			DeleteRule: nil,
+			Schema: schema.NewSchema(
+				relation("product", productsCol.Id, true, true),
+				relation("location", locationsCol.Id, true, true),
+				&schema.SchemaField{Name: "quantity", Type: schema.FieldTypeNumber},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE UNIQUE INDEX idx_stock_levels_product_location ON stock_levels (product, location)",
+				"CREATE INDEX idx_stock_levels_location ON stock_levels (location)",
+			},
+		}
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'stock_levels' créée")
+	}
+
+	if _, err := dao.FindCollectionByNameOrId("stock_transfers"); err != nil {
+		log.Println("📦 Création de la collection 'stock_transfers'...")
+
+		collection := &models.Collection{
+			Name:       "stock_transfers",
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(authRule),
+			ViewRule:   types.Pointer(authRule),
+			CreateRule: nil, // écrit uniquement par /api/stock/transfers
+			UpdateRule: nil, // journal : ni modifié ni supprimé
+			DeleteRule: nil,
+			Schema: schema.NewSchema(
+				relation("owner_company", companiesCol.Id, true, false),
+				// TR-YYYY-XXXX
+				&schema.SchemaField{Name: "number", Type: schema.FieldTypeText, Presentable: true, Options: &schema.TextOptions{Max: types.Pointer(50)}},
+				&schema.SchemaField{Name: "fiscal_year", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+				relation("from_location", locationsCol.Id, true, false),
+				relation("to_location", locationsCol.Id, true, false),
+				relation("transferred_by", "_pb_users_auth_", false, false),
+				&schema.SchemaField{Name: "transferred_at", Type: schema.FieldTypeDate, Required: true},
+				// [{product_id, product_name, quantity, from_before, from_after,
+				//   to_before, to_after, event_id}]
+				&schema.SchemaField{Name: "lines", Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: 1048576}},
+				&schema.SchemaField{Name: "total_quantity", Type: schema.FieldTypeNumber},
+				&schema.SchemaField{Name: "notes", Type: schema.FieldTypeText, Options: &schema.TextOptions{Max: types.Pointer(1000)}},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE INDEX idx_stock_transfers_company ON stock_transfers (owner_company, transferred_at)",
+			},
+		}
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+		log.Println("✅ Collection 'stock_transfers' créée")
+	}
+
+	for _, target := range []struct{ collection, field string }{
+		{"cash_registers", "default_location"},
+		{"inventory_sessions", "location"},
+		{"goods_receipts", "location"},
+	} {
+		col, err := dao.FindCollectionByNameOrId(target.collection)
+		if err != nil {
+			return err
+		}
+		if col.Schema.GetFieldByName(target.field) != nil {
+			continue
+		}
+		col.Schema.AddField(relation(target.field, locationsCol.Id, false, false))
+		if err := dao.SaveCollection(col); err != nil {
+			return err
+		}
+		log.Printf("  ✅ Champ %s ajouté à %s", target.field, target.collection)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// backend/pos/customerscreen.go
+// Écran client web : l'état complet du panier d'une caisse (lignes, remises,
+// totaux, rendu monnaie) diffusé aux tablettes appairées à cette caisse.
+// Complète DisplayManager, limité aux deux lignes de 20 caractères du VFD.
+package pos
+
+import (
+	"sync"
+	"time"
+)
+
+// Phases de l'écran client
+const (
+	ScreenPhaseIdle    = "idle"    // diaporama (logo, promotions, produits)
+	ScreenPhaseCart    = "cart"    // panier en cours
+	ScreenPhasePayment = "payment" // à payer / reçu
+	ScreenPhaseChange  = "change"  // rendu monnaie
+	ScreenPhaseThanks  = "thanks"  // vente terminée
+)
+
+// CustomerScreenLine : une ligne du panier telle que le client la voit
+type CustomerScreenLine struct {
+	Name          string  `json:"name"`
+	Quantity      float64 `json:"quantity"`
+	UnitPriceTtc  float64 `json:"unit_price_ttc"`
+	BasePriceTtc  float64 `json:"base_price_ttc,omitempty"` // prix barré si remise
+	TotalTtc      float64 `json:"total_ttc"`
+	DiscountLabel string  `json:"discount_label,omitempty"` // "-10 %", "-5,00 €"
+	ImageURL      string  `json:"image_url,omitempty"`
+}
+
+// CustomerScreenState : ce que l'écran client affiche à un instant donné
+type CustomerScreenState struct {
+	Phase          string               `json:"phase"`
+	Items          []CustomerScreenLine `json:"items"`
+	ItemCount      float64              `json:"item_count"`
+	SubtotalTtc    float64              `json:"subtotal_ttc"`
+	DiscountAmount float64              `json:"discount_amount"`
+	TotalTtc       float64              `json:"total_ttc"`
+	TotalSavings   float64              `json:"total_savings"`
+	PaymentMethod  string               `json:"payment_method,omitempty"`
+	Received       float64              `json:"received"`
+	Change         float64              `json:"change"`
+	Message        string               `json:"message,omitempty"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// CustomerScreenHub : un état et des abonnés par caisse
+type CustomerScreenHub struct {
+	mu          sync.RWMutex
+	states      map[string]CustomerScreenState
+	subscribers map[string]map[chan CustomerScreenState]bool
+}
+
+// NewCustomerScreenHub crée un hub vide
+func NewCustomerScreenHub() *CustomerScreenHub {
+	return &CustomerScreenHub{
+		states:      make(map[string]CustomerScreenState),
+		subscribers: make(map[string]map[chan CustomerScreenState]bool),
+	}
+}
+
+// Global instance
+var CustomerScreens = NewCustomerScreenHub()
+
+// Publish enregistre l'état de la caisse et le diffuse à ses écrans.
+// Retourne le nombre d'écrans connectés.
+func (h *CustomerScreenHub) Publish(registerID string, state CustomerScreenState) int {
+	if state.Phase == "" {
+		state.Phase = ScreenPhaseIdle
+	}
+	if state.Items == nil {
+		state.Items = []CustomerScreenLine{}
+	}
+	state.UpdatedAt = time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.states[registerID] = state
+	for ch := range h.subscribers[registerID] {
+		select {
+		case ch <- state:
+		default:
+			// Écran trop lent : il recevra le prochain état
+		}
+	}
+	return len(h.subscribers[registerID])
+}
+
+// State retourne le dernier état publié pour la caisse
+func (h *CustomerScreenHub) State(registerID string) CustomerScreenState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	state, ok := h.states[registerID]
+	if !ok {
+		return CustomerScreenState{Phase: ScreenPhaseIdle, Items: []CustomerScreenLine{}}
+	}
+	return state
+}
+
+// Subscribe abonne un écran à la caisse ; l'état courant est envoyé aussitôt
+func (h *CustomerScreenHub) Subscribe(registerID string) chan CustomerScreenState {
+	ch := make(chan CustomerScreenState, 10)
+	current := h.State(registerID)
+
+	h.mu.Lock()
+	if h.subscribers[registerID] == nil {
+		h.subscribers[registerID] = make(map[chan CustomerScreenState]bool)
+	}
+	h.subscribers[registerID][ch] = true
+	h.mu.Unlock()
+
+	ch <- current
+	return ch
+}
+
+// Unsubscribe retire un écran
+func (h *CustomerScreenHub) Unsubscribe(registerID string, ch chan CustomerScreenState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subs := h.subscribers[registerID]; subs != nil {
+		delete(subs, ch)
+		if len(subs) == 0 {
+			delete(h.subscribers, registerID)
+		}
+	}
+	close(ch)
+}
+
+// Viewers retourne le nombre d'écrans connectés à la caisse
+func (h *CustomerScreenHub) Viewers(registerID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[registerID])
+}
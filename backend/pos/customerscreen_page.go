@@ -0,0 +1,231 @@
+// backend/pos/customerscreen_page.go
+// Page de l'écran client, servie telle quelle par le serveur embarqué : une
+// tablette du réseau local l'ouvre sans compte ni application, avec la clé
+// d'appairage de la caisse dans l'URL (/display/<caisse>?k=<clé>).
+package pos
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var customerScreenTemplate = template.Must(template.New("screen").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Écran client</title>
+<style>
+  * { box-sizing: border-box; margin: 0; padding: 0; }
+  html, body { height: 100%; background: #0f172a; color: #f8fafc; font-family: system-ui, -apple-system, "Segoe UI", Roboto, sans-serif; overflow: hidden; }
+  .view { position: absolute; inset: 0; display: none; }
+  .view.on { display: flex; }
+  .muted { color: #94a3b8; }
+
+  #idle { align-items: center; justify-content: center; flex-direction: column; text-align: center; padding: 4vh 4vw; }
+  #idle .slide { display: none; flex-direction: column; align-items: center; gap: 3vh; animation: fade .6s ease; }
+  #idle .slide.on { display: flex; }
+  #idle img.logo { max-width: 60vw; max-height: 40vh; object-fit: contain; }
+  #idle img.product { max-width: 70vw; max-height: 60vh; object-fit: contain; border-radius: 1.5vh; background: #fff; }
+  #idle h1 { font-size: 6vh; font-weight: 700; }
+  #idle h2 { font-size: 4.5vh; font-weight: 600; }
+  #idle .price { font-size: 7vh; font-weight: 800; color: #facc15; }
+  #idle .promo { font-size: 6vh; font-weight: 700; max-width: 80vw; line-height: 1.25; }
+
+  #cart { flex-direction: row; }
+  #cart .lines { flex: 3; overflow: hidden; padding: 3vh 3vw; display: flex; flex-direction: column; justify-content: flex-end; }
+  #cart .line { display: flex; align-items: center; gap: 2vw; padding: 1.6vh 0; border-bottom: 1px solid #1e293b; font-size: 3vh; }
+  #cart .line img { width: 7vh; height: 7vh; object-fit: contain; background: #fff; border-radius: .8vh; }
+  #cart .line .name { flex: 1; }
+  #cart .line .qty { color: #94a3b8; min-width: 8vw; text-align: right; }
+  #cart .line .amount { min-width: 14vw; text-align: right; font-weight: 600; }
+  #cart .line s { color: #64748b; font-size: 2.4vh; margin-right: .8vw; }
+  #cart .line .badge { background: #16a34a; color: #fff; border-radius: .6vh; padding: .2vh .8vw; font-size: 2.2vh; margin-left: 1vw; }
+  #cart .totals { flex: 2; background: #1e293b; padding: 4vh 3vw; display: flex; flex-direction: column; justify-content: center; gap: 2vh; }
+  #cart .row { display: flex; justify-content: space-between; font-size: 3.2vh; }
+  #cart .row.total { font-size: 7vh; font-weight: 800; margin-top: 2vh; }
+  #cart .row.savings { color: #4ade80; }
+  #cart .row.change { font-size: 6vh; font-weight: 800; color: #facc15; }
+  #cart .banner { font-size: 3.4vh; text-align: center; color: #facc15; min-height: 4vh; }
+
+  #thanks { align-items: center; justify-content: center; flex-direction: column; gap: 3vh; text-align: center; }
+  #thanks h1 { font-size: 8vh; }
+  #thanks .change { font-size: 6vh; color: #facc15; font-weight: 800; }
+
+  #offline { position: absolute; bottom: 1.5vh; right: 1.5vw; font-size: 1.8vh; color: #f87171; display: none; }
+  @keyframes fade { from { opacity: 0; } to { opacity: 1; } }
+</style>
+</head>
+<body>
+<div id="idle" class="view on"></div>
+<div id="cart" class="view">
+  <div class="lines" id="lines"></div>
+  <div class="totals">
+    <div class="banner" id="banner"></div>
+    <div class="row"><span>Sous-total</span><span id="subtotal"></span></div>
+    <div class="row" id="discountRow"><span>Remise</span><span id="discount"></span></div>
+    <div class="row savings" id="savingsRow"><span>Vous économisez</span><span id="savings"></span></div>
+    <div class="row total"><span>Total</span><span id="total"></span></div>
+    <div class="row" id="receivedRow"><span id="method">Reçu</span><span id="received"></span></div>
+    <div class="row change" id="changeRow"><span>Rendu</span><span id="change"></span></div>
+  </div>
+</div>
+<div id="thanks" class="view">
+  <h1 id="thanksTitle">Merci de votre visite</h1>
+  <div class="change" id="thanksChange"></div>
+</div>
+<div id="offline">Connexion à la caisse perdue…</div>
+
+<script>
+(function () {
+  var registerId = {{.RegisterID}};
+  var key = {{.Key}};
+  var base = "/api/display/screen/" + encodeURIComponent(registerId);
+  var eur = new Intl.NumberFormat("fr-FR", { style: "currency", currency: "EUR" });
+  var qty = new Intl.NumberFormat("fr-FR", { maximumFractionDigits: 3 });
+
+  function $(id) { return document.getElementById(id); }
+  function el(tag, cls, text) {
+    var e = document.createElement(tag);
+    if (cls) e.className = cls;
+    if (text != null) e.textContent = text;
+    return e;
+  }
+  function show(id) {
+    ["idle", "cart", "thanks"].forEach(function (v) { $(v).classList.toggle("on", v === id); });
+  }
+  function toggle(id, on) { $(id).style.display = on ? "flex" : "none"; }
+
+  // ── Diaporama d'attente ─────────────────────────────────────────────────
+  var slides = [], slideIndex = 0, slideTimer = null, slideSeconds = 8;
+
+  function buildSlides(data) {
+    var idle = $("idle");
+    idle.innerHTML = "";
+    slides = [];
+    if (data.logo || data.company_name) {
+      var s = el("div", "slide");
+      if (data.logo) { var img = el("img", "logo"); img.src = data.logo; s.appendChild(img); }
+      else { s.appendChild(el("h1", null, data.company_name)); }
+      s.appendChild(el("h2", "muted", data.welcome || "Bienvenue"));
+      slides.push(s);
+    }
+    (data.promotions || []).forEach(function (p) {
+      var s = el("div", "slide");
+      s.appendChild(el("div", "promo", p));
+      slides.push(s);
+    });
+    (data.products || []).forEach(function (p) {
+      var s = el("div", "slide");
+      var img = el("img", "product"); img.src = p.image_url; s.appendChild(img);
+      s.appendChild(el("h2", null, p.name));
+      if (p.price_ttc > 0) s.appendChild(el("div", "price", eur.format(p.price_ttc)));
+      slides.push(s);
+    });
+    slides.forEach(function (s) { idle.appendChild(s); });
+    if (data.slide_seconds > 0) slideSeconds = data.slide_seconds;
+    slideIndex = 0;
+    rotate();
+  }
+
+  function rotate() {
+    clearTimeout(slideTimer);
+    if (!slides.length) return;
+    slides.forEach(function (s, i) { s.classList.toggle("on", i === slideIndex); });
+    slideIndex = (slideIndex + 1) % slides.length;
+    slideTimer = setTimeout(rotate, slideSeconds * 1000);
+  }
+
+  function loadSlides() {
+    fetch(base + "/slides?k=" + encodeURIComponent(key))
+      .then(function (r) { return r.ok ? r.json() : null; })
+      .then(function (data) { if (data) buildSlides(data); })
+      .catch(function () {});
+  }
+
+  // ── Panier ──────────────────────────────────────────────────────────────
+  function renderCart(state) {
+    var lines = $("lines");
+    lines.innerHTML = "";
+    (state.items || []).forEach(function (it) {
+      var row = el("div", "line");
+      if (it.image_url) { var img = el("img"); img.src = it.image_url; row.appendChild(img); }
+      var name = el("div", "name", it.name);
+      if (it.discount_label) name.appendChild(el("span", "badge", it.discount_label));
+      row.appendChild(name);
+      row.appendChild(el("div", "qty", "× " + qty.format(it.quantity)));
+      var amount = el("div", "amount");
+      if (it.base_price_ttc > it.unit_price_ttc) amount.appendChild(el("s", null, eur.format(it.base_price_ttc * it.quantity)));
+      amount.appendChild(document.createTextNode(eur.format(it.total_ttc)));
+      row.appendChild(amount);
+      lines.appendChild(row);
+    });
+
+    $("subtotal").textContent = eur.format(state.subtotal_ttc || 0);
+    $("discount").textContent = "− " + eur.format(state.discount_amount || 0);
+    $("savings").textContent = eur.format(state.total_savings || 0);
+    $("total").textContent = eur.format(state.total_ttc || 0);
+    $("method").textContent = state.payment_method || "Reçu";
+    $("received").textContent = eur.format(state.received || 0);
+    $("change").textContent = eur.format(state.change || 0);
+    toggle("discountRow", state.discount_amount > 0);
+    toggle("savingsRow", state.total_savings > 0);
+    toggle("receivedRow", state.received > 0);
+    toggle("changeRow", state.change > 0);
+
+    var banner = state.message || "";
+    if (!banner && state.phase === "payment") banner = "À payer";
+    $("banner").textContent = banner;
+  }
+
+  function render(state) {
+    if (state.phase === "idle" || !state.phase) { show("idle"); rotate(); return; }
+    clearTimeout(slideTimer);
+    if (state.phase === "thanks") {
+      $("thanksTitle").textContent = state.message || "Merci de votre visite";
+      $("thanksChange").textContent = state.change > 0 ? "Rendu : " + eur.format(state.change) : "";
+      show("thanks");
+      return;
+    }
+    renderCart(state);
+    show("cart");
+  }
+
+  // ── Connexion à la caisse ───────────────────────────────────────────────
+  function connect() {
+    var proto = location.protocol === "https:" ? "wss://" : "ws://";
+    var ws = new WebSocket(proto + location.host + base + "/ws?k=" + encodeURIComponent(key));
+    var ping = null;
+    ws.onopen = function () {
+      $("offline").style.display = "none";
+      ping = setInterval(function () { ws.send("ping"); }, 30000);
+    };
+    ws.onmessage = function (e) {
+      var msg = JSON.parse(e.data);
+      if (msg.type === "screen_update") render(msg.state);
+    };
+    ws.onclose = function () {
+      clearInterval(ping);
+      $("offline").style.display = "block";
+      setTimeout(connect, 3000);
+    };
+  }
+
+  loadSlides();
+  setInterval(loadSlides, 10 * 60 * 1000);
+  connect();
+})();
+</script>
+</body>
+</html>
+`))
+
+// CustomerScreenPageHTML : page de l'écran client pour une caisse appairée
+func CustomerScreenPageHTML(registerID, key string) (string, error) {
+	var buf bytes.Buffer
+	err := customerScreenTemplate.Execute(&buf, map[string]string{
+		"RegisterID": registerID,
+		"Key":        key,
+	})
+	return buf.String(), err
+}
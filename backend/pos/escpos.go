@@ -3,6 +3,8 @@ package pos
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"golang.org/x/text/encoding/charmap"
@@ -48,7 +50,7 @@ func NL() []byte { return []byte("\n") }
 
 type ReceiptItem struct {
 	Name         string   `json:"name"`
-	Qty          int      `json:"qty"`
+	Qty          float64  `json:"qty"` // au poids ou au mètre : 0.5, 1.25
 	UnitTtc      float64  `json:"unitTtc"`
 	TotalTtc     float64  `json:"totalTtc"`
 	TvaRate      float64  `json:"tvaRate"`
@@ -96,8 +98,64 @@ type ReceiptData struct {
 	Received      *float64 `json:"received"`
 	Change        *float64 `json:"change"`
 	Width         int      `json:"width"`
+
+	// Réimpression (NF525) : renseignés uniquement par le serveur, jamais
+	// acceptés du client (json:"-")
+	DuplicateNumber int    `json:"-"`
+	ReprintedAt     string `json:"-"`
+
+	// Mentions du ticket enregistré (NF525) : renseignées uniquement par le
+	// serveur depuis la base (voir routes/pos_receipt.go)
+	SequenceNumber int    `json:"-"`
+	HashExcerpt    string `json:"-"`
+	RegisterLabel  string `json:"-"`
+	SoftwareLabel  string `json:"-"`
+
+	// Codes lisibles par machine (ticket enregistré) : QR de vérification et
+	// code-barres du numéro, scanné au remboursement. RasterCodes les imprime
+	// en image pour les imprimantes sans GS ( k / GS k.
+	QRContent      string `json:"-"`
+	BarcodeContent string `json:"-"`
+	RasterCodes    bool   `json:"-"`
+}
+
+// Logiciel de caisse cité sur les tickets ; la version est posée au
+// démarrage par le binaire (currentVersion)
+var (
+	SoftwareName    = "PocketApp"
+	SoftwareVersion = "dev"
+)
+
+// LegalLines : mentions d'identification du ticket enregistré (vide pour un
+// ticket construit par le navigateur)
+func (r ReceiptData) LegalLines() []string {
+	var lines []string
+	if r.SequenceNumber > 0 {
+		lines = append(lines, fmt.Sprintf("Sequence: %d", r.SequenceNumber))
+	}
+	if r.RegisterLabel != "" {
+		lines = append(lines, "Caisse: "+r.RegisterLabel)
+	}
+	if r.HashExcerpt != "" {
+		lines = append(lines, "Empreinte: "+r.HashExcerpt)
+	}
+	if r.SoftwareLabel != "" {
+		lines = append(lines, "Logiciel: "+r.SoftwareLabel)
+	}
+	return lines
 }
 
+// DuplicateBanner : "DUPLICATA n°X" (vide pour un original)
+func (r ReceiptData) DuplicateBanner() string {
+	if r.DuplicateNumber <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("DUPLICATA n°%d", r.DuplicateNumber)
+}
+
+// DoubleSizeOn : ESC ! 0x30 (double hauteur + double largeur)
+func DoubleSizeOn() []byte { return []byte{0x1B, 0x21, 0x30} }
+
 func rightAlign(text string, width int) string {
 	if len(text) >= width {
 		return text
@@ -113,6 +171,12 @@ func labelValue(label string, value string, width int) string {
 	return label + strings.Repeat(" ", available) + value
 }
 
+// formatQty écrit une quantité avec ses décimales, sans zéros inutiles
+// (2, 0.5, 1.25) : un article vendu au poids ne s'arrondit pas à l'unité
+func formatQty(qty float64) string {
+	return strconv.FormatFloat(math.Round(qty*1000)/1000, 'f', -1, 64)
+}
+
 func BuildReceipt(r ReceiptData) []byte {
 	var lineWidth int
 	if r.Width == 80 {
@@ -176,6 +240,18 @@ func BuildReceipt(r ReceiptData) []byte {
 	}
 
 	b.Write(NL())
+	if banner := r.DuplicateBanner(); banner != "" {
+		b.Write(DoubleSizeOn())
+		b.Write(BoldOn())
+		b.Write(Text(banner))
+		b.Write(BoldOff())
+		b.Write(SmallTextOff())
+		b.Write(NL())
+		if r.ReprintedAt != "" {
+			b.Write(Text("Reimprime le " + r.ReprintedAt))
+			b.Write(NL())
+		}
+	}
 	b.Write(Text(strings.Repeat("-", lineWidth)))
 	b.Write(NL())
 	b.Write(Text(fmt.Sprintf("TICKET: %s", r.InvoiceNumber)))
@@ -230,8 +306,8 @@ func BuildReceipt(r ReceiptData) []byte {
 				discountLabel = fmt.Sprintf("-%.2f EUR", discAmt)
 			}
 
-			line1 := fmt.Sprintf("  %dx %.2fEUR (TVA %.2g%%) %s",
-				it.Qty,
+			line1 := fmt.Sprintf("  %sx %.2fEUR (TVA %.2g%%) %s",
+				formatQty(it.Qty),
 				*it.BaseUnitTtc,
 				it.TvaRate,
 				discountLabel,
@@ -247,7 +323,7 @@ func BuildReceipt(r ReceiptData) []byte {
 			b.Write(Text(line2))
 			b.Write(NL())
 		} else {
-			qtyPrice := fmt.Sprintf("  %dx %.2fEUR (TVA %.2g%%)", it.Qty, it.UnitTtc, it.TvaRate)
+			qtyPrice := fmt.Sprintf("  %sx %.2fEUR (TVA %.2g%%)", formatQty(it.Qty), it.UnitTtc, it.TvaRate)
 			total := fmt.Sprintf("%.2fEUR", it.TotalTtc)
 			line := labelValue(qtyPrice, total, lineWidth)
 			b.Write(Text(line))
@@ -340,10 +416,30 @@ func BuildReceipt(r ReceiptData) []byte {
 		b.Write(NL())
 	}
 
+	// =========== MENTIONS (ticket enregistré) ===========
+	if legal := r.LegalLines(); len(legal) > 0 {
+		b.Write(NL())
+		b.Write(SmallTextOn())
+		for _, l := range legal {
+			b.Write(Text(l))
+			b.Write(NL())
+		}
+		b.Write(SmallTextOff())
+	}
+
+	// =========== CODES (ticket enregistré) ===========
+	b.Write(receiptCodes(r))
+
 	// =========== FOOTER ===========
 	b.Write(NL())
 	b.Write(NL())
 	b.Write(AlignCenter())
+	if banner := r.DuplicateBanner(); banner != "" {
+		b.Write(BoldOn())
+		b.Write(Text(banner))
+		b.Write(BoldOff())
+		b.Write(NL())
+	}
 	b.Write(Text("MERCI DE VOTRE VISITE !"))
 	b.Write(NL())
 	b.Write(NL())
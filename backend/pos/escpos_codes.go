@@ -0,0 +1,317 @@
+// backend/pos/escpos_codes.go
+// QR codes et codes-barres sur les tickets : commandes natives ESC/POS
+// (GS ( k pour le QR, GS k pour CODE128 / EAN13) et repli en image raster
+// (GS v 0) générée ici, pour les imprimantes qui ne les connaissent pas.
+package pos
+
+import (
+	"errors"
+	"strings"
+)
+
+// ── Commandes natives ───────────────────────────────────────────────────────
+
+// QRCodeCmd : QR modèle 2, correction M, taille de module 1..16
+func QRCodeCmd(data string, moduleSize byte) []byte {
+	if moduleSize < 1 || moduleSize > 16 {
+		moduleSize = 6
+	}
+	n := len(data) + 3
+	cmd := []byte{
+		0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00, // modèle 2
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, moduleSize, // taille
+		0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x31, // correction M
+		0x1D, 0x28, 0x6B, byte(n & 0xFF), byte(n >> 8), 0x31, 0x50, 0x30, // stockage
+	}
+	cmd = append(cmd, data...)
+	return append(cmd, 0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30) // impression
+}
+
+// barcodeSetup : hauteur, largeur de module, texte lisible sous le code
+func barcodeSetup(height byte) []byte {
+	return []byte{
+		0x1D, 0x68, height, // GS h
+		0x1D, 0x77, 0x02, // GS w
+		0x1D, 0x48, 0x02, // GS H : HRI sous le code
+		0x1D, 0x66, 0x00, // GS f : police A
+	}
+}
+
+// Code128Cmd : GS k 73, jeu B (ASCII 32..126)
+func Code128Cmd(data string, height byte) ([]byte, error) {
+	if _, err := code128Values(data); err != nil {
+		return nil, err
+	}
+	payload := append([]byte("{B"), data...)
+	cmd := barcodeSetup(height)
+	cmd = append(cmd, 0x1D, 0x6B, 73, byte(len(payload)))
+	return append(cmd, payload...), nil
+}
+
+// EAN13Cmd : GS k 67 ; 12 chiffres (l'imprimante calcule la clé) ou 13
+func EAN13Cmd(data string, height byte) ([]byte, error) {
+	digits, err := ean13Digits(data)
+	if err != nil {
+		return nil, err
+	}
+	cmd := barcodeSetup(height)
+	cmd = append(cmd, 0x1D, 0x6B, 67, 13)
+	for _, d := range digits {
+		cmd = append(cmd, '0'+d)
+	}
+	return cmd, nil
+}
+
+// receiptCodes : code-barres du numéro puis QR, centrés. Un code qui ne peut
+// pas être encodé est omis, le ticket part quand même.
+func receiptCodes(r ReceiptData) []byte {
+	if r.BarcodeContent == "" && r.QRContent == "" {
+		return nil
+	}
+
+	moduleDots := 5
+	if r.Width == 80 {
+		moduleDots = 6
+	}
+
+	var out []byte
+	out = append(out, NL()...)
+	out = append(out, AlignCenter()...)
+
+	if r.BarcodeContent != "" {
+		var cmd []byte
+		if r.RasterCodes {
+			if modules, err := Code128Modules(r.BarcodeContent); err == nil {
+				cmd, _ = BarcodeRaster(modules, 2, 80, r.Width)
+				if cmd != nil {
+					cmd = append(cmd, NL()...)
+					cmd = append(cmd, Text(r.BarcodeContent)...)
+				}
+			}
+		} else {
+			cmd, _ = Code128Cmd(r.BarcodeContent, 80)
+		}
+		if cmd != nil {
+			out = append(out, cmd...)
+			out = append(out, NL()...)
+		}
+	}
+
+	if r.QRContent != "" {
+		var cmd []byte
+		if r.RasterCodes {
+			cmd, _ = QRCodeRaster(r.QRContent, moduleDots)
+		} else {
+			cmd = QRCodeCmd(r.QRContent, byte(moduleDots))
+		}
+		if cmd != nil {
+			out = append(out, NL()...)
+			out = append(out, cmd...)
+			out = append(out, NL()...)
+		}
+	}
+	return out
+}
+
+// ── Repli raster ────────────────────────────────────────────────────────────
+
+// QRCodeRaster : QR en image, module de moduleDots points, zone de silence
+// de 4 modules
+func QRCodeRaster(data string, moduleDots int) ([]byte, error) {
+	qr, err := EncodeQR([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	if moduleDots < 1 {
+		moduleDots = 6
+	}
+	const quiet = 4
+	side := (qr.Size + 2*quiet) * moduleDots
+	bits := make([]bool, side*side)
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if !qr.Dark(x, y) {
+				continue
+			}
+			for dy := 0; dy < moduleDots; dy++ {
+				row := ((y+quiet)*moduleDots + dy) * side
+				for dx := 0; dx < moduleDots; dx++ {
+					bits[row+(x+quiet)*moduleDots+dx] = true
+				}
+			}
+		}
+	}
+	return rasterBitsCmd(bits, side, side), nil
+}
+
+// BarcodeRaster : barres (true = noir, un élément par module) en image
+func BarcodeRaster(modules []bool, moduleDots, height, paperWidth int) ([]byte, error) {
+	if len(modules) == 0 {
+		return nil, errors.New("code-barres vide")
+	}
+	// Zone de silence de 10 modules de part et d'autre
+	quiet := make([]bool, 10)
+	modules = append(append(append([]bool{}, quiet...), modules...), quiet...)
+
+	// Réduit la largeur de module si le code ne tient pas sur le papier
+	for moduleDots > 1 && len(modules)*moduleDots > maxLogoWidthDots(paperWidth) {
+		moduleDots--
+	}
+	w := len(modules) * moduleDots
+	bits := make([]bool, w*height)
+	for x, dark := range modules {
+		if !dark {
+			continue
+		}
+		for dx := 0; dx < moduleDots; dx++ {
+			for y := 0; y < height; y++ {
+				bits[y*w+x*moduleDots+dx] = true
+			}
+		}
+	}
+	return rasterBitsCmd(bits, w, height), nil
+}
+
+// ── CODE128 (jeu B) ─────────────────────────────────────────────────────────
+
+// Largeurs barre/espace des symboles 0..105, puis stop (106)
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const code128StartB = 104
+
+func code128Values(data string) ([]int, error) {
+	if data == "" {
+		return nil, errors.New("code-barres vide")
+	}
+	values := make([]int, 0, len(data))
+	for _, r := range data {
+		if r < 32 || r > 126 {
+			return nil, errors.New("CODE128 : caractère non imprimable " + string(r))
+		}
+		values = append(values, int(r)-32)
+	}
+	return values, nil
+}
+
+// Code128Modules : modules du code (clé et stop compris), sans marges
+func Code128Modules(data string) ([]bool, error) {
+	values, err := code128Values(data)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := code128StartB
+	for i, v := range values {
+		checksum += v * (i + 1)
+	}
+	symbols := append([]int{code128StartB}, values...)
+	symbols = append(symbols, checksum%103, 106)
+
+	var modules []bool
+	for _, s := range symbols {
+		for i, width := range code128Patterns[s] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return modules, nil
+}
+
+// ── EAN13 ───────────────────────────────────────────────────────────────────
+
+var (
+	ean13L      = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EAN13CheckDigit : clé de contrôle des 12 premiers chiffres
+func EAN13CheckDigit(digits []byte) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		if i%2 == 1 {
+			sum += int(digits[i]) * 3
+		} else {
+			sum += int(digits[i])
+		}
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+func ean13Digits(data string) ([]byte, error) {
+	data = strings.TrimSpace(data)
+	if len(data) != 12 && len(data) != 13 {
+		return nil, errors.New("EAN13 : 12 ou 13 chiffres attendus")
+	}
+	digits := make([]byte, 0, 13)
+	for _, r := range data {
+		if r < '0' || r > '9' {
+			return nil, errors.New("EAN13 : chiffres uniquement")
+		}
+		digits = append(digits, byte(r-'0'))
+	}
+	check := EAN13CheckDigit(digits)
+	if len(digits) == 13 && digits[12] != check {
+		return nil, errors.New("EAN13 : clé de contrôle invalide")
+	}
+	return append(digits[:12], check), nil
+}
+
+// EAN13Modules : modules du code (gardes comprises), sans marges
+func EAN13Modules(data string) ([]bool, error) {
+	digits, err := ean13Digits(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("101")
+	parity := ean13Parity[digits[0]]
+	for i := 1; i <= 6; i++ {
+		code := ean13L[digits[i]]
+		if parity[i-1] == 'G' {
+			code = reverseString(invertBits(code))
+		}
+		pattern.WriteString(code)
+	}
+	pattern.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		pattern.WriteString(invertBits(ean13L[digits[i]]))
+	}
+	pattern.WriteString("101")
+
+	modules := make([]bool, 0, pattern.Len())
+	for _, c := range pattern.String() {
+		modules = append(modules, c == '1')
+	}
+	return modules, nil
+}
+
+func invertBits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '1' {
+			return '0'
+		}
+		return '1'
+	}, s)
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
@@ -0,0 +1,88 @@
+package pos
+
+import (
+	"strings"
+	"testing"
+)
+
+func modulesText(modules []bool) string {
+	var b strings.Builder
+	for _, dark := range modules {
+		if dark {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// Clé EAN-13 : somme pondérée 1-3 des douze premiers chiffres.
+func TestEAN13Cle(t *testing.T) {
+	cas := []struct {
+		code string
+		cle  byte
+	}{
+		{"400638133393", 1},
+		{"590123412345", 7},
+		{"978020137962", 4},
+		{"000000000000", 0},
+	}
+	for _, c := range cas {
+		digits := make([]byte, 12)
+		for i := range digits {
+			digits[i] = c.code[i] - '0'
+		}
+		if got := EAN13CheckDigit(digits); got != c.cle {
+			t.Errorf("%s : clé %d, attendu %d", c.code, got, c.cle)
+		}
+	}
+
+	if _, err := EAN13Modules("5901234123458"); err == nil {
+		t.Error("clé fausse acceptée")
+	}
+}
+
+// Gardes, jeux L/G selon le premier chiffre, jeu R à droite : 95 modules.
+func TestEAN13Modules(t *testing.T) {
+	cas := []struct {
+		code    string
+		attendu string
+	}{
+		// 5 → LGGLLG
+		{"5901234123457", "10100010110100111011001100100110111101001110101010110011011011001000010101110010011101000100101"},
+		// 4 → LGLLGG ; la clé est calculée si elle manque
+		{"400638133393", "10100011010100111010111101111010001001011001101010100001010000101000010111010010000101100110101"},
+	}
+	for _, c := range cas {
+		modules, err := EAN13Modules(c.code)
+		if err != nil {
+			t.Fatalf("%s : %v", c.code, err)
+		}
+		if got := modulesText(modules); got != c.attendu {
+			t.Errorf("%s :\nobtenu  %s\nattendu %s", c.code, got, c.attendu)
+		}
+	}
+}
+
+// Code 128 B : départ 104, clé (104 + Σ valeur × rang) mod 103, arrêt.
+// « PJJ123C » : clé 55.
+func TestCode128Modules(t *testing.T) {
+	modules, err := Code128Modules("PJJ123C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attendu := "11010010000" + // départ B
+		"11101110110" + "10110111000" + "10110111000" + // P J J
+		"10011100110" + "11001110010" + "11001011100" + // 1 2 3
+		"10001000110" + // C
+		"11101000110" + // clé 55
+		"1100011101011" // arrêt
+	if got := modulesText(modules); got != attendu {
+		t.Errorf("PJJ123C :\nobtenu  %s\nattendu %s", got, attendu)
+	}
+
+	if _, err := Code128Modules("é"); err == nil {
+		t.Error("caractère hors du jeu B accepté")
+	}
+}
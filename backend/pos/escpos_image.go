@@ -265,6 +265,11 @@ func RasterImageCmdFromBytes(imgBytes []byte, paperWidth int, threshold uint8, d
 		return nil, errors.New("image contains no printable pixels")
 	}
 
+	return rasterBitsCmd(bits, w, h), nil
+}
+
+// rasterBitsCmd : GS v 0 pour une image 1 bit (true = noir)
+func rasterBitsCmd(bits []bool, w, h int) []byte {
 	raster := packRaster(bits, w, h)
 	rowBytes := (w + 7) / 8
 
@@ -277,7 +282,7 @@ func RasterImageCmdFromBytes(imgBytes []byte, paperWidth int, threshold uint8, d
 	cmd := make([]byte, 0, 8+len(raster))
 	cmd = append(cmd, 0x1D, 0x76, 0x30, byte(RasterNormal), xL, xH, yL, yH)
 	cmd = append(cmd, raster...)
-	return cmd, nil
+	return cmd
 }
 
 func DecodeBase64Image(b64 string) ([]byte, error) {
@@ -0,0 +1,117 @@
+// backend/pos/escpos_status.go
+// État temps réel de l'imprimante (ESC/POS DLE EOT n).
+//
+// L'imprimante répond à DLE EOT même en erreur (papier épuisé, capot
+// ouvert), sans attendre la fin du tampon : c'est ce qui permet de savoir
+// AVANT d'envoyer un ticket s'il sortira. Seuls les transports
+// bidirectionnels (TCP 9100, USB) peuvent lire la réponse ; derrière un
+// spooler ou CUPS, l'état reste inconnu.
+
+package pos
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	statusPrinter = 1 // DLE EOT 1 : état général (hors ligne, tiroir)
+	statusOffline = 2 // DLE EOT 2 : cause de la mise hors ligne (capot, fin de papier)
+	statusPaper   = 4 // DLE EOT 4 : capteurs de rouleau
+
+	defaultStatusTimeout = 2 * time.Second
+)
+
+// PrinterStatus est l'état lu sur l'imprimante
+type PrinterStatus struct {
+	Known        bool      `json:"known"` // false : transport sans retour (spooler, CUPS)
+	Online       bool      `json:"online"`
+	PaperEnd     bool      `json:"paper_end"`
+	PaperNearEnd bool      `json:"paper_near_end"`
+	CoverOpen    bool      `json:"cover_open"`
+	Error        bool      `json:"error"`
+	DrawerOpen   bool      `json:"drawer_open"`
+	Message      string    `json:"message,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Ready indique qu'un ticket envoyé maintenant sortira (ou que l'état est inconnu)
+func (s PrinterStatus) Ready() bool {
+	if !s.Known {
+		return true
+	}
+	return s.Online && !s.PaperEnd && !s.CoverOpen && !s.Error
+}
+
+// Reason résume en français pourquoi l'imprimante n'est pas prête
+func (s PrinterStatus) Reason() string {
+	switch {
+	case !s.Known || s.Ready():
+		return ""
+	case s.PaperEnd:
+		return "papier épuisé"
+	case s.CoverOpen:
+		return "capot ouvert"
+	case s.Error:
+		return "imprimante en erreur"
+	}
+	return "imprimante hors ligne"
+}
+
+// StatusReader est implémenté par les transports capables de lire l'état
+type StatusReader interface {
+	QueryStatus() (PrinterStatus, error)
+}
+
+// QueryPrinterStatus lit l'état si le transport le permet
+func QueryPrinterStatus(p Printer) (PrinterStatus, error) {
+	reader, ok := p.(StatusReader)
+	if !ok {
+		return PrinterStatus{Known: false, Message: "état non disponible pour " + p.String(), CheckedAt: time.Now()}, nil
+	}
+	return reader.QueryStatus()
+}
+
+// StatusCmd : DLE EOT n (transmission de l'état en temps réel)
+func StatusCmd(n byte) []byte { return []byte{0x10, 0x04, n} }
+
+// validStatusByte vérifie le gabarit fixe d'un octet d'état :
+// bits 0 et 7 à 0, bits 1 et 4 à 1
+func validStatusByte(b byte) bool { return b&0x93 == 0x12 }
+
+// ParsePrinterStatus décode les réponses à DLE EOT 1, 2 et 4
+func ParsePrinterStatus(printer, offline, paper byte) (PrinterStatus, error) {
+	if !validStatusByte(printer) || !validStatusByte(offline) || !validStatusByte(paper) {
+		return PrinterStatus{}, errors.New("réponse d'état ESC/POS invalide")
+	}
+
+	return PrinterStatus{
+		Known:  true,
+		Online: printer&0x08 == 0,
+		// Broche 3 du connecteur tiroir : à l'état bas, la plupart des
+		// tiroirs signalent un tiroir ouvert (polarité propre au modèle)
+		DrawerOpen:   printer&0x04 == 0,
+		CoverOpen:    offline&0x04 != 0,
+		PaperEnd:     offline&0x20 != 0 || paper&0x60 != 0,
+		Error:        offline&0x40 != 0,
+		PaperNearEnd: paper&0x0C != 0,
+		CheckedAt:    time.Now(),
+	}, nil
+}
+
+// readStatus envoie les trois requêtes et lit un octet après chacune
+func readStatus(rw io.ReadWriter) (PrinterStatus, error) {
+	var replies [3]byte
+	for i, n := range []byte{statusPrinter, statusOffline, statusPaper} {
+		if _, err := rw.Write(StatusCmd(n)); err != nil {
+			return PrinterStatus{}, err
+		}
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(rw, buf); err != nil {
+			return PrinterStatus{}, err
+		}
+		replies[i] = buf[0]
+	}
+	return ParsePrinterStatus(replies[0], replies[1], replies[2])
+}
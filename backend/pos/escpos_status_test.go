@@ -0,0 +1,97 @@
+package pos
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// Les bits de DLE EOT 1, 2 et 4 décident si un ticket part ou attend dans la
+// file : un bit mal lu envoie un ticket dans une imprimante sans papier, ou
+// bloque une imprimante prête. 0x12 est l'octet « rien à signaler ».
+func TestParsePrinterStatus(t *testing.T) {
+	cas := []struct {
+		nom                    string
+		general, cause, papier byte
+		attendu                PrinterStatus
+		raison                 string
+	}{
+		{"prête", 0x12, 0x12, 0x12,
+			PrinterStatus{Online: true, DrawerOpen: true}, ""},
+		{"tiroir fermé (broche 3 haute)", 0x16, 0x12, 0x12,
+			PrinterStatus{Online: true}, ""},
+		{"hors ligne", 0x1A, 0x12, 0x12,
+			PrinterStatus{DrawerOpen: true}, "imprimante hors ligne"},
+		{"capot ouvert", 0x1A, 0x16, 0x12,
+			PrinterStatus{CoverOpen: true, DrawerOpen: true}, "capot ouvert"},
+		{"papier presque fini : le ticket part", 0x12, 0x12, 0x1E,
+			PrinterStatus{Online: true, DrawerOpen: true, PaperNearEnd: true}, ""},
+		{"fin de papier au capteur de rouleau", 0x12, 0x12, 0x72,
+			PrinterStatus{Online: true, DrawerOpen: true, PaperEnd: true}, "papier épuisé"},
+		{"arrêt pour fin de papier", 0x1A, 0x32, 0x12,
+			PrinterStatus{DrawerOpen: true, PaperEnd: true}, "papier épuisé"},
+		{"erreur", 0x1A, 0x52, 0x12,
+			PrinterStatus{DrawerOpen: true, Error: true}, "imprimante en erreur"},
+	}
+
+	for _, c := range cas {
+		got, err := ParsePrinterStatus(c.general, c.cause, c.papier)
+		if err != nil {
+			t.Errorf("%s : %v", c.nom, err)
+			continue
+		}
+		c.attendu.Known, c.attendu.CheckedAt = true, got.CheckedAt
+		if got != c.attendu {
+			t.Errorf("%s : obtenu %+v, attendu %+v", c.nom, got, c.attendu)
+		}
+		if got.Reason() != c.raison || got.Ready() != (c.raison == "") {
+			t.Errorf("%s : raison %q / prête %v", c.nom, got.Reason(), got.Ready())
+		}
+	}
+}
+
+// Un octet hors gabarit (bits 0 et 7 à 0, bits 1 et 4 à 1) n'est pas une
+// réponse d'état : c'est du bruit, ou une imprimante qui ne parle pas ESC/POS.
+func TestParsePrinterStatusRefuseUnOctetHorsGabarit(t *testing.T) {
+	cas := []struct {
+		nom                    string
+		general, cause, papier byte
+	}{
+		{"zéro", 0x00, 0x12, 0x12},
+		{"bit 7 levé", 0x92, 0x12, 0x12},
+		{"bit 0 levé sur la cause", 0x12, 0x13, 0x12},
+		{"bit 4 absent sur le papier", 0x12, 0x12, 0x02},
+		{"ASCII", 'A', 'A', 'A'},
+	}
+	for _, c := range cas {
+		if _, err := ParsePrinterStatus(c.general, c.cause, c.papier); err == nil {
+			t.Errorf("%s : accepté", c.nom)
+		}
+	}
+}
+
+// Les trois requêtes partent dans l'ordre 1, 2, 4, un octet lu après chacune ;
+// une réponse courte est une erreur, pas un état.
+func TestReadStatus(t *testing.T) {
+	var envoye bytes.Buffer
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader([]byte{0x12, 0x16, 0x12}), &envoye}
+
+	status, err := readStatus(rw)
+	if err != nil || !status.CoverOpen {
+		t.Fatalf("état %+v, erreur %v", status, err)
+	}
+	if want := []byte{0x10, 0x04, 1, 0x10, 0x04, 2, 0x10, 0x04, 4}; !bytes.Equal(envoye.Bytes(), want) {
+		t.Errorf("requêtes % x, attendu % x", envoye.Bytes(), want)
+	}
+
+	court := struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader([]byte{0x12}), &bytes.Buffer{}}
+	if _, err := readStatus(court); err == nil {
+		t.Errorf("réponse courte acceptée")
+	}
+}
@@ -0,0 +1,36 @@
+package pos
+
+import (
+	"strings"
+	"testing"
+)
+
+// Un article vendu au poids ou au mètre garde ses décimales sur le papier :
+// arrondi à l'unité, le ticket ne correspondrait plus au total.
+func TestQuantiteImprimee(t *testing.T) {
+	cas := []struct {
+		quantite float64
+		attendu  string
+	}{
+		{2, "2"},
+		{0.5, "0.5"},
+		{1.25, "1.25"},
+		{0.3333333, "0.333"},
+		{12.5, "12.5"},
+	}
+	for _, c := range cas {
+		if got := formatQty(c.quantite); got != c.attendu {
+			t.Errorf("formatQty(%v) = %q, attendu %q", c.quantite, got, c.attendu)
+		}
+	}
+
+	raw := string(BuildReceipt(ReceiptData{
+		InvoiceNumber: "TIK-2026-000001",
+		Items:         []ReceiptItem{{Name: "Corde au mètre", Qty: 1.5, UnitTtc: 2, TotalTtc: 3, TvaRate: 20}},
+		TotalTtc:      3,
+		Width:         58,
+	}))
+	if !strings.Contains(raw, "1.5x 2.00EUR") {
+		t.Errorf("ligne de quantité décimale absente du ticket")
+	}
+}
@@ -0,0 +1,340 @@
+// backend/pos/labels.go
+// Étiquettes produit (prix en rayon) rendues depuis le catalogue :
+//   - ZPL pour les imprimantes Zebra, EPL pour les anciennes Zebra/Eltron
+//   - ESC/POS sur l'imprimante ticket (une étiquette par coupe)
+//   - planche A4 (HTML → PDF) pour les feuilles d'étiquettes autocollantes
+//
+// Le code-barres est un EAN13 quand le code produit en est un, un CODE128 sinon.
+package pos
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Formats de modèle (label_templates.format)
+const (
+	LabelFormatZPL    = "zpl"
+	LabelFormatEPL    = "epl"
+	LabelFormatESCPOS = "escpos"
+	LabelFormatSheet  = "sheet"
+)
+
+// LabelData : un produit à étiqueter
+type LabelData struct {
+	Name     string  `json:"name"`
+	Brand    string  `json:"brand"`
+	Sku      string  `json:"sku"`
+	Barcode  string  `json:"barcode"`
+	PriceTtc float64 `json:"price_ttc"`
+}
+
+// LabelTemplate : dimensions et champs d'une étiquette (voir label_templates)
+type LabelTemplate struct {
+	Name         string   `json:"name"`
+	Format       string   `json:"format"`
+	WidthMM      float64  `json:"width_mm"`
+	HeightMM     float64  `json:"height_mm"`
+	Dpi          int      `json:"dpi"`
+	Columns      int      `json:"columns"`
+	Rows         int      `json:"rows"`
+	MarginTopMM  float64  `json:"margin_top_mm"`
+	MarginLeftMM float64  `json:"margin_left_mm"`
+	GapXMM       float64  `json:"gap_x_mm"`
+	GapYMM       float64  `json:"gap_y_mm"`
+	Fields       []string `json:"fields"`
+}
+
+// WithDefaults complète un modèle incomplet : 50×30 mm à 203 dpi, planche
+// A4 de 3×8 étiquettes de 70×37 mm
+func (t LabelTemplate) WithDefaults() LabelTemplate {
+	if t.Format == "" {
+		t.Format = LabelFormatZPL
+	}
+	if t.WidthMM <= 0 || t.HeightMM <= 0 {
+		t.WidthMM, t.HeightMM = 50, 30
+		if t.Format == LabelFormatSheet {
+			t.WidthMM, t.HeightMM = 70, 37
+		}
+	}
+	if t.Dpi != 300 {
+		t.Dpi = 203
+	}
+	if t.Columns <= 0 {
+		t.Columns = max(1, int((210-2*t.MarginLeftMM+t.GapXMM)/(t.WidthMM+t.GapXMM)))
+	}
+	if t.Rows <= 0 {
+		t.Rows = max(1, int((297-2*t.MarginTopMM+t.GapYMM)/(t.HeightMM+t.GapYMM)))
+	}
+	if len(t.Fields) == 0 {
+		t.Fields = []string{"name", "brand", "sku", "barcode"}
+	}
+	return t
+}
+
+func (t LabelTemplate) show(field string) bool {
+	for _, f := range t.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (t LabelTemplate) dots(mm float64) int {
+	return int(math.Round(mm * float64(t.Dpi) / 25.4))
+}
+
+// labelPrice : "24,90 EUR" (les polices d'imprimante n'ont pas toutes le €)
+func labelPrice(v float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", v), ".", ",", 1) + " EUR"
+}
+
+// labelSubline : marque et référence sur une ligne
+func labelSubline(t LabelTemplate, l LabelData) string {
+	var parts []string
+	if t.show("brand") && strings.TrimSpace(l.Brand) != "" {
+		parts = append(parts, strings.TrimSpace(l.Brand))
+	}
+	if t.show("sku") && strings.TrimSpace(l.Sku) != "" {
+		parts = append(parts, "Ref "+strings.TrimSpace(l.Sku))
+	}
+	return strings.Join(parts, " - ")
+}
+
+// isEAN13 : 12 chiffres ou 13 avec une clé valide
+func isEAN13(code string) bool {
+	_, err := ean13Digits(code)
+	return err == nil
+}
+
+// LabelBarcodeModules : EAN13 si possible, CODE128 sinon
+func LabelBarcodeModules(code string) ([]bool, error) {
+	if isEAN13(code) {
+		return EAN13Modules(code)
+	}
+	return Code128Modules(code)
+}
+
+// ── ZPL ─────────────────────────────────────────────────────────────────────
+
+func zplText(s string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(strings.TrimSpace(s))
+}
+
+// BuildLabelsZPL : un bloc ^XA…^XZ par produit, copies via ^PQ
+func BuildLabelsZPL(t LabelTemplate, labels []LabelData, copies int) []byte {
+	t = t.WithDefaults()
+	copies = max(copies, 1)
+	w, h, m := t.dots(t.WidthMM), t.dots(t.HeightMM), t.dots(2)
+
+	var b bytes.Buffer
+	for _, l := range labels {
+		y := m
+		fmt.Fprintf(&b, "^XA^CI28^PW%d^LL%d\n", w, h)
+
+		if t.show("name") {
+			fh := t.dots(3)
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FB%d,2,0,L^FD%s^FS\n", m, y, fh, fh, w-2*m, zplText(l.Name))
+			y += 2*fh + t.dots(0.5)
+		}
+		if sub := labelSubline(t, l); sub != "" {
+			fh := t.dots(2.2)
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", m, y, fh, fh, zplText(sub))
+			y += fh + t.dots(0.5)
+		}
+
+		ph := t.dots(6)
+		fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", m, y, ph, ph, labelPrice(l.PriceTtc))
+		y += ph + t.dots(1)
+
+		if code := strings.TrimSpace(l.Barcode); t.show("barcode") && code != "" {
+			bh := h - y - m - t.dots(3) // place pour le texte lisible
+			if bh >= t.dots(4) {
+				if isEAN13(code) {
+					fmt.Fprintf(&b, "^FO%d,%d^BY2^BEN,%d,Y,N^FD%s^FS\n", m, y, bh, code[:12])
+				} else {
+					fmt.Fprintf(&b, "^FO%d,%d^BY2^BCN,%d,Y,N,N^FD%s^FS\n", m, y, bh, zplText(code))
+				}
+			}
+		}
+
+		fmt.Fprintf(&b, "^PQ%d\n^XZ\n", copies)
+	}
+	return b.Bytes()
+}
+
+// ── EPL ─────────────────────────────────────────────────────────────────────
+
+func eplText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(strings.TrimSpace(s))
+}
+
+// BuildLabelsEPL : EPL2, une étiquette par produit, copies via P
+func BuildLabelsEPL(t LabelTemplate, labels []LabelData, copies int) []byte {
+	t = t.WithDefaults()
+	copies = max(copies, 1)
+	w, h, m := t.dots(t.WidthMM), t.dots(t.HeightMM), t.dots(2)
+
+	var b bytes.Buffer
+	for _, l := range labels {
+		y := m
+		fmt.Fprintf(&b, "\nN\nq%d\nQ%d,24\n", w, h)
+
+		if t.show("name") {
+			fmt.Fprintf(&b, "A%d,%d,0,3,1,1,N,\"%s\"\n", m, y, eplText(l.Name))
+			y += t.dots(3.5)
+		}
+		if sub := labelSubline(t, l); sub != "" {
+			fmt.Fprintf(&b, "A%d,%d,0,2,1,1,N,\"%s\"\n", m, y, eplText(sub))
+			y += t.dots(2.5)
+		}
+
+		fmt.Fprintf(&b, "A%d,%d,0,4,2,2,N,\"%s\"\n", m, y, labelPrice(l.PriceTtc))
+		y += t.dots(6.5)
+
+		if code := strings.TrimSpace(l.Barcode); t.show("barcode") && code != "" {
+			bh := h - y - m - t.dots(3)
+			if bh >= t.dots(4) {
+				if isEAN13(code) {
+					fmt.Fprintf(&b, "B%d,%d,0,E30,2,4,%d,B,\"%s\"\n", m, y, bh, code[:12])
+				} else {
+					fmt.Fprintf(&b, "B%d,%d,0,1,2,4,%d,B,\"%s\"\n", m, y, bh, eplText(code))
+				}
+			}
+		}
+
+		fmt.Fprintf(&b, "P%d\n", copies)
+	}
+	return b.Bytes()
+}
+
+// ── ESC/POS ─────────────────────────────────────────────────────────────────
+
+// BuildLabelsESCPOS : étiquettes sur l'imprimante ticket, une coupe par
+// étiquette ; raster pour les imprimantes sans GS k
+func BuildLabelsESCPOS(t LabelTemplate, labels []LabelData, copies, paperWidth int, raster bool) []byte {
+	t = t.WithDefaults()
+	copies = max(copies, 1)
+
+	var b bytes.Buffer
+	b.Write(InitCmd())
+	b.Write([]byte{0x1B, 0x74, 0x02})
+
+	for _, l := range labels {
+		for c := 0; c < copies; c++ {
+			b.Write(AlignCenter())
+			if t.show("name") {
+				b.Write(BoldOn())
+				b.Write(Text(strings.TrimSpace(l.Name)))
+				b.Write(BoldOff())
+				b.Write(NL())
+			}
+			if sub := labelSubline(t, l); sub != "" {
+				b.Write(SmallTextOn())
+				b.Write(Text(sub))
+				b.Write(SmallTextOff())
+				b.Write(NL())
+			}
+
+			b.Write(DoubleSizeOn())
+			b.Write(Text(labelPrice(l.PriceTtc)))
+			b.Write(SmallTextOff())
+			b.Write(NL())
+
+			if code := strings.TrimSpace(l.Barcode); t.show("barcode") && code != "" {
+				var cmd []byte
+				switch {
+				case raster:
+					if modules, err := LabelBarcodeModules(code); err == nil {
+						cmd, _ = BarcodeRaster(modules, 2, 60, paperWidth)
+						cmd = append(cmd, NL()...)
+						cmd = append(cmd, Text(code)...)
+					}
+				case isEAN13(code):
+					cmd, _ = EAN13Cmd(code, 60)
+				default:
+					cmd, _ = Code128Cmd(code, 60)
+				}
+				b.Write(cmd)
+				b.Write(NL())
+			}
+
+			b.Write(CutCmdFeed(3))
+		}
+	}
+	return b.Bytes()
+}
+
+// ── Planche A4 ──────────────────────────────────────────────────────────────
+
+// BuildLabelSheetHTML : planches A4 (autant de pages que nécessaire)
+func BuildLabelSheetHTML(t LabelTemplate, labels []LabelData, copies int) string {
+	t = t.WithDefaults()
+	copies = max(copies, 1)
+	perPage := t.Columns * t.Rows
+
+	var cells []string
+	for _, l := range labels {
+		cell := labelSheetCell(t, l)
+		for c := 0; c < copies; c++ {
+			cells = append(cells, cell)
+		}
+	}
+
+	var pages strings.Builder
+	for start := 0; start < len(cells); start += perPage {
+		end := min(start+perPage, len(cells))
+		pages.WriteString(`<div class="page">`)
+		for i, cell := range cells[start:end] {
+			col, row := i%t.Columns, i/t.Columns
+			fmt.Fprintf(&pages, `<div class="label" style="left:%.2fmm;top:%.2fmm">%s</div>`,
+				t.MarginLeftMM+float64(col)*(t.WidthMM+t.GapXMM),
+				t.MarginTopMM+float64(row)*(t.HeightMM+t.GapYMM),
+				cell)
+		}
+		pages.WriteString(`</div>`)
+	}
+
+	return fmt.Sprintf(`<!doctype html><html><head><meta charset="utf-8"/>
+<title>Étiquettes</title>
+<style>
+  @page { size: A4; margin: 0; }
+  body { margin: 0; font-family: Arial, Helvetica, sans-serif; }
+  .page { position: relative; width: 210mm; height: 297mm; page-break-after: always; overflow: hidden; }
+  .label { position: absolute; width: %.2fmm; height: %.2fmm; box-sizing: border-box; padding: 2mm;
+           display: flex; flex-direction: column; justify-content: space-between; overflow: hidden; }
+  .name { font-size: 9pt; font-weight: bold; line-height: 1.15; max-height: 2.3em; overflow: hidden; }
+  .sub { font-size: 7pt; color: #333; }
+  .price { font-size: 18pt; font-weight: bold; }
+  .code { display: block; height: 9mm; max-width: 100%%; image-rendering: pixelated; }
+  .code-text { font-size: 7pt; letter-spacing: 1px; }
+</style>
+</head><body>%s</body></html>`, t.WidthMM, t.HeightMM, pages.String())
+}
+
+func labelSheetCell(t LabelTemplate, l LabelData) string {
+	var c strings.Builder
+	if t.show("name") {
+		c.WriteString(`<div class="name">` + html.EscapeString(strings.TrimSpace(l.Name)) + `</div>`)
+	}
+	if sub := labelSubline(t, l); sub != "" {
+		c.WriteString(`<div class="sub">` + html.EscapeString(sub) + `</div>`)
+	}
+	c.WriteString(`<div class="price">` + strings.Replace(fmt.Sprintf("%.2f", l.PriceTtc), ".", ",", 1) + ` €</div>`)
+	if code := strings.TrimSpace(l.Barcode); t.show("barcode") && code != "" {
+		if modules, err := LabelBarcodeModules(code); err == nil {
+			c.WriteString(`<div><img class="code" src="` + barcodePNGDataURL(modules) + `" alt=""><div class="code-text">` + html.EscapeString(code) + `</div></div>`)
+		}
+	}
+	return c.String()
+}
+
+// BuildLabelSheetPDF : planches A4 en PDF, à l'échelle 1 (les dimensions
+// des étiquettes doivent tomber sur celles de la feuille)
+func BuildLabelSheetPDF(t LabelTemplate, labels []LabelData, copies int) ([]byte, error) {
+	return renderHTMLPDF(BuildLabelSheetHTML(t, labels, copies), 1, 0)
+}
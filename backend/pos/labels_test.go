@@ -0,0 +1,137 @@
+package pos
+
+import (
+	"strings"
+	"testing"
+)
+
+// Deux produits qui couvrent les pièges : un EAN13 valide, des caractères
+// de commande ZPL dans le nom, des guillemets et une barre oblique pour EPL,
+// un code interne qui n'est pas un EAN.
+var etiquettesDeTest = []LabelData{
+	{Name: "Ampli ^XA~JA", Brand: "Fender", Sku: "FR-1", Barcode: "4006381333931", PriceTtc: 249.9},
+	{Name: `Câble "jack"`, Sku: `C\1`, Barcode: "CAB-3M", PriceTtc: 12},
+}
+
+// Le ZPL attendu octet pour octet : `^` et `~` d'un nom ne doivent pas
+// devenir des commandes (^XA ouvrirait une étiquette, ~JA viderait la file).
+func TestEtiquettesZPL(t *testing.T) {
+	attendu := "^XA^CI28^PW400^LL240\n" +
+		"^FO16,16^A0N,24,24^FB368,2,0,L^FDAmpli  XA JA^FS\n" +
+		"^FO16,68^A0N,18,18^FDFender - Ref FR-1^FS\n" +
+		"^FO16,90^A0N,48,48^FD249,90 EUR^FS\n" +
+		"^FO16,146^BY2^BEN,54,Y,N^FD400638133393^FS\n" +
+		"^PQ2\n^XZ\n" +
+		"^XA^CI28^PW400^LL240\n" +
+		"^FO16,16^A0N,24,24^FB368,2,0,L^FDCâble \"jack\"^FS\n" +
+		"^FO16,68^A0N,18,18^FDRef C\\1^FS\n" +
+		"^FO16,90^A0N,48,48^FD12,00 EUR^FS\n" +
+		"^FO16,146^BY2^BCN,54,Y,N,N^FDCAB-3M^FS\n" +
+		"^PQ2\n^XZ\n"
+
+	if got := string(BuildLabelsZPL(LabelTemplate{}, etiquettesDeTest, 2)); got != attendu {
+		t.Errorf("ZPL :\n%q\nattendu :\n%q", got, attendu)
+	}
+}
+
+// En EPL, le texte est entre guillemets : un guillemet ou une barre oblique
+// non échappés couperaient le champ et la suite partirait en commande.
+func TestEtiquettesEPL(t *testing.T) {
+	attendu := "\nN\nq400\nQ240,24\n" +
+		"A16,16,0,3,1,1,N,\"Ampli ^XA~JA\"\n" +
+		"A16,44,0,2,1,1,N,\"Fender - Ref FR-1\"\n" +
+		"A16,64,0,4,2,2,N,\"249,90 EUR\"\n" +
+		"B16,116,0,E30,2,4,84,B,\"400638133393\"\n" +
+		"P1\n" +
+		"\nN\nq400\nQ240,24\n" +
+		"A16,16,0,3,1,1,N,\"Câble \\\"jack\\\"\"\n" +
+		"A16,44,0,2,1,1,N,\"Ref C\\\\1\"\n" +
+		"A16,64,0,4,2,2,N,\"12,00 EUR\"\n" +
+		"B16,116,0,1,2,4,84,B,\"CAB-3M\"\n" +
+		"P1\n"
+
+	if got := string(BuildLabelsEPL(LabelTemplate{Format: LabelFormatEPL}, etiquettesDeTest, 0)); got != attendu {
+		t.Errorf("EPL :\n%q\nattendu :\n%q", got, attendu)
+	}
+}
+
+// EAN13 seulement si la clé est bonne ; sinon CODE128, qui encode tout.
+func TestSymbologieDesEtiquettes(t *testing.T) {
+	cas := []struct {
+		nom, code, zpl, epl string
+	}{
+		{"EAN13 valide, clé retirée", "4006381333931", "^BEN,54,Y,N^FD400638133393^FS", `E30,2,4,84,B,"400638133393"`},
+		{"douze chiffres, clé calculée", "400638133393", "^BEN,54,Y,N^FD400638133393^FS", `E30,2,4,84,B,"400638133393"`},
+		{"clé fausse : CODE128", "4006381333932", "^BCN,54,Y,N,N^FD4006381333932^FS", `,1,2,4,84,B,"4006381333932"`},
+		{"code interne : CODE128", "CAB-3M", "^BCN,54,Y,N,N^FDCAB-3M^FS", `,1,2,4,84,B,"CAB-3M"`},
+	}
+	for _, c := range cas {
+		l := []LabelData{{Name: "X", Sku: "R", Barcode: c.code}}
+		if got := string(BuildLabelsZPL(LabelTemplate{}, l, 1)); !strings.Contains(got, c.zpl) {
+			t.Errorf("%s : ZPL sans %q\n%s", c.nom, c.zpl, got)
+		}
+		if got := string(BuildLabelsEPL(LabelTemplate{}, l, 1)); !strings.Contains(got, c.epl) {
+			t.Errorf("%s : EPL sans %q\n%s", c.nom, c.epl, got)
+		}
+	}
+}
+
+// Sur l'imprimante ticket : texte en CP850 (â → 0x83), code-barres natif
+// GS k selon la symbologie, une coupe par étiquette.
+func TestEtiquettesESCPOS(t *testing.T) {
+	attendu := "\x1b@\x1bt\x02" +
+		"\x1ba\x01\x1bE\x01C\x83ble \"jack\"\x1bE\x00\n" +
+		"\x1b!\x01Ref C\\1\x1b!\x00\n" +
+		"\x1b!012,00 EUR\x1b!\x00\n" +
+		"\x1dh<\x1dw\x02\x1dH\x02\x1df\x00\x1dkI\b{BCAB-3M\n" +
+		"\x1dVB\x03"
+	if got := string(BuildLabelsESCPOS(LabelTemplate{}, etiquettesDeTest[1:], 1, 58, false)); got != attendu {
+		t.Errorf("ESC/POS :\n%q\nattendu :\n%q", got, attendu)
+	}
+
+	ean := string(BuildLabelsESCPOS(LabelTemplate{}, etiquettesDeTest[:1], 2, 58, false))
+	if !strings.Contains(ean, "\x1dkC\r4006381333931") {
+		t.Errorf("EAN13 natif absent :\n%q", ean)
+	}
+	if n := strings.Count(ean, "\x1dVB\x03"); n != 2 {
+		t.Errorf("%d coupe(s) pour deux copies", n)
+	}
+}
+
+// Planche A4 de 3×8 : 25 étiquettes font deux pages, la 25e en haut à gauche
+// de la seconde ; la 4e ouvre la deuxième rangée.
+func TestPlancheA4(t *testing.T) {
+	tpl := LabelTemplate{Format: LabelFormatSheet, MarginTopMM: 10, MarginLeftMM: 5, GapXMM: 2, GapYMM: 1, Columns: 3, Rows: 8}
+	var produits []LabelData
+	for i := 0; i < 25; i++ {
+		produits = append(produits, LabelData{Name: "Médiator", PriceTtc: 1})
+	}
+
+	sheet := BuildLabelSheetHTML(tpl, produits, 1)
+	pages := strings.Split(sheet, `<div class="page">`)[1:]
+	if len(pages) != 2 {
+		t.Fatalf("%d page(s), attendu 2", len(pages))
+	}
+
+	cas := []struct {
+		nom            string
+		page, cellules int
+		position       string
+	}{
+		{"première page pleine", 0, 24, `style="left:5.00mm;top:48.00mm"`}, // 4e : colonne 0, rangée 1
+		{"la 25e seule en haut à gauche", 1, 1, `style="left:5.00mm;top:10.00mm"`},
+	}
+	for _, c := range cas {
+		if n := strings.Count(pages[c.page], `class="label"`); n != c.cellules {
+			t.Errorf("%s : %d étiquette(s)", c.nom, n)
+		}
+		if !strings.Contains(pages[c.page], c.position) {
+			t.Errorf("%s : position %s absente", c.nom, c.position)
+		}
+	}
+
+	if got := BuildLabelSheetHTML(tpl, produits[:2], 12); strings.Count(got, `class="label"`) != 24 ||
+		strings.Count(got, `<div class="page">`) != 1 {
+		t.Errorf("deux produits en douze copies : une page de 24 attendue")
+	}
+}
@@ -0,0 +1,277 @@
+// backend/pos/printer.go
+// Transports d'impression brute (ESC/POS).
+//
+// Le ticket, le tiroir et le test sont des octets ESC/POS ; seul le chemin
+// jusqu'à l'imprimante change selon le poste :
+//   - spooler : file d'impression du système (winspool sous Windows, CUPS ailleurs)
+//   - tcp     : imprimante Ethernet en RAW sur le port 9100
+//   - cups    : file CUPS en raw (`lp -o raw`), Linux / macOS / Docker
+//   - usb     : fichier de périphérique (/dev/usb/lp0)
+//
+// Le transport se choisit par caisse dans cash_registers.settings.printer ;
+// un poste ne peut nommer qu'une file du système (voir PrinterFromName) :
+// une adresse réseau ou un fichier venus d'une requête ouvriraient le
+// serveur à n'importe quelle cible.
+
+package pos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	TransportSpooler = "spooler"
+	TransportTCP     = "tcp"
+	TransportCUPS    = "cups"
+	TransportUSB     = "usb"
+
+	defaultRawPort       = "9100"
+	defaultPrintTimeout  = 5 * time.Second
+	maxPrinterTimeoutSec = 60
+)
+
+// usbDevicePattern : les seuls fichiers qu'une caisse peut désigner comme
+// imprimante USB (usblp sous Linux)
+var usbDevicePattern = regexp.MustCompile(`^/dev/(usb/)?lp[0-9]+$`)
+
+// Printer envoie des octets bruts à une imprimante
+type Printer interface {
+	Write(data []byte) error
+	// String décrit la cible pour les logs et les messages d'erreur
+	String() string
+}
+
+// PrinterConfig est la configuration d'imprimante d'une caisse
+// (cash_registers.settings.printer)
+type PrinterConfig struct {
+	Transport string `json:"transport"`         // spooler | tcp | cups | usb
+	Name      string `json:"name,omitempty"`    // file Windows ou CUPS
+	Address   string `json:"address,omitempty"` // tcp : hôte[:port], port 9100 par défaut
+	Device    string `json:"device,omitempty"`  // usb : /dev/usb/lp0
+	Width     int    `json:"width,omitempty"`   // 58 ou 80 (optionnel)
+	TimeoutS  int    `json:"timeout_s,omitempty"`
+
+	// QR et codes-barres en image : pour les imprimantes sans GS ( k / GS k
+	RasterCodes bool `json:"raster_codes,omitempty"`
+}
+
+// IsZero indique qu'aucune imprimante n'est configurée
+func (c PrinterConfig) IsZero() bool {
+	return c.Transport == "" && c.Name == "" && c.Address == "" && c.Device == ""
+}
+
+func (c PrinterConfig) timeout() time.Duration {
+	if c.TimeoutS > 0 && c.TimeoutS <= maxPrinterTimeoutSec {
+		return time.Duration(c.TimeoutS) * time.Second
+	}
+	return defaultPrintTimeout
+}
+
+// NewPrinter construit le transport décrit par la configuration
+func NewPrinter(cfg PrinterConfig) (Printer, error) {
+	transport := strings.ToLower(strings.TrimSpace(cfg.Transport))
+	if transport == "" {
+		transport = TransportSpooler
+	}
+
+	switch transport {
+	case TransportSpooler:
+		if cfg.Name == "" {
+			return nil, errors.New("imprimante spooler : nom de la file requis")
+		}
+		return &spoolerPrinter{name: cfg.Name}, nil
+
+	case TransportTCP:
+		if cfg.Address == "" {
+			return nil, errors.New("imprimante réseau : adresse requise")
+		}
+		addr := cfg.Address
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, defaultRawPort)
+		}
+		return &tcpPrinter{addr: addr, timeout: cfg.timeout()}, nil
+
+	case TransportCUPS:
+		if cfg.Name == "" {
+			return nil, errors.New("imprimante CUPS : nom de la file requis")
+		}
+		return &cupsPrinter{queue: cfg.Name, timeout: cfg.timeout()}, nil
+
+	case TransportUSB:
+		if cfg.Device == "" {
+			return nil, errors.New("imprimante USB : fichier de périphérique requis")
+		}
+		device := filepath.Clean(cfg.Device)
+		if !usbDevicePattern.MatchString(device) {
+			return nil, fmt.Errorf("imprimante USB : %q n'est pas un périphérique d'impression (/dev/usb/lpN)", cfg.Device)
+		}
+		return &usbPrinter{device: device}, nil
+	}
+
+	return nil, fmt.Errorf("transport d'imprimante inconnu : %q", cfg.Transport)
+}
+
+// PrinterFromName interprète le nom d'imprimante envoyé par un poste : une
+// file du système (spooler), rien d'autre. Les transports réseau, CUPS et USB
+// se configurent sur la caisse (cash_registers.settings.printer).
+func PrinterFromName(name string) (Printer, error) {
+	name = strings.TrimSpace(name)
+	if strings.Contains(name, "://") {
+		return nil, fmt.Errorf("imprimante %q : transport réservé à la configuration de la caisse (settings.printer)", name)
+	}
+	if strings.HasPrefix(name, "-") {
+		return nil, fmt.Errorf("nom d'imprimante invalide : %q", name)
+	}
+	return NewPrinter(PrinterConfig{Transport: TransportSpooler, Name: name})
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Spooler système
+// ─────────────────────────────────────────────────────────────────────────────
+
+type spoolerPrinter struct{ name string }
+
+func (p *spoolerPrinter) Write(data []byte) error { return RawPrint(p.name, data) }
+func (p *spoolerPrinter) String() string          { return "spooler:" + p.name }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// TCP 9100 (RAW / JetDirect)
+// ─────────────────────────────────────────────────────────────────────────────
+
+type tcpPrinter struct {
+	addr    string
+	timeout time.Duration
+}
+
+func (p *tcpPrinter) Write(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", p.addr, p.timeout)
+	if err != nil {
+		return fmt.Errorf("imprimante %s injoignable : %w", p.addr, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(p.timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("envoi à %s : %w", p.addr, err)
+	}
+	return nil
+}
+
+func (p *tcpPrinter) String() string { return "tcp://" + p.addr }
+
+// QueryStatus : une imprimante injoignable est rapportée hors ligne
+func (p *tcpPrinter) QueryStatus() (PrinterStatus, error) {
+	conn, err := net.DialTimeout("tcp", p.addr, defaultStatusTimeout)
+	if err != nil {
+		return PrinterStatus{Known: true, Online: false, Message: err.Error(), CheckedAt: time.Now()}, nil
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(defaultStatusTimeout)); err != nil {
+		return PrinterStatus{}, err
+	}
+	return readStatus(conn)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CUPS (file raw)
+// ─────────────────────────────────────────────────────────────────────────────
+
+type cupsPrinter struct {
+	queue   string
+	timeout time.Duration
+}
+
+func (p *cupsPrinter) Write(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return cupsRawPrint(p.queue, data, p.timeout)
+}
+
+func (p *cupsPrinter) String() string { return "cups://" + p.queue }
+
+// cupsRawPrint passe les octets tels quels à `lp` (aucun filtre CUPS)
+func cupsRawPrint(queue string, data []byte, timeout time.Duration) error {
+	if queue == "" {
+		return errors.New("file CUPS requise")
+	}
+
+	cmd := exec.Command("lp", "-d", queue, "-o", "raw", "-s")
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("lp indisponible : %w", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("lp -d %s : %s", queue, msg)
+			}
+			return fmt.Errorf("lp -d %s : %w", queue, err)
+		}
+		return nil
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("lp -d %s : délai dépassé", queue)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// USB (fichier de périphérique, usblp sous Linux)
+// ─────────────────────────────────────────────────────────────────────────────
+
+type usbPrinter struct{ device string }
+
+func (p *usbPrinter) Write(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(p.device, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("périphérique %s : %w", p.device, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("écriture sur %s : %w", p.device, err)
+	}
+	return nil
+}
+
+func (p *usbPrinter) String() string { return "usb://" + p.device }
+
+// QueryStatus : usblp accepte la lecture, mais sans délai possible sur le
+// fichier de périphérique l'état reste inconnu plutôt que de bloquer
+func (p *usbPrinter) QueryStatus() (PrinterStatus, error) {
+	f, err := os.OpenFile(p.device, os.O_RDWR, 0)
+	if err != nil {
+		return PrinterStatus{Known: true, Online: false, Message: err.Error(), CheckedAt: time.Now()}, nil
+	}
+	defer f.Close()
+
+	if err := f.SetDeadline(time.Now().Add(defaultStatusTimeout)); err != nil {
+		return PrinterStatus{Known: false, Message: "lecture d'état non supportée sur " + p.device, CheckedAt: time.Now()}, nil
+	}
+	return readStatus(f)
+}
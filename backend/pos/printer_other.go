@@ -0,0 +1,37 @@
+// backend/pos/printer_other.go
+//go:build !windows
+
+package pos
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ListPrinters retourne les files CUPS déclarées (`lpstat -a`).
+// Sans CUPS installé, la liste est vide : les imprimantes réseau et USB se
+// configurent par caisse, elles n'ont pas besoin d'être listées.
+func ListPrinters() ([]string, error) {
+	out, err := exec.Command("lpstat", "-a").Output()
+	if err != nil {
+		return []string{}, nil
+	}
+
+	seen := map[string]struct{}{}
+	printers := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, ok := seen[fields[0]]; ok {
+			continue
+		}
+		seen[fields[0]] = struct{}{}
+		printers = append(printers, fields[0])
+	}
+
+	sort.Strings(printers)
+	return printers, nil
+}
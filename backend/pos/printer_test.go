@@ -0,0 +1,69 @@
+package pos
+
+import "testing"
+
+// Le nom envoyé par un poste ne désigne qu'une file du système : un poste ne
+// doit pas pouvoir viser une adresse réseau, une file CUPS ou un fichier de
+// périphérique, ni glisser une option à la commande d'impression.
+func TestPrinterFromName(t *testing.T) {
+	cas := []struct {
+		nom, imprimante string
+		attendu         string // vide : refusé
+	}{
+		{"file du système", "EPSON TM-T20", "spooler:EPSON TM-T20"},
+		{"espaces autour retirés", "  Caisse 1  ", "spooler:Caisse 1"},
+		{"un chemin reste un nom de file", "../../etc/passwd", "spooler:../../etc/passwd"},
+		{"adresse réseau", "tcp://zebra:9100", ""},
+		{"file CUPS", "cups://ticket", ""},
+		{"périphérique USB", "usb:///dev/usb/lp0", ""},
+		{"schéma déguisé", " tcp://10.0.0.5 ", ""},
+		{"option de commande", "-o raw", ""},
+		{"nom vide", "   ", ""},
+	}
+	for _, c := range cas {
+		p, err := PrinterFromName(c.imprimante)
+		switch {
+		case c.attendu == "" && err == nil:
+			t.Errorf("%s : %q accepté (%s)", c.nom, c.imprimante, p)
+		case c.attendu != "" && err != nil:
+			t.Errorf("%s : %q refusé : %v", c.nom, c.imprimante, err)
+		case c.attendu != "" && p.String() != c.attendu:
+			t.Errorf("%s : %s, attendu %s", c.nom, p, c.attendu)
+		}
+	}
+}
+
+// Une caisse ne peut désigner comme imprimante USB que /dev/lpN ou
+// /dev/usb/lpN, après nettoyage du chemin : ni un disque, ni un port série,
+// ni un fichier atteint par « .. ».
+func TestPeripheriqueUSB(t *testing.T) {
+	cas := []struct {
+		nom, fichier string
+		attendu      string // vide : refusé
+	}{
+		{"imprimante USB", "/dev/usb/lp0", "usb:///dev/usb/lp0"},
+		{"port parallèle", "/dev/lp1", "usb:///dev/lp1"},
+		{"plusieurs chiffres", "/dev/usb/lp12", "usb:///dev/usb/lp12"},
+		{"chemin nettoyé vers un lp", "/dev/usb/../lp0", "usb:///dev/lp0"},
+		{"remontée vers /etc", "/dev/usb/../../etc/passwd", ""},
+		{"remontée vers un disque", "/dev/usb/lp0/../../sda", ""},
+		{"disque", "/dev/sda", ""},
+		{"port série", "/dev/ttyUSB0", ""},
+		{"autre périphérique USB", "/dev/usb/hiddev0", ""},
+		{"lp sans numéro", "/dev/lp", ""},
+		{"suffixe", "/dev/usb/lp0x", ""},
+		{"retour à la ligne", "/dev/lp0\n", ""},
+		{"chemin relatif", "dev/usb/lp0", ""},
+	}
+	for _, c := range cas {
+		p, err := NewPrinter(PrinterConfig{Transport: TransportUSB, Device: c.fichier})
+		switch {
+		case c.attendu == "" && err == nil:
+			t.Errorf("%s : %q accepté (%s)", c.nom, c.fichier, p)
+		case c.attendu != "" && err != nil:
+			t.Errorf("%s : %q refusé : %v", c.nom, c.fichier, err)
+		case c.attendu != "" && p.String() != c.attendu:
+			t.Errorf("%s : %s, attendu %s", c.nom, p, c.attendu)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+// backend/pos/qrcode.go
+// Encodeur QR minimal (mode octet, correction M, versions 1 à 10) pour le
+// rendu raster des imprimantes qui ne connaissent pas GS ( k. Jusqu'à 213
+// octets : un numéro de ticket, un extrait d'empreinte ou une URL courte.
+package pos
+
+import "errors"
+
+// Par version (1..10) en correction M : codewords totaux, nombre de blocs,
+// codewords de correction par bloc
+var qrVersionsM = [11]struct{ total, blocks, ecc int }{
+	{},
+	{26, 1, 10},
+	{44, 1, 16},
+	{70, 1, 26},
+	{100, 2, 18},
+	{134, 2, 24},
+	{172, 4, 16},
+	{196, 4, 18},
+	{242, 4, 22},
+	{292, 5, 22},
+	{346, 5, 26},
+}
+
+// Centres des motifs d'alignement par version
+var qrAlignment = [11][]int{
+	{}, {},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// QRMatrix : modules du symbole (true = noir), sans zone de silence
+type QRMatrix struct {
+	Size    int
+	modules [][]bool
+	fixed   [][]bool
+}
+
+// Dark indique si le module (x, y) est noir
+func (m *QRMatrix) Dark(x, y int) bool { return m.modules[y][x] }
+
+// EncodeQR encode data en QR (correction M), plus petite version possible
+func EncodeQR(data []byte) (*QRMatrix, error) {
+	version := 0
+	for v := 1; v <= 10; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		capacity := (qrVersionsM[v].total - qrVersionsM[v].blocks*qrVersionsM[v].ecc) * 8
+		if 4+countBits+8*len(data) <= capacity {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("contenu trop long pour un QR code")
+	}
+
+	codewords := qrAddECC(qrDataCodewords(data, version), version)
+
+	size := version*4 + 17
+	m := &QRMatrix{Size: size}
+	m.modules = make([][]bool, size)
+	m.fixed = make([][]bool, size)
+	for i := range m.modules {
+		m.modules[i] = make([]bool, size)
+		m.fixed[i] = make([]bool, size)
+	}
+
+	m.drawFunctionPatterns(version)
+	m.drawCodewords(codewords)
+
+	// Masque retenu : celui de pénalité minimale
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // XOR : réappliquer annule
+	}
+	m.applyMask(best)
+	m.drawFormatBits(best)
+	return m, nil
+}
+
+// ── Données ─────────────────────────────────────────────────────────────────
+
+func qrDataCodewords(data []byte, version int) []byte {
+	capacity := qrVersionsM[version].total - qrVersionsM[version].blocks*qrVersionsM[version].ecc
+
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+
+	appendBits(0x4, 4) // mode octet
+	if version >= 10 {
+		appendBits(len(data), 16)
+	} else {
+		appendBits(len(data), 8)
+	}
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	// Terminateur, alignement sur l'octet, puis octets de bourrage
+	appendBits(0, min(4, capacity*8-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrAddECC découpe en blocs, ajoute la correction Reed-Solomon et entrelace
+func qrAddECC(data []byte, version int) []byte {
+	spec := qrVersionsM[version]
+	shortBlocks := spec.blocks - spec.total%spec.blocks
+	shortLen := spec.total / spec.blocks
+	divisor := rsDivisor(spec.ecc)
+
+	blocks := make([][]byte, 0, spec.blocks)
+	k := 0
+	for i := 0; i < spec.blocks; i++ {
+		n := shortLen - spec.ecc
+		if i >= shortBlocks {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < shortBlocks {
+			block = append(block, 0) // aligne les blocs courts sur les longs
+		}
+		blocks = append(blocks, append(block, ecc...))
+	}
+
+	out := make([]byte, 0, spec.total)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-spec.ecc || j >= shortBlocks {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// ── Reed-Solomon sur GF(256), polynôme 0x11D ───────────────────────────────
+
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// ── Placement ───────────────────────────────────────────────────────────────
+
+func (m *QRMatrix) set(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.fixed[y][x] = true
+}
+
+func (m *QRMatrix) drawFunctionPatterns(version int) {
+	for i := 0; i < m.Size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.Size-4, 3)
+	m.drawFinder(3, m.Size-4)
+
+	pos := qrAlignment[version]
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	m.drawFormatBits(0) // réserve les emplacements
+	m.drawVersion(version)
+}
+
+func (m *QRMatrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= m.Size || y >= m.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			m.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (m *QRMatrix) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (m *QRMatrix) drawFormatBits(mask int) {
+	data := mask // correction M = 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.set(m.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.Size-15+i, bit(i))
+	}
+	m.set(8, m.Size-8, true)
+}
+
+func (m *QRMatrix) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := m.Size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+func (m *QRMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.Size - 1 - vert
+				}
+				if m.fixed[y][x] || i >= len(data)*8 {
+					continue
+				}
+				m.modules[y][x] = (data[i>>3]>>(7-i&7))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (m *QRMatrix) applyMask(mask int) {
+	for y := 0; y < m.Size; y++ {
+		for x := 0; x < m.Size; x++ {
+			if m.fixed[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty : règles de pénalité de la norme (suites, blocs 2x2, motifs
+// ressemblant aux repères, équilibre noir/blanc)
+func (m *QRMatrix) penalty() int {
+	n := m.Size
+	p := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return m.modules[x][y]
+		}
+		return m.modules[y][x]
+	}
+
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for k, dark := range finderLike {
+					if at(x+k, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (qrLightRun(m, x-4, x, y, transpose) || qrLightRun(m, x+7, x+11, y, transpose)) {
+					p += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := m.modules[y][x]
+				if m.modules[y][x+1] == c && m.modules[y+1][x] == c && m.modules[y+1][x+1] == c {
+					p += 3
+				}
+			}
+		}
+	}
+
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	if k > 0 {
+		p += k * 10
+	}
+	return p
+}
+
+// qrLightRun : modules [from, to) blancs (hors symbole = zone de silence)
+func qrLightRun(m *QRMatrix, from, to, y int, transpose bool) bool {
+	for x := from; x < to; x++ {
+		if x < 0 || x >= m.Size {
+			continue
+		}
+		if (transpose && m.modules[x][y]) || (!transpose && m.modules[y][x]) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,190 @@
+package pos
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Matrices de référence, recoupées module par module avec un encodeur écrit
+// à part d'après la norme (ISO/IEC 18004) au même masque. Un écart ici est un
+// QR que les douchettes ne liront plus.
+const (
+	// "TIK-2026-000123" : version 2, masque 1
+	qrTicketV2 = `
+#######.#.#.#..##.#######
+#.....#......##.#.#.....#
+#.###.#.###...###.#.###.#
+#.###.#...##..#...#.###.#
+#.###.#..#...###..#.###.#
+#.....#.####...#..#.....#
+#######.#.#.#.#.#.#######
+...........#..###........
+#.#...##..#####.#..#..#.#
+..##.#..#..####..##...#..
+.##...##.#..#..#.##.#...#
+.#.....#.#####.#...##..#.
+#..##.##.#..##.#.###.#.#.
+..#..#.#..#.#..#.##..##..
+##.#.####.#.########..#.#
+...##.....###.##...#.....
+##.#.##..#...########...#
+........###..####...#..#.
+#######.#.##....#.#.##..#
+#.....#...#..#..#...#..##
+#.###.#....###.######....
+#.###.#...#.#..#...##....
+#.###.#.#.#.#####..######
+#.....#..#.##.###...#....
+#######.#....########...#`
+
+	// "AXE" × 40 : version 7, masque 7 (bloc de version compris)
+	qrAxeV7 = `
+#######..#..##.##.###.#.#######.#...#.#######
+#.....#...#.#.#.......#.....##..##.#..#.....#
+#.###.#...#######.#.###.#...#.##...#..#.###.#
+#.###.#..#....#.#..................##.#.###.#
+#.###.#...#.###.#.#.#####.#..##.#.###.#.###.#
+#.....#.#..#.########...#.###..###....#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+.........##.###..####...#.#.##....###........
+#..#.##.#.#.....#...#####..##.##.##.##.#.....
+#...#..#.##.##.#.#.#.###...............#.##..
+..#...##.#..#......##..####..##.#..####.#.#.#
+..#.##....#...##...##.....##..####....#..#..#
+..#..###.##..#...####.#..####.#####.#####.#.#
+.##.##.#...###..##.#..#..#..#.....#..#..#....
+####.##..###.#.##.#..##.#..####.###.#...#.##.
+#...##.##.#..#.##........#.#.....##..#.#.....
+.#.##.#..###.########...#.##.##.#...#.##..#.#
+##..##.##.###...#..#.#....##.#..#..#..#....##
+##.##.#.#....#.#..#########...#.#.#.#########
+..##...#.##....##..#..#...#.##....###.###....
+....######........#######..##.##.##.#####.#.#
+##.##...#....#.#...##...#..........##...###..
+#...#.#.#..#...#..###.#.###..##.#..##.#.#.#.#
+#.###...###.#..###.##...#.##..####..#...##..#
+.############...#.#.#########.###########.#.#
+##.###.##..##..##...#.#..#..#.....##....#....
+...#.###....####.#..#..##..####.######..#.##.
+..#..#.#...##.###.####...#.#.....#####.##..#.
+..##.##..#####..#.####..#.##.##.#.....##..##.
+##.##...#...##...###.#..#.##.#..#...#.#....##
+##..####..#...#..#..#######...#.#.#.#########
+#.#.##.#.#............#...#.##....###..##....
+.##.###....#.##.#.#####.#..##.##.####...#.#.#
+...##...####....######.#...........#.....##..
+....#.#.#...######.#....###..##.#..#..##..#.#
+.####...####...#..###..#..##..####..#.#..#..#
+#..##.#..##..#.#.#..#########.#####.#####.#.#
+........#......#.#..#...##..#.....#.#...#....
+#######...##...##.#.#.#.#..####.#####.#.#.##.
+#.....#.##.#.#....#.#...##.#.....####...#....
+#.###.#..#..###....######.##.##.#...#####.#.#
+#.###.#.##...###....#..#..##.#..#....#.#.....
+#.###.#..#####...##########...#.#.###.#######
+#.....#.....#.#...#..#.#..#.##....#..#.##....
+#######.#.#..#######..#.#..##.##.##.#.....##.`
+)
+
+func qrText(m *QRMatrix) string {
+	var b strings.Builder
+	for y := 0; y < m.Size; y++ {
+		b.WriteByte('\n')
+		for x := 0; x < m.Size; x++ {
+			if m.Dark(x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+	}
+	return b.String()
+}
+
+// Le symbole entier, pas seulement sa taille : motifs, données, correction,
+// masque et informations de format/version.
+func TestQRMatriceDeReference(t *testing.T) {
+	cas := []struct {
+		nom     string
+		contenu string
+		attendu string
+	}{
+		{"numéro de ticket, version 2", "TIK-2026-000123", qrTicketV2},
+		{"version 7, bloc de version", strings.Repeat("AXE", 40), qrAxeV7},
+	}
+	for _, c := range cas {
+		m, err := EncodeQR([]byte(c.contenu))
+		if err != nil {
+			t.Fatalf("%s : %v", c.nom, err)
+		}
+		if got := qrText(m); got != c.attendu {
+			t.Errorf("%s : matrice différente\nobtenu :%s\nattendu :%s", c.nom, got, c.attendu)
+		}
+	}
+}
+
+// La plus petite version qui contient le texte, jusqu'à 213 octets en
+// version 10 (compteur sur 16 bits à partir de là).
+func TestQRVersionChoisie(t *testing.T) {
+	cas := []struct {
+		octets  int
+		version int
+	}{
+		{1, 1}, {14, 1}, {15, 2}, {26, 2}, {27, 3}, {180, 9}, {181, 10}, {213, 10},
+	}
+	for _, c := range cas {
+		m, err := EncodeQR(bytes.Repeat([]byte{'a'}, c.octets))
+		if err != nil {
+			t.Fatalf("%d octets : %v", c.octets, err)
+		}
+		if want := c.version*4 + 17; m.Size != want {
+			t.Errorf("%d octets : %d modules, attendu %d (version %d)", c.octets, m.Size, want, c.version)
+		}
+	}
+	if _, err := EncodeQR(bytes.Repeat([]byte{'a'}, 214)); err == nil {
+		t.Error("214 octets acceptés au-delà de la version 10")
+	}
+}
+
+// Vecteur publié (« HELLO WORLD », 1-M) : 16 codewords de données, 10 de
+// correction Reed-Solomon.
+func TestQRReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	attendu := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, attendu) {
+		t.Errorf("correction = %v, attendu %v", got, attendu)
+	}
+}
+
+// Informations de format en correction M, telles que la norme les tabule
+// pour chaque masque.
+func TestQRInformationsDeFormat(t *testing.T) {
+	cas := []string{
+		"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+		"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+	}
+	for mask, attendu := range cas {
+		m := &QRMatrix{Size: 21}
+		m.modules = make([][]bool, 21)
+		m.fixed = make([][]bool, 21)
+		for i := range m.modules {
+			m.modules[i] = make([]bool, 21)
+			m.fixed[i] = make([]bool, 21)
+		}
+		m.drawFormatBits(mask)
+
+		// Copie en haut à gauche, bit de poids fort en (ligne 8, colonne 0)
+		var got strings.Builder
+		for _, p := range [][2]int{{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8}, {8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0}} {
+			if m.Dark(p[0], p[1]) {
+				got.WriteByte('1')
+			} else {
+				got.WriteByte('0')
+			}
+		}
+		if got.String() != attendu {
+			t.Errorf("masque %d : %s, attendu %s", mask, got.String(), attendu)
+		}
+	}
+}
@@ -3,8 +3,11 @@
 
 package pos
 
-import "errors"
-
+// RawPrint envoie les octets à la file CUPS du même nom (hors Windows, le
+// spooler du système est CUPS)
 func RawPrint(printerName string, data []byte) error {
-	return errors.New("RawPrint not implemented on this OS")
+	if len(data) == 0 {
+		return nil
+	}
+	return cupsRawPrint(printerName, data, defaultPrintTimeout)
 }
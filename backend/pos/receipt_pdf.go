@@ -29,6 +29,12 @@ func BuildReceiptPreviewPDF(r ReceiptData) ([]byte, error) {
 
 // htmlToPDF convertit du HTML en PDF via chromedp.
 func htmlToPDF(htmlContent string) ([]byte, error) {
+	// Scale légèrement pour centrer le ticket dans la page
+	return renderHTMLPDF(htmlContent, 0.8, 0.2)
+}
+
+// renderHTMLPDF : A4 portrait, échelle et marges (en inches) au choix
+func renderHTMLPDF(htmlContent string, scale, margin float64) ([]byte, error) {
 	// Contexte avec timeout — 30s est large pour un ticket simple
 	ctx, cancel := chromedp.NewContext(context.Background())
 	defer cancel()
@@ -51,17 +57,15 @@ func htmlToPDF(htmlContent string) ([]byte, error) {
 				// Format A4 portrait
 				WithPaperWidth(8.27).
 				WithPaperHeight(11.69).
-				// Marges minimales (en inches)
-				WithMarginTop(0.2).
-				WithMarginBottom(0.2).
-				WithMarginLeft(0.2).
-				WithMarginRight(0.2).
+				WithMarginTop(margin).
+				WithMarginBottom(margin).
+				WithMarginLeft(margin).
+				WithMarginRight(margin).
 				// Pas de header/footer Chrome natif
 				WithDisplayHeaderFooter(false).
 				// Fond blanc (couleurs CSS respectées)
 				WithPrintBackground(true).
-				// Scale légèrement pour centrer le ticket dans la page
-				WithScale(0.8).
+				WithScale(scale).
 				Do(ctx)
 			return err
 		}),
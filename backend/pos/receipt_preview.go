@@ -5,8 +5,13 @@
 package pos
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"html"
+	"image"
+	"image/color"
+	"image/png"
 	"strings"
 )
 
@@ -68,6 +73,14 @@ func BuildReceiptPreviewText(r ReceiptData) string {
 	}
 
 	b.WriteString("\n")
+	if banner := r.DuplicateBanner(); banner != "" {
+		b.WriteString(centerText("*** "+banner+" ***", lineWidth))
+		b.WriteString("\n")
+		if r.ReprintedAt != "" {
+			b.WriteString(centerText("Reimprime le "+r.ReprintedAt, lineWidth))
+			b.WriteString("\n")
+		}
+	}
 	b.WriteString(strings.Repeat("-", lineWidth))
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("TICKET: %s", r.InvoiceNumber))
@@ -117,8 +130,8 @@ func BuildReceiptPreviewText(r ReceiptData) string {
 				discountLabel = fmt.Sprintf("-%.2f EUR", discAmt)
 			}
 
-			line1 := fmt.Sprintf("  %dx %.2fEUR (TVA %.2g%%) %s",
-				it.Qty, *it.BaseUnitTtc, it.TvaRate, discountLabel,
+			line1 := fmt.Sprintf("  %sx %.2fEUR (TVA %.2g%%) %s",
+				formatQty(it.Qty), *it.BaseUnitTtc, it.TvaRate, discountLabel,
 			)
 			b.WriteString(line1)
 			b.WriteString("\n")
@@ -129,7 +142,7 @@ func BuildReceiptPreviewText(r ReceiptData) string {
 			b.WriteString(line2)
 			b.WriteString("\n")
 		} else {
-			qtyPrice := fmt.Sprintf("  %dx %.2fEUR (TVA %.2g%%)", it.Qty, it.UnitTtc, it.TvaRate)
+			qtyPrice := fmt.Sprintf("  %sx %.2fEUR (TVA %.2g%%)", formatQty(it.Qty), it.UnitTtc, it.TvaRate)
 			total := fmt.Sprintf("%.2fEUR", it.TotalTtc)
 			line := labelValue(qtyPrice, total, lineWidth)
 			b.WriteString(line)
@@ -200,6 +213,22 @@ func BuildReceiptPreviewText(r ReceiptData) string {
 		b.WriteString(changeLine + "\n")
 	}
 
+	// MENTIONS (ticket enregistré)
+	if legal := r.LegalLines(); len(legal) > 0 {
+		b.WriteString("\n")
+		for _, l := range legal {
+			b.WriteString(l + "\n")
+		}
+	}
+
+	// CODES : rendus en image dans la preview HTML, rappelés ici en clair
+	if r.BarcodeContent != "" {
+		b.WriteString("\n" + centerText("[code-barres] "+r.BarcodeContent, lineWidth) + "\n")
+	}
+	if r.QRContent != "" {
+		b.WriteString(centerText("[QR] "+r.QRContent, lineWidth) + "\n")
+	}
+
 	// FOOTER (centré)
 	b.WriteString("\n\n")
 	b.WriteString(centerText("MERCI DE VOTRE VISITE !", lineWidth))
@@ -220,6 +249,21 @@ func BuildReceiptPreviewHTML(r ReceiptData) string {
 		}
 	}
 
+	codesHTML := ""
+	if r.BarcodeContent != "" {
+		if modules, err := Code128Modules(r.BarcodeContent); err == nil {
+			codesHTML += `<img class="code" src="` + barcodePNGDataURL(modules) + `" alt="` + html.EscapeString(r.BarcodeContent) + `">`
+		}
+	}
+	if r.QRContent != "" {
+		if qr, err := EncodeQR([]byte(r.QRContent)); err == nil {
+			codesHTML += `<img class="code qr" src="` + qrPNGDataURL(qr) + `" alt="QR">`
+		}
+	}
+	if codesHTML != "" {
+		codesHTML = `<div class="codes">` + codesHTML + `</div>`
+	}
+
 	return `<!doctype html><html><head><meta charset="utf-8"/>
 <meta name="viewport" content="width=device-width,initial-scale=1"/>
 <title>Receipt Preview</title>
@@ -235,6 +279,9 @@ func BuildReceiptPreviewHTML(r ReceiptData) string {
   }
   .logo-wrap { display:flex; justify-content:center; margin-bottom: 8px; }
   .logo { max-width: 240px; height: auto; image-rendering: crisp-edges; }
+  .codes { display:flex; flex-direction:column; align-items:center; gap: 12px; margin-top: 8px; }
+  .code { max-width: 100%; image-rendering: pixelated; }
+  .code.qr { width: 140px; }
   @media print {
     body { margin: 0; }
     .logo { max-width: 240px; }
@@ -243,5 +290,48 @@ func BuildReceiptPreviewHTML(r ReceiptData) string {
 </head><body>
 ` + logoHTML + `
 <div class="paper-wrap"><div class="paper">` + html.EscapeString(txt) + `</div></div>
+` + codesHTML + `
 </body></html>`
 }
+
+// qrPNGDataURL : QR en PNG (1 pixel par module, zone de silence de 4)
+func qrPNGDataURL(qr *QRMatrix) string {
+	const quiet = 4
+	side := qr.Size + 2*quiet
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			qx, qy := x-quiet, y-quiet
+			dark := qx >= 0 && qy >= 0 && qx < qr.Size && qy < qr.Size && qr.Dark(qx, qy)
+			img.SetGray(x, y, grayModule(dark))
+		}
+	}
+	return pngDataURL(img)
+}
+
+// barcodePNGDataURL : code-barres en PNG (2 pixels par module, 50 de haut)
+func barcodePNGDataURL(modules []bool) string {
+	const quiet, dots, height = 10, 2, 50
+	img := image.NewGray(image.Rect(0, 0, (len(modules)+2*quiet)*dots, height))
+	for x := range img.Bounds().Dx() {
+		i := x/dots - quiet
+		dark := i >= 0 && i < len(modules) && modules[i]
+		for y := 0; y < height; y++ {
+			img.SetGray(x, y, grayModule(dark))
+		}
+	}
+	return pngDataURL(img)
+}
+
+func grayModule(dark bool) color.Gray {
+	if dark {
+		return color.Gray{Y: 0}
+	}
+	return color.Gray{Y: 255}
+}
+
+func pngDataURL(img image.Image) string {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,376 @@
+// backend/pos/scancode.go
+// Lecture des codes scannés : avant d'être cherché dans le catalogue, un scan
+// est reconnu pour ce qu'il est.
+//   - GS1-128 / GS1 DataMatrix : identifiants d'application (GTIN, lot,
+//     date limite, numéro de série, poids, prix), séparés par FNC1 (GS, 0x1D)
+//     ou écrits entre parenthèses ;
+//   - EAN-13 à usage interne (préfixes 20 à 29) qui portent un prix ou un
+//     poids, imprimés par les balances ;
+//   - numéros de ticket (TIK-…, QR du ticket) et d'avoir (AVO-…) ;
+//   - le reste : EAN/UPC ordinaires, SKU internes.
+package pos
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nature d'un code scanné
+const (
+	ScanKindGS1      = "gs1"
+	ScanKindInStore  = "in_store" // EAN-13 20..29 à prix ou poids
+	ScanKindEAN      = "ean"      // EAN-13, EAN-8, UPC-A
+	ScanKindTicket   = "ticket"
+	ScanKindVoucher  = "voucher"
+	ScanKindFreeText = "text" // SKU interne ou code inconnu
+)
+
+// gs1GroupSeparator : FNC1 transmis par la scanette
+const gs1GroupSeparator = "\x1d"
+
+// InStoreValue : ce que porte la partie variable d'un EAN-13 interne
+type InStoreValue string
+
+const (
+	InStorePrice  InStoreValue = "price"  // centimes
+	InStoreWeight InStoreValue = "weight" // grammes
+)
+
+// InStorePrefixes : préfixes EAN-13 à usage interne et leur contenu. Code
+// sur 13 chiffres : préfixe (2), article (5), valeur (5), clé (1).
+// Réglage par défaut des balances du magasin ; à ajuster ici si une balance
+// est configurée autrement.
+var InStorePrefixes = map[string]InStoreValue{
+	"20": InStorePrice, "21": InStorePrice, "22": InStorePrice, "23": InStorePrice, "24": InStorePrice,
+	"25": InStoreWeight, "26": InStoreWeight, "27": InStoreWeight, "28": InStoreWeight, "29": InStoreWeight,
+}
+
+// ScanCode : un scan décodé
+type ScanCode struct {
+	Raw  string `json:"raw"`
+	Kind string `json:"kind"`
+
+	// Codes à chercher dans products.barcode, par ordre de préférence
+	Barcodes []string `json:"barcodes,omitempty"`
+
+	// GS1
+	GTIN   string            `json:"gtin,omitempty"`
+	Lot    string            `json:"lot,omitempty"`
+	Serial string            `json:"serial,omitempty"`
+	Expiry string            `json:"expiry,omitempty"` // YYYY-MM-DD
+	AIs    map[string]string `json:"ais,omitempty"`
+
+	// Valeurs embarquées (GS1 310n/392n ou EAN-13 interne)
+	Price      float64 `json:"price,omitempty"` // EUR TTC
+	WeightKg   float64 `json:"weight_kg,omitempty"`
+	ItemCode   string  `json:"item_code,omitempty"` // article d'un EAN-13 interne
+	TicketCode string  `json:"ticket_code,omitempty"`
+}
+
+var (
+	ticketCodeRe  = regexp.MustCompile(`^TIK-\d{4}-\d+(\|[0-9a-fA-F]+)?$`)
+	voucherCodeRe = regexp.MustCompile(`^AVO-\d{4}-\d+$`)
+	symbologyIDRe = regexp.MustCompile(`^\][A-Za-z][0-9A-Za-z]`)
+	gs1ParenRe    = regexp.MustCompile(`^(\(\d{2,4}\)[^()]+)+$`)
+	digitsRe      = regexp.MustCompile(`^\d+$`)
+)
+
+// ParseScan reconnaît un code scanné
+func ParseScan(raw string) ScanCode {
+	code := strings.TrimSpace(raw)
+	out := ScanCode{Raw: code, Kind: ScanKindFreeText}
+
+	// Identifiant de symbologie AIM (]C1 = GS1-128, ]d2 = DataMatrix GS1,
+	// ]Q3 = QR GS1, ]e0 = DataBar, ]E0 = EAN-13)
+	gs1Symbology := false
+	if symbologyIDRe.MatchString(code) {
+		id := code[:3]
+		code = code[3:]
+		switch id {
+		case "]C1", "]d2", "]Q3", "]e0":
+			gs1Symbology = true
+		}
+	}
+
+	// Le QR d'un ticket encode éventuellement une URL ?n=…&h=…
+	if n, h, ok := receiptURLParts(code); ok {
+		code = n
+		if h != "" {
+			code += "|" + h
+		}
+	}
+
+	switch {
+	case ticketCodeRe.MatchString(code):
+		out.Kind = ScanKindTicket
+		out.TicketCode = code
+		return out
+	case voucherCodeRe.MatchString(code):
+		out.Kind = ScanKindVoucher
+		return out
+	}
+
+	if gs1Symbology || strings.Contains(code, gs1GroupSeparator) || gs1ParenRe.MatchString(code) || looksLikeGS1(code) {
+		if ais, ok := parseGS1(code); ok {
+			out.Kind = ScanKindGS1
+			out.AIs = ais
+			applyGS1(&out, ais)
+			return out
+		}
+	}
+
+	if digitsRe.MatchString(code) {
+		switch len(code) {
+		case 13:
+			if kind, ok := InStorePrefixes[code[:2]]; ok && validGTIN(code) {
+				out.Kind = ScanKindInStore
+				out.ItemCode = code[2:7]
+				value, _ := strconv.Atoi(code[7:12])
+				if kind == InStorePrice {
+					out.Price = float64(value) / 100
+				} else {
+					out.WeightKg = float64(value) / 1000
+				}
+				// Fiche article : le code exact (article à prix fixe sous préfixe
+				// interne), sinon le code à valeur nulle ou ses 7 premiers chiffres
+				zero := code[:7] + "00000"
+				out.Barcodes = []string{code, zero + string('0'+EAN13CheckDigit(digitBytes(zero))), code[:7]}
+				return out
+			}
+			if validGTIN(code) {
+				out.Kind = ScanKindEAN
+				out.GTIN = "0" + code
+			}
+		case 8, 12, 14:
+			if validGTIN(code) {
+				out.Kind = ScanKindEAN
+				out.GTIN = strings.Repeat("0", 14-len(code)) + code
+			}
+		}
+		if out.Kind == ScanKindEAN {
+			out.Barcodes = gtinVariants(out.GTIN)
+			return out
+		}
+	}
+
+	out.Barcodes = []string{code}
+	return out
+}
+
+// receiptURLParts : numéro et extrait d'empreinte d'une URL POS_RECEIPT_URL
+func receiptURLParts(code string) (string, string, bool) {
+	i := strings.Index(code, "?")
+	if i < 0 || !strings.HasPrefix(code, "http") {
+		return "", "", false
+	}
+	var n, h string
+	for _, kv := range strings.Split(code[i+1:], "&") {
+		switch {
+		case strings.HasPrefix(kv, "n="):
+			n = kv[2:]
+		case strings.HasPrefix(kv, "h="):
+			h = kv[2:]
+		}
+	}
+	return n, h, n != ""
+}
+
+// looksLikeGS1 : chaîne GS1 dont la scanette a retiré l'identifiant de
+// symbologie (01 + GTIN-14 valide suivi d'autres AI)
+func looksLikeGS1(code string) bool {
+	return len(code) > 16 && strings.HasPrefix(code, "01") && validGTIN(code[2:16])
+}
+
+// ── GS1 ─────────────────────────────────────────────────────────────────────
+
+// Longueur de l'identifiant d'application selon ses deux premiers chiffres
+func gs1AILength(prefix string) int {
+	switch {
+	case prefix >= "00" && prefix <= "22", prefix == "30", prefix == "37", prefix >= "90":
+		return 2
+	case prefix >= "31" && prefix <= "36", prefix == "39", prefix == "43",
+		prefix == "70", prefix == "72", prefix == "80", prefix == "81", prefix == "82":
+		return 4
+	default:
+		return 3
+	}
+}
+
+// Longueur fixe des données de l'AI (0 = variable, terminée par FNC1)
+func gs1FixedLength(ai string) int {
+	switch ai[:2] {
+	case "00":
+		return 18
+	case "01", "02", "03":
+		return 14
+	case "04":
+		return 16
+	case "11", "12", "13", "14", "15", "16", "17", "18", "19":
+		return 6
+	case "20":
+		return 2
+	case "31", "32", "33", "34", "35", "36":
+		return 6
+	case "41":
+		return 13
+	}
+	return 0
+}
+
+// parseGS1 découpe une chaîne GS1, brute (FNC1) ou entre parenthèses
+func parseGS1(code string) (map[string]string, bool) {
+	ais := map[string]string{}
+
+	if strings.HasPrefix(code, "(") {
+		for _, part := range strings.Split(code[1:], "(") {
+			end := strings.Index(part, ")")
+			if end < 2 {
+				return nil, false
+			}
+			ais[part[:end]] = strings.TrimSuffix(part[end+1:], gs1GroupSeparator)
+		}
+		return ais, len(ais) > 0
+	}
+
+	code = strings.TrimPrefix(code, gs1GroupSeparator)
+	for len(code) > 0 {
+		if len(code) < 2 || !digitsRe.MatchString(code[:2]) {
+			return nil, false
+		}
+		aiLen := gs1AILength(code[:2])
+		if len(code) < aiLen || !digitsRe.MatchString(code[:aiLen]) {
+			return nil, false
+		}
+		ai := code[:aiLen]
+		code = code[aiLen:]
+
+		var value string
+		if n := gs1FixedLength(ai); n > 0 {
+			if len(code) < n {
+				return nil, false
+			}
+			value, code = code[:n], code[n:]
+		} else if end := strings.Index(code, gs1GroupSeparator); end >= 0 {
+			value, code = code[:end], code[end:]
+		} else {
+			value, code = code, ""
+		}
+		// FNC1 toléré après un champ fixe
+		code = strings.TrimPrefix(code, gs1GroupSeparator)
+		ais[ai] = value
+	}
+	return ais, len(ais) > 0
+}
+
+// applyGS1 reporte les AI connus dans le ScanCode
+func applyGS1(out *ScanCode, ais map[string]string) {
+	for ai, value := range ais {
+		switch {
+		case ai == "01" || ai == "02":
+			if out.GTIN == "" || ai == "01" {
+				out.GTIN = value
+			}
+		case ai == "10":
+			out.Lot = value
+		case ai == "21":
+			out.Serial = value
+		case ai == "17" || (ai == "15" && out.Expiry == ""):
+			out.Expiry = gs1Date(value)
+		// 310n / 392n : le 4e chiffre donne les décimales ; un AI entre
+		// parenthèses peut en manquer
+		case strings.HasPrefix(ai, "310") && len(ai) >= 4:
+			out.WeightKg = gs1Decimal(value, ai[3])
+		case strings.HasPrefix(ai, "392") && len(ai) >= 4:
+			out.Price = gs1Decimal(value, ai[3])
+		}
+	}
+	if out.GTIN != "" {
+		out.Barcodes = gtinVariants(out.GTIN)
+	}
+}
+
+// gs1Date : YYMMDD → YYYY-MM-DD ; jour 00 = dernier jour du mois. Siècle
+// selon la règle GS1 (fenêtre de -49 à +50 ans autour de l'année courante).
+func gs1Date(v string) string {
+	if len(v) != 6 || !digitsRe.MatchString(v) {
+		return ""
+	}
+	yy, _ := strconv.Atoi(v[:2])
+	mm, _ := strconv.Atoi(v[2:4])
+	dd, _ := strconv.Atoi(v[4:6])
+	if mm < 1 || mm > 12 {
+		return ""
+	}
+
+	current := time.Now().Year()
+	year := current/100*100 + yy
+	switch diff := yy - current%100; {
+	case diff >= 51:
+		year -= 100
+	case diff <= -50:
+		year += 100
+	}
+
+	if dd == 0 {
+		dd = time.Date(year, time.Month(mm)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	}
+	return time.Date(year, time.Month(mm), dd, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+// gs1Decimal : valeur à n décimales (dernier chiffre de l'AI)
+func gs1Decimal(v string, decimals byte) float64 {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || decimals < '0' || decimals > '9' {
+		return 0
+	}
+	for i := byte('0'); i < decimals; i++ {
+		n /= 10
+	}
+	return n
+}
+
+// ── GTIN ────────────────────────────────────────────────────────────────────
+
+func digitBytes(s string) []byte {
+	out := make([]byte, len(s))
+	for i := range s {
+		out[i] = s[i] - '0'
+	}
+	return out
+}
+
+// validGTIN : clé de contrôle d'un GTIN-8/12/13/14
+func validGTIN(code string) bool {
+	if !digitsRe.MatchString(code) {
+		return false
+	}
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte((10-sum%10)%10) == code[len(code)-1]-'0'
+}
+
+// gtinVariants : un GTIN-14 tel qu'il peut être saisi dans la fiche produit
+// (EAN-13, UPC-A, EAN-8 ou GTIN-14)
+func gtinVariants(gtin14 string) []string {
+	variants := []string{}
+	trimmed := strings.TrimLeft(gtin14, "0")
+	for _, n := range []int{13, 12, 8, 14} {
+		if len(trimmed) <= n {
+			variants = append(variants, strings.Repeat("0", n-len(trimmed))+trimmed)
+		}
+	}
+	return variants
+}
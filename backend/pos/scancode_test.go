@@ -0,0 +1,72 @@
+package pos
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Un scan mal reconnu part au mauvais endroit (ticket cherché comme produit,
+// prix de balance ignoré) ; un scan mal formé ne doit jamais faire tomber la
+// caisse.
+func TestParseScan(t *testing.T) {
+	cas := []struct {
+		nom        string
+		scan       string
+		nature     string
+		gtin       string
+		lot        string
+		serie      string
+		peremption string
+		prix       float64
+		poidsKg    float64
+		article    string
+		ticket     string
+		codes      []string
+	}{
+		{nom: "GS1 entre parenthèses", scan: "(01)09501101020917(17)261231(10)LOT42",
+			nature: ScanKindGS1, gtin: "09501101020917", lot: "LOT42", peremption: "2026-12-31",
+			codes: []string{"9501101020917", "09501101020917"}},
+		{nom: "GS1 brut, séparateur FNC1", scan: "]C10109501101020917" + "21SN-001\x1d" + "3103001250",
+			nature: ScanKindGS1, gtin: "09501101020917", serie: "SN-001", poidsKg: 1.25,
+			codes: []string{"9501101020917", "09501101020917"}},
+		// AI 392n sur quatre chiffres : « 39 » lu sur deux faisait paniquer
+		{nom: "GS1 brut, prix 3922", scan: "01095011010209173922000019",
+			nature: ScanKindGS1, gtin: "09501101020917", prix: 0.19,
+			codes: []string{"9501101020917", "09501101020917"}},
+		{nom: "GS1 entre parenthèses, AI 310 tronqué", scan: "(01)09501101020917(310)1250",
+			nature: ScanKindGS1, gtin: "09501101020917",
+			codes: []string{"9501101020917", "09501101020917"}},
+		{nom: "EAN-13 interne à prix", scan: "2112345012346",
+			nature: ScanKindInStore, prix: 12.34, article: "12345",
+			codes: []string{"2112345012346", "2112345000008", "2112345"}},
+		{nom: "EAN-13 interne au poids", scan: "2512345012504",
+			nature: ScanKindInStore, poidsKg: 1.25, article: "12345",
+			codes: []string{"2512345012504", "2512345000006", "2512345"}},
+		{nom: "EAN-13 ordinaire", scan: "3012345678902",
+			nature: ScanKindEAN, gtin: "03012345678902",
+			codes: []string{"3012345678902", "03012345678902"}},
+		{nom: "numéro de ticket", scan: "TIK-2026-000123",
+			nature: ScanKindTicket, ticket: "TIK-2026-000123"},
+		{nom: "QR du ticket avec extrait d'empreinte", scan: "TIK-2026-000123|0123456789abcdef",
+			nature: ScanKindTicket, ticket: "TIK-2026-000123|0123456789abcdef"},
+		{nom: "lien du ticket", scan: "https://boutique.example/ticket?n=TIK-2026-000123&h=0123456789abcdef",
+			nature: ScanKindTicket, ticket: "TIK-2026-000123|0123456789abcdef"},
+		{nom: "avoir", scan: "AVO-2026-000042", nature: ScanKindVoucher},
+		{nom: "SKU interne", scan: " GUIT-STRAT-01 ", nature: ScanKindFreeText,
+			codes: []string{"GUIT-STRAT-01"}},
+		{nom: "EAN-13 à clé fausse", scan: "3012345678903", nature: ScanKindFreeText,
+			codes: []string{"3012345678903"}},
+	}
+
+	for _, c := range cas {
+		got := ParseScan(c.scan)
+		if got.Kind != c.nature || got.GTIN != c.gtin || got.Lot != c.lot || got.Serial != c.serie ||
+			got.Expiry != c.peremption || got.Price != c.prix || got.WeightKg != c.poidsKg ||
+			got.ItemCode != c.article || got.TicketCode != c.ticket {
+			t.Errorf("%s : obtenu %+v", c.nom, got)
+		}
+		if c.codes != nil && !reflect.DeepEqual(got.Barcodes, c.codes) {
+			t.Errorf("%s : codes %v, attendu %v", c.nom, got.Barcodes, c.codes)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package pos
 
 import (
+	"log"
 	"strings"
 	"sync"
 	"time"
@@ -15,15 +16,67 @@ type ScannerManager struct {
 	portName    string
 	baudRate    int
 	isRunning   bool
+	connected   bool
+	reconnects  int
+	stop        chan struct{} // fermé par Stop / un nouveau Start
+	resolver    ScanResolver
 	mu          sync.RWMutex
-	subscribers map[chan string]bool
+	subscribers map[chan ScanEvent]bool
 	subMu       sync.RWMutex
 }
 
+// Type d'un événement de scan
+const (
+	ScanEventProduct = "product"      // produit(s) du catalogue
+	ScanEventUnknown = "unknown_code" // rien trouvé
+	ScanEventTicket  = "ticket"       // ticket de caisse (retour, duplicata)
+	ScanEventVoucher = "voucher"      // avoir
+)
+
+// ScanProduct : produit trouvé, avec la quantité et le prix que le code
+// impose (poids ou prix embarqués), sinon 1 × prix catalogue
+type ScanProduct struct {
+	ID           string  `json:"id"`
+	Company      string  `json:"company"`
+	Name         string  `json:"name"`
+	Sku          string  `json:"sku"`
+	Barcode      string  `json:"barcode"`
+	PriceTtc     float64 `json:"price_ttc"`
+	Quantity     float64 `json:"quantity"`
+	UnitPriceTtc float64 `json:"unit_price_ttc"`
+}
+
+// ScanDocument : ticket ou avoir désigné par le code
+type ScanDocument struct {
+	ID              string  `json:"id"`
+	Number          string  `json:"number"`
+	Company         string  `json:"company"`
+	TotalTtc        float64 `json:"total_ttc"`
+	RemainingAmount float64 `json:"remaining_amount"`
+}
+
+// ScanEvent : ce que reçoivent les abonnés pour chaque scan
+type ScanEvent struct {
+	Type     string        `json:"type"`
+	Code     ScanCode      `json:"code"`
+	Products []ScanProduct `json:"products,omitempty"` // un par entreprise au plus
+	Document *ScanDocument `json:"document,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ScanResolver cherche un code décodé dans la base (fourni par les routes)
+type ScanResolver func(code ScanCode) ScanEvent
+
+// Délais entre deux tentatives de réouverture après un débranchement
+const (
+	scannerReconnectMin = 500 * time.Millisecond
+	scannerReconnectMax = 5 * time.Second
+)
+
 // NewScannerManager crée un nouveau gestionnaire de scanette
 func NewScannerManager() *ScannerManager {
 	return &ScannerManager{
-		subscribers: make(map[chan string]bool),
+		subscribers: make(map[chan ScanEvent]bool),
 	}
 }
 
@@ -35,30 +88,22 @@ func (sm *ScannerManager) Start(portName string, baudRate int) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	if sm.isRunning && sm.port != nil {
-		sm.port.Close()
-	}
-
-	mode := &serial.Mode{
-		BaudRate: baudRate,
-		DataBits: 8,
-		Parity:   serial.NoParity,
-		StopBits: serial.OneStopBit,
-	}
+	sm.stopLocked()
 
-	port, err := serial.Open(portName, mode)
+	port, err := openSerialPort(portName, baudRate, 100*time.Millisecond)
 	if err != nil {
 		return err
 	}
 
-	port.SetReadTimeout(100 * time.Millisecond)
-
 	sm.port = port
 	sm.portName = portName
 	sm.baudRate = baudRate
 	sm.isRunning = true
+	sm.connected = true
+	sm.reconnects = 0
+	sm.stop = make(chan struct{})
 
-	go sm.readLoop()
+	go sm.readLoop(sm.stop, port)
 	return nil
 }
 
@@ -67,7 +112,18 @@ func (sm *ScannerManager) Stop() error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	return sm.stopLocked()
+}
+
+// stopLocked arrête la boucle de lecture en cours. Appelant : sm.mu tenu.
+func (sm *ScannerManager) stopLocked() error {
 	sm.isRunning = false
+	sm.connected = false
+
+	if sm.stop != nil {
+		close(sm.stop)
+		sm.stop = nil
+	}
 
 	if sm.port != nil {
 		err := sm.port.Close()
@@ -96,15 +152,38 @@ func (sm *ScannerManager) GetStatus() map[string]interface{} {
 
 	return map[string]interface{}{
 		"running":     sm.isRunning,
+		"connected":   sm.connected,
+		"reconnects":  sm.reconnects,
 		"portName":    sm.portName,
 		"baudRate":    sm.baudRate,
 		"subscribers": subscriberCount,
 	}
 }
 
+// SetResolver branche la recherche des codes dans la base
+func (sm *ScannerManager) SetResolver(resolver ScanResolver) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.resolver = resolver
+}
+
+// Resolve décode un scan et le cherche dans la base
+func (sm *ScannerManager) Resolve(raw string) ScanEvent {
+	code := ParseScan(raw)
+
+	sm.mu.RLock()
+	resolver := sm.resolver
+	sm.mu.RUnlock()
+
+	if resolver == nil {
+		return ScanEvent{Type: ScanEventUnknown, Code: code}
+	}
+	return resolver(code)
+}
+
 // Subscribe ajoute un subscriber pour recevoir les scans
-func (sm *ScannerManager) Subscribe() chan string {
-	ch := make(chan string, 10)
+func (sm *ScannerManager) Subscribe() chan ScanEvent {
+	ch := make(chan ScanEvent, 10)
 
 	sm.subMu.Lock()
 	sm.subscribers[ch] = true
@@ -114,43 +193,56 @@ func (sm *ScannerManager) Subscribe() chan string {
 }
 
 // Unsubscribe retire un subscriber
-func (sm *ScannerManager) Unsubscribe(ch chan string) {
+func (sm *ScannerManager) Unsubscribe(ch chan ScanEvent) {
 	sm.subMu.Lock()
 	delete(sm.subscribers, ch)
 	close(ch)
 	sm.subMu.Unlock()
 }
 
-// broadcast envoie un scan à tous les subscribers
+// broadcast résout un scan et l'envoie à tous les subscribers
 func (sm *ScannerManager) broadcast(barcode string) {
+	event := sm.Resolve(barcode)
+
 	sm.subMu.RLock()
 	defer sm.subMu.RUnlock()
 
 	for ch := range sm.subscribers {
 		select {
-		case ch <- barcode:
+		case ch <- event:
 		default:
 		}
 	}
 }
 
-// readLoop lit continuellement le port série
-func (sm *ScannerManager) readLoop() {
+// readLoop lit continuellement le port série. Une erreur de lecture
+// (scanette débranchée) ferme le port et passe en reconnexion au lieu de
+// relire aussitôt un port mort.
+func (sm *ScannerManager) readLoop(stop chan struct{}, port serial.Port) {
 	var buffer []byte
+	tmp := make([]byte, 128)
 
 	for {
-		sm.mu.RLock()
-		running := sm.isRunning
-		port := sm.port
-		sm.mu.RUnlock()
-
-		if !running || port == nil {
+		select {
+		case <-stop:
 			return
+		default:
 		}
 
-		tmp := make([]byte, 128)
 		n, err := port.Read(tmp)
 		if err != nil {
+			select {
+			case <-stop:
+				return // fermé par Stop
+			default:
+			}
+
+			log.Printf("🔌 [SCANNER] %s : %v, reconnexion...", sm.portName, err)
+			_ = port.Close()
+			buffer = nil
+			if port = sm.reconnect(stop); port == nil {
+				return
+			}
 			continue
 		}
 
@@ -190,6 +282,51 @@ func (sm *ScannerManager) readLoop() {
 	}
 }
 
+// reconnect rouvre le port jusqu'au retour de l'appareil, avec un délai
+// croissant. Retourne nil si l'écoute a été arrêtée entre-temps.
+func (sm *ScannerManager) reconnect(stop chan struct{}) serial.Port {
+	sm.mu.Lock()
+	if sm.stop != stop {
+		sm.mu.Unlock()
+		return nil
+	}
+	sm.port = nil
+	sm.connected = false
+	portName, baudRate := sm.portName, sm.baudRate
+	sm.mu.Unlock()
+
+	delay := scannerReconnectMin
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(delay):
+		}
+
+		port, err := openSerialPort(portName, baudRate, 100*time.Millisecond)
+		if err != nil {
+			if delay *= 2; delay > scannerReconnectMax {
+				delay = scannerReconnectMax
+			}
+			continue
+		}
+
+		sm.mu.Lock()
+		if sm.stop != stop {
+			sm.mu.Unlock()
+			_ = port.Close()
+			return nil
+		}
+		sm.port = port
+		sm.connected = true
+		sm.reconnects++
+		sm.mu.Unlock()
+
+		log.Printf("✅ [SCANNER] %s reconnectée", portName)
+		return port
+	}
+}
+
 // SimulateScan simule un scan (utile pour les tests)
 func (sm *ScannerManager) SimulateScan(barcode string) {
 	sm.broadcast(barcode)
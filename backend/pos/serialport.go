@@ -1,10 +1,12 @@
-// backend/pos/serialport_windows.go
-//go:build windows
+// backend/pos/serialport.go
+// Ports série (afficheur client VFD, scanette) via go.bug.st/serial, sur
+// Windows (COMx) comme sur Linux (/dev/ttyUSB*, /dev/ttyACM*).
 
 package pos
 
 import (
 	"fmt"
+	"log"
 	"sort"
 	"sync"
 	"time"
@@ -16,16 +18,20 @@ import (
 // List ports
 // ================================
 
-// ListSerialPorts returns available COM ports on Windows
+// ListSerialPorts returns available serial ports: COM ports on Windows,
+// /dev/tty* devices plus their stable /dev/serial/by-id aliases on Linux
 func ListSerialPorts() ([]string, error) {
 	ports, err := serial.GetPortsList()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list serial ports: %w", err)
 	}
+	ports = append(ports, platformSerialPorts()...)
 
+	seen := make(map[string]bool, len(ports))
 	var validPorts []string
 	for _, port := range ports {
-		if port != "" {
+		if port != "" && !seen[port] {
+			seen[port] = true
 			validPorts = append(validPorts, port)
 		}
 	}
@@ -34,6 +40,27 @@ func ListSerialPorts() ([]string, error) {
 	return validPorts, nil
 }
 
+// openSerialPort opens a port in 8N1 with the given read timeout
+func openSerialPort(portName string, baudRate int, readTimeout time.Duration) (serial.Port, error) {
+	mode := &serial.Mode{
+		BaudRate: baudRate,
+		DataBits: 8,
+		Parity:   serial.NoParity,
+		StopBits: serial.OneStopBit,
+	}
+
+	p, err := serial.Open(portName, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open port %s: %w", portName, err)
+	}
+
+	if err := p.SetReadTimeout(readTimeout); err != nil {
+		_ = p.Close()
+		return nil, fmt.Errorf("failed to set read timeout: %w", err)
+	}
+	return p, nil
+}
+
 // ================================
 // Single shared serial handle + mutex
 // ================================
@@ -51,6 +78,11 @@ func CloseSerialPort() {
 	serialMu.Lock()
 	defer serialMu.Unlock()
 
+	closeSerialLocked()
+}
+
+// closeSerialLocked drops the shared handle. Caller MUST hold serialMu.
+func closeSerialLocked() {
 	if serialPort != nil {
 		_ = serialPort.Close()
 		serialPort = nil
@@ -76,29 +108,12 @@ func ensureSerialOpen(portName string, baudRate int) error {
 	}
 
 	// Close previous if open
-	if serialPort != nil {
-		_ = serialPort.Close()
-		serialPort = nil
-		currentPortName = ""
-		currentBaudRate = 0
-	}
-
-	mode := &serial.Mode{
-		BaudRate: baudRate,
-		DataBits: 8,
-		Parity:   serial.NoParity,
-		StopBits: serial.OneStopBit,
-	}
-
-	p, err := serial.Open(portName, mode)
-	if err != nil {
-		return fmt.Errorf("failed to open port %s: %w", portName, err)
-	}
+	closeSerialLocked()
 
 	// Safe even if you never read
-	if err := p.SetReadTimeout(2 * time.Second); err != nil {
-		_ = p.Close()
-		return fmt.Errorf("failed to set read timeout: %w", err)
+	p, err := openSerialPort(portName, baudRate, 2*time.Second)
+	if err != nil {
+		return err
 	}
 
 	serialPort = p
@@ -107,12 +122,28 @@ func ensureSerialOpen(portName string, baudRate int) error {
 	return nil
 }
 
+// writeSerialLocked writes data on the shared handle. Caller MUST hold serialMu.
+func writeSerialLocked(data []byte) error {
+	n, err := serialPort.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write to port: %w", err)
+	}
+
+	if n != len(data) {
+		return fmt.Errorf("incomplete write: sent %d/%d bytes", n, len(data))
+	}
+	return nil
+}
+
 // ================================
 // Send API
 // ================================
 
 // SendToSerialPort opens (or reuses) the port and writes data.
 // This prevents "Serial port busy" when your app tries to open the same COM port multiple times.
+// A handle left stale by an unplugged USB device fails on write: the port is
+// then reopened once and the write retried, so a replugged display works again
+// without restarting the app.
 func SendToSerialPort(portName string, baudRate int, data []byte) error {
 	serialMu.Lock()
 	defer serialMu.Unlock()
@@ -121,13 +152,16 @@ func SendToSerialPort(portName string, baudRate int, data []byte) error {
 		return err
 	}
 
-	n, err := serialPort.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to write to port: %w", err)
-	}
-
-	if n != len(data) {
-		return fmt.Errorf("incomplete write: sent %d/%d bytes", n, len(data))
+	if err := writeSerialLocked(data); err != nil {
+		log.Printf("🔌 [SERIAL] %s : %v, réouverture du port", portName, err)
+		closeSerialLocked()
+		if err := ensureSerialOpen(portName, baudRate); err != nil {
+			return err
+		}
+		if err := writeSerialLocked(data); err != nil {
+			closeSerialLocked()
+			return err
+		}
 	}
 
 	// IMPORTANT: Délai plus long pour VFD
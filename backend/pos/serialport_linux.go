@@ -0,0 +1,14 @@
+// backend/pos/serialport_linux.go
+//go:build linux
+
+package pos
+
+import "path/filepath"
+
+// platformSerialPorts : alias /dev/serial/by-id/… des adaptateurs USB. Un
+// appareil rebranché peut passer de ttyUSB0 à ttyUSB1, son alias by-id ne
+// change pas : c'est lui qu'il faut enregistrer dans la configuration.
+func platformSerialPorts() []string {
+	links, _ := filepath.Glob("/dev/serial/by-id/*")
+	return links
+}
@@ -0,0 +1,9 @@
+// backend/pos/serialport_other.go
+//go:build !linux
+
+package pos
+
+// platformSerialPorts : rien à ajouter à la liste de go.bug.st/serial
+func platformSerialPorts() []string {
+	return nil
+}
@@ -0,0 +1,462 @@
+// backend/pricing.go
+// ═══════════════════════════════════════════════════════════════════════════
+// LISTES DE PRIX — résolution du prix effectif
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : pos_routes.go (ticket), invoice_hooks.go (devis),
+// order_hooks.go (bons de commande), pricing_routes.go (aperçu éditeurs)
+//
+// Règles :
+//   - une liste s'applique si elle est active, valide à la date du document,
+//     et affectée au client, à son type, ou à personne (= tout le monde) ;
+//   - une règle vise un produit ou une catégorie du produit, à partir d'une
+//     quantité minimale (la quantité est cumulée par produit sur le document) ;
+//   - parmi les règles applicables, le client paie le prix le plus bas ;
+//     à prix égal, la règle produit l'emporte sur la règle catégorie ;
+//   - aucune règle : prix catalogue (products.price_ttc).
+
+package backend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// ResolvedPrice est le prix effectif d'un produit pour un client
+type ResolvedPrice struct {
+	ProductID     string  `json:"product_id"`
+	Quantity      float64 `json:"quantity"`
+	BasePriceTTC  float64 `json:"base_price_ttc"`
+	UnitPriceTTC  float64 `json:"unit_price_ttc"`
+	TaxRate       float64 `json:"tax_rate"`
+	PriceListID   string  `json:"price_list_id,omitempty"`
+	PriceListName string  `json:"price_list_name,omitempty"`
+	RuleID        string  `json:"rule_id,omitempty"`
+	MinQuantity   float64 `json:"min_quantity,omitempty"`
+}
+
+// FromPriceList indique si une liste de prix a modifié le prix catalogue
+func (r *ResolvedPrice) FromPriceList() bool {
+	return r.PriceListID != ""
+}
+
+type priceRule struct {
+	id          string
+	listID      string
+	listName    string
+	productID   string
+	categoryID  string
+	mode        string
+	priceTTC    float64
+	discountPct float64
+	minQuantity float64
+}
+
+// PriceResolver charge une fois les règles applicables à un client à une date,
+// puis résout autant de produits que nécessaire (un document = un resolver).
+type PriceResolver struct {
+	dao      *daos.Dao
+	rules    []priceRule
+	products map[string]*models.Record
+}
+
+// NewPriceResolver prépare la résolution pour un client d'une société.
+// customerID peut être vide (seules les listes « tout le monde » s'appliquent).
+func NewPriceResolver(dao *daos.Dao, ownerCompany, customerID string, at time.Time) (*PriceResolver, error) {
+	r := &PriceResolver{dao: dao, products: make(map[string]*models.Record)}
+
+	if _, err := dao.FindCollectionByNameOrId("price_lists"); err != nil {
+		return r, nil // migration pas encore passée : prix catalogue
+	}
+
+	customerType := ""
+	if customerID != "" {
+		if customer, err := dao.FindRecordById("customers", customerID); err == nil {
+			customerType = customer.GetString("customer_type")
+		}
+	}
+
+	lists, err := dao.FindRecordsByFilter(
+		"price_lists",
+		"owner_company = {:company} && active = true",
+		"",
+		0,
+		0,
+		dbx.Params{"company": ownerCompany},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erreur chargement listes de prix: %w", err)
+	}
+
+	day := at.Format("2006-01-02")
+	for _, list := range lists {
+		if !priceListValidOn(list, day) || !priceListAppliesTo(list, customerID, customerType) {
+			continue
+		}
+
+		items, err := dao.FindRecordsByFilter(
+			"price_list_items", "price_list = {:list}", "", 0, 0, dbx.Params{"list": list.Id},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erreur chargement règles de %s: %w", list.GetString("name"), err)
+		}
+		for _, it := range items {
+			r.rules = append(r.rules, priceRule{
+				id:          it.Id,
+				listID:      list.Id,
+				listName:    list.GetString("name"),
+				productID:   it.GetString("product"),
+				categoryID:  it.GetString("category"),
+				mode:        it.GetString("mode"),
+				priceTTC:    it.GetFloat("price_ttc"),
+				discountPct: it.GetFloat("discount_percent"),
+				minQuantity: it.GetFloat("min_quantity"),
+			})
+		}
+	}
+
+	return r, nil
+}
+
+func priceListValidOn(list *models.Record, day string) bool {
+	if from := list.GetDateTime("valid_from"); !from.IsZero() && from.Time().Format("2006-01-02") > day {
+		return false
+	}
+	if until := list.GetDateTime("valid_until"); !until.IsZero() && until.Time().Format("2006-01-02") < day {
+		return false
+	}
+	return true
+}
+
+func priceListAppliesTo(list *models.Record, customerID, customerType string) bool {
+	customers := list.GetStringSlice("customers")
+	types := list.GetStringSlice("customer_types")
+	if len(customers) == 0 && len(types) == 0 {
+		return true
+	}
+	for _, id := range customers {
+		if customerID != "" && id == customerID {
+			return true
+		}
+	}
+	for _, t := range types {
+		if customerType != "" && t == customerType {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve retourne le prix effectif d'un produit pour une quantité donnée
+func (r *PriceResolver) Resolve(productID string, quantity float64) (*ResolvedPrice, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		p, err := r.dao.FindRecordById("products", productID)
+		if err != nil {
+			return nil, fmt.Errorf("produit %s introuvable: %w", productID, err)
+		}
+		product = p
+		r.products[productID] = p
+	}
+
+	base := product.GetFloat("price_ttc")
+	resolved := &ResolvedPrice{
+		ProductID:    productID,
+		Quantity:     quantity,
+		BasePriceTTC: base,
+		UnitPriceTTC: base,
+		TaxRate:      product.GetFloat("tax_rate"),
+	}
+
+	categories := make(map[string]bool)
+	for _, c := range product.GetStringSlice("categories") {
+		categories[c] = true
+	}
+
+	var best *priceRule
+	var bestPrice float64
+	for i := range r.rules {
+		rule := &r.rules[i]
+		switch {
+		case rule.productID != "":
+			if rule.productID != productID {
+				continue
+			}
+		case rule.categoryID != "":
+			if !categories[rule.categoryID] {
+				continue
+			}
+		default:
+			continue
+		}
+		if rule.minQuantity > 0 && quantity < rule.minQuantity {
+			continue
+		}
+
+		price := rule.apply(base)
+		if best == nil || price < bestPrice-0.0001 ||
+			(math.Abs(price-bestPrice) < 0.0001 && best.productID == "" && rule.productID != "") {
+			best = rule
+			bestPrice = price
+		}
+	}
+
+	if best != nil {
+		resolved.UnitPriceTTC = bestPrice
+		resolved.PriceListID = best.listID
+		resolved.PriceListName = best.listName
+		resolved.RuleID = best.id
+		resolved.MinQuantity = best.minQuantity
+	}
+
+	return resolved, nil
+}
+
+func (rule *priceRule) apply(base float64) float64 {
+	if rule.mode == "percent" {
+		pct := math.Max(0, math.Min(100, rule.discountPct))
+		return roundAmount(base * (1 - pct/100))
+	}
+	return roundAmount(rule.priceTTC)
+}
+
+// ============================================================================
+// DEVIS / BONS DE COMMANDE
+// ============================================================================
+
+// RepriceDocumentItems applique les listes de prix aux lignes produit d'un
+// devis ou d'un bon de commande, puis recalcule les totaux du document.
+// Seules les lignes au prix catalogue (telles que l'éditeur les a insérées) ou
+// déjà tarifées par une liste sont reprises : un prix saisi à la main, ou une
+// ligne marquée "price_locked", n'est pas touché.
+// Retourne true si au moins une ligne a changé de prix.
+//
+// Deux formes de lignes coexistent :
+//   - devis : tva_rate en %, unit_price_ttc_before_discount + remise de ligne ;
+//   - bons de commande : vat_rate en fraction (0.20), unit_price_ht seul.
+func RepriceDocumentItems(dao *daos.Dao, record *models.Record) (bool, error) {
+	var items []map[string]any
+	if err := record.UnmarshalJSONField("items", &items); err != nil || len(items) == 0 {
+		return false, nil
+	}
+
+	at := time.Now()
+	if d := record.GetDateTime("date"); !d.IsZero() {
+		at = d.Time()
+	}
+	resolver, err := NewPriceResolver(dao, record.GetString("owner_company"), record.GetString("customer"), at)
+	if err != nil {
+		return false, err
+	}
+
+	// Paliers : quantité cumulée par produit sur tout le document
+	quantities := make(map[string]float64)
+	for _, it := range items {
+		if pid, _ := it["product_id"].(string); pid != "" {
+			quantities[pid] += toFloat(it["quantity"])
+		}
+	}
+
+	changed := false
+	for _, it := range items {
+		pid, _ := it["product_id"].(string)
+		if pid == "" {
+			continue
+		}
+		if locked, _ := it["price_locked"].(bool); locked {
+			continue
+		}
+
+		resolved, err := resolver.Resolve(pid, quantities[pid])
+		if err != nil {
+			continue
+		}
+		listID, _ := it["price_list_id"].(string)
+		wasListed := listID != ""
+		if !resolved.FromPriceList() && !wasListed {
+			continue
+		}
+
+		if _, isOrderLine := it["vat_rate"]; isOrderLine {
+			vatRate := toFloat(it["vat_rate"])
+			current := toFloat(it["unit_price_ht"])
+			if !wasListed && math.Abs(current-roundAmount(resolved.BasePriceTTC/(1+vatRate))) >= 0.005 {
+				continue // prix saisi à la main
+			}
+			unitHT := roundAmount(resolved.UnitPriceTTC / (1 + vatRate))
+			if math.Abs(unitHT-current) < 0.005 {
+				continue
+			}
+			qty := toFloat(it["quantity"])
+			it["unit_price_ht"] = unitHT
+			it["total_ht"] = roundAmount(unitHT * qty)
+			it["total_ttc"] = roundAmount(unitHT * qty * (1 + vatRate))
+		} else {
+			current := toFloat(it["unit_price_ttc_before_discount"])
+			if !wasListed && math.Abs(current-resolved.BasePriceTTC) >= 0.005 {
+				continue // prix saisi à la main
+			}
+			if math.Abs(resolved.UnitPriceTTC-current) < 0.005 {
+				continue
+			}
+			it["unit_price_ttc_before_discount"] = resolved.UnitPriceTTC
+			if _, ok := it["unit_price_ttc"]; ok {
+				it["unit_price_ttc"] = resolved.UnitPriceTTC
+			}
+			applyQuoteLineTotals(it)
+		}
+
+		// Plus de liste applicable (palier non atteint…) : retour au catalogue
+		if resolved.FromPriceList() {
+			it["price_list_id"] = resolved.PriceListID
+			it["price_list_name"] = resolved.PriceListName
+		} else {
+			delete(it, "price_list_id")
+			delete(it, "price_list_name")
+		}
+		it["base_price_ttc"] = resolved.BasePriceTTC
+		changed = true
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	recomputeDocumentTotals(record, items)
+	return true, nil
+}
+
+// applyQuoteLineTotals recalcule une ligne de devis (même calcul que l'éditeur)
+func applyQuoteLineTotals(it map[string]any) {
+	qty := math.Max(0, toFloat(it["quantity"]))
+	rate := 20.0
+	if v, ok := it["tva_rate"]; ok {
+		rate = toFloat(v)
+	}
+	coef := 1 + rate/100
+
+	baseTTC := roundAmount(toFloat(it["unit_price_ttc_before_discount"]) * qty)
+	val := toFloat(it["line_discount_value"])
+	var discount float64
+	if mode, _ := it["line_discount_mode"].(string); mode == "amount" {
+		discount = roundAmount(math.Max(0, math.Min(val, baseTTC)))
+	} else {
+		discount = roundAmount(baseTTC * math.Max(0, math.Min(val, 100)) / 100)
+	}
+
+	finalTTC := roundAmount(baseTTC - discount)
+	finalHT := roundAmount(finalTTC / coef)
+	unitHT := 0.0
+	if qty > 0 {
+		unitHT = roundAmount(finalHT / qty)
+	}
+
+	it["unit_price_ht"] = unitHT
+	it["total_ht"] = finalHT
+	it["total_ttc"] = finalTTC
+}
+
+// recomputeDocumentTotals recalcule totaux, remise panier et ventilation TVA
+func recomputeDocumentTotals(record *models.Record, items []map[string]any) {
+	itemRate := func(it map[string]any) float64 {
+		if v, ok := it["vat_rate"]; ok {
+			return toFloat(v) * 100
+		}
+		if v, ok := it["tva_rate"]; ok {
+			return toFloat(v)
+		}
+		return 20
+	}
+
+	var subtotal float64
+	for _, it := range items {
+		subtotal = roundAmount(subtotal + toFloat(it["total_ttc"]))
+	}
+
+	// Remise panier : recalculée en %, plafonnée en montant, ventilée au prorata
+	cartDiscount := 0.0
+	switch record.GetString("cart_discount_mode") {
+	case "percent":
+		pct := math.Max(0, math.Min(record.GetFloat("cart_discount_value"), 100))
+		cartDiscount = roundAmount(subtotal * pct / 100)
+	case "amount":
+		cartDiscount = roundAmount(math.Max(0, math.Min(record.GetFloat("cart_discount_value"), subtotal)))
+	}
+
+	type vatEntry struct{ baseHT, vat, ttc float64 }
+	byRate := make(map[float64]*vatEntry)
+	var rates []float64
+	var totalHT, totalTTC float64
+
+	remaining := cartDiscount
+	for i, it := range items {
+		lineTTC := toFloat(it["total_ttc"])
+		rate := itemRate(it)
+
+		if cartDiscount > 0 && subtotal > 0 {
+			share := roundAmount(lineTTC / subtotal * cartDiscount)
+			if i == len(items)-1 {
+				share = remaining
+			}
+			remaining = roundAmount(remaining - share)
+			lineTTC = roundAmount(math.Max(0, lineTTC-share))
+		}
+		lineHT := roundAmount(lineTTC / (1 + rate/100))
+
+		e, ok := byRate[rate]
+		if !ok {
+			e = &vatEntry{}
+			byRate[rate] = e
+			rates = append(rates, rate)
+		}
+		e.baseHT = roundAmount(e.baseHT + lineHT)
+		e.vat = roundAmount(e.vat + lineTTC - lineHT)
+		e.ttc = roundAmount(e.ttc + lineTTC)
+
+		totalHT = roundAmount(totalHT + lineHT)
+		totalTTC = roundAmount(totalTTC + lineTTC)
+	}
+
+	sort.Float64s(rates)
+	breakdown := make([]map[string]any, 0, len(rates))
+	for _, rate := range rates {
+		e := byRate[rate]
+		breakdown = append(breakdown, map[string]any{
+			"rate":       rate,
+			"base_ht":    e.baseHT,
+			"vat":        e.vat,
+			"vat_amount": e.vat,
+			"total_ttc":  e.ttc,
+		})
+	}
+
+	record.Set("items", items)
+	record.Set("total_ht", totalHT)
+	record.Set("total_tva", roundAmount(totalTTC-totalHT))
+	record.Set("total_ttc", totalTTC)
+	if record.Collection().Schema.GetFieldByName("vat_breakdown") != nil {
+		record.Set("vat_breakdown", breakdown)
+	}
+	if record.Collection().Schema.GetFieldByName("cart_discount_ttc") != nil && record.GetString("cart_discount_mode") != "" {
+		record.Set("cart_discount_ttc", cartDiscount)
+	}
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func produitDeTest(prix float64, categories ...string) *models.Record {
+	col := &models.Collection{Name: "products", Schema: schema.NewSchema(
+		&schema.SchemaField{Name: "price_ttc", Type: schema.FieldTypeNumber},
+		&schema.SchemaField{Name: "tax_rate", Type: schema.FieldTypeNumber},
+		&schema.SchemaField{Name: "categories", Type: schema.FieldTypeRelation, Options: &schema.RelationOptions{}},
+	)}
+	rec := models.NewRecord(col)
+	rec.Id = "cordes"
+	rec.Set("price_ttc", prix)
+	rec.Set("tax_rate", 20)
+	rec.Set("categories", categories)
+	return rec
+}
+
+// Le client paie le prix le plus bas parmi les règles applicables, et un
+// palier ne s'applique qu'à partir de sa quantité.
+func TestResolvePrixLePlusBasEtPaliers(t *testing.T) {
+	r := &PriceResolver{
+		products: map[string]*models.Record{"cordes": produitDeTest(12, "accessoires")},
+		rules: []priceRule{
+			{id: "pro", listID: "l1", categoryID: "accessoires", mode: "percent", discountPct: 10},
+			{id: "pack", listID: "l2", productID: "cordes", mode: "fixed", priceTTC: 9, minQuantity: 3},
+		},
+	}
+
+	unite, _ := r.Resolve("cordes", 1)
+	if unite.UnitPriceTTC != 10.80 || unite.RuleID != "pro" {
+		t.Errorf("1 jeu : attendu 10.80 (remise catégorie), obtenu %v (%s)", unite.UnitPriceTTC, unite.RuleID)
+	}
+
+	pack, _ := r.Resolve("cordes", 3)
+	if pack.UnitPriceTTC != 9 || pack.RuleID != "pack" || pack.BasePriceTTC != 12 {
+		t.Errorf("3 jeux : attendu 9 (palier), obtenu %v (%s)", pack.UnitPriceTTC, pack.RuleID)
+	}
+}
+
+// À prix égal, la règle produit l'emporte sur la règle catégorie.
+func TestResolveRegleProduitPrioritaireAPrixEgal(t *testing.T) {
+	r := &PriceResolver{
+		products: map[string]*models.Record{"cordes": produitDeTest(10, "accessoires")},
+		rules: []priceRule{
+			{id: "cat", categoryID: "accessoires", mode: "fixed", priceTTC: 8},
+			{id: "prod", productID: "cordes", mode: "fixed", priceTTC: 8},
+		},
+	}
+
+	res, _ := r.Resolve("cordes", 1)
+	if res.RuleID != "prod" {
+		t.Errorf("attendu la règle produit, obtenu %s", res.RuleID)
+	}
+}
+
+// Une ligne de devis retarifée garde sa remise de ligne, recalculée sur le
+// nouveau prix, exactement comme dans l'éditeur.
+func TestApplyQuoteLineTotals(t *testing.T) {
+	ligne := map[string]any{
+		"quantity":                       2.0,
+		"tva_rate":                       20.0,
+		"unit_price_ttc_before_discount": 9.0,
+		"line_discount_mode":             "percent",
+		"line_discount_value":            10.0,
+	}
+	applyQuoteLineTotals(ligne)
+
+	if ligne["total_ttc"] != 16.2 || ligne["total_ht"] != 13.5 || ligne["unit_price_ht"] != 6.75 {
+		t.Errorf("ligne inattendue : %+v", ligne)
+	}
+}
@@ -0,0 +1,153 @@
+// backend/product_events.go
+// ═══════════════════════════════════════════════════════════════════════════
+// JOURNAL PRODUIT — ce qu'une modification de fiche change
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : hooks/product_event_hooks.go (toute fiche enregistrée)
+//
+// Une modification peut produire plusieurs événements : un prix et un nom
+// changés ensemble donnent `sale_price_changed` et `name_changed`. Les champs
+// sans type dédié (statut, TVA, marque…) se regroupent dans un seul
+// `product_updated`. Les clés de before / after / delta sont les noms des
+// champs de `products`, comme dans les événements écrits par la réception.
+//
+// L'origine d'une écriture (source, opérateur, route) voyage avec la fiche
+// jusqu'au hook : SetProductEventOrigin avant l'enregistrement. Une route qui
+// journalise elle-même (vente, réception, inventaire…) marque la fiche par
+// MarkProductJournaled pour ne pas être doublée.
+
+package backend
+
+import (
+	"math"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// ProductChange est un événement à écrire dans product_events
+type ProductChange struct {
+	EventType string
+	Before    map[string]any
+	After     map[string]any
+	Delta     map[string]any
+}
+
+// ProductEventOrigin : d'où vient l'écriture d'une fiche
+type ProductEventOrigin struct {
+	Source   string // valeur de product_events.source
+	Operator string
+	Route    string // « MÉTHODE /chemin » de la requête, vide hors requête
+	// Journaled : l'appelant écrit ses propres événements, le hook n'en
+	// ajoute pas
+	Journaled bool
+}
+
+// productEventOriginKey : donnée hors schéma de la fiche, ni écrite en base
+// ni exportée par l'API
+const productEventOriginKey = "@product_event_origin"
+
+// SetProductEventOrigin attache l'origine à la fiche, avant SaveRecord
+func SetProductEventOrigin(product *models.Record, origin ProductEventOrigin) {
+	product.Set(productEventOriginKey, origin)
+}
+
+// MarkProductJournaled : l'écriture est journalisée par l'appelant, dans sa
+// transaction
+func MarkProductJournaled(product *models.Record) {
+	SetProductEventOrigin(product, ProductEventOrigin{Journaled: true})
+}
+
+// TakeProductEventOrigin rend l'origine attachée à la fiche et l'efface : un
+// second enregistrement de la même fiche ne l'hérite pas
+func TakeProductEventOrigin(product *models.Record) (ProductEventOrigin, bool) {
+	origin, ok := product.Get(productEventOriginKey).(ProductEventOrigin)
+	if ok {
+		product.Set(productEventOriginKey, nil)
+	}
+	return origin, ok
+}
+
+// productTypedFields : un champ, un type d'événement
+var productTypedFields = []struct {
+	field, eventType string
+	numeric          bool
+}{
+	{"stock", "stock_updated", true},
+	{"price_ttc", "sale_price_changed", true},
+	{"purchase_price_ht", "purchase_price_changed", true},
+	{"name", "name_changed", false},
+	{"designation", "designation_changed", false},
+	{"sku", "sku_changed", false},
+	{"barcode", "barcode_changed", false},
+}
+
+// productOtherFields vont dans `product_updated`. La description et les
+// images n'y sont pas : trop lourdes pour le journal, et sans valeur d'audit.
+var productOtherFields = []string{
+	"status", "type", "tax_rate", "min_stock", "manage_stock",
+	"brand", "supplier", "serialized", "warranty_months",
+}
+
+// DiffProduct compare deux états d'une fiche produit et rend les événements
+// à journaliser, dans l'ordre de productTypedFields
+func DiffProduct(before, after *models.Record) []ProductChange {
+	changes := []ProductChange{}
+
+	for _, f := range productTypedFields {
+		if f.numeric {
+			b, a := before.GetFloat(f.field), after.GetFloat(f.field)
+			if math.Abs(a-b) < 0.001 {
+				continue
+			}
+			changes = append(changes, ProductChange{
+				EventType: f.eventType,
+				Before:    map[string]any{f.field: b},
+				After:     map[string]any{f.field: a},
+				Delta:     map[string]any{f.field: roundAmount(a - b)},
+			})
+			continue
+		}
+		b, a := before.GetString(f.field), after.GetString(f.field)
+		if a == b {
+			continue
+		}
+		changes = append(changes, ProductChange{
+			EventType: f.eventType,
+			Before:    map[string]any{f.field: b},
+			After:     map[string]any{f.field: a},
+		})
+	}
+
+	// Les catégories sont une liste : l'ordre de saisie ne compte pas
+	b, a := sortedStrings(before, "categories"), sortedStrings(after, "categories")
+	if !slices.Equal(a, b) {
+		changes = append(changes, ProductChange{
+			EventType: "category_changed",
+			Before:    map[string]any{"categories": b},
+			After:     map[string]any{"categories": a},
+		})
+	}
+
+	otherBefore, otherAfter := map[string]any{}, map[string]any{}
+	for _, field := range productOtherFields {
+		if before.GetString(field) == after.GetString(field) {
+			continue
+		}
+		otherBefore[field], otherAfter[field] = before.Get(field), after.Get(field)
+	}
+	if len(otherAfter) > 0 {
+		changes = append(changes, ProductChange{
+			EventType: "product_updated",
+			Before:    otherBefore,
+			After:     otherAfter,
+		})
+	}
+
+	return changes
+}
+
+func sortedStrings(record *models.Record, field string) []string {
+	values := slices.Clone(record.GetStringSlice(field))
+	slices.Sort(values)
+	return values
+}
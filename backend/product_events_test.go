@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+func fiche(champs map[string]any) *models.Record {
+	r := models.NewRecord(&models.Collection{})
+	for k, v := range champs {
+		r.Set(k, v)
+	}
+	return r
+}
+
+// Une fiche modifiée d'un coup donne un événement par champ suivi, et un seul
+// `product_updated` pour le reste.
+func TestDiffProduct(t *testing.T) {
+	avant := fiche(map[string]any{
+		"name": "Stratocaster", "price_ttc": 899.0, "stock": 3.0, "sku": "STR-1",
+		"categories": []string{"b", "a"}, "tax_rate": 20.0, "status": "draft",
+	})
+	apres := fiche(map[string]any{
+		"name": "Stratocaster Player", "price_ttc": 849.0, "stock": 3.0, "sku": "STR-1",
+		"categories": []string{"a", "b"}, "tax_rate": 20.0, "status": "published",
+	})
+
+	changes := DiffProduct(avant, apres)
+	attendus := []string{"sale_price_changed", "name_changed", "product_updated"}
+	if len(changes) != len(attendus) {
+		t.Fatalf("%d événements, attendu %d : %+v", len(changes), len(attendus), changes)
+	}
+	for i, c := range changes {
+		if c.EventType != attendus[i] {
+			t.Errorf("événement %d : %s, attendu %s", i, c.EventType, attendus[i])
+		}
+	}
+	if delta := changes[0].Delta["price_ttc"]; delta != -50.0 {
+		t.Errorf("delta prix : %v, attendu -50", delta)
+	}
+	if _, ok := changes[2].After["tax_rate"]; ok {
+		t.Errorf("TVA inchangée journalisée : %+v", changes[2].After)
+	}
+}
+
+// Réenregistrer une fiche sans rien changer ne journalise rien.
+func TestDiffProductSansChangement(t *testing.T) {
+	cas := []struct {
+		nom          string
+		avant, apres map[string]any
+	}{
+		{"fiche identique", map[string]any{"name": "Cordes", "stock": 12.0}, map[string]any{"name": "Cordes", "stock": 12.0}},
+		{"arrondi flottant", map[string]any{"price_ttc": 9.9}, map[string]any{"price_ttc": 9.900000001}},
+		{"catégories réordonnées", map[string]any{"categories": []string{"x", "y"}}, map[string]any{"categories": []string{"y", "x"}}},
+	}
+	for _, c := range cas {
+		if changes := DiffProduct(fiche(c.avant), fiche(c.apres)); len(changes) != 0 {
+			t.Errorf("%s : %+v", c.nom, changes)
+		}
+	}
+}
+
+// L'origine attachée à une fiche ne sert qu'à un enregistrement : le suivant,
+// sans origine, ne doit pas se faire passer pour le premier.
+func TestOrigineDuJournal(t *testing.T) {
+	r := fiche(map[string]any{"name": "Cordes"})
+	SetProductEventOrigin(r, ProductEventOrigin{Source: "import", Operator: "catalog-import"})
+
+	origine, ok := TakeProductEventOrigin(r)
+	if !ok || origine.Source != "import" || origine.Operator != "catalog-import" {
+		t.Fatalf("première lecture : %+v (%v)", origine, ok)
+	}
+	if origine, ok := TakeProductEventOrigin(r); ok {
+		t.Errorf("origine relue après usage : %+v", origine)
+	}
+	if _, exporte := r.PublicExport()[productEventOriginKey]; exporte {
+		t.Error("l'origine ne doit pas sortir dans l'export de la fiche")
+	}
+
+	MarkProductJournaled(r)
+	if origine, _ := TakeProductEventOrigin(r); !origine.Journaled {
+		t.Error("une fiche marquée journalisée doit être écartée par le hook")
+	}
+}
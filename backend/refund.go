@@ -290,6 +290,11 @@ func CreateCreditNote(dao *daos.Dao, input RefundInput, soldByUserID string) (*R
 		})
 	}
 
+	// ─── Fidélité : annulation des points gagnés sur le document d'origine ─────
+	if err := ApplyLoyaltyForDocument(dao, credit); err != nil {
+		log.Printf("⚠️ Fidélité non mise à jour pour l'avoir %s: %v", avoNumber, err)
+	}
+
 	return result, nil
 }
 
@@ -402,6 +407,9 @@ func buildPartialCreditItems(
 		cp["total_ht"] = lineHT
 		cp["total_tva"] = lineTVA
 		cp["total_ttc"] = lineTTC
+		// L'avoir ne porte pas la remise panier du ticket : sa ligne
+		// s'imprime au montant remboursé
+		delete(cp, "total_ttc_before_cart_discount")
 
 		totalHT += lineHT
 		totalTVA += lineTVA
@@ -458,6 +466,9 @@ func buildFullCreditItems(
 		cp["total_ht"] = lineHT
 		cp["total_tva"] = lineTVA
 		cp["total_ttc"] = lineTTC
+		// L'avoir ne porte pas la remise panier du ticket : sa ligne
+		// s'imprime au montant remboursé
+		delete(cp, "total_ttc_before_cart_discount")
 
 		totalHT += lineHT
 		totalTVA += lineTVA
@@ -0,0 +1,162 @@
+// backend/reports/cash_drawer.go
+// 🗄️ OUVERTURES DU TIROIR-CAISSE - journal et comptage par caissier
+//
+// Chaque impulsion d'ouverture envoyée par /api/pos/drawer/open laisse une
+// ligne dans cash_drawer_events, rattachée à la session et à l'utilisateur.
+// Les ouvertures « sans vente » (no_sale) sont la source de pertes classique :
+// les rapports X et Z les comptent par caissier.
+
+package reports
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Motifs d'ouverture du tiroir (cash_drawer_events.reason)
+const (
+	DrawerReasonSale         = "sale"
+	DrawerReasonRefund       = "refund"
+	DrawerReasonCashMovement = "cash_movement"
+	DrawerReasonSessionOpen  = "session_open"
+	DrawerReasonSessionClose = "session_close"
+	DrawerReasonNoSale       = "no_sale"
+	DrawerReasonTest         = "test"
+)
+
+// IsDrawerReason indique si le motif est connu
+func IsDrawerReason(reason string) bool {
+	switch reason {
+	case DrawerReasonSale, DrawerReasonRefund, DrawerReasonCashMovement,
+		DrawerReasonSessionOpen, DrawerReasonSessionClose, DrawerReasonNoSale, DrawerReasonTest:
+		return true
+	}
+	return false
+}
+
+// DrawerOpening décrit une ouverture à journaliser
+type DrawerOpening struct {
+	OwnerCompany string
+	CashRegister string
+	Session      string
+	CreatedBy    string
+	Reason       string
+	Note         string
+	Invoice      string
+	Printer      string
+}
+
+// RecordDrawerOpening enregistre une ouverture du tiroir
+func RecordDrawerOpening(app *pocketbase.PocketBase, opening DrawerOpening) (*models.Record, error) {
+	col, err := app.Dao().FindCollectionByNameOrId("cash_drawer_events")
+	if err != nil {
+		return nil, fmt.Errorf("collection cash_drawer_events introuvable: %w", err)
+	}
+
+	rec := models.NewRecord(col)
+	rec.Set("owner_company", opening.OwnerCompany)
+	rec.Set("cash_register", opening.CashRegister)
+	rec.Set("session", opening.Session)
+	rec.Set("created_by", opening.CreatedBy)
+	rec.Set("reason", opening.Reason)
+	rec.Set("note", opening.Note)
+	rec.Set("related_invoice", opening.Invoice)
+	rec.Set("printer", opening.Printer)
+
+	if err := app.Dao().SaveRecord(rec); err != nil {
+		return nil, fmt.Errorf("impossible de journaliser l'ouverture du tiroir: %w", err)
+	}
+	return rec, nil
+}
+
+// DrawerCashierCount : ouvertures d'un caissier sur la période du rapport
+type DrawerCashierCount struct {
+	Cashier     string `json:"cashier"`
+	CashierName string `json:"cashier_name"`
+	Openings    int    `json:"openings"`
+	NoSale      int    `json:"no_sale"`
+}
+
+// DrawerOpeningsSummary : bloc « ouvertures du tiroir » des rapports X et Z
+type DrawerOpeningsSummary struct {
+	Total     int                  `json:"total"`
+	NoSale    int                  `json:"no_sale"`
+	ByReason  map[string]int       `json:"by_reason"`
+	ByCashier []DrawerCashierCount `json:"by_cashier"`
+}
+
+// BuildDrawerSummary compte les ouvertures du tiroir des sessions données.
+// Retourne nil si le journal n'existe pas encore (base non migrée).
+func BuildDrawerSummary(app *pocketbase.PocketBase, sessionIDs []string) *DrawerOpeningsSummary {
+	if _, err := app.Dao().FindCollectionByNameOrId("cash_drawer_events"); err != nil {
+		return nil
+	}
+
+	summary := &DrawerOpeningsSummary{
+		ByReason:  map[string]int{},
+		ByCashier: []DrawerCashierCount{},
+	}
+	if len(sessionIDs) == 0 {
+		return summary
+	}
+
+	ids := make([]interface{}, len(sessionIDs))
+	for i, id := range sessionIDs {
+		ids[i] = id
+	}
+
+	var rows []struct {
+		CreatedBy string `db:"created_by"`
+		Reason    string `db:"reason"`
+		Count     int    `db:"count"`
+	}
+	err := app.Dao().DB().
+		Select("created_by", "reason", "COUNT(*) AS count").
+		From("cash_drawer_events").
+		Where(dbx.In("session", ids...)).
+		GroupBy("created_by", "reason").
+		All(&rows)
+	if err != nil {
+		log.Printf("⚠️ Erreur lecture ouvertures tiroir: %v", err)
+		return summary
+	}
+
+	byCashier := map[string]*DrawerCashierCount{}
+	for _, row := range rows {
+		summary.Total += row.Count
+		summary.ByReason[row.Reason] += row.Count
+
+		c, ok := byCashier[row.CreatedBy]
+		if !ok {
+			c = &DrawerCashierCount{
+				Cashier:     row.CreatedBy,
+				CashierName: getUserName(app, row.CreatedBy),
+			}
+			byCashier[row.CreatedBy] = c
+		}
+		c.Openings += row.Count
+		if row.Reason == DrawerReasonNoSale {
+			c.NoSale += row.Count
+			summary.NoSale += row.Count
+		}
+	}
+
+	for _, c := range byCashier {
+		summary.ByCashier = append(summary.ByCashier, *c)
+	}
+	// Les caissiers aux ouvertures sans vente les plus nombreuses en tête
+	sort.Slice(summary.ByCashier, func(i, j int) bool {
+		a, b := summary.ByCashier[i], summary.ByCashier[j]
+		if a.NoSale != b.NoSale {
+			return a.NoSale > b.NoSale
+		}
+		return a.CashierName < b.CashierName
+	})
+
+	return summary
+}
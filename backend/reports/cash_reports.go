@@ -96,15 +96,18 @@ type VATDetail struct {
 // ============================================================================
 
 type RapportX struct {
-	ReportType   string              `json:"report_type"`
-	GeneratedAt  time.Time           `json:"generated_at"`
-	Session      SessionInfo         `json:"session"`
-	OpeningFloat float64             `json:"opening_float"`
-	Sales        SalesSummaryX       `json:"sales"`
-	Refunds      RefundsSummaryX     `json:"refunds"`
-	Movements    MovementsSummaryX   `json:"movements"`
-	ExpectedCash ExpectedCashSummary `json:"expected_cash"`
-	Note         string              `json:"note"`
+	ReportType   string                 `json:"report_type"`
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Session      SessionInfo            `json:"session"`
+	OpeningFloat float64                `json:"opening_float"`
+	Sales        SalesSummaryX          `json:"sales"`
+	Refunds      RefundsSummaryX        `json:"refunds"`
+	Movements    MovementsSummaryX      `json:"movements"`
+	ExpectedCash ExpectedCashSummary    `json:"expected_cash"`
+	CashCount    *CashCountSummary      `json:"cash_count,omitempty"`
+	Shifts       []ShiftSummary         `json:"shifts,omitempty"`
+	Drawer       *DrawerOpeningsSummary `json:"drawer,omitempty"`
+	Note         string                 `json:"note"`
 }
 
 type SessionInfo struct {
@@ -142,6 +145,60 @@ type ExpectedCashSummary struct {
 	Total        float64 `json:"total"`
 }
 
+// CashCountSummary présente le comptage par coupure et l'écart de caisse
+// d'une session (renseigné à l'ouverture et à la fermeture)
+type CashCountSummary struct {
+	OpeningCount    any     `json:"opening_count,omitempty"`
+	ClosingCount    any     `json:"closing_count,omitempty"`
+	Expected        float64 `json:"expected"`
+	Counted         float64 `json:"counted"`
+	Difference      float64 `json:"difference"`
+	Threshold       float64 `json:"threshold"`
+	Status          string  `json:"status"`
+	Reason          string  `json:"reason,omitempty"`
+	JustifiedBy     string  `json:"justified_by,omitempty"`
+	JustifiedByName string  `json:"justified_by_name,omitempty"`
+	JustifiedAt     string  `json:"justified_at,omitempty"`
+}
+
+// buildCashCountSummary lit le comptage et l'écart stockés sur la session.
+// Retourne nil pour une session sans comptage (ancien fonctionnement).
+func buildCashCountSummary(app *pocketbase.PocketBase, session *models.Record) *CashCountSummary {
+	status := session.GetString("discrepancy_status")
+	opening := jsonFieldOrNil(session, "opening_count")
+	if status == "" && opening == nil {
+		return nil
+	}
+
+	summary := &CashCountSummary{
+		OpeningCount: opening,
+		ClosingCount: jsonFieldOrNil(session, "closing_count"),
+		Status:       status,
+		Threshold:    roundAmount(session.GetFloat("discrepancy_threshold")),
+		Reason:       session.GetString("discrepancy_reason"),
+		JustifiedBy:  session.GetString("discrepancy_justified_by"),
+		JustifiedAt:  session.GetString("discrepancy_justified_at"),
+	}
+	if status != "" {
+		summary.Expected = roundAmount(session.GetFloat("expected_cash_total"))
+		summary.Counted = roundAmount(session.GetFloat("counted_cash_total"))
+		summary.Difference = roundAmount(session.GetFloat("cash_difference"))
+	}
+	if summary.JustifiedBy != "" {
+		summary.JustifiedByName = getUserName(app, summary.JustifiedBy)
+	}
+	return summary
+}
+
+// jsonFieldOrNil retourne la valeur brute d'un champ JSON, nil s'il est vide
+func jsonFieldOrNil(rec *models.Record, field string) any {
+	raw := rec.GetString(field)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
+
 type RefundsSummaryX struct {
 	CreditNotesCount int                `json:"credit_notes_count"`
 	TotalTTC         float64            `json:"total_ttc"`
@@ -484,7 +541,10 @@ func GenerateRapportX(app *pocketbase.PocketBase, sessionID string) (*RapportX,
 			Movements:    movementsTotal,
 			Total:        expectedCash,
 		},
-		Note: "Lecture intermediaire - La caisse reste ouverte",
+		CashCount: buildCashCountSummary(app, session),
+		Shifts:    BuildShiftSummaries(app, sessionID),
+		Drawer:    BuildDrawerSummary(app, []string{sessionID}),
+		Note:      "Lecture intermediaire - La caisse reste ouverte",
 	}
 
 	return rapport, nil
@@ -623,6 +683,7 @@ type SessionSummary struct {
 	CashDifference    float64              `json:"cash_difference"`
 	TotalsByMethod    map[string]float64   `json:"totals_by_method"`
 	VATByRate         map[string]VATDetail `json:"vat_by_rate"` // 🆕
+	CashCount         *CashCountSummary    `json:"cash_count,omitempty"`
 }
 
 type DailyTotalsSummary struct {
@@ -643,6 +704,7 @@ type DailyTotalsSummary struct {
 	RefundsByMethod     map[string]float64              `json:"refunds_by_method"`
 	NetByMethod         map[string]float64              `json:"net_by_method"`
 	ByCustomerType      map[string]*CustomerTypeSummary `json:"by_customer_type"`
+	Drawer              *DrawerOpeningsSummary          `json:"drawer,omitempty"` // hors hash
 }
 
 // GenerateRapportZ génère ET sauvegarde un rapport Z
@@ -910,6 +972,15 @@ func GenerateRapportZ(app *pocketbase.PocketBase, cashRegisterID string, date st
 			cashDiff = 0
 		}
 
+		// Session fermée avec comptage : l'écart a été figé à la fermeture
+		// (depuis le X) et éventuellement justifié — on reprend ces valeurs.
+		cashCount := buildCashCountSummary(app, session)
+		if cashCount != nil && cashCount.Status != "" {
+			expectedCash = cashCount.Expected
+			countedCash = cashCount.Counted
+			cashDiff = cashCount.Difference
+		}
+
 		totalInvoiceCount += invoiceCount
 		totalHT += sessionHT
 		totalTVA += sessionTVA
@@ -962,6 +1033,7 @@ func GenerateRapportZ(app *pocketbase.PocketBase, cashRegisterID string, date st
 			CashDifference:    cashDiff,
 			TotalsByMethod:    sessionMethodTotals,
 			VATByRate:         sessionVATByRate,
+			CashCount:         cashCount,
 		})
 
 		fmt.Printf("📊 Session %s: %d tickets, %.2f € HT, %.2f € TVA, %.2f € TTC\n",
@@ -1118,6 +1190,7 @@ func GenerateRapportZ(app *pocketbase.PocketBase, cashRegisterID string, date st
 			CreditNotesTotal:    creditNotesTotal,
 			RefundsByMethod:     refundsByMethod,
 			ByCustomerType:      globalByCustomerType,
+			Drawer:              BuildDrawerSummary(app, sessionIds),
 		},
 		Note:     "Rapport Z - Document inaltérable",
 		IsLocked: true,
@@ -0,0 +1,324 @@
+// backend/reports/cash_shifts.go
+// 🔄 RELÈVES DE CAISSIER - découpage d'une session par caissier
+//
+// Une session appartient à la caisse, un shift au caissier qui tient le
+// tiroir. Chaque relève compte le tiroir : l'écart du shift sortant se
+// calcule comme celui de la session (rapport X), mais sur sa seule fenêtre :
+//
+//	attendu = espèces reprises + mouvements espèces du shift
+//
+// La somme des écarts des shifts d'une session est donc égale à l'écart de
+// la session elle-même.
+
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ShiftSummary est la vue d'un shift dans le rapport X
+type ShiftSummary struct {
+	ID             string    `json:"id"`
+	Cashier        string    `json:"cashier"`
+	CashierName    string    `json:"cashier_name"`
+	HandedOverTo   string    `json:"handed_over_to,omitempty"`
+	Status         string    `json:"status"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at,omitempty"`
+	TicketCount    int       `json:"ticket_count"`
+	TotalTTC       float64   `json:"total_ttc"`
+	StartCash      float64   `json:"start_cash"`
+	MovementsTotal float64   `json:"movements_total"`
+	ExpectedCash   float64   `json:"expected_cash"`
+	Counted        bool      `json:"counted"`
+	CountedCash    float64   `json:"counted_cash"`
+	CashDifference float64   `json:"cash_difference"`
+}
+
+// FindOpenCashShift retourne le shift en cours d'une session (nil si aucun)
+func FindOpenCashShift(dao *daos.Dao, sessionID string) *models.Record {
+	shift, err := dao.FindFirstRecordByFilter(
+		"cash_shifts",
+		"session = {:s} && status = 'open'",
+		dbx.Params{"s": sessionID},
+	)
+	if err != nil {
+		return nil
+	}
+	return shift
+}
+
+// OpenCashShift démarre un shift pour un caissier sur la session. Les
+// fonctions d'écriture prennent un *daos.Dao : une relève clôture et ouvre
+// dans la même transaction.
+func OpenCashShift(dao *daos.Dao, session *models.Record, cashierID string, startCash float64, startCount any, startedAt time.Time) (*models.Record, error) {
+	col, err := dao.FindCollectionByNameOrId("cash_shifts")
+	if err != nil {
+		return nil, fmt.Errorf("collection cash_shifts introuvable: %w", err)
+	}
+
+	shift := models.NewRecord(col)
+	shift.Set("owner_company", session.GetString("owner_company"))
+	shift.Set("session", session.Id)
+	shift.Set("cash_register", session.GetString("cash_register"))
+	shift.Set("cashier", cashierID)
+	shift.Set("status", "open")
+	shift.Set("started_at", startedAt)
+	shift.Set("start_cash", roundAmount(startCash))
+	if startCount != nil {
+		shift.Set("start_count", startCount)
+	}
+
+	if err := dao.SaveRecord(shift); err != nil {
+		return nil, fmt.Errorf("impossible d'ouvrir le shift: %w", err)
+	}
+	return shift, nil
+}
+
+// EnsureOpenCashShift retourne le shift en cours, ou crée le premier shift
+// d'une session ouverte avant l'existence des relèves (caissier = opened_by,
+// espèces reprises = fond de caisse).
+func EnsureOpenCashShift(dao *daos.Dao, session *models.Record) (*models.Record, error) {
+	if shift := FindOpenCashShift(dao, session.Id); shift != nil {
+		return shift, nil
+	}
+
+	existing, _ := dao.FindRecordsByFilter(
+		"cash_shifts", "session = {:s}", "", 1, 0, dbx.Params{"s": session.Id},
+	)
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("aucun shift en cours sur cette session")
+	}
+
+	return OpenCashShift(dao, session,
+		session.GetString("opened_by"),
+		session.GetFloat("opening_float"),
+		jsonFieldOrNil(session, "opening_count"),
+		session.GetDateTime("opened_at").Time(),
+	)
+}
+
+// CloseCashShift fige l'attendu, le compté et l'écart d'un shift.
+// counted nil = fermeture sans comptage : l'écart reste inconnu.
+func CloseCashShift(dao *daos.Dao, shift *models.Record, counted *float64, endCount any, handedOverTo, note string, endedAt time.Time) error {
+	expected, _, err := computeShiftExpectedCash(dao, shift, endedAt)
+	if err != nil {
+		return err
+	}
+
+	shift.Set("status", "closed")
+	shift.Set("ended_at", endedAt)
+	shift.Set("expected_cash", expected)
+	if counted != nil {
+		shift.Set("counted", true)
+		shift.Set("counted_cash", roundAmount(*counted))
+		shift.Set("cash_difference", roundAmount(*counted-expected))
+	}
+	if endCount != nil {
+		shift.Set("end_count", endCount)
+	}
+	if handedOverTo != "" {
+		shift.Set("handed_over_to", handedOverTo)
+	}
+	if note != "" {
+		shift.Set("note", note)
+	}
+
+	if err := dao.SaveRecord(shift); err != nil {
+		return fmt.Errorf("impossible de clôturer le shift: %w", err)
+	}
+	return nil
+}
+
+// shiftWindow retourne les bornes [début, fin) d'un shift au format PocketBase
+func shiftWindow(shift *models.Record, end time.Time) (string, string) {
+	start := shift.GetDateTime("started_at").String()
+	if ended := shift.GetDateTime("ended_at"); !ended.IsZero() {
+		return start, ended.String()
+	}
+	endDT, _ := types.ParseDateTime(end)
+	return start, endDT.String()
+}
+
+// computeShiftExpectedCash calcule les espèces attendues en fin de shift,
+// avec les mêmes règles de mouvements que le rapport X.
+func computeShiftExpectedCash(dao *daos.Dao, shift *models.Record, end time.Time) (expected, movementsTotal float64, err error) {
+	start, stop := shiftWindow(shift, end)
+
+	movements, err := dao.FindRecordsByFilter(
+		"cash_movements",
+		"session = {:s} && created >= {:start} && created < {:stop}",
+		"created",
+		0,
+		0,
+		dbx.Params{"s": shift.GetString("session"), "start": start, "stop": stop},
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erreur chargement mouvements du shift: %w", err)
+	}
+
+	for _, mov := range movements {
+		amount := mov.GetFloat("amount")
+		switch mov.GetString("movement_type") {
+		case "cash_in":
+			movementsTotal += amount
+		case "cash_out", "refund_out", "safe_drop":
+			movementsTotal -= amount
+		}
+	}
+
+	movementsTotal = roundAmount(movementsTotal)
+	return roundAmount(shift.GetFloat("start_cash") + movementsTotal), movementsTotal, nil
+}
+
+// BuildShiftSummaries découpe une session par shift pour le rapport X
+func BuildShiftSummaries(app *pocketbase.PocketBase, sessionID string) []ShiftSummary {
+	shifts, err := app.Dao().FindRecordsByFilter(
+		"cash_shifts", "session = {:s}", "started_at", 0, 0, dbx.Params{"s": sessionID},
+	)
+	if err != nil || len(shifts) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	summaries := make([]ShiftSummary, 0, len(shifts))
+
+	for _, shift := range shifts {
+		start, stop := shiftWindow(shift, now)
+
+		tickets, _ := app.Dao().FindRecordsByFilter(
+			"invoices",
+			"session = {:s} && is_pos_ticket = true && (invoice_type = 'invoice' || invoice_type = 'deposit') && status != 'draft' && created >= {:start} && created < {:stop}",
+			"",
+			0,
+			0,
+			dbx.Params{"s": sessionID, "start": start, "stop": stop},
+		)
+		var totalTTC float64
+		for _, t := range tickets {
+			totalTTC += t.GetFloat("total_ttc")
+		}
+
+		expected, movementsTotal, _ := computeShiftExpectedCash(app.Dao(), shift, now)
+
+		summary := ShiftSummary{
+			ID:             shift.Id,
+			Cashier:        shift.GetString("cashier"),
+			CashierName:    getUserName(app, shift.GetString("cashier")),
+			HandedOverTo:   shift.GetString("handed_over_to"),
+			Status:         shift.GetString("status"),
+			StartedAt:      shift.GetDateTime("started_at").Time(),
+			TicketCount:    len(tickets),
+			TotalTTC:       roundAmount(totalTTC),
+			StartCash:      roundAmount(shift.GetFloat("start_cash")),
+			MovementsTotal: movementsTotal,
+			ExpectedCash:   expected,
+		}
+		if shift.GetString("status") == "closed" {
+			summary.EndedAt = shift.GetDateTime("ended_at").Time()
+			summary.ExpectedCash = roundAmount(shift.GetFloat("expected_cash"))
+			summary.Counted = shift.GetBool("counted")
+			summary.CountedCash = roundAmount(shift.GetFloat("counted_cash"))
+			summary.CashDifference = roundAmount(shift.GetFloat("cash_difference"))
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// ============================================================================
+// ÉCARTS PAR CAISSIER DANS LE TEMPS
+// ============================================================================
+
+// CashierOverShort cumule les écarts des shifts comptés d'un caissier
+type CashierOverShort struct {
+	Cashier         string  `json:"cashier"`
+	CashierName     string  `json:"cashier_name"`
+	ShiftsCount     int     `json:"shifts_count"`
+	ShiftsWithGap   int     `json:"shifts_with_gap"`
+	OverTotal       float64 `json:"over_total"`  // surplus cumulés (≥ 0)
+	ShortTotal      float64 `json:"short_total"` // manques cumulés (≤ 0)
+	NetDifference   float64 `json:"net_difference"`
+	LargestShortage float64 `json:"largest_shortage"`
+}
+
+// GenerateCashierOverShort agrège les écarts par caissier sur une période.
+// from / to au format YYYY-MM-DD (inclus) ; cashierID optionnel.
+func GenerateCashierOverShort(app *pocketbase.PocketBase, ownerCompany, from, to, cashierID string) ([]CashierOverShort, error) {
+	filter := "owner_company = {:company} && status = 'closed' && counted = true"
+	params := dbx.Params{"company": ownerCompany}
+
+	if from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("format de date invalide: %w", err)
+		}
+		filter += " && ended_at >= {:from}"
+		params["from"] = fromDate.Format("2006-01-02") + " 00:00:00"
+	}
+	if to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("format de date invalide: %w", err)
+		}
+		filter += " && ended_at < {:to}"
+		params["to"] = toDate.Add(24*time.Hour).Format("2006-01-02") + " 00:00:00"
+	}
+	if cashierID != "" {
+		filter += " && cashier = {:cashier}"
+		params["cashier"] = cashierID
+	}
+
+	shifts, err := app.Dao().FindRecordsByFilter("cash_shifts", filter, "ended_at", 0, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("erreur chargement shifts: %w", err)
+	}
+
+	byCashier := make(map[string]*CashierOverShort)
+	for _, shift := range shifts {
+		id := shift.GetString("cashier")
+		entry, ok := byCashier[id]
+		if !ok {
+			entry = &CashierOverShort{Cashier: id, CashierName: getUserName(app, id)}
+			byCashier[id] = entry
+		}
+
+		diff := shift.GetFloat("cash_difference")
+		entry.ShiftsCount++
+		if abs(diff) >= 0.005 {
+			entry.ShiftsWithGap++
+		}
+		if diff > 0 {
+			entry.OverTotal += diff
+		} else {
+			entry.ShortTotal += diff
+			if diff < entry.LargestShortage {
+				entry.LargestShortage = diff
+			}
+		}
+		entry.NetDifference += diff
+	}
+
+	result := make([]CashierOverShort, 0, len(byCashier))
+	for _, entry := range byCashier {
+		entry.OverTotal = roundAmount(entry.OverTotal)
+		entry.ShortTotal = roundAmount(entry.ShortTotal)
+		entry.NetDifference = roundAmount(entry.NetDifference)
+		entry.LargestShortage = roundAmount(entry.LargestShortage)
+		result = append(result, *entry)
+	}
+	// Les plus gros manques en premier
+	sort.Slice(result, func(i, j int) bool { return result[i].ShortTotal < result[j].ShortTotal })
+
+	return result, nil
+}
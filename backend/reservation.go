@@ -0,0 +1,265 @@
+// backend/reservation.go
+// ═══════════════════════════════════════════════════════════════════════════
+// RÉSERVATIONS DE STOCK — ce qui est promis et ne se vend plus librement
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : order_hooks.go (bons de commande), reservation_routes.go
+// (paniers en attente, disponibilité), pos_routes.go (contrôle du ticket)
+//
+// Règles :
+//   - un bon de commande confirmé ou en cours, et pas encore facturé, réserve
+//     les quantités de ses lignes catalogue (hors services) ;
+//   - livré, facturé, annulé ou repassé en brouillon, il les libère ;
+//   - un panier mis en attente réserve si la société a fixé une durée
+//     (`reservation_cart_minutes`), et libère quand on le reprend ;
+//   - une réservation dont `expires_at` est passé est libérée (`expired`) à
+//     la lecture suivante, il n'y a pas de tâche planifiée ;
+//   - disponible = stock − réservé ; la caisse avertit ou refuse selon
+//     `companies.reservation_policy`.
+// Le stock lui-même ne bouge pas : une réservation n'est pas un mouvement.
+
+package backend
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ReservationSettings sont les réglages de réservation d'une société
+type ReservationSettings struct {
+	Policy      string `json:"policy"` // warn | block
+	OrderDays   int    `json:"order_days"`
+	CartMinutes int    `json:"cart_minutes"`
+}
+
+// Blocks indique si la caisse refuse la vente d'unités réservées
+func (s ReservationSettings) Blocks() bool {
+	return s.Policy == "block"
+}
+
+// GetReservationSettings lit les réglages de la société ; sans société ou
+// sans réglage, la caisse avertit et rien n'expire
+func GetReservationSettings(dao *daos.Dao, companyID string) ReservationSettings {
+	settings := ReservationSettings{Policy: "warn"}
+	company, err := dao.FindRecordById("companies", companyID)
+	if err != nil {
+		return settings
+	}
+	if company.GetString("reservation_policy") == "block" {
+		settings.Policy = "block"
+	}
+	settings.OrderDays = company.GetInt("reservation_order_days")
+	settings.CartMinutes = company.GetInt("reservation_cart_minutes")
+	return settings
+}
+
+// OrderReserves dit si un bon de commande doit tenir ses réservations, et
+// sinon pour quelle raison il les libère
+func OrderReserves(status, invoiceID string) (bool, string) {
+	switch {
+	case invoiceID != "" || status == "billed":
+		return false, "billed"
+	case status == "confirmed" || status == "in_progress":
+		return true, ""
+	case status == "delivered" || status == "cancelled":
+		return false, status
+	default:
+		return false, "reopened"
+	}
+}
+
+// ReservationLine est la quantité d'un produit à réserver
+type ReservationLine struct {
+	ProductID string  `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// ReservationLines regroupe par produit les lignes catalogue d'un document,
+// dans l'ordre de leur première apparition
+func ReservationLines(items []map[string]any) []ReservationLine {
+	lines := []ReservationLine{}
+	index := map[string]int{}
+	for _, it := range items {
+		pid, _ := it["product_id"].(string)
+		qty := toFloat(it["quantity"])
+		if pid == "" || qty <= 0 {
+			continue
+		}
+		if i, ok := index[pid]; ok {
+			lines[i].Quantity += qty
+			continue
+		}
+		index[pid] = len(lines)
+		lines = append(lines, ReservationLine{ProductID: pid, Quantity: qty})
+	}
+	return lines
+}
+
+// ReservedShortfall rend la part d'une vente de `qty` qui entame les unités
+// réservées, quand le stock libre (stock − réservé) ne suffit pas
+func ReservedShortfall(stock, reserved, qty float64) float64 {
+	if reserved <= 0 || qty <= 0 {
+		return 0
+	}
+	free := math.Max(0, stock-reserved)
+	return math.Min(reserved, math.Max(0, qty-free))
+}
+
+// ReleaseExpiredReservations libère les réservations d'une société dont le
+// délai est passé
+func ReleaseExpiredReservations(dao *daos.Dao, companyID string) {
+	now := types.NowDateTime()
+	expired, err := dao.FindRecordsByFilter(
+		"stock_reservations",
+		"owner_company = {:company} && status = 'active' && expires_at != '' && expires_at <= {:now}",
+		"", 0, 0,
+		dbx.Params{"company": companyID, "now": now.String()},
+	)
+	if err != nil {
+		return
+	}
+	for _, r := range expired {
+		if err := releaseReservation(dao, r, "expired", now); err != nil {
+			log.Printf("⚠️ Réservation %s non libérée : %v", r.Id, err)
+		}
+	}
+	if len(expired) > 0 {
+		log.Printf("⏱️ %d réservation(s) expirée(s) libérée(s)", len(expired))
+	}
+}
+
+// ActiveReservations rend les réservations en cours des produits donnés,
+// les plus anciennes d'abord, après avoir libéré celles qui ont expiré
+func ActiveReservations(dao *daos.Dao, companyID string, productIDs []string) ([]*models.Record, error) {
+	records := []*models.Record{}
+	if len(productIDs) == 0 {
+		return records, nil
+	}
+	ReleaseExpiredReservations(dao, companyID)
+
+	ids := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id
+	}
+	err := dao.RecordQuery("stock_reservations").
+		AndWhere(dbx.HashExp{"owner_company": companyID, "status": "active"}).
+		AndWhere(dbx.In("product", ids...)).
+		OrderBy("created ASC").
+		All(&records)
+	return records, err
+}
+
+// SyncOrderReservations aligne les réservations d'un bon de commande sur son
+// statut et ses lignes. Les réservations gardent leur date d'expiration quand
+// seule la quantité change ; une réservation expirée ne revient qu'à la
+// modification suivante du bon de commande.
+func SyncOrderReservations(dao *daos.Dao, order *models.Record, userID string) error {
+	col, err := dao.FindCollectionByNameOrId("stock_reservations")
+	if err != nil {
+		return nil // pas encore migré
+	}
+	now := types.NowDateTime()
+
+	existing, err := dao.FindRecordsByFilter(
+		"stock_reservations", "order = {:order} && status = 'active'", "", 0, 0,
+		dbx.Params{"order": order.Id},
+	)
+	if err != nil {
+		return err
+	}
+
+	reserves, reason := OrderReserves(order.GetString("status"), order.GetString("invoice_id"))
+	if !reserves {
+		for _, r := range existing {
+			if err := releaseReservation(dao, r, reason, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var items []map[string]any
+	_ = order.UnmarshalJSONField("items", &items)
+	byProduct := map[string]*models.Record{}
+	for _, r := range existing {
+		byProduct[r.GetString("product")] = r
+	}
+
+	settings := GetReservationSettings(dao, order.GetString("owner_company"))
+	for _, line := range ReservationLines(items) {
+		product, err := dao.FindRecordById("products", line.ProductID)
+		if err != nil || product.GetString("type") == "service" {
+			continue
+		}
+		r, ok := byProduct[line.ProductID]
+		delete(byProduct, line.ProductID)
+		if !ok {
+			r = models.NewRecord(col)
+			r.Set("owner_company", order.GetString("owner_company"))
+			r.Set("product", product.Id)
+			r.Set("kind", "order")
+			r.Set("order", order.Id)
+			r.Set("status", "active")
+			if settings.OrderDays > 0 {
+				r.Set("expires_at", now.Time().AddDate(0, 0, settings.OrderDays))
+			}
+			if userID != "" {
+				r.Set("created_by", userID)
+			}
+		} else if r.GetFloat("quantity") == line.Quantity &&
+			r.GetString("label") == order.GetString("customer_name") {
+			continue
+		}
+		r.Set("product_name", product.GetString("name"))
+		r.Set("order_number", order.GetString("number"))
+		r.Set("label", order.GetString("customer_name"))
+		r.Set("quantity", line.Quantity)
+		if err := dao.SaveRecord(r); err != nil {
+			return fmt.Errorf("réservation de %q : %w", product.GetString("name"), err)
+		}
+	}
+
+	// Produits retirés du bon de commande
+	for _, r := range byProduct {
+		if err := releaseReservation(dao, r, "changed", now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseOrderReservations libère toutes les réservations d'un bon de
+// commande, par exemple avant sa suppression
+func ReleaseOrderReservations(dao *daos.Dao, orderID, reason string) error {
+	records, err := dao.FindRecordsByFilter(
+		"stock_reservations", "order = {:order} && status = 'active'", "", 0, 0,
+		dbx.Params{"order": orderID},
+	)
+	if err != nil {
+		return nil // pas encore migré
+	}
+	now := types.NowDateTime()
+	for _, r := range records {
+		if err := releaseReservation(dao, r, reason, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseReservation libère une réservation active
+func ReleaseReservation(dao *daos.Dao, r *models.Record, reason string) error {
+	return releaseReservation(dao, r, reason, types.NowDateTime())
+}
+
+func releaseReservation(dao *daos.Dao, r *models.Record, reason string, now types.DateTime) error {
+	r.Set("status", "released")
+	r.Set("release_reason", reason)
+	r.Set("released_at", now)
+	return dao.SaveRecord(r)
+}
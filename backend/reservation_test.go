@@ -0,0 +1,65 @@
+package backend
+
+import "testing"
+
+// Un bon de commande ne réserve qu'entre sa confirmation et sa livraison ou
+// sa facturation.
+func TestOrderReserves(t *testing.T) {
+	cas := []struct {
+		statut, facture string
+		reserve         bool
+		raison          string
+	}{
+		{"draft", "", false, "reopened"},
+		{"confirmed", "", true, ""},
+		{"in_progress", "", true, ""},
+		{"confirmed", "inv123", false, "billed"},
+		{"billed", "", false, "billed"},
+		{"delivered", "", false, "delivered"},
+		{"cancelled", "", false, "cancelled"},
+	}
+	for _, c := range cas {
+		reserve, raison := OrderReserves(c.statut, c.facture)
+		if reserve != c.reserve || raison != c.raison {
+			t.Errorf("%s/%q : %v %q, attendu %v %q", c.statut, c.facture, reserve, raison, c.reserve, c.raison)
+		}
+	}
+}
+
+// Deux lignes du même produit réservent leur somme ; une ligne libre n'en
+// réserve pas.
+func TestReservationLines(t *testing.T) {
+	lignes := ReservationLines([]map[string]any{
+		{"product_id": "strat", "quantity": 1.0},
+		{"description": "Réglage", "quantity": 1.0},
+		{"product_id": "cordes", "quantity": 3.0},
+		{"product_id": "strat", "quantity": 1.0},
+		{"product_id": "ampli", "quantity": 0.0},
+	})
+	if len(lignes) != 2 {
+		t.Fatalf("%d lignes, attendu 2 : %+v", len(lignes), lignes)
+	}
+	if lignes[0].ProductID != "strat" || lignes[0].Quantity != 2 {
+		t.Errorf("première ligne : %+v", lignes[0])
+	}
+}
+
+// Seule la part de la vente que le stock libre ne couvre pas entame les
+// réservations.
+func TestReservedShortfall(t *testing.T) {
+	cas := []struct {
+		nom                            string
+		stock, reserve, vendu, attendu float64
+	}{
+		{"rien de réservé", 1, 0, 1, 0},
+		{"stock libre suffisant", 3, 1, 2, 0},
+		{"la dernière guitare est promise", 1, 1, 1, 1},
+		{"une sur deux", 2, 1, 2, 1},
+		{"stock déjà négatif", -1, 1, 1, 1},
+	}
+	for _, c := range cas {
+		if obtenu := ReservedShortfall(c.stock, c.reserve, c.vendu); obtenu != c.attendu {
+			t.Errorf("%s : %v, attendu %v", c.nom, obtenu, c.attendu)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// backend/rma.go
+// ═══════════════════════════════════════════════════════════════════════════
+// RETOURS FOURNISSEUR ET RÉPARATIONS (RMA) — le cycle d'un article défectueux
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : rma_routes.go
+//
+// Règles :
+//   - un RMA part d'un article rapporté par un client (`customer`) ou trouvé
+//     défectueux en stock (`stock`) ;
+//   - il va : ouvert → expédié → réparé, remplacé ou crédité → rendu ;
+//     seul un RMA encore ouvert s'annule ;
+//   - un article sorti du stock attend au bac RMA (emplacement `rma` de la
+//     société) : il y entre à l'ouverture et au retour du fournisseur, il en
+//     sort à l'expédition et quand on le remet en rayon ;
+//   - l'article d'un client n'est jamais à nous : il ne touche ni le bac ni
+//     `products.stock`, seul le statut du RMA dit où il est ;
+//   - « rendu » et « annulé » le ramènent d'où il vient : au client, ou à
+//     l'emplacement d'où il a été sorti.
+// Un avoir fournisseur se rattache au RMA à toute étape ; « crédité » est
+// l'issue où rien ne revient.
+
+package backend
+
+import "fmt"
+
+// Étapes d'un RMA
+const (
+	RmaOpen     = "open"
+	RmaShip     = "ship"
+	RmaRepaired = "repaired"
+	RmaReplaced = "replaced"
+	RmaCredited = "credited"
+	RmaReturn   = "return"
+	RmaCancel   = "cancel"
+)
+
+// Statut atteint par chaque étape, depuis les statuts où elle est permise
+var rmaTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	RmaShip:     {[]string{"opened"}, "shipped"},
+	RmaRepaired: {[]string{"shipped"}, "repaired"},
+	RmaReplaced: {[]string{"shipped"}, "replaced"},
+	RmaCredited: {[]string{"shipped"}, "credited"},
+	RmaReturn:   {[]string{"repaired", "replaced"}, "returned"},
+	RmaCancel:   {[]string{"opened"}, "cancelled"},
+}
+
+// RmaTransition rend le statut qu'atteint un RMA `status` par l'étape
+// `action`, ou une erreur si elle n'est pas permise
+func RmaTransition(status, action string) (string, error) {
+	t, ok := rmaTransitions[action]
+	if !ok {
+		return "", fmt.Errorf("étape inconnue : %q", action)
+	}
+	for _, from := range t.from {
+		if from == status {
+			return t.to, nil
+		}
+	}
+	return "", fmt.Errorf("étape %q impossible depuis le statut %q", action, status)
+}
+
+// RmaStockMove dit ce qu'une étape fait au stock : `Delta` au bac RMA, et
+// d'où vient ou où va l'article. `Counterpart` vaut `stock` quand l'article
+// passe d'un emplacement de la société à l'autre : le total ne bouge pas.
+type RmaStockMove struct {
+	Delta       float64
+	Counterpart string // supplier | stock ; vide : rien ne bouge
+}
+
+// RmaMove rend le mouvement de stock d'une étape, pour un RMA d'origine
+// `origin` (customer | stock) portant sur `qty` unités. Un RMA client ne
+// bouge jamais le stock.
+func RmaMove(origin, action string, qty float64) RmaStockMove {
+	if origin != "stock" {
+		return RmaStockMove{}
+	}
+	switch action {
+	case RmaOpen:
+		return RmaStockMove{Delta: qty, Counterpart: "stock"}
+	case RmaShip:
+		return RmaStockMove{Delta: -qty, Counterpart: "supplier"}
+	case RmaRepaired, RmaReplaced:
+		return RmaStockMove{Delta: qty, Counterpart: "supplier"}
+	case RmaReturn, RmaCancel:
+		return RmaStockMove{Delta: -qty, Counterpart: "stock"}
+	default:
+		return RmaStockMove{}
+	}
+}
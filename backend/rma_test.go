@@ -0,0 +1,60 @@
+package backend
+
+import "testing"
+
+// Un RMA suit son cycle dans l'ordre ; une étape sautée ou rejouée est
+// refusée.
+func TestRmaTransition(t *testing.T) {
+	cas := []struct {
+		statut, etape, attendu string
+		refus                  bool
+	}{
+		{"opened", RmaShip, "shipped", false},
+		{"opened", RmaCancel, "cancelled", false},
+		{"shipped", RmaRepaired, "repaired", false},
+		{"shipped", RmaReplaced, "replaced", false},
+		{"shipped", RmaCredited, "credited", false},
+		{"repaired", RmaReturn, "returned", false},
+		{"replaced", RmaReturn, "returned", false},
+		{"opened", RmaReturn, "", true},
+		{"shipped", RmaCancel, "", true},
+		{"credited", RmaReturn, "", true},
+		{"returned", RmaShip, "", true},
+		{"opened", "lost", "", true},
+	}
+	for _, c := range cas {
+		statut, err := RmaTransition(c.statut, c.etape)
+		if (err != nil) != c.refus || statut != c.attendu {
+			t.Errorf("%s + %s : %q (%v), attendu %q", c.statut, c.etape, statut, err, c.attendu)
+		}
+	}
+}
+
+// L'article sorti du stock entre au bac RMA et en sort dans l'ordre du
+// cycle : sur un RMA complet, le bac revient à zéro.
+func TestRmaMove(t *testing.T) {
+	bac := 0.0
+	for _, etape := range []string{RmaOpen, RmaShip, RmaRepaired, RmaReturn} {
+		bac += RmaMove("stock", etape, 2).Delta
+	}
+	if bac != 0 {
+		t.Errorf("bac à %v après un RMA réparé et rendu", bac)
+	}
+	if m := RmaMove("stock", RmaOpen, 1); m.Counterpart != "stock" || m.Delta != 1 {
+		t.Errorf("ouverture depuis le stock : %+v", m)
+	}
+	if m := RmaMove("stock", RmaCredited, 1); m.Delta != 0 {
+		t.Errorf("avoir : %+v, rien ne doit bouger", m)
+	}
+}
+
+// L'article d'un client n'est pas à nous : aucune étape ne le fait entrer
+// dans le stock, même de passage.
+func TestRmaMoveClient(t *testing.T) {
+	etapes := []string{RmaOpen, RmaShip, RmaRepaired, RmaReplaced, RmaCredited, RmaReturn, RmaCancel}
+	for _, etape := range etapes {
+		if m := RmaMove("customer", etape, 2); m != (RmaStockMove{}) {
+			t.Errorf("%s : %+v, le stock ne doit pas bouger", etape, m)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tokens"
+	"github.com/pocketbase/pocketbase/tools/migrate"
+
+	appmig "pocket-react/backend/migrations"
+)
+
+// Le décor des tests de route : la vraie base (migrations de l'application
+// comprises), une société, une caisse active et sa session ouverte par
+// `caissier`. Les routes s'enregistrent sur `e` au gré de chaque test.
+type caisse struct {
+	app      *pocketbase.PocketBase
+	e        *echo.Echo
+	societe  *models.Record
+	caisse   *models.Record
+	session  *models.Record
+	caissier *models.Record
+	client   *models.Record
+}
+
+func caisseDeTest(t *testing.T) *caisse {
+	t.Helper()
+
+	app := pocketbase.NewWithConfig(pocketbase.Config{DefaultDataDir: t.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	t.Cleanup(func() { app.ResetBootstrapState() })
+
+	runner, err := migrate.NewRunner(app.DB(), migrations.AppMigrations)
+	if err != nil {
+		t.Fatalf("runner: %v", err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations système: %v", err)
+	}
+	if err := appmig.RunMigrations(app); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	e, err := apis.InitApi(app)
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+
+	c := &caisse{app: app, e: e}
+	c.societe = unEnregistrement(t, app, "companies", map[string]any{"name": "Musique & Co"})
+	c.caissier = unUtilisateur(t, app, "caissier", "caissier", c.societe.Id)
+	c.client = unEnregistrement(t, app, "customers", map[string]any{
+		"name": "Client comptoir", "owner_company": c.societe.Id,
+	})
+	c.caisse = unEnregistrement(t, app, "cash_registers", map[string]any{
+		"name": "Caisse 1", "code": "C1", "owner_company": c.societe.Id, "is_active": true,
+	})
+	c.session = unEnregistrement(t, app, "cash_sessions", map[string]any{
+		"owner_company": c.societe.Id,
+		"cash_register": c.caisse.Id,
+		"opened_by":     c.caissier.Id,
+		"status":        "open",
+		"opened_at":     time.Now().UTC().Add(-time.Hour),
+		"opening_float": 100.0,
+	})
+	return c
+}
+
+func unEnregistrement(t *testing.T, app *pocketbase.PocketBase, collection string, champs map[string]any) *models.Record {
+	t.Helper()
+	col, err := app.Dao().FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatalf("collection %s: %v", collection, err)
+	}
+	rec := models.NewRecord(col)
+	for k, v := range champs {
+		rec.Set(k, v)
+	}
+	if err := app.Dao().SaveRecord(rec); err != nil {
+		t.Fatalf("%s: %v", collection, err)
+	}
+	return rec
+}
+
+func unUtilisateur(t *testing.T, app *pocketbase.PocketBase, nom, role string, societes ...string) *models.Record {
+	t.Helper()
+	col, err := app.Dao().FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatalf("collection users: %v", err)
+	}
+	user := models.NewRecord(col)
+	user.SetEmail(nom + "@exemple.fr")
+	user.SetUsername(nom)
+	if err := user.SetPassword("motdepasse123"); err != nil {
+		t.Fatalf("mot de passe: %v", err)
+	}
+	user.Set("role", role)
+	user.Set("company", societes)
+	if err := app.Dao().SaveRecord(user); err != nil {
+		t.Fatalf("utilisateur %s: %v", nom, err)
+	}
+	return user
+}
+
+// envoyer poste `corps` en JSON au nom de `user` et rend le code HTTP et la
+// réponse décodée.
+func (c *caisse) envoyer(t *testing.T, user *models.Record, methode, url string, corps any) (int, map[string]any) {
+	t.Helper()
+	brut, _ := json.Marshal(corps)
+	req := httptest.NewRequest(methode, url, strings.NewReader(string(brut)))
+	req.Header.Set("Content-Type", "application/json")
+	if user != nil {
+		jeton, err := tokens.NewRecordAuthToken(c.app, user)
+		if err != nil {
+			t.Fatalf("jeton: %v", err)
+		}
+		req.Header.Set("Authorization", jeton)
+	}
+	rec := httptest.NewRecorder()
+	c.e.ServeHTTP(rec, req)
+
+	var reponse map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &reponse)
+	if rec.Code >= http.StatusInternalServerError {
+		t.Logf("%s %s → %d %s", methode, url, rec.Code, rec.Body.String())
+	}
+	return rec.Code, reponse
+}
@@ -6,6 +6,7 @@ package routes
 import (
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 
 	"strconv"
@@ -19,19 +20,28 @@ import (
 
 	// Import du package backend pour accéder à CreateCreditNote
 	"pocket-react/backend"
+	"pocket-react/backend/hooks"
 	"pocket-react/backend/reports"
 )
 
 // DTOs ---------------------------------------------------------
 
 type OpenSessionInput struct {
-	OwnerCompany string  `json:"owner_company"`
-	CashRegister string  `json:"cash_register"`
-	OpeningFloat float64 `json:"opening_float"`
+	OwnerCompany string                  `json:"owner_company"`
+	CashRegister string                  `json:"cash_register"`
+	OpeningFloat float64                 `json:"opening_float"`
+	OpeningCount []backend.CashCountLine `json:"opening_count,omitempty"` // comptage par coupure
 }
 
 type CloseSessionInput struct {
-	CountedCashTotal float64 `json:"counted_cash_total"`
+	// nil : rien compté ; 0 est un comptage (tiroir vide)
+	CountedCashTotal  *float64                `json:"counted_cash_total"`
+	ClosingCount      []backend.CashCountLine `json:"closing_count,omitempty"` // prioritaire sur counted_cash_total
+	DiscrepancyReason string                  `json:"discrepancy_reason,omitempty"`
+}
+
+type JustifyDiscrepancyInput struct {
+	Reason string `json:"reason"`
 }
 
 type CashMovementInput struct {
@@ -43,12 +53,14 @@ type CashMovementInput struct {
 }
 
 type PosRefundInput struct {
-	OriginalTicketID  string                 `json:"original_ticket_id"`
-	RefundType        string                 `json:"refund_type"`   // full|partial
-	RefundMethod      string                 `json:"refund_method"` // especes|cb|autre
-	RefundMethodLabel string                 `json:"refund_method_label"`
-	RefundedItems     []PosRefundedItemInput `json:"refunded_items"`
-	Reason            string                 `json:"reason"`
+	OriginalTicketID   string                 `json:"original_ticket_id"`
+	OriginalTicketCode string                 `json:"original_ticket_code"` // code-barres / QR scanné sur le ticket
+	OwnerCompany       string                 `json:"owner_company"`
+	RefundType         string                 `json:"refund_type"`   // full|partial
+	RefundMethod       string                 `json:"refund_method"` // especes|cb|autre
+	RefundMethodLabel  string                 `json:"refund_method_label"`
+	RefundedItems      []PosRefundedItemInput `json:"refunded_items"`
+	Reason             string                 `json:"reason"`
 }
 
 type PosRefundedItemInput struct {
@@ -74,9 +86,18 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewBadRequestError("Corps invalide", err)
 		}
 
+		// Ticket d'origine retrouvé par son code scanné
+		if payload.OriginalTicketID == "" && payload.OriginalTicketCode != "" {
+			orig, err := findPosTicketByCode(dao, payload.OriginalTicketCode, payload.OwnerCompany)
+			if err != nil {
+				return apis.NewNotFoundError(err.Error(), nil)
+			}
+			payload.OriginalTicketID = orig.Id
+		}
+
 		// Validation basique
 		if payload.OriginalTicketID == "" {
-			return apis.NewBadRequestError("original_ticket_id requis", nil)
+			return apis.NewBadRequestError("original_ticket_id ou original_ticket_code requis", nil)
 		}
 		if payload.RefundType == "" {
 			payload.RefundType = "full"
@@ -219,6 +240,21 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewBadRequestError("Champs requis manquants", nil)
 		}
 
+		// Comptage par coupure : il fait foi pour le fond de caisse
+		var openingCount *backend.CashCount
+		if len(payload.OpeningCount) > 0 {
+			count, err := backend.NormalizeCashCount(payload.OpeningCount)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			if payload.OpeningFloat > 0 && math.Abs(payload.OpeningFloat-count.Total) > 0.005 {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("Fond de caisse (%.2f€) différent du comptage (%.2f€)", payload.OpeningFloat, count.Total), nil)
+			}
+			openingCount = count
+			payload.OpeningFloat = count.Total
+		}
+
 		// Vérifier s'il existe déjà une session ouverte
 		filter := fmt.Sprintf("cash_register = '%s' && status = 'open'", payload.CashRegister)
 		existing, _ := dao.FindFirstRecordByFilter("cash_sessions", filter)
@@ -235,6 +271,9 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 		rec.Set("status", "open")
 		rec.Set("opened_at", time.Now())
 		rec.Set("opening_float", payload.OpeningFloat)
+		if openingCount != nil {
+			rec.Set("opening_count", openingCount)
+		}
 
 		if info.AuthRecord != nil {
 			rec.Set("opened_by", info.AuthRecord.Id)
@@ -244,6 +283,32 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewApiError(500, "Impossible d'ouvrir la session", err)
 		}
 
+		// Premier shift : le caissier qui ouvre tient le tiroir
+		var shiftCount any
+		if openingCount != nil {
+			shiftCount = openingCount
+		}
+		if _, err := reports.OpenCashShift(dao, rec, rec.GetString("opened_by"),
+			payload.OpeningFloat, shiftCount, rec.GetDateTime("opened_at").Time()); err != nil {
+			log.Printf("⚠️ Shift initial session %s: %v", rec.Id, err)
+		}
+
+		if err := hooks.CreateAuditLog(app, c, hooks.AuditLogParams{
+			Action:       "cash_session_opened",
+			EntityType:   "cash_session",
+			EntityID:     rec.Id,
+			EntityNumber: payload.CashRegister,
+			OwnerCompany: payload.OwnerCompany,
+			Details: map[string]interface{}{
+				"cash_register": payload.CashRegister,
+				"opening_float": payload.OpeningFloat,
+				"opening_count": openingCount,
+				"opened_by":     rec.GetString("opened_by"),
+			},
+		}); err != nil {
+			log.Printf("⚠️ Audit log ouverture session %s: %v", rec.Id, err)
+		}
+
 		return c.JSON(http.StatusCreated, rec)
 	},
 		apis.RequireRecordAuth(),
@@ -289,14 +354,69 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 		}
 
 		var payload CloseSessionInput
-		_ = c.Bind(&payload)
+		if err := c.Bind(&payload); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
 
-		rec.Set("closed_at", time.Now())
-		rec.Set("status", "closed")
+		// Pas de fermeture sans comptage : l'écart de la session en dépend.
+		// Le comptage par coupure fait foi pour les espèces comptées.
+		var counted float64
+		var closingCount *backend.CashCount
+		switch {
+		case len(payload.ClosingCount) > 0:
+			closingCount, err = backend.NormalizeCashCount(payload.ClosingCount)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			counted = closingCount.Total
+		case payload.CountedCashTotal != nil:
+			counted = *payload.CountedCashTotal
+		default:
+			return apis.NewBadRequestError("Comptage du tiroir requis pour fermer la session", nil)
+		}
+		if counted < 0 {
+			return apis.NewBadRequestError("Le comptage ne peut pas être négatif", nil)
+		}
 
-		if payload.CountedCashTotal > 0 {
-			rec.Set("counted_cash_total", payload.CountedCashTotal)
+		// Écart = compté − attendu, l'attendu étant celui du rapport X
+		// (même calcul que ce que le caissier voit à l'écran)
+		rapportX, err := reports.GenerateRapportX(app, id)
+		if err != nil {
+			return apis.NewApiError(500, "Impossible de calculer les espèces attendues", err)
 		}
+		expected := rapportX.ExpectedCash.Total
+		difference := roundAmount(counted - expected)
+
+		threshold := backend.DefaultCashDiscrepancyThreshold
+		if register, err := dao.FindRecordById("cash_registers", rec.GetString("cash_register")); err == nil {
+			if t := register.GetFloat("cash_discrepancy_threshold"); t > 0 {
+				threshold = t
+			}
+		}
+		discrepancyStatus := backend.ClassifyCashDiscrepancy(difference, threshold)
+
+		reason := strings.TrimSpace(payload.DiscrepancyReason)
+
+		rec.Set("closed_at", time.Now())
+		rec.Set("status", "closed")
+		rec.Set("counted_cash_total", counted)
+		rec.Set("expected_cash_total", expected)
+		rec.Set("cash_difference", difference)
+		rec.Set("discrepancy_threshold", threshold)
+		if closingCount != nil {
+			rec.Set("closing_count", closingCount)
+		}
+		if reason != "" {
+			rec.Set("discrepancy_reason", reason)
+		}
+		// Un responsable qui ferme lui-même la caisse justifie l'écart d'emblée
+		if discrepancyStatus == backend.DiscrepancyPendingJustification &&
+			reason != "" && hasManagerRole(info.AuthRecord) {
+			discrepancyStatus = backend.DiscrepancyJustified
+			rec.Set("discrepancy_justified_by", info.AuthRecord.Id)
+			rec.Set("discrepancy_justified_at", time.Now())
+		}
+		rec.Set("discrepancy_status", discrepancyStatus)
 		if info.AuthRecord != nil {
 			rec.Set("closed_by", info.AuthRecord.Id)
 		}
@@ -305,6 +425,113 @@ func RegisterCashRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewApiError(500, "Impossible de fermer la session", err)
 		}
 
+		// Le dernier shift se clôture avec le comptage de fermeture
+		if shift, err := reports.EnsureOpenCashShift(dao, rec); err == nil {
+			var shiftCount any
+			if closingCount != nil {
+				shiftCount = closingCount
+			}
+			if err := reports.CloseCashShift(dao, shift, &counted, shiftCount, "", "",
+				rec.GetDateTime("closed_at").Time()); err != nil {
+				log.Printf("⚠️ Clôture du shift %s: %v", shift.Id, err)
+			}
+		} else {
+			log.Printf("⚠️ Shift en cours session %s: %v", id, err)
+		}
+
+		if discrepancyStatus == backend.DiscrepancyPendingJustification {
+			log.Printf("⚠️ Session %s fermée avec un écart de %.2f€ (seuil %.2f€) — justification responsable requise",
+				id, difference, threshold)
+		}
+
+		if err := hooks.CreateAuditLog(app, c, hooks.AuditLogParams{
+			Action:       "cash_session_closed",
+			EntityType:   "cash_session",
+			EntityID:     rec.Id,
+			EntityNumber: rec.GetString("cash_register"),
+			OwnerCompany: rec.GetString("owner_company"),
+			Details: map[string]interface{}{
+				"cash_register":         rec.GetString("cash_register"),
+				"closed_by":             rec.GetString("closed_by"),
+				"opening_float":         rec.GetFloat("opening_float"),
+				"expected_cash_total":   expected,
+				"counted_cash_total":    counted,
+				"cash_difference":       difference,
+				"closing_count":         closingCount,
+				"discrepancy_status":    discrepancyStatus,
+				"discrepancy_threshold": threshold,
+				"discrepancy_reason":    rec.GetString("discrepancy_reason"),
+			},
+		}); err != nil {
+			log.Printf("⚠️ Audit log fermeture session %s: %v", rec.Id, err)
+		}
+
+		return c.JSON(http.StatusOK, rec)
+	},
+		apis.RequireRecordAuth(),
+	)
+
+	// ----------------------------------------------------------------------
+	// JUSTIFICATION D'ÉCART (responsable uniquement)
+	// ----------------------------------------------------------------------
+	router.POST("/api/cash/session/:id/justify", func(c echo.Context) error {
+		info := apis.RequestInfo(c)
+		dao := app.Dao()
+
+		if !hasManagerRole(info.AuthRecord) {
+			return apis.NewForbiddenError("Justification réservée aux responsables", nil)
+		}
+
+		rec, err := dao.FindRecordById("cash_sessions", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Session introuvable", err)
+		}
+		if rec.GetString("discrepancy_status") != backend.DiscrepancyPendingJustification {
+			return apis.NewBadRequestError("Aucun écart en attente de justification sur cette session", nil)
+		}
+
+		var payload JustifyDiscrepancyInput
+		if err := c.Bind(&payload); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		reason := strings.TrimSpace(payload.Reason)
+		if reason == "" {
+			return apis.NewBadRequestError("reason requis", nil)
+		}
+
+		previousReason := rec.GetString("discrepancy_reason")
+
+		rec.Set("discrepancy_status", backend.DiscrepancyJustified)
+		rec.Set("discrepancy_reason", reason)
+		rec.Set("discrepancy_justified_by", info.AuthRecord.Id)
+		rec.Set("discrepancy_justified_at", time.Now())
+
+		if err := dao.SaveRecord(rec); err != nil {
+			return apis.NewApiError(500, "Impossible d'enregistrer la justification", err)
+		}
+
+		if err := hooks.CreateAuditLog(app, c, hooks.AuditLogParams{
+			Action:       "cash_discrepancy_justified",
+			EntityType:   "cash_session",
+			EntityID:     rec.Id,
+			EntityNumber: rec.GetString("cash_register"),
+			OwnerCompany: rec.GetString("owner_company"),
+			Details: map[string]interface{}{
+				"cash_difference":       rec.GetFloat("cash_difference"),
+				"discrepancy_threshold": rec.GetFloat("discrepancy_threshold"),
+			},
+			PreviousValues: map[string]interface{}{
+				"discrepancy_status": backend.DiscrepancyPendingJustification,
+				"discrepancy_reason": previousReason,
+			},
+			NewValues: map[string]interface{}{
+				"discrepancy_status": backend.DiscrepancyJustified,
+				"discrepancy_reason": reason,
+			},
+		}); err != nil {
+			log.Printf("⚠️ Audit log justification session %s: %v", rec.Id, err)
+		}
+
 		return c.JSON(http.StatusOK, rec)
 	},
 		apis.RequireRecordAuth(),
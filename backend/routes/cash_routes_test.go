@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Une session ne se ferme pas sans comptage, et un tiroir vide compté à 0 €
+// est un comptage : son écart contre le fond de caisse est calculé.
+func TestFermetureExigeUnComptage(t *testing.T) {
+	c := caisseDeTest(t)
+	RegisterCashRoutes(c.app, c.e)
+	url := "/api/cash/session/" + c.session.Id + "/close"
+
+	cas := []struct {
+		nom     string
+		corps   map[string]any
+		attendu int
+	}{
+		{"sans comptage", map[string]any{}, http.StatusBadRequest},
+		{"comptage négatif", map[string]any{"counted_cash_total": -5}, http.StatusBadRequest},
+		{"tiroir vide compté à zéro", map[string]any{"counted_cash_total": 0}, http.StatusOK},
+	}
+	for _, tc := range cas {
+		if code, reponse := c.envoyer(t, c.caissier, http.MethodPost, url, tc.corps); code != tc.attendu {
+			t.Errorf("%s : attendu %d, obtenu %d (%v)", tc.nom, tc.attendu, code, reponse)
+		}
+	}
+
+	session, err := c.app.Dao().FindRecordById("cash_sessions", c.session.Id)
+	if err != nil {
+		t.Fatalf("session: %v", err)
+	}
+	if session.GetString("status") != "closed" || session.GetFloat("cash_difference") != -100 {
+		t.Errorf("session %s, écart %v (attendu fermée, -100)",
+			session.GetString("status"), session.GetFloat("cash_difference"))
+	}
+}
@@ -0,0 +1,197 @@
+// backend/routes/cash_shift_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — RELÈVES DE CAISSIER
+// ═══════════════════════════════════════════════════════════════════════════
+// Une relève passe le tiroir d'un caissier à l'autre sans fermer la session :
+// le tiroir est compté, le shift sortant est clôturé avec son écart, et le
+// shift entrant reprend exactement les espèces comptées.
+// Voir backend/reports/cash_shifts.go pour le calcul des écarts.
+
+package routes
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+
+	"pocket-react/backend"
+	"pocket-react/backend/hooks"
+	"pocket-react/backend/reports"
+)
+
+// HandoverInput représente une relève de caissier
+type HandoverInput struct {
+	IncomingCashier  string                  `json:"incoming_cashier"`
+	CountedCashTotal float64                 `json:"counted_cash_total"`
+	Count            []backend.CashCountLine `json:"count,omitempty"` // prioritaire sur counted_cash_total
+	Note             string                  `json:"note,omitempty"`
+}
+
+func RegisterCashShiftRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/cash/session/:id/handover
+	// Compte le tiroir, clôture le shift sortant et ouvre celui du caissier
+	// entrant. La session reste ouverte.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/cash/session/:id/handover", func(c echo.Context) error {
+		info := apis.RequestInfo(c)
+		dao := app.Dao()
+
+		session, err := dao.FindRecordById("cash_sessions", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Session introuvable", err)
+		}
+		if session.GetString("status") != "open" {
+			return apis.NewBadRequestError("Session fermée : relève impossible", nil)
+		}
+		ownerCompany := session.GetString("owner_company")
+		if !belongsToCompany(info.AuthRecord, ownerCompany) {
+			return apis.NewForbiddenError("Session hors de votre société", nil)
+		}
+
+		var payload HandoverInput
+		if err := c.Bind(&payload); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if payload.IncomingCashier == "" {
+			return apis.NewBadRequestError("incoming_cashier requis", nil)
+		}
+		incoming, err := dao.FindRecordById("users", payload.IncomingCashier)
+		if err != nil {
+			return apis.NewBadRequestError("Caissier entrant introuvable", err)
+		}
+		if !belongsToCompany(incoming, ownerCompany) {
+			return apis.NewBadRequestError("Le caissier entrant n'appartient pas à la société de la session", nil)
+		}
+
+		// Comptage par coupure : il fait foi pour les espèces remises
+		counted := payload.CountedCashTotal
+		var count *backend.CashCount
+		if len(payload.Count) > 0 {
+			count, err = backend.NormalizeCashCount(payload.Count)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			counted = count.Total
+		}
+		if count == nil && counted <= 0 {
+			return apis.NewBadRequestError("Comptage du tiroir requis pour une relève", nil)
+		}
+
+		var countValue any
+		if count != nil {
+			countValue = count
+		}
+		note := strings.TrimSpace(payload.Note)
+		now := time.Now()
+
+		// Clôture et ouverture ensemble : une session ne reste jamais sans
+		// shift en cours
+		var outgoing, next *models.Record
+		err = dao.RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			outgoing, err = reports.EnsureOpenCashShift(tx, session)
+			if err != nil {
+				return apis.NewApiError(500, "Impossible de retrouver le shift en cours", err)
+			}
+			// Le tiroir se remet par celui qui le tient, ou par un responsable
+			if outgoing.GetString("cashier") != info.AuthRecord.Id && !hasManagerRole(info.AuthRecord) {
+				return apis.NewForbiddenError("Seul le caissier sortant ou un responsable peut faire la relève", nil)
+			}
+			if outgoing.GetString("cashier") == incoming.Id {
+				return apis.NewBadRequestError("Le caissier entrant tient déjà le tiroir", nil)
+			}
+			if err := reports.CloseCashShift(tx, outgoing, &counted, countValue, incoming.Id, note, now); err != nil {
+				return apis.NewApiError(500, "Impossible de clôturer le shift sortant", err)
+			}
+			next, err = reports.OpenCashShift(tx, session, incoming.Id, counted, countValue, now)
+			if err != nil {
+				return apis.NewApiError(500, "Impossible d'ouvrir le shift entrant", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		difference := outgoing.GetFloat("cash_difference")
+		if absFloat(difference) >= 0.005 {
+			log.Printf("⚠️ Relève session %s : écart de %.2f€ sur le shift de %s",
+				session.Id, difference, outgoing.GetString("cashier"))
+		}
+
+		if err := hooks.CreateAuditLog(app, c, hooks.AuditLogParams{
+			Action:       "cash_session_handover",
+			EntityType:   "cash_session",
+			EntityID:     session.Id,
+			EntityNumber: session.GetString("cash_register"),
+			OwnerCompany: ownerCompany,
+			Details: map[string]interface{}{
+				"outgoing_shift":   outgoing.Id,
+				"incoming_shift":   next.Id,
+				"outgoing_cashier": outgoing.GetString("cashier"),
+				"incoming_cashier": incoming.Id,
+				"performed_by":     info.AuthRecord.Id,
+				"expected_cash":    outgoing.GetFloat("expected_cash"),
+				"counted_cash":     counted,
+				"cash_difference":  difference,
+				"count":            countValue,
+				"note":             note,
+			},
+		}); err != nil {
+			log.Printf("⚠️ Audit log relève session %s: %v", session.Id, err)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"closed_shift": outgoing,
+			"open_shift":   next,
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /api/cash/shifts/over-short?owner_company=&date_from=&date_to=&cashier=
+	// Écarts cumulés par caissier. Un caissier ne voit que les siens.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/api/cash/shifts/over-short", func(c echo.Context) error {
+		info := apis.RequestInfo(c)
+
+		ownerCompany := c.QueryParam("owner_company")
+		if ownerCompany == "" {
+			if companies := info.AuthRecord.GetStringSlice("company"); len(companies) > 0 {
+				ownerCompany = companies[0]
+			}
+		}
+		if ownerCompany == "" {
+			return apis.NewBadRequestError("owner_company requis", nil)
+		}
+
+		cashier := c.QueryParam("cashier")
+		if !hasManagerRole(info.AuthRecord) {
+			if cashier != "" && cashier != info.AuthRecord.Id {
+				return apis.NewForbiddenError("Écarts des autres caissiers réservés aux responsables", nil)
+			}
+			cashier = info.AuthRecord.Id
+		}
+
+		result, err := reports.GenerateCashierOverShort(app, ownerCompany,
+			c.QueryParam("date_from"), c.QueryParam("date_to"), cashier)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"owner_company": ownerCompany,
+			"date_from":     c.QueryParam("date_from"),
+			"date_to":       c.QueryParam("date_to"),
+			"cashiers":      result,
+		})
+	}, apis.RequireRecordAuth())
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// La relève remet le tiroir : seul celui qui le tient, ou un responsable de
+// la société, peut la faire, et seulement à un caissier de la même société.
+// Les cas s'enchaînent sur la même session, dans l'ordre.
+func TestReleveDeCaissier(t *testing.T) {
+	c := caisseDeTest(t)
+	RegisterCashShiftRoutes(c.app, c.e)
+
+	autre := unEnregistrement(t, c.app, "companies", map[string]any{"name": "Autre boutique"})
+	collegue := unUtilisateur(t, c.app, "collegue", "caissier", c.societe.Id)
+	responsable := unUtilisateur(t, c.app, "responsable", "manager", c.societe.Id)
+	etranger := unUtilisateur(t, c.app, "etranger", "caissier", autre.Id)
+	intrus := unUtilisateur(t, c.app, "intrus", "manager", autre.Id)
+
+	url := "/api/cash/session/" + c.session.Id + "/handover"
+	cas := []struct {
+		nom     string
+		auteur  *models.Record
+		entrant *models.Record
+		attendu int
+	}{
+		{"un collègue ne prend pas le tiroir d'un autre", collegue, collegue, http.StatusForbidden},
+		{"pas de relève vers une autre société", c.caissier, etranger, http.StatusBadRequest},
+		{"un responsable d'une autre société non plus", intrus, collegue, http.StatusForbidden},
+		{"le caissier sortant remet son tiroir", c.caissier, collegue, http.StatusOK},
+		{"l'ancien caissier ne le tient plus", c.caissier, c.caissier, http.StatusForbidden},
+		{"un responsable fait la relève pour lui", responsable, c.caissier, http.StatusOK},
+	}
+
+	for _, tc := range cas {
+		code, reponse := c.envoyer(t, tc.auteur, http.MethodPost, url, map[string]any{
+			"incoming_cashier":   tc.entrant.Id,
+			"counted_cash_total": 100,
+		})
+		if code != tc.attendu {
+			t.Errorf("%s : attendu %d, obtenu %d (%v)", tc.nom, tc.attendu, code, reponse)
+		}
+	}
+}
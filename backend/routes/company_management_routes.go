@@ -125,6 +125,10 @@ func RegisterCompanyManagementRoutes(pb *pocketbase.PocketBase, router *echo.Ech
 				"invoice_footer":             record.GetString("invoice_footer"),
 				"invoice_prefix":             record.GetString("invoice_prefix"),
 				"warranties_text":            record.GetString("warranties_text"),
+				"warranty_months":            record.GetInt("warranty_months"),
+				"reservation_policy":         record.GetString("reservation_policy"),
+				"reservation_order_days":     record.GetInt("reservation_order_days"),
+				"reservation_cart_minutes":   record.GetInt("reservation_cart_minutes"),
 				"created":                    record.Created,
 				"updated":                    record.Updated,
 				"is_first":                   record.Id == firstCompanyId,
@@ -191,6 +195,10 @@ func RegisterCompanyManagementRoutes(pb *pocketbase.PocketBase, router *echo.Ech
 			"invoice_footer":             record.GetString("invoice_footer"),
 			"invoice_prefix":             record.GetString("invoice_prefix"),
 			"warranties_text":            record.GetString("warranties_text"),
+			"warranty_months":            record.GetInt("warranty_months"),
+			"reservation_policy":         record.GetString("reservation_policy"),
+			"reservation_order_days":     record.GetInt("reservation_order_days"),
+			"reservation_cart_minutes":   record.GetInt("reservation_cart_minutes"),
 			"created":                    record.Created,
 			"updated":                    record.Updated,
 			"is_first":                   isFirst,
@@ -0,0 +1,259 @@
+// backend/routes/customer_screen_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — ÉCRAN CLIENT WEB
+// ═══════════════════════════════════════════════════════════════════════════
+// Une tablette du réseau local sert d'écran client à une caisse : la caisse
+// publie son panier, la tablette l'affiche, et passe en diaporama (logo,
+// promotions, produits du catalogue) quand le panier est vide.
+//
+//   POST /api/display/screen/:registerId       → publie l'état (caisse, auth)
+//   POST /api/display/screen/:registerId/pair  → nouvelle clé d'appairage (auth)
+//   GET  /display/:registerId?k=               → la page de l'écran
+//   GET  /api/display/screen/:registerId/ws    → les états, en direct (clé)
+//   GET  /api/display/screen/:registerId/slides → le diaporama (clé)
+//
+// La clé est rangée dans cash_registers.settings.customer_screen ; en générer
+// une nouvelle déconnecte les tablettes appairées avec l'ancienne.
+
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"golang.org/x/net/websocket"
+
+	"pocket-react/backend/pos"
+)
+
+// Produits du diaporama quand la caisse n'en choisit pas
+const maxScreenProducts = 12
+
+// customerScreenSettings : settings.customer_screen d'une caisse
+type customerScreenSettings struct {
+	Key          string   `json:"key"`
+	Welcome      string   `json:"welcome,omitempty"`
+	Promotions   []string `json:"promotions,omitempty"`
+	ProductIDs   []string `json:"product_ids,omitempty"` // vide = produits des catégories mises en avant
+	SlideSeconds int      `json:"slide_seconds,omitempty"`
+}
+
+func RegisterCustomerScreenRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/display/screen/:registerId — la caisse publie son panier
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/display/screen/:registerId", func(c echo.Context) error {
+		var state pos.CustomerScreenState
+		if err := c.Bind(&state); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		switch state.Phase {
+		case "", pos.ScreenPhaseIdle, pos.ScreenPhaseCart, pos.ScreenPhasePayment,
+			pos.ScreenPhaseChange, pos.ScreenPhaseThanks:
+		default:
+			return apis.NewBadRequestError("phase inconnue : "+state.Phase, nil)
+		}
+
+		viewers := pos.CustomerScreens.Publish(c.PathParam("registerId"), state)
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"viewers": viewers,
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/display/screen/:registerId/pair — nouvelle clé d'appairage
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/display/screen/:registerId/pair", func(c echo.Context) error {
+		register, err := pb.Dao().FindRecordById("cash_registers", c.PathParam("registerId"))
+		if err != nil {
+			return apis.NewNotFoundError("Caisse introuvable", err)
+		}
+
+		settings := map[string]interface{}{}
+		if raw := register.GetString("settings"); raw != "" && raw != "null" {
+			if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+				return apis.NewBadRequestError("settings invalide sur la caisse", err)
+			}
+		}
+		screen, _ := settings["customer_screen"].(map[string]interface{})
+		if screen == nil {
+			screen = map[string]interface{}{}
+		}
+		key := security.RandomString(24)
+		screen["key"] = key
+		settings["customer_screen"] = screen
+
+		register.Set("settings", settings)
+		if err := pb.Dao().SaveRecord(register); err != nil {
+			return apis.NewBadRequestError("Enregistrement de la clé impossible", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"key":  key,
+			"path": "/display/" + register.Id + "?k=" + key,
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /display/:registerId — page de l'écran client
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/display/:registerId", func(c echo.Context) error {
+		register, _, err := pairedScreenRegister(pb, c)
+		if err != nil {
+			return c.HTML(http.StatusForbidden,
+				`<!DOCTYPE html><meta charset="utf-8"><body style="font-family:sans-serif;padding:2em">`+
+					`<h1>Écran non appairé</h1><p>Ouvrez le lien d'appairage affiché par la caisse.</p></body>`)
+		}
+		page, err := pos.CustomerScreenPageHTML(register.Id, c.QueryParam("k"))
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Page de l'écran client indisponible", err)
+		}
+		return c.HTML(http.StatusOK, page)
+	})
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /api/display/screen/:registerId/slides — diaporama d'attente
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/api/display/screen/:registerId/slides", func(c echo.Context) error {
+		register, settings, err := pairedScreenRegister(pb, c)
+		if err != nil {
+			return err
+		}
+
+		companyID := register.GetString("owner_company")
+		companyName := ""
+		if company, err := pb.Dao().FindRecordById("companies", companyID); err == nil {
+			companyName = company.GetString("name")
+		}
+
+		var logo string
+		if url := companyLogoDataURL(pb, companyID); url != nil {
+			logo = *url
+		}
+
+		products := make([]map[string]interface{}, 0)
+		for _, p := range screenSlideProducts(pb, companyID, settings.ProductIDs) {
+			products = append(products, map[string]interface{}{
+				"name":      p.GetString("name"),
+				"price_ttc": p.GetFloat("price_ttc"),
+				"image_url": "/api/files/" + p.Collection().Id + "/" + p.Id + "/" + p.GetString("image") + "?thumb=600x600f",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"company_name":  companyName,
+			"logo":          logo,
+			"welcome":       settings.Welcome,
+			"promotions":    settings.Promotions,
+			"products":      products,
+			"slide_seconds": settings.SlideSeconds,
+		})
+	})
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /api/display/screen/:registerId/ws — états du panier en direct
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/api/display/screen/:registerId/ws", func(c echo.Context) error {
+		register, _, err := pairedScreenRegister(pb, c)
+		if err != nil {
+			return err
+		}
+		registerID := register.Id
+
+		websocket.Handler(func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			updates := pos.CustomerScreens.Subscribe(registerID)
+			defer pos.CustomerScreens.Unsubscribe(registerID, updates)
+
+			// Goroutine pour détecter la déconnexion
+			done := make(chan bool, 1)
+			go func() {
+				for {
+					var msg string
+					if err := websocket.Message.Receive(ws, &msg); err != nil {
+						done <- true
+						return
+					}
+					if msg == "ping" {
+						websocket.JSON.Send(ws, map[string]string{"type": "pong"})
+					}
+				}
+			}()
+
+			for {
+				select {
+				case state := <-updates:
+					if err := websocket.JSON.Send(ws, map[string]interface{}{
+						"type":  "screen_update",
+						"state": state,
+					}); err != nil {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}).ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+}
+
+// pairedScreenRegister vérifie la clé d'appairage (?k=) de la caisse
+func pairedScreenRegister(pb *pocketbase.PocketBase, c echo.Context) (*models.Record, customerScreenSettings, error) {
+	var settings customerScreenSettings
+
+	register, err := pb.Dao().FindRecordById("cash_registers", c.PathParam("registerId"))
+	if err != nil {
+		return nil, settings, apis.NewNotFoundError("Caisse introuvable", err)
+	}
+
+	var raw struct {
+		CustomerScreen customerScreenSettings `json:"customer_screen"`
+	}
+	if s := register.GetString("settings"); s != "" && s != "null" {
+		_ = json.Unmarshal([]byte(s), &raw)
+	}
+	settings = raw.CustomerScreen
+
+	key := c.QueryParam("k")
+	if settings.Key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(settings.Key)) != 1 {
+		return nil, settings, apis.NewForbiddenError("Écran non appairé à cette caisse", nil)
+	}
+	return register, settings, nil
+}
+
+// screenSlideProducts : les produits choisis pour la caisse, sinon ceux des
+// catégories mises en avant ; seulement ceux qui ont une image
+func screenSlideProducts(pb *pocketbase.PocketBase, companyID string, productIDs []string) []*models.Record {
+	var products []*models.Record
+	if len(productIDs) > 0 {
+		products, _ = pb.Dao().FindRecordsByIds("products", productIDs)
+	} else {
+		products, _ = pb.Dao().FindRecordsByFilter(
+			"products",
+			"company = {:company} && image != '' && categories.is_featured ?= true",
+			"-updated",
+			maxScreenProducts,
+			0,
+			dbx.Params{"company": companyID},
+		)
+	}
+
+	withImage := make([]*models.Record, 0, len(products))
+	for _, p := range products {
+		if p.GetString("image") != "" && p.GetString("company") == companyID {
+			withImage = append(withImage, p)
+		}
+	}
+	return withImage
+}
@@ -0,0 +1,1008 @@
+// backend/routes/inventory_routes.go
+//
+// LA SESSION D'INVENTAIRE, CÔTÉ SERVEUR — gel, comptage, application.
+//
+// ── CE QUE LE CLIENT FAISAIT ──────────────────────────────────────────────
+// Le navigateur lisait tout le catalogue, créait une entrée par produit en
+// autant d'appels REST, puis appliquait chaque écart au moment du comptage
+// par un mouvement `absolute` sur /api/stock/adjust, suivi d'un
+// `product_events` best-effort. Trois défauts :
+//   - le gel n'était pas un gel : deux mille appels séquentiels, pendant que
+//     la caisse continuait de vendre, et une session à moitié créée si l'onglet
+//     se fermait ;
+//   - deux postes qui scannaient le même rayon s'écrasaient l'un l'autre ;
+//   - l'écart et sa trace pouvaient se contredire quand l'un des deux ratait.
+//
+// ── CE QUE FONT CES ROUTES ────────────────────────────────────────────────
+//   POST /api/inventory/:id/start              gel des stocks théoriques,
+//                                              en une transaction
+//   POST /api/inventory/:id/count              saisies, d'un ou plusieurs
+//                                              postes (somme ou écrasement)
+//   POST /api/inventory/:id/validate-category  verrouille une catégorie
+//   POST /api/inventory/:id/complete           applique TOUS les écarts et
+//                                              leurs événements, puis les
+//                                              stats_*, en une transaction
+//
+// Le comptage ne touche plus au stock : il ne fait que constater. Le stock ne
+// bouge qu'à la clôture. Les entrées déjà ajustées par l'ancien chemin
+// (`adjusted` vrai) ne sont pas rejouées.
+//
+// ── LES MOUVEMENTS PENDANT LE COMPTAGE ────────────────────────────────────
+// La caisse vend, le quai reçoit, la réserve transfère pendant qu'on compte.
+// Le stock attendu d'une entrée n'est donc pas `stock_theorique` (gelé au
+// démarrage) mais `stock_theorique` plus tous les mouvements journalisés à
+// l'emplacement compté entre le gel et son `counted_at` (ventes, retours,
+// réceptions, transferts, RMA, fiche produit) — c'est `sales_delta`, qui
+// garde son nom d'avant. L'écart se mesure contre ce stock attendu, et la
+// clôture l'AJOUTE au stock courant au lieu d'y poser la quantité comptée :
+// les mouvements faits après le comptage restent acquis.
+//
+// ── L'EMPLACEMENT ─────────────────────────────────────────────────────────
+// Dans une société qui a des emplacements (`location_routes.go`), une
+// session compte UN emplacement : celui choisi, sinon celui par défaut. Le
+// stock gelé, les mouvements rapprochés et l'écart appliqué sont ceux de cet
+// emplacement ; deux sessions peuvent compter la boutique et la réserve en
+// même temps.
+//
+// L'atomicité repose sur la connexion d'écriture unique de PocketBase — voir
+// l'en-tête de `stock_routes.go`.
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"pocket-react/backend"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Politiques de fusion des saisies (inventory_sessions.count_policy)
+const (
+	// Chaque scan ajoute sa quantité : deux postes qui comptent le même rayon
+	// à deux bouts s'additionnent.
+	CountPolicySum = "sum"
+	// La dernière saisie remplace la précédente : un recomptage corrige.
+	CountPolicyOverwrite = "overwrite"
+)
+
+// Catégorie des produits qui n'en ont pas : `category_id` est requis.
+const inventoryNoCategoryID = "sans_categorie"
+
+// Une saisie de plus que cela dans un seul appel tiendrait la connexion
+// d'écriture trop longtemps ; un terminal envoie ses scans par petits lots.
+const maxInventoryCounts = 500
+
+type inventoryStartInput struct {
+	// Société dont on gèle le catalogue. À défaut : owner_company de la
+	// session, puis la société de l'utilisateur.
+	CompanyID string `json:"company_id"`
+	// Session libre ciblée : les produits choisis à la création, gelés avec
+	// la session plutôt qu'ajoutés un par un ensuite.
+	ProductIDs []string `json:"product_ids"`
+	// Emplacement compté. À défaut : celui de la session, puis l'emplacement
+	// par défaut de la société.
+	LocationID string `json:"location_id"`
+}
+
+// InventoryCountLine — une saisie. L'entrée est désignée par son id, ou le
+// produit par son id / legacy_id, ou par son code-barres (scan).
+type InventoryCountLine struct {
+	EntryID   string  `json:"entry_id"`
+	ProductID string  `json:"product_id"`
+	Barcode   string  `json:"barcode"`
+	Quantity  float64 `json:"quantity"`
+}
+
+type inventoryCountInput struct {
+	// Nom du poste ou du terminal, retenu dans `counted_by`
+	Device string `json:"device"`
+	// Vide : la politique de la session (sum par défaut)
+	Mode   string               `json:"mode"`
+	Counts []InventoryCountLine `json:"counts"`
+}
+
+// InventoryCountResult — rendu ligne par ligne, dans l'ordre reçu
+type InventoryCountResult struct {
+	EntryID     string  `json:"entry_id"`
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	StockCompte float64 `json:"stock_compte"`
+	// Mouvements de l'emplacement depuis le gel : stock attendu = théorique + ceci
+	SalesDelta float64 `json:"sales_delta"`
+	ScanCount  int     `json:"scan_count"`
+	Created    bool    `json:"created,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// InventoryAdjustment — un écart appliqué (ou non) à la clôture
+type InventoryAdjustment struct {
+	EntryID     string   `json:"entry_id"`
+	ProductID   string   `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	StockBefore *float64 `json:"stock_before"`
+	StockAfter  float64  `json:"stock_after"`
+	SalesDelta  float64  `json:"sales_delta"`
+	EventID     string   `json:"event_id,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// ReconcileCount — l'écart d'une entrée et le stock à poser à la clôture.
+// `salesDelta` est le mouvement net de l'emplacement (ventes, réceptions,
+// transferts…) entre le gel et le comptage ; `stockNow` le stock à la
+// clôture, mouvements faits après le comptage compris. L'écart s'ajoute au
+// stock courant : poser la quantité comptée effacerait ces mouvements-là.
+func ReconcileCount(theorique, salesDelta, counted, stockNow float64) (gap, after float64) {
+	gap = counted - (theorique + salesDelta)
+	return gap, stockNow + gap
+}
+
+// IsCountPolicy indique si la politique de fusion est connue
+func IsCountPolicy(mode string) bool {
+	return mode == CountPolicySum || mode == CountPolicyOverwrite
+}
+
+// MergeCount — la quantité comptée après une saisie. `current` est nil tant
+// que l'entrée n'a jamais été comptée. En somme, une quantité négative
+// retire un scan de trop ; c'est à l'appelant de refuser un total négatif.
+func MergeCount(mode string, current *float64, quantity float64) float64 {
+	if mode == CountPolicyOverwrite || current == nil {
+		return quantity
+	}
+	return *current + quantity
+}
+
+func RegisterInventoryRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/inventory/:id/start", func(c echo.Context) error {
+		var input inventoryStartInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		user := apis.RequestInfo(c).AuthRecord
+
+		var session *models.Record
+		created := 0
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			session, err = tx.FindRecordById("inventory_sessions", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Session d'inventaire introuvable", err)
+			}
+			if session.GetString("status") != "draft" {
+				return apis.NewBadRequestError("La session est déjà démarrée", nil)
+			}
+
+			companyID := input.CompanyID
+			if companyID == "" {
+				companyID = session.GetString("owner_company")
+			}
+			if companyID == "" {
+				if companies := user.GetStringSlice("company"); len(companies) > 0 {
+					companyID = companies[0]
+				}
+			}
+
+			locationID := input.LocationID
+			if locationID == "" {
+				locationID = session.GetString("location")
+			}
+			location, err := resolveStockLocation(tx, companyID, locationID)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			if location != nil {
+				session.Set("location", location.Id)
+			}
+
+			products, err := inventoryScopeProducts(tx, session, companyID, input.ProductIDs)
+			if err != nil {
+				return err
+			}
+			if len(products) == 0 && session.GetString("scope") != "free" {
+				return apis.NewBadRequestError("Aucun produit trouvé pour ce périmètre. Vérifiez la sélection de catégories.", nil)
+			}
+
+			entriesCol, err := tx.FindCollectionByNameOrId("inventory_entries")
+			if err != nil {
+				return err
+			}
+			categoryNames := inventoryCategoryNames(tx, products)
+			for _, p := range products {
+				theorique, err := inventoryTheorique(tx, location, p)
+				if err != nil {
+					return err
+				}
+				entry := newInventoryEntry(entriesCol, session, p, categoryNames, theorique)
+				if err := tx.SaveRecord(entry); err != nil {
+					return fmt.Errorf("produit %q : %w", p.GetString("name"), err)
+				}
+				created++
+			}
+
+			now := types.NowDateTime()
+			if companyID != "" {
+				session.Set("owner_company", companyID)
+			}
+			if !IsCountPolicy(session.GetString("count_policy")) {
+				session.Set("count_policy", CountPolicySum)
+			}
+			session.Set("status", "in_progress")
+			session.Set("started_at", now)
+			session.Set("apppos_snapshot_at", now)
+			return tx.SaveRecord(session)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("📋 Inventaire %s démarré : %d produits gelés", session.Id, created)
+		return c.JSON(http.StatusOK, map[string]any{
+			"session":       session,
+			"entries_count": created,
+		})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/inventory/:id/count", func(c echo.Context) error {
+		var input inventoryCountInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.Mode != "" && !IsCountPolicy(input.Mode) {
+			return apis.NewBadRequestError("Mode de comptage inconnu", nil)
+		}
+		if len(input.Counts) > maxInventoryCounts {
+			return apis.NewBadRequestError("trop de saisies dans un seul lot", nil)
+		}
+
+		session, err := app.Dao().FindRecordById("inventory_sessions", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Session d'inventaire introuvable", err)
+		}
+		if session.GetString("status") != "in_progress" {
+			return apis.NewBadRequestError("La session n'est pas en cours", nil)
+		}
+
+		mode := input.Mode
+		if mode == "" {
+			mode = session.GetString("count_policy")
+		}
+		if !IsCountPolicy(mode) {
+			mode = CountPolicySum
+		}
+		device := input.Device
+		if device == "" {
+			device = inventoryOperatorName(apis.RequestInfo(c).AuthRecord, "")
+		}
+
+		// Chaque saisie a sa propre transaction, comme chaque mouvement de
+		// /api/stock/adjust : un code-barres inconnu n'annule pas le reste du
+		// lot, il est rendu dans le résultat.
+		results := make([]InventoryCountResult, 0, len(input.Counts))
+		for _, line := range input.Counts {
+			results = append(results, applyInventoryCount(app, session.Id, mode, device, line))
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"mode":    mode,
+			"results": results,
+		})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/inventory/:id/validate-category", func(c echo.Context) error {
+		var input struct {
+			CategoryID string `json:"category_id"`
+		}
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.CategoryID == "" {
+			return apis.NewBadRequestError("category_id requis", nil)
+		}
+
+		var session *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			session, err = tx.FindRecordById("inventory_sessions", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Session d'inventaire introuvable", err)
+			}
+			if session.GetString("status") != "in_progress" {
+				return apis.NewBadRequestError("La session doit être en cours pour valider une catégorie", nil)
+			}
+
+			validated := session.GetStringSlice("validated_category_ids")
+			for _, id := range validated {
+				if id == input.CategoryID {
+					return nil // déjà validée
+				}
+			}
+
+			pending, err := tx.FindRecordsByFilter(
+				"inventory_entries",
+				"session_id = {:session} && category_id = {:category} && status = 'pending'",
+				"", 0, 0,
+				dbx.Params{"session": session.Id, "category": input.CategoryID},
+			)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("%d produit(s) de la catégorie ne sont pas comptés", len(pending)), nil)
+			}
+
+			session.Set("validated_category_ids", append(validated, input.CategoryID))
+			return tx.SaveRecord(session)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		return c.JSON(http.StatusOK, session)
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/inventory/:id/complete", func(c echo.Context) error {
+		user := apis.RequestInfo(c).AuthRecord
+
+		var session *models.Record
+		adjustments := []InventoryAdjustment{}
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			session, err = tx.FindRecordById("inventory_sessions", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Session d'inventaire introuvable", err)
+			}
+			if session.GetString("status") != "in_progress" {
+				return apis.NewBadRequestError("La session n'est pas en cours", nil)
+			}
+
+			entries, err := tx.FindRecordsByFilter(
+				"inventory_entries", "session_id = {:session}", "category_name,product_name", 0, 0,
+				dbx.Params{"session": session.Id},
+			)
+			if err != nil {
+				return err
+			}
+
+			// Les mouvements sont relus ici, et non repris du comptage : un ticket
+			// rejoué d'un poste hors-ligne peut être arrivé depuis, daté d'avant.
+			for _, entry := range entries {
+				if entry.GetBool("adjusted") || entry.GetString("status") != "counted" {
+					continue
+				}
+				delta, err := inventoryMovementDelta(tx, session, entry, entry.GetDateTime("counted_at"))
+				if err != nil {
+					return err
+				}
+				if delta != entry.GetFloat("sales_delta") {
+					entry.Set("sales_delta", delta)
+					if err := tx.SaveRecord(entry); err != nil {
+						return err
+					}
+				}
+			}
+
+			stats := ComputeInventoryStats(entries)
+			if stats.Counted < stats.Total {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("%d produit(s) ne sont pas encore comptés", stats.Total-stats.Counted), nil)
+			}
+
+			eventsCol, err := tx.FindCollectionByNameOrId("product_events")
+			if err != nil {
+				return err
+			}
+			operator := inventoryOperatorName(user, session.GetString("operator"))
+			now := types.NowDateTime()
+
+			for _, entry := range entries {
+				if entry.GetBool("adjusted") {
+					continue
+				}
+				if inventoryEntryGap(entry) == 0 {
+					continue
+				}
+
+				adj, err := applyInventoryGap(tx, eventsCol, session, entry, operator, now)
+				if err != nil {
+					return err
+				}
+				adjustments = append(adjustments, adj)
+			}
+
+			session.Set("status", "completed")
+			session.Set("completed_at", now)
+			session.Set("stats_total_products", stats.Total)
+			session.Set("stats_counted_products", stats.Counted)
+			session.Set("stats_total_gaps", stats.Gaps)
+			session.Set("stats_category_names", stats.CategoryNames)
+			return tx.SaveRecord(session)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("✅ Inventaire %s clôturé : %d écart(s) appliqué(s)", session.Id, len(adjustments))
+		return c.JSON(http.StatusOK, map[string]any{
+			"session":     session,
+			"adjustments": adjustments,
+		})
+	}, apis.RequireRecordAuth())
+}
+
+// inventoryScopeProducts : les produits à geler pour la session
+func inventoryScopeProducts(tx *daos.Dao, session *models.Record, companyID string, productIDs []string) ([]*models.Record, error) {
+	filter := "1 = 1"
+	params := dbx.Params{}
+	if companyID != "" {
+		filter = "company = {:company}"
+		params["company"] = companyID
+	}
+
+	switch session.GetString("scope") {
+	case "free":
+		// Une session libre ne gèle que les produits ciblés, s'il y en a
+		if len(productIDs) == 0 {
+			return nil, nil
+		}
+		records, err := tx.FindRecordsByIds("products", productIDs)
+		if err != nil {
+			return nil, err
+		}
+		// Un produit disparu entre la sélection et le démarrage est simplement
+		// absent du périmètre ; un produit d'une autre société aussi.
+		kept := records[:0]
+		for _, r := range records {
+			if companyID == "" || r.GetString("company") == companyID {
+				kept = append(kept, r)
+			}
+		}
+		return kept, nil
+
+	case "selection":
+		scope := session.GetStringSlice("scope_category_ids")
+		if len(scope) == 0 {
+			return nil, apis.NewBadRequestError("Aucune catégorie sélectionnée", nil)
+		}
+		products, err := tx.FindRecordsByFilter("products", filter, "name", 0, 0, params)
+		if err != nil {
+			return nil, err
+		}
+		inScope := map[string]bool{}
+		for _, id := range scope {
+			inScope[id] = true
+		}
+		kept := products[:0]
+		for _, p := range products {
+			for _, cid := range p.GetStringSlice("categories") {
+				if inScope[cid] {
+					kept = append(kept, p)
+					break
+				}
+			}
+		}
+		return kept, nil
+	}
+
+	return tx.FindRecordsByFilter("products", filter, "name", 0, 0, params)
+}
+
+// inventoryCategoryNames : nom de la première catégorie de chaque produit
+func inventoryCategoryNames(tx *daos.Dao, products []*models.Record) map[string]string {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, p := range products {
+		if cats := p.GetStringSlice("categories"); len(cats) > 0 && !seen[cats[0]] {
+			seen[cats[0]] = true
+			ids = append(ids, cats[0])
+		}
+	}
+
+	names := map[string]string{}
+	if len(ids) == 0 {
+		return names
+	}
+	categories, err := tx.FindRecordsByIds("categories", ids)
+	if err != nil {
+		return names
+	}
+	for _, cat := range categories {
+		names[cat.Id] = cat.GetString("name")
+	}
+	return names
+}
+
+// newInventoryEntry : l'entrée gelée d'un produit. Pour une session libre, la
+// « catégorie » affichée est le nom de la session, comme le faisait le client.
+func newInventoryEntry(col *models.Collection, session, product *models.Record, categoryNames map[string]string, theorique float64) *models.Record {
+	categoryID := inventoryNoCategoryID
+	if cats := product.GetStringSlice("categories"); len(cats) > 0 {
+		categoryID = cats[0]
+	}
+	categoryName := categoryNames[categoryID]
+	if session.GetString("scope") == "free" {
+		categoryName = session.GetString("label")
+		if categoryName == "" {
+			categoryName = "Session libre"
+		}
+	}
+	if categoryName == "" {
+		categoryName = "Sans catégorie"
+	}
+
+	name := product.GetString("name")
+	if name == "" {
+		name = product.GetString("sku")
+	}
+	if name == "" {
+		name = product.Id
+	}
+
+	image := ""
+	if file := product.GetString("image"); file != "" {
+		image = "/api/files/" + product.Collection().Id + "/" + product.Id + "/" + file
+	}
+
+	entry := models.NewRecord(col)
+	entry.Set("session_id", session.Id)
+	// L'identifiant POCKETBASE : le pont legacy_id n'est lu qu'à l'affichage
+	// des entrées d'avant.
+	entry.Set("product_id", product.Id)
+	entry.Set("product_name", name)
+	entry.Set("product_sku", product.GetString("sku"))
+	entry.Set("product_barcode", product.GetString("barcode"))
+	entry.Set("product_image", image)
+	entry.Set("category_id", categoryID)
+	entry.Set("category_name", categoryName)
+	entry.Set("stock_theorique", theorique)
+	entry.Set("status", "pending")
+	entry.Set("adjusted", false)
+	entry.Set("scan_count", 0)
+	return entry
+}
+
+// applyInventoryCount : une saisie, dans sa propre transaction
+func applyInventoryCount(app *pocketbase.PocketBase, sessionID, mode, device string, line InventoryCountLine) InventoryCountResult {
+	res := InventoryCountResult{EntryID: line.EntryID, ProductID: line.ProductID}
+
+	if line.EntryID == "" && line.ProductID == "" && line.Barcode == "" {
+		res.Error = "entry_id, product_id ou barcode requis"
+		return res
+	}
+
+	err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+		// Relue dans la transaction : une clôture a pu passer entre-temps
+		session, err := tx.FindRecordById("inventory_sessions", sessionID)
+		if err != nil {
+			return err
+		}
+		if session.GetString("status") != "in_progress" {
+			return fmt.Errorf("la session n'est plus en cours")
+		}
+
+		entry, created, err := resolveInventoryEntry(tx, session, line)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range session.GetStringSlice("validated_category_ids") {
+			if id == entry.GetString("category_id") {
+				return fmt.Errorf("catégorie déjà validée")
+			}
+		}
+
+		var current *float64
+		if entry.GetString("status") == "counted" {
+			v := entry.GetFloat("stock_compte")
+			current = &v
+		}
+		next := MergeCount(mode, current, line.Quantity)
+		if next < 0 {
+			return fmt.Errorf("le stock compté ne peut pas être négatif")
+		}
+
+		now := types.NowDateTime()
+		salesDelta, err := inventoryMovementDelta(tx, session, entry, now)
+		if err != nil {
+			return err
+		}
+
+		scans := entry.GetInt("scan_count") + 1
+		entry.Set("stock_compte", next)
+		entry.Set("sales_delta", salesDelta)
+		entry.Set("status", "counted")
+		entry.Set("counted_at", now)
+		entry.Set("counted_by", device)
+		entry.Set("scan_count", scans)
+		if err := tx.SaveRecord(entry); err != nil {
+			return err
+		}
+
+		res.EntryID = entry.Id
+		res.ProductID = entry.GetString("product_id")
+		res.ProductName = entry.GetString("product_name")
+		res.StockCompte = next
+		res.SalesDelta = salesDelta
+		res.ScanCount = scans
+		res.Created = created
+		return nil
+	})
+
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// resolveInventoryEntry retrouve l'entrée visée par une saisie. Dans une
+// session libre, un produit du catalogue absent de la session y est ajouté,
+// gelé au stock du moment.
+func resolveInventoryEntry(tx *daos.Dao, session *models.Record, line InventoryCountLine) (*models.Record, bool, error) {
+	if line.EntryID != "" {
+		entry, err := tx.FindRecordById("inventory_entries", line.EntryID)
+		if err != nil || entry.GetString("session_id") != session.Id {
+			return nil, false, fmt.Errorf("entrée introuvable dans cette session")
+		}
+		return entry, false, nil
+	}
+
+	// Produit désigné : par id / legacy_id, ou par code-barres
+	var product *models.Record
+	var err error
+	if line.ProductID != "" {
+		product, err = tx.FindFirstRecordByFilter(
+			"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": line.ProductID})
+	} else {
+		filter := "barcode = {:code}"
+		params := dbx.Params{"code": line.Barcode}
+		if company := session.GetString("owner_company"); company != "" {
+			filter += " && company = {:company}"
+			params["company"] = company
+		}
+		product, err = tx.FindFirstRecordByFilter("products", filter, params)
+	}
+
+	keys := []string{line.ProductID}
+	if product != nil {
+		keys = []string{product.Id, product.GetString("legacy_id")}
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if entry, err := tx.FindFirstRecordByFilter(
+			"inventory_entries", "session_id = {:session} && product_id = {:cle}",
+			dbx.Params{"session": session.Id, "cle": key},
+		); err == nil {
+			return entry, false, nil
+		}
+	}
+	if product == nil && line.Barcode != "" {
+		// Le code-barres gelé de l'entrée, si le produit l'a changé depuis
+		if entry, err := tx.FindFirstRecordByFilter(
+			"inventory_entries", "session_id = {:session} && product_barcode = {:code}",
+			dbx.Params{"session": session.Id, "code": line.Barcode},
+		); err == nil {
+			return entry, false, nil
+		}
+	}
+
+	if err != nil || product == nil {
+		return nil, false, fmt.Errorf("produit introuvable")
+	}
+	if session.GetString("scope") != "free" {
+		return nil, false, fmt.Errorf("produit hors périmètre de l'inventaire")
+	}
+
+	col, err := tx.FindCollectionByNameOrId("inventory_entries")
+	if err != nil {
+		return nil, false, err
+	}
+	location, err := inventoryLocation(tx, session)
+	if err != nil {
+		return nil, false, err
+	}
+	theorique, err := inventoryTheorique(tx, location, product)
+	if err != nil {
+		return nil, false, err
+	}
+	entry := newInventoryEntry(col, session, product, nil, theorique)
+	return entry, true, nil
+}
+
+// inventoryLocation : l'emplacement compté par la session, nil sans emplacement
+func inventoryLocation(tx *daos.Dao, session *models.Record) (*models.Record, error) {
+	id := session.GetString("location")
+	if id == "" {
+		return nil, nil
+	}
+	location, err := tx.FindRecordById("stock_locations", id)
+	if err != nil {
+		return nil, fmt.Errorf("emplacement de la session introuvable")
+	}
+	return location, nil
+}
+
+// inventoryTheorique : le stock gelé d'un produit — à l'emplacement compté
+// s'il y en a un, sinon le total
+func inventoryTheorique(tx *daos.Dao, location, product *models.Record) (float64, error) {
+	if location == nil {
+		return product.GetFloat("stock"), nil
+	}
+	return locationQuantity(tx, product, location)
+}
+
+// applyInventoryGap ajoute l'écart au stock du produit et écrit l'événement
+// `stock_adjusted_inventory`, dans la transaction de la clôture. Un produit
+// disparu du catalogue n'est pas ajustable : il est rendu, non levé, pour
+// qu'une entrée orpheline ne bloque pas la clôture de toute la session.
+func applyInventoryGap(tx *daos.Dao, eventsCol *models.Collection, session, entry *models.Record, operator string, now types.DateTime) (InventoryAdjustment, error) {
+	counted := entry.GetFloat("stock_compte")
+	theorique := entry.GetFloat("stock_theorique")
+	salesDelta := entry.GetFloat("sales_delta")
+	adj := InventoryAdjustment{
+		EntryID:     entry.Id,
+		ProductID:   entry.GetString("product_id"),
+		ProductName: entry.GetString("product_name"),
+		StockAfter:  counted,
+	}
+
+	product, err := tx.FindFirstRecordByFilter(
+		"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": adj.ProductID})
+	if err != nil {
+		adj.Error = "produit introuvable au catalogue"
+		return adj, nil
+	}
+
+	location, err := inventoryLocation(tx, session)
+	if err != nil {
+		return adj, err
+	}
+	// L'écart se mesure à l'emplacement compté quand il y en a un ; le total
+	// le suit
+	here, err := inventoryTheorique(tx, location, product)
+	if err != nil {
+		return adj, err
+	}
+	gap, hereAfter := ReconcileCount(theorique, salesDelta, counted, here)
+	adj.StockBefore = &here
+	adj.StockAfter = hereAfter
+	adj.SalesDelta = salesDelta
+	adj.ProductID = product.Id
+
+	before := product.GetFloat("stock")
+	after := before + gap
+
+	if gap != 0 {
+		if location != nil {
+			if _, _, err := moveLocationStock(tx, product, location, StockMovementInput{Delta: &gap}); err != nil {
+				return adj, err
+			}
+		} else {
+			product.Set("stock", after)
+		}
+		backend.MarkProductJournaled(product) // l'écart est journalisé ici
+		if err := tx.SaveRecord(product); err != nil {
+			return adj, fmt.Errorf("produit %q : %w", adj.ProductName, err)
+		}
+
+		event := models.NewRecord(eventsCol)
+		event.Set("product_id", product.Id)
+		event.Set("product_name_snapshot", product.GetString("name"))
+		event.Set("product_sku_snapshot", product.GetString("sku"))
+		event.Set("event_type", "stock_adjusted_inventory")
+		event.Set("source", "inventory_session")
+		event.Set("source_id", session.Id)
+		event.Set("operator", operator)
+		event.Set("occurred_at", now)
+		event.Set("before", map[string]any{"stock": before})
+		event.Set("after", map[string]any{"stock": after})
+		event.Set("delta", map[string]any{"stock": gap})
+		metadata := map[string]any{
+			"session_id":      session.Id,
+			"entry_id":        entry.Id,
+			"adjusted_at":     now.String(),
+			"session_label":   session.GetString("label"),
+			"stock_theorique": theorique,
+			"sales_delta":     salesDelta,
+			"stock_compte":    counted,
+			"counted_at":      entry.GetString("counted_at"),
+			"counted_by":      entry.GetString("counted_by"),
+		}
+		if location != nil {
+			metadata["location_id"] = location.Id
+			metadata["location_stock_before"] = here
+			metadata["location_stock_after"] = hereAfter
+		}
+		event.Set("metadata", metadata)
+		if err := tx.SaveRecord(event); err != nil {
+			return adj, fmt.Errorf("journal de %q : %w", adj.ProductName, err)
+		}
+		adj.EventID = event.Id
+	}
+
+	entry.Set("adjusted", true)
+	entry.Set("adjusted_at", now)
+	if err := tx.SaveRecord(entry); err != nil {
+		return adj, err
+	}
+	return adj, nil
+}
+
+// InventoryStats — les champs stats_* de la session
+type InventoryStats struct {
+	Total         int
+	Counted       int
+	Gaps          int
+	CategoryNames []string
+}
+
+// ComputeInventoryStats calcule les stats dénormalisées de la session, comme
+// le faisaient `backfillInventoryStats` et le client avant elles.
+func ComputeInventoryStats(entries []*models.Record) InventoryStats {
+	stats := InventoryStats{Total: len(entries), CategoryNames: []string{}}
+	names := map[string]bool{}
+	for _, e := range entries {
+		if name := e.GetString("category_name"); name != "" && !names[name] {
+			names[name] = true
+			stats.CategoryNames = append(stats.CategoryNames, name)
+		}
+		if e.GetString("status") != "counted" {
+			continue
+		}
+		stats.Counted++
+		if inventoryEntryGap(e) != 0 {
+			stats.Gaps++
+		}
+	}
+	sort.Strings(stats.CategoryNames)
+	return stats
+}
+
+// inventoryEntryGap : écart d'une entrée comptée contre son stock attendu
+func inventoryEntryGap(entry *models.Record) float64 {
+	gap, _ := ReconcileCount(
+		entry.GetFloat("stock_theorique"), entry.GetFloat("sales_delta"), entry.GetFloat("stock_compte"), 0)
+	return gap
+}
+
+// inventoryStockEvents : les événements du journal qui bougent un stock
+var inventoryStockEvents = []any{
+	"stock_sale", "stock_return", "stock_received", "stock_transferred",
+	"stock_rma", "stock_updated", "stock_adjusted_inventory",
+}
+
+// LocationStockDelta — ce qu'un événement du journal a fait au stock de
+// l'emplacement compté. `locationID` vide : l'inventaire porte sur le total
+// du produit, seul `delta.stock` compte. Un événement sans emplacement a
+// touché l'emplacement par défaut (vente d'avant les emplacements, fiche
+// produit, réception d'une société sans emplacement).
+func LocationStockDelta(eventType string, delta, metadata map[string]any, locationID string, isDefault bool) float64 {
+	stock := eventFloat(delta, "stock")
+	if locationID == "" {
+		return stock
+	}
+
+	switch eventType {
+	case "stock_transferred":
+		// Le total ne bouge pas ; l'emplacement, si
+		var moved float64
+		if metadata["to_location_id"] == locationID {
+			moved += eventFloat(metadata, "quantity")
+		}
+		if metadata["from_location_id"] == locationID {
+			moved -= eventFloat(metadata, "quantity")
+		}
+		return moved
+	case "stock_rma":
+		// Passage par le bac RMA : deux emplacements, chacun ses bornes
+		if _, ok := metadata["rma_location_id"]; ok {
+			var moved float64
+			if metadata["rma_location_id"] == locationID {
+				moved += eventFloat(metadata, "rma_location_after") - eventFloat(metadata, "rma_location_before")
+			}
+			if metadata["location_id"] == locationID {
+				moved += eventFloat(metadata, "location_stock_after") - eventFloat(metadata, "location_stock_before")
+			}
+			return moved
+		}
+	}
+
+	at, _ := metadata["location_id"].(string)
+	if at == locationID || (at == "" && isDefault) {
+		return stock
+	}
+	return 0
+}
+
+func eventFloat(values map[string]any, key string) float64 {
+	v, _ := values[key].(float64)
+	return v
+}
+
+// inventoryMovementDelta : mouvement net du stock de l'emplacement compté
+// (ventes, retours, réceptions, transferts, RMA, fiche produit) pour le
+// produit de l'entrée, entre le gel de la session et `until`. Les écarts de
+// la session elle-même n'en sont pas. Le journal porte l'identifiant
+// PocketBase du produit ; une entrée d'avant le 19 août 2026 porte un
+// identifiant NeDB — on cherche sous les deux clés.
+func inventoryMovementDelta(tx *daos.Dao, session, entry *models.Record, until types.DateTime) (float64, error) {
+	from := session.GetDateTime("apppos_snapshot_at")
+	if from.IsZero() || until.IsZero() {
+		return 0, nil
+	}
+
+	keys := []any{entry.GetString("product_id")}
+	if product, err := tx.FindFirstRecordByFilter(
+		"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": entry.GetString("product_id")},
+	); err == nil {
+		keys = append(keys, product.Id)
+		if legacy := product.GetString("legacy_id"); legacy != "" {
+			keys = append(keys, legacy)
+		}
+	}
+
+	locationID := session.GetString("location")
+	isDefault := false
+	if locationID != "" {
+		if location, err := tx.FindRecordById("stock_locations", locationID); err == nil {
+			isDefault = location.GetBool("is_default")
+		}
+	}
+
+	var rows []struct {
+		EventType string        `db:"event_type"`
+		Delta     types.JsonMap `db:"delta"`
+		Metadata  types.JsonMap `db:"metadata"`
+	}
+	err := tx.DB().
+		Select("event_type", "delta", "metadata").
+		From("product_events").
+		Where(dbx.In("event_type", inventoryStockEvents...)).
+		AndWhere(dbx.In("product_id", keys...)).
+		AndWhere(dbx.NewExp("occurred_at > {:from} AND occurred_at <= {:until}", dbx.Params{
+			"from":  from.String(),
+			"until": until.String(),
+		})).
+		AndWhere(dbx.NewExp("NOT (source = 'inventory_session' AND source_id = {:session})",
+			dbx.Params{"session": session.Id})).
+		All(&rows)
+	if err != nil {
+		return 0, fmt.Errorf("mouvements pendant l'inventaire : %w", err)
+	}
+
+	var total float64
+	for _, row := range rows {
+		total += LocationStockDelta(row.EventType, row.Delta, row.Metadata, locationID, isDefault)
+	}
+	return roundAmount(total), nil
+}
+
+// inventoryOperatorName : le nom retenu dans le journal
+func inventoryOperatorName(user *models.Record, fallback string) string {
+	if user != nil {
+		for _, field := range []string{"name", "username", "email"} {
+			if v := strings.TrimSpace(user.GetString(field)); v != "" {
+				return v
+			}
+		}
+	}
+	return fallback
+}
+
+// inventoryError rend une erreur d'API telle quelle, et enveloppe les autres
+func inventoryError(err error) error {
+	if apiErr, ok := err.(*apis.ApiError); ok {
+		return apiErr
+	}
+	return apis.NewBadRequestError(err.Error(), nil)
+}
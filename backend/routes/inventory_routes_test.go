@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// La fusion des saisies est la seule règle que deux postes partagent : si elle
+// change, deux scans du même rayon ne s'additionnent plus, ou s'écrasent.
+func TestMergeCount(t *testing.T) {
+	cas := []struct {
+		nom     string
+		mode    string
+		actuel  *float64
+		saisie  float64
+		attendu float64
+	}{
+		{"la première saisie pose sa valeur", CountPolicySum, nil, 3, 3},
+		{"deux postes s'additionnent", CountPolicySum, ptr(3), 2, 5},
+		{"un scan de trop se retire", CountPolicySum, ptr(3), -1, 2},
+		{"un recomptage remplace", CountPolicyOverwrite, ptr(3), 2, 2},
+		{"même sans comptage préalable", CountPolicyOverwrite, nil, 4, 4},
+		// Le total négatif n'est pas plafonné ici : c'est la route qui refuse.
+		{"le total négatif est rendu tel quel", CountPolicySum, ptr(1), -3, -2},
+	}
+
+	for _, c := range cas {
+		if got := MergeCount(c.mode, c.actuel, c.saisie); got != c.attendu {
+			t.Errorf("%s : attendu %v, obtenu %v", c.nom, c.attendu, got)
+		}
+	}
+}
+
+// Gelé à 10. Deux ventes avant le comptage, une après. On compte 7 : il en
+// manque un. La clôture doit retirer ce manquant du stock courant (7, la
+// vente d'après comptage déjà retirée), pas y poser 7 — ce qui rendrait
+// l'unité vendue après le comptage.
+func TestReconcileCount(t *testing.T) {
+	cas := []struct {
+		nom                                string
+		theorique, ventes, compte, courant float64
+		ecartAttendu, stockAttendu         float64
+	}{
+		{"les ventes pendant le comptage ne sont pas un écart", 10, -2, 8, 7, 0, 7},
+		{"le manquant se retire du stock courant", 10, -2, 7, 7, -1, 6},
+		{"un retour pendant le comptage aussi", 10, 1, 11, 11, 0, 11},
+		{"sans vente, on retrouve l'ancien calcul", 10, 0, 4, 10, -6, 4},
+		{"une réception pendant le comptage n'est pas un écart", 10, 5, 15, 15, 0, 15},
+		// Le stock négatif reste une information (voir NextStock)
+		{"rien n'est plafonné à zéro", 1, -3, 0, -2, 2, 0},
+	}
+
+	for _, c := range cas {
+		ecart, stock := ReconcileCount(c.theorique, c.ventes, c.compte, c.courant)
+		if ecart != c.ecartAttendu || stock != c.stockAttendu {
+			t.Errorf("%s : attendu écart %v / stock %v, obtenu %v / %v",
+				c.nom, c.ecartAttendu, c.stockAttendu, ecart, stock)
+		}
+	}
+}
+
+// Ce qu'un événement du journal fait à l'emplacement compté. Un transfert ne
+// bouge pas le total mais vide un emplacement ; un passage au bac RMA a deux
+// emplacements ; un événement sans emplacement est à l'emplacement par défaut.
+func TestLocationStockDelta(t *testing.T) {
+	transfert := map[string]any{"from_location_id": "reserve", "to_location_id": "boutique", "quantity": 3.0}
+	rma := map[string]any{
+		"rma_location_id": "bac", "rma_location_before": 0.0, "rma_location_after": 1.0,
+		"location_id": "boutique", "location_stock_before": 4.0, "location_stock_after": 3.0,
+	}
+	cas := []struct {
+		nom         string
+		evenement   string
+		delta, meta map[string]any
+		emplacement string
+		parDefaut   bool
+		attendu     float64
+	}{
+		{"sans emplacement, le total", "stock_sale", map[string]any{"stock": -2.0}, map[string]any{}, "", false, -2},
+		{"vente à l'emplacement", "stock_sale", map[string]any{"stock": -2.0}, map[string]any{"location_id": "boutique"}, "boutique", false, -2},
+		{"vente ailleurs", "stock_sale", map[string]any{"stock": -2.0}, map[string]any{"location_id": "reserve"}, "boutique", false, 0},
+		{"réception à l'emplacement", "stock_received", map[string]any{"stock": 5.0}, map[string]any{"location_id": "boutique"}, "boutique", false, 5},
+		{"fiche produit, emplacement par défaut", "stock_updated", map[string]any{"stock": 4.0}, map[string]any{}, "boutique", true, 4},
+		{"fiche produit, autre emplacement", "stock_updated", map[string]any{"stock": 4.0}, map[string]any{}, "reserve", false, 0},
+		{"transfert entrant", "stock_transferred", map[string]any{"stock": 0.0}, transfert, "boutique", true, 3},
+		{"transfert sortant", "stock_transferred", map[string]any{"stock": 0.0}, transfert, "reserve", false, -3},
+		{"transfert, total inchangé", "stock_transferred", map[string]any{"stock": 0.0}, transfert, "", false, 0},
+		{"RMA, le rayon", "stock_rma", map[string]any{"stock": 0.0}, rma, "boutique", true, -1},
+		{"RMA, le bac", "stock_rma", map[string]any{"stock": 0.0}, rma, "bac", false, 1},
+	}
+
+	for _, c := range cas {
+		if got := LocationStockDelta(c.evenement, c.delta, c.meta, c.emplacement, c.parDefaut); got != c.attendu {
+			t.Errorf("%s : attendu %v, obtenu %v", c.nom, c.attendu, got)
+		}
+	}
+}
+
+// Gelé à 10, une réception de 5 pendant le comptage, on compte 15 : aucun
+// écart, le stock reste à 15. Avec les seules ventes, la réception passait
+// pour un surplus et la clôture posait 20.
+func TestReceptionPendantLeComptage(t *testing.T) {
+	c := caisseDeTest(t)
+	dao := c.app.Dao()
+	gel := time.Now().UTC().Add(-time.Hour)
+
+	evenements, err := dao.FindCollectionByNameOrId("product_events")
+	if err != nil {
+		t.Fatalf("collection: %v", err)
+	}
+	for _, ev := range []struct {
+		typ, source string
+		stock       float64
+		quand       time.Time
+	}{
+		{"stock_received", "purchase_receipt", 5, gel.Add(10 * time.Minute)},
+		{"stock_sale", "sale", -1, gel.Add(-10 * time.Minute)}, // avant le gel
+	} {
+		rec := models.NewRecord(evenements)
+		rec.Set("product_id", "p1")
+		rec.Set("event_type", ev.typ)
+		rec.Set("source", ev.source)
+		rec.Set("occurred_at", ev.quand)
+		rec.Set("delta", map[string]any{"stock": ev.stock})
+		if err := dao.SaveRecord(rec); err != nil {
+			t.Fatalf("événement %s: %v", ev.typ, err)
+		}
+	}
+
+	sessions, _ := dao.FindCollectionByNameOrId("inventory_sessions")
+	entrees, _ := dao.FindCollectionByNameOrId("inventory_entries")
+	session := models.NewRecord(sessions)
+	session.Set("apppos_snapshot_at", gel)
+	entree := models.NewRecord(entrees)
+	entree.Set("product_id", "p1")
+
+	compte, _ := types.ParseDateTime(gel.Add(20 * time.Minute))
+	delta, err := inventoryMovementDelta(dao, session, entree, compte)
+	if err != nil {
+		t.Fatalf("mouvements: %v", err)
+	}
+	ecart, stock := ReconcileCount(10, delta, 15, 15)
+	if delta != 5 || ecart != 0 || stock != 15 {
+		t.Errorf("attendu mouvements 5 / écart 0 / stock 15, obtenu %v / %v / %v", delta, ecart, stock)
+	}
+}
@@ -0,0 +1,339 @@
+// backend/routes/label_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — ÉTIQUETTES PRODUIT
+// ═══════════════════════════════════════════════════════════════════════════
+// Étiquettes de prix rendues depuis le catalogue, selon un modèle
+// (label_templates, géré par l'API REST de PocketBase). Les produits sont
+// choisis par sélection, par catégorie, ou parmi ceux dont le prix de vente a
+// changé depuis une date (événements sale_price_changed de product_events).
+//
+//   POST /api/labels/render → le document (PDF, HTML, ZPL, EPL ou ESC/POS)
+//   POST /api/labels/print  → envoi direct à l'imprimante (ZPL, EPL, ESC/POS)
+//
+// Les étiquettes ne passent pas par la file d'impression : une Zebra ne
+// répond pas à l'état ESC/POS, et un lot raté se relance d'un clic.
+
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+
+	"pocket-react/backend/pos"
+)
+
+// Garde-fou : une planche de 24 étiquettes × 100 pages
+const maxLabelProducts = 2400
+
+// LabelRequestInput : modèle et produits à étiqueter
+type LabelRequestInput struct {
+	OwnerCompany      string   `json:"owner_company"`
+	TemplateID        string   `json:"template_id"` // vide = modèle par défaut de l'entreprise
+	Format            string   `json:"format"`      // remplace le format du modèle
+	ProductIDs        []string `json:"product_ids"`
+	CategoryID        string   `json:"category_id"`
+	PriceChangedSince string   `json:"price_changed_since"` // YYYY-MM-DD
+	Copies            int      `json:"copies"`
+	Preview           bool     `json:"preview"` // planche A4 : HTML au lieu du PDF
+
+	// Impression
+	PrinterName    string `json:"printer_name"`
+	CashRegisterID string `json:"cash_register_id"`
+	Width          int    `json:"width"`
+}
+
+func RegisterLabelRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/labels/render
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/labels/render", func(c echo.Context) error {
+		var input LabelRequestInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+
+		tpl, labels, err := prepareLabels(app.Dao(), input)
+		if err != nil {
+			return err
+		}
+
+		switch tpl.Format {
+		case pos.LabelFormatSheet:
+			if input.Preview {
+				return c.HTML(http.StatusOK, pos.BuildLabelSheetHTML(tpl, labels, input.Copies))
+			}
+			pdf, err := pos.BuildLabelSheetPDF(tpl, labels, input.Copies)
+			if err != nil {
+				return apis.NewApiError(500, "Génération du PDF impossible", err)
+			}
+			c.Response().Header().Set("Content-Disposition", `attachment; filename="etiquettes.pdf"`)
+			return c.Blob(http.StatusOK, "application/pdf", pdf)
+		case pos.LabelFormatZPL:
+			c.Response().Header().Set("Content-Disposition", `attachment; filename="etiquettes.zpl"`)
+			return c.Blob(http.StatusOK, "text/plain; charset=utf-8", pos.BuildLabelsZPL(tpl, labels, input.Copies))
+		case pos.LabelFormatEPL:
+			c.Response().Header().Set("Content-Disposition", `attachment; filename="etiquettes.epl"`)
+			return c.Blob(http.StatusOK, "text/plain; charset=utf-8", pos.BuildLabelsEPL(tpl, labels, input.Copies))
+		default:
+			c.Response().Header().Set("Content-Disposition", `attachment; filename="etiquettes.bin"`)
+			return c.Blob(http.StatusOK, "application/octet-stream",
+				pos.BuildLabelsESCPOS(tpl, labels, input.Copies, labelPaperWidth(input.Width), false))
+		}
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/labels/print
+	// L'imprimante est résolue comme pour les tickets : configuration de la
+	// caisse (settings.printer), sinon le nom d'une file du système. Un
+	// transport réseau ou USB ne se déclare que dans la caisse.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/labels/print", func(c echo.Context) error {
+		var input LabelRequestInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+
+		tpl, labels, err := prepareLabels(app.Dao(), input)
+		if err != nil {
+			return err
+		}
+		if tpl.Format == pos.LabelFormatSheet {
+			return apis.NewBadRequestError("Planche A4 : téléchargez le PDF et imprimez-le sur la feuille d'étiquettes", nil)
+		}
+
+		printer, printerConfig, err := resolvePosPrinter(app, input.CashRegisterID, input.PrinterName)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		var data []byte
+		switch tpl.Format {
+		case pos.LabelFormatZPL:
+			data = pos.BuildLabelsZPL(tpl, labels, input.Copies)
+		case pos.LabelFormatEPL:
+			data = pos.BuildLabelsEPL(tpl, labels, input.Copies)
+		default:
+			width := input.Width
+			if printerConfig.Width != 0 {
+				width = printerConfig.Width
+			}
+			data = pos.BuildLabelsESCPOS(tpl, labels, input.Copies, labelPaperWidth(width), printerConfig.RasterCodes)
+		}
+
+		if err := printer.Write(data); err != nil {
+			return apis.NewApiError(http.StatusBadGateway, fmt.Sprintf("%s : %v", printer, err), nil)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"labels":  len(labels),
+			"printer": printer.String(),
+		})
+	}, apis.RequireRecordAuth())
+}
+
+func labelPaperWidth(width int) int {
+	if width == 80 {
+		return 80
+	}
+	return 58
+}
+
+// prepareLabels résout le modèle et les produits d'une demande
+func prepareLabels(dao *daos.Dao, input LabelRequestInput) (pos.LabelTemplate, []pos.LabelData, error) {
+	if input.OwnerCompany == "" {
+		return pos.LabelTemplate{}, nil, apis.NewBadRequestError("owner_company requis", nil)
+	}
+
+	tpl, err := loadLabelTemplate(dao, input.OwnerCompany, input.TemplateID)
+	if err != nil {
+		return tpl, nil, apis.NewNotFoundError("Modèle d'étiquette introuvable", err)
+	}
+	if input.Format != "" {
+		tpl.Format = input.Format
+	}
+	switch tpl.Format {
+	case pos.LabelFormatZPL, pos.LabelFormatEPL, pos.LabelFormatESCPOS, pos.LabelFormatSheet:
+	default:
+		return tpl, nil, apis.NewBadRequestError("format inconnu : "+tpl.Format, nil)
+	}
+
+	products, err := selectLabelProducts(dao, input)
+	if err != nil {
+		return tpl, nil, err
+	}
+	if len(products) == 0 {
+		return tpl, nil, apis.NewBadRequestError("Aucun produit à étiqueter", nil)
+	}
+
+	return tpl.WithDefaults(), labelDataFromProducts(dao, products), nil
+}
+
+// loadLabelTemplate : le modèle demandé, sinon celui marqué par défaut, sinon
+// une planche A4 standard
+func loadLabelTemplate(dao *daos.Dao, ownerCompany, templateID string) (pos.LabelTemplate, error) {
+	var record *models.Record
+	if templateID != "" {
+		rec, err := dao.FindRecordById("label_templates", templateID)
+		if err != nil || rec.GetString("owner_company") != ownerCompany {
+			return pos.LabelTemplate{}, fmt.Errorf("modèle %s introuvable", templateID)
+		}
+		record = rec
+	} else {
+		record, _ = dao.FindFirstRecordByFilter(
+			"label_templates",
+			"owner_company = {:company} && is_default = true",
+			dbx.Params{"company": ownerCompany},
+		)
+	}
+	if record == nil {
+		return pos.LabelTemplate{Format: pos.LabelFormatSheet}, nil
+	}
+
+	return pos.LabelTemplate{
+		Name:         record.GetString("name"),
+		Format:       record.GetString("format"),
+		WidthMM:      record.GetFloat("width_mm"),
+		HeightMM:     record.GetFloat("height_mm"),
+		Dpi:          record.GetInt("dpi"),
+		Columns:      record.GetInt("columns"),
+		Rows:         record.GetInt("rows"),
+		MarginTopMM:  record.GetFloat("margin_top_mm"),
+		MarginLeftMM: record.GetFloat("margin_left_mm"),
+		GapXMM:       record.GetFloat("gap_x_mm"),
+		GapYMM:       record.GetFloat("gap_y_mm"),
+		Fields:       record.GetStringSlice("fields"),
+	}, nil
+}
+
+// selectLabelProducts réunit la sélection, la catégorie et les produits dont
+// le prix a changé, sans doublon, triés par nom
+func selectLabelProducts(dao *daos.Dao, input LabelRequestInput) ([]*models.Record, error) {
+	if len(input.ProductIDs) == 0 && input.CategoryID == "" && input.PriceChangedSince == "" {
+		return nil, apis.NewBadRequestError("product_ids, category_id ou price_changed_since requis", nil)
+	}
+
+	byID := make(map[string]*models.Record)
+	keep := func(records []*models.Record) {
+		for _, r := range records {
+			if r.GetString("company") == input.OwnerCompany {
+				byID[r.Id] = r
+			}
+		}
+	}
+
+	if len(input.ProductIDs) > 0 {
+		records, err := dao.FindRecordsByIds("products", input.ProductIDs)
+		if err != nil {
+			return nil, apis.NewApiError(500, "Lecture des produits impossible", err)
+		}
+		keep(records)
+	}
+
+	if input.CategoryID != "" {
+		records, err := dao.FindRecordsByFilter(
+			"products",
+			"company = {:company} && categories ~ {:category}",
+			"name",
+			maxLabelProducts,
+			0,
+			dbx.Params{"company": input.OwnerCompany, "category": input.CategoryID},
+		)
+		if err != nil {
+			return nil, apis.NewApiError(500, "Lecture de la catégorie impossible", err)
+		}
+		keep(records)
+	}
+
+	if input.PriceChangedSince != "" {
+		since, err := time.ParseInLocation("2006-01-02", input.PriceChangedSince, time.Local)
+		if err != nil {
+			return nil, apis.NewBadRequestError("price_changed_since invalide (YYYY-MM-DD attendu)", err)
+		}
+		ids, err := priceChangedProductIDs(dao, since)
+		if err != nil {
+			return nil, apis.NewApiError(500, "Lecture des changements de prix impossible", err)
+		}
+		if len(ids) > 0 {
+			records, err := dao.FindRecordsByIds("products", ids)
+			if err != nil {
+				return nil, apis.NewApiError(500, "Lecture des produits impossible", err)
+			}
+			keep(records)
+		}
+	}
+
+	if len(byID) > maxLabelProducts {
+		return nil, apis.NewBadRequestError(fmt.Sprintf("Trop de produits (%d, maximum %d)", len(byID), maxLabelProducts), nil)
+	}
+
+	products := make([]*models.Record, 0, len(byID))
+	for _, r := range byID {
+		products = append(products, r)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return strings.ToLower(products[i].GetString("name")) < strings.ToLower(products[j].GetString("name"))
+	})
+	return products, nil
+}
+
+// priceChangedProductIDs : produits ayant un sale_price_changed depuis since
+func priceChangedProductIDs(dao *daos.Dao, since time.Time) ([]string, error) {
+	var rows []struct {
+		ProductID string `db:"product_id"`
+	}
+	err := dao.DB().
+		Select("product_id").
+		Distinct(true).
+		From("product_events").
+		Where(dbx.NewExp(
+			"event_type = 'sale_price_changed' AND occurred_at >= {:since}",
+			dbx.Params{"since": since.UTC().Format("2006-01-02 15:04:05.000Z")},
+		)).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ProductID)
+	}
+	return ids, nil
+}
+
+func labelDataFromProducts(dao *daos.Dao, products []*models.Record) []pos.LabelData {
+	brands := make(map[string]string)
+	labels := make([]pos.LabelData, 0, len(products))
+
+	for _, p := range products {
+		brandID := p.GetString("brand")
+		if _, seen := brands[brandID]; !seen && brandID != "" {
+			if brand, err := dao.FindRecordById("brands", brandID); err == nil {
+				brands[brandID] = brand.GetString("name")
+			} else {
+				brands[brandID] = ""
+			}
+		}
+
+		labels = append(labels, pos.LabelData{
+			Name:     p.GetString("name"),
+			Brand:    brands[brandID],
+			Sku:      p.GetString("sku"),
+			Barcode:  p.GetString("barcode"),
+			PriceTtc: p.GetFloat("price_ttc"),
+		})
+	}
+	return labels
+}
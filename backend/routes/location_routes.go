@@ -0,0 +1,574 @@
+// backend/routes/location_routes.go
+//
+// LE STOCK PAR EMPLACEMENT — boutique, réserve, atelier.
+//
+//   POST /api/stock/locations/:id/default   change l'emplacement par défaut
+//   GET  /api/stock/locations/:id/levels    les quantités d'un emplacement
+//   POST /api/stock/transfers               déplace des quantités, d'un bloc
+//
+// ── LE MODÈLE ─────────────────────────────────────────────────────────────
+// `stock_levels` porte la quantité d'un produit à un emplacement, et
+// `products.stock` en reste le TOTAL : tout ce qui lit le stock (caisse,
+// réassort, valorisation, site) continue de lire un seul nombre. Les deux ne
+// s'écrivent jamais séparément : chaque mouvement passe par
+// `moveLocationStock`, qui modifie la ligne et reporte l'écart sur le total
+// dans la même transaction.
+//
+// Un produit qui n'a encore AUCUNE ligne a tout son stock à l'emplacement
+// par défaut de sa société. Sa ligne n'est créée qu'au premier mouvement :
+// pas de migration de deux mille produits le jour où l'on crée la réserve,
+// et un produit créé ou rechargé depuis le catalogue n'a rien à initialiser.
+// Une société sans emplacement fonctionne comme avant, sur le seul total.
+//
+// ── CE QUI CHOISIT L'EMPLACEMENT ─────────────────────────────────────────
+//   - la vente : l'emplacement de la caisse (`default_location`), sinon
+//     celui par défaut ;
+//   - la réception fournisseur : celui choisi à la réception ;
+//   - l'inventaire : celui de la session ;
+//   - le transfert : les deux qu'il nomme.
+// Le journal (`product_events`) garde le total en `before` / `after`, comme
+// avant : la valorisation et le réassort le rejouent tel quel. L'emplacement
+// et sa quantité sont dans `metadata`.
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"pocket-react/backend"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Un transfert se saisit ligne à ligne ; au-delà, c'est un déménagement,
+// qui se fait en plusieurs bons.
+const maxTransferLines = 500
+
+// StockTransferLineInput — une ligne demandée
+type StockTransferLineInput struct {
+	// Identifiant PocketBase OU legacy_id, comme pour /api/stock/adjust
+	ProductID string  `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+}
+
+type stockTransferInput struct {
+	CompanyID    string                   `json:"company_id"`
+	FromLocation string                   `json:"from_location"`
+	ToLocation   string                   `json:"to_location"`
+	Lines        []StockTransferLineInput `json:"lines"`
+	Notes        string                   `json:"notes"`
+}
+
+// StockTransferLine — une ligne postée, telle que stock_transfers.lines la garde
+type StockTransferLine struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	FromBefore  float64 `json:"from_before"`
+	FromAfter   float64 `json:"from_after"`
+	ToBefore    float64 `json:"to_before"`
+	ToAfter     float64 `json:"to_after"`
+	EventID     string  `json:"event_id"`
+}
+
+// LocationLevel — la quantité d'un produit à un emplacement
+type LocationLevel struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Sku         string  `json:"sku"`
+	Quantity    float64 `json:"quantity"`
+}
+
+// MergeTransferLines regroupe les lignes d'un même produit et refuse une
+// quantité nulle ou négative : un transfert se fait dans le sens qu'il nomme.
+func MergeTransferLines(lines []StockTransferLineInput) ([]StockTransferLineInput, error) {
+	merged := []StockTransferLineInput{}
+	index := map[string]int{}
+	for _, l := range lines {
+		key := strings.TrimSpace(l.ProductID)
+		if key == "" {
+			return nil, fmt.Errorf("product_id requis")
+		}
+		if l.Quantity <= 0 || math.IsNaN(l.Quantity) || math.IsInf(l.Quantity, 0) {
+			return nil, fmt.Errorf("quantité invalide pour %s", key)
+		}
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += l.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, StockTransferLineInput{ProductID: key, Quantity: l.Quantity})
+	}
+	return merged, nil
+}
+
+func RegisterLocationRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/stock/locations/:id/default", func(c echo.Context) error {
+		if !hasManagerRole(apis.RequestInfo(c).AuthRecord) {
+			return apis.NewForbiddenError("Changement d'emplacement par défaut réservé aux responsables", nil)
+		}
+
+		var location *models.Record
+		materialized := 0
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			location, err = tx.FindRecordById("stock_locations", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Emplacement introuvable", err)
+			}
+			if location.GetBool("is_default") {
+				return nil
+			}
+			if location.GetBool("archived") {
+				return apis.NewBadRequestError("Un emplacement archivé ne peut pas être l'emplacement par défaut", nil)
+			}
+
+			companyID := location.GetString("owner_company")
+			previous, err := defaultStockLocation(tx, companyID)
+			if err != nil {
+				return err
+			}
+			if previous != nil {
+				// Les produits sans ligne sont comptés à l'ancien emplacement par
+				// défaut : on l'écrit avant de changer, sinon leur stock
+				// changerait de lieu sans que rien n'ait bougé.
+				if materialized, err = materializeDefaultLevels(tx, companyID, previous); err != nil {
+					return err
+				}
+				previous.Set("is_default", false)
+				if err := tx.SaveRecord(previous); err != nil {
+					return err
+				}
+			}
+
+			location.Set("is_default", true)
+			return tx.SaveRecord(location)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("📍 Emplacement par défaut : %s (%d quantité(s) écrite(s))", location.GetString("name"), materialized)
+		return c.JSON(http.StatusOK, location)
+	}, apis.RequireRecordAuth())
+
+	router.GET("/api/stock/locations/:id/levels", func(c echo.Context) error {
+		location, err := app.Dao().FindRecordById("stock_locations", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Emplacement introuvable", err)
+		}
+		levels, err := locationLevels(app.Dao(), location)
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Lecture du stock impossible", err)
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"location": location,
+			"levels":   levels,
+		})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/stock/transfers", func(c echo.Context) error {
+		var input stockTransferInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.FromLocation == "" || input.ToLocation == "" {
+			return apis.NewBadRequestError("from_location et to_location requis", nil)
+		}
+		if input.FromLocation == input.ToLocation {
+			return apis.NewBadRequestError("Les deux emplacements sont identiques", nil)
+		}
+		if len(input.Lines) > maxTransferLines {
+			return apis.NewBadRequestError("trop de lignes dans un seul transfert", nil)
+		}
+		lines, err := MergeTransferLines(input.Lines)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		if len(lines) == 0 {
+			return apis.NewBadRequestError("Aucune ligne à transférer", nil)
+		}
+
+		user := apis.RequestInfo(c).AuthRecord
+		operator := inventoryOperatorName(user, "")
+
+		var transfer *models.Record
+		posted := []StockTransferLine{}
+		err = app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			from, err := tx.FindRecordById("stock_locations", input.FromLocation)
+			if err != nil {
+				return apis.NewNotFoundError("Emplacement de départ introuvable", err)
+			}
+			to, err := tx.FindRecordById("stock_locations", input.ToLocation)
+			if err != nil {
+				return apis.NewNotFoundError("Emplacement d'arrivée introuvable", err)
+			}
+			companyID := from.GetString("owner_company")
+			if to.GetString("owner_company") != companyID ||
+				(input.CompanyID != "" && input.CompanyID != companyID) {
+				return apis.NewBadRequestError("Les emplacements n'appartiennent pas à la même société", nil)
+			}
+			if to.GetBool("archived") {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("L'emplacement %q est archivé", to.GetString("name")), nil)
+			}
+
+			transfersCol, err := tx.FindCollectionByNameOrId("stock_transfers")
+			if err != nil {
+				return err
+			}
+			eventsCol, err := tx.FindCollectionByNameOrId("product_events")
+			if err != nil {
+				return err
+			}
+
+			now := types.NowDateTime()
+			number, year := nextTransferNumber(tx, companyID, now.Time())
+			transfer = models.NewRecord(transfersCol)
+			transfer.RefreshId()
+
+			total := 0.0
+			for _, line := range lines {
+				product, err := tx.FindFirstRecordByFilter(
+					"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": line.ProductID})
+				if err != nil {
+					return apis.NewBadRequestError(fmt.Sprintf("Produit %s introuvable", line.ProductID), nil)
+				}
+				if product.GetString("company") != companyID {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("%q n'appartient pas à la société des emplacements", product.GetString("name")), nil)
+				}
+
+				qty := line.Quantity
+				out := -qty
+				fromBefore, fromAfter, err := moveLocationStock(tx, product, from, StockMovementInput{Delta: &out})
+				if err != nil {
+					return err
+				}
+				// On ne déplace pas ce qu'on n'a pas : un stock faux se corrige
+				// par un inventaire, pas par un transfert.
+				if fromAfter < 0 {
+					return apis.NewBadRequestError(fmt.Sprintf(
+						"Stock insuffisant pour %q à %s : %v disponible(s), %v demandé(s)",
+						product.GetString("name"), from.GetString("name"), fromBefore, qty), nil)
+				}
+				toBefore, toAfter, err := moveLocationStock(tx, product, to, StockMovementInput{Delta: &qty})
+				if err != nil {
+					return err
+				}
+				backend.MarkProductJournaled(product) // stock_transfer ci-dessous
+				if err := tx.SaveRecord(product); err != nil {
+					return fmt.Errorf("produit %q : %w", product.GetString("name"), err)
+				}
+
+				stock := product.GetFloat("stock")
+				event := models.NewRecord(eventsCol)
+				event.Set("product_id", product.Id)
+				event.Set("product_name_snapshot", product.GetString("name"))
+				event.Set("product_sku_snapshot", product.GetString("sku"))
+				event.Set("event_type", "stock_transferred")
+				event.Set("source", "stock_transfer")
+				event.Set("source_id", transfer.Id)
+				event.Set("operator", operator)
+				event.Set("occurred_at", now)
+				// Le total ne bouge pas : le journal le dit, et la valorisation
+				// le rejoue sans rien savoir des emplacements
+				event.Set("before", map[string]any{"stock": stock})
+				event.Set("after", map[string]any{"stock": stock})
+				event.Set("delta", map[string]any{"stock": 0})
+				event.Set("metadata", map[string]any{
+					"transfer_id":      transfer.Id,
+					"transfer_number":  number,
+					"from_location_id": from.Id,
+					"to_location_id":   to.Id,
+					"quantity":         qty,
+					"from_before":      fromBefore,
+					"from_after":       fromAfter,
+					"to_before":        toBefore,
+					"to_after":         toAfter,
+				})
+				if err := tx.SaveRecord(event); err != nil {
+					return fmt.Errorf("journal de %q : %w", product.GetString("name"), err)
+				}
+
+				posted = append(posted, StockTransferLine{
+					ProductID:   product.Id,
+					ProductName: product.GetString("name"),
+					Quantity:    qty,
+					FromBefore:  fromBefore,
+					FromAfter:   fromAfter,
+					ToBefore:    toBefore,
+					ToAfter:     toAfter,
+					EventID:     event.Id,
+				})
+				total += qty
+			}
+
+			transfer.Set("owner_company", companyID)
+			transfer.Set("number", number)
+			transfer.Set("fiscal_year", year)
+			transfer.Set("from_location", from.Id)
+			transfer.Set("to_location", to.Id)
+			if user != nil {
+				transfer.Set("transferred_by", user.Id)
+			}
+			transfer.Set("transferred_at", now)
+			transfer.Set("lines", posted)
+			transfer.Set("total_quantity", total)
+			transfer.Set("notes", input.Notes)
+			return tx.SaveRecord(transfer)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("🔀 Transfert %s : %d ligne(s)", transfer.GetString("number"), len(posted))
+		return c.JSON(http.StatusOK, map[string]any{
+			"transfer": transfer,
+			"lines":    posted,
+		})
+	}, apis.RequireRecordAuth())
+}
+
+// nextTransferNumber : TR-YYYY-XXXX, lu dans la transaction du transfert,
+// comme les numéros de commande fournisseur
+func nextTransferNumber(tx *daos.Dao, companyID string, now time.Time) (string, int) {
+	year := now.Year()
+	prefix := fmt.Sprintf("TR-%d-", year)
+	seq := 1
+	if records, err := tx.FindRecordsByFilter(
+		"stock_transfers", "owner_company = {:company} && fiscal_year = {:year}", "-number", 1, 0,
+		dbx.Params{"company": companyID, "year": year},
+	); err == nil && len(records) > 0 {
+		var last int
+		fmt.Sscanf(strings.TrimPrefix(records[0].GetString("number"), prefix), "%d", &last)
+		seq = last + 1
+	}
+	return fmt.Sprintf("%s%04d", prefix, seq), year
+}
+
+// defaultStockLocation : l'emplacement par défaut de la société, nil si elle
+// n'en a pas — ou si la collection n'existe pas encore
+func defaultStockLocation(tx *daos.Dao, companyID string) (*models.Record, error) {
+	if companyID == "" {
+		return nil, nil
+	}
+	if _, err := tx.FindCollectionByNameOrId("stock_locations"); err != nil {
+		return nil, nil
+	}
+	records, err := tx.FindRecordsByFilter(
+		"stock_locations", "owner_company = {:company} && is_default = true", "created", 1, 0,
+		dbx.Params{"company": companyID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0], nil
+}
+
+// resolveStockLocation : l'emplacement nommé, vérifié contre la société du
+// produit, ou à défaut l'emplacement par défaut. nil : la société n'a pas
+// d'emplacement, le mouvement ne touche que le total.
+func resolveStockLocation(tx *daos.Dao, companyID, locationID string) (*models.Record, error) {
+	if locationID == "" {
+		return defaultStockLocation(tx, companyID)
+	}
+	location, err := tx.FindRecordById("stock_locations", locationID)
+	if err != nil {
+		return nil, fmt.Errorf("emplacement introuvable")
+	}
+	if companyID != "" && location.GetString("owner_company") != companyID {
+		return nil, fmt.Errorf("emplacement %q d'une autre société", location.GetString("name"))
+	}
+	return location, nil
+}
+
+// hasStockLevels : le produit a-t-il déjà une quantité par emplacement ?
+func hasStockLevels(tx *daos.Dao, productID string) (bool, error) {
+	var row struct {
+		N int `db:"n"`
+	}
+	err := tx.DB().Select("COUNT(*) AS n").From("stock_levels").
+		Where(dbx.HashExp{"product": productID}).One(&row)
+	return row.N > 0, err
+}
+
+// stockLevelRecord : la ligne du produit à l'emplacement, créée au besoin
+// (non enregistrée). Un produit sans aucune ligne a tout son stock à
+// l'emplacement par défaut : cette ligne-là est écrite d'abord, pour que la
+// somme des lignes reste égale au total.
+func stockLevelRecord(tx *daos.Dao, product, location *models.Record) (*models.Record, error) {
+	level, err := tx.FindFirstRecordByFilter(
+		"stock_levels", "product = {:product} && location = {:location}",
+		dbx.Params{"product": product.Id, "location": location.Id},
+	)
+	if err == nil {
+		return level, nil
+	}
+
+	col, err := tx.FindCollectionByNameOrId("stock_levels")
+	if err != nil {
+		return nil, err
+	}
+	seeded, err := hasStockLevels(tx, product.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity := 0.0
+	if !seeded {
+		if location.GetBool("is_default") {
+			quantity = product.GetFloat("stock")
+		} else if def, err := defaultStockLocation(tx, location.GetString("owner_company")); err != nil {
+			return nil, err
+		} else if def != nil {
+			initial := models.NewRecord(col)
+			initial.Set("product", product.Id)
+			initial.Set("location", def.Id)
+			initial.Set("quantity", product.GetFloat("stock"))
+			if err := tx.SaveRecord(initial); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	level = models.NewRecord(col)
+	level.Set("product", product.Id)
+	level.Set("location", location.Id)
+	level.Set("quantity", quantity)
+	return level, nil
+}
+
+// moveLocationStock applique un mouvement à la quantité du produit à
+// l'emplacement, et reporte l'écart sur `products.stock`. Le produit n'est
+// PAS enregistré : l'appelant le fait, dans la même transaction, avec ce
+// qu'il a à y changer d'autre.
+func moveLocationStock(tx *daos.Dao, product, location *models.Record, mouvement StockMovementInput) (before, after float64, err error) {
+	level, err := stockLevelRecord(tx, product, location)
+	if err != nil {
+		return 0, 0, err
+	}
+	before = level.GetFloat("quantity")
+	after = NextStock(before, mouvement)
+	if after == before && !level.IsNew() {
+		return before, after, nil
+	}
+
+	level.Set("quantity", after)
+	if err := tx.SaveRecord(level); err != nil {
+		return before, after, fmt.Errorf("stock à %s : %w", location.GetString("name"), err)
+	}
+	product.Set("stock", product.GetFloat("stock")+after-before)
+	return before, after, nil
+}
+
+// locationQuantity : la quantité du produit à l'emplacement, sans rien écrire
+func locationQuantity(tx *daos.Dao, product, location *models.Record) (float64, error) {
+	level, err := tx.FindFirstRecordByFilter(
+		"stock_levels", "product = {:product} && location = {:location}",
+		dbx.Params{"product": product.Id, "location": location.Id},
+	)
+	if err == nil {
+		return level.GetFloat("quantity"), nil
+	}
+	if !location.GetBool("is_default") {
+		return 0, nil
+	}
+	seeded, err := hasStockLevels(tx, product.Id)
+	if err != nil || seeded {
+		return 0, err
+	}
+	return product.GetFloat("stock"), nil
+}
+
+// locationLevels : les quantités non nulles d'un emplacement, produits sans
+// ligne compris quand c'est l'emplacement par défaut
+func locationLevels(dao *daos.Dao, location *models.Record) ([]LocationLevel, error) {
+	var rows []struct {
+		ID       string  `db:"id"`
+		Name     string  `db:"name"`
+		Sku      string  `db:"sku"`
+		Quantity float64 `db:"quantity"`
+	}
+	query := dao.DB().
+		Select("p.id AS id", "p.name AS name", "p.sku AS sku", "l.quantity AS quantity").
+		From("stock_levels l").
+		InnerJoin("products p", dbx.NewExp("p.id = l.product")).
+		Where(dbx.HashExp{"l.location": location.Id}).
+		AndWhere(dbx.NewExp("l.quantity != 0"))
+	if err := query.All(&rows); err != nil {
+		return nil, err
+	}
+
+	if location.GetBool("is_default") {
+		var implicit []struct {
+			ID       string  `db:"id"`
+			Name     string  `db:"name"`
+			Sku      string  `db:"sku"`
+			Quantity float64 `db:"quantity"`
+		}
+		err := dao.DB().
+			Select("id", "name", "sku", "stock AS quantity").
+			From("products").
+			Where(dbx.HashExp{"company": location.GetString("owner_company")}).
+			AndWhere(dbx.NewExp("stock != 0 AND id NOT IN (SELECT product FROM stock_levels)")).
+			All(&implicit)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, implicit...)
+	}
+
+	levels := make([]LocationLevel, 0, len(rows))
+	for _, r := range rows {
+		levels = append(levels, LocationLevel{ProductID: r.ID, ProductName: r.Name, Sku: r.Sku, Quantity: r.Quantity})
+	}
+	return levels, nil
+}
+
+// materializeDefaultLevels écrit, pour chaque produit de la société qui n'a
+// encore aucune ligne, sa quantité à l'emplacement par défaut actuel
+func materializeDefaultLevels(tx *daos.Dao, companyID string, location *models.Record) (int, error) {
+	products, err := tx.FindRecordsByFilter(
+		"products", "company = {:company}", "", 0, 0, dbx.Params{"company": companyID})
+	if err != nil {
+		return 0, err
+	}
+	col, err := tx.FindCollectionByNameOrId("stock_levels")
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, p := range products {
+		seeded, err := hasStockLevels(tx, p.Id)
+		if err != nil {
+			return written, err
+		}
+		if seeded || p.GetFloat("stock") == 0 {
+			continue
+		}
+		level := models.NewRecord(col)
+		level.Set("product", p.Id)
+		level.Set("location", location.Id)
+		level.Set("quantity", p.GetFloat("stock"))
+		if err := tx.SaveRecord(level); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,34 @@
+package routes
+
+import "testing"
+
+// Un bon de transfert saisi à la douchette porte souvent deux fois le même
+// produit : une ligne, une quantité. Et un transfert ne se fait qu'à l'endroit.
+func TestMergeTransferLines(t *testing.T) {
+	lignes, err := MergeTransferLines([]StockTransferLineInput{
+		{ProductID: "ampli", Quantity: 1},
+		{ProductID: " corde ", Quantity: 6},
+		{ProductID: "ampli", Quantity: 2},
+	})
+	if err != nil {
+		t.Fatalf("lignes valides refusées : %v", err)
+	}
+	if len(lignes) != 2 || lignes[0].ProductID != "ampli" || lignes[0].Quantity != 3 ||
+		lignes[1].ProductID != "corde" || lignes[1].Quantity != 6 {
+		t.Errorf("regroupement inattendu : %+v", lignes)
+	}
+
+	cas := []struct {
+		nom    string
+		lignes []StockTransferLineInput
+	}{
+		{"quantité nulle", []StockTransferLineInput{{ProductID: "ampli", Quantity: 0}}},
+		{"quantité négative : le sens est celui du bon", []StockTransferLineInput{{ProductID: "ampli", Quantity: -1}}},
+		{"produit manquant", []StockTransferLineInput{{ProductID: " ", Quantity: 1}}},
+	}
+	for _, c := range cas {
+		if _, err := MergeTransferLines(c.lignes); err == nil {
+			t.Errorf("%s : accepté", c.nom)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// backend/routes/loyalty_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — PROGRAMME DE FIDÉLITÉ
+// ═══════════════════════════════════════════════════════════════════════════
+// Le gain / l'annulation / l'utilisation de points sont déclenchés par les
+// documents eux-mêmes (ticket POS, facture, avoir) : voir backend/loyalty.go.
+// Ces routes servent à consulter le solde, le journal, à corriger un solde
+// (responsable uniquement) et à reconstruire le solde depuis les documents.
+
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+
+	"pocket-react/backend"
+)
+
+// LoyaltyAdjustInput représente une correction manuelle de points
+type LoyaltyAdjustInput struct {
+	Points int    `json:"points"` // signé
+	Reason string `json:"reason"`
+}
+
+func RegisterLoyaltyRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /api/loyalty/customers/:id
+	// Solde, palier actuel / suivant et paramétrage de la société.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/api/loyalty/customers/:id", func(c echo.Context) error {
+		dao := app.Dao()
+
+		balance, err := backend.GetLoyaltyBalance(dao, c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError(err.Error(), nil)
+		}
+
+		customer, _ := dao.FindRecordById("customers", balance.CustomerID)
+		settings, err := backend.GetLoyaltySettings(dao, customer.GetString("owner_company"))
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"balance":  balance,
+			"settings": settings,
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// GET /api/loyalty/customers/:id/ledger?limit=100&offset=0
+	// Journal des mouvements, du plus récent au plus ancien.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.GET("/api/loyalty/customers/:id/ledger", func(c echo.Context) error {
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		offset, _ := strconv.Atoi(c.QueryParam("offset"))
+
+		entries, err := app.Dao().FindRecordsByFilter(
+			"loyalty_ledger",
+			"customer = {:c}",
+			"-created",
+			limit,
+			offset,
+			dbx.Params{"c": c.PathParam("id")},
+		)
+		if err != nil {
+			return apis.NewApiError(500, "Erreur chargement du journal fidélité", err)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"entries": entries,
+			"count":   len(entries),
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/loyalty/customers/:id/adjust
+	// Correction manuelle (geste commercial, erreur de saisie…).
+	// Body : { "points": -50, "reason": "..." } — motif obligatoire.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/loyalty/customers/:id/adjust", func(c echo.Context) error {
+		info := apis.RequestInfo(c)
+		if !hasManagerRole(info.AuthRecord) {
+			return apis.NewForbiddenError("Ajustement réservé aux responsables", nil)
+		}
+
+		var input LoyaltyAdjustInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.Points == 0 {
+			return apis.NewBadRequestError("points requis (non nul)", nil)
+		}
+		input.Reason = strings.TrimSpace(input.Reason)
+		if input.Reason == "" {
+			return apis.NewBadRequestError("reason requis", nil)
+		}
+
+		entry, err := backend.AddLoyaltyEntry(app.Dao(), backend.LoyaltyEntryParams{
+			CustomerID: c.PathParam("id"),
+			EntryType:  backend.LoyaltyEntryAdjust,
+			Points:     input.Points,
+			Reason:     input.Reason,
+			CreatedBy:  info.AuthRecord.Id,
+		})
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		balance, _ := backend.GetLoyaltyBalance(app.Dao(), c.PathParam("id"))
+
+		return c.JSON(http.StatusCreated, echo.Map{
+			"entry":   entry,
+			"balance": balance,
+		})
+	}, apis.RequireRecordAuth())
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/loyalty/customers/:id/rebuild
+	// Rejoue les documents du client (mouvements manquants) puis recalcule le
+	// solde depuis le journal. N'efface jamais de mouvement.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/loyalty/customers/:id/rebuild", func(c echo.Context) error {
+		info := apis.RequestInfo(c)
+		if !hasManagerRole(info.AuthRecord) {
+			return apis.NewForbiddenError("Reconstruction réservée aux responsables", nil)
+		}
+
+		result, err := backend.RebuildCustomerLoyalty(app.Dao(), c.PathParam("id"))
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		return c.JSON(http.StatusOK, result)
+	}, apis.RequireRecordAuth())
+}
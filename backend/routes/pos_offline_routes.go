@@ -0,0 +1,117 @@
+// backend/routes/pos_offline_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — RÉCONCILIATION DES TICKETS HORS-LIGNE
+// ═══════════════════════════════════════════════════════════════════════════
+// Un poste navigateur coupé de l'hôte met ses ventes en file sous un
+// identifiant provisoire (offline_id), puis les rejoue dans l'ordre via
+// POST /api/pos/ticket, qui les numérote et les chaîne à ce moment-là.
+// Cette route dit au poste quels identifiants provisoires sont devenus quels
+// tickets, pour qu'il purge sa file sans risque de double saisie.
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+)
+
+// maxReconcileIDs borne la taille d'une demande de réconciliation
+const maxReconcileIDs = 500
+
+// OfflineReconcileInput liste les identifiants provisoires encore en file
+type OfflineReconcileInput struct {
+	OwnerCompany string   `json:"owner_company"`
+	OfflineIDs   []string `json:"offline_ids"`
+}
+
+// OfflineReconcileEntry indique ce qu'est devenu un identifiant provisoire
+type OfflineReconcileEntry struct {
+	OfflineID        string  `json:"offline_id"`
+	Status           string  `json:"status"` // synced | pending
+	TicketID         string  `json:"ticket_id,omitempty"`
+	TicketNumber     string  `json:"ticket_number,omitempty"`
+	TotalTTC         float64 `json:"total_ttc,omitempty"`
+	OfflineCreatedAt string  `json:"offline_created_at,omitempty"`
+	SyncedAt         string  `json:"synced_at,omitempty"`
+}
+
+func RegisterPosOfflineRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/pos/offline/reconcile
+	// { owner_company, offline_ids: [...] } → correspondance provisoire → ticket.
+	// "pending" = jamais reçu par l'hôte : le poste doit le rejouer.
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/pos/offline/reconcile", func(c echo.Context) error {
+		var payload OfflineReconcileInput
+		if err := c.Bind(&payload); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if payload.OwnerCompany == "" {
+			return apis.NewBadRequestError("owner_company requis", nil)
+		}
+		if !belongsToCompany(apis.RequestInfo(c).AuthRecord, payload.OwnerCompany) {
+			return apis.NewForbiddenError("Société hors de votre compte", nil)
+		}
+		if len(payload.OfflineIDs) > maxReconcileIDs {
+			return apis.NewBadRequestError("Trop d'identifiants (500 max par appel)", nil)
+		}
+
+		ids := make([]any, 0, len(payload.OfflineIDs))
+		for _, id := range payload.OfflineIDs {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+
+		byOfflineID := make(map[string]OfflineReconcileEntry, len(ids))
+		if len(ids) > 0 {
+			tickets, err := app.Dao().FindRecordsByExpr("invoices",
+				dbx.HashExp{"owner_company": payload.OwnerCompany},
+				dbx.In("offline_id", ids...),
+			)
+			if err != nil {
+				return apis.NewApiError(500, "Erreur lecture des tickets", err)
+			}
+			for _, t := range tickets {
+				entry := OfflineReconcileEntry{
+					OfflineID:    t.GetString("offline_id"),
+					Status:       "synced",
+					TicketID:     t.Id,
+					TicketNumber: t.GetString("number"),
+					TotalTTC:     t.GetFloat("total_ttc"),
+					SyncedAt:     t.GetString("created"),
+				}
+				if dt := t.GetDateTime("offline_created_at"); !dt.IsZero() {
+					entry.OfflineCreatedAt = dt.String()
+				}
+				byOfflineID[entry.OfflineID] = entry
+			}
+		}
+
+		// Réponse dans l'ordre de la demande (= ordre de la file du poste)
+		results := make([]OfflineReconcileEntry, 0, len(ids))
+		var synced int
+		for _, raw := range ids {
+			id := raw.(string)
+			entry, ok := byOfflineID[id]
+			if !ok {
+				entry = OfflineReconcileEntry{OfflineID: id, Status: "pending"}
+			} else {
+				synced++
+			}
+			results = append(results, entry)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"results":       results,
+			"synced_count":  synced,
+			"pending_count": len(results) - synced,
+		})
+	}, apis.RequireRecordAuth())
+}
@@ -2,19 +2,65 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"pocket-react/backend/hooks"
 	"pocket-react/backend/pos"
+	"pocket-react/backend/reports"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
+// resolvePosPrinter choisit le transport d'impression : la configuration de la
+// caisse (cash_registers.settings.printer) l'emporte sur le nom d'imprimante
+// envoyé par le poste, qui ne peut désigner qu'une file du système. Renvoie aussi la configuration de la caisse (vide si
+// l'imprimante vient du nom : largeur 0, codes natifs).
+func resolvePosPrinter(pb *pocketbase.PocketBase, cashRegisterID, printerName string) (pos.Printer, pos.PrinterConfig, error) {
+	if cashRegisterID != "" {
+		register, err := pb.Dao().FindRecordById("cash_registers", cashRegisterID)
+		if err != nil {
+			return nil, pos.PrinterConfig{}, errors.New("caisse introuvable")
+		}
+		var settings struct {
+			Printer pos.PrinterConfig `json:"printer"`
+		}
+		if raw := register.GetString("settings"); raw != "" && raw != "null" {
+			if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+				return nil, pos.PrinterConfig{}, errors.New("settings.printer invalide sur la caisse")
+			}
+		}
+		if !settings.Printer.IsZero() {
+			printer, err := pos.NewPrinter(settings.Printer)
+			return printer, settings.Printer, err
+		}
+	}
+
+	if printerName == "" {
+		return nil, pos.PrinterConfig{}, errors.New("printerName is required")
+	}
+	printer, err := pos.PrinterFromName(printerName)
+	return printer, pos.PrinterConfig{}, err
+}
+
 // RegisterPosPrintRoutes enregistre les routes pour l'impression POS et le tiroir caisse
 // Ces routes permettent l'impression depuis n'importe quel appareil du réseau
 func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 	// Groupe /api/pos
 	posGroup := router.Group("/api/pos")
+	queue := startPrintQueue(pb)
 
 	// Liste des imprimantes Windows disponibles
 	posGroup.GET("/printers", func(c echo.Context) error {
@@ -30,6 +76,92 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		})
 	})
 
+	// État temps réel d'une imprimante (DLE EOT). :name est un id de caisse
+	// (imprimante de settings.printer) ou le nom d'une file du système
+	posGroup.GET("/printers/:name/status", func(c echo.Context) error {
+		name, err := url.PathUnescape(c.PathParam("name"))
+		if err != nil {
+			name = c.PathParam("name")
+		}
+
+		registerID, printerName := "", name
+		if _, err := pb.Dao().FindRecordById("cash_registers", name); err == nil {
+			registerID, printerName = name, ""
+		}
+
+		printer, _, err := resolvePosPrinter(pb, registerID, printerName)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+
+		status, err := pos.QueryPrinterStatus(printer)
+		if err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		queue.recordStatus(name, printer, status)
+
+		pending, _ := pb.Dao().FindRecordsByFilter(
+			"print_jobs",
+			"status = 'pending' && cash_register = {:register} && printer_name = {:printer}",
+			"",
+			0,
+			0,
+			dbx.Params{"register": registerID, "printer": printerName},
+		)
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"printer":      printer.String(),
+			"status":       status,
+			"ready":        status.Ready(),
+			"reason":       status.Reason(),
+			"pending_jobs": len(pending),
+		})
+	}, apis.RequireRecordAuth())
+
+	// Relance immédiate d'un ticket en attente, ou abandonné après trop
+	// d'échecs (il repart pour une série complète de tentatives)
+	posGroup.POST("/print-jobs/:id/retry", func(c echo.Context) error {
+		job, err := pb.Dao().FindRecordById("print_jobs", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Travail d'impression introuvable", err)
+		}
+		switch job.GetString("status") {
+		case "pending":
+		case "failed":
+			job.Set("status", "pending")
+			job.Set("attempts", 0)
+		default:
+			return apis.NewBadRequestError("Ce ticket n'est plus en attente", nil)
+		}
+		job.Set("next_attempt_at", types.NowDateTime())
+		if err := pb.Dao().SaveRecord(job); err != nil {
+			return apis.NewApiError(500, "Impossible de relancer le ticket", err)
+		}
+		queue.Wake()
+		return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+	}, apis.RequireRecordAuth())
+
+	// Abandon d'un ticket en attente ou en échec (il ne sortira plus)
+	posGroup.POST("/print-jobs/:id/cancel", func(c echo.Context) error {
+		job, err := pb.Dao().FindRecordById("print_jobs", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Travail d'impression introuvable", err)
+		}
+		if status := job.GetString("status"); status != "pending" && status != "failed" {
+			return apis.NewBadRequestError("Ce ticket n'est plus en attente", nil)
+		}
+		job.Set("status", "cancelled")
+		if err := pb.Dao().SaveRecord(job); err != nil {
+			return apis.NewApiError(500, "Impossible d'annuler le ticket", err)
+		}
+		broadcastPrintJob(job)
+		return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+	}, apis.RequireRecordAuth())
+
 	// Liste des ports série disponibles (pour afficheur VFD)
 	posGroup.GET("/serial-ports", func(c echo.Context) error {
 		ports, err := pos.ListSerialPorts()
@@ -44,13 +176,17 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		})
 	})
 
-	// Impression d'un ticket de caisse
+	// Impression d'un ticket de caisse enregistré : le ticket est toujours
+	// reconstruit depuis la base (ce qui est imprimé est ce qui a été haché).
+	// Un ticket composé par le poste ne s'imprime pas ; seul /test-print
+	// imprime hors ticket, un exemple marqué sans valeur.
 	posGroup.POST("/print", func(c echo.Context) error {
 		var input struct {
-			PrinterName string          `json:"printerName"`
-			Width       int             `json:"width"`
-			CompanyId   string          `json:"companyId"`
-			Receipt     pos.ReceiptData `json:"receipt"`
+			TicketId       string `json:"ticketId"`
+			Printer        string `json:"printer"`
+			PrinterName    string `json:"printerName"`
+			CashRegisterId string `json:"cashRegisterId"`
+			Width          int    `json:"width"`
 		}
 
 		if err := c.Bind(&input); err != nil {
@@ -58,77 +194,71 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 				"error": "Invalid request body",
 			})
 		}
+		if input.PrinterName == "" {
+			input.PrinterName = input.Printer
+		}
+		if input.TicketId == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "ticketId requis : seul un ticket enregistré s'imprime",
+			})
+		}
+
+		ticket, err := pb.Dao().FindRecordById("invoices", input.TicketId)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Ticket introuvable",
+			})
+		}
+		// Sans imprimante précisée, celle de la caisse du ticket
+		if input.CashRegisterId == "" && input.PrinterName == "" {
+			input.CashRegisterId = ticket.GetString("cash_register")
+		}
 
 		// Validation
-		if input.PrinterName == "" {
+		printer, printerConfig, err := resolvePosPrinter(pb, input.CashRegisterId, input.PrinterName)
+		if err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "printerName is required",
+				"error": err.Error(),
 			})
 		}
 
+		if printerConfig.Width != 0 {
+			input.Width = printerConfig.Width
+		}
 		if input.Width != 58 && input.Width != 80 {
 			input.Width = 58 // Valeur par défaut
 		}
 
-		receipt := input.Receipt
-		receipt.Width = input.Width
+		receipt := buildReceiptFromTicket(pb, ticket, input.Width)
+		receipt.RasterCodes = printerConfig.RasterCodes
 
-		// Enrichissement des données company depuis PocketBase (optionnel)
-		if input.CompanyId != "" {
-			rec, err := pb.Dao().FindRecordById("companies", input.CompanyId)
-			if err == nil && rec != nil {
-				// Enrichir receipt avec les données de l'entreprise
-				if receipt.CompanyName == "" {
-					tradeName := rec.GetString("trade_name")
-					legalName := rec.GetString("name")
-					if tradeName != "" {
-						receipt.CompanyName = tradeName
-					} else {
-						receipt.CompanyName = legalName
-					}
-				}
-
-				if receipt.CompanyLine1 == "" {
-					receipt.CompanyLine1 = rec.GetString("address_line1")
-				}
-				if receipt.CompanyLine2 == "" {
-					receipt.CompanyLine2 = rec.GetString("address_line2")
-				}
-				if receipt.CompanyLine3 == "" {
-					zip := rec.GetString("zip_code")
-					city := rec.GetString("city")
-					if zip != "" || city != "" {
-						receipt.CompanyLine3 = zip + " " + city
-					}
-				}
-				if receipt.CompanyPhone == "" {
-					receipt.CompanyPhone = rec.GetString("phone")
-				}
-				if receipt.CompanyEmail == "" {
-					receipt.CompanyEmail = rec.GetString("email")
-				}
-				if receipt.CompanySiret == "" {
-					receipt.CompanySiret = rec.GetString("siret")
-				}
-				if receipt.CompanyVat == "" {
-					receipt.CompanyVat = rec.GetString("vat_number")
-				}
-			}
-		}
-
-		// Construction et impression
+		// Construction et impression, via la file : un ticket qui ne sort pas
+		// (papier, capot, imprimante éteinte) est relancé jusqu'à impression
 		raw := pos.BuildReceipt(receipt)
-		if err := pos.RawPrint(input.PrinterName, raw); err != nil {
+		label := "Ticket " + receipt.InvoiceNumber
+		job, err := queue.Enqueue(input.CashRegisterId, input.PrinterName, ticket.Id, label, raw)
+		if job == nil {
+			log.Printf("❌ [POS] Impression sur %s : %v", printer, err)
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": err.Error(),
 			})
 		}
+		if err != nil {
+			return c.JSON(http.StatusAccepted, map[string]interface{}{
+				"success": false,
+				"queued":  true,
+				"jobId":   job.Id,
+				"error":   err.Error(),
+				"message": "Ticket en attente : il sera imprimé dès que l'imprimante sera prête",
+			})
+		}
 
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success": true,
+			"jobId":   job.Id,
 			"message": "Ticket imprimé avec succès",
 		})
-	})
+	}, apis.RequireRecordAuth())
 
 	type receiptInput struct {
 		Width     int             `json:"width"`
@@ -136,52 +266,6 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		Receipt   pos.ReceiptData `json:"receipt"`
 	}
 
-	enrichCompany := func(receipt *pos.ReceiptData, companyId string) {
-		if companyId == "" {
-			return
-		}
-		rec, err := pb.Dao().FindRecordById("companies", companyId)
-		if err != nil || rec == nil {
-			return
-		}
-
-		if receipt.CompanyName == "" {
-			tradeName := rec.GetString("trade_name")
-			legalName := rec.GetString("name")
-			if strings.TrimSpace(tradeName) != "" {
-				receipt.CompanyName = tradeName
-			} else {
-				receipt.CompanyName = legalName
-			}
-		}
-
-		if receipt.CompanyLine1 == "" {
-			receipt.CompanyLine1 = rec.GetString("address_line1")
-		}
-		if receipt.CompanyLine2 == "" {
-			receipt.CompanyLine2 = rec.GetString("address_line2")
-		}
-		if receipt.CompanyLine3 == "" {
-			zip := rec.GetString("zip_code")
-			city := rec.GetString("city")
-			if zip != "" || city != "" {
-				receipt.CompanyLine3 = strings.TrimSpace(zip + " " + city)
-			}
-		}
-		if receipt.CompanyPhone == "" {
-			receipt.CompanyPhone = rec.GetString("phone")
-		}
-		if receipt.CompanyEmail == "" {
-			receipt.CompanyEmail = rec.GetString("email")
-		}
-		if receipt.CompanySiret == "" {
-			receipt.CompanySiret = rec.GetString("siret")
-		}
-		if receipt.CompanyVat == "" {
-			receipt.CompanyVat = rec.GetString("vat_number")
-		}
-	}
-
 	// Preview texte
 	posGroup.POST("/preview/text", func(c echo.Context) error {
 		var input receiptInput
@@ -195,7 +279,7 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 
 		receipt := input.Receipt
 		receipt.Width = input.Width
-		enrichCompany(&receipt, input.CompanyId)
+		enrichReceiptCompany(pb, &receipt, input.CompanyId)
 
 		out := pos.BuildReceiptPreviewText(receipt)
 		return c.Blob(http.StatusOK, "text/plain; charset=utf-8", []byte(out))
@@ -214,7 +298,7 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 
 		receipt := input.Receipt
 		receipt.Width = input.Width
-		enrichCompany(&receipt, input.CompanyId)
+		enrichReceiptCompany(pb, &receipt, input.CompanyId)
 
 		out := pos.BuildReceiptPreviewHTML(receipt)
 		return c.HTML(http.StatusOK, out)
@@ -233,7 +317,7 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 
 		receipt := input.Receipt
 		receipt.Width = input.Width
-		enrichCompany(&receipt, input.CompanyId)
+		enrichReceiptCompany(pb, &receipt, input.CompanyId)
 
 		pdfBytes, err := pos.BuildReceiptPreviewPDF(receipt)
 		if err != nil {
@@ -251,45 +335,105 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		return c.Blob(http.StatusOK, "application/pdf", pdfBytes)
 	})
 
-	// Ouverture du tiroir caisse
+	// Ouverture du tiroir caisse — chaque impulsion est journalisée dans
+	// cash_drawer_events (qui, pourquoi, sur quelle session). Une ouverture
+	// « sans vente » exige un motif écrit et le rôle minimum de la caisse.
 	posGroup.POST("/drawer/open", func(c echo.Context) error {
 		var input struct {
-			PrinterName string `json:"printerName"`
-			Width       int    `json:"width"`
+			PrinterName    string `json:"printerName"`
+			CashRegisterId string `json:"cashRegisterId"`
+			Width          int    `json:"width"`
+			Reason         string `json:"reason"`
+			Note           string `json:"note"`
+			InvoiceId      string `json:"invoiceId"`
 		}
 
 		if err := c.Bind(&input); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid request body",
-			})
+			return apis.NewBadRequestError("Invalid request body", err)
 		}
 
-		// Validation
-		if input.PrinterName == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "printerName is required",
-			})
+		user := apis.RequestInfo(c).AuthRecord
+		if !reports.IsDrawerReason(input.Reason) {
+			return apis.NewBadRequestError("Motif d'ouverture du tiroir inconnu : "+input.Reason, nil)
+		}
+		input.Note = strings.TrimSpace(input.Note)
+		if utf8.RuneCountInString(input.Note) > drawerNoteMaxLength {
+			return apis.NewBadRequestError(fmt.Sprintf("Motif trop long (%d caractères au plus)", drawerNoteMaxLength), nil)
 		}
 
-		// Commande ESC/POS pour ouvrir le tiroir
-		cmd := pos.OpenDrawerCmd()
-		if err := pos.RawPrint(input.PrinterName, cmd); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": err.Error(),
-			})
+		opening, err := resolveDrawerOpening(pb, user, input.CashRegisterId, input.InvoiceId)
+		if err != nil {
+			return err
 		}
+		opening.Reason = input.Reason
+		opening.Note = input.Note
+		opening.Printer = input.PrinterName
+
+		switch input.Reason {
+		case reports.DrawerReasonSale, reports.DrawerReasonRefund:
+			if err := checkDrawerInvoice(pb, opening, input.Reason); err != nil {
+				return err
+			}
+		case reports.DrawerReasonTest:
+			if !hasManagerRole(user) {
+				return apis.NewForbiddenError("Test du tiroir réservé aux managers", nil)
+			}
+		default:
+			// Sans ticket à l'appui, toute autre ouverture est une ouverture
+			// sans vente : le motif annoncé devient la note
+			if label, ok := drawerManualLabels[input.Reason]; ok {
+				opening.Note = strings.TrimSpace(label + " " + input.Note)
+				if utf8.RuneCountInString(opening.Note) > drawerNoteMaxLength {
+					opening.Note = string([]rune(opening.Note)[:drawerNoteMaxLength])
+				}
+			}
+			opening.Reason = reports.DrawerReasonNoSale
+			if opening.Note == "" {
+				return apis.NewBadRequestError("Un motif est obligatoire pour ouvrir le tiroir sans vente", nil)
+			}
+			if minRole := noSaleMinRole(pb, opening.CashRegister); !hasRoleAtLeast(user, minRole) {
+				return apis.NewForbiddenError("Ouverture sans vente réservée au rôle "+minRole+" ou supérieur", nil)
+			}
+		}
+
+		printer, _, err := resolvePosPrinter(pb, input.CashRegisterId, input.PrinterName)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+
+		// Journal d'abord : un tiroir ne s'ouvre pas sans trace
+		event, err := reports.RecordDrawerOpening(pb, opening)
+		if err != nil {
+			log.Printf("❌ [POS] Ouverture du tiroir non journalisée, tiroir laissé fermé : %v", err)
+			return apis.NewApiError(http.StatusInternalServerError, "Impossible de journaliser l'ouverture du tiroir", err)
+		}
+
+		// Commande ESC/POS pour ouvrir le tiroir ; si elle échoue, l'ouverture
+		// n'a pas eu lieu et sa trace est retirée
+		if err := printer.Write(pos.OpenDrawerCmd()); err != nil {
+			log.Printf("❌ [POS] Ouverture tiroir via %s : %v", printer, err)
+			if delErr := pb.Dao().DeleteRecord(event); delErr != nil {
+				log.Printf("⚠️ [POS] Trace %s d'une ouverture échouée conservée : %v", event.Id, delErr)
+			}
+			return apis.NewApiError(http.StatusInternalServerError, err.Error(), nil)
+		}
+		log.Printf("🗄️ [POS] Tiroir ouvert par %s (%s) session=%s", user.Id, opening.Reason, opening.Session)
 
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"success": true,
 			"message": "Tiroir caisse ouvert",
+			"eventId": event.Id,
+			"reason":  opening.Reason,
 		})
-	})
+	}, apis.RequireRecordAuth())
 
-	// Test d'impression (ticket de démonstration)
+	// Test d'impression : un exemple fixe, marqué sans valeur, qui ne passe ni
+	// par la file ni par un ticket enregistré
 	posGroup.POST("/test-print", func(c echo.Context) error {
 		var input struct {
-			PrinterName string `json:"printerName"`
-			Width       int    `json:"width"`
+			PrinterName    string `json:"printerName"`
+			CashRegisterId string `json:"cashRegisterId"`
+			Width          int    `json:"width"`
 		}
 
 		if err := c.Bind(&input); err != nil {
@@ -298,12 +442,16 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 			})
 		}
 
-		if input.PrinterName == "" {
+		printer, printerConfig, err := resolvePosPrinter(pb, input.CashRegisterId, input.PrinterName)
+		if err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "printerName is required",
+				"error": err.Error(),
 			})
 		}
 
+		if printerConfig.Width != 0 {
+			input.Width = printerConfig.Width
+		}
 		if input.Width != 58 && input.Width != 80 {
 			input.Width = 58
 		}
@@ -312,7 +460,7 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		testReceipt := pos.ReceiptData{
 			CompanyName:   "TEST BOUTIQUE",
 			InvoiceNumber: "TEST-001",
-			DateLabel:     "Test impression",
+			DateLabel:     "Test impression - sans valeur fiscale",
 			Items: []pos.ReceiptItem{
 				{
 					Name:     "Article Test",
@@ -330,7 +478,8 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		}
 
 		raw := pos.BuildReceipt(testReceipt)
-		if err := pos.RawPrint(input.PrinterName, raw); err != nil {
+		if err := printer.Write(raw); err != nil {
+			log.Printf("❌ [POS] Test d'impression sur %s : %v", printer, err)
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": err.Error(),
 			})
@@ -340,7 +489,108 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 			"success": true,
 			"message": "Ticket de test envoyé",
 		})
-	})
+	}, apis.RequireRecordAuth())
+
+	// Duplicata d'un ticket (NF525) : le ticket est reconstruit depuis la base,
+	// marqué "DUPLICATA n°X", le compteur de réimpressions est incrémenté et
+	// la réimpression est tracée dans audit_logs
+	router.POST("/api/pos/ticket/:id/reprint", func(c echo.Context) error {
+		var input struct {
+			PrinterName    string `json:"printerName"`
+			CashRegisterId string `json:"cashRegisterId"`
+			Width          int    `json:"width"`
+		}
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+
+		dao := pb.Dao()
+		ticket, err := dao.FindRecordById("invoices", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Ticket introuvable", err)
+		}
+		if !ticket.GetBool("is_pos_ticket") {
+			return apis.NewBadRequestError("Ce document n'est pas un ticket POS", nil)
+		}
+
+		// L'imprimante est validée AVANT de compter : une réimpression
+		// refusée n'est pas un duplicata
+		_, printerConfig, err := resolvePosPrinter(pb, input.CashRegisterId, input.PrinterName)
+		if err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+		if printerConfig.Width != 0 {
+			input.Width = printerConfig.Width
+		}
+		if input.Width != 58 && input.Width != 80 {
+			input.Width = 58
+		}
+
+		// Compteur, travail d'impression et audit dans une seule transaction :
+		// deux réimpressions simultanées obtiennent deux numéros distincts, et
+		// un numéro n'est consommé que si son duplicata est en file et tracé
+		var duplicateNumber int
+		var job *models.Record
+		err = dao.RunInTransaction(func(txDao *daos.Dao) error {
+			fresh, err := txDao.FindRecordById("invoices", ticket.Id)
+			if err != nil {
+				return err
+			}
+			duplicateNumber = fresh.GetInt("reprint_count") + 1
+			fresh.Set("reprint_count", duplicateNumber)
+			fresh.Set("last_reprinted_at", types.NowDateTime())
+			if err := txDao.SaveRecord(fresh); err != nil {
+				return err
+			}
+
+			receipt := buildReceiptFromTicket(pb, ticket, input.Width)
+			receipt.DuplicateNumber = duplicateNumber
+			receipt.RasterCodes = printerConfig.RasterCodes
+			receipt.ReprintedAt = time.Now().Format("02/01/2006 15:04")
+
+			label := fmt.Sprintf("Duplicata n°%d %s", duplicateNumber, ticket.GetString("number"))
+			job, err = queue.insert(txDao, input.CashRegisterId, input.PrinterName, ticket.Id, label, pos.BuildReceipt(receipt))
+			if err != nil {
+				return fmt.Errorf("mise en file du duplicata : %w", err)
+			}
+
+			if err := hooks.CreateAuditLogTx(txDao, c, hooks.AuditLogParams{
+				Action:       "receipt_reprinted",
+				EntityType:   "ticket",
+				EntityID:     ticket.Id,
+				EntityNumber: ticket.GetString("number"),
+				OwnerCompany: ticket.GetString("owner_company"),
+				Details: map[string]interface{}{
+					"duplicate_number": duplicateNumber,
+					"cash_register":    input.CashRegisterId,
+					"printer":          input.PrinterName,
+					"print_job":        job.Id,
+				},
+			}); err != nil {
+				return fmt.Errorf("audit du duplicata : %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("❌ [POS] Duplicata du ticket %s non enregistré : %v", ticket.GetString("number"), err)
+			return apis.NewApiError(500, "Impossible d'enregistrer la réimpression", err)
+		}
+
+		// Le duplicata est en file : s'il ne sort pas maintenant, la file le
+		// relance
+		err = queue.Send(job)
+		result := map[string]interface{}{
+			"success":          err == nil,
+			"queued":           err != nil,
+			"jobId":            job.Id,
+			"duplicate_number": duplicateNumber,
+		}
+		if err != nil {
+			result["error"] = err.Error()
+			return c.JSON(http.StatusAccepted, result)
+		}
+		return c.JSON(http.StatusOK, result)
+	}, apis.RequireRecordAuth())
 
 	// Envoi de texte sur afficheur VFD
 	posGroup.POST("/display/send", func(c echo.Context) error {
@@ -431,3 +681,151 @@ func RegisterPosPrintRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		})
 	})
 }
+
+// resolveDrawerOpening retrouve le contexte d'une ouverture du tiroir : la
+// caisse, sa session ouverte (à défaut, la session ouverte par l'utilisateur)
+// et la société, prise sur la caisse, la session, le ticket ou l'utilisateur.
+func resolveDrawerOpening(pb *pocketbase.PocketBase, user *models.Record, cashRegisterID, invoiceID string) (reports.DrawerOpening, error) {
+	dao := pb.Dao()
+	opening := reports.DrawerOpening{CreatedBy: user.Id}
+
+	var session *models.Record
+	if cashRegisterID != "" {
+		register, err := dao.FindRecordById("cash_registers", cashRegisterID)
+		if err != nil {
+			return opening, apis.NewNotFoundError("Caisse introuvable", err)
+		}
+		opening.CashRegister = register.Id
+		opening.OwnerCompany = register.GetString("owner_company")
+		session, _ = dao.FindFirstRecordByFilter(
+			"cash_sessions",
+			"cash_register = {:r} && status = 'open'",
+			dbx.Params{"r": register.Id},
+		)
+	} else {
+		sessions, _ := dao.FindRecordsByFilter(
+			"cash_sessions",
+			"opened_by = {:u} && status = 'open'",
+			"-opened_at",
+			1,
+			0,
+			dbx.Params{"u": user.Id},
+		)
+		if len(sessions) > 0 {
+			session = sessions[0]
+		}
+	}
+
+	if session != nil {
+		opening.Session = session.Id
+		if opening.CashRegister == "" {
+			opening.CashRegister = session.GetString("cash_register")
+		}
+		if opening.OwnerCompany == "" {
+			opening.OwnerCompany = session.GetString("owner_company")
+		}
+	}
+
+	if invoiceID != "" {
+		invoice, err := dao.FindRecordById("invoices", invoiceID)
+		if err != nil {
+			return opening, apis.NewNotFoundError("Ticket introuvable", err)
+		}
+		opening.Invoice = invoice.Id
+		if opening.OwnerCompany == "" {
+			opening.OwnerCompany = invoice.GetString("owner_company")
+		}
+	}
+
+	if opening.OwnerCompany == "" {
+		if companies := user.GetStringSlice("company"); len(companies) > 0 {
+			opening.OwnerCompany = companies[0]
+		}
+	}
+	if opening.OwnerCompany == "" {
+		return opening, apis.NewBadRequestError("Caisse requise pour journaliser l'ouverture du tiroir", nil)
+	}
+	return opening, nil
+}
+
+// drawerNoteMaxLength : taille de cash_drawer_events.note
+const drawerNoteMaxLength = 255
+
+// drawerManualLabels : motifs annoncés par le poste sans pièce à l'appui,
+// journalisés comme ouvertures sans vente avec ce libellé en note
+var drawerManualLabels = map[string]string{
+	reports.DrawerReasonCashMovement: "Mouvement de caisse.",
+	reports.DrawerReasonSessionOpen:  "Ouverture de session.",
+	reports.DrawerReasonSessionClose: "Clôture de session.",
+}
+
+// checkDrawerInvoice : une ouverture « vente » ou « remboursement » doit
+// s'appuyer sur un ticket de la session ouverte de la caisse — encaissé dans
+// la session, ou (facture réglée au comptoir, avoir d'un ancien ticket)
+// réglé ou émis depuis son ouverture
+func checkDrawerInvoice(pb *pocketbase.PocketBase, opening reports.DrawerOpening, reason string) error {
+	if opening.Invoice == "" {
+		return apis.NewBadRequestError("Ticket requis pour ouvrir le tiroir sur une vente ou un remboursement", nil)
+	}
+	if opening.Session == "" {
+		return apis.NewBadRequestError("Aucune session ouverte : ouvrez le tiroir sans vente", nil)
+	}
+	invoice, err := pb.Dao().FindRecordById("invoices", opening.Invoice)
+	if err != nil {
+		return apis.NewNotFoundError("Ticket introuvable", err)
+	}
+	session, err := pb.Dao().FindRecordById("cash_sessions", opening.Session)
+	if err != nil {
+		return apis.NewNotFoundError("Session introuvable", err)
+	}
+
+	wantType := "invoice"
+	if reason == reports.DrawerReasonRefund {
+		wantType = "credit_note"
+	}
+	inSession := invoice.GetString("session") == session.Id
+	if !inSession {
+		since := session.GetDateTime("opened_at").Time()
+		at := invoice.GetDateTime("created").Time()
+		if wantType == "invoice" {
+			at = invoice.GetDateTime("paid_at").Time()
+			if !invoice.GetBool("is_paid") {
+				at = time.Time{}
+			}
+		}
+		inSession = !at.IsZero() && !at.Before(since)
+	}
+
+	if invoice.GetString("owner_company") != opening.OwnerCompany ||
+		invoice.GetString("invoice_type") != wantType || !inSession {
+		return apis.NewBadRequestError("Ce ticket n'appartient pas à la session ouverte : ouvrez le tiroir sans vente", nil)
+	}
+
+	// Un ticket ouvre le tiroir une fois ; une seconde ouverture est une
+	// ouverture sans vente, avec son motif et son rôle
+	if _, err := pb.Dao().FindFirstRecordByFilter(
+		"cash_drawer_events",
+		"session = {:session} && related_invoice = {:invoice} && (reason = 'sale' || reason = 'refund')",
+		dbx.Params{"session": session.Id, "invoice": invoice.Id},
+	); err == nil {
+		return apis.NewBadRequestError("Le tiroir a déjà été ouvert pour ce ticket : ouvrez-le sans vente", nil)
+	}
+	return nil
+}
+
+// noSaleMinRole : rôle minimum pour ouvrir le tiroir sans vente sur la caisse
+// (cash_registers.no_sale_min_role, manager par défaut). Un rôle inconnu est
+// renvoyé tel quel : hasRoleAtLeast ne l'accorde alors à personne
+func noSaleMinRole(pb *pocketbase.PocketBase, cashRegisterID string) string {
+	if cashRegisterID != "" {
+		if register, err := pb.Dao().FindRecordById("cash_registers", cashRegisterID); err == nil {
+			if role := register.GetString("no_sale_min_role"); role != "" {
+				if _, known := roleRank[role]; !known {
+					log.Printf("⚠️ [POS] Rôle %q inconnu pour l'ouverture sans vente de la caisse %s : refusée à tous", role, cashRegisterID)
+				}
+				return role
+			}
+		}
+	}
+	return "manager"
+}
@@ -0,0 +1,354 @@
+// backend/routes/pos_receipt.go
+// Reconstruction du ticket imprimé à partir du ticket ENREGISTRÉ (invoices),
+// de son entreprise et de ses paiements : ce qui sort de l'imprimante est ce
+// qui a été haché, pas ce que le navigateur a envoyé. Le ticket porte les
+// mentions d'identification (séquence, caisse, extrait d'empreinte, logiciel)
+// et la ventilation TVA.
+
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+
+	"pocket-react/backend/pos"
+)
+
+// receiptPaymentLabels : libellés des anciens codes mono-paiement
+var receiptPaymentLabels = map[string]string{
+	"cb":       "CB",
+	"especes":  "Espèces",
+	"cheque":   "Chèque",
+	"virement": "Virement",
+	"autre":    "Autre",
+	"multi":    "Multi-paiement",
+}
+
+// enrichReceiptCompany complète l'en-tête du ticket avec la fiche entreprise
+// (les champs déjà renseignés sont conservés)
+func enrichReceiptCompany(pb *pocketbase.PocketBase, receipt *pos.ReceiptData, companyId string) {
+	if companyId == "" {
+		return
+	}
+	rec, err := pb.Dao().FindRecordById("companies", companyId)
+	if err != nil || rec == nil {
+		return
+	}
+
+	if receipt.CompanyName == "" {
+		tradeName := rec.GetString("trade_name")
+		legalName := rec.GetString("name")
+		if strings.TrimSpace(tradeName) != "" {
+			receipt.CompanyName = tradeName
+		} else {
+			receipt.CompanyName = legalName
+		}
+	}
+
+	if receipt.CompanyLine1 == "" {
+		receipt.CompanyLine1 = rec.GetString("address_line1")
+	}
+	if receipt.CompanyLine2 == "" {
+		receipt.CompanyLine2 = rec.GetString("address_line2")
+	}
+	if receipt.CompanyLine3 == "" {
+		zip := rec.GetString("zip_code")
+		city := rec.GetString("city")
+		if zip != "" || city != "" {
+			receipt.CompanyLine3 = strings.TrimSpace(zip + " " + city)
+		}
+	}
+	if receipt.CompanyPhone == "" {
+		receipt.CompanyPhone = rec.GetString("phone")
+	}
+	if receipt.CompanyEmail == "" {
+		receipt.CompanyEmail = rec.GetString("email")
+	}
+	if receipt.CompanySiret == "" {
+		receipt.CompanySiret = rec.GetString("siret")
+	}
+	if receipt.CompanyVat == "" {
+		receipt.CompanyVat = rec.GetString("vat_number")
+	}
+}
+
+// buildReceiptFromTicket reconstruit le ReceiptData d'un ticket enregistré
+func buildReceiptFromTicket(pb *pocketbase.PocketBase, ticket *models.Record, width int) pos.ReceiptData {
+	// Un ticket rejoué depuis un poste hors-ligne porte l'heure réelle de la
+	// vente, pas celle de son arrivée au serveur
+	soldAt := ticket.GetDateTime("created")
+	if offline := ticket.GetDateTime("offline_created_at"); !offline.IsZero() {
+		soldAt = offline
+	}
+	receipt := pos.ReceiptData{
+		InvoiceNumber: ticket.GetString("number"),
+		DateLabel:     soldAt.Time().In(time.Local).Format("02/01/2006 15:04:05"),
+		Width:         width,
+	}
+
+	if sellerID := ticket.GetString("sold_by"); sellerID != "" {
+		if seller, err := pb.Dao().FindRecordById("users", sellerID); err == nil {
+			receipt.SellerName = seller.GetString("name")
+		}
+	}
+
+	// ── Lignes ────────────────────────────────────────────────────────────────
+	var items []map[string]any
+	_ = ticket.UnmarshalJSONField("items", &items)
+
+	// Une ligne s'imprime avant la remise panier et fidélité : celle-ci est
+	// ventilée dans total_ttc, mais n'apparaît qu'une fois, en pied de ticket.
+	// Un ticket d'avant `total_ttc_before_cart_discount` n'a que total_ttc.
+	for _, item := range items {
+		qty := toFloat(item["quantity"])
+		totalTTC := math.Abs(toFloat(item["total_ttc"]))
+		unitTTC := 0.0
+		if _, ok := item["total_ttc_before_cart_discount"]; ok {
+			totalTTC = math.Abs(toFloat(item["total_ttc_before_cart_discount"]))
+			unitTTC = math.Abs(toFloat(item["unit_price_ttc"]))
+		} else if qty != 0 {
+			unitTTC = roundAmount(totalTTC / math.Abs(qty))
+		}
+
+		line := pos.ReceiptItem{
+			Name:     fmt.Sprint(item["name"]),
+			Qty:      math.Abs(qty),
+			UnitTtc:  unitTTC,
+			TotalTtc: totalTTC,
+			TvaRate:  toFloat(item["tva_rate"]),
+		}
+
+		if toFloat(item["line_discount_ttc"]) > 0 {
+			base := toFloat(item["unit_price_ttc_before_discount"])
+			text := fmt.Sprintf("-%.2f€", roundAmount(base-unitTTC))
+			if item["line_discount_mode"] == "percent" {
+				text = fmt.Sprintf("-%g%%", toFloat(item["line_discount_value"]))
+			}
+			line.HasDiscount = true
+			line.BaseUnitTtc = &base
+			line.DiscountText = &text
+		}
+
+		receipt.Items = append(receipt.Items, line)
+	}
+
+	// ── Totaux et remises ─────────────────────────────────────────────────────
+	totalTTC := math.Abs(ticket.GetFloat("total_ttc"))
+	lineDiscounts := ticket.GetFloat("line_discounts_total_ttc")
+	cartDiscount := ticket.GetFloat("cart_discount_ttc") + ticket.GetFloat("loyalty_discount_ttc")
+
+	receipt.TotalTtc = totalTTC
+	receipt.TaxAmount = math.Abs(ticket.GetFloat("total_tva"))
+	receipt.SubtotalTtc = roundAmount(totalTTC + cartDiscount)
+
+	if lineDiscounts > 0 {
+		grand := roundAmount(receipt.SubtotalTtc + lineDiscounts)
+		receipt.GrandSubtotal = &grand
+		receipt.LineDiscountsTotal = &lineDiscounts
+	}
+	if cartDiscount > 0 {
+		receipt.DiscountAmount = &cartDiscount
+		if ticket.GetString("cart_discount_mode") == "percent" {
+			pct := ticket.GetFloat("cart_discount_value")
+			receipt.DiscountPercent = &pct
+		}
+	}
+	if savings := roundAmount(lineDiscounts + cartDiscount); savings > 0 {
+		receipt.TotalSavings = &savings
+	}
+
+	var vat []VATBreakdownEntry
+	_ = ticket.UnmarshalJSONField("vat_breakdown", &vat)
+	for _, v := range vat {
+		receipt.VatBreakdown = append(receipt.VatBreakdown, pos.VatBreakdown{
+			Rate:     v.Rate,
+			BaseHt:   math.Abs(v.BaseHT),
+			Vat:      math.Abs(v.VAT),
+			TotalTtc: math.Abs(v.TotalTTC),
+		})
+	}
+
+	// ── Paiements ─────────────────────────────────────────────────────────────
+	var payments []map[string]any
+	_ = ticket.UnmarshalJSONField("payments", &payments)
+
+	switch {
+	case len(payments) > 1:
+		parts := make([]string, 0, len(payments))
+		for _, p := range payments {
+			parts = append(parts, fmt.Sprintf("%v %.2f", p["method_label"], toFloat(p["amount"])))
+		}
+		receipt.PaymentMethod = strings.Join(parts, " + ")
+	case ticket.GetString("payment_method_label") != "":
+		receipt.PaymentMethod = ticket.GetString("payment_method_label")
+	default:
+		method := ticket.GetString("payment_method")
+		receipt.PaymentMethod = receiptPaymentLabels[method]
+		if receipt.PaymentMethod == "" {
+			receipt.PaymentMethod = method
+		}
+	}
+
+	for _, p := range payments {
+		if p["accounting_category"] != "cash" {
+			continue
+		}
+		received := toFloat(p["amount_received"])
+		if change := roundAmount(received - toFloat(p["amount"])); received > 0 && change > 0 {
+			receipt.Received = &received
+			receipt.Change = &change
+		}
+	}
+
+	// ── Mentions d'identification ────────────────────────────────────────────
+	receipt.SequenceNumber = ticket.GetInt("sequence_number")
+	if h := ticket.GetString("hash"); len(h) >= receiptHashExcerpt {
+		receipt.HashExcerpt = h[:receiptHashExcerpt]
+	}
+	if registerID := ticket.GetString("cash_register"); registerID != "" {
+		receipt.RegisterLabel = registerID
+		if register, err := pb.Dao().FindRecordById("cash_registers", registerID); err == nil {
+			label := register.GetString("name")
+			if code := register.GetString("code"); code != "" {
+				label += " [" + code + "]"
+			}
+			receipt.RegisterLabel = label + " - " + registerID
+		}
+	}
+	receipt.SoftwareLabel = pos.SoftwareName + " " + pos.SoftwareVersion
+
+	// ── Codes : numéro en code-barres (remboursement), QR de vérification ──
+	receipt.BarcodeContent = ticket.GetString("number")
+	receipt.QRContent = receiptQRContent(receipt.BarcodeContent, receipt.HashExcerpt)
+
+	enrichReceiptCompany(pb, &receipt, ticket.GetString("owner_company"))
+	receipt.CompanyLogoBase64 = companyLogoDataURL(pb, ticket.GetString("owner_company"))
+	return receipt
+}
+
+// Longueur de l'extrait d'empreinte imprimé (hash SHA-256 complet : 64)
+const receiptHashExcerpt = 16
+
+// receiptQRContent : "numéro|extrait d'empreinte", ou un lien vers le ticket
+// dématérialisé si POS_RECEIPT_URL est défini (?n=numéro&h=extrait)
+func receiptQRContent(number, hashExcerpt string) string {
+	if number == "" {
+		return ""
+	}
+	if base := strings.TrimSpace(os.Getenv("POS_RECEIPT_URL")); base != "" {
+		q := url.Values{"n": {number}}
+		if hashExcerpt != "" {
+			q.Set("h", hashExcerpt)
+		}
+		sep := "?"
+		if strings.Contains(base, "?") {
+			sep = "&"
+		}
+		return base + sep + q.Encode()
+	}
+	if hashExcerpt == "" {
+		return number
+	}
+	return number + "|" + hashExcerpt
+}
+
+// parseReceiptCode lit un code scanné sur un ticket : code-barres (numéro),
+// QR "numéro|extrait" ou lien ?n=numéro&h=extrait
+func parseReceiptCode(code string) (number, hashExcerpt string) {
+	code = strings.TrimSpace(code)
+	if i := strings.Index(code, "?"); i >= 0 {
+		if q, err := url.ParseQuery(code[i+1:]); err == nil && q.Get("n") != "" {
+			return q.Get("n"), q.Get("h")
+		}
+	}
+	if number, excerpt, ok := strings.Cut(code, "|"); ok {
+		return strings.TrimSpace(number), strings.TrimSpace(excerpt)
+	}
+	return code, ""
+}
+
+// findPosTicketByCode retrouve le ticket d'origine à partir du code scanné.
+// L'extrait d'empreinte du QR, s'il est présent, doit correspondre.
+func findPosTicketByCode(dao *daos.Dao, code, ownerCompany string) (*models.Record, error) {
+	number, excerpt := parseReceiptCode(code)
+	if number == "" {
+		return nil, fmt.Errorf("code de ticket vide")
+	}
+
+	filter := "number = {:number} && is_pos_ticket = true"
+	params := dbx.Params{"number": number}
+	if ownerCompany != "" {
+		filter += " && owner_company = {:company}"
+		params["company"] = ownerCompany
+	}
+	tickets, err := dao.FindRecordsByFilter("invoices", filter, "-created", 2, 0, params)
+	if err != nil || len(tickets) == 0 {
+		return nil, fmt.Errorf("aucun ticket %s", number)
+	}
+	if len(tickets) > 1 {
+		return nil, fmt.Errorf("plusieurs tickets %s : précisez l'entreprise", number)
+	}
+
+	ticket := tickets[0]
+	if excerpt != "" && !strings.HasPrefix(ticket.GetString("hash"), excerpt) {
+		return nil, fmt.Errorf("l'empreinte du ticket %s ne correspond pas", number)
+	}
+	return ticket, nil
+}
+
+// companyLogoDataURL lit le logo de l'entreprise dans le stockage PocketBase
+func companyLogoDataURL(pb *pocketbase.PocketBase, companyId string) *string {
+	if companyId == "" {
+		return nil
+	}
+	company, err := pb.Dao().FindRecordById("companies", companyId)
+	if err != nil || company.GetString("logo") == "" {
+		return nil
+	}
+
+	fsys, err := pb.NewFilesystem()
+	if err != nil {
+		return nil
+	}
+	defer fsys.Close()
+
+	file, err := fsys.GetFile(company.BaseFilesPath() + "/" + company.GetString("logo"))
+	if err != nil {
+		log.Printf("⚠️ [POS] Logo de %s illisible : %v", companyId, err)
+		return nil
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil
+	}
+	url := "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return &url
+}
+
+// toFloat lit un nombre d'une map JSON
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
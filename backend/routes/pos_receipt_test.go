@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Le code scanné au remboursement doit redonner le numéro et l'extrait
+// d'empreinte, quelle que soit la forme imprimée (code-barres, QR, lien).
+func TestCodeTicketAllerRetour(t *testing.T) {
+	const number, excerpt = "TIK-2026-000123", "0123456789abcdef"
+
+	t.Setenv("POS_RECEIPT_URL", "")
+	qr := receiptQRContent(number, excerpt)
+	if n, h := parseReceiptCode(qr); n != number || h != excerpt {
+		t.Errorf("QR %q : obtenu %q / %q", qr, n, h)
+	}
+
+	if n, h := parseReceiptCode(" " + number + "\r\n"); n != number || h != "" {
+		t.Errorf("code-barres : obtenu %q / %q", n, h)
+	}
+
+	t.Setenv("POS_RECEIPT_URL", "https://boutique.example/ticket")
+	link := receiptQRContent(number, excerpt)
+	if n, h := parseReceiptCode(link); n != number || h != excerpt {
+		t.Errorf("lien %q : obtenu %q / %q", link, n, h)
+	}
+}
+
+// La remise panier est ventilée dans le total_ttc des lignes, pas sur le
+// ticket imprimé : chaque ligne garde son prix, sa remise de ligne seule, et
+// la remise panier n'apparaît qu'une fois, en pied.
+func TestTicketImprimeAvantRemisePanier(t *testing.T) {
+	c := caisseDeTest(t)
+
+	input := PosTicketInput{
+		Items: []PosItemInput{
+			{Name: "Cordes", Quantity: 2, UnitPriceTTC: 12, TVARate: 20},
+			{Name: "Méthode", Quantity: 1, UnitPriceTTC: 26, TVARate: 5.5,
+				LineDiscountMode: "percent", LineDiscountValue: 10},
+		},
+		CartDiscountMode:  "amount",
+		CartDiscountValue: 10,
+	}
+	totals, items, err := calculateTicketTotals(input, 0)
+	if err != nil {
+		t.Fatalf("calcul: %v", err)
+	}
+
+	col, err := c.app.Dao().FindCollectionByNameOrId("invoices")
+	if err != nil {
+		t.Fatalf("collection: %v", err)
+	}
+	ticket := models.NewRecord(col)
+	ticket.Set("items", items)
+	ticket.Set("total_ttc", totals.TotalTTC)
+	ticket.Set("line_discounts_total_ttc", totals.LineDiscountsTotalTTC)
+	ticket.Set("cart_discount_ttc", totals.CartDiscountTTC)
+	ticket.Set("cart_discount_mode", "amount")
+
+	receipt := buildReceiptFromTicket(c.app, ticket, 48)
+	if len(receipt.Items) != 2 {
+		t.Fatalf("%d ligne(s) imprimée(s)", len(receipt.Items))
+	}
+
+	cas := []struct {
+		nom             string
+		unitaire, total float64
+		remise          bool
+	}{
+		{"sans remise de ligne", 12, 24, false},
+		{"avec remise de ligne", 23.4, 23.4, true},
+	}
+	var somme float64
+	for i, tc := range cas {
+		ligne := receipt.Items[i]
+		somme += ligne.TotalTtc
+		if ligne.UnitTtc != tc.unitaire || ligne.TotalTtc != tc.total || ligne.HasDiscount != tc.remise {
+			t.Errorf("%s : attendu %v / %v / remise %v, obtenu %v / %v / remise %v", tc.nom,
+				tc.unitaire, tc.total, tc.remise, ligne.UnitTtc, ligne.TotalTtc, ligne.HasDiscount)
+		}
+	}
+	if texte := receipt.Items[1].DiscountText; texte == nil || *texte != "-10%" {
+		t.Errorf("remise de ligne : %v", texte)
+	}
+
+	if receipt.DiscountAmount == nil || *receipt.DiscountAmount != 10 {
+		t.Errorf("remise panier : %v", receipt.DiscountAmount)
+	}
+	if roundAmount(somme) != receipt.SubtotalTtc || receipt.TotalTtc != 37.4 {
+		t.Errorf("lignes %v, sous-total %v, total %v", somme, receipt.SubtotalTtc, receipt.TotalTtc)
+	}
+}
@@ -5,6 +5,7 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -12,11 +13,13 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
 
+	"pocket-react/backend"
 	"pocket-react/backend/hash"
 )
 
@@ -65,6 +68,23 @@ type PosTicketInput struct {
 	// Remises globales (optionnel)
 	CartDiscountMode  string  `json:"cart_discount_mode,omitempty"`
 	CartDiscountValue float64 `json:"cart_discount_value,omitempty"`
+
+	// 🎁 Fidélité : points utilisés sur ce ticket (optionnel)
+	LoyaltyRedemption *PosLoyaltyRedemption `json:"loyalty_redemption,omitempty"`
+
+	// 📴 Rejeu hors-ligne : identifiant provisoire attribué par le poste.
+	// Un même offline_id n'est jamais numéroté deux fois (rejeu idempotent).
+	OfflineID        string `json:"offline_id,omitempty"`
+	OfflineCreatedAt string `json:"offline_created_at,omitempty"` // heure réelle de la vente (RFC3339)
+	Workstation      string `json:"workstation,omitempty"`
+}
+
+// PosLoyaltyRedemption représente l'utilisation de points fidélité en caisse.
+// Mode "discount" : remise sur le panier (réduit la base TVA).
+// Mode "voucher"  : bon d'achat, ajouté comme ligne de paiement "fidelite".
+type PosLoyaltyRedemption struct {
+	Points int    `json:"points"`
+	Mode   string `json:"mode"` // discount | voucher
 }
 
 // PosItemInput représente un item du panier
@@ -79,6 +99,17 @@ type PosItemInput struct {
 
 	LineDiscountMode  string  `json:"line_discount_mode,omitempty"`
 	LineDiscountValue float64 `json:"line_discount_value,omitempty"`
+
+	// Prix saisi à la main en caisse : les listes de prix ne s'appliquent pas
+	PriceLocked bool `json:"price_locked,omitempty"`
+
+	// Produit suivi : un numéro par unité vendue (voir serial_routes.go)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	// Renseignés par le serveur (applyPriceLists), jamais par le client
+	PriceListID   string  `json:"-"`
+	PriceListName string  `json:"-"`
+	BasePriceTTC  float64 `json:"-"`
 }
 
 // PosTicketResult représente la réponse de création
@@ -87,6 +118,9 @@ type PosTicketResult struct {
 	CashMovement *models.Record `json:"cash_movement,omitempty"`
 	Change       float64        `json:"change,omitempty"`
 	Totals       TicketTotals   `json:"totals"`
+	Replayed     bool           `json:"replayed,omitempty"` // offline_id déjà enregistré
+	// Lignes vendues sur des unités réservées (politique `warn`)
+	ReservationWarnings []string `json:"reservation_warnings,omitempty"`
 }
 
 // TicketTotals contient les totaux calculés
@@ -94,6 +128,7 @@ type TicketTotals struct {
 	SubtotalTTC           float64             `json:"subtotal_ttc"`
 	LineDiscountsTotalTTC float64             `json:"line_discounts_total_ttc"`
 	CartDiscountTTC       float64             `json:"cart_discount_ttc"`
+	LoyaltyDiscountTTC    float64             `json:"loyalty_discount_ttc,omitempty"`
 	TotalHT               float64             `json:"total_ht"`
 	TotalTVA              float64             `json:"total_tva"`
 	TotalTTC              float64             `json:"total_ttc"`
@@ -196,6 +231,29 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewBadRequestError("items requis (panier vide)", nil)
 		}
 
+		// 2b) Rejeu hors-ligne : un ticket déjà enregistré est renvoyé tel quel
+		var offlineCreatedAt time.Time
+		if input.OfflineID != "" {
+			// Le rejeu renvoie un ticket existant : seulement celui d'une
+			// société du vendeur
+			if !belongsToCompany(info.AuthRecord, input.OwnerCompany) {
+				return apis.NewForbiddenError("Société hors de votre compte", nil)
+			}
+			if existing := findOfflineTicket(dao, input.OwnerCompany, input.OfflineID); existing != nil {
+				return c.JSON(http.StatusOK, replayedTicketResult(existing))
+			}
+			if input.OfflineCreatedAt != "" {
+				parsed, err := time.Parse(time.RFC3339, input.OfflineCreatedAt)
+				if err != nil {
+					return apis.NewBadRequestError("offline_created_at invalide (RFC3339 attendu)", err)
+				}
+				if parsed.After(time.Now().Add(5 * time.Minute)) {
+					return apis.NewBadRequestError("offline_created_at dans le futur", nil)
+				}
+				offlineCreatedAt = parsed
+			}
+		}
+
 		// 3) Vérifier que la session est ouverte
 		session, err := dao.FindRecordById("cash_sessions", input.SessionID)
 		if err != nil || session == nil {
@@ -217,24 +275,95 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewBadRequestError("Cette caisse est désactivée", nil)
 		}
 
+		// 4b) Fidélité : vérifier le solde et convertir les points en euros
+		var loyaltyPoints int
+		var loyaltyAmount, loyaltyDiscount, loyaltyVoucher float64
+		if r := input.LoyaltyRedemption; r != nil && r.Points > 0 {
+			if r.Mode != "discount" && r.Mode != "voucher" {
+				return apis.NewBadRequestError("loyalty_redemption.mode invalide (discount|voucher)", nil)
+			}
+			settings, err := backend.GetLoyaltySettings(dao, input.OwnerCompany)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			if !settings.Enabled {
+				return apis.NewBadRequestError("Le programme de fidélité n'est pas activé", nil)
+			}
+			customer, err := dao.FindRecordById("customers", input.CustomerID)
+			if err != nil || customer == nil {
+				return apis.NewNotFoundError("Client introuvable", err)
+			}
+			if customer.GetInt("loyalty_points") < r.Points {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("Solde fidélité insuffisant: %d points disponibles", customer.GetInt("loyalty_points")), nil)
+			}
+
+			loyaltyPoints = r.Points
+			loyaltyAmount = settings.PointsToAmount(r.Points)
+			if r.Mode == "discount" {
+				loyaltyDiscount = loyaltyAmount
+			} else {
+				loyaltyVoucher = loyaltyAmount
+			}
+		}
+
+		// 4c) Listes de prix : prix négocié / paliers de quantité, résolus ici
+		if err := applyPriceLists(dao, &input); err != nil {
+			log.Printf("⚠️ Listes de prix non appliquées au ticket: %v", err)
+		}
+
+		// 4d) Numéros de série des produits suivis
+		serialUnits, err := pickTicketSerials(dao, &input)
+		if err != nil {
+			return err
+		}
+
+		// 4e) Réservations : avertir ou refuser selon la société
+		reservationWarnings, err := checkTicketReservations(dao, &input)
+		if err != nil {
+			return err
+		}
+
 		// 5) Calculer les totaux
-		totals, processedItems, err := calculateTicketTotals(input)
+		totals, processedItems, err := calculateTicketTotals(input, loyaltyDiscount)
 		if err != nil {
 			return apis.NewBadRequestError(err.Error(), nil)
 		}
+		if totals.LoyaltyDiscountTTC < loyaltyDiscount-0.005 || loyaltyVoucher > totals.TotalTTC+0.005 {
+			return apis.NewBadRequestError(
+				fmt.Sprintf("Points fidélité (%.2f€) supérieurs au montant du ticket", loyaltyAmount), nil)
+		}
 
 		// 6) Normaliser et valider les paiements
 		payments := normalizePayments(&input)
 
 		// Pour le fallback mono-paiement, remplir le montant avec TotalTTC
+		// (hors part réglée en bon fidélité). Rien à régler : le bon porte
+		// le paiement s'il y en a un, sinon le moyen choisi reste à 0 €
+		// (ticket offert, remise fidélité qui couvre tout)
 		if len(input.Payments) == 0 && len(payments) == 1 {
-			payments[0].Amount = totals.TotalTTC
+			payments[0].Amount = roundAmount(math.Max(0, totals.TotalTTC-loyaltyVoucher))
+			if payments[0].Amount == 0 && loyaltyVoucher > 0 {
+				payments = payments[:0]
+			}
+		}
+		if loyaltyVoucher > 0 {
+			payments = append(payments, PosPaymentEntry{
+				MethodCode:         backend.LoyaltyPaymentMethodCode,
+				MethodLabel:        backend.LoyaltyPaymentMethodLabel,
+				AccountingCategory: "other",
+				Amount:             loyaltyVoucher,
+			})
 		}
 
 		// Valider que la somme des paiements couvre le total
+		if len(payments) == 0 {
+			return apis.NewBadRequestError("Aucun moyen de paiement", nil)
+		}
 		var totalPaid float64
 		for _, p := range payments {
-			if p.Amount <= 0 {
+			// 0 € n'est un montant que pour un ticket à 0 €
+			if p.Amount < 0 || (p.Amount == 0 && totals.TotalTTC > 0.005) {
 				return apis.NewBadRequestError(
 					fmt.Sprintf("Montant invalide pour le moyen '%s'", p.MethodCode), nil)
 			}
@@ -360,6 +489,22 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			ticket.Set("cart_discount_ttc", totals.CartDiscountTTC)
 		}
 
+		// Fidélité (le mouvement de points est écrit avec le ticket)
+		if loyaltyPoints > 0 {
+			ticket.Set("loyalty_points_redeemed", loyaltyPoints)
+			ticket.Set("loyalty_discount_ttc", loyaltyAmount)
+		}
+
+		// Hors-ligne : la numérotation et le chaînage se font au rejeu,
+		// l'heure réelle de la vente est conservée à part
+		if input.OfflineID != "" {
+			ticket.Set("offline_id", input.OfflineID)
+			ticket.Set("offline_workstation", input.Workstation)
+			if !offlineCreatedAt.IsZero() {
+				ticket.Set("offline_created_at", offlineCreatedAt)
+			}
+		}
+
 		// Chaînage NF525
 		ticket.Set("previous_hash", previousHash)
 		ticket.Set("sequence_number", sequenceNumber)
@@ -383,20 +528,52 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 		ticket.Set("hash", hashValue)
 		ticket.Set("_skip_hook_processing", true)
 
-		if err := dao.SaveRecord(ticket); err != nil {
+		// Le ticket, ses unités vendues et les points utilisés s'écrivent
+		// ensemble : le solde est relu dans la transaction
+		err = dao.RunInTransaction(func(tx *daos.Dao) error {
+			if err := tx.SaveRecord(ticket); err != nil {
+				return err
+			}
+			if err := sellTicketSerials(tx, ticket, serialUnits, input.OfflineID != ""); err != nil {
+				return err
+			}
+			if err := backend.RedeemLoyaltyForDocument(tx, ticket); err != nil {
+				if errors.Is(err, backend.ErrLoyaltyBalance) {
+					return apis.NewBadRequestError(err.Error(), nil)
+				}
+				return fmt.Errorf("points fidélité : %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			if apiErr, ok := err.(*apis.ApiError); ok {
+				return apiErr
+			}
+			// Deux rejeux simultanés du même ticket : l'index unique a tranché
+			if input.OfflineID != "" {
+				if existing := findOfflineTicket(dao, input.OwnerCompany, input.OfflineID); existing != nil {
+					return c.JSON(http.StatusOK, replayedTicketResult(existing))
+				}
+			}
 			return apis.NewApiError(500, "Erreur création ticket", err)
 		}
 
 		log.Printf("✅ Ticket %s créé: %.2f€ TTC | %d moyen(s) | session: %s",
 			ticketNumber, totals.TotalTTC, len(payments), input.SessionID)
 
+		// 🎁 Fidélité : gain de points (non bloquant, le ticket est déjà scellé ;
+		// l'utilisation est déjà écrite)
+		if err := backend.ApplyLoyaltyForDocument(dao, ticket); err != nil {
+			log.Printf("⚠️ Fidélité non mise à jour pour %s: %v", ticketNumber, err)
+		}
+
 		// 10) Créer les mouvements de caisse (une entrée par ligne espèces)
 		var lastCashMovement *models.Record
 		cmCol, cmErr := dao.FindCollectionByNameOrId("cash_movements")
 
 		if cmErr == nil {
 			for i, p := range payments {
-				if p.AccountingCategory != "cash" {
+				if p.AccountingCategory != "cash" || p.Amount <= 0 {
 					continue
 				}
 
@@ -448,6 +625,26 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			CashMovement: lastCashMovement,
 			Change:       change,
 			Totals:       totals,
+
+			ReservationWarnings: reservationWarnings,
+		})
+	},
+		apis.RequireRecordAuth(),
+	)
+
+	// -------------------------------------------------------------------------
+	// GET /api/pos/ticket/lookup?code= - Ticket retrouvé par son code scanné
+	// (code-barres du numéro ou QR de vérification), pour le remboursement
+	// -------------------------------------------------------------------------
+	router.GET("/api/pos/ticket/lookup", func(c echo.Context) error {
+		ticket, err := findPosTicketByCode(app.Dao(), c.QueryParam("code"), c.QueryParam("owner_company"))
+		if err != nil {
+			return apis.NewNotFoundError(err.Error(), nil)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"ticket":     ticket,
+			"can_refund": ticket.GetFloat("remaining_amount") > 0.01,
 		})
 	},
 		apis.RequireRecordAuth(),
@@ -534,7 +731,7 @@ func RegisterPosRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 // FONCTIONS DE CALCUL (inchangées)
 // ============================================================================
 
-func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any, error) {
+func calculateTicketTotals(input PosTicketInput, loyaltyDiscountTTC float64) (TicketTotals, []map[string]any, error) {
 	var totals TicketTotals
 	processedItems := make([]map[string]any, 0, len(input.Items))
 	vatMap := make(map[float64]*VATBreakdownEntry)
@@ -586,6 +783,11 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 			"tva_rate":      tvaRate,
 			"total_ht":      lineHT,
 			"total_ttc":     lineTTC,
+			// Ce que le ticket imprime : la ligne avant la remise panier et
+			// fidélité, ventilée plus bas dans total_ttc
+			"unit_price_ttc_before_discount": item.UnitPriceTTC,
+			"unit_price_ttc":                 roundAmount(lineTTC / item.Quantity),
+			"total_ttc_before_cart_discount": lineTTC,
 		}
 
 		if item.Designation != "" {
@@ -594,11 +796,18 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 		if item.SKU != "" {
 			processedItem["sku"] = item.SKU
 		}
+		if len(item.SerialNumbers) > 0 {
+			processedItem["serial_numbers"] = item.SerialNumbers
+		}
+		if item.PriceListID != "" {
+			processedItem["price_list_id"] = item.PriceListID
+			processedItem["price_list_name"] = item.PriceListName
+			processedItem["base_price_ttc"] = item.BasePriceTTC
+		}
 		if lineDiscountTTC > 0 {
 			processedItem["line_discount_mode"] = item.LineDiscountMode
 			processedItem["line_discount_value"] = item.LineDiscountValue
 			processedItem["line_discount_ttc"] = lineDiscountTTC
-			processedItem["unit_price_ttc_before_discount"] = item.UnitPriceTTC
 		}
 
 		processedItems = append(processedItems, processedItem)
@@ -613,9 +822,18 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 		totals.CartDiscountTTC = roundAmount(clampFloat(input.CartDiscountValue, 0, subtotalAfterLineDiscounts))
 	}
 
-	if totals.CartDiscountTTC > 0 && subtotalAfterLineDiscounts > 0 {
+	// Remise fidélité : appliquée après la remise panier, sur le reste dû
+	if loyaltyDiscountTTC > 0 {
+		totals.LoyaltyDiscountTTC = roundAmount(clampFloat(loyaltyDiscountTTC, 0,
+			subtotalAfterLineDiscounts-totals.CartDiscountTTC))
+	}
+
+	// Ventilation des remises globales (panier + fidélité) au prorata des lignes
+	globalDiscountTTC := roundAmount(totals.CartDiscountTTC + totals.LoyaltyDiscountTTC)
+
+	if globalDiscountTTC > 0 && subtotalAfterLineDiscounts > 0 {
 		vatMap = make(map[float64]*VATBreakdownEntry)
-		remaining := totals.CartDiscountTTC
+		remaining := globalDiscountTTC
 
 		for i, item := range processedItems {
 			lineTTC := item["total_ttc"].(float64)
@@ -625,7 +843,7 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 			if i == len(processedItems)-1 {
 				lineCartDiscount = remaining
 			} else {
-				lineCartDiscount = roundAmount(totals.CartDiscountTTC * lineTTC / subtotalAfterLineDiscounts)
+				lineCartDiscount = roundAmount(globalDiscountTTC * lineTTC / subtotalAfterLineDiscounts)
 			}
 			remaining = roundAmount(remaining - lineCartDiscount)
 
@@ -669,6 +887,7 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 	totals.SubtotalTTC = roundAmount(totals.SubtotalTTC)
 	totals.LineDiscountsTotalTTC = roundAmount(totals.LineDiscountsTotalTTC)
 	totals.CartDiscountTTC = roundAmount(totals.CartDiscountTTC)
+	totals.LoyaltyDiscountTTC = roundAmount(totals.LoyaltyDiscountTTC)
 	totals.TotalHT = roundAmount(totals.TotalHT)
 	totals.TotalTVA = roundAmount(totals.TotalTVA)
 	totals.TotalTTC = roundAmount(totals.TotalTTC)
@@ -681,6 +900,75 @@ func calculateTicketTotals(input PosTicketInput) (TicketTotals, []map[string]any
 	return totals, processedItems, nil
 }
 
+// applyPriceLists remplace le prix catalogue des lignes produit par le prix
+// effectif du client (listes de prix, paliers cumulés par produit).
+// Un prix saisi à la main (≠ prix catalogue, ou price_locked) est respecté.
+func applyPriceLists(dao *daos.Dao, input *PosTicketInput) error {
+	resolver, err := backend.NewPriceResolver(dao, input.OwnerCompany, input.CustomerID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	quantities := make(map[string]float64)
+	for _, item := range input.Items {
+		if item.ProductID != "" {
+			quantities[item.ProductID] += item.Quantity
+		}
+	}
+
+	for i := range input.Items {
+		item := &input.Items[i]
+		if item.ProductID == "" || item.PriceLocked {
+			continue
+		}
+		resolved, err := resolver.Resolve(item.ProductID, quantities[item.ProductID])
+		if err != nil || !resolved.FromPriceList() {
+			continue
+		}
+		if math.Abs(item.UnitPriceTTC-resolved.BasePriceTTC) >= 0.005 {
+			continue
+		}
+		item.UnitPriceTTC = resolved.UnitPriceTTC
+		item.PriceListID = resolved.PriceListID
+		item.PriceListName = resolved.PriceListName
+		item.BasePriceTTC = resolved.BasePriceTTC
+	}
+	return nil
+}
+
+// findOfflineTicket retrouve un ticket déjà rejoué par son identifiant provisoire
+func findOfflineTicket(dao *daos.Dao, ownerCompany, offlineID string) *models.Record {
+	rec, err := dao.FindFirstRecordByFilter(
+		"invoices",
+		"owner_company = {:company} && offline_id = {:offline}",
+		dbx.Params{"company": ownerCompany, "offline": offlineID},
+	)
+	if err != nil {
+		return nil
+	}
+	return rec
+}
+
+// replayedTicketResult reconstruit la réponse d'un ticket déjà enregistré
+func replayedTicketResult(ticket *models.Record) PosTicketResult {
+	var vat []VATBreakdownEntry
+	_ = ticket.UnmarshalJSONField("vat_breakdown", &vat)
+
+	totals := TicketTotals{
+		LineDiscountsTotalTTC: ticket.GetFloat("line_discounts_total_ttc"),
+		CartDiscountTTC:       ticket.GetFloat("cart_discount_ttc"),
+		LoyaltyDiscountTTC:    ticket.GetFloat("loyalty_discount_ttc"),
+		TotalHT:               ticket.GetFloat("total_ht"),
+		TotalTVA:              ticket.GetFloat("total_tva"),
+		TotalTTC:              ticket.GetFloat("total_ttc"),
+		VATBreakdown:          vat,
+	}
+	totals.SubtotalTTC = roundAmount(totals.TotalTTC + totals.LineDiscountsTotalTTC +
+		totals.CartDiscountTTC + totals.LoyaltyDiscountTTC)
+
+	return PosTicketResult{Ticket: ticket, Totals: totals, Replayed: true}
+}
+
 func roundAmount(amount float64) float64 {
 	return math.Round(amount*100) / 100
 }
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Une remise fidélité est une remise, pas un paiement : elle doit baisser la
+// base HT et la TVA du ticket, ventilée au prorata comme la remise panier.
+func TestRemiseFideliteReduitLaBaseTVA(t *testing.T) {
+	input := PosTicketInput{
+		Items: []PosItemInput{
+			{Name: "Cordes", Quantity: 2, UnitPriceTTC: 12, TVARate: 20},
+			{Name: "Méthode", Quantity: 1, UnitPriceTTC: 26, TVARate: 5.5},
+		},
+		CartDiscountMode:  "amount",
+		CartDiscountValue: 10,
+	}
+
+	totals, items, err := calculateTicketTotals(input, 5)
+	if err != nil {
+		t.Fatalf("calcul: %v", err)
+	}
+
+	if totals.CartDiscountTTC != 10 || totals.LoyaltyDiscountTTC != 5 {
+		t.Errorf("remises : panier %v, fidélité %v", totals.CartDiscountTTC, totals.LoyaltyDiscountTTC)
+	}
+	if totals.TotalTTC != 35 {
+		t.Errorf("total TTC : attendu 35, obtenu %v", totals.TotalTTC)
+	}
+
+	var somme float64
+	for _, it := range items {
+		somme += it["total_ttc"].(float64)
+	}
+	if roundAmount(somme) != totals.TotalTTC {
+		t.Errorf("les lignes (%v) ne retombent pas sur le total (%v)", somme, totals.TotalTTC)
+	}
+	if roundAmount(totals.TotalHT+totals.TotalTVA) != totals.TotalTTC {
+		t.Errorf("HT %v + TVA %v ≠ TTC %v", totals.TotalHT, totals.TotalTVA, totals.TotalTTC)
+	}
+
+	// Plus de points que de reste dû : la remise est plafonnée à 40 € et le
+	// ticket tombe à 0 € ; la route refuse ce dépassement, mais un ticket que
+	// les points couvrent tout juste passe à 0 € (TestTicketAZeroEuro).
+	totals, _, _ = calculateTicketTotals(input, 100)
+	if totals.LoyaltyDiscountTTC != 40 || totals.TotalTTC != 0 {
+		t.Errorf("plafond : fidélité %v, total %v", totals.LoyaltyDiscountTTC, totals.TotalTTC)
+	}
+}
+
+// Un ticket à 0 € (remise panier de 100 %, ou points qui couvrent tout) est
+// une vente : il s'enregistre avec son moyen de paiement à 0 €, sans
+// mouvement d'espèces, au lieu de faire tomber la route.
+func TestTicketAZeroEuro(t *testing.T) {
+	c := caisseDeTest(t)
+	RegisterPosRoutes(c.app, c.e)
+
+	cas := []struct {
+		nom      string
+		paiement map[string]any
+	}{
+		{"mono-paiement legacy", map[string]any{"payment_method": "especes"}},
+		{"multipaiement", map[string]any{"payments": []map[string]any{
+			{"method_code": "cb", "method_label": "CB", "accounting_category": "card", "amount": 0},
+		}}},
+	}
+	for _, tc := range cas {
+		corps := map[string]any{
+			"owner_company":       c.societe.Id,
+			"cash_register":       c.caisse.Id,
+			"session_id":          c.session.Id,
+			"customer_id":         c.client.Id,
+			"items":               []map[string]any{{"name": "Médiator", "quantity": 1, "unit_price_ttc": 2, "tva_rate": 20}},
+			"cart_discount_mode":  "percent",
+			"cart_discount_value": 100,
+		}
+		for k, v := range tc.paiement {
+			corps[k] = v
+		}
+		code, reponse := c.envoyer(t, c.caissier, http.MethodPost, "/api/pos/ticket", corps)
+		if code != http.StatusOK && code != http.StatusCreated {
+			t.Errorf("%s : code %d, réponse %v", tc.nom, code, reponse)
+		}
+	}
+
+	mouvements, err := c.app.Dao().FindRecordsByFilter("cash_movements", "session = {:s}", "", 0, 0,
+		map[string]any{"s": c.session.Id})
+	if err != nil {
+		t.Fatalf("mouvements: %v", err)
+	}
+	if len(mouvements) != 0 {
+		t.Errorf("%d mouvement(s) d'espèces pour des tickets à 0 €", len(mouvements))
+	}
+}
@@ -0,0 +1,83 @@
+// backend/routes/pricing_routes.go
+// ═══════════════════════════════════════════════════════════════════════════
+// ROUTES — LISTES DE PRIX
+// ═══════════════════════════════════════════════════════════════════════════
+// Les listes et leurs règles se gèrent par l'API REST de PocketBase
+// (price_lists, price_list_items). Le prix effectif est résolu côté serveur
+// au moment d'enregistrer un ticket, un devis ou un BC (voir backend/pricing.go) ;
+// cette route permet aux éditeurs d'afficher ce prix avant l'enregistrement.
+
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+
+	"pocket-react/backend"
+)
+
+// PriceResolveInput liste les lignes à tarifer pour un client
+type PriceResolveInput struct {
+	OwnerCompany string `json:"owner_company"`
+	CustomerID   string `json:"customer_id"`
+	Date         string `json:"date,omitempty"` // YYYY-MM-DD, défaut aujourd'hui
+	Lines        []struct {
+		ProductID string  `json:"product_id"`
+		Quantity  float64 `json:"quantity"`
+	} `json:"lines"`
+}
+
+func RegisterPricingRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// POST /api/pricing/resolve
+	// Prix effectif de chaque ligne (quantités cumulées par produit, comme à
+	// l'enregistrement du document).
+	// ─────────────────────────────────────────────────────────────────────────
+	router.POST("/api/pricing/resolve", func(c echo.Context) error {
+		var payload PriceResolveInput
+		if err := c.Bind(&payload); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if payload.OwnerCompany == "" {
+			return apis.NewBadRequestError("owner_company requis", nil)
+		}
+
+		at := time.Now()
+		if payload.Date != "" {
+			parsed, err := time.Parse("2006-01-02", payload.Date)
+			if err != nil {
+				return apis.NewBadRequestError("date invalide (YYYY-MM-DD attendu)", err)
+			}
+			at = parsed
+		}
+
+		resolver, err := backend.NewPriceResolver(app.Dao(), payload.OwnerCompany, payload.CustomerID, at)
+		if err != nil {
+			return apis.NewApiError(500, "Impossible de charger les listes de prix", err)
+		}
+
+		quantities := make(map[string]float64)
+		for _, l := range payload.Lines {
+			quantities[l.ProductID] += l.Quantity
+		}
+
+		prices := make([]*backend.ResolvedPrice, 0, len(payload.Lines))
+		for _, l := range payload.Lines {
+			if l.ProductID == "" {
+				return apis.NewBadRequestError("product_id requis sur chaque ligne", nil)
+			}
+			resolved, err := resolver.Resolve(l.ProductID, quantities[l.ProductID])
+			if err != nil {
+				return apis.NewNotFoundError(err.Error(), nil)
+			}
+			prices = append(prices, resolved)
+		}
+
+		return c.JSON(http.StatusOK, echo.Map{"prices": prices})
+	}, apis.RequireRecordAuth())
+}
@@ -0,0 +1,416 @@
+// backend/routes/print_queue.go
+// ═══════════════════════════════════════════════════════════════════════════
+// FILE D'IMPRESSION — un ticket n'est jamais perdu
+// ═══════════════════════════════════════════════════════════════════════════
+// Chaque ticket à imprimer est d'abord enregistré dans print_jobs (octets
+// ESC/POS en base64), puis envoyé. S'il ne sort pas (papier épuisé, capot
+// ouvert, imprimante éteinte), il reste en attente et le serveur le relance
+// avec un délai croissant, y compris après un redémarrage. Après
+// printMaxAttempts échecs, il passe en "failed" : il ne repart que sur une
+// relance manuelle (/api/pos/print-jobs/:id/retry).
+//
+// Les travaux terminés sont purgés après printJobRetention (imprimés,
+// annulés) ou printFailedRetention (en échec) : chacun porte son ticket
+// ESC/POS en base64, et le ticket lui-même reste dans invoices.
+//
+// Avant chaque envoi, l'état de l'imprimante est lu (DLE EOT) quand le
+// transport le permet : inutile d'envoyer un ticket à une imprimante sans
+// papier. Les changements d'état et l'avancement des travaux sont poussés
+// sur le flux SSE (/api/presence/events) :
+//   "printer_status" → { printer, target, status }
+//   "print_job"      → { id, status, label, attempts, last_error }
+//
+// Le tiroir et le ticket de test ne passent PAS par la file : ouvrir un
+// tiroir dix minutes plus tard serait pire que ne pas l'ouvrir.
+
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"pocket-react/backend/pos"
+)
+
+const (
+	printQueueTick       = 5 * time.Second
+	printStatusPollEvery = 30 * time.Second
+	printRetryBase       = 5 * time.Second
+	printRetryMax        = 2 * time.Minute
+	printQueueBatch      = 100
+	printMaxAttempts     = 30 // ~50 min de relances au délai maximal
+	printPurgeEvery      = time.Hour
+	printJobRetention    = 7 * 24 * time.Hour
+	printFailedRetention = 30 * 24 * time.Hour
+)
+
+type printQueue struct {
+	pb   *pocketbase.PocketBase
+	wake chan struct{}
+
+	// Une imprimante n'envoie qu'un ticket à la fois, dans l'ordre de
+	// création ; le verrou ne protège que la liste des imprimantes occupées,
+	// jamais un envoi
+	mu   sync.Mutex
+	busy map[string]bool
+
+	statusMu   sync.Mutex
+	lastStatus map[string]pos.PrinterStatus
+}
+
+var (
+	posPrintQueue     *printQueue
+	posPrintQueueOnce sync.Once
+)
+
+// startPrintQueue démarre (une seule fois) le traitement en arrière-plan
+func startPrintQueue(pb *pocketbase.PocketBase) *printQueue {
+	posPrintQueueOnce.Do(func() {
+		posPrintQueue = &printQueue{
+			pb:         pb,
+			wake:       make(chan struct{}, 1),
+			busy:       make(map[string]bool),
+			lastStatus: make(map[string]pos.PrinterStatus),
+		}
+		go posPrintQueue.run()
+		log.Println("🖨️  File d'impression démarrée")
+	})
+	return posPrintQueue
+}
+
+func (q *printQueue) run() {
+	ticker := time.NewTicker(printQueueTick)
+	defer ticker.Stop()
+	lastPoll := time.Time{}
+	lastPurge := time.Time{}
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.wake:
+		}
+		q.processDue()
+
+		if time.Since(lastPoll) >= printStatusPollEvery {
+			q.pollStatuses()
+			lastPoll = time.Now()
+		}
+		if time.Since(lastPurge) >= printPurgeEvery {
+			q.purge()
+			lastPurge = time.Now()
+		}
+	}
+}
+
+// Wake demande un passage immédiat (relance manuelle)
+func (q *printQueue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue enregistre un ticket puis tente de l'imprimer tout de suite.
+// Renvoie le travail et l'erreur de la première tentative (nil = imprimé) ;
+// en cas d'erreur le ticket reste en file.
+func (q *printQueue) Enqueue(registerID, printerName, ticketID, label string, data []byte) (*models.Record, error) {
+	job, err := q.insert(q.pb.Dao(), registerID, printerName, ticketID, label, data)
+	if err != nil {
+		return nil, err
+	}
+	return job, q.Send(job)
+}
+
+// insert enregistre le travail avec `dao`, sans l'envoyer : dans une
+// transaction, le ticket n'entre en file que si elle aboutit
+func (q *printQueue) insert(dao *daos.Dao, registerID, printerName, ticketID, label string, data []byte) (*models.Record, error) {
+	collection, err := dao.FindCollectionByNameOrId("print_jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.NewRecord(collection)
+	job.Set("cash_register", registerID)
+	job.Set("printer_name", printerName)
+	job.Set("ticket", ticketID)
+	job.Set("label", label)
+	job.Set("payload", base64.StdEncoding.EncodeToString(data))
+	job.Set("status", "pending")
+	job.Set("attempts", 0)
+	job.Set("next_attempt_at", types.NowDateTime())
+	if err := dao.SaveRecord(job); err != nil {
+		return nil, fmt.Errorf("enregistrement du travail d'impression : %w", err)
+	}
+	return job, nil
+}
+
+// Send tente tout de suite un travail enregistré ; nil = imprimé, sinon il
+// reste en file
+func (q *printQueue) Send(job *models.Record) error {
+	target := jobTarget(job)
+	if !q.claim(target) {
+		q.Wake()
+		return fmt.Errorf("l'imprimante termine un autre ticket : celui-ci suit")
+	}
+	defer q.release(target)
+
+	// Des tickets plus anciens attendent sur la même imprimante : celui-ci
+	// passe après eux, au prochain passage
+	if q.olderPendingExists(job) {
+		q.Wake()
+		return fmt.Errorf("des tickets précédents attendent encore l'imprimante")
+	}
+	return q.attempt(job)
+}
+
+// claim réserve une imprimante le temps d'un envoi ; false si elle est déjà
+// occupée
+func (q *printQueue) claim(target string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.busy[target] {
+		return false
+	}
+	q.busy[target] = true
+	return true
+}
+
+func (q *printQueue) release(target string) {
+	q.mu.Lock()
+	delete(q.busy, target)
+	q.mu.Unlock()
+}
+
+// jobTarget identifie l'imprimante d'un travail (caisse, sinon nom)
+func jobTarget(job *models.Record) string {
+	if id := job.GetString("cash_register"); id != "" {
+		return id
+	}
+	return job.GetString("printer_name")
+}
+
+func (q *printQueue) olderPendingExists(job *models.Record) bool {
+	others, err := q.pb.Dao().FindRecordsByFilter(
+		"print_jobs",
+		"status = 'pending' && id != {:id} && created <= {:created} && cash_register = {:register} && printer_name = {:printer}",
+		"created",
+		1,
+		0,
+		dbx.Params{
+			"id":       job.Id,
+			"created":  job.GetString("created"),
+			"register": job.GetString("cash_register"),
+			"printer":  job.GetString("printer_name"),
+		},
+	)
+	return err == nil && len(others) > 0
+}
+
+// processDue relance les travaux arrivés à échéance, imprimante par
+// imprimante : au premier échec, les suivants de la même imprimante attendent
+func (q *printQueue) processDue() {
+	jobs, err := q.pb.Dao().FindRecordsByFilter(
+		"print_jobs",
+		"status = 'pending'",
+		"created",
+		printQueueBatch,
+		0,
+	)
+	if err != nil || len(jobs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	for _, job := range jobs {
+		target := jobTarget(job)
+		if blocked[target] {
+			continue
+		}
+		if next := job.GetDateTime("next_attempt_at"); !next.IsZero() && next.Time().After(now) {
+			blocked[target] = true
+			continue
+		}
+		if !q.claim(target) {
+			blocked[target] = true
+			continue
+		}
+		err := q.attempt(job)
+		q.release(target)
+		if err != nil {
+			blocked[target] = true
+		}
+	}
+}
+
+// attempt envoie un travail ; en cas d'échec, planifie la relance suivante
+func (q *printQueue) attempt(job *models.Record) error {
+	err := q.send(job)
+
+	attempts := job.GetInt("attempts") + 1
+	job.Set("attempts", attempts)
+
+	if err == nil {
+		job.Set("status", "printed")
+		job.Set("printed_at", types.NowDateTime())
+		job.Set("last_error", "")
+		if attempts > 1 {
+			log.Printf("✅ [PRINT] %s imprimé après %d tentatives", job.GetString("label"), attempts)
+		}
+	} else if attempts >= printMaxAttempts {
+		job.Set("status", "failed")
+		job.Set("last_error", err.Error())
+		log.Printf("❌ [PRINT] %s abandonné après %d tentatives : %v", job.GetString("label"), attempts, err)
+	} else {
+		delay := printRetryBase << min(attempts-1, 5)
+		if delay > printRetryMax {
+			delay = printRetryMax
+		}
+		next, _ := types.ParseDateTime(time.Now().Add(delay))
+		job.Set("next_attempt_at", next)
+		job.Set("last_error", err.Error())
+		log.Printf("⚠️ [PRINT] %s non imprimé (tentative %d) : %v", job.GetString("label"), attempts, err)
+	}
+
+	if saveErr := q.pb.Dao().SaveRecord(job); saveErr != nil {
+		log.Printf("❌ [PRINT] Mise à jour du travail %s : %v", job.Id, saveErr)
+	}
+	broadcastPrintJob(job)
+	return err
+}
+
+func (q *printQueue) send(job *models.Record) error {
+	printer, _, err := resolvePosPrinter(q.pb, job.GetString("cash_register"), job.GetString("printer_name"))
+	if err != nil {
+		return err
+	}
+
+	status, err := pos.QueryPrinterStatus(printer)
+	if err == nil {
+		q.recordStatus(jobTarget(job), printer, status)
+		if !status.Ready() {
+			return fmt.Errorf("%s : %s", printer, status.Reason())
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(job.GetString("payload"))
+	if err != nil {
+		return fmt.Errorf("contenu du ticket illisible : %w", err)
+	}
+	return printer.Write(data)
+}
+
+// purge supprime les travaux terminés depuis plus longtemps que leur durée
+// de conservation
+func (q *printQueue) purge() {
+	now := time.Now()
+	for _, rule := range []struct {
+		filter string
+		keep   time.Duration
+	}{
+		{"status = 'printed' || status = 'cancelled'", printJobRetention},
+		{"status = 'failed'", printFailedRetention},
+	} {
+		before, _ := types.ParseDateTime(now.Add(-rule.keep))
+		jobs, err := q.pb.Dao().FindRecordsByFilter(
+			"print_jobs",
+			"("+rule.filter+") && updated < {:before}",
+			"updated",
+			0,
+			0,
+			dbx.Params{"before": before.String()},
+		)
+		if err != nil {
+			continue
+		}
+		for _, job := range jobs {
+			if err := q.pb.Dao().DeleteRecord(job); err != nil {
+				log.Printf("⚠️ [PRINT] Purge du travail %s : %v", job.Id, err)
+			}
+		}
+		if len(jobs) > 0 {
+			log.Printf("🧹 [PRINT] %d travaux d'impression purgés", len(jobs))
+		}
+	}
+}
+
+// pollStatuses lit l'état des imprimantes configurées sur les caisses et de
+// celles qui ont des tickets en attente
+func (q *printQueue) pollStatuses() {
+	targets := make(map[string][2]string) // cible → {caisse, nom}
+
+	registers, err := q.pb.Dao().FindRecordsByFilter("cash_registers", "settings != '' && settings != 'null'", "", 0, 0)
+	if err == nil {
+		for _, r := range registers {
+			targets[r.Id] = [2]string{r.Id, ""}
+		}
+	}
+	pending, err := q.pb.Dao().FindRecordsByFilter("print_jobs", "status = 'pending'", "created", printQueueBatch, 0)
+	if err == nil {
+		for _, job := range pending {
+			targets[jobTarget(job)] = [2]string{job.GetString("cash_register"), job.GetString("printer_name")}
+		}
+	}
+
+	for target, ref := range targets {
+		printer, _, err := resolvePosPrinter(q.pb, ref[0], ref[1])
+		if err != nil {
+			continue
+		}
+		if _, ok := printer.(pos.StatusReader); !ok {
+			continue
+		}
+		status, err := pos.QueryPrinterStatus(printer)
+		if err != nil {
+			continue
+		}
+		q.recordStatus(target, printer, status)
+	}
+}
+
+// recordStatus mémorise l'état et le diffuse s'il a changé
+func (q *printQueue) recordStatus(target string, printer pos.Printer, status pos.PrinterStatus) {
+	q.statusMu.Lock()
+	previous, seen := q.lastStatus[target]
+	q.lastStatus[target] = status
+	q.statusMu.Unlock()
+
+	if seen && previous.Ready() == status.Ready() && previous.Reason() == status.Reason() &&
+		previous.PaperNearEnd == status.PaperNearEnd && previous.DrawerOpen == status.DrawerOpen {
+		return
+	}
+
+	if !status.Ready() {
+		log.Printf("⚠️ [PRINT] %s : %s", printer, status.Reason())
+	}
+	sseStore.Send(nil, SSEEvent{
+		Type: "printer_status",
+		Payload: map[string]interface{}{
+			"target":  target,
+			"printer": printer.String(),
+			"status":  status,
+		},
+	})
+}
+
+func broadcastPrintJob(job *models.Record) {
+	sseStore.Send(nil, SSEEvent{
+		Type: "print_job",
+		Payload: map[string]interface{}{
+			"id":         job.Id,
+			"status":     job.GetString("status"),
+			"label":      job.GetString("label"),
+			"attempts":   job.GetInt("attempts"),
+			"last_error": job.GetString("last_error"),
+		},
+	})
+}
@@ -0,0 +1,511 @@
+// backend/routes/purchase_routes.go
+//
+// LES ACHATS FOURNISSEUR, CÔTÉ SERVEUR — envoi, annulation, réception.
+//
+// ── LE CYCLE ──────────────────────────────────────────────────────────────
+//   draft → sent → partially_received → received
+//   draft | sent → cancelled (rien de reçu)
+//
+// La commande et ses lignes s'écrivent par l'API REST tant qu'elle est en
+// brouillon (règles de la migration `purchasing_migration.go`). Ensuite, seul
+// ce fichier la fait avancer :
+//   POST /api/purchase-orders/:id/send     fige les lignes et le total
+//   POST /api/purchase-orders/:id/cancel   abandonne une commande non reçue
+//   POST /api/purchase-orders/:id/receive  poste une réception
+//
+// ── LA RÉCEPTION ──────────────────────────────────────────────────────────
+// Une réception est à la fois un mouvement de stock et une trace. Elle tient
+// dans UNE transaction, comme `applyOneMovement` : le stock de chaque produit
+// est lu et réécrit sur la connexion d'écriture unique (voir l'en-tête de
+// `stock_routes.go`), la ligne de commande avance, et le journal
+// `product_events` (`stock_received`) est écrit avec, pas après. Une ligne
+// fautive — quantité au-delà du reste à recevoir, ligne d'une autre commande —
+// annule toute la réception : un bon de livraison se poste entier ou pas.
+//
+// ── LE PRIX D'ACHAT ───────────────────────────────────────────────────────
+// Chaque réception met à jour `purchase_price_ht` selon
+// `companies.purchase_cost_method` : le dernier prix payé (vide ou `last`),
+// ou le coût moyen pondéré (`wac`) du stock en main et de ce qui arrive.
+// Un changement est journalisé (`purchase_price_changed`).
+//
+// ── LES NUMÉROS DE SÉRIE ──────────────────────────────────────────────────
+// Un produit suivi (`products.serialized`) se reçoit avec un numéro par
+// unité ; chacun devient une unité en stock de `product_serials`, écrite
+// après la réception qu'elle désigne (voir `serial_routes.go`).
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"pocket-react/backend"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Méthodes de valorisation du prix d'achat (companies.purchase_cost_method)
+const (
+	// Le prix de la dernière réception remplace le prix d'achat
+	CostMethodLast = "last"
+	// Coût moyen pondéré : (stock × coût + reçu × prix) / (stock + reçu)
+	CostMethodWAC = "wac"
+)
+
+// Statuts de purchase_orders.status
+const (
+	PurchaseStatusDraft             = "draft"
+	PurchaseStatusSent              = "sent"
+	PurchaseStatusPartiallyReceived = "partially_received"
+	PurchaseStatusReceived          = "received"
+	PurchaseStatusCancelled         = "cancelled"
+)
+
+// Même borne que /api/inventory/:id/count : un bon de livraison réel en est
+// loin, et la connexion d'écriture est tenue pendant toute la réception.
+const maxReceiptLines = 500
+
+// PurchaseReceiptLineInput — une ligne reçue. Sans prix, celui de la ligne de
+// commande fait foi ; avec, c'est le prix facturé qui diffère. Un produit
+// suivi par numéro de série en porte un par unité reçue.
+type PurchaseReceiptLineInput struct {
+	LineID        string   `json:"line_id"`
+	Quantity      float64  `json:"quantity"`
+	UnitPriceHT   *float64 `json:"unit_price_ht"`
+	SerialNumbers []string `json:"serial_numbers"`
+	LotNumber     string   `json:"lot_number"`
+}
+
+type purchaseReceiveInput struct {
+	Lines []PurchaseReceiptLineInput `json:"lines"`
+	// Numéro du bon de livraison fournisseur
+	DeliveryNote string `json:"delivery_note"`
+	Notes        string `json:"notes"`
+	// Où la marchandise entre. Vide : l'emplacement par défaut de la société.
+	LocationID string `json:"location_id"`
+}
+
+// PurchaseReceiptLine — ce que la réception a posté pour une ligne, tel que
+// conservé dans goods_receipts.lines
+type PurchaseReceiptLine struct {
+	LineID      string  `json:"line_id"`
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	UnitPriceHT float64 `json:"unit_price_ht"`
+	StockBefore float64 `json:"stock_before"`
+	StockAfter  float64 `json:"stock_after"`
+	CostBefore  float64 `json:"cost_before"`
+	CostAfter   float64 `json:"cost_after"`
+	EventID     string  `json:"event_id"`
+
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+	LotNumber     string   `json:"lot_number,omitempty"`
+}
+
+// PurchaseLineProgress — quantités d'une ligne de commande
+type PurchaseLineProgress struct {
+	Ordered  float64
+	Received float64
+}
+
+// Tolérance des comparaisons de quantités : elles peuvent être décimales
+// (poids, longueurs) et se cumulent réception après réception.
+const purchaseQtyEpsilon = 1e-9
+
+// ReceiptCost — le prix d'achat du produit après une réception de `qty` au
+// prix `price`. En coût moyen pondéré, un stock nul ou négatif avant la
+// réception ne pèse rien : son coût ne correspond à aucune unité en main.
+func ReceiptCost(method string, stockBefore, costBefore, qty, price float64) float64 {
+	if method != CostMethodWAC || stockBefore <= 0 || qty <= 0 {
+		return price
+	}
+	wac := (stockBefore*costBefore + qty*price) / (stockBefore + qty)
+	return math.Round(wac*10000) / 10000
+}
+
+// PurchaseOrderStatus — le statut d'une commande envoyée d'après ses lignes
+func PurchaseOrderStatus(lines []PurchaseLineProgress) string {
+	anyReceived := false
+	complete := true
+	for _, l := range lines {
+		if l.Received > purchaseQtyEpsilon {
+			anyReceived = true
+		}
+		if l.Received+purchaseQtyEpsilon < l.Ordered {
+			complete = false
+		}
+	}
+	switch {
+	case !anyReceived:
+		return PurchaseStatusSent
+	case complete:
+		return PurchaseStatusReceived
+	default:
+		return PurchaseStatusPartiallyReceived
+	}
+}
+
+// IsCostMethod indique si la méthode de valorisation est connue
+func IsCostMethod(method string) bool {
+	return method == CostMethodLast || method == CostMethodWAC
+}
+
+func RegisterPurchaseRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/purchase-orders/:id/send", func(c echo.Context) error {
+		var order *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			order, err = tx.FindRecordById("purchase_orders", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Commande fournisseur introuvable", err)
+			}
+			if order.GetString("status") != PurchaseStatusDraft {
+				return apis.NewBadRequestError("Seule une commande en brouillon peut être envoyée", nil)
+			}
+
+			lines, err := purchaseOrderLines(tx, order.Id)
+			if err != nil {
+				return err
+			}
+			if len(lines) == 0 {
+				return apis.NewBadRequestError("La commande n'a aucune ligne", nil)
+			}
+
+			// Les lignes sont figées ici : le nom du produit est repris s'il
+			// manque, pour que la commande reste lisible si la fiche change.
+			total := 0.0
+			for _, line := range lines {
+				if line.GetFloat("quantity_ordered") <= 0 {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("Ligne « %s » : quantité commandée nulle", line.GetString("product_name")), nil)
+				}
+				if line.GetString("product_name") == "" {
+					if product, err := tx.FindRecordById("products", line.GetString("product")); err == nil {
+						line.Set("product_name", product.GetString("name"))
+						if err := tx.SaveRecord(line); err != nil {
+							return err
+						}
+					}
+				}
+				total += line.GetFloat("quantity_ordered") * line.GetFloat("unit_price_ht")
+			}
+
+			order.Set("status", PurchaseStatusSent)
+			order.Set("sent_at", types.NowDateTime())
+			order.Set("total_ht", math.Round(total*100)/100)
+			return tx.SaveRecord(order)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("📤 Commande fournisseur %s envoyée", order.GetString("number"))
+		return c.JSON(http.StatusOK, map[string]any{"order": order})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/purchase-orders/:id/cancel", func(c echo.Context) error {
+		var order *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			order, err = tx.FindRecordById("purchase_orders", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Commande fournisseur introuvable", err)
+			}
+			// Une commande partiellement reçue a déjà bougé le stock : on ne
+			// l'annule pas, le reliquat reste visible.
+			switch order.GetString("status") {
+			case PurchaseStatusDraft, PurchaseStatusSent:
+			default:
+				return apis.NewBadRequestError("Seule une commande sans réception peut être annulée", nil)
+			}
+
+			order.Set("status", PurchaseStatusCancelled)
+			order.Set("cancelled_at", types.NowDateTime())
+			return tx.SaveRecord(order)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("🚫 Commande fournisseur %s annulée", order.GetString("number"))
+		return c.JSON(http.StatusOK, map[string]any{"order": order})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/purchase-orders/:id/receive", func(c echo.Context) error {
+		var input purchaseReceiveInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if len(input.Lines) == 0 {
+			return apis.NewBadRequestError("Aucune ligne reçue", nil)
+		}
+		if len(input.Lines) > maxReceiptLines {
+			return apis.NewBadRequestError("trop de lignes dans une seule réception", nil)
+		}
+		user := apis.RequestInfo(c).AuthRecord
+
+		var order, receipt *models.Record
+		posted := make([]PurchaseReceiptLine, 0, len(input.Lines))
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			order, err = tx.FindRecordById("purchase_orders", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Commande fournisseur introuvable", err)
+			}
+			switch order.GetString("status") {
+			case PurchaseStatusSent, PurchaseStatusPartiallyReceived:
+			default:
+				return apis.NewBadRequestError("La commande n'est pas en attente de réception", nil)
+			}
+
+			method := CostMethodLast
+			if company, err := tx.FindRecordById("companies", order.GetString("owner_company")); err == nil {
+				if m := company.GetString("purchase_cost_method"); IsCostMethod(m) {
+					method = m
+				}
+			}
+
+			location, err := resolveStockLocation(tx, order.GetString("owner_company"), input.LocationID)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			if location != nil && location.GetBool("archived") {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("L'emplacement %q est archivé", location.GetString("name")), nil)
+			}
+
+			receiptsCol, err := tx.FindCollectionByNameOrId("goods_receipts")
+			if err != nil {
+				return err
+			}
+			eventsCol, err := tx.FindCollectionByNameOrId("product_events")
+			if err != nil {
+				return err
+			}
+
+			// L'id est tiré d'avance pour que le journal de chaque produit
+			// désigne la réception, écrite en dernier.
+			receipt = models.NewRecord(receiptsCol)
+			receipt.RefreshId()
+
+			operator := inventoryOperatorName(user, "")
+			now := types.NowDateTime()
+			total := 0.0
+			var units []*models.Record
+			seenSerials := map[string]bool{}
+
+			for _, in := range input.Lines {
+				line, err := tx.FindRecordById("purchase_order_lines", in.LineID)
+				if err != nil || line.GetString("purchase_order") != order.Id {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("Ligne %q absente de la commande", in.LineID), nil)
+				}
+				name := line.GetString("product_name")
+				if in.Quantity <= 0 {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("Ligne « %s » : quantité reçue nulle ou négative", name), nil)
+				}
+				ordered := line.GetFloat("quantity_ordered")
+				received := line.GetFloat("quantity_received")
+				if received+in.Quantity > ordered+purchaseQtyEpsilon {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("Ligne « %s » : %g reçu(s) pour %g restant(s) à recevoir", name, in.Quantity, ordered-received), nil)
+				}
+
+				price := line.GetFloat("unit_price_ht")
+				if in.UnitPriceHT != nil {
+					if *in.UnitPriceHT < 0 {
+						return apis.NewBadRequestError(
+							fmt.Sprintf("Ligne « %s » : prix négatif", name), nil)
+					}
+					price = *in.UnitPriceHT
+				}
+
+				product, err := tx.FindRecordById("products", line.GetString("product"))
+				if err != nil {
+					return apis.NewBadRequestError(
+						fmt.Sprintf("Ligne « %s » : produit introuvable au catalogue", name), nil)
+				}
+
+				lineUnits, serials, err := receiptSerialUnits(tx, order, receipt, product, in, now, seenSerials)
+				if err != nil {
+					return err
+				}
+				units = append(units, lineUnits...)
+
+				posting, err := postPurchaseReceipt(tx, eventsCol, order, receipt, product, location, in.Quantity, price, method, operator, now)
+				if err != nil {
+					return err
+				}
+				posting.LineID = line.Id
+				posting.SerialNumbers = serials
+				posting.LotNumber = strings.TrimSpace(in.LotNumber)
+				posted = append(posted, posting)
+				total += in.Quantity * price
+
+				line.Set("quantity_received", received+in.Quantity)
+				if err := tx.SaveRecord(line); err != nil {
+					return err
+				}
+			}
+
+			lines, err := purchaseOrderLines(tx, order.Id)
+			if err != nil {
+				return err
+			}
+			progress := make([]PurchaseLineProgress, 0, len(lines))
+			for _, l := range lines {
+				progress = append(progress, PurchaseLineProgress{
+					Ordered:  l.GetFloat("quantity_ordered"),
+					Received: l.GetFloat("quantity_received"),
+				})
+			}
+			status := PurchaseOrderStatus(progress)
+			order.Set("status", status)
+			if status == PurchaseStatusReceived {
+				order.Set("received_at", now)
+			}
+			if err := tx.SaveRecord(order); err != nil {
+				return err
+			}
+
+			receipt.Set("owner_company", order.GetString("owner_company"))
+			receipt.Set("purchase_order", order.Id)
+			receipt.Set("supplier", order.GetString("supplier"))
+			if user != nil {
+				receipt.Set("received_by", user.Id)
+			}
+			receipt.Set("received_at", now)
+			receipt.Set("delivery_note", input.DeliveryNote)
+			if location != nil {
+				receipt.Set("location", location.Id)
+			}
+			receipt.Set("lines", posted)
+			receipt.Set("total_ht", math.Round(total*100)/100)
+			receipt.Set("notes", input.Notes)
+			if err := tx.SaveRecord(receipt); err != nil {
+				return err
+			}
+
+			for _, unit := range units {
+				if err := tx.SaveRecord(unit); err != nil {
+					return fmt.Errorf("numéro %s : %w", unit.GetString("serial_number"), err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("📦 Réception %s sur %s : %d ligne(s), commande %s",
+			receipt.Id, order.GetString("number"), len(posted), order.GetString("status"))
+		return c.JSON(http.StatusOK, map[string]any{
+			"order":   order,
+			"receipt": receipt,
+			"lines":   posted,
+		})
+	}, apis.RequireRecordAuth())
+}
+
+// purchaseOrderLines : les lignes d'une commande, dans l'ordre de saisie
+func purchaseOrderLines(tx *daos.Dao, orderID string) ([]*models.Record, error) {
+	return tx.FindRecordsByFilter(
+		"purchase_order_lines", "purchase_order = {:order}", "created", 0, 0,
+		dbx.Params{"order": orderID},
+	)
+}
+
+// postPurchaseReceipt entre `qty` unités d'un produit au stock — à
+// `location` si la société a des emplacements —, met à jour son prix d'achat
+// et journalise les deux, dans la transaction de la réception. Le coût moyen
+// se calcule sur le stock total : le prix d'achat est celui du produit, pas
+// d'un emplacement.
+func postPurchaseReceipt(tx *daos.Dao, eventsCol *models.Collection, order, receipt, product, location *models.Record, qty, price float64, method, operator string, now types.DateTime) (PurchaseReceiptLine, error) {
+	stockBefore := product.GetFloat("stock")
+	costBefore := product.GetFloat("purchase_price_ht")
+	posting := PurchaseReceiptLine{
+		ProductID:   product.Id,
+		ProductName: product.GetString("name"),
+		Quantity:    qty,
+		UnitPriceHT: price,
+		StockBefore: stockBefore,
+		StockAfter:  NextStock(stockBefore, StockMovementInput{Delta: &qty}),
+		CostBefore:  costBefore,
+		CostAfter:   ReceiptCost(method, stockBefore, costBefore, qty, price),
+	}
+
+	metadata := map[string]any{
+		"purchase_order_id":     order.Id,
+		"purchase_order_number": order.GetString("number"),
+		"goods_receipt_id":      receipt.Id,
+		"supplier_id":           order.GetString("supplier"),
+		"unit_price_ht":         price,
+		"cost_method":           method,
+	}
+
+	if location != nil {
+		before, after, err := moveLocationStock(tx, product, location, StockMovementInput{Delta: &qty})
+		if err != nil {
+			return posting, err
+		}
+		metadata["location_id"] = location.Id
+		metadata["location_stock_before"] = before
+		metadata["location_stock_after"] = after
+	} else {
+		product.Set("stock", posting.StockAfter)
+	}
+	product.Set("purchase_price_ht", posting.CostAfter)
+	backend.MarkProductJournaled(product) // stock_received porte stock et coût
+	if err := tx.SaveRecord(product); err != nil {
+		return posting, fmt.Errorf("produit %q : %w", posting.ProductName, err)
+	}
+	newEvent := func(eventType string, before, after, delta map[string]any) *models.Record {
+		event := models.NewRecord(eventsCol)
+		event.Set("product_id", product.Id)
+		event.Set("product_name_snapshot", product.GetString("name"))
+		event.Set("product_sku_snapshot", product.GetString("sku"))
+		event.Set("event_type", eventType)
+		event.Set("source", "purchase_receipt")
+		event.Set("source_id", receipt.Id)
+		event.Set("operator", operator)
+		event.Set("occurred_at", now)
+		event.Set("before", before)
+		event.Set("after", after)
+		event.Set("delta", delta)
+		event.Set("metadata", metadata)
+		return event
+	}
+
+	stockEvent := newEvent("stock_received",
+		map[string]any{"stock": stockBefore},
+		map[string]any{"stock": posting.StockAfter},
+		map[string]any{"stock": qty},
+	)
+	if err := tx.SaveRecord(stockEvent); err != nil {
+		return posting, fmt.Errorf("journal de %q : %w", posting.ProductName, err)
+	}
+	posting.EventID = stockEvent.Id
+
+	if posting.CostAfter != costBefore {
+		costEvent := newEvent("purchase_price_changed",
+			map[string]any{"purchase_price_ht": costBefore},
+			map[string]any{"purchase_price_ht": posting.CostAfter},
+			map[string]any{"purchase_price_ht": posting.CostAfter - costBefore},
+		)
+		if err := tx.SaveRecord(costEvent); err != nil {
+			return posting, fmt.Errorf("journal de %q : %w", posting.ProductName, err)
+		}
+	}
+
+	return posting, nil
+}
@@ -0,0 +1,49 @@
+package routes
+
+import "testing"
+
+// Le prix d'achat posé à la réception sert ensuite aux marges et à la
+// valorisation : une erreur ici se propage sans bruit.
+func TestReceiptCost(t *testing.T) {
+	cas := []struct {
+		nom                     string
+		methode                 string
+		stock, cout, recu, prix float64
+		attendu                 float64
+	}{
+		{"dernier prix : le prix reçu remplace", CostMethodLast, 10, 4, 5, 7, 7},
+		{"méthode vide : dernier prix", "", 10, 4, 5, 7, 7},
+		{"coût moyen pondéré", CostMethodWAC, 10, 4, 10, 6, 5},
+		{"coût moyen arrondi à quatre décimales", CostMethodWAC, 2, 1, 1, 2, 1.3333},
+		// Un stock nul ou négatif n'a pas de coût à moyenner
+		{"stock nul : prix reçu", CostMethodWAC, 0, 4, 5, 7, 7},
+		{"stock négatif : prix reçu", CostMethodWAC, -3, 4, 5, 7, 7},
+	}
+
+	for _, c := range cas {
+		if obtenu := ReceiptCost(c.methode, c.stock, c.cout, c.recu, c.prix); obtenu != c.attendu {
+			t.Errorf("%s : attendu %v, obtenu %v", c.nom, c.attendu, obtenu)
+		}
+	}
+}
+
+// Le statut décide si une commande peut encore être reçue ou annulée.
+func TestPurchaseOrderStatus(t *testing.T) {
+	cas := []struct {
+		nom     string
+		lignes  []PurchaseLineProgress
+		attendu string
+	}{
+		{"rien de reçu", []PurchaseLineProgress{{10, 0}, {5, 0}}, PurchaseStatusSent},
+		{"une ligne entamée", []PurchaseLineProgress{{10, 4}, {5, 0}}, PurchaseStatusPartiallyReceived},
+		{"une ligne soldée sur deux", []PurchaseLineProgress{{10, 10}, {5, 0}}, PurchaseStatusPartiallyReceived},
+		{"tout reçu", []PurchaseLineProgress{{10, 10}, {5, 5}}, PurchaseStatusReceived},
+		{"quantités décimales cumulées", []PurchaseLineProgress{{0.3, 0.1 + 0.2}}, PurchaseStatusReceived},
+	}
+
+	for _, c := range cas {
+		if obtenu := PurchaseOrderStatus(c.lignes); obtenu != c.attendu {
+			t.Errorf("%s : attendu %q, obtenu %q", c.nom, c.attendu, obtenu)
+		}
+	}
+}
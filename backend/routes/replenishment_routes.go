@@ -0,0 +1,393 @@
+// backend/routes/replenishment_routes.go
+//
+// LE RÉASSORT — quoi recommander, à qui, et combien.
+//
+//   POST /api/purchasing/replenishment   rapport par fournisseur (JSON), ou
+//                                        bon à envoyer (format "csv")
+//
+// ── LE CALCUL ─────────────────────────────────────────────────────────────
+// La vitesse de vente d'un produit est le net de ses `stock_sale` et
+// `stock_return` dans `product_events` sur les N dernières semaines, ramené
+// au jour. Sa couverture est le nombre de jours que son stock tient à cette
+// vitesse. Un produit est proposé s'il est sous `min_stock`, ou si sa
+// couverture est sous l'objectif ; la quantité proposée remonte le stock au
+// plus grand des deux, déduction faite de ce qui est déjà en commande
+// (commandes fournisseur non soldées, brouillons compris).
+//
+// Seuls les produits à stock géré (`manage_stock`) et hors `service` sont
+// pris en compte. Le journal peut désigner un produit par son id PocketBase
+// ou par sa clé NeDB (`legacy_id`) : les deux sont additionnés.
+//
+// Le rapport ne crée rien. La commande se crée en brouillon par l'API REST,
+// depuis l'écran, pour passer par la numérotation CF- (hook).
+
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Valeurs par défaut du rapport : deux mois de ventes, un mois de stock
+const (
+	defaultReplenishmentWeeks     = 8
+	defaultReplenishmentCoverDays = 30
+)
+
+// Raisons d'une proposition
+const (
+	ReplenishmentBelowMin   = "min_stock"
+	ReplenishmentUnderCover = "cover"
+)
+
+type replenishmentInput struct {
+	CompanyID string `json:"company_id"`
+	// Fenêtre de calcul de la vitesse de vente (1 à 52)
+	Weeks int `json:"weeks"`
+	// Objectif de couverture en jours (1 à 365)
+	CoverDays int `json:"cover_days"`
+	// Vide : tous les fournisseurs. "none" : les produits sans fournisseur.
+	SupplierID string `json:"supplier_id"`
+	// "json" (défaut) ou "csv"
+	Format string `json:"format"`
+}
+
+// ReplenishmentFacts — ce que le calcul sait d'un produit
+type ReplenishmentFacts struct {
+	Stock    float64
+	MinStock float64
+	// Reste à recevoir des commandes fournisseur ouvertes
+	Incoming float64
+	// Unités vendues nettes des retours sur la période
+	Sold       float64
+	PeriodDays float64
+	CoverDays  float64
+}
+
+// ReplenishmentSuggestion — le verdict pour un produit
+type ReplenishmentSuggestion struct {
+	DailySales float64 `json:"daily_sales"`
+	// nil quand le produit ne s'est pas vendu : sa couverture est infinie
+	DaysOfCover *float64 `json:"days_of_cover"`
+	// Vide : rien à recommander
+	Reason       string  `json:"reason"`
+	SuggestedQty float64 `json:"suggested_qty"`
+}
+
+// ReplenishmentLine — une ligne du rapport
+type ReplenishmentLine struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Sku         string  `json:"sku"`
+	Barcode     string  `json:"barcode"`
+	Stock       float64 `json:"stock"`
+	MinStock    float64 `json:"min_stock"`
+	Incoming    float64 `json:"incoming"`
+	Sold        float64 `json:"sold"`
+	UnitPriceHT float64 `json:"unit_price_ht"`
+	ReplenishmentSuggestion
+}
+
+// ReplenishmentGroup — les propositions d'un fournisseur
+type ReplenishmentGroup struct {
+	SupplierID   string              `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	SupplierCode string              `json:"supplier_code"`
+	Lines        []ReplenishmentLine `json:"lines"`
+	TotalHT      float64             `json:"total_ht"`
+}
+
+// SuggestReplenishment — le calcul, seul, pour être testé seul
+func SuggestReplenishment(f ReplenishmentFacts) ReplenishmentSuggestion {
+	var s ReplenishmentSuggestion
+	if f.PeriodDays > 0 && f.Sold > 0 {
+		s.DailySales = f.Sold / f.PeriodDays
+	}
+	if s.DailySales > 0 {
+		cover := math.Max(f.Stock, 0) / s.DailySales
+		cover = math.Round(cover*10) / 10
+		s.DaysOfCover = &cover
+	}
+
+	switch {
+	case f.MinStock > 0 && f.Stock < f.MinStock:
+		s.Reason = ReplenishmentBelowMin
+	case s.DaysOfCover != nil && *s.DaysOfCover < f.CoverDays:
+		s.Reason = ReplenishmentUnderCover
+	default:
+		return s
+	}
+
+	target := math.Max(f.MinStock, math.Ceil(s.DailySales*f.CoverDays))
+	if qty := math.Ceil(target - f.Stock - f.Incoming); qty > 0 {
+		s.SuggestedQty = qty
+	}
+	return s
+}
+
+func RegisterReplenishmentRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/purchasing/replenishment", func(c echo.Context) error {
+		var input replenishmentInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.Weeks == 0 {
+			input.Weeks = defaultReplenishmentWeeks
+		}
+		if input.CoverDays == 0 {
+			input.CoverDays = defaultReplenishmentCoverDays
+		}
+		if input.Weeks < 1 || input.Weeks > 52 {
+			return apis.NewBadRequestError("La période doit faire de 1 à 52 semaines", nil)
+		}
+		if input.CoverDays < 1 || input.CoverDays > 365 {
+			return apis.NewBadRequestError("L'objectif de couverture doit faire de 1 à 365 jours", nil)
+		}
+		if input.CompanyID == "" {
+			if companies := apis.RequestInfo(c).AuthRecord.GetStringSlice("company"); len(companies) > 0 {
+				input.CompanyID = companies[0]
+			}
+		}
+		if input.CompanyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+
+		groups, err := buildReplenishment(app.Dao(), input, time.Now())
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Calcul du réassort impossible", err)
+		}
+
+		if input.Format == "csv" {
+			name := "reassort"
+			if len(groups) == 1 && groups[0].SupplierCode != "" {
+				name += "-" + groups[0].SupplierCode
+			}
+			c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+			return c.Blob(http.StatusOK, "text/csv; charset=utf-8", ReplenishmentCSV(groups))
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"generated_at": types.NowDateTime(),
+			"weeks":        input.Weeks,
+			"cover_days":   input.CoverDays,
+			"suppliers":    groups,
+		})
+	}, apis.RequireRecordAuth())
+}
+
+// buildReplenishment assemble le rapport : produits, ventes de la période,
+// reste à recevoir, puis regroupement par fournisseur
+func buildReplenishment(dao *daos.Dao, input replenishmentInput, now time.Time) ([]ReplenishmentGroup, error) {
+	filter := "company = {:company} && manage_stock = true && type != 'service'"
+	params := dbx.Params{"company": input.CompanyID}
+	switch input.SupplierID {
+	case "":
+	case "none":
+		filter += " && supplier = ''"
+	default:
+		filter += " && supplier = {:supplier}"
+		params["supplier"] = input.SupplierID
+	}
+	products, err := dao.FindRecordsByFilter("products", filter, "name", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+
+	from := now.AddDate(0, 0, -7*input.Weeks)
+	sold, err := replenishmentSales(dao, from)
+	if err != nil {
+		return nil, err
+	}
+	incoming, err := replenishmentIncoming(dao, input.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySupplier := map[string]*ReplenishmentGroup{}
+	for _, p := range products {
+		facts := ReplenishmentFacts{
+			Stock:      p.GetFloat("stock"),
+			MinStock:   p.GetFloat("min_stock"),
+			Incoming:   incoming[p.Id],
+			Sold:       sold[p.Id],
+			PeriodDays: float64(7 * input.Weeks),
+			CoverDays:  float64(input.CoverDays),
+		}
+		if legacy := p.GetString("legacy_id"); legacy != "" && legacy != p.Id {
+			facts.Sold += sold[legacy]
+		}
+
+		suggestion := SuggestReplenishment(facts)
+		if suggestion.Reason == "" {
+			continue
+		}
+
+		supplierID := p.GetString("supplier")
+		group, ok := bySupplier[supplierID]
+		if !ok {
+			group = &ReplenishmentGroup{SupplierID: supplierID, Lines: []ReplenishmentLine{}}
+			bySupplier[supplierID] = group
+		}
+		line := ReplenishmentLine{
+			ProductID:               p.Id,
+			ProductName:             p.GetString("name"),
+			Sku:                     p.GetString("sku"),
+			Barcode:                 p.GetString("barcode"),
+			Stock:                   facts.Stock,
+			MinStock:                facts.MinStock,
+			Incoming:                facts.Incoming,
+			Sold:                    facts.Sold,
+			UnitPriceHT:             p.GetFloat("purchase_price_ht"),
+			ReplenishmentSuggestion: suggestion,
+		}
+		group.Lines = append(group.Lines, line)
+		group.TotalHT += line.SuggestedQty * line.UnitPriceHT
+	}
+
+	groups := make([]ReplenishmentGroup, 0, len(bySupplier))
+	for id, group := range bySupplier {
+		if id == "" {
+			group.SupplierName = "Sans fournisseur"
+		} else if supplier, err := dao.FindRecordById("suppliers", id); err == nil {
+			group.SupplierName = supplier.GetString("name")
+			group.SupplierCode = supplier.GetString("supplier_code")
+		}
+		group.TotalHT = math.Round(group.TotalHT*100) / 100
+		sortReplenishmentLines(group.Lines)
+		groups = append(groups, *group)
+	}
+	// Par nom de fournisseur ; les produits sans fournisseur en dernier
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].SupplierID == "") != (groups[j].SupplierID == "") {
+			return groups[j].SupplierID == ""
+		}
+		return strings.ToLower(groups[i].SupplierName) < strings.ToLower(groups[j].SupplierName)
+	})
+	return groups, nil
+}
+
+// sortReplenishmentLines : le plus urgent d'abord — sous le minimum, puis la
+// couverture la plus courte
+func sortReplenishmentLines(lines []ReplenishmentLine) {
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if (a.Reason == ReplenishmentBelowMin) != (b.Reason == ReplenishmentBelowMin) {
+			return a.Reason == ReplenishmentBelowMin
+		}
+		if a.DaysOfCover != nil && b.DaysOfCover != nil && *a.DaysOfCover != *b.DaysOfCover {
+			return *a.DaysOfCover < *b.DaysOfCover
+		}
+		return (a.DaysOfCover != nil) && (b.DaysOfCover == nil)
+	})
+}
+
+// replenishmentSales : unités vendues nettes des retours depuis `from`, par
+// clé produit telle que le journal l'a écrite (id ou legacy_id)
+func replenishmentSales(dao *daos.Dao, from time.Time) (map[string]float64, error) {
+	since, err := types.ParseDateTime(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ProductID string  `db:"product_id"`
+		Sold      float64 `db:"sold"`
+	}
+	err = dao.DB().
+		Select("product_id", "-COALESCE(SUM(json_extract(delta, '$.stock')), 0) AS sold").
+		From("product_events").
+		Where(dbx.In("event_type", "stock_sale", "stock_return")).
+		AndWhere(dbx.NewExp("occurred_at >= {:from}", dbx.Params{"from": since.String()})).
+		GroupBy("product_id").
+		All(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("ventes de la période : %w", err)
+	}
+
+	sold := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		sold[r.ProductID] = r.Sold
+	}
+	return sold, nil
+}
+
+// replenishmentIncoming : reste à recevoir par produit sur les commandes
+// fournisseur non soldées de la société. Les brouillons comptent : un
+// réassort déjà préparé ne doit pas être proposé deux fois.
+func replenishmentIncoming(dao *daos.Dao, companyID string) (map[string]float64, error) {
+	var rows []struct {
+		Product  string  `db:"product"`
+		Incoming float64 `db:"incoming"`
+	}
+	err := dao.DB().
+		Select("l.product AS product", "SUM(MAX(l.quantity_ordered - COALESCE(l.quantity_received, 0), 0)) AS incoming").
+		From("purchase_order_lines l").
+		InnerJoin("purchase_orders o", dbx.NewExp("o.id = l.purchase_order")).
+		Where(dbx.HashExp{"o.owner_company": companyID}).
+		AndWhere(dbx.In("o.status", PurchaseStatusDraft, PurchaseStatusSent, PurchaseStatusPartiallyReceived)).
+		GroupBy("l.product").
+		All(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("commandes en cours : %w", err)
+	}
+
+	incoming := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		incoming[r.Product] = r.Incoming
+	}
+	return incoming, nil
+}
+
+// ReplenishmentCSV — le bon de réassort pour le fournisseur. Seules les
+// lignes à commander y figurent.
+func ReplenishmentCSV(groups []ReplenishmentGroup) []byte {
+	rows := [][]string{{"Fournisseur", "Code fournisseur", "Référence", "Code-barres", "Désignation", "Quantité", "Prix unitaire HT", "Total HT"}}
+	for _, g := range groups {
+		for _, l := range g.Lines {
+			if l.SuggestedQty <= 0 {
+				continue
+			}
+			rows = append(rows, []string{
+				g.SupplierName,
+				g.SupplierCode,
+				l.Sku,
+				l.Barcode,
+				l.ProductName,
+				csvDecimal(l.SuggestedQty),
+				csvDecimal(l.UnitPriceHT),
+				csvDecimal(math.Round(l.SuggestedQty*l.UnitPriceHT*100) / 100),
+			})
+		}
+	}
+	return frenchCSV(rows)
+}
+
+// frenchCSV : séparateur « ; », comme l'ouvre un tableur réglé en français,
+// et BOM UTF-8 pour que les accents y survivent
+func frenchCSV(rows [][]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+	w.WriteAll(rows)
+	return buf.Bytes()
+}
+
+// csvDecimal : un nombre à virgule décimale
+func csvDecimal(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+}
@@ -0,0 +1,39 @@
+package routes
+
+import "testing"
+
+// La quantité proposée part telle quelle dans une commande fournisseur : une
+// erreur ici se commande.
+func TestSuggestReplenishment(t *testing.T) {
+	cas := []struct {
+		nom       string
+		faits     ReplenishmentFacts
+		raison    string
+		quantite  float64
+		sansVente bool
+	}{
+		{"sous le minimum, sans vente", ReplenishmentFacts{Stock: 2, MinStock: 5, PeriodDays: 56, CoverDays: 30},
+			ReplenishmentBelowMin, 3, true},
+		{"couverture courte : on remonte à l'objectif", ReplenishmentFacts{Stock: 10, Sold: 56, PeriodDays: 56, CoverDays: 30},
+			ReplenishmentUnderCover, 20, false},
+		{"couverture suffisante", ReplenishmentFacts{Stock: 40, Sold: 56, PeriodDays: 56, CoverDays: 30},
+			"", 0, false},
+		{"le plus grand des deux objectifs", ReplenishmentFacts{Stock: 1, MinStock: 50, Sold: 56, PeriodDays: 56, CoverDays: 30},
+			ReplenishmentBelowMin, 49, false},
+		{"déjà en commande : rien de plus", ReplenishmentFacts{Stock: 10, Incoming: 25, Sold: 56, PeriodDays: 56, CoverDays: 30},
+			ReplenishmentUnderCover, 0, false},
+		{"plus de retours que de ventes : pas de vitesse", ReplenishmentFacts{Stock: 0, Sold: -3, PeriodDays: 56, CoverDays: 30},
+			"", 0, true},
+		// Un stock négatif se rattrape aussi
+		{"stock négatif", ReplenishmentFacts{Stock: -4, MinStock: 2, PeriodDays: 56, CoverDays: 30},
+			ReplenishmentBelowMin, 6, true},
+	}
+
+	for _, c := range cas {
+		s := SuggestReplenishment(c.faits)
+		if s.Reason != c.raison || s.SuggestedQty != c.quantite || (s.DaysOfCover == nil) != c.sansVente {
+			t.Errorf("%s : attendu %q / %v, obtenu %q / %v (couverture %v)",
+				c.nom, c.raison, c.quantite, s.Reason, s.SuggestedQty, s.DaysOfCover)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// backend/routes/reservation_routes.go
+//
+// LES RÉSERVATIONS DE STOCK — ce qui est promis et ne se vend plus librement.
+//
+//   GET    /api/stock/reservations?company_id=&product_id=   les réservations en cours
+//   GET    /api/stock/availability?company_id=&product_ids=  stock, réservé, disponible
+//   PUT    /api/stock/reservations/cart/:ref                 un panier mis en attente réserve
+//   DELETE /api/stock/reservations/cart/:ref                 le panier est repris ou abandonné
+//   POST   /api/stock/reservations/:id/release               libération à la main
+//
+// ── CE QUI RÉSERVE ────────────────────────────────────────────────────────
+//   - un bon de commande confirmé, par ses hooks (hooks/order_hooks.go) : ses
+//     réservations suivent ses lignes et se libèrent à la livraison, à la
+//     facturation ou à l'annulation ;
+//   - un panier mis en attente en caisse, si la société a fixé une durée
+//     (`reservation_cart_minutes`) : le poste l'envoie à la mise en attente,
+//     le libère à la reprise. Un poste qui disparaît laisse expirer le sien.
+// Les règles sont dans backend/reservation.go. Une réservation ne déplace
+// pas de stock : disponible = stock − réservé, le stock reste le même nombre.
+//
+// ── LA CAISSE ─────────────────────────────────────────────────────────────
+// /api/pos/ticket compare chaque ligne au stock libre
+// (`checkTicketReservations`). Selon `companies.reservation_policy`, le
+// ticket passe avec `reservation_warnings` (warn) ou est refusé (block). Un
+// ticket rejoué après une coupure n'est jamais refusé : il est déjà encaissé.
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pocket-react/backend"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Un panier en attente se saisit à la main : au-delà, ce n'est plus un panier
+const maxCartReservationLines = 200
+
+// ReservationSummary — une réservation, telle que la caisse la montre
+type ReservationSummary struct {
+	ID          string  `json:"id"`
+	Kind        string  `json:"kind"`
+	Label       string  `json:"label"`
+	OrderID     string  `json:"order_id,omitempty"`
+	OrderNumber string  `json:"order_number,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	ExpiresAt   string  `json:"expires_at,omitempty"`
+}
+
+// ProductAvailability — le stock d'un produit et ce qui en est promis
+type ProductAvailability struct {
+	ProductID    string               `json:"product_id"`
+	ProductName  string               `json:"product_name"`
+	Stock        float64              `json:"stock"`
+	Reserved     float64              `json:"reserved"`
+	Available    float64              `json:"available"`
+	Reservations []ReservationSummary `json:"reservations"`
+}
+
+type cartReservationInput struct {
+	CompanyID    string                    `json:"company_id"`
+	CashRegister string                    `json:"cash_register_id"`
+	Label        string                    `json:"label"`
+	Items        []backend.ReservationLine `json:"items"`
+}
+
+func RegisterReservationRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.GET("/api/stock/reservations", func(c echo.Context) error {
+		companyID := c.QueryParam("company_id")
+		if companyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+		dao := app.Dao()
+		backend.ReleaseExpiredReservations(dao, companyID)
+
+		filter := "owner_company = {:company} && status = 'active'"
+		params := dbx.Params{"company": companyID}
+		if productID := c.QueryParam("product_id"); productID != "" {
+			filter += " && product = {:product}"
+			params["product"] = productID
+		}
+		records, err := dao.FindRecordsByFilter("stock_reservations", filter, "-created", 0, 0, params)
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Lecture des réservations impossible", err)
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"reservations": records,
+			"settings":     backend.GetReservationSettings(dao, companyID),
+		})
+	}, apis.RequireRecordAuth())
+
+	router.GET("/api/stock/availability", func(c echo.Context) error {
+		companyID := c.QueryParam("company_id")
+		if companyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+		keys := []string{}
+		for _, k := range strings.Split(c.QueryParam("product_ids"), ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) > maxCartReservationLines {
+			return apis.NewBadRequestError("trop de produits demandés", nil)
+		}
+
+		dao := app.Dao()
+		products := findReservableProducts(dao, keys)
+		availability, err := productAvailability(dao, companyID, products)
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Lecture des réservations impossible", err)
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"policy":   backend.GetReservationSettings(dao, companyID).Policy,
+			"products": availability,
+		})
+	}, apis.RequireRecordAuth())
+
+	router.PUT("/api/stock/reservations/cart/:ref", func(c echo.Context) error {
+		ref := strings.TrimSpace(c.PathParam("ref"))
+		var input cartReservationInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.CompanyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+		if len(input.Items) > maxCartReservationLines {
+			return apis.NewBadRequestError("trop de lignes dans un seul panier", nil)
+		}
+
+		settings := backend.GetReservationSettings(app.Dao(), input.CompanyID)
+		if settings.CartMinutes <= 0 {
+			// Les paniers en attente ne réservent pas dans cette société
+			return c.JSON(http.StatusOK, map[string]any{"reservations": []any{}})
+		}
+
+		user := apis.RequestInfo(c).AuthRecord
+		var saved []*models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			saved, err = reserveParkedCart(tx, ref, input, settings, user)
+			return err
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+		log.Printf("🛒 Panier en attente %s : %d réservation(s)", ref, len(saved))
+		return c.JSON(http.StatusOK, map[string]any{"reservations": saved})
+	}, apis.RequireRecordAuth())
+
+	router.DELETE("/api/stock/reservations/cart/:ref", func(c echo.Context) error {
+		records, err := app.Dao().FindRecordsByFilter(
+			"stock_reservations", "cart_ref = {:ref} && status = 'active'", "", 0, 0,
+			dbx.Params{"ref": c.PathParam("ref")},
+		)
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Lecture des réservations impossible", err)
+		}
+		for _, r := range records {
+			if err := backend.ReleaseReservation(app.Dao(), r, "unparked"); err != nil {
+				return apis.NewApiError(http.StatusInternalServerError, "Libération impossible", err)
+			}
+		}
+		return c.JSON(http.StatusOK, map[string]any{"released": len(records)})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/stock/reservations/:id/release", func(c echo.Context) error {
+		if !hasManagerRole(apis.RequestInfo(c).AuthRecord) {
+			return apis.NewForbiddenError("Libération d'une réservation réservée aux responsables", nil)
+		}
+		r, err := app.Dao().FindRecordById("stock_reservations", c.PathParam("id"))
+		if err != nil {
+			return apis.NewNotFoundError("Réservation introuvable", err)
+		}
+		if r.GetString("status") != "active" {
+			return apis.NewBadRequestError("Cette réservation est déjà libérée", nil)
+		}
+		if err := backend.ReleaseReservation(app.Dao(), r, "manual"); err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Libération impossible", err)
+		}
+		log.Printf("🔓 Réservation de %q (%s) libérée à la main",
+			r.GetString("product_name"), reservationLabel(r))
+		return c.JSON(http.StatusOK, r)
+	}, apis.RequireRecordAuth())
+}
+
+// reserveParkedCart remplace les réservations d'un panier en attente par ses
+// lignes actuelles ; l'échéance repart de la dernière mise en attente
+func reserveParkedCart(tx *daos.Dao, ref string, input cartReservationInput, settings backend.ReservationSettings, user *models.Record) ([]*models.Record, error) {
+	col, err := tx.FindCollectionByNameOrId("stock_reservations")
+	if err != nil {
+		return nil, err
+	}
+	existing, err := tx.FindRecordsByFilter(
+		"stock_reservations", "cart_ref = {:ref} && status = 'active'", "", 0, 0,
+		dbx.Params{"ref": ref},
+	)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := map[string]*models.Record{}
+	for _, r := range existing {
+		byProduct[r.GetString("product")] = r
+	}
+
+	items := make([]map[string]any, len(input.Items))
+	for i, it := range input.Items {
+		items[i] = map[string]any{"product_id": it.ProductID, "quantity": it.Quantity}
+	}
+	expires := types.NowDateTime().Time().Add(
+		time.Duration(settings.CartMinutes) * time.Minute)
+
+	saved := []*models.Record{}
+	for _, line := range backend.ReservationLines(items) {
+		product, err := tx.FindFirstRecordByFilter(
+			"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": line.ProductID})
+		if err != nil || product.GetString("type") == "service" {
+			continue // article libre ou service : rien à réserver
+		}
+		if product.GetString("company") != input.CompanyID {
+			return nil, apis.NewBadRequestError(
+				fmt.Sprintf("« %s » appartient à une autre société", product.GetString("name")), nil)
+		}
+		r, ok := byProduct[product.Id]
+		delete(byProduct, product.Id)
+		if !ok {
+			r = models.NewRecord(col)
+			r.Set("owner_company", input.CompanyID)
+			r.Set("product", product.Id)
+			r.Set("kind", "parked_cart")
+			r.Set("cart_ref", ref)
+			r.Set("status", "active")
+			if user != nil {
+				r.Set("created_by", user.Id)
+			}
+		}
+		r.Set("product_name", product.GetString("name"))
+		r.Set("quantity", line.Quantity)
+		r.Set("label", strings.TrimSpace(input.Label))
+		if input.CashRegister != "" {
+			r.Set("cash_register", input.CashRegister)
+		}
+		r.Set("expires_at", expires)
+		if err := tx.SaveRecord(r); err != nil {
+			return nil, fmt.Errorf("réservation de %q : %w", product.GetString("name"), err)
+		}
+		saved = append(saved, r)
+	}
+
+	// Lignes retirées du panier depuis sa dernière mise en attente
+	for _, r := range byProduct {
+		if err := backend.ReleaseReservation(tx, r, "unparked"); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// findReservableProducts retrouve les produits par identifiant PocketBase ou
+// legacy_id ; une clé inconnue est ignorée
+func findReservableProducts(dao *daos.Dao, keys []string) []*models.Record {
+	products := []*models.Record{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		product, err := dao.FindFirstRecordByFilter(
+			"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": key})
+		if err != nil || seen[product.Id] {
+			continue
+		}
+		seen[product.Id] = true
+		products = append(products, product)
+	}
+	return products
+}
+
+// productAvailability rend, pour chaque produit, son stock, ce qui en est
+// réservé et le reste disponible
+func productAvailability(dao *daos.Dao, companyID string, products []*models.Record) ([]ProductAvailability, error) {
+	ids := make([]string, len(products))
+	for i, p := range products {
+		ids[i] = p.Id
+	}
+	reservations, err := backend.ActiveReservations(dao, companyID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ProductAvailability, len(products))
+	index := map[string]int{}
+	for i, p := range products {
+		index[p.Id] = i
+		out[i] = ProductAvailability{
+			ProductID:    p.Id,
+			ProductName:  p.GetString("name"),
+			Stock:        p.GetFloat("stock"),
+			Reservations: []ReservationSummary{},
+		}
+	}
+	for _, r := range reservations {
+		a := &out[index[r.GetString("product")]]
+		a.Reserved += r.GetFloat("quantity")
+		summary := ReservationSummary{
+			ID:          r.Id,
+			Kind:        r.GetString("kind"),
+			Label:       r.GetString("label"),
+			OrderID:     r.GetString("order"),
+			OrderNumber: r.GetString("order_number"),
+			Quantity:    r.GetFloat("quantity"),
+		}
+		if d := r.GetDateTime("expires_at"); !d.IsZero() {
+			summary.ExpiresAt = d.String()
+		}
+		a.Reservations = append(a.Reservations, summary)
+	}
+	for i := range out {
+		out[i].Available = out[i].Stock - out[i].Reserved
+	}
+	return out, nil
+}
+
+// checkTicketReservations compare les lignes du ticket au stock libre. Rend
+// les avertissements, ou une erreur d'API si la société refuse la vente
+// d'unités réservées.
+func checkTicketReservations(dao *daos.Dao, input *PosTicketInput) ([]string, error) {
+	if input.OfflineID != "" {
+		return nil, nil // déjà encaissé : rien à refuser
+	}
+
+	quantities := map[string]float64{}
+	keys := []string{}
+	for _, item := range input.Items {
+		if item.ProductID == "" || item.Quantity <= 0 {
+			continue
+		}
+		if _, ok := quantities[item.ProductID]; !ok {
+			keys = append(keys, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	products := findReservableProducts(dao, keys)
+	if len(products) == 0 {
+		return nil, nil
+	}
+	availability, err := productAvailability(dao, input.OwnerCompany, products)
+	if err != nil {
+		log.Printf("⚠️ Réservations non vérifiées : %v", err)
+		return nil, nil
+	}
+
+	// Un produit peut arriver par son legacy_id : on recompte par identifiant
+	sold := map[string]float64{}
+	for _, p := range products {
+		sold[p.Id] += quantities[p.Id] + quantities[p.GetString("legacy_id")]
+	}
+
+	warnings := []string{}
+	for _, a := range availability {
+		short := backend.ReservedShortfall(a.Stock, a.Reserved, sold[a.ProductID])
+		if short <= 0 {
+			continue
+		}
+		holders := make([]string, len(a.Reservations))
+		for i, r := range a.Reservations {
+			holders[i] = summaryLabel(r)
+		}
+		warnings = append(warnings, fmt.Sprintf("« %s » : %g unité(s) réservée(s) (%s)",
+			a.ProductName, short, strings.Join(holders, ", ")))
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	if backend.GetReservationSettings(dao, input.OwnerCompany).Blocks() {
+		return nil, apis.NewBadRequestError(
+			"Unités réservées : "+strings.Join(warnings, " ; ")+
+				". Livrez ou annulez la commande, ou libérez la réservation, avant de vendre.", nil)
+	}
+	log.Printf("⚠️ Vente d'unités réservées : %s", strings.Join(warnings, " ; "))
+	return warnings, nil
+}
+
+func summaryLabel(r ReservationSummary) string {
+	switch {
+	case r.OrderNumber != "" && r.Label != "":
+		return r.OrderNumber + " " + r.Label
+	case r.OrderNumber != "":
+		return r.OrderNumber
+	case r.Label != "":
+		return "panier en attente " + r.Label
+	default:
+		return "panier en attente"
+	}
+}
+
+func reservationLabel(r *models.Record) string {
+	return summaryLabel(ReservationSummary{
+		OrderNumber: r.GetString("order_number"),
+		Label:       r.GetString("label"),
+	})
+}
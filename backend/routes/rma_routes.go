@@ -0,0 +1,593 @@
+// backend/routes/rma_routes.go
+//
+// LES RETOURS FOURNISSEUR ET RÉPARATIONS (RMA) — l'article défectueux, de
+// son arrivée à son retour.
+//
+//   POST /api/rma             ouvre un RMA ; sorti du stock, l'article
+//                             entre au bac RMA
+//   POST /api/rma/:id/step    une étape : ship, repaired, replaced,
+//                             credited, return, cancel
+//
+// La liste, la fiche et ce qui ne touche pas au stock (fournisseur, numéro
+// d'accord, suivi, avoir reçu et son fichier, notes) passent par l'API REST
+// de la collection `rma`. Les règles du cycle sont dans backend/rma.go.
+//
+// ── LE STOCK ──────────────────────────────────────────────────────────────
+// Seul un article sorti de notre stock (origine `stock`) y bouge. Il attend
+// au bac RMA : l'emplacement `rma` de la société, créé au premier RMA de ce
+// genre (`rmaStockLocation`). Comme l'atelier, il compte dans
+// `products.stock` : l'article est à nous, il vaut quelque chose tant qu'il
+// n'est ni expédié ni crédité. Chaque étape qui le déplace passe par
+// `moveLocationStock` et journalise un `stock_rma` dans la transaction :
+//   - ouverture : de l'emplacement d'origine au bac (le total ne bouge pas) ;
+//   - expédition : du bac au fournisseur (le total baisse) ;
+//   - réparé ou remplacé : du fournisseur au bac ;
+//   - rendu, ou annulé avant expédition : du bac à l'emplacement d'origine
+//     (ou à celui choisi).
+// Une société sans emplacement n'a pas de bac : seul le total bouge, et un
+// article qui va d'un endroit du magasin à l'autre ne le change pas.
+//
+// L'article d'un client (origine `customer`) n'est pas à nous : il n'entre ni
+// au bac ni dans `products.stock`, la réparation ne le rend pas vendable. Le
+// statut du RMA et son historique disent seuls où il est.
+//
+// ── LES NUMÉROS DE SÉRIE ──────────────────────────────────────────────────
+// Une unité sortie du stock passe à `rma` : la caisse ne la propose plus. Elle
+// revient `in_stock` quand on la remet en rayon ; remplacée, c'est l'unité de
+// remplacement (`replacement_serial`) qui entre en stock, l'ancienne reste
+// chez le fournisseur. L'unité d'un client reste à son nom.
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pocket-react/backend"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type rmaCreateInput struct {
+	CompanyID string `json:"company_id"`
+	Kind      string `json:"kind"`   // supplier_return | repair
+	Origin    string `json:"origin"` // customer | stock
+	// Identifiant PocketBase OU legacy_id ; vide si l'unité est nommée
+	ProductID    string  `json:"product_id"`
+	Quantity     float64 `json:"quantity"`
+	SerialID     string  `json:"serial_id"`
+	SerialNumber string  `json:"serial_number"`
+	CustomerID   string  `json:"customer_id"`
+	InvoiceID    string  `json:"invoice_id"`
+	SupplierID   string  `json:"supplier_id"`
+	// Origine `stock` : d'où l'article est sorti, sinon l'emplacement par défaut
+	LocationID string `json:"location_id"`
+	Fault      string `json:"fault"`
+	Notes      string `json:"notes"`
+}
+
+type rmaStepInput struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+	// Retour en stock ailleurs que d'où l'article est sorti
+	LocationID        string   `json:"location_id"`
+	SupplierReference string   `json:"supplier_reference"`
+	TrackingNumber    string   `json:"tracking_number"`
+	ReplacementSerial string   `json:"replacement_serial"`
+	CreditNoteNumber  string   `json:"credit_note_number"`
+	CreditNoteAmount  *float64 `json:"credit_note_amount_ht"`
+	CreditNoteDate    string   `json:"credit_note_date"`
+}
+
+func RegisterRmaRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/rma", func(c echo.Context) error {
+		var input rmaCreateInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		if input.CompanyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+		if input.Kind == "" {
+			input.Kind = "supplier_return"
+		}
+		if input.Kind != "supplier_return" && input.Kind != "repair" {
+			return apis.NewBadRequestError("kind invalide (supplier_return ou repair)", nil)
+		}
+		if input.Origin != "customer" && input.Origin != "stock" {
+			return apis.NewBadRequestError("origin invalide (customer ou stock)", nil)
+		}
+		if input.Quantity == 0 {
+			input.Quantity = 1
+		}
+		if input.Quantity < 0 {
+			return apis.NewBadRequestError("Quantité invalide", nil)
+		}
+
+		user := apis.RequestInfo(c).AuthRecord
+		var rma *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			rma, err = openRma(tx, input, user)
+			return err
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("🔧 RMA %s ouvert : %g × %q", rma.GetString("number"),
+			rma.GetFloat("quantity"), rma.GetString("product_name"))
+		return c.JSON(http.StatusOK, rma)
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/rma/:id/step", func(c echo.Context) error {
+		var input rmaStepInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+
+		user := apis.RequestInfo(c).AuthRecord
+		var rma *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			rma, err = tx.FindRecordById("rma", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("RMA introuvable", err)
+			}
+			return stepRma(tx, rma, input, user)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("🔧 RMA %s : %s", rma.GetString("number"), rma.GetString("status"))
+		return c.JSON(http.StatusOK, rma)
+	}, apis.RequireRecordAuth())
+}
+
+// openRma crée le RMA et fait entrer l'article au bac, dans la transaction
+func openRma(tx *daos.Dao, input rmaCreateInput, user *models.Record) (*models.Record, error) {
+	col, err := tx.FindCollectionByNameOrId("rma")
+	if err != nil {
+		return nil, err
+	}
+	rma := models.NewRecord(col)
+	rma.RefreshId()
+
+	// L'unité nommée donne le produit, et pour un client sa vente
+	var unit *models.Record
+	if input.SerialID != "" || strings.TrimSpace(input.SerialNumber) != "" {
+		unit, err = findRmaSerial(tx, input)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case input.Origin == "stock" && unit.GetString("status") != "in_stock":
+			return nil, apis.NewBadRequestError(
+				fmt.Sprintf("L'unité %s n'est pas en stock", unit.GetString("serial_number")), nil)
+		case input.Origin == "customer" && unit.GetString("status") == "in_stock":
+			return nil, apis.NewBadRequestError(
+				fmt.Sprintf("L'unité %s est en stock : elle n'a pas été vendue", unit.GetString("serial_number")), nil)
+		}
+		input.ProductID = unit.GetString("product")
+		input.Quantity = 1
+		if input.Origin == "customer" {
+			if input.CustomerID == "" {
+				input.CustomerID = unit.GetString("customer")
+			}
+			if input.InvoiceID == "" {
+				input.InvoiceID = unit.GetString("invoice")
+			}
+		}
+	}
+
+	if input.ProductID == "" {
+		return nil, apis.NewBadRequestError("product_id ou numéro de série requis", nil)
+	}
+	product, err := tx.FindFirstRecordByFilter(
+		"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": input.ProductID})
+	if err != nil {
+		return nil, apis.NewBadRequestError(fmt.Sprintf("Produit %s introuvable", input.ProductID), nil)
+	}
+	companyID := product.GetString("company")
+	if companyID != input.CompanyID {
+		return nil, apis.NewBadRequestError(
+			fmt.Sprintf("%q appartient à une autre société", product.GetString("name")), nil)
+	}
+	if product.GetBool("serialized") && unit == nil {
+		return nil, apis.NewBadRequestError(
+			fmt.Sprintf("%q est suivi par numéro de série : nommez l'unité", product.GetString("name")), nil)
+	}
+
+	if input.SupplierID == "" {
+		input.SupplierID = product.GetString("supplier")
+	}
+	if input.SupplierID != "" {
+		supplier, err := tx.FindRecordById("suppliers", input.SupplierID)
+		if err != nil || supplier.GetString("company") != companyID {
+			return nil, apis.NewBadRequestError("Fournisseur introuvable", nil)
+		}
+	}
+	if input.InvoiceID != "" {
+		invoice, err := tx.FindRecordById("invoices", input.InvoiceID)
+		if err != nil || invoice.GetString("owner_company") != companyID {
+			return nil, apis.NewBadRequestError("Facture d'origine introuvable", nil)
+		}
+		rma.Set("invoice", invoice.Id)
+		rma.Set("invoice_number", invoice.GetString("number"))
+		if input.CustomerID == "" {
+			input.CustomerID = invoice.GetString("customer")
+		}
+	}
+	if input.CustomerID != "" {
+		if _, err := tx.FindRecordById("customers", input.CustomerID); err != nil {
+			return nil, apis.NewBadRequestError("Client introuvable", nil)
+		}
+		rma.Set("customer", input.CustomerID)
+	}
+
+	// L'article d'un client ne passe pas par le bac
+	if input.Origin == "stock" {
+		rmaLocation, err := rmaStockLocation(tx, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if rmaLocation != nil {
+			location, err := resolveStockLocation(tx, companyID, input.LocationID)
+			if err != nil {
+				return nil, apis.NewBadRequestError(err.Error(), nil)
+			}
+			if location.Id == rmaLocation.Id {
+				return nil, apis.NewBadRequestError("L'article est déjà au bac RMA", nil)
+			}
+			rma.Set("location", location.Id)
+			rma.Set("rma_location", rmaLocation.Id)
+		}
+	}
+
+	now := types.NowDateTime()
+	number, year := nextRmaNumber(tx, companyID, now.Time())
+	rma.Set("owner_company", companyID)
+	rma.Set("number", number)
+	rma.Set("fiscal_year", year)
+	rma.Set("kind", input.Kind)
+	rma.Set("origin", input.Origin)
+	rma.Set("status", "opened")
+	rma.Set("supplier", input.SupplierID)
+	rma.Set("product", product.Id)
+	rma.Set("product_name", product.GetString("name"))
+	rma.Set("quantity", input.Quantity)
+	rma.Set("fault", strings.TrimSpace(input.Fault))
+	rma.Set("notes", strings.TrimSpace(input.Notes))
+	rma.Set("opened_at", now)
+	if unit != nil {
+		rma.Set("serial", unit.Id)
+		rma.Set("serial_number", unit.GetString("serial_number"))
+		if input.Origin == "stock" {
+			unit.Set("status", "rma")
+			if err := tx.SaveRecord(unit); err != nil {
+				return nil, err
+			}
+		}
+	}
+	operator := inventoryOperatorName(user, "")
+	if user != nil {
+		rma.Set("created_by", user.Id)
+	}
+	appendRmaHistory(rma, backend.RmaOpen, operator, input.Fault, now)
+
+	if err := moveRmaStock(tx, rma, product, backend.RmaOpen, nil, operator, now); err != nil {
+		return nil, err
+	}
+	if err := tx.SaveRecord(rma); err != nil {
+		return nil, err
+	}
+	return rma, nil
+}
+
+// stepRma fait passer le RMA à l'étape demandée et déplace l'article
+func stepRma(tx *daos.Dao, rma *models.Record, input rmaStepInput, user *models.Record) error {
+	status, err := backend.RmaTransition(rma.GetString("status"), input.Action)
+	if err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+	product, err := tx.FindRecordById("products", rma.GetString("product"))
+	if err != nil {
+		return apis.NewBadRequestError("Le produit du RMA n'existe plus", nil)
+	}
+
+	now := types.NowDateTime()
+	setIfGiven := func(field, value string) {
+		if v := strings.TrimSpace(value); v != "" {
+			rma.Set(field, v)
+		}
+	}
+	setIfGiven("supplier_reference", input.SupplierReference)
+	setIfGiven("tracking_number", input.TrackingNumber)
+	setIfGiven("replacement_serial", backend.NormalizeSerial(input.ReplacementSerial))
+	setIfGiven("credit_note_number", input.CreditNoteNumber)
+	if input.CreditNoteAmount != nil {
+		rma.Set("credit_note_amount_ht", *input.CreditNoteAmount)
+	}
+	if input.CreditNoteDate != "" {
+		rma.Set("credit_note_date", input.CreditNoteDate)
+	}
+
+	// Sur un retour en stock, l'emplacement choisi remplace celui d'origine
+	var returnTo *models.Record
+	switch input.Action {
+	case backend.RmaShip:
+		if rma.GetString("supplier") == "" {
+			return apis.NewBadRequestError("Choisissez le fournisseur ou le réparateur avant d'expédier", nil)
+		}
+		rma.Set("shipped_at", now)
+	case backend.RmaRepaired, backend.RmaReplaced:
+		rma.Set("resolved_at", now)
+	case backend.RmaCredited:
+		if rma.GetString("credit_note_number") == "" {
+			return apis.NewBadRequestError("Numéro de l'avoir fournisseur requis", nil)
+		}
+		rma.Set("resolved_at", now)
+		rma.Set("closed_at", now)
+	case backend.RmaReturn, backend.RmaCancel:
+		if rma.GetString("origin") == "stock" && input.LocationID != "" && rma.GetString("rma_location") != "" {
+			returnTo, err = resolveStockLocation(tx, rma.GetString("owner_company"), input.LocationID)
+			if err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+			if returnTo.Id == rma.GetString("rma_location") || returnTo.GetBool("archived") {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("L'article ne peut pas être remis à %s", returnTo.GetString("name")), nil)
+			}
+			rma.Set("location", returnTo.Id)
+		}
+		rma.Set("closed_at", now)
+	}
+
+	operator := inventoryOperatorName(user, "")
+	if err := moveRmaStock(tx, rma, product, input.Action, returnTo, operator, now); err != nil {
+		return err
+	}
+	if err := restockRmaSerial(tx, rma, input.Action, status, now); err != nil {
+		return err
+	}
+
+	rma.Set("status", status)
+	appendRmaHistory(rma, input.Action, operator, input.Note, now)
+	return tx.SaveRecord(rma)
+}
+
+// moveRmaStock applique au stock le mouvement d'une étape et le journalise.
+// `returnTo` remplace l'emplacement d'origine du RMA s'il est donné.
+func moveRmaStock(tx *daos.Dao, rma, product *models.Record, action string, returnTo *models.Record, operator string, now types.DateTime) error {
+	qty := rma.GetFloat("quantity")
+	move := backend.RmaMove(rma.GetString("origin"), action, qty)
+	if move.Delta == 0 || product.GetString("type") == "service" {
+		return nil
+	}
+
+	stockBefore := product.GetFloat("stock")
+	metadata := map[string]any{
+		"rma_id":      rma.Id,
+		"rma_number":  rma.GetString("number"),
+		"action":      action,
+		"counterpart": move.Counterpart,
+		"quantity":    qty,
+	}
+
+	if rmaLocationID := rma.GetString("rma_location"); rmaLocationID != "" {
+		bin, err := tx.FindRecordById("stock_locations", rmaLocationID)
+		if err != nil {
+			return fmt.Errorf("bac RMA introuvable")
+		}
+		delta := move.Delta
+		before, after, err := moveLocationStock(tx, product, bin, StockMovementInput{Delta: &delta})
+		if err != nil {
+			return err
+		}
+		if after < 0 {
+			return apis.NewBadRequestError(fmt.Sprintf(
+				"%q n'est plus au bac RMA (%v) : corrigez par un inventaire", product.GetString("name"), before), nil)
+		}
+		metadata["rma_location_id"] = bin.Id
+		metadata["rma_location_before"] = before
+		metadata["rma_location_after"] = after
+
+		if move.Counterpart == "stock" {
+			location := returnTo
+			if location == nil {
+				if location, err = resolveStockLocation(tx, rma.GetString("owner_company"), rma.GetString("location")); err != nil {
+					return apis.NewBadRequestError(err.Error(), nil)
+				}
+			}
+			other := -move.Delta
+			before, after, err := moveLocationStock(tx, product, location, StockMovementInput{Delta: &other})
+			if err != nil {
+				return err
+			}
+			// Comme un transfert : on ne sort pas ce qu'on n'a pas
+			if after < 0 {
+				return apis.NewBadRequestError(fmt.Sprintf(
+					"Stock insuffisant pour %q à %s : %v disponible(s), %v demandé(s)",
+					product.GetString("name"), location.GetString("name"), before, qty), nil)
+			}
+			metadata["location_id"] = location.Id
+			metadata["location_stock_before"] = before
+			metadata["location_stock_after"] = after
+		}
+	} else if move.Counterpart != "stock" {
+		product.Set("stock", NextStock(stockBefore, StockMovementInput{Delta: &move.Delta}))
+	}
+
+	stockAfter := product.GetFloat("stock")
+	backend.MarkProductJournaled(product) // événement stock_rma ci-dessous
+	if err := tx.SaveRecord(product); err != nil {
+		return fmt.Errorf("produit %q : %w", product.GetString("name"), err)
+	}
+
+	eventsCol, err := tx.FindCollectionByNameOrId("product_events")
+	if err != nil {
+		return err
+	}
+	event := models.NewRecord(eventsCol)
+	event.Set("product_id", product.Id)
+	event.Set("product_name_snapshot", product.GetString("name"))
+	event.Set("product_sku_snapshot", product.GetString("sku"))
+	event.Set("event_type", "stock_rma")
+	event.Set("source", "rma")
+	event.Set("source_id", rma.Id)
+	event.Set("operator", operator)
+	event.Set("occurred_at", now)
+	event.Set("before", map[string]any{"stock": stockBefore})
+	event.Set("after", map[string]any{"stock": stockAfter})
+	event.Set("delta", map[string]any{"stock": stockAfter - stockBefore})
+	event.Set("metadata", metadata)
+	if err := tx.SaveRecord(event); err != nil {
+		return fmt.Errorf("journal de %q : %w", product.GetString("name"), err)
+	}
+	return nil
+}
+
+// restockRmaSerial remet en stock l'unité d'un RMA d'origine `stock` qui
+// revient en rayon : la même si elle est réparée ou si le RMA est annulé,
+// l'unité de remplacement sinon
+func restockRmaSerial(tx *daos.Dao, rma *models.Record, action, status string, now types.DateTime) error {
+	unitID := rma.GetString("serial")
+	if unitID == "" || rma.GetString("origin") != "stock" ||
+		(action != backend.RmaReturn && action != backend.RmaCancel) {
+		return nil
+	}
+	unit, err := tx.FindRecordById("product_serials", unitID)
+	if err != nil {
+		return nil // unité supprimée entre-temps : le RMA garde son numéro
+	}
+
+	replacement := rma.GetString("replacement_serial")
+	if rma.GetString("status") != "replaced" || replacement == "" || replacement == unit.GetString("serial_number") {
+		unit.Set("status", "in_stock")
+		return tx.SaveRecord(unit)
+	}
+
+	if existing, err := tx.FindFirstRecordByFilter(
+		"product_serials", "product = {:product} && serial_number = {:serial}",
+		dbx.Params{"product": unit.GetString("product"), "serial": replacement},
+	); err == nil && existing != nil {
+		return apis.NewBadRequestError(
+			fmt.Sprintf("Le numéro %s existe déjà pour ce produit", replacement), nil)
+	}
+	col, err := tx.FindCollectionByNameOrId("product_serials")
+	if err != nil {
+		return err
+	}
+	fresh := models.NewRecord(col)
+	fresh.Set("owner_company", unit.GetString("owner_company"))
+	fresh.Set("product", unit.GetString("product"))
+	fresh.Set("product_name", unit.GetString("product_name"))
+	fresh.Set("serial_number", replacement)
+	fresh.Set("lot_number", unit.GetString("lot_number"))
+	fresh.Set("status", "in_stock")
+	fresh.Set("received_at", now)
+	fresh.Set("notes", fmt.Sprintf("Remplacement de %s (RMA %s)",
+		unit.GetString("serial_number"), rma.GetString("number")))
+	return tx.SaveRecord(fresh)
+}
+
+// findRmaSerial retrouve l'unité par son identifiant, ou par son numéro dans
+// la société
+func findRmaSerial(tx *daos.Dao, input rmaCreateInput) (*models.Record, error) {
+	if input.SerialID != "" {
+		unit, err := tx.FindRecordById("product_serials", input.SerialID)
+		if err != nil || unit.GetString("owner_company") != input.CompanyID {
+			return nil, apis.NewNotFoundError("Numéro de série introuvable", err)
+		}
+		return unit, nil
+	}
+	serial := backend.NormalizeSerial(input.SerialNumber)
+	units, err := tx.FindRecordsByFilter(
+		"product_serials", "owner_company = {:company} && serial_number = {:serial}", "", 2, 0,
+		dbx.Params{"company": input.CompanyID, "serial": serial},
+	)
+	if err != nil || len(units) == 0 {
+		return nil, apis.NewNotFoundError(fmt.Sprintf("Numéro de série %s introuvable", serial), err)
+	}
+	if len(units) > 1 {
+		return nil, apis.NewBadRequestError(
+			fmt.Sprintf("Le numéro %s existe pour plusieurs produits : choisissez l'unité", serial), nil)
+	}
+	return units[0], nil
+}
+
+// rmaStockLocation : le bac RMA de la société, créé au premier besoin. nil si
+// la société n'a pas d'emplacement : le RMA ne touche alors que le total.
+func rmaStockLocation(tx *daos.Dao, companyID string) (*models.Record, error) {
+	def, err := defaultStockLocation(tx, companyID)
+	if err != nil || def == nil {
+		return nil, err
+	}
+	if bins, err := tx.FindRecordsByFilter(
+		"stock_locations", "owner_company = {:company} && kind = 'rma' && archived = false", "created", 1, 0,
+		dbx.Params{"company": companyID},
+	); err == nil && len(bins) > 0 {
+		return bins[0], nil
+	}
+
+	col, err := tx.FindCollectionByNameOrId("stock_locations")
+	if err != nil {
+		return nil, err
+	}
+	bin := models.NewRecord(col)
+	bin.Set("owner_company", companyID)
+	bin.Set("name", "Retours SAV")
+	bin.Set("code", "RMA")
+	bin.Set("kind", "rma")
+	bin.Set("is_default", false)
+	bin.Set("archived", false)
+	if err := tx.SaveRecord(bin); err != nil {
+		return nil, err
+	}
+	log.Printf("📍 Bac RMA créé pour la société %s", companyID)
+	return bin, nil
+}
+
+func appendRmaHistory(rma *models.Record, action, operator, note string, now types.DateTime) {
+	var history []map[string]any
+	if err := rma.UnmarshalJSONField("history", &history); err != nil {
+		history = nil
+	}
+	entry := map[string]any{
+		"action":   action,
+		"status":   rma.GetString("status"),
+		"at":       now.String(),
+		"operator": operator,
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		entry["note"] = note
+	}
+	rma.Set("history", append(history, entry))
+}
+
+// nextRmaNumber : RMA-YYYY-XXXX, lu dans la transaction, comme les numéros de
+// transfert
+func nextRmaNumber(tx *daos.Dao, companyID string, now time.Time) (string, int) {
+	year := now.Year()
+	prefix := fmt.Sprintf("RMA-%d-", year)
+	seq := 1
+	if records, err := tx.FindRecordsByFilter(
+		"rma", "owner_company = {:company} && fiscal_year = {:year}", "-number", 1, 0,
+		dbx.Params{"company": companyID, "year": year},
+	); err == nil && len(records) > 0 {
+		var last int
+		fmt.Sscanf(strings.TrimPrefix(records[0].GetString("number"), prefix), "%d", &last)
+		seq = last + 1
+	}
+	return fmt.Sprintf("%s%04d", prefix, seq), year
+}
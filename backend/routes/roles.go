@@ -0,0 +1,53 @@
+// backend/routes/roles.go
+// Contrôles de rôle partagés par les routes métier.
+// Rôles possibles sur users.role : admin, manager, caissier, user.
+
+package routes
+
+import "github.com/pocketbase/pocketbase/models"
+
+// hasManagerRole indique si l'utilisateur peut effectuer une opération de
+// responsable (ajustement, justification d'écart…) : admin ou manager.
+func hasManagerRole(user *models.Record) bool {
+	if user == nil {
+		return false
+	}
+	role := user.GetString("role")
+	return role == "admin" || role == "manager"
+}
+
+// roleRank classe les rôles du moins au plus habilité
+var roleRank = map[string]int{
+	"user":     1,
+	"caissier": 2,
+	"manager":  3,
+	"admin":    4,
+}
+
+// hasRoleAtLeast indique si l'utilisateur a au moins le rôle demandé (un
+// rôle inconnu, de l'utilisateur ou demandé, n'autorise rien)
+func hasRoleAtLeast(user *models.Record, minRole string) bool {
+	if user == nil {
+		return false
+	}
+	need, known := roleRank[minRole]
+	if !known {
+		return false
+	}
+	rank, ok := roleRank[user.GetString("role")]
+	return ok && rank >= need
+}
+
+// belongsToCompany indique si la société fait partie de celles de
+// l'utilisateur (users.company, relation multiple)
+func belongsToCompany(user *models.Record, companyID string) bool {
+	if user == nil || companyID == "" {
+		return false
+	}
+	for _, id := range user.GetStringSlice("company") {
+		if id == companyID {
+			return true
+		}
+	}
+	return false
+}
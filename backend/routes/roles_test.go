@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// Un rôle minimum mal saisi sur la caisse ne doit autoriser personne, pas
+// tout le monde.
+func TestRoleMinimum(t *testing.T) {
+	cas := []struct {
+		nom     string
+		role    string
+		minimum string
+		attendu bool
+	}{
+		{"caissier sur caissier", "caissier", "caissier", true},
+		{"caissier sur manager", "caissier", "manager", false},
+		{"admin sur manager", "admin", "manager", true},
+		{"rôle utilisateur inconnu", "stagiaire", "caissier", false},
+		{"rôle minimum inconnu", "admin", "gérant", false},
+		{"rôle minimum vide", "admin", "", false},
+	}
+	for _, c := range cas {
+		user := models.NewRecord(&models.Collection{Name: "users"})
+		user.Set("role", c.role)
+		if got := hasRoleAtLeast(user, c.minimum); got != c.attendu {
+			t.Errorf("%s : attendu %v, obtenu %v", c.nom, c.attendu, got)
+		}
+	}
+}
@@ -2,11 +2,18 @@
 package routes
 
 import (
+	"fmt"
+	"math"
 	"net/http"
 	"pocket-react/backend/pos"
+	"strings"
 
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
 	"golang.org/x/net/websocket"
 )
 
@@ -15,6 +22,20 @@ func RegisterScannerRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 	// Groupe /api/scanner
 	scannerGroup := router.Group("/api/scanner")
 
+	// Chaque scan est décodé puis cherché dans la base avant diffusion
+	pos.Scanner.SetResolver(func(code pos.ScanCode) pos.ScanEvent {
+		return resolveScan(pb.Dao(), code)
+	})
+
+	// Résoudre un code (scan HID saisi au clavier, saisie manuelle)
+	scannerGroup.GET("/resolve", func(c echo.Context) error {
+		code := c.QueryParam("code")
+		if strings.TrimSpace(code) == "" {
+			return apis.NewBadRequestError("code requis", nil)
+		}
+		return c.JSON(http.StatusOK, pos.Scanner.Resolve(code))
+	}, apis.RequireRecordAuth())
+
 	// Status de la scanette
 	scannerGroup.GET("/status", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, pos.Scanner.GetStatus())
@@ -165,10 +186,11 @@ func RegisterScannerRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 			// Boucle principale : envoyer les scans
 			for {
 				select {
-				case barcode := <-scanChan:
+				case event := <-scanChan:
 					err := websocket.JSON.Send(ws, map[string]interface{}{
 						"type":    "scan",
-						"barcode": barcode,
+						"barcode": event.Code.Raw,
+						"event":   event,
 					})
 					if err != nil {
 						return
@@ -181,3 +203,151 @@ func RegisterScannerRoutes(pb *pocketbase.PocketBase, router *echo.Echo) {
 		return nil
 	})
 }
+
+// Produits retenus pour un scan : un par entreprise au plus
+const maxScanProducts = 10
+
+// resolveScan cherche un code décodé : ticket, avoir, ou produit par
+// products.barcode puis products.sku
+func resolveScan(dao *daos.Dao, code pos.ScanCode) pos.ScanEvent {
+	event := pos.ScanEvent{Type: pos.ScanEventUnknown, Code: code}
+
+	switch code.Kind {
+	case pos.ScanKindTicket:
+		ticket, err := findPosTicketByCode(dao, code.TicketCode, "")
+		if err != nil {
+			event.Error = err.Error()
+			return event
+		}
+		event.Type = pos.ScanEventTicket
+		event.Document = scanDocument(ticket)
+		return event
+
+	case pos.ScanKindVoucher:
+		vouchers, err := dao.FindRecordsByFilter(
+			"invoices",
+			"number = {:number} && invoice_type = 'credit_note'",
+			"-created", 2, 0,
+			dbx.Params{"number": code.Raw},
+		)
+		if err != nil || len(vouchers) == 0 {
+			event.Error = fmt.Sprintf("aucun avoir %s", code.Raw)
+			return event
+		}
+		if len(vouchers) > 1 {
+			event.Error = fmt.Sprintf("plusieurs avoirs %s : précisez l'entreprise", code.Raw)
+			return event
+		}
+		event.Type = pos.ScanEventVoucher
+		event.Document = scanDocument(vouchers[0])
+		return event
+	}
+
+	products, matched := findScannedProducts(dao, code)
+	if len(products) == 0 {
+		return event
+	}
+
+	event.Type = pos.ScanEventProduct
+	for _, p := range products {
+		sp := pos.ScanProduct{
+			ID:           p.Id,
+			Company:      p.GetString("company"),
+			Name:         p.GetString("name"),
+			Sku:          p.GetString("sku"),
+			Barcode:      p.GetString("barcode"),
+			PriceTtc:     p.GetFloat("price_ttc"),
+			Quantity:     1,
+			UnitPriceTtc: p.GetFloat("price_ttc"),
+		}
+		// Valeur embarquée, sauf si la fiche porte le code exact (article à
+		// prix fixe)
+		if matched[p.Id] != code.Raw {
+			switch {
+			case code.WeightKg > 0:
+				sp.Quantity = code.WeightKg
+			case code.Price > 0:
+				sp.UnitPriceTtc = code.Price
+			}
+		}
+		event.Products = append(event.Products, sp)
+	}
+	return event
+}
+
+// findScannedProducts : produits dont le code-barres est l'un des candidats
+// (par ordre de préférence), sinon dont la référence est le code scanné.
+// Retourne aussi, par produit, la valeur qui a correspondu.
+func findScannedProducts(dao *daos.Dao, code pos.ScanCode) ([]*models.Record, map[string]string) {
+	matched := map[string]string{}
+	rank := map[string]int{}
+	params := dbx.Params{"raw": code.Raw, "item": code.ItemCode}
+
+	var clauses []string
+	for i, b := range code.Barcodes {
+		key := fmt.Sprintf("b%d", i)
+		params[key] = b
+		clauses = append(clauses, "barcode = {:"+key+"}")
+		if _, seen := rank[b]; !seen {
+			rank[b] = i
+		}
+	}
+	clauses = append(clauses, "sku = {:raw}")
+	if code.ItemCode != "" {
+		clauses = append(clauses, "sku = {:item}")
+	}
+
+	records, err := dao.FindRecordsByFilter(
+		"products",
+		strings.Join(clauses, " || "),
+		"name", maxScanProducts*len(clauses), 0,
+		params,
+	)
+	if err != nil {
+		return nil, matched
+	}
+
+	// Meilleure correspondance par entreprise
+	best := map[string]*models.Record{}
+	bestRank := map[string]int{}
+	var order []string
+	for _, r := range records {
+		value, score := r.GetString("barcode"), math.MaxInt
+		if i, ok := rank[value]; ok {
+			score = i
+		} else if r.GetString("sku") == code.Raw {
+			value, score = code.Raw, len(code.Barcodes)
+		} else {
+			value, score = code.ItemCode, len(code.Barcodes)+1
+		}
+
+		company := r.GetString("company")
+		if prev, ok := bestRank[company]; ok && prev <= score {
+			continue
+		}
+		if _, ok := best[company]; !ok {
+			order = append(order, company)
+		}
+		best[company], bestRank[company] = r, score
+		matched[r.Id] = value
+	}
+
+	out := make([]*models.Record, 0, len(order))
+	for _, company := range order {
+		out = append(out, best[company])
+		if len(out) == maxScanProducts {
+			break
+		}
+	}
+	return out, matched
+}
+
+func scanDocument(r *models.Record) *pos.ScanDocument {
+	return &pos.ScanDocument{
+		ID:              r.Id,
+		Number:          r.GetString("number"),
+		Company:         r.GetString("owner_company"),
+		TotalTtc:        r.GetFloat("total_ttc"),
+		RemainingAmount: r.GetFloat("remaining_amount"),
+	}
+}
@@ -0,0 +1,338 @@
+// backend/routes/serial_routes.go
+//
+// LES NUMÉROS DE SÉRIE — guitares, amplis, pianos.
+//
+//   GET  /api/serials/lookup?q=&company_id=   qui a acheté ce numéro, et quand
+//   POST /api/serials/:id/return              l'unité vendue revient en stock
+//
+// ── LE CYCLE D'UNE UNITÉ ──────────────────────────────────────────────────
+// Un produit coché `serialized` se suit unité par unité dans
+// `product_serials` :
+//   - la réception fournisseur crée une unité par numéro saisi, en stock,
+//     avec son lot (`receiptSerialUnits`) ;
+//   - la vente en caisse choisit les unités vendues ; le ticket et le
+//     passage des unités à « vendu » s'écrivent dans la même transaction
+//     (`pickTicketSerials`, `sellTicketSerials`), et les numéros sont
+//     recopiés sur la ligne du ticket (`items[].serial_numbers`) : la facture
+//     reste lisible même si l'unité change ensuite ;
+//   - un retour remet l'unité en stock et garde la vente dans `history`.
+// Le stock lui-même ne bouge pas ici : réception et vente le déplacent comme
+// pour tout produit. Une unité est une trace, pas une quantité.
+//
+// ── LE HORS-LIGNE ─────────────────────────────────────────────────────────
+// Un ticket rejoué après une coupure a déjà été encaissé : on ne le refuse
+// pas pour un numéro manquant ou déjà vendu. Les numéros saisis sont gardés
+// sur la ligne, les unités trouvées en stock passent à « vendu », le reste
+// est signalé dans les logs.
+
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pocket-react/backend"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// En dessous, un numéro se cherche en entier : « 12 » se retrouve dans la
+// moitié des numéros du magasin.
+const minSerialSearchLength = 3
+
+// SerialLookupResult — une unité retrouvée par son numéro
+type SerialLookupResult struct {
+	Unit          *models.Record `json:"unit"`
+	ProductID     string         `json:"product_id"`
+	ProductName   string         `json:"product_name"`
+	Status        string         `json:"status"`
+	CustomerID    string         `json:"customer_id,omitempty"`
+	CustomerName  string         `json:"customer_name,omitempty"`
+	InvoiceID     string         `json:"invoice_id,omitempty"`
+	InvoiceNumber string         `json:"invoice_number,omitempty"`
+	SoldAt        string         `json:"sold_at,omitempty"`
+	WarrantyUntil string         `json:"warranty_until,omitempty"`
+	UnderWarranty bool           `json:"under_warranty"`
+}
+
+func RegisterSerialRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.GET("/api/serials/lookup", func(c echo.Context) error {
+		q := backend.NormalizeSerial(c.QueryParam("q"))
+		if q == "" {
+			return apis.NewBadRequestError("Numéro de série requis", nil)
+		}
+
+		filter := "serial_number = {:q}"
+		if len(q) >= minSerialSearchLength {
+			filter = "serial_number ~ {:q}"
+		}
+		params := dbx.Params{"q": q}
+		if companyID := c.QueryParam("company_id"); companyID != "" {
+			filter += " && owner_company = {:company}"
+			params["company"] = companyID
+		}
+
+		dao := app.Dao()
+		units, err := dao.FindRecordsByFilter("product_serials", filter, "serial_number", 50, 0, params)
+		if err != nil {
+			return apis.NewBadRequestError("Recherche impossible", err)
+		}
+
+		today := time.Now().Format("2006-01-02")
+		results := make([]SerialLookupResult, 0, len(units))
+		for _, unit := range units {
+			r := SerialLookupResult{
+				Unit:          unit,
+				ProductID:     unit.GetString("product"),
+				ProductName:   unit.GetString("product_name"),
+				Status:        unit.GetString("status"),
+				CustomerID:    unit.GetString("customer"),
+				InvoiceID:     unit.GetString("invoice"),
+				InvoiceNumber: unit.GetString("invoice_number"),
+			}
+			if sold := unit.GetDateTime("sold_at"); !sold.IsZero() {
+				r.SoldAt = sold.Time().Format(time.RFC3339)
+			}
+			if until := unit.GetDateTime("warranty_until"); !until.IsZero() {
+				r.WarrantyUntil = until.Time().Format("2006-01-02")
+				r.UnderWarranty = r.Status == "sold" && r.WarrantyUntil >= today
+			}
+			if r.CustomerID != "" {
+				if customer, err := dao.FindRecordById("customers", r.CustomerID); err == nil {
+					r.CustomerName = customer.GetString("name")
+				}
+			}
+			results = append(results, r)
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{"results": results})
+	}, apis.RequireRecordAuth())
+
+	router.POST("/api/serials/:id/return", func(c echo.Context) error {
+		var input struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+
+		var unit *models.Record
+		err := app.Dao().RunInTransaction(func(tx *daos.Dao) error {
+			var err error
+			unit, err = tx.FindRecordById("product_serials", c.PathParam("id"))
+			if err != nil {
+				return apis.NewNotFoundError("Numéro de série introuvable", err)
+			}
+			if unit.GetString("status") != "sold" {
+				return apis.NewBadRequestError("Cette unité est déjà en stock", nil)
+			}
+
+			now := types.NowDateTime()
+			var history []map[string]any
+			if err := unit.UnmarshalJSONField("history", &history); err != nil {
+				history = nil
+			}
+			history = append(history, map[string]any{
+				"invoice":        unit.GetString("invoice"),
+				"invoice_number": unit.GetString("invoice_number"),
+				"customer":       unit.GetString("customer"),
+				"sold_at":        unit.GetString("sold_at"),
+				"warranty_until": unit.GetString("warranty_until"),
+				"returned_at":    now.String(),
+				"reason":         strings.TrimSpace(input.Reason),
+			})
+
+			unit.Set("history", history)
+			unit.Set("status", "in_stock")
+			unit.Set("invoice", "")
+			unit.Set("invoice_number", "")
+			unit.Set("customer", "")
+			unit.Set("sold_at", "")
+			unit.Set("warranty_until", "")
+			unit.Set("returned_at", now)
+			return tx.SaveRecord(unit)
+		})
+		if err != nil {
+			return inventoryError(err)
+		}
+
+		log.Printf("↩️ Numéro %s (%s) revenu en stock",
+			unit.GetString("serial_number"), unit.GetString("product_name"))
+		return c.JSON(http.StatusOK, map[string]any{"unit": unit})
+	}, apis.RequireRecordAuth())
+}
+
+// receiptSerialUnits vérifie les numéros d'une ligne reçue et prépare ses
+// unités, NON enregistrées : elles désignent la réception, écrite en
+// dernier. `seen` évite qu'une même réception reçoive deux fois un numéro.
+func receiptSerialUnits(tx *daos.Dao, order, receipt, product *models.Record, in PurchaseReceiptLineInput, now types.DateTime, seen map[string]bool) ([]*models.Record, []string, error) {
+	name := product.GetString("name")
+	if !product.GetBool("serialized") {
+		if len(in.SerialNumbers) > 0 {
+			return nil, nil, apis.NewBadRequestError(
+				fmt.Sprintf("Ligne « %s » : produit non suivi par numéro de série", name), nil)
+		}
+		return nil, nil, nil
+	}
+
+	serials, err := backend.CheckSerialCount(in.SerialNumbers, in.Quantity)
+	if err != nil {
+		return nil, nil, apis.NewBadRequestError(fmt.Sprintf("Ligne « %s » : %v", name, err), nil)
+	}
+
+	col, err := tx.FindCollectionByNameOrId("product_serials")
+	if err != nil {
+		return nil, nil, err
+	}
+	units := make([]*models.Record, 0, len(serials))
+	for _, serial := range serials {
+		key := product.Id + "|" + serial
+		existing, _ := tx.FindFirstRecordByFilter(
+			"product_serials", "product = {:product} && serial_number = {:serial}",
+			dbx.Params{"product": product.Id, "serial": serial},
+		)
+		if existing != nil || seen[key] {
+			return nil, nil, apis.NewBadRequestError(
+				fmt.Sprintf("Ligne « %s » : le numéro %s est déjà enregistré", name, serial), nil)
+		}
+		seen[key] = true
+
+		unit := models.NewRecord(col)
+		unit.Set("owner_company", order.GetString("owner_company"))
+		unit.Set("product", product.Id)
+		unit.Set("product_name", name)
+		unit.Set("serial_number", serial)
+		unit.Set("lot_number", strings.TrimSpace(in.LotNumber))
+		unit.Set("status", "in_stock")
+		unit.Set("goods_receipt", receipt.Id)
+		unit.Set("received_at", now)
+		units = append(units, unit)
+	}
+	return units, serials, nil
+}
+
+// pickTicketSerials vérifie les numéros des lignes du ticket et renvoie les
+// unités vendues. Les numéros sont normalisés sur les lignes elles-mêmes,
+// pour que le ticket les garde sous la forme où on les cherche.
+func pickTicketSerials(dao *daos.Dao, input *PosTicketInput) ([]*models.Record, error) {
+	offline := input.OfflineID != ""
+	var units []*models.Record
+	seen := map[string]bool{}
+
+	for i := range input.Items {
+		item := &input.Items[i]
+		if item.ProductID == "" || item.Quantity <= 0 {
+			continue
+		}
+		product, err := dao.FindFirstRecordByFilter(
+			"products", "id = {:cle} || legacy_id = {:cle}", dbx.Params{"cle": item.ProductID})
+		if err != nil || !product.GetBool("serialized") {
+			// Produit libre ou non suivi : un numéro saisi reste sur la ligne
+			for j, s := range item.SerialNumbers {
+				item.SerialNumbers[j] = backend.NormalizeSerial(s)
+			}
+			continue
+		}
+
+		serials, err := backend.CheckSerialCount(item.SerialNumbers, item.Quantity)
+		if err != nil {
+			if !offline {
+				return nil, apis.NewBadRequestError(fmt.Sprintf("« %s » : %v", item.Name, err), nil)
+			}
+			log.Printf("⚠️ Ticket hors-ligne %s, « %s » : %v", input.OfflineID, item.Name, err)
+			serials = make([]string, 0, len(item.SerialNumbers))
+			for _, s := range item.SerialNumbers {
+				if n := backend.NormalizeSerial(s); n != "" && !seen[product.Id+"|"+n] {
+					serials = append(serials, n)
+				}
+			}
+		}
+		item.SerialNumbers = serials
+
+		for _, serial := range serials {
+			key := product.Id + "|" + serial
+			if seen[key] {
+				if !offline {
+					return nil, apis.NewBadRequestError(
+						fmt.Sprintf("« %s » : le numéro %s est sur deux lignes", item.Name, serial), nil)
+				}
+				continue
+			}
+			seen[key] = true
+
+			unit, err := dao.FindFirstRecordByFilter(
+				"product_serials", "product = {:product} && serial_number = {:serial}",
+				dbx.Params{"product": product.Id, "serial": serial},
+			)
+			var problem string
+			switch {
+			case err != nil:
+				problem = fmt.Sprintf("le numéro %s n'est pas enregistré", serial)
+			case unit.GetString("owner_company") != input.OwnerCompany:
+				problem = fmt.Sprintf("le numéro %s appartient à une autre société", serial)
+			case unit.GetString("status") != "in_stock":
+				problem = fmt.Sprintf("le numéro %s est déjà vendu (%s)", serial, unit.GetString("invoice_number"))
+			}
+			if problem != "" {
+				if !offline {
+					return nil, apis.NewBadRequestError(fmt.Sprintf("« %s » : %s", item.Name, problem), nil)
+				}
+				log.Printf("⚠️ Ticket hors-ligne %s, « %s » : %s", input.OfflineID, item.Name, problem)
+				continue
+			}
+			units = append(units, unit)
+		}
+	}
+	return units, nil
+}
+
+// sellTicketSerials passe les unités choisies à « vendu », dans la
+// transaction du ticket. Une unité vendue entre-temps par une autre caisse
+// fait échouer le ticket ; au rejeu hors-ligne, elle est seulement signalée.
+func sellTicketSerials(tx *daos.Dao, ticket *models.Record, units []*models.Record, offline bool) error {
+	if len(units) == 0 {
+		return nil
+	}
+	company, _ := tx.FindRecordById("companies", ticket.GetString("owner_company"))
+	soldAt := time.Now()
+	if created := ticket.GetDateTime("offline_created_at"); !created.IsZero() {
+		soldAt = created.Time()
+	}
+
+	for _, u := range units {
+		unit, err := tx.FindRecordById("product_serials", u.Id)
+		if err != nil || unit.GetString("status") != "in_stock" {
+			if !offline {
+				return apis.NewBadRequestError(
+					fmt.Sprintf("Le numéro %s vient d'être vendu sur une autre caisse", u.GetString("serial_number")), nil)
+			}
+			log.Printf("⚠️ Ticket hors-ligne %s : numéro %s déjà vendu", ticket.GetString("number"), u.GetString("serial_number"))
+			continue
+		}
+
+		months := backend.LegalWarrantyMonths
+		if product, err := tx.FindRecordById("products", unit.GetString("product")); err == nil {
+			months = backend.WarrantyMonths(product, company)
+		}
+
+		unit.Set("status", "sold")
+		unit.Set("invoice", ticket.Id)
+		unit.Set("invoice_number", ticket.GetString("number"))
+		unit.Set("customer", ticket.GetString("customer"))
+		unit.Set("sold_at", soldAt)
+		unit.Set("warranty_until", backend.WarrantyUntil(soldAt, months))
+		if err := tx.SaveRecord(unit); err != nil {
+			return fmt.Errorf("numéro %s : %w", unit.GetString("serial_number"), err)
+		}
+	}
+	return nil
+}
@@ -31,6 +31,12 @@
 // mouvement a sa propre transaction, comme le client traitait chaque produit
 // séparément. Un produit introuvable est rendu dans le résultat, pas levé.
 //
+// ── L'EMPLACEMENT ─────────────────────────────────────────────────────────
+// Quand la société a des emplacements (`location_routes.go`), le mouvement
+// touche la quantité d'UN emplacement, et le total suit : celui du
+// mouvement, sinon celui de la caisse (`cash_register_id`), sinon celui par
+// défaut. Sans emplacement, rien ne change.
+//
 // Pas de nouvelle sortie réseau : la route est locale, servie par le
 // PocketBase embarqué (point 1 de CLAUDE.md).
 
@@ -38,6 +44,7 @@ package routes
 
 import (
 	"net/http"
+	"pocket-react/backend"
 
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
@@ -57,10 +64,15 @@ type StockMovementInput struct {
 	// Valeur absolue : ce que l'inventaire a compté. Prime sur `delta` —
 	// l'inventaire ne corrige pas, il constate.
 	Absolute *float64 `json:"absolute"`
+	// Vide : celui du lot, sinon l'emplacement par défaut de la société
+	LocationID string `json:"location_id"`
 }
 
 type StockAdjustInput struct {
 	Movements []StockMovementInput `json:"movements"`
+	// Emplacement de tout le lot. À défaut, celui de la caisse qui vend.
+	LocationID     string `json:"location_id"`
+	CashRegisterID string `json:"cash_register_id"`
 }
 
 // StockMovementResult reprend les champs de `StockAdjustResult` côté client.
@@ -74,8 +86,13 @@ type StockMovementResult struct {
 	ProductSku  string   `json:"product_sku"`
 	StockBefore *float64 `json:"stock_before"`
 	StockAfter  *float64 `json:"stock_after"`
-	Applied     bool     `json:"applied"`
-	Error       string   `json:"error,omitempty"`
+	// L'emplacement touché et sa quantité, vides sans emplacement. Le client
+	// les reporte dans la métadonnée du journal.
+	LocationID          string   `json:"location_id,omitempty"`
+	LocationStockBefore *float64 `json:"location_stock_before,omitempty"`
+	LocationStockAfter  *float64 `json:"location_stock_after,omitempty"`
+	Applied             bool     `json:"applied"`
+	Error               string   `json:"error,omitempty"`
 }
 
 type StockAdjustOutput struct {
@@ -105,9 +122,19 @@ func RegisterStockRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
 			return apis.NewBadRequestError("trop de mouvements dans un seul lot", nil)
 		}
 
+		locationID := payload.LocationID
+		if locationID == "" && payload.CashRegisterID != "" {
+			if register, err := app.Dao().FindRecordById("cash_registers", payload.CashRegisterID); err == nil {
+				locationID = register.GetString("default_location")
+			}
+		}
+
 		results := make([]StockMovementResult, 0, len(payload.Movements))
 
 		for _, mouvement := range payload.Movements {
+			if mouvement.LocationID == "" {
+				mouvement.LocationID = locationID
+			}
 			results = append(results, applyOneMovement(app, mouvement))
 		}
 
@@ -135,8 +162,24 @@ func applyOneMovement(app *pocketbase.PocketBase, mouvement StockMovementInput)
 			return err
 		}
 
+		emplacement, err := resolveStockLocation(tx, produit.GetString("company"), mouvement.LocationID)
+		if err != nil {
+			return err
+		}
+
 		avant := produit.GetFloat("stock")
 		apres := NextStock(avant, mouvement)
+		if emplacement != nil {
+			// Le mouvement porte sur l'emplacement ; le total suit
+			ici, la, err := moveLocationStock(tx, produit, emplacement, mouvement)
+			if err != nil {
+				return err
+			}
+			apres = produit.GetFloat("stock")
+			res.LocationID = emplacement.Id
+			res.LocationStockBefore = &ici
+			res.LocationStockAfter = &la
+		}
 
 		res.RecordID = produit.Id
 		res.ProductName = produit.GetString("name")
@@ -151,6 +194,8 @@ func applyOneMovement(app *pocketbase.PocketBase, mouvement StockMovementInput)
 		}
 
 		produit.Set("stock", apres)
+		// Le client journalise le mouvement avec son motif
+		backend.MarkProductJournaled(produit)
 		if err := tx.SaveRecord(produit); err != nil {
 			return err
 		}
@@ -164,6 +209,9 @@ func applyOneMovement(app *pocketbase.PocketBase, mouvement StockMovementInput)
 		// mensongères.
 		res.StockBefore = nil
 		res.StockAfter = nil
+		res.LocationID = ""
+		res.LocationStockBefore = nil
+		res.LocationStockAfter = nil
 		res.Applied = false
 		res.Error = err.Error()
 	}
@@ -0,0 +1,474 @@
+// backend/routes/valuation_routes.go
+//
+// LA VALORISATION DU STOCK À UNE DATE — ce que vaut le stock au bilan.
+//
+//   POST /api/stock/valuation   {company_id, at: "2026-12-31", method, format}
+//
+// ── LES QUANTITÉS ─────────────────────────────────────────────────────────
+// Le stock d'un produit à la date est rejoué depuis `product_events` : chaque
+// événement qui porte un `after.stock` donne la quantité, sinon son
+// `delta.stock` s'y ajoute. La date est incluse jusqu'à minuit, heure du
+// serveur. Un produit sans événement avant la date est repris à rebours
+// depuis son stock actuel, à travers les événements suivants. La date de
+// création de la fiche n'est pas lue : un rechargement du catalogue la
+// remet au jour de l'import.
+//
+// ── LE COÛT ───────────────────────────────────────────────────────────────
+// Les couches de coût sont les lignes des réceptions fournisseur
+// (`goods_receipts.lines`) antérieures à la date :
+//   - wac  : coût moyen pondéré rejoué réception après réception ;
+//   - fifo : le stock restant est réputé venir des dernières réceptions.
+// Sans réception, le coût est le prix d'achat d'avant la première réception,
+// ou `purchase_price_ht` à défaut (`cost_source` = "catalog"). Un stock
+// négatif est compté pour zéro et signalé.
+//
+// Les ventilations (catégorie, marque, fournisseur) reprennent les
+// rattachements ACTUELS du produit : le journal ne les historise pas. Un
+// produit à plusieurs catégories est compté dans la première.
+//
+// JSON et CSV ici ; le PDF est mis en page par le navigateur, comme les
+// rapports Z.
+
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Méthodes de valorisation
+const (
+	ValuationWAC  = "wac"
+	ValuationFIFO = "fifo"
+)
+
+type valuationInput struct {
+	CompanyID string `json:"company_id"`
+	// AAAA-MM-JJ, incluse. Vide : aujourd'hui.
+	At string `json:"at"`
+	// wac (défaut) ou fifo
+	Method string `json:"method"`
+	// json (défaut) ou csv
+	Format string `json:"format"`
+}
+
+// StockEventPoint — un événement de stock réduit à ce que le rejeu lit
+type StockEventPoint struct {
+	At     time.Time
+	Before *float64
+	After  *float64
+	Delta  *float64
+}
+
+// CostLayer — une ligne de réception, dans l'ordre chronologique
+type CostLayer struct {
+	At          time.Time
+	Quantity    float64
+	UnitCost    float64
+	StockBefore float64
+	CostBefore  float64
+}
+
+// ValuationLine — un produit valorisé
+type ValuationLine struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Sku         string  `json:"sku"`
+	Category    string  `json:"category"`
+	Brand       string  `json:"brand"`
+	Supplier    string  `json:"supplier"`
+	Quantity    float64 `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+	Value       float64 `json:"value"`
+	// receipts : coût tiré des réceptions ; catalog : purchase_price_ht
+	CostSource string `json:"cost_source"`
+}
+
+// ValuationBucket — une ventilation (catégorie, marque ou fournisseur)
+type ValuationBucket struct {
+	Name     string  `json:"name"`
+	Products int     `json:"products"`
+	Quantity float64 `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+// ValuationReport — le rapport complet
+type ValuationReport struct {
+	At               string            `json:"at"`
+	Method           string            `json:"method"`
+	TotalQuantity    float64           `json:"total_quantity"`
+	TotalValue       float64           `json:"total_value"`
+	NegativeProducts int               `json:"negative_products"`
+	Lines            []ValuationLine   `json:"lines"`
+	ByCategory       []ValuationBucket `json:"by_category"`
+	ByBrand          []ValuationBucket `json:"by_brand"`
+	BySupplier       []ValuationBucket `json:"by_supplier"`
+}
+
+// StockAt — la quantité juste avant `until`. `events` est trié par date ;
+// `current` est le stock actuel, point de départ du rejeu à rebours quand
+// rien ne précède la date.
+func StockAt(events []StockEventPoint, until time.Time, current float64) float64 {
+	split := sort.Search(len(events), func(i int) bool { return !events[i].At.Before(until) })
+
+	if split > 0 {
+		var qty float64
+		if first := events[0]; first.Before != nil {
+			qty = *first.Before
+		}
+		for _, e := range events[:split] {
+			switch {
+			case e.After != nil:
+				qty = *e.After
+			case e.Delta != nil:
+				qty += *e.Delta
+			}
+		}
+		return qty
+	}
+
+	// À rebours : l'état avant chaque événement est son `before`, ou son
+	// `after` moins son `delta`
+	qty := current
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		switch {
+		case e.Before != nil:
+			qty = *e.Before
+		case e.Delta != nil:
+			qty -= *e.Delta
+		}
+	}
+	return qty
+}
+
+// UnitCostAt — le coût unitaire du stock `qty` selon la méthode, à partir
+// des couches reçues avant la date (triées) et du coût d'avant la première
+// réception. `fromReceipts` est faux quand aucune couche n'a servi.
+func UnitCostAt(method string, qty float64, layers []CostLayer, initial float64) (cost float64, fromReceipts bool) {
+	if len(layers) == 0 {
+		return initial, false
+	}
+
+	if method == ValuationFIFO {
+		if qty <= 0 {
+			return layers[len(layers)-1].UnitCost, true
+		}
+		remaining, value := qty, 0.0
+		for i := len(layers) - 1; i >= 0 && remaining > 0; i-- {
+			take := math.Min(remaining, layers[i].Quantity)
+			value += take * layers[i].UnitCost
+			remaining -= take
+		}
+		// Plus de stock que de réceptions : le reste est au coût d'origine
+		value += remaining * initial
+		return math.Round(value/qty*10000) / 10000, true
+	}
+
+	wac := initial
+	for _, l := range layers {
+		wac = ReceiptCost(CostMethodWAC, l.StockBefore, wac, l.Quantity, l.UnitCost)
+	}
+	return wac, true
+}
+
+func RegisterValuationRoutes(app *pocketbase.PocketBase, router *echo.Echo) {
+	router.POST("/api/stock/valuation", func(c echo.Context) error {
+		var input valuationInput
+		if err := c.Bind(&input); err != nil {
+			return apis.NewBadRequestError("Corps invalide", err)
+		}
+		user := apis.RequestInfo(c).AuthRecord
+		if !hasManagerRole(user) {
+			return apis.NewForbiddenError("Valorisation du stock réservée aux responsables", nil)
+		}
+		if input.Method == "" {
+			input.Method = ValuationWAC
+		}
+		if input.Method != ValuationWAC && input.Method != ValuationFIFO {
+			return apis.NewBadRequestError("Méthode de valorisation inconnue", nil)
+		}
+		if input.CompanyID == "" {
+			if companies := user.GetStringSlice("company"); len(companies) > 0 {
+				input.CompanyID = companies[0]
+			}
+		}
+		if input.CompanyID == "" {
+			return apis.NewBadRequestError("company_id requis", nil)
+		}
+
+		day := time.Now()
+		if input.At != "" {
+			var err error
+			if day, err = time.ParseInLocation("2006-01-02", input.At, time.Local); err != nil {
+				return apis.NewBadRequestError("Date invalide (AAAA-MM-JJ)", err)
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+
+		report, err := buildValuation(app.Dao(), input.CompanyID, input.Method, day)
+		if err != nil {
+			return apis.NewApiError(http.StatusInternalServerError, "Valorisation impossible", err)
+		}
+
+		if input.Format == "csv" {
+			c.Response().Header().Set("Content-Disposition",
+				fmt.Sprintf(`attachment; filename="valorisation-stock-%s.csv"`, report.At))
+			return c.Blob(http.StatusOK, "text/csv; charset=utf-8", ValuationCSV(report))
+		}
+		return c.JSON(http.StatusOK, report)
+	}, apis.RequireRecordAuth())
+}
+
+// buildValuation valorise le stock de la société à la fin du jour `day`
+func buildValuation(dao *daos.Dao, companyID, method string, day time.Time) (ValuationReport, error) {
+	until := day.AddDate(0, 0, 1)
+	report := ValuationReport{
+		At:     day.Format("2006-01-02"),
+		Method: method,
+		Lines:  []ValuationLine{},
+	}
+
+	products, err := dao.FindRecordsByFilter(
+		"products", "company = {:company} && type != 'service'", "name", 0, 0,
+		dbx.Params{"company": companyID},
+	)
+	if err != nil {
+		return report, err
+	}
+
+	events, err := valuationStockEvents(dao)
+	if err != nil {
+		return report, err
+	}
+	layers, initialCosts, err := valuationCostLayers(dao, companyID, until)
+	if err != nil {
+		return report, err
+	}
+
+	names := valuationNames(dao, "categories", "brands", "suppliers")
+	byCategory := map[string]*ValuationBucket{}
+	byBrand := map[string]*ValuationBucket{}
+	bySupplier := map[string]*ValuationBucket{}
+
+	for _, p := range products {
+		productEvents := events[p.Id]
+		if legacy := p.GetString("legacy_id"); legacy != "" && legacy != p.Id && len(events[legacy]) > 0 {
+			productEvents = append(append([]StockEventPoint{}, productEvents...), events[legacy]...)
+			sort.SliceStable(productEvents, func(i, j int) bool { return productEvents[i].At.Before(productEvents[j].At) })
+		}
+		qty := StockAt(productEvents, until, p.GetFloat("stock"))
+		if qty == 0 {
+			continue
+		}
+
+		initial := p.GetFloat("purchase_price_ht")
+		if cost, ok := initialCosts[p.Id]; ok {
+			initial = cost
+		}
+		cost, fromReceipts := UnitCostAt(method, qty, layers[p.Id], initial)
+
+		line := ValuationLine{
+			ProductID:   p.Id,
+			ProductName: p.GetString("name"),
+			Sku:         p.GetString("sku"),
+			Category:    "Sans catégorie",
+			Brand:       "Sans marque",
+			Supplier:    "Sans fournisseur",
+			Quantity:    qty,
+			UnitCost:    cost,
+			CostSource:  "catalog",
+		}
+		if fromReceipts {
+			line.CostSource = "receipts"
+		}
+		if cats := p.GetStringSlice("categories"); len(cats) > 0 && names[cats[0]] != "" {
+			line.Category = names[cats[0]]
+		}
+		if n := names[p.GetString("brand")]; n != "" {
+			line.Brand = n
+		}
+		if n := names[p.GetString("supplier")]; n != "" {
+			line.Supplier = n
+		}
+		if qty > 0 {
+			line.Value = math.Round(qty*cost*100) / 100
+		} else {
+			report.NegativeProducts++
+		}
+
+		report.Lines = append(report.Lines, line)
+		report.TotalQuantity += math.Max(qty, 0)
+		report.TotalValue += line.Value
+		for _, split := range []struct {
+			bucket map[string]*ValuationBucket
+			key    string
+		}{
+			{byCategory, line.Category},
+			{byBrand, line.Brand},
+			{bySupplier, line.Supplier},
+		} {
+			bucket, key := split.bucket, split.key
+			b, ok := bucket[key]
+			if !ok {
+				b = &ValuationBucket{Name: key}
+				bucket[key] = b
+			}
+			b.Products++
+			b.Quantity += math.Max(qty, 0)
+			b.Value += line.Value
+		}
+	}
+
+	report.TotalValue = math.Round(report.TotalValue*100) / 100
+	report.ByCategory = sortedBuckets(byCategory)
+	report.ByBrand = sortedBuckets(byBrand)
+	report.BySupplier = sortedBuckets(bySupplier)
+	return report, nil
+}
+
+// sortedBuckets : par valeur décroissante
+func sortedBuckets(m map[string]*ValuationBucket) []ValuationBucket {
+	out := make([]ValuationBucket, 0, len(m))
+	for _, b := range m {
+		b.Value = math.Round(b.Value*100) / 100
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Value != out[j].Value {
+			return out[i].Value > out[j].Value
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// valuationStockEvents : tous les événements qui touchent au stock, triés,
+// par clé produit telle que le journal l'a écrite (id ou legacy_id)
+func valuationStockEvents(dao *daos.Dao) (map[string][]StockEventPoint, error) {
+	var rows []struct {
+		ProductID  string   `db:"product_id"`
+		OccurredAt string   `db:"occurred_at"`
+		Before     *float64 `db:"before_stock"`
+		After      *float64 `db:"after_stock"`
+		Delta      *float64 `db:"delta_stock"`
+	}
+	err := dao.DB().
+		Select(
+			"product_id", "occurred_at",
+			"json_extract(before, '$.stock') AS before_stock",
+			"json_extract(after, '$.stock') AS after_stock",
+			"json_extract(delta, '$.stock') AS delta_stock",
+		).
+		From("product_events").
+		Where(dbx.NewExp("json_extract(after, '$.stock') IS NOT NULL OR json_extract(delta, '$.stock') IS NOT NULL")).
+		OrderBy("occurred_at ASC", "created ASC").
+		All(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("journal de stock : %w", err)
+	}
+
+	events := map[string][]StockEventPoint{}
+	for _, r := range rows {
+		at, err := types.ParseDateTime(r.OccurredAt)
+		if err != nil {
+			continue
+		}
+		events[r.ProductID] = append(events[r.ProductID], StockEventPoint{
+			At: at.Time(), Before: r.Before, After: r.After, Delta: r.Delta,
+		})
+	}
+	return events, nil
+}
+
+// valuationCostLayers : les lignes de réception avant `until`, par produit,
+// et le coût de chaque produit d'avant sa toute première réception
+func valuationCostLayers(dao *daos.Dao, companyID string, until time.Time) (map[string][]CostLayer, map[string]float64, error) {
+	receipts, err := dao.FindRecordsByFilter(
+		"goods_receipts", "owner_company = {:company}", "received_at", 0, 0,
+		dbx.Params{"company": companyID},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("réceptions : %w", err)
+	}
+
+	layers := map[string][]CostLayer{}
+	initial := map[string]float64{}
+	for _, r := range receipts {
+		var lines []PurchaseReceiptLine
+		if err := json.Unmarshal([]byte(r.GetString("lines")), &lines); err != nil {
+			continue
+		}
+		at := r.GetDateTime("received_at").Time()
+		for _, l := range lines {
+			if _, seen := initial[l.ProductID]; !seen {
+				initial[l.ProductID] = l.CostBefore
+			}
+			if !at.Before(until) {
+				continue
+			}
+			layers[l.ProductID] = append(layers[l.ProductID], CostLayer{
+				At:          at,
+				Quantity:    l.Quantity,
+				UnitCost:    l.UnitPriceHT,
+				StockBefore: l.StockBefore,
+				CostBefore:  l.CostBefore,
+			})
+		}
+	}
+	return layers, initial, nil
+}
+
+// valuationNames : id → nom pour les collections de rattachement
+func valuationNames(dao *daos.Dao, collections ...string) map[string]string {
+	names := map[string]string{}
+	for _, col := range collections {
+		records, err := dao.FindRecordsByFilter(col, "id != ''", "", 0, 0)
+		if err != nil {
+			continue
+		}
+		for _, r := range records {
+			names[r.Id] = r.GetString("name")
+		}
+	}
+	return names
+}
+
+// ValuationCSV — le détail par produit, puis le total
+func ValuationCSV(report ValuationReport) []byte {
+	method := "Coût moyen pondéré"
+	if report.Method == ValuationFIFO {
+		method = "Premier entré, premier sorti"
+	}
+	rows := [][]string{
+		{"Valorisation du stock au " + report.At, method},
+		{"Référence", "Désignation", "Catégorie", "Marque", "Fournisseur", "Quantité", "Coût unitaire HT", "Valeur HT", "Origine du coût"},
+	}
+	for _, l := range report.Lines {
+		source := "Réceptions"
+		if l.CostSource == "catalog" {
+			source = "Prix d'achat fiche"
+		}
+		rows = append(rows, []string{
+			l.Sku, l.ProductName, l.Category, l.Brand, l.Supplier,
+			csvDecimal(l.Quantity), csvDecimal(l.UnitCost), csvDecimal(l.Value), source,
+		})
+	}
+	rows = append(rows, []string{"", "Total", "", "", "", csvDecimal(report.TotalQuantity), "", csvDecimal(report.TotalValue), ""})
+	if report.NegativeProducts > 0 {
+		rows = append(rows, []string{"", fmt.Sprintf(
+			"%d produit(s) en stock négatif, comptés pour zéro", report.NegativeProducts)})
+	}
+	return frenchCSV(rows)
+}
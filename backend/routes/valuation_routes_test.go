@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"testing"
+	"time"
+)
+
+// La quantité au 31 décembre est celle que le bilan retient : le rejeu doit
+// tomber juste, quel que soit le côté de la date d'où l'on part.
+func TestStockAt(t *testing.T) {
+	jour := func(d int) time.Time { return time.Date(2026, 12, d, 12, 0, 0, 0, time.UTC) }
+	evenements := []StockEventPoint{
+		{At: jour(1), Before: ptr(10), After: ptr(9), Delta: ptr(-1)},
+		{At: jour(5), Delta: ptr(-2)},                                 // vente sans before/after
+		{At: jour(20), Before: ptr(7), After: ptr(12), Delta: ptr(5)}, // réception
+		{At: jour(28), After: ptr(11)},                                // inventaire : absolu
+	}
+
+	cas := []struct {
+		nom     string
+		jusqua  time.Time
+		actuel  float64
+		attendu float64
+	}{
+		{"avant tout événement : le before du premier", jour(1), 99, 10},
+		{"après une vente en delta seul", jour(6), 99, 7},
+		{"après la réception", jour(21), 99, 12},
+		{"l'inventaire pose la quantité", jour(31), 99, 11},
+	}
+	for _, c := range cas {
+		if obtenu := StockAt(evenements, c.jusqua, c.actuel); obtenu != c.attendu {
+			t.Errorf("%s : attendu %v, obtenu %v", c.nom, c.attendu, obtenu)
+		}
+	}
+
+	// Sans before : à rebours depuis le stock actuel
+	ventes := []StockEventPoint{{At: jour(10), Delta: ptr(-3)}, {At: jour(12), Delta: ptr(1)}}
+	if obtenu := StockAt(ventes, jour(1), 20); obtenu != 22 {
+		t.Errorf("rejeu à rebours : attendu 22, obtenu %v", obtenu)
+	}
+	if obtenu := StockAt(nil, jour(1), 20); obtenu != 20 {
+		t.Errorf("sans événement : attendu le stock actuel, obtenu %v", obtenu)
+	}
+}
+
+func TestUnitCostAt(t *testing.T) {
+	couches := []CostLayer{
+		{Quantity: 10, UnitCost: 4, StockBefore: 0},
+		{Quantity: 10, UnitCost: 6, StockBefore: 10},
+	}
+
+	cas := []struct {
+		nom      string
+		methode  string
+		quantite float64
+		couches  []CostLayer
+		attendu  float64
+		recu     bool
+	}{
+		{"sans réception : coût d'origine", ValuationWAC, 5, nil, 3, false},
+		{"coût moyen pondéré", ValuationWAC, 15, couches, 5, true},
+		{"FIFO : le stock vient des dernières réceptions", ValuationFIFO, 10, couches, 6, true},
+		{"FIFO à cheval sur deux réceptions", ValuationFIFO, 15, couches, 5.3333, true},
+		{"FIFO : au-delà des réceptions, coût d'origine", ValuationFIFO, 25, couches, 4.6, true},
+	}
+	for _, c := range cas {
+		cout, recu := UnitCostAt(c.methode, c.quantite, c.couches, 3)
+		if cout != c.attendu || recu != c.recu {
+			t.Errorf("%s : attendu %v (%v), obtenu %v (%v)", c.nom, c.attendu, c.recu, cout, recu)
+		}
+	}
+}
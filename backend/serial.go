@@ -0,0 +1,92 @@
+// backend/serial.go
+// ═══════════════════════════════════════════════════════════════════════════
+// NUMÉROS DE SÉRIE — saisie et garantie
+// ═══════════════════════════════════════════════════════════════════════════
+// Utilisé par : serial_hooks.go (saisie à la main), purchase_routes.go
+// (réception), pos_routes.go (vente), serial_routes.go (recherche)
+//
+// Règles :
+//   - un numéro se compare en majuscules et sans espaces : « ab 123 » saisi
+//     à la réception et « AB123 » scanné en caisse sont la même unité ;
+//   - une ligne d'un produit suivi porte autant de numéros que d'unités,
+//     tous différents ;
+//   - la garantie court de la vente : durée du produit, sinon celle de la
+//     société, sinon la garantie légale de conformité (24 mois).
+
+package backend
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// LegalWarrantyMonths — garantie légale de conformité d'un bien neuf
+const LegalWarrantyMonths = 24
+
+// NormalizeSerial met un numéro de série sous sa forme de comparaison
+func NormalizeSerial(serial string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, serial)
+}
+
+// CheckSerialCount vérifie les numéros d'une ligne de `qty` unités et les
+// renvoie normalisés. Un produit suivi se compte à l'unité : une quantité
+// décimale n'a pas de sens.
+func CheckSerialCount(serials []string, qty float64) ([]string, error) {
+	if qty <= 0 || qty != math.Trunc(qty) {
+		return nil, fmt.Errorf("quantité %g : un produit suivi par numéro de série se compte à l'unité", qty)
+	}
+	if len(serials) != int(qty) {
+		return nil, fmt.Errorf("%d numéro(s) de série pour %g unité(s)", len(serials), qty)
+	}
+	normalized := make([]string, 0, len(serials))
+	seen := map[string]bool{}
+	for _, s := range serials {
+		n := NormalizeSerial(s)
+		if n == "" {
+			return nil, fmt.Errorf("numéro de série vide")
+		}
+		if seen[n] {
+			return nil, fmt.Errorf("numéro de série %s saisi deux fois", n)
+		}
+		seen[n] = true
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+// WarrantyMonths — la durée de garantie d'un produit vendu par la société
+// (company peut être nil)
+func WarrantyMonths(product, company *models.Record) int {
+	if m := product.GetInt("warranty_months"); m > 0 {
+		return m
+	}
+	if company != nil {
+		if m := company.GetInt("warranty_months"); m > 0 {
+			return m
+		}
+	}
+	return LegalWarrantyMonths
+}
+
+// WarrantyUntil — le dernier jour de garantie d'une unité vendue le
+// `soldAt`. Vendue un 31 janvier avec un mois de garantie, elle est couverte
+// jusqu'au 28 (ou 29) février, pas jusqu'au 3 mars.
+func WarrantyUntil(soldAt time.Time, months int) time.Time {
+	y, m, d := soldAt.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, soldAt.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if d > lastDay {
+		d = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, soldAt.Location())
+}
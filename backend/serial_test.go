@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+// Un numéro scanné en caisse doit retrouver celui saisi à la réception,
+// quelle que soit la casse ou les espaces du bon de livraison.
+func TestNormalizeSerial(t *testing.T) {
+	cas := []struct {
+		saisi, attendu string
+	}{
+		{"us 1234 5678", "US12345678"},
+		{" Ab-9\t", "AB-9"},
+		{"KC1234", "KC1234"},
+	}
+	for _, c := range cas {
+		if obtenu := NormalizeSerial(c.saisi); obtenu != c.attendu {
+			t.Errorf("%q : %q, attendu %q", c.saisi, obtenu, c.attendu)
+		}
+	}
+}
+
+// Une ligne de trois guitares porte trois numéros, tous différents.
+func TestCheckSerialCount(t *testing.T) {
+	numeros, err := CheckSerialCount([]string{"a 1", "A2", "a3"}, 3)
+	if err != nil {
+		t.Fatalf("numéros valides refusés : %v", err)
+	}
+	if numeros[0] != "A1" || numeros[2] != "A3" {
+		t.Errorf("numéros non normalisés : %v", numeros)
+	}
+
+	cas := []struct {
+		nom      string
+		numeros  []string
+		quantite float64
+	}{
+		{"un numéro manquant", []string{"A1"}, 2},
+		{"un numéro de trop", []string{"A1", "A2"}, 1},
+		{"doublon à la casse près", []string{"a1", "A 1"}, 2},
+		{"numéro vide", []string{"A1", "  "}, 2},
+		{"quantité décimale", []string{"A1"}, 1.5},
+		{"quantité nulle", nil, 0},
+	}
+	for _, c := range cas {
+		if _, err := CheckSerialCount(c.numeros, c.quantite); err == nil {
+			t.Errorf("%s : accepté", c.nom)
+		}
+	}
+}
+
+// La garantie finit le même jour du mois, ou le dernier jour d'un mois plus
+// court.
+func TestWarrantyUntil(t *testing.T) {
+	jour := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 15, 30, 0, 0, time.UTC)
+	}
+	cas := []struct {
+		nom     string
+		vente   time.Time
+		mois    int
+		attendu string
+	}{
+		{"garantie légale", jour(2026, time.March, 14), 24, "2028-03-14"},
+		{"fin de mois plus courte", jour(2026, time.January, 31), 1, "2026-02-28"},
+		{"vers une année bissextile", jour(2027, time.February, 28), 12, "2028-02-28"},
+		{"29 février sur une année non bissextile", jour(2028, time.February, 29), 12, "2029-02-28"},
+	}
+	for _, c := range cas {
+		if obtenu := WarrantyUntil(c.vente, c.mois).Format("2006-01-02"); obtenu != c.attendu {
+			t.Errorf("%s : %s, attendu %s", c.nom, obtenu, c.attendu)
+		}
+	}
+}
@@ -92,6 +92,10 @@ const emptyFormData: FormDataWithLogo = {
 	invoice_footer: '',
 	invoice_prefix: '',
 	warranties_text: '',
+	warranty_months: 0,
+	reservation_policy: 'warn',
+	reservation_order_days: 0,
+	reservation_cart_minutes: 0,
 	logoFile: null,
 	logoPreview: null,
 	removeLogo: false,
@@ -210,6 +214,10 @@ export default function CompanyManagement() {
 			invoice_footer: company.invoice_footer || '',
 			invoice_prefix: company.invoice_prefix || '',
 			warranties_text: company.warranties_text || '',
+			warranty_months: company.warranty_months || 0,
+			reservation_policy: company.reservation_policy || 'warn',
+			reservation_order_days: company.reservation_order_days || 0,
+			reservation_cart_minutes: company.reservation_cart_minutes || 0,
 			logoFile: null,
 			logoPreview: null,
 			removeLogo: false,
@@ -944,6 +952,104 @@ export default function CompanyManagement() {
 									mentions par · ou un retour à la ligne.
 								</p>
 							</div>
+
+							<div className='space-y-2'>
+								<Label htmlFor='warranty_months'>
+									Durée de garantie (mois)
+								</Label>
+								<Input
+									id='warranty_months'
+									type='number'
+									min={0}
+									value={formData.warranty_months ?? 0}
+									onChange={(e) =>
+										setFormData({
+											...formData,
+											warranty_months: Number.parseInt(e.target.value) || 0,
+										})
+									}
+									placeholder='24'
+								/>
+								<p className='text-xs text-muted-foreground'>
+									Date de fin de garantie des produits suivis par numéro de
+									série, sauf durée propre au produit. 0 : garantie légale de 24
+									mois.
+								</p>
+							</div>
+
+							<div className='space-y-2'>
+								<Label htmlFor='reservation_policy'>
+									Vente d'unités réservées en caisse
+								</Label>
+								<Select
+									value={formData.reservation_policy ?? 'warn'}
+									onValueChange={(value) =>
+										setFormData({
+											...formData,
+											reservation_policy:
+												value as CompanyDto['reservation_policy'],
+										})
+									}
+								>
+									<SelectTrigger id='reservation_policy'>
+										<SelectValue />
+									</SelectTrigger>
+									<SelectContent>
+										<SelectItem value='warn'>Avertir le vendeur</SelectItem>
+										<SelectItem value='block'>Refuser la vente</SelectItem>
+									</SelectContent>
+								</Select>
+								<p className='text-xs text-muted-foreground'>
+									Unités promises à un bon de commande confirmé ou à un panier
+									en attente. Un ticket encaissé hors-ligne n'est jamais
+									refusé.
+								</p>
+							</div>
+
+							<div className='grid grid-cols-2 gap-4'>
+								<div className='space-y-2'>
+									<Label htmlFor='reservation_order_days'>
+										Réservation d'un bon de commande (jours)
+									</Label>
+									<Input
+										id='reservation_order_days'
+										type='number'
+										min={0}
+										value={formData.reservation_order_days ?? 0}
+										onChange={(e) =>
+											setFormData({
+												...formData,
+												reservation_order_days:
+													Number.parseInt(e.target.value) || 0,
+											})
+										}
+									/>
+									<p className='text-xs text-muted-foreground'>
+										0 : jusqu'à la livraison, la facturation ou l'annulation.
+									</p>
+								</div>
+								<div className='space-y-2'>
+									<Label htmlFor='reservation_cart_minutes'>
+										Réservation d'un panier en attente (minutes)
+									</Label>
+									<Input
+										id='reservation_cart_minutes'
+										type='number'
+										min={0}
+										value={formData.reservation_cart_minutes ?? 0}
+										onChange={(e) =>
+											setFormData({
+												...formData,
+												reservation_cart_minutes:
+													Number.parseInt(e.target.value) || 0,
+											})
+										}
+									/>
+									<p className='text-xs text-muted-foreground'>
+										0 : les paniers en attente ne réservent pas.
+									</p>
+								</div>
+							</div>
 						</TabsContent>
 					</Tabs>
 
@@ -1,9 +1,17 @@
 // frontend/lib/inventory/inventory-pocketbase.ts
 // Fonctions CRUD PocketBase pour les sessions et entrées d'inventaire
+//
+// Le gel, le comptage et la clôture passent par les routes serveur
+// `/api/inventory/:id/…` (backend/routes/inventory_routes.go) : elles tiennent
+// chacune dans une transaction. Le reste est du CRUD simple.
 
 import type PocketBase from 'pocketbase'
 import type {
 	CreateInventorySessionInput,
+	InventoryAdjustmentResult,
+	InventoryCountLine,
+	InventoryCountPolicy,
+	InventoryCountResult,
 	InventoryEntry,
 	InventorySession,
 	InventorySessionStatus,
@@ -90,89 +98,64 @@ export async function createInventorySession(
 		validated_category_ids: [],
 		apppos_snapshot_at: new Date().toISOString(),
 		notes: input.notes ?? '',
+		owner_company: input.companyId ?? '',
+		count_policy: input.count_policy ?? 'sum',
 	})
 }
 
 /**
- * Passe la session de "draft" à "in_progress".
+ * Passe la session de "draft" à "in_progress" en gelant les stocks
+ * théoriques côté serveur, en une transaction. Une session libre ne gèle que
+ * les produits ciblés (`productIds`), s'il y en a.
  */
 export async function startInventorySession(
 	pb: PocketBase,
 	sessionId: string,
-): Promise<InventorySession> {
-	return pb
-		.collection(INVENTORY_SESSIONS_COLLECTION)
-		.update<InventorySession>(sessionId, {
-			status: 'in_progress',
-			started_at: new Date().toISOString(),
-		})
+	options: {
+		companyId?: string
+		productIds?: string[]
+		locationId?: string
+	} = {},
+): Promise<{ session: InventorySession; entries_count: number }> {
+	return pb.send(`/api/inventory/${sessionId}/start`, {
+		method: 'POST',
+		body: {
+			company_id: options.companyId ?? '',
+			product_ids: options.productIds ?? [],
+			location_id: options.locationId ?? '',
+		},
+	})
 }
 
 /**
  * Marque une catégorie comme validée dans la session.
- * Une catégorie validée n'est plus modifiable.
+ * Une catégorie validée n'est plus modifiable ; le serveur refuse tant qu'un
+ * de ses produits reste à compter.
  */
 export async function validateInventoryCategory(
 	pb: PocketBase,
 	sessionId: string,
 	categoryId: string,
 ): Promise<InventorySession> {
-	const session = await getInventorySession(pb, sessionId)
-	if (!session) throw new Error('Session introuvable')
-	if (session.status !== 'in_progress') {
-		throw new Error('La session doit être en cours pour valider une catégorie')
-	}
-
-	const already = session.validated_category_ids ?? []
-	if (already.includes(categoryId)) return session // Déjà validée
-
-	return pb
-		.collection(INVENTORY_SESSIONS_COLLECTION)
-		.update<InventorySession>(sessionId, {
-			validated_category_ids: [...already, categoryId],
-		})
+	return pb.send(`/api/inventory/${sessionId}/validate-category`, {
+		method: 'POST',
+		body: { category_id: categoryId },
+	})
 }
 
 /**
- * Complète la session (tous les écarts ont été appliqués).
- * Écrit les stats dénormalisées pour l'affichage de l'historique sans requêter les entrées.
+ * Clôture la session : le serveur applique TOUS les écarts et leurs
+ * événements `stock_adjusted_inventory`, puis écrit les stats dénormalisées,
+ * en une seule transaction. Tout passe, ou rien.
  */
 export async function completeInventorySession(
 	pb: PocketBase,
 	sessionId: string,
-): Promise<InventorySession> {
-	return pb
-		.collection(INVENTORY_SESSIONS_COLLECTION)
-		.update<InventorySession>(sessionId, {
-			status: 'completed',
-			completed_at: new Date().toISOString(),
-		})
-}
-
-/**
- * Complète la session ET écrit les stats dénormalisées.
- * À préférer à completeInventorySession() pour un historique riche.
- */
-export async function completeInventorySessionWithStats(
-	pb: PocketBase,
-	sessionId: string,
-	stats: {
-		totalProducts: number
-		countedProducts: number
-		totalGaps: number
-		categoryNames: string[]
-	},
-): Promise<InventorySession> {
-	return pb
-		.collection(INVENTORY_SESSIONS_COLLECTION)
-		.update<InventorySession>(sessionId, {
-			status: 'completed',
-			completed_at: new Date().toISOString(),
-			stats_total_products: stats.totalProducts,
-			stats_counted_products: stats.countedProducts,
-			stats_total_gaps: stats.totalGaps,
-			stats_category_names: stats.categoryNames,
-		})
+): Promise<{
+	session: InventorySession
+	adjustments: InventoryAdjustmentResult[]
+}> {
+	return pb.send(`/api/inventory/${sessionId}/complete`, { method: 'POST' })
 }
 
 /**
@@ -295,95 +278,56 @@ export async function createInventoryEntries(
 }
 
 /**
- * Met à jour le stock compté d'un produit dans la session.
+ * Envoie des saisies au serveur — d'un poste de comptage ou d'un terminal de
+ * scan. Sans `mode`, la politique de la session s'applique (somme par défaut) :
+ * deux postes qui scannent le même rayon s'additionnent. Chaque ligne est
+ * rendue avec son résultat ou son erreur, dans l'ordre envoyé.
  */
-export async function countInventoryProduct(
+export async function countInventoryEntries(
 	pb: PocketBase,
-	entryId: string,
-	stockCompte: number,
-): Promise<InventoryEntry> {
-	if (stockCompte < 0)
-		throw new Error('Le stock compté ne peut pas être négatif')
-
-	return pb
-		.collection(INVENTORY_ENTRIES_COLLECTION)
-		.update<InventoryEntry>(entryId, {
-			stock_compte: stockCompte,
-			status: 'counted',
-			counted_at: new Date().toISOString(),
-		})
+	sessionId: string,
+	input: {
+		counts: InventoryCountLine[]
+		mode?: InventoryCountPolicy
+		device?: string
+	},
+): Promise<InventoryCountResult[]> {
+	const response = await pb.send<{ results: InventoryCountResult[] }>(
+		`/api/inventory/${sessionId}/count`,
+		{
+			method: 'POST',
+			body: {
+				device: input.device ?? '',
+				mode: input.mode ?? '',
+				counts: input.counts,
+			},
+		},
+	)
+	return response.results ?? []
 }
 
-// ============================================================================
-// countAndAdjustProduct
-// ============================================================================
-
 /**
- * Compte un produit ET applique immédiatement l'ajustement si écart.
- * Appelé dès que l'opérateur valide la quantité (onBlur / Enter).
- *
- * - Si stock_compte === stock_theorique : marqué counted, adjusted reste false.
- * - Si écart : ajustement + adjusted: true.
- *
- * `applyStock` est injecté : ce fichier ne sait pas où va le stock, et c'est
- * ainsi que la destination a pu passer d'AppPos à PocketBase le 19 août 2026
- * en changeant une seule ligne, au point d'appel. Il journalise lui-même —
- * le rappel `onAdjusted` qui doublait cette écriture a été retiré.
+ * Saisie d'une quantité tapée à la main : elle remplace la précédente.
+ * Le stock n'est PAS ajusté ici — il ne bouge qu'à la clôture.
  */
-export async function countAndAdjustProduct(
+export async function countInventoryProduct(
 	pb: PocketBase,
 	entry: InventoryEntry,
 	stockCompte: number,
-	applyStock: (productId: string, stock: number) => Promise<unknown>,
-): Promise<{ entry: InventoryEntry; adjusted: boolean; error?: string }> {
+	device?: string,
+): Promise<InventoryCountResult> {
 	if (stockCompte < 0)
 		throw new Error('Le stock compté ne peut pas être négatif')
 
-	const hasGap = stockCompte !== entry.stock_theorique
-
-	// 1. Toujours mettre à jour PocketBase
-	const updated = await pb
-		.collection(INVENTORY_ENTRIES_COLLECTION)
-		.update<InventoryEntry>(
-			entry.id,
-			{
-				stock_compte: stockCompte,
-				status: 'counted',
-				counted_at: new Date().toISOString(),
-				adjusted: false,
-				adjusted_at: null,
-			},
-			{ $autoCancel: false },
-		)
-
-	// 2. Si écart → ajuster le stock immédiatement (et journaliser, côté
-	//    `applyStock`)
-	if (hasGap) {
-		try {
-			await applyStock(entry.product_id, stockCompte)
-
-			const adjustedAt = new Date().toISOString()
-
-			const adjustedEntry = await pb
-				.collection(INVENTORY_ENTRIES_COLLECTION)
-				.update<InventoryEntry>(
-					entry.id,
-					{ adjusted: true, adjusted_at: adjustedAt },
-					{ $autoCancel: false },
-				)
-
-			return { entry: adjustedEntry, adjusted: true }
-		} catch (err: any) {
-			// L'ajustement a échoué — on rend l'entrée comptée, sans `adjusted`
-			return {
-				entry: updated,
-				adjusted: false,
-				error: err?.message ?? 'Erreur ajustement du stock',
-			}
-		}
+	const [result] = await countInventoryEntries(pb, entry.session_id, {
+		counts: [{ entry_id: entry.id, quantity: stockCompte }],
+		mode: 'overwrite',
+		device,
+	})
+	if (!result || result.error) {
+		throw new Error(result?.error ?? 'Saisie refusée par le serveur')
 	}
-
-	return { entry: updated, adjusted: false }
+	return result
 }
 
 /**
@@ -400,6 +344,8 @@ export async function resetInventoryProduct(
 			stock_compte: null,
 			status: 'pending',
 			counted_at: null,
+			counted_by: '',
+			scan_count: 0,
 		})
 }
 
@@ -456,7 +402,15 @@ export async function getSessionProgress(
 // ============================================================================
 
 /**
- * Calcule les écarts pour une liste d'entrées.
+ * Stock attendu au moment du comptage : le gel, corrigé des ventes et retours
+ * passés en caisse pendant que le rayon attendait d'être compté.
+ */
+export function expectedStock(entry: InventoryEntry): number {
+	return entry.stock_theorique + (entry.sales_delta ?? 0)
+}
+
+/**
+ * Calcule les écarts pour une liste d'entrées, contre le stock attendu.
  * Ne retourne que les produits comptés avec un écart ≠ 0.
  */
 export function computeGaps(
@@ -467,7 +421,7 @@ export function computeGaps(
 			(e): e is InventoryEntry & { stock_compte: number } =>
 				e.status === 'counted' && e.stock_compte !== null,
 		)
-		.map((e) => ({ entry: e, ecart: e.stock_compte - e.stock_theorique }))
+		.map((e) => ({ entry: e, ecart: e.stock_compte - expectedStock(e) }))
 		.filter(({ ecart }) => ecart !== 0)
 }
 
@@ -11,6 +11,13 @@ export type InventorySessionStatus =
 	| 'completed' // Validée et ajustements appliqués
 	| 'cancelled' // Annulée
 
+/**
+ * Fusion des saisies de plusieurs postes :
+ * - sum : chaque scan ajoute sa quantité (défaut)
+ * - overwrite : la dernière saisie remplace la précédente
+ */
+export type InventoryCountPolicy = 'sum' | 'overwrite'
+
 export type InventoryScope =
 	| 'all' // Tout le catalogue
 	| 'selection' // Catégories choisies
@@ -37,7 +44,10 @@ export interface InventorySession {
 	 * null pour les sessions all / selection
 	 */
 	label: string | null
-	// Stats dénormalisées — écrites à la clôture de la session (completeInventorySession)
+	owner_company?: string // Société dont le catalogue a été gelé
+	location?: string // Emplacement compté — posé par le serveur au démarrage
+	count_policy?: InventoryCountPolicy | '' // Vide = sum
+	// Stats dénormalisées — écrites par le serveur à la clôture (completeInventorySession)
 	// Évite de requêter toutes les entrées pour afficher l'historique
 	stats_total_products: number | null // Nb total de produits dans la session
 	stats_counted_products: number | null // Nb de produits effectivement comptés
@@ -76,8 +86,14 @@ export interface InventoryEntry {
 	stock_compte: number | null // Quantité saisie par l'opérateur (null = pas encore compté)
 	status: InventoryEntryStatus
 	counted_at: string | null // ISO date — quand la quantité a été saisie
-	adjusted: boolean // true si l'ajustement AppPOS a été appliqué
+	adjusted: boolean // true si l'ajustement du stock a été appliqué (à la clôture)
 	adjusted_at: string | null // ISO date — quand l'ajustement a été fait
+	// Mouvements journalisés à l'emplacement entre le gel et le comptage
+	// (ventes, réceptions, transferts…, négatif si sorti). Stock attendu au
+	// comptage = stock_theorique + sales_delta.
+	sales_delta?: number
+	scan_count?: number // Nombre de saisies reçues (tous postes confondus)
+	counted_by?: string // Poste ou opérateur de la dernière saisie
 	// Champs PocketBase auto
 	created: string
 	updated: string
@@ -98,8 +114,9 @@ export interface ProductGap {
 	categoryId: string
 	categoryName: string
 	stockTheorique: number
+	salesDelta: number // Mouvements de l'emplacement pendant le comptage
 	stockCompte: number // Jamais null ici — seulement sur les produits comptés
-	ecart: number // stockCompte - stockTheorique (positif = surplus, négatif = manquant)
+	ecart: number // stockCompte - (stockTheorique + salesDelta) (positif = surplus, négatif = manquant)
 	entryId: string
 }
 
@@ -151,6 +168,43 @@ export interface CreateInventorySessionInput {
 	companyId?: string
 	label?: string | null // Requis si scope === 'free' — nom libre de la session
 	notes?: string
+	count_policy?: InventoryCountPolicy
+	/** Session libre ciblée : produits gelés au démarrage */
+	productIds?: string[]
+	/** Emplacement compté ; absent, le serveur prend celui par défaut */
+	locationId?: string
+}
+
+/** Une saisie envoyée à /api/inventory/:id/count */
+export interface InventoryCountLine {
+	entry_id?: string
+	product_id?: string // Identifiant PocketBase ou legacy_id
+	barcode?: string
+	quantity: number
+}
+
+/** Résultat d'une saisie, dans l'ordre envoyé */
+export interface InventoryCountResult {
+	entry_id: string
+	product_id: string
+	product_name: string
+	stock_compte: number
+	sales_delta: number
+	scan_count: number
+	created?: boolean // Produit ajouté à une session libre par le scan
+	error?: string
+}
+
+/** Écart appliqué par /api/inventory/:id/complete */
+export interface InventoryAdjustmentResult {
+	entry_id: string
+	product_id: string
+	product_name: string
+	stock_before: number | null
+	stock_after: number
+	sales_delta: number
+	event_id?: string
+	error?: string // Produit disparu du catalogue : écart non appliqué
 }
 
 /** Pour enregistrer la saisie d'un produit */
@@ -234,5 +288,8 @@ export const INVENTORY_SESSIONS_COLLECTION = 'inventory_sessions'
  * - counted_at      : Date    — optional
  * - adjusted        : Bool    — default: false
  * - adjusted_at     : Date    — optional
+ * - scan_count      : Number  — saisies reçues
+ * - counted_by      : Text    — poste de la dernière saisie
+ * - sales_delta     : Number  — ventes et retours entre le gel et le comptage
  */
 export const INVENTORY_ENTRIES_COLLECTION = 'inventory_entries'
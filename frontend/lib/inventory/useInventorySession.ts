@@ -2,28 +2,26 @@
 // Hook principal qui orchestre la session d'inventaire
 // PocketBase de bout en bout : persistance, ajustements ET snapshot.
 //
-// L'AJUSTEMENT DE STOCK VA DANS POCKETBASE depuis le 19 août 2026, par
-// `setCountedStock` (`lib/queries/stock-adjust.ts`) — un seul chemin pour les
-// quatre motifs. LE SNAPSHOT AUSSI depuis le 19 août 2026, par
-// `fetchCatalogSnapshot` (`lib/queries/catalog-snapshot.ts`) : AppPos ne se lit
-// plus ici non plus.
+// LE SNAPSHOT, LE COMPTAGE ET L'AJUSTEMENT SONT CÔTÉ SERVEUR depuis le
+// 18 octobre 2026 (`backend/routes/inventory_routes.go`). Le gel tient en une
+// transaction, les saisies de plusieurs postes se fusionnent sur le serveur, et
+// la clôture applique tous les écarts avec leur journal, d'un bloc. Compter ne
+// touche plus au stock : il ne bouge qu'à la clôture.
 //
 // ⚠️ Les entrées neuves portent l'identifiant POCKETBASE dans `product_id`.
 // Les 2465 déjà en base portent un identifiant NeDB ; l'affichage les résout
 // par `indexCatalogueParCle`, qui interroge `id` ET `legacy_id`.
 
-import { fetchCatalogSnapshot } from '@/lib/queries/catalog-snapshot'
-import { setCountedStock } from '@/lib/queries/stock-adjust'
 import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
-import { useMemo, useState } from 'react'
+import { useMemo } from 'react'
 import { usePocketBase } from '../use-pocketbase'
 import {
 	cancelInventorySession,
-	completeInventorySessionWithStats,
+	completeInventorySession,
 	computeGaps,
-	countAndAdjustProduct,
-	createInventoryEntries,
+	countInventoryProduct,
 	createInventorySession,
+	expectedStock,
 	getActiveSessions,
 	getInventoryEntries,
 	getInventoryEntriesByCategory,
@@ -39,6 +37,7 @@ import type {
 	InventoryEntry,
 	InventorySessionSummary,
 } from './inventory-types'
+import { INVENTORY_SESSIONS_COLLECTION } from './inventory-types'
 
 // ============================================================================
 // QUERY KEYS
@@ -176,6 +175,7 @@ export function useInventorySession(sessionId: string | undefined) {
 					categoryId: entry.category_id,
 					categoryName: entry.category_name,
 					stockTheorique: entry.stock_theorique,
+					salesDelta: entry.sales_delta ?? 0,
 					// stock_compte garanti non-null par computeGaps (filtre status === 'counted')
 					stockCompte: entry.stock_compte ?? 0,
 					ecart,
@@ -212,6 +212,7 @@ export function useInventorySession(sessionId: string | undefined) {
 				categoryId: entry.category_id,
 				categoryName: entry.category_name,
 				stockTheorique: entry.stock_theorique,
+				salesDelta: entry.sales_delta ?? 0,
 				stockCompte: entry.stock_compte ?? 0,
 				ecart,
 				entryId: entry.id,
@@ -232,21 +233,9 @@ export function useInventorySession(sessionId: string | undefined) {
 			stockCompte: number
 			operator?: string
 		}) =>
-			countAndAdjustProduct(pb, entry, stockCompte, (productId, stock) =>
-				// Un seul chemin, un seul journal : `setCountedStock` écrit le stock
-				// ET l'événement. Le rappel d'avant journalisait à côté de
-				// l'ajustement, si bien que la trace et le mouvement pouvaient se
-				// contredire quand l'un des deux ratait.
-				setCountedStock(pb, productId, stock, {
-					sourceId: entry.session_id,
-					operator,
-					metadata: {
-						session_id: entry.session_id,
-						entry_id: entry.id,
-						adjusted_at: new Date().toISOString(),
-					},
-				}),
-			),
+			// La saisie tapée remplace la précédente ; l'opérateur est retenu
+			// comme poste de comptage. Le stock, lui, attend la clôture.
+			countInventoryProduct(pb, entry, stockCompte, operator || undefined),
 		onSuccess: () => {
 			queryClient.invalidateQueries({
 				queryKey: inventoryKeys.entries(sessionId ?? ''),
@@ -268,18 +257,9 @@ export function useInventorySession(sessionId: string | undefined) {
 	const completeSession = useMutation({
 		mutationFn: () => {
 			if (!sessionId) throw new Error('Pas de session active')
-			if (!summary) throw new Error('Résumé de session indisponible')
-
-			const categoryNames = [
-				...new Set(entries.map((e) => e.category_name).filter(Boolean)),
-			].sort()
-
-			return completeInventorySessionWithStats(pb, sessionId, {
-				totalProducts: summary.totalProducts,
-				countedProducts: summary.countedProducts,
-				totalGaps: summary.totalGaps.length,
-				categoryNames,
-			})
+			// Les écarts et les stats sont calculés par le serveur, sur les
+			// entrées en base — pas sur ce que cet écran a chargé.
+			return completeInventorySession(pb, sessionId)
 		},
 		onSuccess: () => {
 			queryClient.invalidateQueries({ queryKey: inventoryKeys.all })
@@ -313,7 +293,7 @@ export function useInventorySession(sessionId: string | undefined) {
 		summary,
 		entriesLoading,
 
-		// countProduct accepte maintenant un operator optionnel pour le journal
+		// countProduct accepte un operator optionnel, retenu dans `counted_by`
 		countProduct: (
 			entry: InventoryEntry,
 			stockCompte: number,
@@ -334,93 +314,43 @@ export function useInventorySession(sessionId: string | undefined) {
 }
 
 // ============================================================================
-// HOOK: Créer une session + snapshot AppPOS
+// HOOK: Créer une session + snapshot côté serveur
 // ============================================================================
 export function useCreateInventorySession() {
 	const pb = usePocketBase()
 	const queryClient = useQueryClient()
-	const [progress, setProgress] = useState<{
-		done: number
-		total: number
-	} | null>(null)
 
-	const mutation = useMutation({
+	return useMutation({
 		mutationFn: async (input: CreateInventorySessionInput) => {
-			// ── Session libre : pas de snapshot.
-			// L'opérateur ajoute les produits manuellement via scan/recherche.
-			if (input.scope === 'free') {
-				const session = await createInventorySession(pb, input)
-				const started = await startInventorySession(pb, session.id)
-				return { session: started, entriesCount: 0 }
-			}
-
-			// ── Sessions all / selection : snapshot du catalogue PocketBase ───
-			// `fetchCatalogSnapshot` prend TOUT le catalogue (`getFullList`) : une
-			// page de 25 produits ferait une session d'inventaire muette.
-			const [allProducts, allCategories] = await Promise.all([
-				fetchCatalogSnapshot(pb, input.companyId),
-				pb.collection('categories').getFullList<{ id: string; name?: string }>({
-					batch: 500,
-					fields: 'id,name',
-				}),
-			])
-
-			const categoryNameById = new Map(
-				allCategories.map((c) => [c.id, c.name ?? '']),
-			)
-
-			let products = allProducts
-			if (input.scope === 'selection' && input.scope_category_ids?.length) {
-				const scopeIds = new Set(input.scope_category_ids)
-				products = products.filter((p) =>
-					p.categories.some((cid) => scopeIds.has(cid)),
-				)
-			}
-
-			if (products.length === 0) {
-				throw new Error(
-					'Aucun produit trouvé pour ce périmètre. Vérifiez la sélection de catégories.',
-				)
-			}
-
+			// La session naît en brouillon, puis le serveur gèle le périmètre
+			// (tout, catégories choisies, ou produits ciblés d'une session libre)
+			// en une transaction. Une session libre sans cible démarre vide :
+			// l'opérateur ajoute les produits par scan/recherche.
 			const session = await createInventorySession(pb, input)
-
-			const entries = products.map((p) => {
-				const categoryId = p.categories[0] ?? ''
+			try {
+				const started = await startInventorySession(pb, session.id, {
+					companyId: input.companyId,
+					productIds: input.productIds,
+					locationId: input.locationId,
+				})
 				return {
-					// L'identifiant POCKETBASE, désormais. Le pont `legacy_id` reste
-					// lisible à l'affichage pour les entrées d'avant.
-					product_id: p.id,
-					product_name: p.name,
-					product_sku: p.sku,
-					product_barcode: p.barcode,
-					product_image: p.imageUrl ?? '',
-					category_id: categoryId,
-					category_name: categoryNameById.get(categoryId) || 'Sans catégorie',
-					stock_theorique: p.stock,
+					session: started.session,
+					entriesCount: started.entries_count,
 				}
-			})
-
-			setProgress({ done: 0, total: entries.length })
-
-			await createInventoryEntries(pb, session.id, entries, (done, total) => {
-				setProgress({ done, total })
-			})
-
-			setProgress(null)
-
-			const started = await startInventorySession(pb, session.id)
-			return { session: started, entriesCount: entries.length }
+			} catch (err) {
+				// Le gel a échoué en bloc : rien n'a été créé, le brouillon vide
+				// ne doit pas traîner dans les sessions actives.
+				await pb
+					.collection(INVENTORY_SESSIONS_COLLECTION)
+					.delete(session.id)
+					.catch(() => {})
+				throw err
+			}
 		},
 		onSuccess: () => {
 			queryClient.invalidateQueries({ queryKey: inventoryKeys.all })
 		},
-		onError: () => {
-			setProgress(null)
-		},
 	})
-
-	return { ...mutation, progress }
 }
 
 export { inventoryKeys as inventoryQueryKeys }
@@ -460,7 +390,7 @@ export function useInventorySessionDetail(sessionId: string | undefined) {
 						.filter((e) => e.stock_compte !== null)
 						.map((e) => ({
 							entry: e,
-							ecart: (e.stock_compte ?? 0) - e.stock_theorique,
+							ecart: (e.stock_compte ?? 0) - expectedStock(e),
 						}))
 					const withGap = gaps.filter((g) => g.ecart !== 0)
 					return {
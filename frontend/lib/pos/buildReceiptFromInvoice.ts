@@ -1,7 +1,7 @@
 // frontend/lib/pos/buildReceiptFromInvoice.ts
 //
 // Transforme un InvoiceResponse (ticket POS persisté en base) en
-// PrintReceiptPayload prêt à passer à printReceipt() ou openReceiptPreviewWindow().
+// PrintReceiptPayload prêt à passer à openReceiptPreviewWindow().
 //
 // ⚠️  Cette fonction est PURE : aucun hook, aucun side-effect.
 //     Elle peut être appelée depuis n'importe quel contexte (hook, callback…).
@@ -0,0 +1,95 @@
+// frontend/lib/pos/customerScreen.ts
+// Écran client web : la caisse publie son panier complet, une tablette du
+// réseau local l'affiche sur /display/<caisse>?k=<clé> (page servie par le
+// serveur embarqué). Complète l'afficheur VFD 2×20 de display.ts.
+
+import { useEffect, useRef } from 'react'
+
+export type CustomerScreenPhase =
+	| 'idle'
+	| 'cart'
+	| 'payment'
+	| 'change'
+	| 'thanks'
+
+export interface CustomerScreenLine {
+	name: string
+	quantity: number
+	unit_price_ttc: number
+	base_price_ttc?: number
+	total_ttc: number
+	discount_label?: string
+	image_url?: string
+}
+
+export interface CustomerScreenState {
+	phase: CustomerScreenPhase
+	items: CustomerScreenLine[]
+	item_count: number
+	subtotal_ttc: number
+	discount_amount: number
+	total_ttc: number
+	total_savings: number
+	payment_method?: string
+	received: number
+	change: number
+	message?: string
+}
+
+export async function publishCustomerScreen(
+	pb: any,
+	registerId: string,
+	state: CustomerScreenState,
+): Promise<{ viewers: number }> {
+	return pb.send(`/api/display/screen/${registerId}`, {
+		method: 'POST',
+		body: JSON.stringify(state),
+		headers: { 'Content-Type': 'application/json' },
+	})
+}
+
+// Génère une nouvelle clé (les tablettes appairées avant sont déconnectées)
+// et retourne l'URL à ouvrir sur la tablette, avec l'IP locale du serveur
+export async function pairCustomerScreen(
+	pb: any,
+	registerId: string,
+): Promise<string> {
+	const res = await pb.send(`/api/display/screen/${registerId}/pair`, {
+		method: 'POST',
+	})
+	let origin = document.location.origin
+	try {
+		const info = await pb.send('/api/network/info', { method: 'GET' })
+		if (info?.url) origin = info.url
+	} catch {}
+	return `${origin}${res.path}`
+}
+
+/**
+ * Publie l'état du panier sur l'écran client de la caisse, regroupé sur
+ * 150 ms pour ne pas envoyer une requête par touche saisie.
+ */
+export function useCustomerScreen(
+	pb: any,
+	registerId: string | undefined,
+	state: CustomerScreenState,
+	enabled = true,
+) {
+	const lastSentRef = useRef('')
+	const serialized = JSON.stringify(state)
+
+	useEffect(() => {
+		if (!enabled || !registerId) return
+		if (serialized === lastSentRef.current) return
+
+		const timer = setTimeout(() => {
+			lastSentRef.current = serialized
+			publishCustomerScreen(pb, registerId, JSON.parse(serialized)).catch(
+				(err) => {
+					console.warn('[CustomerScreen] Publication impossible:', err)
+				},
+			)
+		}, 150)
+		return () => clearTimeout(timer)
+	}, [pb, registerId, serialized, enabled])
+}
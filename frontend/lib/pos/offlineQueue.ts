@@ -0,0 +1,204 @@
+// frontend/lib/pos/offlineQueue.ts
+// 📴 File locale des tickets d'un poste navigateur coupé de l'hôte
+//
+// Pendant la coupure, chaque vente reçoit un identifiant provisoire
+// (offline_id) et attend dans localStorage. Au retour de l'hôte, la file est
+// rejouée DANS L'ORDRE via /api/pos/ticket : c'est l'hôte qui numérote et
+// chaîne. Le serveur ignore un offline_id déjà enregistré, un rejeu
+// interrompu peut donc être relancé sans risque de doublon.
+
+import type PocketBase from 'pocketbase'
+import type { PosTicketInput } from '@/lib/queries/pos'
+
+const STORAGE_KEY = 'pos_offline_queue_v1'
+
+export type OfflineTicketStatus = 'pending' | 'failed'
+
+export interface OfflineTicket {
+	offline_id: string
+	created_at: string
+	input: PosTicketInput
+	status: OfflineTicketStatus
+	error?: string
+}
+
+export interface OfflineReconcileEntry {
+	offline_id: string
+	status: 'synced' | 'pending'
+	ticket_id?: string
+	ticket_number?: string
+	total_ttc?: number
+	offline_created_at?: string
+	synced_at?: string
+}
+
+export interface FlushResult {
+	synced: OfflineReconcileEntry[]
+	failed: OfflineTicket[]
+	remaining: number
+	stoppedOffline: boolean
+}
+
+// ============================================================================
+// STOCKAGE
+// ============================================================================
+
+export function loadOfflineQueue(): OfflineTicket[] {
+	try {
+		const raw = localStorage.getItem(STORAGE_KEY)
+		if (!raw) return []
+		const parsed = JSON.parse(raw)
+		return Array.isArray(parsed) ? parsed : []
+	} catch {
+		return []
+	}
+}
+
+function saveOfflineQueue(queue: OfflineTicket[]) {
+	try {
+		localStorage.setItem(STORAGE_KEY, JSON.stringify(queue))
+	} catch (error) {
+		console.error('Erreur sauvegarde file hors-ligne:', error)
+	}
+}
+
+function getWorkstationId(): string {
+	const key = 'pos_workstation_id'
+	let id = localStorage.getItem(key)
+	if (!id) {
+		id = Math.random().toString(36).slice(2, 8).toUpperCase()
+		localStorage.setItem(key, id)
+	}
+	return id
+}
+
+// newOfflineId : identifiant provisoire, unique par poste
+export function newOfflineId(): string {
+	const stamp = new Date().toISOString().replace(/[-:TZ.]/g, '').slice(0, 14)
+	const rand = Math.random().toString(36).slice(2, 6).toUpperCase()
+	return `OFF-${getWorkstationId()}-${stamp}-${rand}`
+}
+
+// withOfflineId complète un ticket avec son identifiant provisoire, attribué
+// AVANT l'envoi : si la réponse se perd, le rejeu ne crée pas de doublon.
+export function withOfflineId(input: PosTicketInput): PosTicketInput {
+	if (input.offline_id) return input
+	return {
+		...input,
+		offline_id: newOfflineId(),
+		offline_created_at: new Date().toISOString(),
+		workstation: getWorkstationId(),
+	}
+}
+
+export function enqueueOfflineTicket(input: PosTicketInput): OfflineTicket {
+	const withId = withOfflineId(input)
+	const entry: OfflineTicket = {
+		offline_id: withId.offline_id as string,
+		created_at: withId.offline_created_at as string,
+		input: withId,
+		status: 'pending',
+	}
+	saveOfflineQueue([...loadOfflineQueue(), entry])
+	return entry
+}
+
+// isNetworkError : l'hôte n'a pas répondu (≠ refus métier, qui a un statut HTTP)
+export function isNetworkError(error: unknown): boolean {
+	const e = error as { status?: number; isAbort?: boolean }
+	return !e?.isAbort && (e?.status === 0 || e?.status === undefined)
+}
+
+// ============================================================================
+// REJEU + RÉCONCILIATION
+// ============================================================================
+
+export async function reconcileOfflineTickets(
+	pb: PocketBase,
+	ownerCompany: string,
+	offlineIds: string[],
+): Promise<OfflineReconcileEntry[]> {
+	if (offlineIds.length === 0) return []
+	const response = await pb.send('/api/pos/offline/reconcile', {
+		method: 'POST',
+		body: JSON.stringify({
+			owner_company: ownerCompany,
+			offline_ids: offlineIds,
+		}),
+		headers: { 'Content-Type': 'application/json' },
+	})
+	return (response?.results ?? []) as OfflineReconcileEntry[]
+}
+
+// flushOfflineQueue rejoue la file dans l'ordre. Elle s'arrête au premier
+// échec réseau (l'ordre de chaînage doit être respecté) ; un refus métier
+// (session fermée, panier invalide…) marque le ticket "failed" et passe au
+// suivant, pour qu'un responsable le traite sans bloquer la file.
+export async function flushOfflineQueue(pb: PocketBase): Promise<FlushResult> {
+	const queue = loadOfflineQueue()
+	const failed: OfflineTicket[] = []
+	const sent: OfflineTicket[] = []
+	let stoppedOffline = false
+
+	// Les ventes mises en file pendant le rejeu sont conservées à la fin
+	const knownIds = new Set(queue.map((t) => t.offline_id))
+	const persist = () => {
+		const added = loadOfflineQueue().filter((t) => !knownIds.has(t.offline_id))
+		saveOfflineQueue([...failed, ...remaining, ...added])
+	}
+
+	const remaining = [...queue]
+	while (remaining.length > 0) {
+		const entry = remaining[0]
+		if (entry.status === 'failed') {
+			failed.push(entry)
+			remaining.shift()
+			continue
+		}
+		try {
+			await pb.send('/api/pos/ticket', {
+				method: 'POST',
+				body: JSON.stringify(entry.input),
+				headers: { 'Content-Type': 'application/json' },
+			})
+			sent.push(entry)
+			remaining.shift()
+		} catch (error: any) {
+			if (isNetworkError(error)) {
+				stoppedOffline = true
+				break
+			}
+			failed.push({
+				...entry,
+				status: 'failed',
+				error: error?.message || 'Ticket refusé par le serveur',
+			})
+			remaining.shift()
+		}
+		persist()
+	}
+	persist()
+
+	// Correspondance provisoire → ticket pour tout ce qui a été rejoué
+	const byCompany = new Map<string, string[]>()
+	for (const t of sent) {
+		const list = byCompany.get(t.input.owner_company) ?? []
+		list.push(t.offline_id)
+		byCompany.set(t.input.owner_company, list)
+	}
+
+	const synced: OfflineReconcileEntry[] = []
+	if (!stoppedOffline) {
+		for (const [company, ids] of byCompany) {
+			const results = await reconcileOfflineTickets(pb, company, ids)
+			synced.push(...results.filter((r) => r.status === 'synced'))
+		}
+	}
+
+	return {
+		synced,
+		failed,
+		remaining: remaining.length,
+		stoppedOffline,
+	}
+}
@@ -37,14 +37,45 @@ export type PrintReceiptPayload = {
 
 type PrintPosReceiptInput = {
 	printerName: string
+	// Caisse dont la configuration (settings.printer) choisit le transport :
+	// tcp 9100, CUPS, USB ou spooler. Prioritaire sur printerName côté serveur,
+	// qui ne désigne qu'une file du système.
+	cashRegisterId?: string
 	width: 58 | 80
-	companyId?: string
-	receipt: PrintReceiptPayload
+	// Ticket enregistré : le serveur reconstruit le ticket imprimé depuis la
+	// base (mentions légales comprises). Rien d'autre ne s'imprime.
+	ticketId: string
 }
 
+// Réponse de /api/pos/print : un ticket qui ne sort pas reste en file
+// côté serveur (queued) et sera relancé jusqu'à impression
+export type PrintReceiptResult = {
+	success: boolean
+	queued?: boolean
+	jobId?: string
+	message?: string
+	error?: string
+}
+
+// Motif d'une ouverture du tiroir, journalisée côté serveur
+// (cash_drawer_events). sale/refund exigent un ticket de la session ouverte ;
+// les autres motifs sont journalisés en no_sale (rôle minimum de la caisse).
+export type DrawerOpenReason =
+	| 'sale'
+	| 'refund'
+	| 'cash_movement'
+	| 'session_open'
+	| 'session_close'
+	| 'no_sale'
+	| 'test'
+
 type OpenCashDrawerInput = {
 	printerName: string
+	cashRegisterId?: string
 	width: 58 | 80
+	reason: DrawerOpenReason
+	note?: string
+	invoiceId?: string
 }
 
 // ============================================================================
@@ -60,6 +91,15 @@ function getPosApiBaseUrl(): string {
 	return `${document.location.origin}/api/pos`
 }
 
+// Les routes d'impression exigent un utilisateur connecté : le jeton
+// PocketBase accompagne chaque appel
+function posApiHeaders(pb: any): Record<string, string> {
+	return {
+		'Content-Type': 'application/json',
+		Authorization: pb.authStore.token,
+	}
+}
+
 // ============================================================================
 // WAILS BINDINGS
 // ============================================================================
@@ -87,28 +127,18 @@ async function callGo(method: string, payload: any) {
 // API HTTP
 // ============================================================================
 
-async function printReceiptHttp(payload: PrintPosReceiptInput): Promise<void> {
-	console.log(
-		'[POS PRINT] HTTP payload',
-		JSON.stringify(
-			{
-				printerName: payload.printerName,
-				width: payload.width,
-				companyId: payload.companyId || '',
-				receipt: payload.receipt,
-			},
-			null,
-			2,
-		),
-	)
+async function printReceiptHttp(
+	pb: any,
+	payload: PrintPosReceiptInput,
+): Promise<PrintReceiptResult> {
 	const response = await fetch(`${getPosApiBaseUrl()}/print`, {
 		method: 'POST',
-		headers: { 'Content-Type': 'application/json' },
+		headers: posApiHeaders(pb),
 		body: JSON.stringify({
 			printerName: payload.printerName,
+			cashRegisterId: payload.cashRegisterId || '',
 			width: payload.width,
-			companyId: payload.companyId || '',
-			receipt: payload.receipt,
+			ticketId: payload.ticketId,
 		}),
 	})
 	if (!response.ok) {
@@ -118,29 +148,16 @@ async function printReceiptHttp(payload: PrintPosReceiptInput): Promise<void> {
 	return response.json()
 }
 
-async function openCashDrawerHttp(payload: OpenCashDrawerInput): Promise<void> {
-	const response = await fetch(`${getPosApiBaseUrl()}/drawer/open`, {
-		method: 'POST',
-		headers: { 'Content-Type': 'application/json' },
-		body: JSON.stringify({
-			printerName: payload.printerName,
-			width: payload.width,
-		}),
-	})
-	if (!response.ok) {
-		const errorData = await response.json().catch(() => ({}))
-		throw new Error(errorData.error || `HTTP ${response.status}`)
-	}
-	return response.json()
-}
-
-async function testPrintHttp(payload: {
-	printerName: string
-	width: 58 | 80
-}): Promise<void> {
+async function testPrintHttp(
+	pb: any,
+	payload: {
+		printerName: string
+		width: 58 | 80
+	},
+): Promise<void> {
 	const response = await fetch(`${getPosApiBaseUrl()}/test-print`, {
 		method: 'POST',
-		headers: { 'Content-Type': 'application/json' },
+		headers: posApiHeaders(pb),
 		body: JSON.stringify({
 			printerName: payload.printerName,
 			width: payload.width,
@@ -157,36 +174,81 @@ async function testPrintHttp(payload: {
 // EXPORTS
 // ============================================================================
 
-export async function printReceipt(payload: PrintPosReceiptInput) {
-	console.log('[POS PRINT] mode', isWailsEnv() ? 'WAILS' : 'HTTP')
-	console.log('[POS PRINT] receipt.vatBreakdown', payload.receipt.vatBreakdown)
-	if (isWailsEnv()) {
-		await callGo('PrintPosReceipt', {
-			printerName: payload.printerName,
-			width: payload.width,
-			companyId: payload.companyId,
-			receipt: payload.receipt,
-		})
-	} else {
-		await printReceiptHttp(payload)
+// Toujours via le serveur, même en Wails : c'est lui qui reconstruit le
+// ticket depuis la base
+export async function printReceipt(
+	pb: any,
+	payload: PrintPosReceiptInput,
+): Promise<PrintReceiptResult> {
+	return printReceiptHttp(pb, payload)
+}
+
+// État temps réel de l'imprimante (DLE EOT) — transports TCP et USB
+// seulement ; derrière un spooler ou CUPS, known = false
+export type PrinterStatus = {
+	known: boolean
+	online: boolean
+	paper_end: boolean
+	paper_near_end: boolean
+	cover_open: boolean
+	error: boolean
+	drawer_open: boolean
+	message?: string
+	checked_at: string
+}
+
+export type PrinterStatusResult = {
+	printer: string
+	status: PrinterStatus
+	ready: boolean
+	reason: string
+	pending_jobs: number
+}
+
+// target : id de caisse (imprimante configurée sur la caisse) ou nom d'une
+// file du système
+export async function fetchPrinterStatus(
+	pb: any,
+	target: string,
+): Promise<PrinterStatusResult> {
+	const response = await fetch(
+		`${getPosApiBaseUrl()}/printers/${encodeURIComponent(target)}/status`,
+		{ headers: posApiHeaders(pb) },
+	)
+	if (!response.ok) {
+		const errorData = await response.json().catch(() => ({}))
+		throw new Error(errorData.error || `HTTP ${response.status}`)
 	}
+	return response.json()
 }
 
-export async function openCashDrawer(payload: OpenCashDrawerInput) {
-	if (isWailsEnv()) {
-		await callGo('OpenCashDrawer', {
+// Toujours via le serveur, même en Wails : c'est lui qui journalise
+// l'ouverture (utilisateur, session, motif)
+export async function openCashDrawer(
+	pb: any,
+	payload: OpenCashDrawerInput,
+) {
+	return pb.send('/api/pos/drawer/open', {
+		method: 'POST',
+		body: JSON.stringify({
 			printerName: payload.printerName,
+			cashRegisterId: payload.cashRegisterId || '',
 			width: payload.width,
-		})
-	} else {
-		await openCashDrawerHttp(payload)
-	}
+			reason: payload.reason,
+			note: payload.note || '',
+			invoiceId: payload.invoiceId || '',
+		}),
+		headers: { 'Content-Type': 'application/json' },
+	})
 }
 
-export async function testPrint(payload: {
-	printerName: string
-	width: 58 | 80
-}): Promise<void> {
+export async function testPrint(
+	pb: any,
+	payload: {
+		printerName: string
+		width: 58 | 80
+	},
+): Promise<void> {
 	if (isWailsEnv()) {
 		const testReceipt: PrintReceiptPayload = {
 			companyName: 'TEST BOUTIQUE',
@@ -212,7 +274,7 @@ export async function testPrint(payload: {
 			receipt: testReceipt,
 		})
 	} else {
-		await testPrintHttp(payload)
+		await testPrintHttp(pb, payload)
 	}
 }
 
@@ -1,12 +1,18 @@
 // frontend/lib/pos/printerQueries.ts
 // Adapté pour fonctionner en mode Wails (bindings) ET en mode HTTP (réseau)
 
+import { usePocketBase } from '@/lib/use-pocketbase'
 import { queryOptions, useMutation } from '@tanstack/react-query'
 import { toast } from 'sonner'
 import { listWindowsPrinters } from './listPrinters'
-import { openCashDrawer, printReceipt, testPrint } from './posPrint'
+import {
+	type DrawerOpenReason,
+	fetchPrinterStatus,
+	openCashDrawer,
+	printReceipt,
+	testPrint,
+} from './posPrint'
 import { loadPosPrinterSettings } from './printerSettings'
-import type { PrintReceiptPayload } from './printerSettings.schema'
 
 // ========================================
 // Query Keys Factory
@@ -15,6 +21,8 @@ export const printerKeys = {
 	all: ['printers'] as const,
 	lists: () => [...printerKeys.all, 'list'] as const,
 	settings: () => [...printerKeys.all, 'settings'] as const,
+	statuses: () => [...printerKeys.all, 'status'] as const,
+	status: (target: string) => [...printerKeys.statuses(), target] as const,
 }
 
 // ========================================
@@ -31,24 +39,38 @@ export const printersQueryOptions = queryOptions({
 	retry: 1,
 })
 
+// État de l'imprimante : rafraîchi par le flux SSE (événement
+// "printer_status"), le polling n'est qu'un filet de sécurité
+export const printerStatusQueryOptions = (pb: any, target: string) =>
+	queryOptions({
+		queryKey: printerKeys.status(target),
+		queryFn: () => fetchPrinterStatus(pb, target),
+		enabled: !!target,
+		refetchInterval: 60_000,
+		retry: false,
+	})
+
 // ========================================
 // Mutations
 // ========================================
 
+// Le serveur imprime le ticket enregistré (haché), jamais un ticket composé
+// par le poste
 type PrintReceiptInput = {
-	receipt: PrintReceiptPayload
+	ticketId: string
+	cashRegisterId?: string
 	printerName?: string
 	width?: 58 | 80
-	companyId?: string
 }
 
 export function usePrintReceiptMutation() {
+	const pb = usePocketBase()
 	return useMutation({
 		mutationFn: async ({
-			receipt,
+			ticketId,
+			cashRegisterId,
 			printerName,
 			width,
-			companyId,
 		}: PrintReceiptInput) => {
 			const settings = loadPosPrinterSettings()
 
@@ -57,47 +79,26 @@ export function usePrintReceiptMutation() {
 			}
 
 			const finalPrinterName = printerName || settings.printerName
-			if (!finalPrinterName) {
+			if (!finalPrinterName && !cashRegisterId) {
 				throw new Error('Aucune imprimante sélectionnée')
 			}
 
-			const finalWidth = width || settings.width
-
-			const receiptForPrint = {
-				...receipt,
-				vatBreakdown: (receipt as any).vatBreakdown ?? [], // si déjà présent
-			} as any
-
-			console.log(
-				'[POS PRINT] vatBreakdown computed',
-				receiptForPrint.vatBreakdown?.map((vb: any) => ({
-					rate: vb.rate,
-					baseHt: vb.baseHt,
-					vat: vb.vat,
-					totalTtc: vb.totalTtc,
-				})),
-			)
-
-			await printReceipt({
+			const result = await printReceipt(pb, {
 				printerName: finalPrinterName,
-				width: finalWidth,
-				receipt: receiptForPrint,
-				companyId,
+				cashRegisterId,
+				width: width || settings.width,
+				ticketId,
 			})
-			// Ouverture tiroir auto si espèces
-			if (
-				settings.autoOpenDrawer &&
-				receipt.paymentMethod.toLowerCase().includes('espèce')
-			) {
-				await openCashDrawer({
-					printerName: finalPrinterName,
-					width: finalWidth,
-				})
-			}
 
-			return { success: true }
+			return { success: true, queued: !!result.queued, error: result.error }
 		},
-		onSuccess: () => {
+		onSuccess: (result) => {
+			if (result.queued) {
+				toast.warning("Ticket en attente d'impression", {
+					description: result.error,
+				})
+				return
+			}
 			toast.success('Ticket imprimé avec succès')
 		},
 		onError: (error: Error) => {
@@ -106,19 +107,29 @@ export function usePrintReceiptMutation() {
 	})
 }
 
+type OpenDrawerInput = {
+	reason: DrawerOpenReason
+	// Obligatoire pour une ouverture sans vente (no_sale)
+	note?: string
+	cashRegisterId?: string
+	invoiceId?: string
+}
+
 export function useOpenCashDrawerMutation() {
+	const pb = usePocketBase()
 	return useMutation({
-		mutationFn: async () => {
+		mutationFn: async (input: OpenDrawerInput) => {
 			const settings = loadPosPrinterSettings()
 
 			if (!settings.enabled || !settings.printerName) {
 				throw new Error('Imprimante non configurée')
 			}
 
-			// ✅ MODIFIÉ : Utilise la nouvelle fonction qui détecte automatiquement Wails/HTTP
-			await openCashDrawer({
+			// Journalisée côté serveur (utilisateur, session, motif)
+			await openCashDrawer(pb, {
 				printerName: settings.printerName,
 				width: settings.width,
+				...input,
 			})
 
 			return { success: true }
@@ -136,6 +147,7 @@ export function useOpenCashDrawerMutation() {
 // Test Mutations
 // ========================================
 export function useTestPrintMutation() {
+	const pb = usePocketBase()
 	return useMutation({
 		mutationFn: async ({
 			printerName,
@@ -145,7 +157,7 @@ export function useTestPrintMutation() {
 			width: 58 | 80
 		}) => {
 			// ✅ MODIFIÉ : Utilise la nouvelle fonction qui détecte automatiquement Wails/HTTP
-			await testPrint({
+			await testPrint(pb, {
 				printerName,
 				width,
 			})
@@ -10,19 +10,63 @@ import { useCallback, useEffect, useRef, useState } from 'react'
 
 export type ScannerStatus = {
 	running: boolean
+	// false pendant la reconnexion après un débranchement USB
+	connected?: boolean
+	reconnects?: number
 	portName: string
 	baudRate: number
 	subscribers: number
 }
 
+// Scan décodé et résolu par le serveur (backend/pos/scancode.go)
+export type ScanCode = {
+	raw: string
+	kind: 'gs1' | 'in_store' | 'ean' | 'ticket' | 'voucher' | 'text'
+	barcodes?: string[]
+	gtin?: string
+	lot?: string
+	serial?: string
+	expiry?: string
+	price?: number
+	weight_kg?: number
+	item_code?: string
+	ticket_code?: string
+}
+
+export type ScanEvent = {
+	type: 'product' | 'unknown_code' | 'ticket' | 'voucher'
+	code: ScanCode
+	// Un produit par entreprise au plus ; quantité et prix imposés par le
+	// code (poids ou prix embarqués)
+	products?: Array<{
+		id: string
+		company: string
+		name: string
+		sku: string
+		barcode: string
+		price_ttc: number
+		quantity: number
+		unit_price_ttc: number
+	}>
+	document?: {
+		id: string
+		number: string
+		company: string
+		total_ttc: number
+		remaining_amount: number
+	}
+	error?: string
+}
+
 export type ScanMessage = {
 	type: 'connected' | 'scan' | 'pong'
 	barcode?: string
+	event?: ScanEvent
 	message?: string
 	status?: ScannerStatus
 }
 
-export type ScanCallback = (barcode: string) => void
+export type ScanCallback = (barcode: string, event?: ScanEvent) => void
 
 // ============================================================================
 // CONFIGURATION
@@ -76,7 +120,7 @@ class ScannerClient {
 
 			if (data.type === 'scan' && data.barcode) {
 				for (const cb of this.callbacks) {
-					cb(data.barcode)
+					cb(data.barcode, data.event)
 				}
 			}
 		}
@@ -175,6 +219,16 @@ export async function stopScanner(): Promise<void> {
 	}
 }
 
+// Résout un code saisi hors scanette série (HID, clavier)
+export async function resolveScanCode(
+	pb: any,
+	code: string,
+): Promise<ScanEvent> {
+	return pb.send(`/api/scanner/resolve?code=${encodeURIComponent(code)}`, {
+		method: 'GET',
+	})
+}
+
 export async function simulateScan(barcode: string): Promise<void> {
 	const response = await fetch(`${getApiBaseUrl()}/api/scanner/simulate`, {
 		method: 'POST',
@@ -231,9 +285,9 @@ export function useScanner(onScan?: ScanCallback) {
 	}, [])
 
 	useEffect(() => {
-		const handleScan = (barcode: string) => {
+		const handleScan = (barcode: string, event?: ScanEvent) => {
 			setLastScan(barcode)
-			onScanRef.current?.(barcode)
+			onScanRef.current?.(barcode, event)
 		}
 
 		const unsubscribe = scannerClient.onScan(handleScan)
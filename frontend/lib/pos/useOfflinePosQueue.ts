@@ -0,0 +1,120 @@
+// frontend/lib/pos/useOfflinePosQueue.ts
+//
+// Hook qui vide la file hors-ligne du poste dès que l'hôte est de nouveau
+// joignable : au montage, à l'événement navigateur "online", puis toutes les
+// 30 s tant que la file n'est pas vide.
+//
+// Après le rejeu, le stock des tickets numérotés est décrémenté (il ne
+// pouvait pas l'être pendant la coupure) et les vues caisse sont rafraîchies.
+
+import { useQueryClient } from '@tanstack/react-query'
+import * as React from 'react'
+import { toast } from 'sonner'
+
+import { recordSale } from '@/lib/queries/stock-adjust'
+import { usePocketBase } from '@/lib/use-pocketbase'
+
+import {
+	type OfflineTicket,
+	flushOfflineQueue,
+	loadOfflineQueue,
+} from './offlineQueue'
+
+const RETRY_INTERVAL_MS = 30_000
+
+export function useOfflinePosQueue() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	const [queue, setQueue] = React.useState<OfflineTicket[]>(loadOfflineQueue)
+	const flushingRef = React.useRef(false)
+
+	const refresh = React.useCallback(() => setQueue(loadOfflineQueue()), [])
+
+	const flush = React.useCallback(async () => {
+		if (flushingRef.current) return
+		const pending = loadOfflineQueue()
+		if (!pending.some((t) => t.status === 'pending')) {
+			setQueue(pending)
+			return
+		}
+
+		flushingRef.current = true
+		try {
+			const inputs = new Map(pending.map((t) => [t.offline_id, t.input]))
+			const result = await flushOfflineQueue(pb)
+
+			for (const entry of result.synced) {
+				const input = inputs.get(entry.offline_id)
+				if (!input || !entry.ticket_number) continue
+				try {
+					await recordSale(
+						pb,
+						input.items.map((item) => ({
+							productId: item.product_id ?? '',
+							productName: item.name,
+							productSku: '',
+							quantity: item.quantity,
+						})),
+						{
+							sourceId: entry.ticket_number,
+							cashRegisterId: input.cash_register,
+						},
+					)
+				} catch {
+					toast.warning(
+						`Ticket ${entry.ticket_number} numéroté mais stock non synchronisé`,
+					)
+				}
+			}
+
+			if (result.synced.length > 0) {
+				toast.success(
+					`${result.synced.length} ticket(s) hors-ligne numéroté(s) : ${result.synced
+						.map((e) => `${e.offline_id} → ${e.ticket_number}`)
+						.join(', ')}`,
+				)
+				queryClient.invalidateQueries({ queryKey: ['invoices'] })
+				queryClient.invalidateQueries({ queryKey: ['tickets'] })
+				queryClient.invalidateQueries({ queryKey: ['session_tickets'] })
+				queryClient.invalidateQueries({ queryKey: ['x_report'] })
+			}
+			const wasPending = new Set(
+				pending.filter((t) => t.status === 'pending').map((t) => t.offline_id),
+			)
+			const newlyFailed = result.failed.filter((t) =>
+				wasPending.has(t.offline_id),
+			)
+			if (newlyFailed.length > 0) {
+				toast.error(
+					`${newlyFailed.length} ticket(s) hors-ligne refusé(s) par le serveur`,
+				)
+			}
+		} catch (error) {
+			console.error('Erreur rejeu file hors-ligne:', error)
+		} finally {
+			flushingRef.current = false
+			refresh()
+		}
+	}, [pb, queryClient, refresh])
+
+	React.useEffect(() => {
+		flush()
+		window.addEventListener('online', flush)
+		const timer = window.setInterval(() => {
+			if (loadOfflineQueue().some((t) => t.status === 'pending')) flush()
+		}, RETRY_INTERVAL_MS)
+		return () => {
+			window.removeEventListener('online', flush)
+			window.clearInterval(timer)
+		}
+	}, [flush])
+
+	return {
+		queue,
+		pendingCount: queue.filter((t) => t.status === 'pending').length,
+		failed: queue.filter((t) => t.status === 'failed'),
+		flush,
+		refresh,
+	}
+}
@@ -5,7 +5,6 @@ import {
 	usePrintReceiptMutation,
 } from './printerQueries'
 import { loadPosPrinterSettings } from './printerSettings'
-import type { PrintReceiptPayload } from './printerSettings.schema'
 
 /**
  * Hook principal pour gérer l'impression POS
@@ -20,9 +19,9 @@ export function usePosPrinter() {
 
 	return {
 		// Actions
-		print: (receipt: PrintReceiptPayload) =>
-			printMutation.mutateAsync({ receipt }),
-		openDrawer: () => drawerMutation.mutateAsync(),
+		print: (ticketId: string) => printMutation.mutateAsync({ ticketId }),
+		openDrawer: (input: Parameters<typeof drawerMutation.mutateAsync>[0]) =>
+			drawerMutation.mutateAsync(input),
 
 		// États
 		isPrinting: printMutation.isPending,
@@ -1,7 +1,8 @@
 // frontend/lib/pos/useReprintTicket.ts
 //
 // Hook qui expose deux actions :
-//   • reprintTicket(ticket)  — envoie à l'imprimante physique (si configurée)
+//   • reprintTicket(ticket)  — imprime un DUPLICATA (NF525) : le serveur
+//     reconstruit le ticket depuis la base, le numérote et trace la réimpression
 //   • previewTicket(ticket)  — ouvre la preview HTML dans un nouvel onglet
 //
 // Les deux actions acceptent un InvoiceResponse complet (items déjà présents).
@@ -21,7 +22,6 @@ import {
 	buildReceiptFromInvoice,
 } from './buildReceiptFromInvoice'
 import { openReceiptPreviewWindow } from './posPreview'
-import { printReceipt } from './posPrint'
 import { loadPosPrinterSettings } from './printerSettings'
 
 export function useReprintTicket() {
@@ -46,12 +46,16 @@ export function useReprintTicket() {
 		}
 	}, [activeCompany, pb])
 
-	// ── Impression physique ──────────────────────────────────────────────────
+	// ── Impression physique (duplicata) ─────────────────────────────────────
 	const reprintTicket = React.useCallback(
 		async (ticket: InvoiceResponse) => {
 			const printerSettings = loadPosPrinterSettings()
+			const cashRegisterId = (ticket as any).cash_register as string | undefined
 
-			if (!printerSettings.enabled || !printerSettings.printerName) {
+			if (
+				!printerSettings.enabled ||
+				(!printerSettings.printerName && !cashRegisterId)
+			) {
 				toast.error(
 					"Aucune imprimante configurée. Vérifiez les paramètres d'impression.",
 				)
@@ -60,27 +64,34 @@ export function useReprintTicket() {
 
 			setIsPrinting(true)
 			try {
-				const logoBase64 = await getLogoBase64()
-				const receipt: PrintReceiptPayloadWithLogo = buildReceiptFromInvoice(
-					ticket,
-					logoBase64,
-				)
-
-				await printReceipt({
-					printerName: printerSettings.printerName,
-					width: printerSettings.width,
-					companyId: activeCompanyId ?? undefined,
-					receipt: receipt as any,
+				// L'imprimante du poste d'abord ; à défaut, celle configurée
+				// sur la caisse du ticket
+				const result = await pb.send(`/api/pos/ticket/${ticket.id}/reprint`, {
+					method: 'POST',
+					body: {
+						printerName: printerSettings.printerName,
+						cashRegisterId: printerSettings.printerName ? '' : cashRegisterId,
+						width: printerSettings.width,
+					},
 				})
 
-				toast.success(`Ticket ${ticket.number} réimprimé`)
+				if (result?.queued) {
+					toast.warning(
+						`Duplicata n°${result.duplicate_number} en attente d'impression`,
+						{ description: result.error },
+					)
+				} else {
+					toast.success(
+						`Duplicata n°${result?.duplicate_number} du ticket ${ticket.number} imprimé`,
+					)
+				}
 			} catch (err: any) {
 				toast.error(err?.message || "Erreur lors de l'impression")
 			} finally {
 				setIsPrinting(false)
 			}
 		},
-		[activeCompanyId, getLogoBase64],
+		[pb],
 	)
 
 	// ── Aperçu dans un nouvel onglet ─────────────────────────────────────────
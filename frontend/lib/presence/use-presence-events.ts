@@ -9,6 +9,8 @@
 //   "message"    → notification persistante (useNotifications) + toast
 //   "task"       → toast persistant avec bouton accusé de réception
 //   "invalidate" → queryClient.invalidateQueries({ queryKey: [...] })
+//   "printer_status" → état d'imprimante (papier, capot) : toast + cache
+//   "print_job"  → avancement de la file d'impression : toast + cache
 //   "connected"  → log silencieux (confirmation de connexion)
 //
 // Reconnexion automatique exponentielle (1s → 2s → 4s → max 30s).
@@ -52,7 +54,13 @@ import type { AppNotification } from '@/lib/notifications'
 
 // ─── Types ──────────────────────────────────────────────────────────────────
 
-export type SSEEventType = 'message' | 'task' | 'invalidate' | 'connected'
+export type SSEEventType =
+	| 'message'
+	| 'task'
+	| 'invalidate'
+	| 'printer_status'
+	| 'print_job'
+	| 'connected'
 
 export interface SSEFrom {
 	userId: string
@@ -75,6 +83,28 @@ export interface SSEInvalidatePayload {
 	payload: { queryKey: unknown[] }
 }
 
+// Émis par le serveur lui-même (file d'impression), sans expéditeur
+export interface SSEPrinterStatusPayload {
+	target: string
+	printer: string
+	status: {
+		known: boolean
+		online: boolean
+		paper_end: boolean
+		paper_near_end: boolean
+		cover_open: boolean
+		error: boolean
+	}
+}
+
+export interface SSEPrintJobPayload {
+	id: string
+	status: 'pending' | 'printed' | 'failed' | 'cancelled'
+	label: string
+	attempts: number
+	last_error: string
+}
+
 // ─── Hook ────────────────────────────────────────────────────────────────────
 
 interface UsePresenceEventsOptions {
@@ -203,6 +233,52 @@ export function usePresenceEvents({
 				}
 			})
 
+			// ── État d'imprimante (papier, capot, hors ligne) ─────────────
+			es.addEventListener('printer_status', (e) => {
+				try {
+					const data = JSON.parse(e.data) as SSEPrinterStatusPayload
+					queryClient.invalidateQueries({
+						queryKey: ['printers', 'status', data.target],
+					})
+					const s = data.status
+					if (!s?.known) return
+					if (s.paper_end) {
+						toast.error('🖨️ Imprimante : papier épuisé', {
+							description: 'Les tickets sont conservés et sortiront au rechargement.',
+						})
+					} else if (s.cover_open) {
+						toast.error('🖨️ Imprimante : capot ouvert')
+					} else if (!s.online || s.error) {
+						toast.error('🖨️ Imprimante hors ligne', {
+							description: data.printer,
+						})
+					} else if (s.paper_near_end) {
+						toast.warning('🖨️ Fin de rouleau proche')
+					}
+				} catch (err) {
+					console.warn('[SSE] printer_status parse error', err)
+				}
+			})
+
+			// ── File d'impression ──────────────────────────────────────────
+			es.addEventListener('print_job', (e) => {
+				try {
+					const data = JSON.parse(e.data) as SSEPrintJobPayload
+					queryClient.invalidateQueries({ queryKey: ['print_jobs'] })
+					if (data.status === 'printed' && data.attempts > 1) {
+						toast.success(`🖨️ ${data.label} imprimé`, {
+							description: 'Ticket en attente sorti après reprise.',
+						})
+					} else if (data.status === 'failed') {
+						toast.error(`🖨️ ${data.label} non imprimé`, {
+							description: `Abandonné après ${data.attempts} tentatives : ${data.last_error}`,
+						})
+					}
+				} catch (err) {
+					console.warn('[SSE] print_job parse error', err)
+				}
+			})
+
 			// ── Erreur / reconnexion ───────────────────────────────────────
 			es.onerror = () => {
 				es.close()
@@ -71,7 +71,9 @@ export async function createProductEvents(
 
 /**
  * Crée un événement stock_adjusted_inventory après comptage physique.
- * Appelé depuis countAndAdjustProduct() après le adjusted_at.
+ * Les sessions d'inventaire l'écrivent désormais côté serveur, à la clôture
+ * (backend/routes/inventory_routes.go) ; ce constructeur reste pour les
+ * ajustements hors session.
  */
 export async function createInventoryAdjustmentEvent(
 	pb: PocketBase,
@@ -24,6 +24,8 @@ export type ProductEventType =
 	| 'stock_adjusted_inventory' // écart appliqué après comptage physique
 	| 'stock_sale' // décrémentation après vente
 	| 'stock_return' // incrémentation après retour client
+	| 'stock_received' // réception d'une commande fournisseur (serveur)
+	| 'stock_transferred' // transfert entre emplacements, total inchangé (serveur)
 	// Prix
 	| 'purchase_price_changed'
 	| 'sale_price_changed'
@@ -46,8 +48,11 @@ export type ProductEventSource =
 	| 'return' // retour client
 	| 'apppos_update' // modification via UI AppPOS
 	| 'apppos_sync' // resynchronisation AppPOS → PocketApp
-	| 'manual' // correction manuelle opérateur
+	| 'manual' // correction manuelle opérateur, ou fiche modifiée (serveur)
+	| 'admin' // fiche modifiée depuis le tableau de bord PocketBase (serveur)
 	| 'import' // import externe
+	| 'purchase_receipt' // réception fournisseur (goods_receipts)
+	| 'stock_transfer' // transfert entre emplacements (stock_transfers)
 
 // ============================================================================
 // PAYLOADS BEFORE / AFTER / DELTA (selon event_type)
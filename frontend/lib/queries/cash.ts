@@ -507,7 +507,7 @@ export function useCloseCashSession() {
 		mutationFn: async (params: {
 			sessionId: string
 			cashRegisterId?: string
-			countedCashTotal?: number
+			countedCashTotal: number
 		}) => {
 			const token = pb.authStore.token
 
@@ -520,7 +520,8 @@ export function useCloseCashSession() {
 						Authorization: token ? `Bearer ${token}` : '',
 					},
 					body: JSON.stringify({
-						counted_cash_total: params.countedCashTotal ?? 0,
+						// 0 est un comptage (tiroir vide) ; sans comptage, le serveur refuse
+						counted_cash_total: params.countedCashTotal,
 					}),
 				},
 			)
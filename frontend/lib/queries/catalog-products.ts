@@ -63,6 +63,10 @@ export type CatalogProductShape = PocketBaseRecord & {
 	stock?: number
 	min_stock?: number
 	manage_stock?: boolean
+	/** Suivi à l'unité : un numéro de série par unité reçue et vendue. */
+	serialized?: boolean
+	/** Durée de garantie ; 0 ou absent : celle de la société. */
+	warranty_months?: number
 	image?: string
 	/** Les noms de fichiers de la galerie, DANS L'ORDRE — l'ordre est une
 	 *  donnée (règle du 19 août 2026). Jusqu'à dix. */
@@ -89,7 +93,7 @@ export const PRODUCT_FIELDS =
 	// ⚠️ `gallery` a manqué à cette liste jusqu'au 19 août 2026, et c'est la
 	// raison pour laquelle 747 galeries importées ne s'affichaient nulle part :
 	// **un champ absent de `fields` revient vide, sans erreur.**
-	'id,collectionId,collectionName,legacy_id,name,designation,sku,barcode,slug,status,type,price_ttc,purchase_price_ht,tax_rate,stock,min_stock,manage_stock,image,gallery,brand,supplier,categories'
+	'id,collectionId,collectionName,legacy_id,name,designation,sku,barcode,slug,status,type,price_ttc,purchase_price_ht,tax_rate,stock,min_stock,manage_stock,serialized,warranty_months,image,gallery,brand,supplier,categories'
 
 export type CatalogProductQuery = {
 	companyId?: string
@@ -289,6 +293,8 @@ export type CatalogProductWrite = ImageIntent &
 		stock?: number
 		min_stock?: number
 		manage_stock?: boolean
+		serialized?: boolean
+		warranty_months?: number
 		brand?: string
 		supplier?: string
 		categories?: string[]
@@ -35,6 +35,14 @@ export interface Company {
 	invoice_footer?: string
 	invoice_prefix?: string
 	warranties_text?: string
+	/** Garantie des produits vendus ; 0 : garantie légale (24 mois). */
+	warranty_months?: number
+	/** La caisse avertit ou refuse la vente d'unités réservées. */
+	reservation_policy?: 'warn' | 'block'
+	/** Durée d'une réservation de bon de commande ; 0 : sans échéance. */
+	reservation_order_days?: number
+	/** Durée d'une réservation de panier en attente ; 0 : pas de réservation. */
+	reservation_cart_minutes?: number
 	created?: string
 	updated?: string
 	is_first?: boolean
@@ -69,6 +77,10 @@ export interface CompanyDto {
 	invoice_footer?: string
 	invoice_prefix?: string
 	warranties_text?: string
+	warranty_months?: number
+	reservation_policy?: 'warn' | 'block'
+	reservation_order_days?: number
+	reservation_cart_minutes?: number
 }
 
 // 📋 Liste des entreprises
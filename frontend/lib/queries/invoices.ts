@@ -115,7 +115,11 @@ export function useRefundInvoice() {
 // ---------------------------------------------------------------------------
 
 export interface RefundTicketInput {
-	originalTicketId: string
+	originalTicketId?: string
+	// Code scanné sur le ticket (code-barres du numéro ou QR de vérification),
+	// à défaut d'identifiant : le serveur retrouve le ticket d'origine
+	originalTicketCode?: string
+	ownerCompany?: string
 	refundType: 'full' | 'partial'
 	refundMethod: 'especes' | 'cb' | 'cheque' | 'autre'
 	refundedItems?: {
@@ -164,14 +168,27 @@ function formatRefundTicketError(err: unknown): Error {
 	return new Error(apiMsg || 'Une erreur est survenue lors du remboursement.')
 }
 
+// Ticket POS retrouvé par le code imprimé dessus (GET /api/pos/ticket/lookup)
+export async function lookupPosTicketByCode(
+	pb: any,
+	code: string,
+	ownerCompany?: string,
+): Promise<{ ticket: InvoiceResponse; canRefund: boolean }> {
+	const res = await pb.send('/api/pos/ticket/lookup', {
+		method: 'GET',
+		query: { code, owner_company: ownerCompany ?? '' },
+	})
+	return { ticket: res.ticket as InvoiceResponse, canRefund: !!res.can_refund }
+}
+
 export function useRefundTicket() {
 	const pb = usePocketBase()
 	const queryClient = useQueryClient()
 
 	return useMutation({
 		mutationFn: async (input: RefundTicketInput): Promise<RefundResult> => {
-			if (!input.originalTicketId) {
-				throw new Error('originalTicketId est requis.')
+			if (!input.originalTicketId && !input.originalTicketCode) {
+				throw new Error('originalTicketId ou originalTicketCode est requis.')
 			}
 			if (!input.reason) {
 				throw new Error('reason est requis.')
@@ -185,6 +202,8 @@ export function useRefundTicket() {
 
 			const payload = {
 				original_ticket_id: input.originalTicketId,
+				original_ticket_code: input.originalTicketCode,
+				owner_company: input.ownerCompany,
 				refund_type: input.refundType,
 				refund_method: input.refundMethod,
 				refunded_items:
@@ -0,0 +1,92 @@
+// frontend/lib/queries/labels.ts
+// 🏷️ Étiquettes produit (prix en rayon)
+//
+// Les modèles (label_templates) se gèrent par l'API REST de PocketBase ; le
+// rendu et l'impression passent par /api/labels/* qui choisit les produits
+// (sélection, catégorie, ou prix de vente modifié depuis une date).
+
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useQuery } from '@tanstack/react-query'
+
+export type LabelFormat = 'zpl' | 'epl' | 'escpos' | 'sheet'
+export type LabelField = 'name' | 'brand' | 'sku' | 'barcode'
+
+export interface LabelTemplate {
+	id: string
+	owner_company: string
+	name: string
+	format: LabelFormat
+	is_default: boolean
+	width_mm: number
+	height_mm: number
+	dpi: number
+	columns: number
+	rows: number
+	margin_top_mm: number
+	margin_left_mm: number
+	gap_x_mm: number
+	gap_y_mm: number
+	fields: LabelField[]
+}
+
+export interface LabelRequest {
+	owner_company: string
+	template_id?: string
+	format?: LabelFormat
+	product_ids?: string[]
+	category_id?: string
+	price_changed_since?: string // YYYY-MM-DD
+	copies?: number
+	preview?: boolean
+	// Impression directe
+	printer_name?: string
+	cash_register_id?: string
+	width?: 58 | 80
+}
+
+export function useLabelTemplates(ownerCompany?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['label_templates', ownerCompany],
+		queryFn: async (): Promise<LabelTemplate[]> =>
+			pb.collection('label_templates').getFullList({
+				filter: `owner_company = "${ownerCompany}"`,
+				sort: '-is_default,name',
+			}),
+		enabled: !!ownerCompany,
+	})
+}
+
+// Document d'étiquettes (PDF, HTML, ZPL, EPL ou ESC/POS) à télécharger
+export async function renderLabels(
+	pb: any,
+	input: LabelRequest,
+): Promise<Blob> {
+	const token = pb.authStore.token
+	const res = await fetch('/api/labels/render', {
+		method: 'POST',
+		headers: {
+			'Content-Type': 'application/json',
+			Authorization: token ? `Bearer ${token}` : '',
+		},
+		body: JSON.stringify(input),
+	})
+	if (!res.ok) {
+		const err = await res.json().catch(() => ({}))
+		throw new Error(err.message || `HTTP ${res.status}`)
+	}
+	return res.blob()
+}
+
+// Envoi direct à l'imprimante d'étiquettes (hors planche A4)
+export async function printLabels(
+	pb: any,
+	input: LabelRequest,
+): Promise<{ success: boolean; labels: number; printer: string }> {
+	return pb.send('/api/labels/print', {
+		method: 'POST',
+		body: JSON.stringify(input),
+		headers: { 'Content-Type': 'application/json' },
+	})
+}
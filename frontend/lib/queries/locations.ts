@@ -0,0 +1,249 @@
+// frontend/lib/queries/locations.ts
+// 📍 Emplacements de stock et transferts
+//
+// Les emplacements s'écrivent par l'API REST, sauf l'emplacement par défaut
+// (`/api/stock/locations/:id/default`). Les quantités par emplacement et les
+// transferts ne s'écrivent que côté serveur : `products.stock` reste le total
+// et bouge avec elles, voir `backend/routes/location_routes.go`.
+
+import { invalidateCatalog } from '@/lib/queries/catalog-products'
+import type { PocketBaseRecord } from '@/lib/queries/catalog-shapes'
+import { cashKeys } from '@/lib/queries/cash'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+
+export type StockLocationKind = 'shop' | 'storage' | 'workshop' | 'rma'
+
+export const STOCK_LOCATION_KIND_LABELS: Record<StockLocationKind, string> = {
+	shop: 'Boutique',
+	storage: 'Réserve',
+	workshop: 'Atelier',
+	rma: 'Retours SAV',
+}
+
+export type StockLocation = PocketBaseRecord & {
+	owner_company: string
+	name: string
+	code: string
+	kind: StockLocationKind | ''
+	is_default: boolean
+	archived: boolean
+}
+
+export interface StockLocationInput {
+	name: string
+	code?: string
+	kind?: StockLocationKind | ''
+}
+
+/** Quantité d'un produit à un emplacement, implicite comprise */
+export interface LocationLevel {
+	product_id: string
+	product_name: string
+	sku: string
+	quantity: number
+}
+
+export interface StockTransferLineInput {
+	/** Identifiant PocketBase ou legacy_id */
+	product_id: string
+	quantity: number
+}
+
+export interface CreateStockTransferInput {
+	company_id: string
+	from_location: string
+	to_location: string
+	lines: StockTransferLineInput[]
+	notes?: string
+}
+
+/** Ce que le transfert a posté, tel que conservé dans stock_transfers.lines */
+export interface StockTransferLine {
+	product_id: string
+	product_name: string
+	quantity: number
+	from_before: number
+	from_after: number
+	to_before: number
+	to_after: number
+	event_id: string
+}
+
+export type StockTransfer = PocketBaseRecord & {
+	owner_company: string
+	number: string
+	fiscal_year: number
+	from_location: string
+	to_location: string
+	transferred_by: string
+	transferred_at: string
+	lines: StockTransferLine[]
+	total_quantity: number
+	notes: string
+	expand?: {
+		from_location?: { id: string; name: string }
+		to_location?: { id: string; name: string }
+	}
+}
+
+/** L'emplacement par défaut d'une liste, s'il existe */
+export function defaultLocation(
+	locations: StockLocation[] | undefined,
+): StockLocation | undefined {
+	return locations?.find((l) => l.is_default)
+}
+
+export function useStockLocations(ownerCompany?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['stock_locations', ownerCompany],
+		queryFn: async (): Promise<StockLocation[]> =>
+			pb.collection('stock_locations').getFullList({
+				filter: `owner_company = "${ownerCompany}"`,
+				sort: '-is_default,name',
+			}),
+		enabled: !!ownerCompany,
+	})
+}
+
+export function useLocationLevels(locationId?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['stock_levels', locationId],
+		queryFn: async () =>
+			pb.send<{ location: StockLocation; levels: LocationLevel[] }>(
+				`/api/stock/locations/${locationId}/levels`,
+				{ method: 'GET' },
+			),
+		enabled: !!locationId,
+	})
+}
+
+export function useStockTransfers(ownerCompany?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['stock_transfers', ownerCompany],
+		queryFn: async (): Promise<StockTransfer[]> =>
+			pb.collection('stock_transfers').getFullList({
+				filter: `owner_company = "${ownerCompany}"`,
+				sort: '-transferred_at',
+				expand: 'from_location,to_location',
+			}),
+		enabled: !!ownerCompany,
+	})
+}
+
+function useInvalidateLocations() {
+	const queryClient = useQueryClient()
+	return () => {
+		queryClient.invalidateQueries({ queryKey: ['stock_locations'] })
+		queryClient.invalidateQueries({ queryKey: ['stock_levels'] })
+	}
+}
+
+export function useCreateStockLocation() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateLocations()
+
+	return useMutation({
+		mutationFn: async ({
+			ownerCompany,
+			input,
+		}: {
+			ownerCompany: string
+			input: StockLocationInput
+		}): Promise<StockLocation> =>
+			pb
+				.collection('stock_locations')
+				.create<StockLocation>({ ...input, owner_company: ownerCompany }),
+		onSuccess: invalidate,
+	})
+}
+
+export function useUpdateStockLocation() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateLocations()
+
+	return useMutation({
+		mutationFn: async ({
+			id,
+			data,
+		}: {
+			id: string
+			data: Partial<StockLocationInput> & { archived?: boolean }
+		}): Promise<StockLocation> =>
+			pb.collection('stock_locations').update<StockLocation>(id, data),
+		onSuccess: invalidate,
+	})
+}
+
+/** Refusé par le serveur si l'emplacement est par défaut ou encore garni */
+export function useDeleteStockLocation() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateLocations()
+
+	return useMutation({
+		mutationFn: async (id: string) =>
+			pb.collection('stock_locations').delete(id),
+		onSuccess: invalidate,
+	})
+}
+
+export function useSetDefaultLocation() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateLocations()
+
+	return useMutation({
+		mutationFn: async (id: string) =>
+			pb.send<StockLocation>(`/api/stock/locations/${id}/default`, {
+				method: 'POST',
+			}),
+		onSuccess: invalidate,
+	})
+}
+
+/** L'emplacement débité par les ventes d'une caisse (vide = par défaut) */
+export function useSetCashRegisterLocation() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	return useMutation({
+		mutationFn: async ({
+			registerId,
+			locationId,
+		}: {
+			registerId: string
+			locationId: string
+		}) =>
+			pb
+				.collection('cash_registers')
+				.update(registerId, { default_location: locationId }),
+		onSuccess: () => {
+			queryClient.invalidateQueries({ queryKey: cashKeys.registers() })
+		},
+	})
+}
+
+export function useCreateStockTransfer() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+	const invalidate = useInvalidateLocations()
+
+	return useMutation({
+		mutationFn: async (input: CreateStockTransferInput) =>
+			pb.send<{ transfer: StockTransfer; lines: StockTransferLine[] }>(
+				'/api/stock/transfers',
+				{ method: 'POST', body: input },
+			),
+		onSuccess: () => {
+			invalidate()
+			queryClient.invalidateQueries({ queryKey: ['stock_transfers'] })
+			// Le total ne bouge pas, mais le journal du produit si
+			invalidateCatalog(queryClient)
+		},
+	})
+}
@@ -19,6 +19,7 @@ export type OrderStatus =
 
 export interface OrderItem {
 	id: string
+	product_id?: string // ligne catalogue : tarifée par les listes de prix
 	description: string
 	quantity: number
 	unit_price_ht: number // HT
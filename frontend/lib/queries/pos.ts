@@ -2,6 +2,11 @@
 // 🎫 Queries React Query pour les routes POS centralisées
 // ✅ CORRIGÉ : Ajout de payment_method_label + multipaiement
 
+import {
+	enqueueOfflineTicket,
+	isNetworkError,
+	withOfflineId,
+} from '@/lib/pos/offlineQueue'
 import { usePocketBase } from '@/lib/use-pocketbase'
 import type { PosPaymentInput } from '@/modules/cash/components/terminal/types/payment'
 import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
@@ -18,6 +23,8 @@ export interface PosItemInput {
 	tva_rate: number
 	line_discount_mode?: 'percent' | 'amount'
 	line_discount_value?: number
+	/** Produit suivi : un numéro par unité, vérifié par le serveur */
+	serial_numbers?: string[]
 }
 
 export interface PosTicketInput {
@@ -37,6 +44,11 @@ export interface PosTicketInput {
 
 	cart_discount_mode?: 'percent' | 'amount'
 	cart_discount_value?: number
+
+	// 📴 Rejeu hors-ligne — attribués par withOfflineId avant l'envoi
+	offline_id?: string
+	offline_created_at?: string
+	workstation?: string
 }
 
 export interface PosTicketTotals {
@@ -60,6 +72,12 @@ export interface PosTicketResult {
 	cash_movement?: any
 	change: number
 	totals: PosTicketTotals
+	// Ticket déjà enregistré pour cet offline_id (rejeu)
+	replayed?: boolean
+	// Hôte injoignable : ticket mis en file, numéro provisoire = offline_id
+	offline?: boolean
+	// Lignes vendues sur des unités réservées (politique `warn`)
+	reservation_warnings?: string[]
 }
 
 export interface PosTicketDetails {
@@ -97,6 +115,7 @@ interface CartItem {
 	lineDiscountMode?: 'percent' | 'unit'
 	lineDiscountValue?: number
 	displayMode?: DisplayMode
+	serialNumbers?: string[]
 }
 
 function round2(n: number) {
@@ -129,6 +148,9 @@ export function cartItemToPosItem(item: CartItem): PosItemInput {
 			tva_rate: Number(item.tvaRate ?? 0),
 			line_discount_mode: discountTotalTtc > 0 ? 'amount' : undefined,
 			line_discount_value: discountTotalTtc > 0 ? discountTotalTtc : undefined,
+			serial_numbers: item.serialNumbers?.length
+				? item.serialNumbers
+				: undefined,
 		}
 	}
 
@@ -144,6 +166,7 @@ export function cartItemToPosItem(item: CartItem): PosItemInput {
 			item.lineDiscountMode === 'percent' && item.lineDiscountValue != null
 				? Number(item.lineDiscountValue)
 				: undefined,
+		serial_numbers: item.serialNumbers?.length ? item.serialNumbers : undefined,
 	}
 }
 
@@ -165,17 +188,44 @@ export function useCreatePosTicket() {
 			}
 			console.log('📦 POS payload:', JSON.stringify(input, null, 2))
 
-			const response = await pb.send('/api/pos/ticket', {
-				method: 'POST',
-				body: JSON.stringify(input),
-				headers: {
-					'Content-Type': 'application/json',
-				},
-			})
+			const payload = withOfflineId(input)
+			try {
+				const response = await pb.send('/api/pos/ticket', {
+					method: 'POST',
+					body: JSON.stringify(payload),
+					headers: {
+						'Content-Type': 'application/json',
+					},
+				})
 
-			return response as PosTicketResult
+				return response as PosTicketResult
+			} catch (error) {
+				if (!isNetworkError(error)) throw error
+
+				// Hôte injoignable : la vente attend dans la file du poste
+				const queued = enqueueOfflineTicket(payload)
+				return {
+					ticket: {
+						id: queued.offline_id,
+						number: queued.offline_id,
+						offline_id: queued.offline_id,
+					},
+					change: 0,
+					totals: {
+						subtotal_ttc: 0,
+						line_discounts_ttc: 0,
+						cart_discount_ttc: 0,
+						total_ht: 0,
+						total_tva: 0,
+						total_ttc: 0,
+						vat_breakdown: [],
+					},
+					offline: true,
+				}
+			}
 		},
-		onSuccess: (_data, variables) => {
+		onSuccess: (data, variables) => {
+			if (data.offline) return
 			queryClient.invalidateQueries({ queryKey: ['invoices'] })
 			queryClient.invalidateQueries({ queryKey: ['tickets'] })
 			queryClient.invalidateQueries({
@@ -0,0 +1,60 @@
+// frontend/lib/queries/pricing.ts
+// 🏷️ Prix effectifs (listes de prix, paliers de quantité)
+//
+// Le serveur applique lui-même les listes de prix à l'enregistrement d'un
+// ticket, d'un devis ou d'un BC : cette query sert seulement à AFFICHER le
+// prix négocié dans les éditeurs avant l'enregistrement.
+
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useQuery } from '@tanstack/react-query'
+
+export interface ResolvedPrice {
+	product_id: string
+	quantity: number
+	base_price_ttc: number
+	unit_price_ttc: number
+	tax_rate: number
+	price_list_id?: string
+	price_list_name?: string
+	rule_id?: string
+	min_quantity?: number
+}
+
+export interface PriceResolveLine {
+	product_id: string
+	quantity: number
+}
+
+export function useResolvedPrices(params: {
+	ownerCompany?: string
+	customerId?: string
+	date?: string
+	lines: PriceResolveLine[]
+}) {
+	const pb = usePocketBase()
+	const lines = params.lines.filter((l) => !!l.product_id)
+
+	return useQuery({
+		queryKey: [
+			'resolved_prices',
+			params.ownerCompany,
+			params.customerId,
+			params.date,
+			lines,
+		],
+		queryFn: async (): Promise<ResolvedPrice[]> => {
+			const response = await pb.send('/api/pricing/resolve', {
+				method: 'POST',
+				body: JSON.stringify({
+					owner_company: params.ownerCompany,
+					customer_id: params.customerId,
+					date: params.date,
+					lines,
+				}),
+				headers: { 'Content-Type': 'application/json' },
+			})
+			return (response?.prices ?? []) as ResolvedPrice[]
+		},
+		enabled: !!params.ownerCompany && lines.length > 0,
+	})
+}
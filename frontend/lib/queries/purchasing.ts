@@ -0,0 +1,289 @@
+// frontend/lib/queries/purchasing.ts
+// 🚚 Commandes fournisseur et réceptions
+//
+// La commande et ses lignes s'écrivent par l'API REST tant qu'elle est en
+// brouillon. Ensuite tout passe par /api/purchase-orders/:id/* : l'envoi, la
+// réception (stock, prix d'achat et journal dans une seule transaction, voir
+// `backend/routes/purchase_routes.go`) et l'annulation.
+
+import { invalidateCatalog } from '@/lib/queries/catalog-products'
+import type { PocketBaseRecord } from '@/lib/queries/catalog-shapes'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+
+export type PurchaseOrderStatus =
+	| 'draft'
+	| 'sent'
+	| 'partially_received'
+	| 'received'
+	| 'cancelled'
+
+export const PURCHASE_ORDER_STATUS_LABELS: Record<
+	PurchaseOrderStatus,
+	string
+> = {
+	draft: 'Brouillon',
+	sent: 'Envoyée',
+	partially_received: 'Reçue en partie',
+	received: 'Reçue',
+	cancelled: 'Annulée',
+}
+
+/** companies.purchase_cost_method — vide : dernier prix */
+export type PurchaseCostMethod = 'last' | 'wac'
+
+export type PurchaseOrder = PocketBaseRecord & {
+	owner_company: string
+	number: string
+	fiscal_year: number
+	supplier: string
+	status: PurchaseOrderStatus
+	supplier_reference: string
+	expected_at: string
+	sent_at: string
+	received_at: string
+	cancelled_at: string
+	total_ht: number
+	created_by: string
+	notes: string
+	expand?: { supplier?: { id: string; name: string } }
+}
+
+export type PurchaseOrderLine = PocketBaseRecord & {
+	purchase_order: string
+	product: string
+	product_name: string
+	supplier_sku: string
+	quantity_ordered: number
+	quantity_received: number
+	unit_price_ht: number
+	expand?: { product?: { id: string; serialized?: boolean } }
+}
+
+export interface PurchaseOrderLineInput {
+	product: string
+	product_name: string
+	supplier_sku?: string
+	quantity_ordered: number
+	unit_price_ht: number
+}
+
+export interface CreatePurchaseOrderInput {
+	owner_company: string
+	supplier: string
+	expected_at?: string
+	supplier_reference?: string
+	notes?: string
+	lines: PurchaseOrderLineInput[]
+}
+
+/** Une ligne reçue ; sans prix, celui de la commande fait foi. Un produit
+ *  suivi par numéro de série en porte un par unité reçue. */
+export interface PurchaseReceiptLineInput {
+	line_id: string
+	quantity: number
+	unit_price_ht?: number
+	serial_numbers?: string[]
+	lot_number?: string
+}
+
+export interface ReceivePurchaseOrderInput {
+	lines: PurchaseReceiptLineInput[]
+	delivery_note?: string
+	notes?: string
+	/** Emplacement qui reçoit ; absent, celui par défaut de la société */
+	location_id?: string
+}
+
+/** Ce que la réception a posté, tel que conservé dans goods_receipts.lines */
+export interface PurchaseReceiptLine {
+	line_id: string
+	product_id: string
+	product_name: string
+	quantity: number
+	unit_price_ht: number
+	stock_before: number
+	stock_after: number
+	cost_before: number
+	cost_after: number
+	event_id: string
+	serial_numbers?: string[]
+	lot_number?: string
+}
+
+export type GoodsReceipt = PocketBaseRecord & {
+	owner_company: string
+	purchase_order: string
+	supplier: string
+	received_by: string
+	received_at: string
+	delivery_note: string
+	location: string
+	lines: PurchaseReceiptLine[]
+	total_ht: number
+	notes: string
+}
+
+/** Le reste à recevoir d'une ligne, jamais négatif */
+export function remainingQuantity(line: PurchaseOrderLine): number {
+	return Math.max(
+		0,
+		(line.quantity_ordered || 0) - (line.quantity_received || 0),
+	)
+}
+
+export function usePurchaseOrders(ownerCompany?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['purchase_orders', ownerCompany],
+		queryFn: async (): Promise<PurchaseOrder[]> =>
+			pb.collection('purchase_orders').getFullList({
+				filter: `owner_company = "${ownerCompany}"`,
+				sort: '-created',
+				expand: 'supplier',
+			}),
+		enabled: !!ownerCompany,
+	})
+}
+
+export function usePurchaseOrderLines(orderId?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['purchase_order_lines', orderId],
+		queryFn: async (): Promise<PurchaseOrderLine[]> =>
+			pb.collection('purchase_order_lines').getFullList({
+				filter: `purchase_order = "${orderId}"`,
+				sort: 'created',
+				// La réception doit savoir quelles lignes demandent des numéros
+				expand: 'product',
+			}),
+		enabled: !!orderId,
+	})
+}
+
+export function useGoodsReceipts(orderId?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['goods_receipts', orderId],
+		queryFn: async (): Promise<GoodsReceipt[]> =>
+			pb.collection('goods_receipts').getFullList({
+				filter: `purchase_order = "${orderId}"`,
+				sort: '-received_at',
+			}),
+		enabled: !!orderId,
+	})
+}
+
+function useInvalidatePurchasing() {
+	const queryClient = useQueryClient()
+	return () => {
+		queryClient.invalidateQueries({ queryKey: ['purchase_orders'] })
+		queryClient.invalidateQueries({ queryKey: ['purchase_order_lines'] })
+		queryClient.invalidateQueries({ queryKey: ['goods_receipts'] })
+	}
+}
+
+/** Crée la commande en brouillon puis ses lignes. Une ligne refusée
+ *  supprime le brouillon : on ne laisse pas de commande à moitié saisie. */
+export function useCreatePurchaseOrder() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidatePurchasing()
+
+	return useMutation({
+		mutationFn: async ({
+			lines,
+			...order
+		}: CreatePurchaseOrderInput): Promise<PurchaseOrder> => {
+			const created = await pb
+				.collection('purchase_orders')
+				.create<PurchaseOrder>({
+					...order,
+					status: 'draft',
+					created_by: pb.authStore.model?.id,
+				})
+			try {
+				for (const line of lines) {
+					await pb
+						.collection('purchase_order_lines')
+						.create({ ...line, purchase_order: created.id })
+				}
+			} catch (error) {
+				await pb.collection('purchase_orders').delete(created.id)
+				throw error
+			}
+			return created
+		},
+		onSuccess: invalidate,
+	})
+}
+
+export function useDeletePurchaseOrder() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidatePurchasing()
+
+	return useMutation({
+		mutationFn: async (orderId: string) =>
+			pb.collection('purchase_orders').delete(orderId),
+		onSuccess: invalidate,
+	})
+}
+
+export function useSendPurchaseOrder() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidatePurchasing()
+
+	return useMutation({
+		mutationFn: async (orderId: string) =>
+			pb.send<{ order: PurchaseOrder }>(
+				`/api/purchase-orders/${orderId}/send`,
+				{ method: 'POST' },
+			),
+		onSuccess: invalidate,
+	})
+}
+
+export function useCancelPurchaseOrder() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidatePurchasing()
+
+	return useMutation({
+		mutationFn: async (orderId: string) =>
+			pb.send<{ order: PurchaseOrder }>(
+				`/api/purchase-orders/${orderId}/cancel`,
+				{ method: 'POST' },
+			),
+		onSuccess: invalidate,
+	})
+}
+
+export function useReceivePurchaseOrder() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+	const invalidate = useInvalidatePurchasing()
+
+	return useMutation({
+		mutationFn: async ({
+			orderId,
+			input,
+		}: {
+			orderId: string
+			input: ReceivePurchaseOrderInput
+		}) =>
+			pb.send<{
+				order: PurchaseOrder
+				receipt: GoodsReceipt
+				lines: PurchaseReceiptLine[]
+			}>(`/api/purchase-orders/${orderId}/receive`, {
+				method: 'POST',
+				body: input,
+			}),
+		onSuccess: () => {
+			invalidate()
+			// Le stock et le prix d'achat ont bougé
+			invalidateCatalog(queryClient)
+		},
+	})
+}
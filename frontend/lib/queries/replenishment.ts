@@ -0,0 +1,107 @@
+// frontend/lib/queries/replenishment.ts
+// 📉 Réassort : propositions de commande par fournisseur
+//
+// Le calcul est au serveur (`backend/routes/replenishment_routes.go`) : il
+// lit le journal des ventes, que le navigateur n'a pas à rapatrier. La
+// commande, elle, se crée en brouillon par `useCreatePurchaseOrder`.
+
+import type { PurchaseOrderLineInput } from '@/lib/queries/purchasing'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useQuery } from '@tanstack/react-query'
+
+export type ReplenishmentReason = 'min_stock' | 'cover'
+
+export interface ReplenishmentRequest {
+	company_id: string
+	/** Fenêtre de calcul de la vitesse de vente, 1 à 52 (défaut 8) */
+	weeks?: number
+	/** Objectif de couverture, 1 à 365 jours (défaut 30) */
+	cover_days?: number
+	/** Vide : tous. "none" : les produits sans fournisseur */
+	supplier_id?: string
+}
+
+export interface ReplenishmentLine {
+	product_id: string
+	product_name: string
+	sku: string
+	barcode: string
+	stock: number
+	min_stock: number
+	/** Reste à recevoir des commandes fournisseur ouvertes */
+	incoming: number
+	/** Vendu net des retours sur la période */
+	sold: number
+	unit_price_ht: number
+	daily_sales: number
+	/** null : aucune vente sur la période */
+	days_of_cover: number | null
+	reason: ReplenishmentReason
+	suggested_qty: number
+}
+
+export interface ReplenishmentGroup {
+	/** Chaîne vide : produits sans fournisseur */
+	supplier_id: string
+	supplier_name: string
+	supplier_code: string
+	lines: ReplenishmentLine[]
+	total_ht: number
+}
+
+export interface ReplenishmentReport {
+	generated_at: string
+	weeks: number
+	cover_days: number
+	suppliers: ReplenishmentGroup[]
+}
+
+export function useReplenishment(request: ReplenishmentRequest | null) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['replenishment', request],
+		queryFn: async (): Promise<ReplenishmentReport> =>
+			pb.send('/api/purchasing/replenishment', {
+				method: 'POST',
+				body: request,
+			}),
+		enabled: !!request?.company_id,
+	})
+}
+
+// Bon de réassort CSV à envoyer au fournisseur (« ; », virgule décimale)
+export async function downloadReplenishmentCsv(
+	pb: any,
+	request: ReplenishmentRequest,
+): Promise<Blob> {
+	const token = pb.authStore.token
+	const res = await fetch('/api/purchasing/replenishment', {
+		method: 'POST',
+		headers: {
+			'Content-Type': 'application/json',
+			Authorization: token ? `Bearer ${token}` : '',
+		},
+		body: JSON.stringify({ ...request, format: 'csv' }),
+	})
+	if (!res.ok) {
+		const err = await res.json().catch(() => ({}))
+		throw new Error(err.message || `HTTP ${res.status}`)
+	}
+	return res.blob()
+}
+
+/** Les lignes d'une commande fournisseur : ce qui est à commander, au prix
+ *  d'achat actuel */
+export function replenishmentOrderLines(
+	group: ReplenishmentGroup,
+): PurchaseOrderLineInput[] {
+	return group.lines
+		.filter((l) => l.suggested_qty > 0)
+		.map((l) => ({
+			product: l.product_id,
+			product_name: l.product_name,
+			quantity_ordered: l.suggested_qty,
+			unit_price_ht: l.unit_price_ht,
+		}))
+}
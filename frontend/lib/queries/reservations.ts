@@ -0,0 +1,187 @@
+// frontend/lib/queries/reservations.ts
+// 🔒 Réservations de stock : ce qui est promis et ne se vend plus librement
+//
+// Un bon de commande confirmé réserve ses lignes côté serveur (hooks des bons
+// de commande) ; un panier mis en attente réserve si la société a fixé une
+// durée. Disponible = stock − réservé. La caisse avertit ou refuse selon
+// `companies.reservation_policy`, voir `backend/reservation.go` et
+// `backend/routes/reservation_routes.go`.
+
+import type { PocketBaseRecord } from '@/lib/queries/catalog-shapes'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+import type PocketBase from 'pocketbase'
+
+export type ReservationPolicy = 'warn' | 'block'
+export type StockReservationKind = 'order' | 'parked_cart'
+
+export const STOCK_RESERVATION_KIND_LABELS: Record<
+	StockReservationKind,
+	string
+> = {
+	order: 'Bon de commande',
+	parked_cart: 'Panier en attente',
+}
+
+export type StockReservation = PocketBaseRecord & {
+	owner_company: string
+	product: string
+	product_name: string
+	quantity: number
+	kind: StockReservationKind
+	order: string
+	order_number: string
+	cart_ref: string
+	cash_register: string
+	label: string
+	status: 'active' | 'released'
+	expires_at: string
+	released_at: string
+	release_reason: string
+	created_by: string
+}
+
+export interface ReservationSettings {
+	policy: ReservationPolicy
+	order_days: number
+	cart_minutes: number
+}
+
+/** Une réservation, telle que la caisse la montre */
+export interface ReservationSummary {
+	id: string
+	kind: StockReservationKind
+	label: string
+	order_id?: string
+	order_number?: string
+	quantity: number
+	expires_at?: string
+}
+
+export interface ProductAvailability {
+	product_id: string
+	product_name: string
+	stock: number
+	reserved: number
+	available: number
+	reservations: ReservationSummary[]
+}
+
+export interface StockAvailabilityResult {
+	policy: ReservationPolicy
+	products: ProductAvailability[]
+}
+
+export interface ParkedCartHold {
+	ref: string
+	companyId: string
+	cashRegisterId?: string
+	label?: string
+	items: Array<{ product_id: string; quantity: number }>
+}
+
+export function useStockReservations(companyId?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['stock_reservations', companyId],
+		queryFn: async () =>
+			pb.send<{
+				reservations: StockReservation[]
+				settings: ReservationSettings
+			}>('/api/stock/reservations', {
+				method: 'GET',
+				query: { company_id: companyId ?? '' },
+			}),
+		enabled: !!companyId,
+	})
+}
+
+export function useReleaseReservation() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	return useMutation({
+		mutationFn: async (id: string) =>
+			pb.send<StockReservation>(`/api/stock/reservations/${id}/release`, {
+				method: 'POST',
+			}),
+		onSuccess: () => {
+			queryClient.invalidateQueries({ queryKey: ['stock_reservations'] })
+		},
+	})
+}
+
+/** Stock, réservé et disponible des produits d'un panier */
+export async function fetchStockAvailability(
+	pb: PocketBase,
+	companyId: string,
+	productIds: string[],
+): Promise<StockAvailabilityResult> {
+	return pb.send<StockAvailabilityResult>('/api/stock/availability', {
+		method: 'GET',
+		query: { company_id: companyId, product_ids: productIds.join(',') },
+	})
+}
+
+/**
+ * Un panier mis en attente réserve ses lignes. Sans durée fixée par la
+ * société, le serveur ne réserve rien et rend une liste vide.
+ */
+export async function holdParkedCart(
+	pb: PocketBase,
+	hold: ParkedCartHold,
+): Promise<StockReservation[]> {
+	const res = await pb.send<{ reservations: StockReservation[] }>(
+		`/api/stock/reservations/cart/${encodeURIComponent(hold.ref)}`,
+		{
+			method: 'PUT',
+			body: {
+				company_id: hold.companyId,
+				cash_register_id: hold.cashRegisterId,
+				label: hold.label,
+				items: hold.items,
+			},
+		},
+	)
+	return res.reservations
+}
+
+/** Le panier est repris ou abandonné : ses réservations sont libérées */
+export async function releaseParkedCart(
+	pb: PocketBase,
+	ref: string,
+): Promise<void> {
+	await pb.send(`/api/stock/reservations/cart/${encodeURIComponent(ref)}`, {
+		method: 'DELETE',
+	})
+}
+
+/**
+ * Les lignes d'un panier qui entament des unités réservées, avec de quoi le
+ * dire au vendeur : quelle quantité, et pour qui.
+ */
+export function reservedShortfalls(
+	availability: ProductAvailability[],
+	quantities: Map<string, number>,
+): string[] {
+	const messages: string[] = []
+	for (const a of availability) {
+		const qty = quantities.get(a.product_id) ?? 0
+		if (a.reserved <= 0 || qty <= 0) continue
+		const free = Math.max(0, a.stock - a.reserved)
+		const short = Math.min(a.reserved, Math.max(0, qty - free))
+		if (short <= 0) continue
+		const holders = a.reservations
+			.map((r) =>
+				r.order_number
+					? [r.order_number, r.label].filter(Boolean).join(' ')
+					: `panier en attente${r.label ? ` ${r.label}` : ''}`,
+			)
+			.join(', ')
+		messages.push(
+			`« ${a.product_name} » : ${short} unité(s) réservée(s) (${holders})`,
+		)
+	}
+	return messages
+}
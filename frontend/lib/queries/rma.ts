@@ -0,0 +1,227 @@
+// frontend/lib/queries/rma.ts
+// 🔧 Retours fournisseur et réparations (RMA)
+//
+// Un RMA s'ouvre et avance côté serveur (`/api/rma`), qui déplace l'article
+// sorti du stock entre le rayon, le bac RMA et le fournisseur ; celui d'un
+// client n'est suivi que par le statut du RMA. L'API REST ne
+// modifie que le reste : fournisseur, accord, suivi, avoir reçu et notes.
+// Voir `backend/rma.go` et `backend/routes/rma_routes.go`.
+
+import { invalidateCatalog } from '@/lib/queries/catalog-products'
+import type { PocketBaseRecord } from '@/lib/queries/catalog-shapes'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+
+export type RmaKind = 'supplier_return' | 'repair'
+export type RmaOrigin = 'customer' | 'stock'
+export type RmaStatus =
+	| 'opened'
+	| 'shipped'
+	| 'repaired'
+	| 'replaced'
+	| 'credited'
+	| 'returned'
+	| 'cancelled'
+export type RmaAction =
+	| 'ship'
+	| 'repaired'
+	| 'replaced'
+	| 'credited'
+	| 'return'
+	| 'cancel'
+
+export const RMA_KIND_LABELS: Record<RmaKind, string> = {
+	supplier_return: 'Retour fournisseur',
+	repair: 'Réparation',
+}
+
+export const RMA_ORIGIN_LABELS: Record<RmaOrigin, string> = {
+	customer: 'Client',
+	stock: 'Stock',
+}
+
+export const RMA_STATUS_LABELS: Record<RmaStatus, string> = {
+	opened: 'Ouvert',
+	shipped: 'Expédié',
+	repaired: 'Réparé',
+	replaced: 'Remplacé',
+	credited: 'Crédité',
+	returned: 'Rendu',
+	cancelled: 'Annulé',
+}
+
+export const RMA_ACTION_LABELS: Record<RmaAction, string> = {
+	ship: 'Expédier',
+	repaired: 'Revenu réparé',
+	replaced: 'Revenu remplacé',
+	credited: 'Avoir reçu',
+	return: 'Rendre',
+	cancel: 'Annuler',
+}
+
+/** Les étapes possibles depuis chaque statut, comme `backend/rma.go` */
+export const RMA_NEXT_ACTIONS: Record<RmaStatus, RmaAction[]> = {
+	opened: ['ship', 'cancel'],
+	shipped: ['repaired', 'replaced', 'credited'],
+	repaired: ['return'],
+	replaced: ['return'],
+	credited: [],
+	returned: [],
+	cancelled: [],
+}
+
+export interface RmaHistoryEntry {
+	action: string
+	status: RmaStatus
+	at: string
+	operator: string
+	note?: string
+}
+
+export type Rma = PocketBaseRecord & {
+	owner_company: string
+	number: string
+	fiscal_year: number
+	kind: RmaKind
+	origin: RmaOrigin
+	status: RmaStatus
+	supplier: string
+	product: string
+	product_name: string
+	quantity: number
+	serial: string
+	serial_number: string
+	customer: string
+	invoice: string
+	invoice_number: string
+	location: string
+	rma_location: string
+	fault: string
+	supplier_reference: string
+	tracking_number: string
+	replacement_serial: string
+	credit_note_number: string
+	credit_note_amount_ht: number
+	credit_note_date: string
+	credit_note_file: string
+	opened_at: string
+	shipped_at: string
+	resolved_at: string
+	closed_at: string
+	history: RmaHistoryEntry[] | null
+	notes: string
+	created_by: string
+	expand?: {
+		supplier?: { id: string; name: string }
+		customer?: { id: string; name: string }
+	}
+}
+
+export interface CreateRmaInput {
+	company_id: string
+	kind: RmaKind
+	origin: RmaOrigin
+	/** Vide si l'unité est nommée par son numéro de série */
+	product_id?: string
+	quantity?: number
+	serial_number?: string
+	customer_id?: string
+	invoice_id?: string
+	supplier_id?: string
+	/** Origine `stock` : d'où l'article sort, sinon l'emplacement par défaut */
+	location_id?: string
+	fault?: string
+	notes?: string
+}
+
+export interface RmaStepInput {
+	action: RmaAction
+	note?: string
+	/** Retour en stock ailleurs que d'où l'article est sorti */
+	location_id?: string
+	supplier_reference?: string
+	tracking_number?: string
+	replacement_serial?: string
+	credit_note_number?: string
+	credit_note_amount_ht?: number
+	credit_note_date?: string
+}
+
+/** Ce que l'API REST laisse modifier, fichier de l'avoir compris */
+export interface UpdateRmaInput {
+	supplier?: string
+	supplier_reference?: string
+	tracking_number?: string
+	credit_note_number?: string
+	credit_note_amount_ht?: number
+	credit_note_date?: string
+	credit_note_file?: File
+	notes?: string
+}
+
+export function useRmas(ownerCompany?: string) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['rma', ownerCompany],
+		queryFn: async (): Promise<Rma[]> =>
+			pb.collection('rma').getFullList({
+				filter: `owner_company = "${ownerCompany}"`,
+				sort: '-created',
+				expand: 'supplier,customer',
+			}),
+		enabled: !!ownerCompany,
+	})
+}
+
+function useInvalidateRma() {
+	const queryClient = useQueryClient()
+	return () => {
+		queryClient.invalidateQueries({ queryKey: ['rma'] })
+		queryClient.invalidateQueries({ queryKey: ['product_serials'] })
+		queryClient.invalidateQueries({ queryKey: ['stock_locations'] })
+		queryClient.invalidateQueries({ queryKey: ['stock_levels'] })
+		// Le stock a bougé
+		invalidateCatalog(queryClient)
+	}
+}
+
+export function useCreateRma() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateRma()
+
+	return useMutation({
+		mutationFn: async (input: CreateRmaInput) =>
+			pb.send<Rma>('/api/rma', { method: 'POST', body: input }),
+		onSuccess: invalidate,
+	})
+}
+
+export function useRmaStep() {
+	const pb = usePocketBase()
+	const invalidate = useInvalidateRma()
+
+	return useMutation({
+		mutationFn: async ({ id, ...input }: RmaStepInput & { id: string }) =>
+			pb.send<Rma>(`/api/rma/${id}/step`, { method: 'POST', body: input }),
+		onSuccess: invalidate,
+	})
+}
+
+export function useUpdateRma() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	return useMutation({
+		mutationFn: async ({ id, ...input }: UpdateRmaInput & { id: string }) => {
+			const data = new FormData()
+			for (const [key, value] of Object.entries(input)) {
+				if (value !== undefined) data.append(key, value as string | Blob)
+			}
+			return pb.collection('rma').update<Rma>(id, data)
+		},
+		onSuccess: () => {
+			queryClient.invalidateQueries({ queryKey: ['rma'] })
+		},
+	})
+}
@@ -0,0 +1,136 @@
+// frontend/lib/queries/serials.ts
+// 🔢 Numéros de série des produits suivis (guitares, amplis, pianos)
+//
+// Une unité naît à la réception fournisseur, ou à la main pour celles déjà en
+// boutique ; la vente en caisse et le retour changent son statut côté
+// serveur. Le numéro est comparé en majuscules et sans espaces, voir
+// `backend/serial.go` et `backend/routes/serial_routes.go`.
+
+import type { PocketBaseRecord } from '@/lib/queries/catalog-shapes'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query'
+
+export type ProductSerialStatus = 'in_stock' | 'sold' | 'rma'
+
+export const PRODUCT_SERIAL_STATUS_LABELS: Record<ProductSerialStatus, string> =
+	{
+		in_stock: 'En stock',
+		sold: 'Vendu',
+		rma: 'En retour SAV',
+	}
+
+/** Une vente suivie d'un retour, gardée sur l'unité */
+export interface ProductSerialSale {
+	invoice: string
+	invoice_number: string
+	customer: string
+	sold_at: string
+	warranty_until: string
+	returned_at: string
+	reason: string
+}
+
+export type ProductSerial = PocketBaseRecord & {
+	owner_company: string
+	product: string
+	product_name: string
+	serial_number: string
+	lot_number: string
+	status: ProductSerialStatus
+	goods_receipt: string
+	received_at: string
+	invoice: string
+	invoice_number: string
+	customer: string
+	sold_at: string
+	warranty_until: string
+	returned_at: string
+	history: ProductSerialSale[] | null
+	notes: string
+}
+
+/** Réponse de `/api/serials/lookup` : l'unité et ce qu'on en demande au
+ *  comptoir — qui l'a achetée, quand, et si elle est encore garantie. */
+export interface SerialLookupResult {
+	unit: ProductSerial
+	product_id: string
+	product_name: string
+	status: ProductSerialStatus
+	customer_id?: string
+	customer_name?: string
+	invoice_id?: string
+	invoice_number?: string
+	sold_at?: string
+	warranty_until?: string
+	under_warranty: boolean
+}
+
+export interface CreateProductSerialInput {
+	owner_company: string
+	product: string
+	serial_number: string
+	lot_number?: string
+	notes?: string
+}
+
+/** Les unités en stock d'un produit, pour la caisse */
+export function useAvailableSerials(productId?: string, enabled = true) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['product_serials', 'available', productId],
+		queryFn: async (): Promise<ProductSerial[]> =>
+			pb.collection('product_serials').getFullList({
+				filter: `product = "${productId}" && status = "in_stock"`,
+				sort: 'serial_number',
+			}),
+		enabled: !!productId && enabled,
+	})
+}
+
+export function useSerialLookup(term: string, companyId?: string) {
+	const pb = usePocketBase()
+	const q = term.trim()
+
+	return useQuery({
+		queryKey: ['product_serials', 'lookup', companyId, q],
+		queryFn: async () =>
+			pb.send<{ results: SerialLookupResult[] }>('/api/serials/lookup', {
+				method: 'GET',
+				query: { q, company_id: companyId ?? '' },
+			}),
+		enabled: q.length > 0,
+	})
+}
+
+export function useCreateProductSerial() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	return useMutation({
+		mutationFn: async (input: CreateProductSerialInput) =>
+			pb
+				.collection('product_serials')
+				.create<ProductSerial>({ ...input, status: 'in_stock' }),
+		onSuccess: () => {
+			queryClient.invalidateQueries({ queryKey: ['product_serials'] })
+		},
+	})
+}
+
+/** Une unité vendue revient en stock ; sa vente passe dans l'historique */
+export function useReturnSerial() {
+	const pb = usePocketBase()
+	const queryClient = useQueryClient()
+
+	return useMutation({
+		mutationFn: async ({ id, reason }: { id: string; reason?: string }) =>
+			pb.send<{ unit: ProductSerial }>(`/api/serials/${id}/return`, {
+				method: 'POST',
+				body: { reason },
+			}),
+		onSuccess: () => {
+			queryClient.invalidateQueries({ queryKey: ['product_serials'] })
+		},
+	})
+}
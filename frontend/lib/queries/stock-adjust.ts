@@ -72,6 +72,11 @@ export interface StockAdjustOptions {
 	/** Ce que le motif a de particulier : la destination d'un retour, l'entrée
 	 *  d'inventaire, le ticket. Le journal le porte tel quel. */
 	metadata?: Record<string, unknown> | null
+	/** L'emplacement touché. À défaut, celui de la caisse, puis celui par
+	 *  défaut de la société — résolus par le serveur. */
+	locationId?: string
+	/** La caisse qui vend : le serveur prend son `default_location`. */
+	cashRegisterId?: string
 }
 
 // ---------------------------------------------------------------------------
@@ -124,6 +129,10 @@ interface ReponseServeur {
 		product_sku: string
 		stock_before: number | null
 		stock_after: number | null
+		/** Absents tant que la société n'a pas d'emplacement */
+		location_id?: string
+		location_stock_before?: number
+		location_stock_after?: number
 		applied: boolean
 		error?: string
 	}>
@@ -165,6 +174,8 @@ export async function applyStockMovements(
 					delta: m.delta,
 					absolute: m.absolute,
 				})),
+				location_id: options.locationId,
+				cash_register_id: options.cashRegisterId,
 			},
 		})
 	} catch (error) {
@@ -215,6 +226,24 @@ export async function applyStockMovements(
 		// Rien à journaliser d'un comptage conforme ou d'une ligne en échec.
 		if (!ligne.applied) continue
 
+		// Le journal garde le total ; l'emplacement et sa quantité vont dans la
+		// métadonnée, où l'inventaire d'un emplacement relit ses ventes.
+		const emplacement = ligne.location_id
+			? {
+					location_id: ligne.location_id,
+					location_stock_before: ligne.location_stock_before,
+					location_stock_after: ligne.location_stock_after,
+				}
+			: null
+		const metadata =
+			movement.metadata || options.metadata || emplacement
+				? {
+						...(options.metadata ?? {}),
+						...(movement.metadata ?? {}),
+						...(emplacement ?? {}),
+					}
+				: null
+
 		try {
 			await createProductEvent(pb, {
 				product_id: ligne.record_id,
@@ -229,10 +258,7 @@ export async function applyStockMovements(
 				// Le journal porte le mouvement, pas seulement les deux bornes :
 				// c'est lui qu'on additionne pour reconstituer une période.
 				delta: { stock: (ligne.stock_after ?? 0) - (ligne.stock_before ?? 0) },
-				metadata:
-					movement.metadata || options.metadata
-						? { ...(options.metadata ?? {}), ...(movement.metadata ?? {}) }
-						: null,
+				metadata,
 				occurred_at: new Date().toISOString(),
 			})
 		} catch (error) {
@@ -0,0 +1,89 @@
+// frontend/lib/queries/valuation.ts
+// 💶 Valorisation du stock à une date (coût moyen pondéré ou FIFO)
+//
+// Le calcul est au serveur (`backend/routes/valuation_routes.go`) : il rejoue
+// le journal `product_events` et les réceptions fournisseur. Le PDF, lui, est
+// mis en page ici (`StockValuationPDF`), comme les rapports Z.
+
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { useQuery } from '@tanstack/react-query'
+
+export type ValuationMethod = 'wac' | 'fifo'
+
+export const VALUATION_METHOD_LABELS: Record<ValuationMethod, string> = {
+	wac: 'Coût moyen pondéré',
+	fifo: 'Premier entré, premier sorti (FIFO)',
+}
+
+export interface ValuationRequest {
+	company_id: string
+	/** AAAA-MM-JJ, journée incluse */
+	at: string
+	method: ValuationMethod
+}
+
+export interface ValuationLine {
+	product_id: string
+	product_name: string
+	sku: string
+	category: string
+	brand: string
+	supplier: string
+	/** Négative : signalée, valorisée à zéro */
+	quantity: number
+	unit_cost: number
+	value: number
+	/** receipts : réceptions fournisseur ; catalog : prix d'achat de la fiche */
+	cost_source: 'receipts' | 'catalog'
+}
+
+export interface ValuationBucket {
+	name: string
+	products: number
+	quantity: number
+	value: number
+}
+
+export interface ValuationReport {
+	at: string
+	method: ValuationMethod
+	total_quantity: number
+	total_value: number
+	negative_products: number
+	lines: ValuationLine[]
+	by_category: ValuationBucket[]
+	by_brand: ValuationBucket[]
+	by_supplier: ValuationBucket[]
+}
+
+export function useStockValuation(request: ValuationRequest | null) {
+	const pb = usePocketBase()
+
+	return useQuery({
+		queryKey: ['stock-valuation', request],
+		queryFn: async (): Promise<ValuationReport> =>
+			pb.send('/api/stock/valuation', { method: 'POST', body: request }),
+		enabled: !!request?.company_id && !!request.at,
+	})
+}
+
+// Détail par produit en CSV (« ; », virgule décimale)
+export async function downloadValuationCsv(
+	pb: any,
+	request: ValuationRequest,
+): Promise<Blob> {
+	const token = pb.authStore.token
+	const res = await fetch('/api/stock/valuation', {
+		method: 'POST',
+		headers: {
+			'Content-Type': 'application/json',
+			Authorization: token ? `Bearer ${token}` : '',
+		},
+		body: JSON.stringify({ ...request, format: 'csv' }),
+	})
+	if (!res.ok) {
+		const err = await res.json().catch(() => ({}))
+		throw new Error(err.message || `HTTP ${res.status}`)
+	}
+	return res.blob()
+}
@@ -26,8 +26,12 @@ export interface CashRegister {
 	code?: string | null
 	owner_company: string
 	location?: string | null
+	// Emplacement de stock débité par les ventes de cette caisse (vide = défaut)
+	default_location?: string | null
 	is_active?: boolean
 	settings?: Record<string, any> | null
+	// Rôle minimum pour ouvrir le tiroir sans vente (vide = manager)
+	no_sale_min_role?: '' | 'caissier' | 'manager' | 'admin'
 	created: string
 	updated: string
 }
@@ -156,6 +160,21 @@ export interface RefundsSummaryX {
 	by_method_labels?: Record<string, string>
 }
 
+// Ouvertures du tiroir-caisse (journal cash_drawer_events)
+export interface DrawerCashierCount {
+	cashier: string
+	cashier_name: string
+	openings: number
+	no_sale: number // ouvertures sans vente
+}
+
+export interface DrawerOpeningsSummary {
+	total: number
+	no_sale: number
+	by_reason: Record<string, number>
+	by_cashier: DrawerCashierCount[]
+}
+
 // ============================================================================
 // RAPPORT X (Lecture intermédiaire)
 // ============================================================================
@@ -179,6 +198,7 @@ export interface RapportX {
 		movements: number
 		total: number
 	}
+	drawer?: DrawerOpeningsSummary
 	note: string
 }
 
@@ -248,6 +268,7 @@ export interface RapportZDailyTotals {
 	// ✅ optionnels (dès que le backend les expose)
 	refunds_by_method?: Record<string, number>
 	net_by_method?: Record<string, number>
+	drawer?: DrawerOpeningsSummary
 }
 
 // ============================================================================
@@ -123,6 +123,9 @@ export interface InvoiceResponse extends InvoiceBase {
 	deposit_percentage?: number // % de l'acompte (sur une facture deposit)
 	deposits_total_ttc?: number // somme des acomptes versés (sur la facture parente)
 	balance_due?: number // solde restant à payer (sur la facture parente)
+	// 🆕 Duplicatas (NF525) — tenus par le serveur
+	reprint_count?: number
+	last_reprinted_at?: string
 }
 
 export interface CustomerExpand {
@@ -4,6 +4,7 @@ import {
 	Loader2,
 	Monitor,
 	Package,
+	QrCode,
 	ShieldAlert,
 	ShoppingCart,
 	Vault,
@@ -13,12 +14,18 @@ import { toast } from 'sonner'
 
 import { EmptyState } from '@/components/module-ui'
 import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	type CustomerScreenState,
+	pairCustomerScreen,
+	useCustomerScreen,
+} from '@/lib/pos/customerScreen'
 import { releaseControl, takeControl, useDisplay } from '@/lib/pos/display'
 import { openReceiptPreviewWindow } from '@/lib/pos/posPreview'
 import { openCashDrawer, printReceipt } from '@/lib/pos/posPrint'
 import { loadPosPrinterSettings } from '@/lib/pos/printerSettings'
 import { useScanner } from '@/lib/pos/scanner'
 import { useCustomerDisplay } from '@/lib/pos/useCustomerDisplay'
+import { useOfflinePosQueue } from '@/lib/pos/useOfflinePosQueue'
 import {
 	getOrCreateDefaultCustomer,
 	useActiveCashSession,
@@ -31,6 +38,12 @@ import {
 import { type Company, getLogoUrl, useCompany } from '@/lib/queries/companies'
 import { fetchAsDataUrl } from '@/lib/queries/logoToDataUrl'
 import { cartItemToPosItem, useCreatePosTicket } from '@/lib/queries/pos'
+import {
+	fetchStockAvailability,
+	holdParkedCart,
+	releaseParkedCart,
+	reservedShortfalls,
+} from '@/lib/queries/reservations'
 import { recordSale } from '@/lib/queries/stock-adjust'
 import { clearLastRouteForModule } from '@/lib/stores/moduleNavigationStore'
 import { usePocketBase } from '@/lib/use-pocketbase'
@@ -38,15 +51,16 @@ import { useAuth } from '@/modules/auth/AuthProvider'
 import { CreateProductDialog } from '@/modules/cash/CreateProductDialog'
 import { CashModuleShell } from './CashModuleShell'
 
-import { useOpenCashDrawerMutation } from '@/lib/pos/printerQueries'
 import {
 	CartPanel,
+	NoSaleDrawerDialog,
 	PaymentDialog,
 	type PaymentEntry,
 	type PaymentMethod,
 	type PaymentStep,
 	type PosProduct,
 	ProductsPanel,
+	SerialPickerDialog,
 	SuccessView,
 	getEffectiveUnitTtc,
 	getLineTotalTtc,
@@ -57,24 +71,68 @@ import {
 	useCartManager,
 } from './components/terminal'
 
-// Bouton tiroir — isolé pour éviter re-render du terminal entier
-function TerminalDrawerButton() {
-	const openDrawer = useOpenCashDrawerMutation()
+// Bouton tiroir — isolé pour éviter re-render du terminal entier.
+// Ouverture hors vente : motif obligatoire, journalisée (no_sale)
+function TerminalDrawerButton({ cashRegisterId }: { cashRegisterId: string }) {
+	const [showNoSale, setShowNoSale] = React.useState(false)
+	return (
+		<>
+			<button
+				type='button'
+				onClick={() => setShowNoSale(true)}
+				className='inline-flex items-center gap-1.5 h-7 px-3 rounded-lg text-xs font-medium
+					border border-border/50 text-foreground hover:bg-muted/30 hover:border-border
+					disabled:opacity-40 disabled:cursor-not-allowed transition-all'
+			>
+				<Vault className='h-3 w-3' />
+				Ouvrir tiroir
+			</button>
+			<NoSaleDrawerDialog
+				open={showNoSale}
+				onOpenChange={setShowNoSale}
+				cashRegisterId={cashRegisterId}
+			/>
+		</>
+	)
+}
+
+// Bouton écran client — génère le lien d'appairage d'une tablette
+function TerminalScreenButton({ cashRegisterId }: { cashRegisterId: string }) {
+	const pb = usePocketBase()
+	const [isPairing, setIsPairing] = React.useState(false)
+
+	const pair = async () => {
+		setIsPairing(true)
+		try {
+			const url = await pairCustomerScreen(pb, cashRegisterId)
+			await navigator.clipboard?.writeText(url).catch(() => {})
+			toast.success('Lien de l\'écran client copié', {
+				description: `Ouvrez-le sur la tablette : ${url}`,
+				duration: 15000,
+			})
+		} catch (err: any) {
+			toast.error(err?.message || 'Appairage impossible')
+		} finally {
+			setIsPairing(false)
+		}
+	}
+
 	return (
 		<button
 			type='button'
-			onClick={() => openDrawer.mutate()}
-			disabled={openDrawer.isPending}
+			onClick={pair}
+			disabled={isPairing}
+			title="Appairer une tablette comme écran client (l'ancien lien est révoqué)"
 			className='inline-flex items-center gap-1.5 h-7 px-3 rounded-lg text-xs font-medium
 				border border-border/50 text-foreground hover:bg-muted/30 hover:border-border
 				disabled:opacity-40 disabled:cursor-not-allowed transition-all'
 		>
-			{openDrawer.isPending ? (
+			{isPairing ? (
 				<Loader2 className='h-3 w-3 animate-spin' />
 			) : (
-				<Vault className='h-3 w-3' />
+				<QrCode className='h-3 w-3' />
 			)}
-			Ouvrir tiroir
+			Écran client
 		</button>
 	)
 }
@@ -100,6 +158,7 @@ export function CashTerminalPage() {
 		React.useState<PaymentMethod | null>(null)
 	const [isProcessing, setIsProcessing] = React.useState(false)
 	const [editingLineId, setEditingLineId] = React.useState<string | null>(null)
+	const [serialItemId, setSerialItemId] = React.useState<string | null>(null)
 	const [showCreateProductDialog, setShowCreateProductDialog] =
 		React.useState(false)
 	const [productNotFoundBarcode, setProductNotFoundBarcode] = React.useState('')
@@ -137,11 +196,13 @@ export function CashTerminalPage() {
 				stock: p.stock ?? null,
 				tax_rate: p.tax_rate ?? null,
 				imageUrl: p.image ? pb.files.getUrl(p, p.image) : null,
+				serialized: !!p.serialized,
 			})),
 		[catalogItems, pb],
 	)
 
 	const createPosTicket = useCreatePosTicket()
+	const { refresh: refreshOfflineQueue } = useOfflinePosQueue()
 	const currentRegister = registers?.find((r) => r.id === cashRegisterId)
 	const isSessionOpen = activeSession?.status === 'open'
 
@@ -212,6 +273,71 @@ export function CashTerminalPage() {
 		enabled: hasControl,
 	})
 
+	// Écran client web : le panier complet, indépendant du contrôle du VFD
+	const customerScreenState = React.useMemo((): CustomerScreenState => {
+		const items = cartManager.cart.map((item) => {
+			const unit = getEffectiveUnitTtc(item)
+			const base = item.originalUnitPrice ?? item.unitPrice
+			let discountLabel: string | undefined
+			if (item.lineDiscountMode && item.lineDiscountValue) {
+				discountLabel =
+					item.lineDiscountMode === 'percent'
+						? `-${item.lineDiscountValue} %`
+						: `-${item.lineDiscountValue.toFixed(2)} €`
+			}
+			return {
+				name: item.name,
+				quantity: item.quantity,
+				unit_price_ttc: unit,
+				base_price_ttc: base > unit ? base : undefined,
+				total_ttc: getLineTotalTtc(item),
+				discount_label: discountLabel,
+				image_url: item.image || undefined,
+			}
+		})
+		const lineSavings = items.reduce(
+			(sum, it) =>
+				sum +
+				(it.base_price_ttc
+					? (it.base_price_ttc - it.unit_price_ttc) * it.quantity
+					: 0),
+			0,
+		)
+		const received = Number.parseFloat(amountReceived) || 0
+		const phase: CustomerScreenState['phase'] =
+			displayPhase === 'success'
+				? 'thanks'
+				: displayPhase === 'change' || displayPhase === 'payment'
+					? displayPhase
+					: items.length > 0
+						? 'cart'
+						: 'idle'
+
+		return {
+			phase,
+			items,
+			item_count: items.reduce((sum, it) => sum + it.quantity, 0),
+			subtotal_ttc: subtotalTtc,
+			discount_amount: discountAmount,
+			total_ttc: totalTtc,
+			total_savings: +(lineSavings + discountAmount).toFixed(2),
+			payment_method: selectedPaymentMethod?.name,
+			received,
+			change: change > 0 ? +change.toFixed(2) : 0,
+		}
+	}, [
+		cartManager.cart,
+		amountReceived,
+		displayPhase,
+		subtotalTtc,
+		discountAmount,
+		totalTtc,
+		selectedPaymentMethod?.name,
+		change,
+	])
+
+	useCustomerScreen(pb, cashRegisterId, customerScreenState, isSessionOpen)
+
 	const recentScansRef = React.useRef<Map<string, number>>(new Map())
 
 	const isDuplicateScan = React.useCallback((barcode: string): boolean => {
@@ -236,8 +362,22 @@ export function CashTerminalPage() {
 		onScan: (barcode) => handleBarcodeScan(barcode, 'hid'),
 	})
 
-	useScanner((barcode) => {
-		if (paymentStep === 'cart') handleBarcodeScan(barcode, 'websocket')
+	useScanner((barcode, event) => {
+		if (paymentStep !== 'cart') return
+		// Ticket ou avoir : pas un article, on signale seulement
+		if (event?.type === 'ticket' || event?.type === 'voucher') {
+			const doc = event.document
+			toast.info(
+				event.type === 'ticket'
+					? `Ticket ${doc?.number} scanné`
+					: `Avoir ${doc?.number} : ${doc?.remaining_amount.toFixed(2)} € disponibles`,
+			)
+			return
+		}
+		// Code GS1 ou EAN-13 interne : on cherche la fiche par son propre code
+		const hit = event?.products?.find((p) => p.company === activeCompanyId)
+		const code = hit ? hit.barcode || hit.sku || barcode : barcode
+		handleBarcodeScan(code, 'websocket')
 	})
 
 	React.useEffect(() => {
@@ -288,6 +428,7 @@ export function CashTerminalPage() {
 				imageUrl: product.image
 					? pb.files.getUrl(product, product.image)
 					: null,
+				serialized: !!product.serialized,
 			})
 			toast.success(`${product.name} ajouté au panier`)
 			setTimeout(() => {
@@ -335,19 +476,70 @@ export function CashTerminalPage() {
 	)
 
 	const handlePaymentClick = React.useCallback(
-		(method: PaymentMethod) => {
+		async (method: PaymentMethod) => {
 			if (cartManager.cart.length === 0) {
 				toast.error('Le panier est vide')
 				return
 			}
+			// Le serveur refuserait le ticket : autant choisir les unités ici
+			const missing = cartManager.cart.find(
+				(item) =>
+					item.serialized &&
+					(item.serialNumbers?.length ?? 0) !== item.quantity,
+			)
+			if (missing) {
+				toast.error(`« ${missing.name} » : un numéro de série par unité`)
+				setSerialItemId(missing.id)
+				return
+			}
+			// Unités promises à un bon de commande ou à un panier en attente :
+			// on le dit avant d'encaisser, le serveur refuserait après
+			if (activeCompanyId) {
+				const quantities = new Map<string, number>()
+				for (const item of cartManager.cart) {
+					if (!item.productId) continue
+					quantities.set(
+						item.productId,
+						(quantities.get(item.productId) ?? 0) + item.quantity,
+					)
+				}
+				try {
+					const availability = await fetchStockAvailability(
+						pb,
+						activeCompanyId,
+						[...quantities.keys()],
+					)
+					const shortfalls = reservedShortfalls(
+						availability.products,
+						quantities,
+					)
+					if (shortfalls.length > 0 && availability.policy === 'block') {
+						toast.error('Vente refusée : unités réservées', {
+							description: shortfalls.join('\n'),
+						})
+						return
+					}
+					if (shortfalls.length > 0) {
+						toast.warning('Unités réservées', {
+							description: shortfalls.join('\n'),
+						})
+					}
+				} catch {
+					// Serveur injoignable : la vente partira en file, sans contrôle
+				}
+			}
 			setInitialPaymentMethod(method)
 			setPaymentEntries([])
 			setPaymentStep('payment')
 		},
-		[cartManager.cart.length],
+		[activeCompanyId, cartManager.cart, pb],
 	)
 
 	const clearAll = React.useCallback(() => {
+		// Les paniers en attente partent avec le panier : leurs réservations aussi
+		for (const parked of cartManager.parkedCarts) {
+			releaseParkedCart(pb, parked.id).catch(() => {})
+		}
 		cartManager.clearCartAndStore()
 		setCartDiscountMode('percent')
 		setCartDiscountValue(0)
@@ -356,7 +548,34 @@ export function CashTerminalPage() {
 		setPaymentEntries([])
 		setInitialPaymentMethod(null)
 		setEditingLineId(null)
-	}, [cartManager])
+	}, [cartManager, pb])
+
+	// Un panier en attente réserve ses lignes si la société a fixé une durée.
+	// Best-effort : hors-ligne, le panier attend sans réserver.
+	const handleParkCart = React.useCallback(() => {
+		const parked = cartManager.parkCart()
+		if (!parked || !activeCompanyId) return
+		holdParkedCart(pb, {
+			ref: parked.id,
+			companyId: activeCompanyId,
+			cashRegisterId,
+			label: parked.label,
+			items: parked.items
+				.filter((item) => !!item.productId)
+				.map((item) => ({
+					product_id: item.productId,
+					quantity: item.quantity,
+				})),
+		}).catch(() => {})
+	}, [activeCompanyId, cartManager, cashRegisterId, pb])
+
+	const handleUnparkCart = React.useCallback(
+		(parkedId: string) => {
+			cartManager.unparkCart(parkedId)
+			releaseParkedCart(pb, parkedId).catch(() => {})
+		},
+		[cartManager, pb],
+	)
 
 	const buildReceiptPayload = React.useCallback(
 		async (args: {
@@ -517,8 +736,18 @@ export function CashTerminalPage() {
 						cartDiscountValue > 0 ? cartDiscountValue : undefined,
 				})
 
+				// Hôte injoignable : stock et impression attendront le rejeu
+				if (result.offline) {
+					refreshOfflineQueue()
+					toast.warning(
+						`Hors-ligne : vente mise en file (${result.ticket.offline_id}), numérotée au retour du serveur`,
+					)
+					setPaymentStep('success')
+					setTimeout(() => clearAll(), 500)
+					return
+				}
+
 				const ticket = result.ticket
-				const backendTotals = result.totals
 				try {
 					await recordSale(
 						pb,
@@ -528,7 +757,7 @@ export function CashTerminalPage() {
 							productSku: item.sku ?? '',
 							quantity: item.quantity,
 						})),
-						{ sourceId: ticket.number },
+						{ sourceId: ticket.number, cashRegisterId },
 					)
 				} catch (stockError) {
 					toast.warning(
@@ -536,34 +765,47 @@ export function CashTerminalPage() {
 					)
 				}
 
-				if (printerSettings.enabled && printerSettings.printerName) {
+				// Imprimante : celle du poste, ou celle configurée sur la caisse
+				// (settings.printer : réseau, CUPS, USB), résolue par le serveur
+				const registerHasPrinter = !!currentRegister?.settings?.printer
+				if (
+					printerSettings.enabled &&
+					(printerSettings.printerName || registerHasPrinter)
+				) {
 					if (printerSettings.autoPrint) {
-						const receiptPayload = await buildReceiptPayload({
-							invoiceNumber: ticket.number,
-							dateLabel: new Date().toLocaleString('fr-FR'),
-							totalTtcValue: backendTotals.total_ttc,
-							taxAmountValue: backendTotals.total_tva,
-							totalSavingsValue:
-								backendTotals.line_discounts_ttc +
-								backendTotals.cart_discount_ttc,
-						})
-						await printReceipt({
+						// Le serveur imprime le ticket enregistré (haché), pas le panier
+						const printResult = await printReceipt(pb, {
 							printerName: printerSettings.printerName,
+							cashRegisterId,
 							width: printerSettings.width,
-							companyId: activeCompanyId,
-							receipt: receiptPayload,
+							ticketId: ticket.id,
 						})
+						if (printResult.queued) {
+							toast.warning("Ticket en attente d'impression", {
+								description: printResult.error,
+							})
+						}
 					}
 					// Tiroir-caisse — ouverture auto si activé (tous moyens de paiement)
 					if (printerSettings.autoOpenDrawer) {
-						await openCashDrawer({
+						await openCashDrawer(pb, {
 							printerName: printerSettings.printerName,
+							cashRegisterId,
 							width: printerSettings.width,
+							reason: 'sale',
+							invoiceId: ticket.id,
 						})
 					}
 				}
 
-				toast.success(`Ticket ${ticket.number} créé`)
+				if (result.reservation_warnings?.length) {
+					toast.warning(
+						`Ticket ${ticket.number} créé sur des unités réservées`,
+						{ description: result.reservation_warnings.join('\n') },
+					)
+				} else {
+					toast.success(`Ticket ${ticket.number} créé`)
+				}
 				setPaymentStep('success')
 				setTimeout(() => clearAll(), 500)
 			} catch (error: any) {
@@ -575,15 +817,16 @@ export function CashTerminalPage() {
 		[
 			activeCompanyId,
 			activeSession,
-			buildReceiptPayload,
 			cartDiscountMode,
 			cartDiscountValue,
 			cartManager.cart,
 			cashRegisterId,
 			clearAll,
 			createPosTicket,
+			currentRegister,
 			paymentEntries,
 			pb,
+			refreshOfflineQueue,
 			totalTtc,
 		],
 	)
@@ -679,13 +922,14 @@ export function CashTerminalPage() {
 				<span className='h-2 w-2 rounded-full bg-emerald-500 shrink-0' />
 				<span className='hidden tablet:inline'>Catalogue local</span>
 			</div>
-			<TerminalDrawerButton />
+			<TerminalScreenButton cashRegisterId={cashRegisterId} />
+			<TerminalDrawerButton cashRegisterId={cashRegisterId} />
 		</div>
 	)
 
 	const cartProps = {
 		cart: cartManager.cart,
-		onParkCart: () => cartManager.parkCart(),
+		onParkCart: handleParkCart,
 		onClearCart: clearAll,
 		onUpdateQuantity: cartManager.updateQuantity,
 		subtotalTtc,
@@ -711,6 +955,7 @@ export function CashTerminalPage() {
 		setEditingLineId,
 		setUnitPrice: cartManager.setUnitPrice,
 		clearUnitPrice: cartManager.clearUnitPrice,
+		onPickSerials: setSerialItemId,
 	}
 
 	const productsPanel = (
@@ -737,7 +982,7 @@ export function CashTerminalPage() {
 					<button
 						key={parked.id}
 						type='button'
-						onClick={() => cartManager.unparkCart(parked.id)}
+						onClick={() => handleUnparkCart(parked.id)}
 						className='rounded bg-primary px-3 py-1.5 text-xs text-primary-foreground hover:bg-primary/90'
 					>
 						{parked.items.length} article{parked.items.length > 1 ? 's' : ''}
@@ -849,6 +1094,11 @@ export function CashTerminalPage() {
 				initialName={productInitialName}
 				onProductCreated={handleProductCreated}
 			/>
+			<SerialPickerDialog
+				item={cartManager.cart.find((i) => i.id === serialItemId) ?? null}
+				onOpenChange={(open) => !open && setSerialItemId(null)}
+				onConfirm={cartManager.setSerialNumbers}
+			/>
 		</CashModuleShell>
 	)
 }
@@ -371,7 +371,7 @@ export function DisplaySettingsCard() {
 							) : (
 								<div className='flex gap-2'>
 									<Input
-										placeholder='Ex: COM10, COM15...'
+										placeholder='Ex: COM10, /dev/ttyUSB0...'
 										value={manualPortInput}
 										onChange={(e) => handleManualPortChange(e.target.value)}
 										className='flex-1 h-9 bg-card border-border/40'
@@ -7,6 +7,7 @@ import { Label } from '@/components/ui/label'
 import { Separator } from '@/components/ui/separator'
 import {
 	printerKeys,
+	printerStatusQueryOptions,
 	printersQueryOptions,
 	useOpenCashDrawerMutation,
 	useTestPrintMutation,
@@ -19,6 +20,7 @@ import {
 	type PosPrinterSettings,
 	posPrinterSettingsSchema,
 } from '@/lib/pos/printerSettings.schema'
+import { usePocketBase } from '@/lib/use-pocketbase'
 import { isWailsEnv } from '@/lib/wails'
 import { zodResolver } from '@hookform/resolvers/zod'
 import { useQuery, useQueryClient } from '@tanstack/react-query'
@@ -27,6 +29,7 @@ import { useEffect } from 'react'
 import { useForm } from 'react-hook-form'
 
 export function PosPrinterConfigCard() {
+	const pb = usePocketBase()
 	const queryClient = useQueryClient()
 	const isWails = isWailsEnv()
 
@@ -51,6 +54,12 @@ export function PosPrinterConfigCard() {
 	const { watch, setValue } = form
 	const settings = watch()
 
+	// État temps réel (DLE EOT) : seulement pour les imprimantes réseau / USB
+	const { data: printerStatus } = useQuery({
+		...printerStatusQueryOptions(pb, settings.printerName),
+		enabled: settings.enabled && !!settings.printerName,
+	})
+
 	// Sélection auto de la première imprimante
 	useEffect(() => {
 		if (!settings.printerName && printers.length > 0) {
@@ -83,7 +92,7 @@ export function PosPrinterConfigCard() {
 	}
 
 	const handleTestDrawer = () => {
-		testDrawer.mutate()
+		testDrawer.mutate({ reason: 'test' })
 	}
 
 	return (
@@ -163,6 +172,19 @@ export function PosPrinterConfigCard() {
 							Aucune imprimante détectée. Vérifiez vos pilotes.
 						</p>
 					)}
+					{printerStatus?.status.known && (
+						<p
+							className={`text-xs ${printerStatus.ready ? 'text-emerald-700' : 'text-red-600'}`}
+						>
+							{printerStatus.ready
+								? printerStatus.status.paper_near_end
+									? '● Prête — fin de rouleau proche'
+									: '● Prête'
+								: `● ${printerStatus.reason}`}
+							{printerStatus.pending_jobs > 0 &&
+								` — ${printerStatus.pending_jobs} ticket(s) en attente`}
+						</p>
+					)}
 				</div>
 
 				{/* Largeur papier */}
@@ -213,7 +213,7 @@ export function PrinterSettingsCard() {
 						disabled={
 							!settings.enabled || !settings.printerName || testDrawer.isPending
 						}
-						onClick={() => testDrawer.mutate()}
+						onClick={() => testDrawer.mutate({ reason: 'test' })}
 						className='inline-flex items-center gap-1.5 h-8 px-3 rounded-lg text-xs font-medium
               text-muted-foreground border border-border/50
               hover:text-foreground hover:border-border hover:bg-muted/30
@@ -12,13 +12,18 @@ import {
 	DialogTitle,
 } from '@/components/ui/dialog'
 import { Separator } from '@/components/ui/separator'
-import { type RapportX, aggregateEreporting } from '@/lib/types/cash.types'
+import {
+	type DrawerOpeningsSummary,
+	type RapportX,
+	aggregateEreporting,
+} from '@/lib/types/cash.types'
 import {
 	FileText,
 	Loader2,
 	Printer,
 	Receipt,
 	TrendingUp,
+	Vault,
 	Wallet,
 } from 'lucide-react'
 import {
@@ -162,6 +167,16 @@ export function RapportXDialog({
 
 						<Separator />
 
+						{/* Ouvertures du tiroir */}
+						{rapport.drawer && rapport.drawer.total > 0 && (
+							<>
+								<Section icon={Vault} title='Ouvertures du tiroir'>
+									<DrawerOpeningsBlock drawer={rapport.drawer} />
+								</Section>
+								<Separator />
+							</>
+						)}
+
 						{/* Espèces attendues */}
 						<Section title='Espèces attendues en caisse'>
 							<ExpectedCashCard
@@ -215,6 +230,49 @@ function Section({
 	)
 }
 
+function DrawerOpeningsBlock({ drawer }: { drawer: DrawerOpeningsSummary }) {
+	return (
+		<div className='space-y-2 text-sm'>
+			<div className='flex justify-between'>
+				<span className='text-muted-foreground'>Ouvertures</span>
+				<span className='font-medium'>{drawer.total}</span>
+			</div>
+			<div className='flex justify-between'>
+				<span className='text-muted-foreground'>Dont sans vente</span>
+				<span
+					className={
+						drawer.no_sale > 0 ? 'font-semibold text-amber-700' : 'font-medium'
+					}
+				>
+					{drawer.no_sale}
+				</span>
+			</div>
+			{drawer.by_cashier.length > 0 && (
+				<div className='rounded-md border divide-y'>
+					{drawer.by_cashier.map((c) => (
+						<div
+							key={c.cashier}
+							className='flex items-center justify-between px-3 py-1.5'
+						>
+							<span>{c.cashier_name}</span>
+							<span className='text-muted-foreground'>
+								{c.openings} ouverture{c.openings > 1 ? 's' : ''} —{' '}
+								<span
+									className={
+										c.no_sale > 0 ? 'font-semibold text-amber-700' : undefined
+									}
+								>
+									{c.no_sale} sans vente
+								</span>
+							</span>
+						</div>
+					))}
+				</div>
+			)}
+		</div>
+	)
+}
+
 function EreportingBlock({ rapport }: { rapport: RapportX }) {
 	const { b2c, b2b } = aggregateEreporting(rapport.sales.by_customer_type ?? {})
 	const hasB2C = b2c.count > 0
@@ -469,6 +469,32 @@ export function ZReportPDF({ rapport }: ZReportPDFProps) {
 					</View>
 				</View>
 
+				{/* Ouvertures du tiroir */}
+				{rapport.daily_totals.drawer &&
+					rapport.daily_totals.drawer.total > 0 && (
+						<View style={s.section}>
+							<Text style={s.sectionTitle}>OUVERTURES DU TIROIR</Text>
+							<View style={s.row}>
+								<Text>Ouvertures</Text>
+								<Text style={s.value}>{rapport.daily_totals.drawer.total}</Text>
+							</View>
+							<View style={s.row}>
+								<Text>Dont sans vente</Text>
+								<Text style={s.value}>
+									{rapport.daily_totals.drawer.no_sale}
+								</Text>
+							</View>
+							{rapport.daily_totals.drawer.by_cashier.map((c) => (
+								<View key={c.cashier} style={s.row}>
+									<Text>{c.cashier_name}</Text>
+									<Text>
+										{c.openings} ouv. — {c.no_sale} sans vente
+									</Text>
+								</View>
+							))}
+						</View>
+					)}
+
 				{/* Sessions */}
 				<View style={s.section}>
 					<Text style={s.sectionTitle}>
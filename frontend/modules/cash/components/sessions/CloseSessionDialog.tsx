@@ -65,7 +65,10 @@ export function CloseSessionDialog({
 	const [isGeneratingZRedirect, setIsGeneratingZRedirect] = useState(false)
 	const openDrawer = useOpenCashDrawerMutation()
 	const handleOpenDrawer = () => {
-		openDrawer.mutate()
+		openDrawer.mutate({
+			reason: 'session_close',
+			cashRegisterId: (session as any)?.cash_register,
+		})
 	}
 
 	// ✅ Source de vérité pour "Espèces attendues" : Rapport X
@@ -52,7 +52,7 @@ export function OpenSessionDialog({
 
 	const openDrawer = useOpenCashDrawerMutation()
 	const handleOpenDrawer = () => {
-		openDrawer.mutate()
+		openDrawer.mutate({ reason: 'session_open' })
 	}
 
 	const form = useForm<DenominationsForm>({
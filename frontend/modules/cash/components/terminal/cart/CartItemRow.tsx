@@ -23,6 +23,7 @@ interface CartItemRowProps {
 	getLineTotalTtc: (item: CartItem) => number
 	onSetUnitPrice: (itemId: string, raw: string) => void
 	onClearUnitPrice: (itemId: string) => void
+	onPickSerials?: (itemId: string) => void
 }
 
 export function CartItemRow({
@@ -38,6 +39,7 @@ export function CartItemRow({
 	getLineTotalTtc,
 	onSetUnitPrice,
 	onClearUnitPrice,
+	onPickSerials,
 }: CartItemRowProps) {
 	const hasPriceOverride =
 		item.originalUnitPrice != null && item.unitPrice !== item.originalUnitPrice
@@ -73,6 +75,7 @@ export function CartItemRow({
 	const mode: LineDiscountMode = item.lineDiscountMode ?? 'percent'
 	const value = item.lineDiscountRaw || ''
 	const currentDisplayMode = item.displayMode || 'name'
+	const serialCount = item.serialNumbers?.length ?? 0
 
 	return (
 		<div className='py-2.5'>
@@ -165,6 +168,22 @@ export function CartItemRow({
 							: 'Remise'}
 				</button>
 
+				{/* Numéros de série — la ligne ne s'encaisse pas sans eux */}
+				{item.serialized && onPickSerials && (
+					<button
+						type='button'
+						onClick={() => onPickSerials(item.id)}
+						title={item.serialNumbers?.join(', ')}
+						className={`h-7 px-2.5 rounded-md text-[11px] font-medium border transition-colors ${
+							serialCount === item.quantity
+								? 'border-primary/30 bg-primary/5 text-primary'
+								: 'border-amber-500/50 bg-amber-50 text-amber-700'
+						}`}
+					>
+						N° série {serialCount}/{item.quantity}
+					</button>
+				)}
+
 				{/* Dropdown affichage — discret, en ligne */}
 				{hasDisplayChoice && (
 					<Select
@@ -45,6 +45,7 @@ interface CartPanelProps {
 	setEditingLineId: (id: string | null) => void
 	setUnitPrice: (itemId: string, raw: string) => void
 	clearUnitPrice: (itemId: string) => void
+	onPickSerials?: (itemId: string) => void
 }
 
 export function CartPanel({
@@ -73,6 +74,7 @@ export function CartPanel({
 	setEditingLineId,
 	setUnitPrice,
 	clearUnitPrice,
+	onPickSerials,
 }: CartPanelProps) {
 	const cartItems = (
 		<div className='divide-y'>
@@ -91,6 +93,7 @@ export function CartPanel({
 					getLineTotalTtc={getLineTotalTtc}
 					onSetUnitPrice={setUnitPrice}
 					onClearUnitPrice={clearUnitPrice}
+					onPickSerials={onPickSerials}
 				/>
 			))}
 		</div>
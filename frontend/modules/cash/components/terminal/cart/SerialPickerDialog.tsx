@@ -0,0 +1,164 @@
+// frontend/modules/cash/components/terminal/cart/SerialPickerDialog.tsx
+//
+// Choix des unités vendues d'un produit suivi par numéro de série. On scanne
+// l'étiquette de l'instrument ou on coche dans la liste des unités en stock ;
+// la quantité de la ligne suit le nombre d'unités choisies. Le serveur
+// revérifie au moment du ticket : une unité vendue entre-temps sur une autre
+// caisse le fait refuser.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Check } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useAvailableSerials } from '@/lib/queries/serials'
+import type { CartItem } from '../types/cart'
+
+/** Même forme que `backend.NormalizeSerial` */
+function normalizeSerial(serial: string): string {
+	return serial.replace(/\s+/g, '').toUpperCase()
+}
+
+interface SerialPickerDialogProps {
+	item: CartItem | null
+	onOpenChange: (open: boolean) => void
+	onConfirm: (itemId: string, serials: string[]) => void
+}
+
+export function SerialPickerDialog({
+	item,
+	onOpenChange,
+	onConfirm,
+}: SerialPickerDialogProps) {
+	const { data: units = [], isError } = useAvailableSerials(
+		item?.productId,
+		!!item,
+	)
+	const [selected, setSelected] = useState<string[]>([])
+	const [scan, setScan] = useState('')
+
+	useEffect(() => {
+		setSelected(item?.serialNumbers ?? [])
+		setScan('')
+	}, [item])
+
+	const toggle = (serial: string) =>
+		setSelected((prev) =>
+			prev.includes(serial)
+				? prev.filter((s) => s !== serial)
+				: [...prev, serial],
+		)
+
+	const handleScan = () => {
+		const serial = normalizeSerial(scan)
+		setScan('')
+		if (!serial) return
+		if (selected.includes(serial)) {
+			toast.info(`${serial} est déjà choisi`)
+			return
+		}
+		// Hors connexion la liste ne se charge pas : le numéro scanné est pris
+		// tel quel, le rejeu du ticket le rapprochera du stock.
+		if (!isError && !units.some((u) => u.serial_number === serial)) {
+			toast.error(`${serial} n’est pas en stock pour ce produit`)
+			return
+		}
+		setSelected((prev) => [...prev, serial])
+	}
+
+	const handleConfirm = () => {
+		if (!item) return
+		if (selected.length === 0) {
+			toast.error('Choisissez au moins une unité')
+			return
+		}
+		onConfirm(item.id, selected)
+		onOpenChange(false)
+	}
+
+	return (
+		<Dialog open={!!item} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-md'>
+				<DialogHeader>
+					<DialogTitle>Numéros de série — {item?.name}</DialogTitle>
+					<DialogDescription>
+						Scannez l’étiquette de chaque unité vendue, ou cochez-la dans la
+						liste.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-3'>
+					<Input
+						autoFocus
+						placeholder='Scanner ou saisir un numéro'
+						value={scan}
+						onChange={(e) => setScan(e.target.value)}
+						onKeyDown={(e) => {
+							if (e.key === 'Enter') {
+								e.preventDefault()
+								handleScan()
+							}
+						}}
+					/>
+
+					{isError ? (
+						<p className='text-muted-foreground text-sm'>
+							Stock des unités indisponible : les numéros scannés seront
+							vérifiés à l’envoi du ticket.
+						</p>
+					) : units.length === 0 ? (
+						<p className='text-muted-foreground text-sm'>
+							Aucune unité en stock pour ce produit
+						</p>
+					) : (
+						<div className='max-h-64 divide-y overflow-y-auto rounded-md border'>
+							{units.map((unit) => {
+								const checked = selected.includes(unit.serial_number)
+								return (
+									<button
+										key={unit.id}
+										type='button'
+										className='flex w-full items-center justify-between px-3 py-2 text-left text-sm hover:bg-muted'
+										onClick={() => toggle(unit.serial_number)}
+									>
+										<span>
+											<span className='font-mono'>{unit.serial_number}</span>
+											{unit.lot_number && (
+												<span className='ml-2 text-muted-foreground text-xs'>
+													lot {unit.lot_number}
+												</span>
+											)}
+										</span>
+										{checked && <Check className='h-4 w-4 text-primary' />}
+									</button>
+								)
+							})}
+						</div>
+					)}
+
+					{selected.length > 0 && (
+						<p className='text-sm'>
+							{selected.length} unité(s) : {selected.join(', ')}
+						</p>
+					)}
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleConfirm}>Valider</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
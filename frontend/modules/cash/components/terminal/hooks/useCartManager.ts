@@ -73,6 +73,7 @@ export function useCartManager(registerId: string) {
 					quantity: 1,
 					tvaRate,
 					displayMode: 'name',
+					serialized: !!product.serialized,
 				}
 				setLastAddedItem(newItem)
 				return [...prev, newItem]
@@ -88,7 +89,14 @@ export function useCartManager(registerId: string) {
 			setCart((prev) => {
 				if (newQuantity <= 0) return prev.filter((item) => item.id !== itemId)
 				return prev.map((item) =>
-					item.id === itemId ? { ...item, quantity: newQuantity } : item,
+					item.id === itemId
+						? {
+								...item,
+								quantity: newQuantity,
+								// Une unité retirée rend son numéro
+								serialNumbers: item.serialNumbers?.slice(0, newQuantity),
+							}
+						: item,
 				)
 			})
 		},
@@ -200,6 +208,24 @@ export function useCartManager(registerId: string) {
 		[setCart],
 	)
 
+	// Les unités choisies font la quantité : on vend ce qu'on a en main
+	const setSerialNumbers = React.useCallback(
+		(itemId: string, serials: string[]) => {
+			setCart((prev) =>
+				prev.map((it) =>
+					it.id === itemId
+						? {
+								...it,
+								serialNumbers: serials,
+								quantity: serials.length > 0 ? serials.length : it.quantity,
+							}
+						: it,
+				),
+			)
+		},
+		[setCart],
+	)
+
 	const toggleItemDisplayMode = React.useCallback(
 		(itemId: string) => {
 			setCart((prev) =>
@@ -232,9 +258,10 @@ export function useCartManager(registerId: string) {
 		clearTerminalState(registerId)
 	}, [setCart, registerId])
 
+	// Rend le panier mis en attente, pour que l'appelant réserve ses lignes
 	const parkCart = React.useCallback(
-		(label?: string) => {
-			if (cart.length === 0) return
+		(label?: string): ParkedCart | undefined => {
+			if (cart.length === 0) return undefined
 			const parked: ParkedCart = {
 				id: `parked-${Date.now()}`,
 				items: cart,
@@ -243,6 +270,7 @@ export function useCartManager(registerId: string) {
 			}
 			setParkedCarts((prev) => [...prev, parked])
 			clearCart()
+			return parked
 		},
 		[cart, clearCart],
 	)
@@ -272,6 +300,7 @@ export function useCartManager(registerId: string) {
 		setLineDiscountMode,
 		setLineDiscountValue,
 		toggleItemDisplayMode,
+		setSerialNumbers,
 		clearCart,
 		clearCartAndStore,
 		parkCart,
@@ -8,5 +8,7 @@ export * from './hooks/useBarcodeScanner'
 export * from './payment/PaymentDialog'
 export * from './payment/SuccessView'
 export * from './cart/CartPanel'
+export * from './cart/SerialPickerDialog'
 export * from './products/ProductsPanel'
 export * from './layout/TerminalHeader'
+export * from './layout/NoSaleDrawerDialog'
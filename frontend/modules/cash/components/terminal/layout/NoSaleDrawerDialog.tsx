@@ -0,0 +1,101 @@
+// frontend/modules/cash/components/terminal/layout/NoSaleDrawerDialog.tsx
+// Ouverture manuelle du tiroir, hors vente : le motif est obligatoire et
+// l'ouverture est journalisée (caissier, session). Le serveur refuse si le
+// rôle de l'utilisateur est inférieur au minimum réglé sur la caisse.
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Textarea } from '@/components/ui/textarea'
+import { useOpenCashDrawerMutation } from '@/lib/pos/printerQueries'
+import { Loader2, Vault } from 'lucide-react'
+import { useState } from 'react'
+
+const QUICK_REASONS = ['Faire la monnaie', 'Erreur de rendu', 'Contrôle caisse']
+
+interface NoSaleDrawerDialogProps {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+	cashRegisterId?: string
+}
+
+export function NoSaleDrawerDialog({
+	open,
+	onOpenChange,
+	cashRegisterId,
+}: NoSaleDrawerDialogProps) {
+	const openDrawer = useOpenCashDrawerMutation()
+	const [note, setNote] = useState('')
+
+	const handleOpen = () => {
+		openDrawer.mutate(
+			{ reason: 'no_sale', note: note.trim(), cashRegisterId },
+			{
+				onSuccess: () => {
+					setNote('')
+					onOpenChange(false)
+				},
+			},
+		)
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='sm:max-w-md'>
+				<DialogHeader>
+					<DialogTitle className='flex items-center gap-2'>
+						<Vault className='h-4 w-4' />
+						Ouvrir le tiroir sans vente
+					</DialogTitle>
+					<DialogDescription>
+						L'ouverture est enregistrée à votre nom et apparaît dans les
+						rapports X et Z.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-3'>
+					<div className='flex flex-wrap gap-2'>
+						{QUICK_REASONS.map((reason) => (
+							<Button
+								key={reason}
+								type='button'
+								variant='outline'
+								size='sm'
+								onClick={() => setNote(reason)}
+							>
+								{reason}
+							</Button>
+						))}
+					</div>
+					<Textarea
+						value={note}
+						onChange={(e) => setNote(e.target.value)}
+						placeholder='Motif de l’ouverture'
+						rows={2}
+						maxLength={255}
+					/>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button
+						onClick={handleOpen}
+						disabled={!note.trim() || openDrawer.isPending}
+					>
+						{openDrawer.isPending && (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						)}
+						Ouvrir le tiroir
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
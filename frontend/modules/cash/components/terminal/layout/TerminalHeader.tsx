@@ -1,13 +1,15 @@
 // frontend/modules/cash/components/terminal/layout/TerminalHeader.tsx
 import { Button } from '@/components/ui/button'
-import { useOpenCashDrawerMutation } from '@/lib/pos/printerQueries'
-import { Loader2, Vault } from 'lucide-react'
+import { Vault } from 'lucide-react'
+import { useState } from 'react'
 import { CashPageHeader } from '../../layout/CashPageHeader'
+import { NoSaleDrawerDialog } from './NoSaleDrawerDialog'
 
 interface TerminalHeaderProps {
 	registerName: string
 	sessionIdShort: string
 	today: string
+	cashRegisterId?: string
 	onBack: () => void
 }
 
@@ -15,13 +17,10 @@ export function TerminalHeader({
 	registerName,
 	sessionIdShort,
 	today,
+	cashRegisterId,
 	// onBack,
 }: TerminalHeaderProps) {
-	const openDrawer = useOpenCashDrawerMutation()
-
-	const handleOpenDrawer = () => {
-		openDrawer.mutate()
-	}
+	const [showNoSale, setShowNoSale] = useState(false)
 
 	return (
 		<CashPageHeader
@@ -33,22 +32,17 @@ export function TerminalHeader({
 					<Button
 						variant='outline'
 						size='sm'
-						onClick={handleOpenDrawer}
-						disabled={openDrawer.isPending}
+						onClick={() => setShowNoSale(true)}
 						className='h-8'
 					>
-						{openDrawer.isPending ? (
-							<>
-								<Loader2 className='h-3.5 w-3.5 mr-2 animate-spin' />
-								Ouverture...
-							</>
-						) : (
-							<>
-								<Vault className='h-3.5 w-3.5 mr-2' />
-								Ouvrir tiroir
-							</>
-						)}
+						<Vault className='h-3.5 w-3.5 mr-2' />
+						Ouvrir tiroir
 					</Button>
+					<NoSaleDrawerDialog
+						open={showNoSale}
+						onOpenChange={setShowNoSale}
+						cashRegisterId={cashRegisterId}
+					/>
 					<div className='flex items-center gap-2 rounded-full bg-emerald-100 px-3 py-1'>
 						<span className='h-2 w-2 rounded-full bg-emerald-500' />
 						<span className='font-medium text-emerald-700'>
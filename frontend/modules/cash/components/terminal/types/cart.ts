@@ -18,6 +18,9 @@ export interface CartItem {
 	lineDiscountValue?: number
 	lineDiscountRaw?: string
 	displayMode?: DisplayMode
+	/** Produit suivi : la vente exige un numéro de série par unité */
+	serialized?: boolean
+	serialNumbers?: string[]
 }
 
 export interface VatBreakdown {
@@ -45,4 +48,5 @@ export type PosProduct = {
 	/** Taux de TVA du schéma `catalog_v2`. Le nom `tva_rate` était celui du
 	 *  transformateur AppPos. */
 	tax_rate?: number | null
+	serialized?: boolean
 }
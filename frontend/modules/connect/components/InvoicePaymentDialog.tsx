@@ -193,9 +193,11 @@ export function InvoicePaymentDialog({
 				printerSettings.autoOpenDrawer
 			) {
 				try {
-					await openCashDrawer({
+					await openCashDrawer(pb, {
 						printerName: printerSettings.printerName,
 						width: printerSettings.width,
+						reason: 'sale',
+						invoiceId: invoice.id,
 					})
 				} catch {
 					/* non-fatal */
@@ -265,7 +267,12 @@ export function InvoicePaymentDialog({
 							<Button
 								variant='outline'
 								size='sm'
-								onClick={() => openDrawer.mutate()}
+								onClick={() =>
+									openDrawer.mutate({
+										reason: 'sale',
+										invoiceId: paidInvoice.id,
+									})
+								}
 								disabled={openDrawer.isPending}
 							>
 								<Printer className='h-4 w-4 mr-2' />
@@ -324,15 +331,6 @@ export function InvoicePaymentDialog({
 								{formatCurrency(invoice.total_ttc)}
 							</DialogDescription>
 						</div>
-						<Button
-							variant='outline'
-							size='sm'
-							onClick={() => openDrawer.mutate()}
-							disabled={openDrawer.isPending}
-						>
-							<Printer className='h-4 w-4 mr-2' />
-							Tiroir
-						</Button>
 					</div>
 				</DialogHeader>
 
@@ -142,6 +142,7 @@ export function OrderCreatePage() {
 				: 0
 		const newItem = computeItem({
 			id: crypto.randomUUID(),
+			product_id: product.id,
 			description: product.name,
 			quantity: 1,
 			unit_price_ht: unitPriceHT,
@@ -22,7 +22,10 @@ import { Label } from '@/components/ui/label'
 import { Progress } from '@/components/ui/progress'
 import { Separator } from '@/components/ui/separator'
 import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
-import { createInventoryEntries } from '@/lib/inventory/inventory-pocketbase'
+import {
+	createInventoryEntries,
+	expectedStock,
+} from '@/lib/inventory/inventory-pocketbase'
 import { INVENTORY_ENTRIES_COLLECTION } from '@/lib/inventory/inventory-types'
 import type {
 	CategoryInventoryStatus,
@@ -48,6 +51,7 @@ import {
 	useCatalogSnapshot,
 } from '@/lib/queries/catalog-snapshot'
 import { useCategories } from '@/lib/queries/categories'
+import { useStockLocations } from '@/lib/queries/locations'
 import { usePocketBase } from '@/lib/use-pocketbase'
 import { cn } from '@/lib/utils'
 import { useAuth } from '@/modules/auth/AuthProvider'
@@ -426,6 +430,7 @@ function CreateSessionDialog({
 		categoryIds: string[],
 		label?: string,
 		targetedProductIds?: string[],
+		locationId?: string,
 	) => void
 	isLoading: boolean
 	activeSessions: InventorySession[]
@@ -443,12 +448,20 @@ function CreateSessionDialog({
 		Set<string>
 	>(new Set())
 	const [selectedProductIds, setSelectedProductIds] = useState<string[]>([])
+	const [locationId, setLocationId] = useState('')
 
 	const { data: history } = useInventoryHistory()
 
 	const pb = usePocketBase()
 	const { activeCompanyId } = useActiveCompany()
 
+	// Une société à un seul emplacement n'a rien à choisir : la session compte
+	// l'emplacement par défaut, que le serveur résout seul.
+	const { data: locations = [] } = useStockLocations(
+		activeCompanyId ?? undefined,
+	)
+	const activeLocations = locations.filter((l) => !l.archived)
+
 	const { data: catalogProducts = [] } = useCatalogSnapshot(
 		pb,
 		activeCompanyId ?? undefined,
@@ -543,6 +556,7 @@ function CreateSessionDialog({
 			setExpandedGroups(new Set())
 			setExpandedProductGroups(new Set())
 			setSelectedProductIds([])
+			setLocationId('')
 		}
 	}, [open, defaultOperator])
 
@@ -766,7 +780,14 @@ function CreateSessionDialog({
 	const handleConfirm = () => {
 		if (!canConfirm) return
 		if (scopeMode === 'free') {
-			onConfirm(operator.trim(), 'free', [], freeLabel.trim())
+			onConfirm(
+				operator.trim(),
+				'free',
+				[],
+				freeLabel.trim(),
+				undefined,
+				locationId || undefined,
+			)
 		} else if (scopeMode === 'targeted') {
 			const categoryNames = [
 				...new Set(
@@ -786,9 +807,17 @@ function CreateSessionDialog({
 				[],
 				`Ciblée · ${categoryNames}`,
 				selectedProductIds,
+				locationId || undefined,
 			)
 		} else {
-			onConfirm(operator.trim(), 'selection', selectedCategoryIds)
+			onConfirm(
+				operator.trim(),
+				'selection',
+				selectedCategoryIds,
+				undefined,
+				undefined,
+				locationId || undefined,
+			)
 		}
 	}
 
@@ -813,6 +842,27 @@ function CreateSessionDialog({
 						/>
 					</div>
 
+					{activeLocations.length > 1 && (
+						<div className='mb-5 max-w-md space-y-1.5'>
+							<Label htmlFor='inventory-location'>Emplacement compté</Label>
+							<select
+								id='inventory-location'
+								className='h-9 w-full rounded-md border border-input bg-background px-3 text-sm'
+								value={locationId}
+								onChange={(e) => setLocationId(e.target.value)}
+							>
+								<option value=''>Emplacement par défaut</option>
+								{activeLocations
+									.filter((l) => !l.is_default)
+									.map((l) => (
+										<option key={l.id} value={l.id}>
+											{l.name}
+										</option>
+									))}
+							</select>
+						</div>
+					)}
+
 					{/* ── Type de session ───────────────────────────────────────── */}
 					<div className='mb-5 max-w-md space-y-1.5'>
 						<Label>Type de session</Label>
@@ -2022,8 +2072,9 @@ function CountingRow({
 	const isAdjusted = entry.adjusted
 	const ecart =
 		isCounted && entry.stock_compte !== null
-			? entry.stock_compte - entry.stock_theorique
+			? entry.stock_compte - expectedStock(entry)
 			: null
+	const salesDelta = entry.sales_delta ?? 0
 
 	const handleSave = () => {
 		if (localValue === '') return
@@ -2101,6 +2152,15 @@ function CountingRow({
 				<span className='text-muted-foreground font-mono'>
 					{entry.stock_theorique}
 				</span>
+				{salesDelta !== 0 && (
+					<div
+						className='text-xs text-muted-foreground'
+						title='Ventes, réceptions, transferts et retours à cet emplacement entre le gel et le comptage'
+					>
+						{salesDelta > 0 ? `+${salesDelta}` : salesDelta} depuis le gel →{' '}
+						{expectedStock(entry)}
+					</div>
+				)}
 			</td>
 			<td className='px-3 py-3 text-center'>
 				{isValidated ? (
@@ -2204,7 +2264,7 @@ function CategoryCountingView({
 		(e) =>
 			e.adjusted &&
 			e.stock_compte !== null &&
-			e.stock_compte !== e.stock_theorique,
+			e.stock_compte !== expectedStock(e),
 	).length
 
 	if (entriesLoading)
@@ -2405,7 +2465,7 @@ function SessionDetailView({
 											{cat.entries.map((entry) => {
 												const ecart =
 													entry.stock_compte !== null
-														? entry.stock_compte - entry.stock_theorique
+														? entry.stock_compte - expectedStock(entry)
 														: null
 												const hasGap = ecart !== null && ecart !== 0
 												return (
@@ -2464,6 +2524,12 @@ function SessionDetailView({
 																	<span className='font-medium text-foreground'>
 																		{entry.stock_theorique}
 																	</span>
+																	{(entry.sales_delta ?? 0) !== 0 && (
+																		<>
+																			{' '}
+																			(attendu {expectedStock(entry)})
+																		</>
+																	)}
 																</span>
 																<span className='text-xs text-muted-foreground'>
 																	→
@@ -2966,13 +3032,14 @@ export function InventoryPage() {
 
 	const { user } = useAuth()
 	const userName = (user as any)?.name || (user as any)?.username || ''
-	const pb = usePocketBase()
 	const { activeCompanyId } = useActiveCompany()
 
 	const { data: activeSessions = [], isLoading: sessionsLoading } =
 		useActiveSessions()
 	const createSession = useCreateInventorySession()
-	const { progress: creationProgress } = createSession
+	// Le gel se fait côté serveur, en une transaction : pas de progression à
+	// suivre produit par produit, seulement son attente.
+	const isCreatingSnapshot = createSession.isPending
 	const currentSession =
 		activeSessions.find((s) => s.id === selectedSessionId) ?? null
 
@@ -3013,41 +3080,21 @@ export function InventoryPage() {
 		categoryIds: string[],
 		label?: string,
 		targetedProductIds?: string[],
+		locationId?: string,
 	) => {
+		// Les produits ciblés sont gelés avec la session, par le serveur. Un
+		// produit disparu entre la sélection et la validation est simplement
+		// absent du périmètre.
 		const result = await createSession.mutateAsync({
 			operator,
 			scope,
 			scope_category_ids: categoryIds,
 			label: label ?? null,
 			companyId: activeCompanyId ?? undefined,
+			productIds: targetedProductIds,
+			locationId,
 		})
 
-		if (targetedProductIds && targetedProductIds.length > 0) {
-			const freshCatalog = await fetchCatalogSnapshot(
-				pb,
-				activeCompanyId ?? undefined,
-			)
-			const entries = targetedProductIds.flatMap((pid) => {
-				// Un produit disparu entre la sélection et la validation ne fait plus
-				// planter la création : il est simplement absent du périmètre.
-				const p = freshCatalog.find((c) => c.id === pid)
-				if (!p) return []
-				return [
-					{
-						product_id: p.id,
-						product_name: p.name,
-						product_sku: p.sku,
-						product_barcode: p.barcode,
-						product_image: p.imageUrl ?? '',
-						category_id: p.categories[0] ?? '',
-						category_name: label ?? 'Session ciblée',
-						stock_theorique: p.stock,
-					},
-				]
-			})
-			await createInventoryEntries(pb, result.session.id, entries)
-		}
-
 		setShowCreateDialog(false)
 		setSelectedSessionId(result.session.id)
 		setView('overview')
@@ -3068,7 +3115,7 @@ export function InventoryPage() {
 	const handleCancelSession = async () => {
 		if (
 			!confirm(
-				"Annuler l'inventaire ? Les ajustements déjà appliqués ne seront pas annulés.",
+				"Annuler l'inventaire ? Les écarts comptés ne seront pas appliqués au stock.",
 			)
 		)
 			return
@@ -3099,7 +3146,7 @@ export function InventoryPage() {
 			}
 		>
 			<div className='flex flex-col h-full -m-6 overflow-hidden'>
-				{creationProgress && (
+				{isCreatingSnapshot && (
 					<div className='flex flex-col items-center justify-center h-full gap-6'>
 						<div className='w-16 h-16 rounded-2xl bg-orange-500/10 flex items-center justify-center'>
 							<Loader2 className='h-8 w-8 text-orange-500 animate-spin' />
@@ -3108,31 +3155,14 @@ export function InventoryPage() {
 							<h3 className='text-lg font-semibold mb-1'>
 								Création du snapshot en cours...
 							</h3>
-							<p className='text-sm text-muted-foreground mb-4'>
-								{creationProgress.done} / {creationProgress.total} produits
-								enregistrés
+							<p className='text-sm text-muted-foreground'>
+								Les stocks théoriques sont gelés en une seule fois.
 							</p>
-							<div className='w-72'>
-								<div className='h-2 bg-muted rounded-full overflow-hidden'>
-									<div
-										className='h-full bg-orange-500 rounded-full transition-all duration-300'
-										style={{
-											width: `${Math.round((creationProgress.done / creationProgress.total) * 100)}%`,
-										}}
-									/>
-								</div>
-								<p className='text-xs text-muted-foreground mt-1 text-right'>
-									{Math.round(
-										(creationProgress.done / creationProgress.total) * 100,
-									)}
-									%
-								</p>
-							</div>
 						</div>
 					</div>
 				)}
 
-				{view === 'home' && !creationProgress && (
+				{view === 'home' && !isCreatingSnapshot && (
 					<InventoryHomeView
 						activeSessions={activeSessions}
 						sessionsLoading={sessionsLoading}
@@ -3144,7 +3174,7 @@ export function InventoryPage() {
 
 				{view === 'overview' &&
 					currentSession &&
-					!creationProgress &&
+					!isCreatingSnapshot &&
 					(currentSession.scope === 'free' ? (
 						<FreeSessionView
 							session={currentSession}
@@ -0,0 +1,217 @@
+// frontend/modules/stock/PurchaseOrdersPage.tsx
+//
+// Commandes fournisseur : saisie en brouillon, envoi, réception, annulation.
+// La réception est la seule écriture du stock depuis cet écran, et elle passe
+// par le serveur (`/api/purchase-orders/:id/receive`), jamais par une lecture
+// puis une réécriture du produit.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import {
+	Ban,
+	PackageCheck,
+	Plus,
+	Send,
+	ShoppingCart,
+	Trash2,
+} from 'lucide-react'
+import { useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	PURCHASE_ORDER_STATUS_LABELS,
+	type PurchaseOrder,
+	type PurchaseOrderStatus,
+	useCancelPurchaseOrder,
+	useDeletePurchaseOrder,
+	usePurchaseOrders,
+	useSendPurchaseOrder,
+} from '@/lib/queries/purchasing'
+import { PurchaseOrderDialog } from './components/PurchaseOrderDialog'
+import { PurchaseReceiptDialog } from './components/PurchaseReceiptDialog'
+
+const STATUS_VARIANTS: Record<
+	PurchaseOrderStatus,
+	'default' | 'secondary' | 'destructive' | 'outline'
+> = {
+	draft: 'outline',
+	sent: 'secondary',
+	partially_received: 'secondary',
+	received: 'default',
+	cancelled: 'destructive',
+}
+
+const fmt = (amount: number) =>
+	new Intl.NumberFormat('fr-FR', { style: 'currency', currency: 'EUR' }).format(
+		amount,
+	)
+
+export function PurchaseOrdersPage() {
+	const { activeCompanyId } = useActiveCompany()
+	const { data: orders, isLoading } = usePurchaseOrders(
+		activeCompanyId ?? undefined,
+	)
+	const sendOrder = useSendPurchaseOrder()
+	const cancelOrder = useCancelPurchaseOrder()
+	const deleteOrder = useDeletePurchaseOrder()
+
+	const [createOpen, setCreateOpen] = useState(false)
+	const [receiving, setReceiving] = useState<PurchaseOrder | null>(null)
+
+	const run = async (
+		action: () => Promise<unknown>,
+		success: string,
+	): Promise<void> => {
+		try {
+			await action()
+			toast.success(success)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6 flex items-start justify-between'>
+				<div>
+					<div className='mb-2 flex items-center gap-3'>
+						<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+							<ShoppingCart className='h-6 w-6 text-primary' />
+						</div>
+						<h1 className='font-bold text-3xl'>Commandes fournisseur</h1>
+					</div>
+					<p className='text-muted-foreground'>
+						Une commande reste modifiable tant qu’elle est en brouillon. Chaque
+						réception entre en stock et met à jour le prix d’achat des produits.
+					</p>
+				</div>
+				<Button onClick={() => setCreateOpen(true)}>
+					<Plus className='mr-2 h-4 w-4' />
+					Nouvelle commande
+				</Button>
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Chargement...
+				</div>
+			) : !orders?.length ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucune commande fournisseur
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Numéro</TableHead>
+								<TableHead>Fournisseur</TableHead>
+								<TableHead>Statut</TableHead>
+								<TableHead>Livraison prévue</TableHead>
+								<TableHead className='text-right'>Total HT</TableHead>
+								<TableHead className='w-[160px]'>Actions</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{orders.map((order) => (
+								<TableRow key={order.id}>
+									<TableCell className='font-medium'>{order.number}</TableCell>
+									<TableCell>{order.expand?.supplier?.name ?? '—'}</TableCell>
+									<TableCell>
+										<Badge variant={STATUS_VARIANTS[order.status]}>
+											{PURCHASE_ORDER_STATUS_LABELS[order.status]}
+										</Badge>
+									</TableCell>
+									<TableCell>
+										{order.expected_at
+											? new Date(order.expected_at).toLocaleDateString('fr-FR')
+											: '—'}
+									</TableCell>
+									<TableCell className='text-right'>
+										{order.status === 'draft' ? '—' : fmt(order.total_ht || 0)}
+									</TableCell>
+									<TableCell>
+										<div className='flex gap-1'>
+											{order.status === 'draft' && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Envoyer'
+													onClick={() =>
+														run(
+															() => sendOrder.mutateAsync(order.id),
+															`Commande ${order.number} envoyée`,
+														)
+													}
+												>
+													<Send className='h-4 w-4' />
+												</Button>
+											)}
+											{(order.status === 'sent' ||
+												order.status === 'partially_received') && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Réceptionner'
+													onClick={() => setReceiving(order)}
+												>
+													<PackageCheck className='h-4 w-4' />
+												</Button>
+											)}
+											{(order.status === 'draft' || order.status === 'sent') && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Annuler la commande'
+													onClick={() =>
+														run(
+															() => cancelOrder.mutateAsync(order.id),
+															`Commande ${order.number} annulée`,
+														)
+													}
+												>
+													<Ban className='h-4 w-4' />
+												</Button>
+											)}
+											{order.status === 'draft' && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Supprimer le brouillon'
+													onClick={() =>
+														run(
+															() => deleteOrder.mutateAsync(order.id),
+															`Brouillon ${order.number} supprimé`,
+														)
+													}
+												>
+													<Trash2 className='h-4 w-4 text-destructive' />
+												</Button>
+											)}
+										</div>
+									</TableCell>
+								</TableRow>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+
+			<PurchaseOrderDialog open={createOpen} onOpenChange={setCreateOpen} />
+			<PurchaseReceiptDialog
+				order={receiving}
+				onOpenChange={(open) => !open && setReceiving(null)}
+			/>
+		</div>
+	)
+}
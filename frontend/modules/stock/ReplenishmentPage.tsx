@@ -0,0 +1,249 @@
+// frontend/modules/stock/ReplenishmentPage.tsx
+//
+// Réassort : les produits sous leur stock minimum ou sous l'objectif de
+// couverture, regroupés par fournisseur. Chaque groupe part en commande
+// fournisseur (brouillon, à relire avant envoi) ou en CSV.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { useNavigate } from '@tanstack/react-router'
+import { Download, FileText, TrendingDown } from 'lucide-react'
+import { useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import { useCreatePurchaseOrder } from '@/lib/queries/purchasing'
+import {
+	type ReplenishmentGroup,
+	downloadReplenishmentCsv,
+	replenishmentOrderLines,
+	useReplenishment,
+} from '@/lib/queries/replenishment'
+import { usePocketBase } from '@/lib/use-pocketbase'
+
+const fmt = (amount: number) =>
+	new Intl.NumberFormat('fr-FR', { style: 'currency', currency: 'EUR' }).format(
+		amount,
+	)
+
+export function ReplenishmentPage() {
+	const pb = usePocketBase()
+	const navigate = useNavigate()
+	const { activeCompanyId } = useActiveCompany()
+	const createOrder = useCreatePurchaseOrder()
+
+	const [weeks, setWeeks] = useState(8)
+	const [coverDays, setCoverDays] = useState(30)
+
+	const request = activeCompanyId
+		? { company_id: activeCompanyId, weeks, cover_days: coverDays }
+		: null
+	const { data: report, isLoading, error } = useReplenishment(request)
+
+	const handleCreateOrder = async (group: ReplenishmentGroup) => {
+		if (!activeCompanyId) return
+		const lines = replenishmentOrderLines(group)
+		if (lines.length === 0) {
+			toast.info('Tout est déjà en commande chez ce fournisseur')
+			return
+		}
+		try {
+			const order = await createOrder.mutateAsync({
+				owner_company: activeCompanyId,
+				supplier: group.supplier_id,
+				notes: `Réassort du ${new Date().toLocaleDateString('fr-FR')}`,
+				lines,
+			})
+			toast.success(`Brouillon ${order.number} créé`)
+			navigate({ to: '/stock/commandes' })
+		} catch (err) {
+			toast.error(pocketbaseErrorMessage(err))
+		}
+	}
+
+	const handleCsv = async (group: ReplenishmentGroup) => {
+		if (!activeCompanyId) return
+		try {
+			const blob = await downloadReplenishmentCsv(pb, {
+				company_id: activeCompanyId,
+				weeks,
+				cover_days: coverDays,
+				supplier_id: group.supplier_id || 'none',
+			})
+			const url = URL.createObjectURL(blob)
+			const link = document.createElement('a')
+			link.href = url
+			link.download = `reassort-${group.supplier_code || group.supplier_name}.csv`
+			link.click()
+			URL.revokeObjectURL(url)
+		} catch (err) {
+			toast.error(pocketbaseErrorMessage(err))
+		}
+	}
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6'>
+				<div className='mb-2 flex items-center gap-3'>
+					<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+						<TrendingDown className='h-6 w-6 text-primary' />
+					</div>
+					<h1 className='font-bold text-3xl'>Réassort</h1>
+				</div>
+				<p className='text-muted-foreground'>
+					Les produits sous leur stock minimum, ou dont le stock ne couvre pas
+					l’objectif au rythme des ventes récentes. Les quantités déjà en
+					commande chez le fournisseur sont déduites.
+				</p>
+			</div>
+
+			<div className='mb-6 flex gap-4'>
+				<div className='space-y-1'>
+					<Label htmlFor='repl-weeks'>Ventes des … dernières semaines</Label>
+					<Input
+						id='repl-weeks'
+						type='number'
+						min={1}
+						max={52}
+						className='w-32'
+						value={weeks}
+						onChange={(e) => setWeeks(Number(e.target.value) || 1)}
+					/>
+				</div>
+				<div className='space-y-1'>
+					<Label htmlFor='repl-cover'>Couverture visée (jours)</Label>
+					<Input
+						id='repl-cover'
+						type='number'
+						min={1}
+						max={365}
+						className='w-32'
+						value={coverDays}
+						onChange={(e) => setCoverDays(Number(e.target.value) || 1)}
+					/>
+				</div>
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Calcul en cours...
+				</div>
+			) : error ? (
+				<div className='py-12 text-center text-destructive'>
+					{pocketbaseErrorMessage(error)}
+				</div>
+			) : !report?.suppliers.length ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucun produit à réapprovisionner
+				</div>
+			) : (
+				<div className='space-y-6'>
+					{report.suppliers.map((group) => (
+						<Card key={group.supplier_id || 'none'}>
+							<CardHeader className='flex flex-row items-center justify-between space-y-0'>
+								<CardTitle className='text-lg'>
+									{group.supplier_name}
+									{group.supplier_code && (
+										<span className='ml-2 font-normal text-muted-foreground text-sm'>
+											{group.supplier_code}
+										</span>
+									)}
+								</CardTitle>
+								<div className='flex items-center gap-2'>
+									<span className='font-medium text-sm'>
+										{fmt(group.total_ht)} HT
+									</span>
+									<Button
+										variant='outline'
+										size='sm'
+										onClick={() => handleCsv(group)}
+									>
+										<Download className='mr-2 h-4 w-4' />
+										CSV
+									</Button>
+									{group.supplier_id && (
+										<Button
+											size='sm'
+											disabled={createOrder.isPending}
+											onClick={() => handleCreateOrder(group)}
+										>
+											<FileText className='mr-2 h-4 w-4' />
+											Créer la commande
+										</Button>
+									)}
+								</div>
+							</CardHeader>
+							<CardContent>
+								<Table>
+									<TableHeader>
+										<TableRow>
+											<TableHead>Produit</TableHead>
+											<TableHead className='text-right'>Stock</TableHead>
+											<TableHead className='text-right'>Minimum</TableHead>
+											<TableHead className='text-right'>Ventes / jour</TableHead>
+											<TableHead className='text-right'>Couverture</TableHead>
+											<TableHead className='text-right'>En commande</TableHead>
+											<TableHead className='text-right'>À commander</TableHead>
+										</TableRow>
+									</TableHeader>
+									<TableBody>
+										{group.lines.map((line) => (
+											<TableRow key={line.product_id}>
+												<TableCell>
+													<div className='font-medium'>{line.product_name}</div>
+													{line.sku && (
+														<div className='text-muted-foreground text-xs'>
+															{line.sku}
+														</div>
+													)}
+												</TableCell>
+												<TableCell className='text-right'>
+													{line.reason === 'min_stock' ? (
+														<Badge variant='destructive'>{line.stock}</Badge>
+													) : (
+														line.stock
+													)}
+												</TableCell>
+												<TableCell className='text-right'>
+													{line.min_stock || '—'}
+												</TableCell>
+												<TableCell className='text-right'>
+													{line.daily_sales.toLocaleString('fr-FR', {
+														maximumFractionDigits: 2,
+													})}
+												</TableCell>
+												<TableCell className='text-right'>
+													{line.days_of_cover === null
+														? '—'
+														: `${line.days_of_cover} j`}
+												</TableCell>
+												<TableCell className='text-right'>
+													{line.incoming || '—'}
+												</TableCell>
+												<TableCell className='text-right font-medium'>
+													{line.suggested_qty}
+												</TableCell>
+											</TableRow>
+										))}
+									</TableBody>
+								</Table>
+							</CardContent>
+						</Card>
+					))}
+				</div>
+			)}
+		</div>
+	)
+}
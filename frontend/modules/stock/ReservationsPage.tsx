@@ -0,0 +1,154 @@
+// frontend/modules/stock/ReservationsPage.tsx
+//
+// Ce qui est promis et ne se vend plus librement : les lignes des bons de
+// commande confirmés, et les paniers mis en attente en caisse. Une
+// réservation se libère seule à la livraison, à la facturation, à
+// l'annulation ou à son échéance ; un responsable peut aussi la libérer ici
+// quand le client ne viendra pas.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { Lock, Unlock } from 'lucide-react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	STOCK_RESERVATION_KIND_LABELS,
+	type StockReservation,
+	useReleaseReservation,
+	useStockReservations,
+} from '@/lib/queries/reservations'
+
+function formatDateTime(value?: string): string {
+	return value
+		? new Date(value).toLocaleString('fr-FR', {
+				dateStyle: 'short',
+				timeStyle: 'short',
+			})
+		: '—'
+}
+
+export function ReservationsPage() {
+	const { activeCompanyId } = useActiveCompany()
+	const { data, isLoading } = useStockReservations(
+		activeCompanyId ?? undefined,
+	)
+	const releaseReservation = useReleaseReservation()
+	const reservations = data?.reservations ?? []
+	const settings = data?.settings
+
+	const handleRelease = async (r: StockReservation) => {
+		if (
+			!confirm(
+				`Libérer ${r.quantity} × « ${r.product_name} » ? Ces unités redeviennent disponibles à la vente.`,
+			)
+		)
+			return
+		try {
+			await releaseReservation.mutateAsync(r.id)
+			toast.success('Réservation libérée')
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6'>
+				<div className='mb-2 flex items-center gap-3'>
+					<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+						<Lock className='h-6 w-6 text-primary' />
+					</div>
+					<h1 className='font-bold text-3xl'>Réservations</h1>
+				</div>
+				<p className='text-muted-foreground'>
+					Unités promises à un bon de commande confirmé ou à un panier en
+					attente. Disponible = stock − réservé ; le stock, lui, ne bouge pas.
+				</p>
+				{settings && (
+					<p className='mt-2 text-sm text-muted-foreground'>
+						En caisse :{' '}
+						{settings.policy === 'block'
+							? 'la vente est refusée'
+							: 'le vendeur est averti'}
+						{' · '}
+						{settings.order_days > 0
+							? `bons de commande réservés ${settings.order_days} jour(s)`
+							: "bons de commande réservés jusqu'à la livraison"}
+						{' · '}
+						{settings.cart_minutes > 0
+							? `paniers en attente réservés ${settings.cart_minutes} min`
+							: 'paniers en attente non réservés'}
+					</p>
+				)}
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Chargement...
+				</div>
+			) : reservations.length === 0 ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucune réservation en cours
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Produit</TableHead>
+								<TableHead className='text-right'>Quantité</TableHead>
+								<TableHead>Origine</TableHead>
+								<TableHead>Pour</TableHead>
+								<TableHead>Depuis</TableHead>
+								<TableHead>Échéance</TableHead>
+								<TableHead className='w-[60px]' />
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{reservations.map((r) => (
+								<TableRow key={r.id}>
+									<TableCell>{r.product_name}</TableCell>
+									<TableCell className='text-right'>{r.quantity}</TableCell>
+									<TableCell>
+										<Badge
+											variant={r.kind === 'order' ? 'default' : 'secondary'}
+										>
+											{STOCK_RESERVATION_KIND_LABELS[r.kind]}
+										</Badge>
+									</TableCell>
+									<TableCell>
+										{[r.order_number, r.label].filter(Boolean).join(' · ') ||
+											'—'}
+									</TableCell>
+									<TableCell>{formatDateTime(r.created)}</TableCell>
+									<TableCell>{formatDateTime(r.expires_at)}</TableCell>
+									<TableCell>
+										<Button
+											variant='ghost'
+											size='icon'
+											title='Libérer'
+											disabled={releaseReservation.isPending}
+											onClick={() => handleRelease(r)}
+										>
+											<Unlock className='h-4 w-4' />
+										</Button>
+									</TableCell>
+								</TableRow>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+		</div>
+	)
+}
@@ -0,0 +1,297 @@
+// frontend/modules/stock/RmaPage.tsx
+//
+// Les retours SAV : articles renvoyés au fournisseur sous garantie ou confiés
+// à un réparateur. Chaque étape suit l'article côté serveur — chez le
+// fournisseur, puis rendu au client ou remis en rayon — et laisse sa trace
+// dans l'historique du RMA ; seul un article sorti du stock passe par le bac
+// RMA. Un RMA ne se supprime pas : il s'annule
+// tant qu'il n'est pas expédié.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { FileText, Plus, Wrench } from 'lucide-react'
+import { Fragment, useMemo, useState } from 'react'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	RMA_ACTION_LABELS,
+	RMA_KIND_LABELS,
+	RMA_NEXT_ACTIONS,
+	RMA_ORIGIN_LABELS,
+	RMA_STATUS_LABELS,
+	type Rma,
+	type RmaAction,
+	type RmaStatus,
+	useRmas,
+} from '@/lib/queries/rma'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { RmaCreditNoteDialog } from './components/RmaCreditNoteDialog'
+import { RmaDialog } from './components/RmaDialog'
+import { RmaStepDialog } from './components/RmaStepDialog'
+
+const selectClassName =
+	'h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+// Encore chez nous ou chez le fournisseur : ce qu'il reste à suivre
+const OPEN_STATUSES: RmaStatus[] = ['opened', 'shipped', 'repaired', 'replaced']
+
+function formatDate(value?: string): string {
+	return value ? new Date(value).toLocaleDateString('fr-FR') : '—'
+}
+
+export function RmaPage() {
+	const pb = usePocketBase()
+	const { activeCompanyId } = useActiveCompany()
+	const { data: rmas, isLoading } = useRmas(activeCompanyId ?? undefined)
+
+	const [statusFilter, setStatusFilter] = useState<RmaStatus | 'pending' | ''>(
+		'pending',
+	)
+	const [createOpen, setCreateOpen] = useState(false)
+	const [expanded, setExpanded] = useState<string | null>(null)
+	const [stepping, setStepping] = useState<{
+		rma: Rma
+		action: RmaAction
+	} | null>(null)
+	const [crediting, setCrediting] = useState<Rma | null>(null)
+
+	const filtered = useMemo(
+		() =>
+			(rmas ?? []).filter((r) =>
+				statusFilter === 'pending'
+					? OPEN_STATUSES.includes(r.status)
+					: !statusFilter || r.status === statusFilter,
+			),
+		[rmas, statusFilter],
+	)
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6 flex items-start justify-between'>
+				<div>
+					<div className='mb-2 flex items-center gap-3'>
+						<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+							<Wrench className='h-6 w-6 text-primary' />
+						</div>
+						<h1 className='font-bold text-3xl'>Retours SAV</h1>
+					</div>
+					<p className='text-muted-foreground'>
+						Articles défectueux renvoyés au fournisseur ou en réparation. Sorti
+						du stock, l’article attend au bac RMA et compte dans le stock tant
+						qu’il est chez nous ; l’article d’un client n’y entre jamais.
+					</p>
+				</div>
+				<Button onClick={() => setCreateOpen(true)}>
+					<Plus className='mr-2 h-4 w-4' />
+					Nouveau retour
+				</Button>
+			</div>
+
+			<div className='mb-4'>
+				<select
+					aria-label='Statut'
+					className={selectClassName}
+					value={statusFilter}
+					onChange={(e) =>
+						setStatusFilter(e.target.value as RmaStatus | 'pending' | '')
+					}
+				>
+					<option value='pending'>En cours</option>
+					<option value=''>Tous</option>
+					{Object.entries(RMA_STATUS_LABELS).map(([value, label]) => (
+						<option key={value} value={value}>
+							{label}
+						</option>
+					))}
+				</select>
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Chargement...
+				</div>
+			) : filtered.length === 0 ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucun retour SAV
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Numéro</TableHead>
+								<TableHead>Ouvert le</TableHead>
+								<TableHead>Produit</TableHead>
+								<TableHead>Origine</TableHead>
+								<TableHead>Fournisseur</TableHead>
+								<TableHead>Statut</TableHead>
+								<TableHead className='text-right'>Étapes</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{filtered.map((rma) => (
+								<Fragment key={rma.id}>
+									<TableRow
+										className='cursor-pointer'
+										onClick={() =>
+											setExpanded((prev) => (prev === rma.id ? null : rma.id))
+										}
+									>
+										<TableCell className='font-medium'>
+											{rma.number}
+											<div className='text-muted-foreground text-xs'>
+												{RMA_KIND_LABELS[rma.kind]}
+											</div>
+										</TableCell>
+										<TableCell>{formatDate(rma.opened_at)}</TableCell>
+										<TableCell>
+											{rma.quantity} × {rma.product_name}
+											{rma.serial_number && (
+												<div className='text-muted-foreground text-xs'>
+													{rma.serial_number}
+												</div>
+											)}
+										</TableCell>
+										<TableCell>
+											{rma.origin === 'customer'
+												? (rma.expand?.customer?.name ??
+													RMA_ORIGIN_LABELS.customer)
+												: RMA_ORIGIN_LABELS.stock}
+										</TableCell>
+										<TableCell>{rma.expand?.supplier?.name ?? '—'}</TableCell>
+										<TableCell>
+											<Badge
+												variant={
+													OPEN_STATUSES.includes(rma.status)
+														? 'default'
+														: 'secondary'
+												}
+											>
+												{RMA_STATUS_LABELS[rma.status]}
+											</Badge>
+										</TableCell>
+										<TableCell
+											className='space-x-2 text-right'
+											onClick={(e) => e.stopPropagation()}
+										>
+											{RMA_NEXT_ACTIONS[rma.status].map((action) => (
+												<Button
+													key={action}
+													size='sm'
+													variant={action === 'cancel' ? 'ghost' : 'outline'}
+													onClick={() => setStepping({ rma, action })}
+												>
+													{RMA_ACTION_LABELS[action]}
+												</Button>
+											))}
+											{rma.status !== 'cancelled' && (
+												<Button
+													size='sm'
+													variant='ghost'
+													title='Avoir fournisseur'
+													onClick={() => setCrediting(rma)}
+												>
+													<FileText className='h-4 w-4' />
+												</Button>
+											)}
+										</TableCell>
+									</TableRow>
+									{expanded === rma.id && (
+										<TableRow>
+											<TableCell colSpan={7} className='bg-muted/40'>
+												<div className='grid grid-cols-2 gap-6 text-sm'>
+													<div className='space-y-1'>
+														{rma.fault && <p>Panne : {rma.fault}</p>}
+														{rma.invoice_number && (
+															<p>Facture d’origine : {rma.invoice_number}</p>
+														)}
+														{rma.supplier_reference && (
+															<p>Accord fournisseur : {rma.supplier_reference}</p>
+														)}
+														{rma.tracking_number && (
+															<p>Suivi : {rma.tracking_number}</p>
+														)}
+														{rma.replacement_serial && (
+															<p>Remplacé par : {rma.replacement_serial}</p>
+														)}
+														{rma.credit_note_number && (
+															<p>
+																Avoir {rma.credit_note_number}
+																{rma.credit_note_amount_ht
+																	? ` — ${rma.credit_note_amount_ht.toFixed(2)} € HT`
+																	: ''}
+																{rma.credit_note_date
+																	? ` du ${formatDate(rma.credit_note_date)}`
+																	: ''}
+																{rma.credit_note_file && (
+																	<>
+																		{' · '}
+																		<a
+																			className='underline'
+																			href={pb.files.getUrl(
+																				rma,
+																				rma.credit_note_file,
+																			)}
+																			target='_blank'
+																			rel='noreferrer'
+																		>
+																			pièce
+																		</a>
+																	</>
+																)}
+															</p>
+														)}
+														{rma.notes && (
+															<p className='text-muted-foreground'>{rma.notes}</p>
+														)}
+													</div>
+													<div className='space-y-1'>
+														{rma.history?.map((entry) => (
+															<div
+																key={`${entry.action}-${entry.at}`}
+																className='flex justify-between gap-4'
+															>
+																<span>
+																	{RMA_STATUS_LABELS[entry.status] ??
+																		entry.action}
+																	{entry.note ? ` — ${entry.note}` : ''}
+																</span>
+																<span className='text-muted-foreground'>
+																	{new Date(entry.at).toLocaleString('fr-FR')}
+																	{entry.operator ? ` · ${entry.operator}` : ''}
+																</span>
+															</div>
+														))}
+													</div>
+												</div>
+											</TableCell>
+										</TableRow>
+									)}
+								</Fragment>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+
+			<RmaDialog open={createOpen} onOpenChange={setCreateOpen} />
+			<RmaStepDialog
+				rma={stepping?.rma ?? null}
+				action={stepping?.action ?? null}
+				onOpenChange={(open) => !open && setStepping(null)}
+			/>
+			<RmaCreditNoteDialog
+				rma={crediting}
+				onOpenChange={(open) => !open && setCrediting(null)}
+			/>
+		</div>
+	)
+}
@@ -0,0 +1,363 @@
+// frontend/modules/stock/SerialNumbersPage.tsx
+//
+// Recherche d'une unité par son numéro de série : un client rapporte sa
+// guitare, on retrouve à qui et quand elle a été vendue, et si elle est encore
+// sous garantie. On y saisit aussi à la main les unités déjà en boutique avant
+// le suivi ; les autres naissent à la réception fournisseur.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { Hash, Plus, Search, Undo2 } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { useCatalogProductSearch } from '@/lib/queries/catalog-products'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	PRODUCT_SERIAL_STATUS_LABELS,
+	type SerialLookupResult,
+	useCreateProductSerial,
+	useReturnSerial,
+	useSerialLookup,
+} from '@/lib/queries/serials'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+function formatDate(value?: string): string {
+	return value ? new Date(value).toLocaleDateString('fr-FR') : '—'
+}
+
+export function SerialNumbersPage() {
+	const { activeCompanyId } = useActiveCompany()
+	const [term, setTerm] = useState('')
+	const { data, isFetching } = useSerialLookup(
+		term,
+		activeCompanyId ?? undefined,
+	)
+	const results = data?.results ?? []
+
+	const [createOpen, setCreateOpen] = useState(false)
+	const [returning, setReturning] = useState<SerialLookupResult | null>(null)
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6 flex items-start justify-between'>
+				<div>
+					<div className='mb-2 flex items-center gap-3'>
+						<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+							<Hash className='h-6 w-6 text-primary' />
+						</div>
+						<h1 className='font-bold text-3xl'>Numéros de série</h1>
+					</div>
+					<p className='text-muted-foreground'>
+						Retrouvez une unité vendue, son client et sa garantie. Au-delà de
+						deux caractères, la recherche porte sur une partie du numéro.
+					</p>
+				</div>
+				<Button onClick={() => setCreateOpen(true)}>
+					<Plus className='mr-2 h-4 w-4' />
+					Saisir une unité
+				</Button>
+			</div>
+
+			<div className='relative mb-6 max-w-md'>
+				<Search className='absolute top-2.5 left-3 h-4 w-4 text-muted-foreground' />
+				<Input
+					autoFocus
+					className='pl-9'
+					placeholder='Numéro de série'
+					value={term}
+					onChange={(e) => setTerm(e.target.value)}
+				/>
+			</div>
+
+			{!term.trim() ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Scannez ou saisissez un numéro de série
+				</div>
+			) : isFetching && !data ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Recherche...
+				</div>
+			) : results.length === 0 ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucune unité ne correspond
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Numéro</TableHead>
+								<TableHead>Produit</TableHead>
+								<TableHead>Lot</TableHead>
+								<TableHead>Statut</TableHead>
+								<TableHead>Client</TableHead>
+								<TableHead>Ticket</TableHead>
+								<TableHead>Vendu le</TableHead>
+								<TableHead>Garantie</TableHead>
+								<TableHead className='w-[60px]' />
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{results.map((result) => (
+								<TableRow key={result.unit.id}>
+									<TableCell className='font-mono'>
+										{result.unit.serial_number}
+									</TableCell>
+									<TableCell>{result.product_name}</TableCell>
+									<TableCell>{result.unit.lot_number || '—'}</TableCell>
+									<TableCell>
+										<Badge
+											variant={
+												result.status === 'sold' ? 'secondary' : 'default'
+											}
+										>
+											{PRODUCT_SERIAL_STATUS_LABELS[result.status]}
+										</Badge>
+									</TableCell>
+									<TableCell>{result.customer_name || '—'}</TableCell>
+									<TableCell>{result.invoice_number || '—'}</TableCell>
+									<TableCell>{formatDate(result.sold_at)}</TableCell>
+									<TableCell>
+										{result.warranty_until ? (
+											<div className='flex items-center gap-2'>
+												{formatDate(result.warranty_until)}
+												{result.under_warranty ? (
+													<Badge>Sous garantie</Badge>
+												) : (
+													<Badge variant='outline'>Expirée</Badge>
+												)}
+											</div>
+										) : (
+											'—'
+										)}
+									</TableCell>
+									<TableCell>
+										{result.status === 'sold' && (
+											<Button
+												variant='ghost'
+												size='icon'
+												title='Remettre en stock'
+												onClick={() => setReturning(result)}
+											>
+												<Undo2 className='h-4 w-4' />
+											</Button>
+										)}
+									</TableCell>
+								</TableRow>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+
+			<CreateSerialDialog open={createOpen} onOpenChange={setCreateOpen} />
+			<ReturnSerialDialog
+				result={returning}
+				onOpenChange={(open) => !open && setReturning(null)}
+			/>
+		</div>
+	)
+}
+
+function CreateSerialDialog({
+	open,
+	onOpenChange,
+}: {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+}) {
+	const { activeCompanyId } = useActiveCompany()
+	const createSerial = useCreateProductSerial()
+
+	const [search, setSearch] = useState('')
+	const [productId, setProductId] = useState('')
+	const [serial, setSerial] = useState('')
+	const [lot, setLot] = useState('')
+
+	const { items } = useCatalogProductSearch({
+		companyId: activeCompanyId ?? undefined,
+		term: search,
+		enabled: open,
+	})
+	const products = items.filter((p) => p.serialized)
+
+	useEffect(() => {
+		if (!open) return
+		setSearch('')
+		setProductId('')
+		setSerial('')
+		setLot('')
+	}, [open])
+
+	const handleSubmit = async () => {
+		if (!activeCompanyId || !productId || !serial.trim()) {
+			toast.error('Choisissez un produit et saisissez le numéro')
+			return
+		}
+		try {
+			const unit = await createSerial.mutateAsync({
+				owner_company: activeCompanyId,
+				product: productId,
+				serial_number: serial,
+				lot_number: lot.trim() || undefined,
+			})
+			toast.success(`Unité ${unit.serial_number} enregistrée`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-md'>
+				<DialogHeader>
+					<DialogTitle>Saisir une unité</DialogTitle>
+					<DialogDescription>
+						Pour une unité déjà en boutique : celles reçues d’un fournisseur
+						sont enregistrées à la réception.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='space-y-2'>
+						<Label htmlFor='serial-product-search'>Produit</Label>
+						<Input
+							id='serial-product-search'
+							placeholder='Rechercher un produit'
+							value={search}
+							onChange={(e) => setSearch(e.target.value)}
+						/>
+						<select
+							className={selectClassName}
+							aria-label='Produit'
+							value={productId}
+							onChange={(e) => setProductId(e.target.value)}
+						>
+							<option value=''>
+								{products.length
+									? 'Choisir un produit'
+									: 'Aucun produit suivi par numéro de série'}
+							</option>
+							{products.map((p) => (
+								<option key={p.id} value={p.id}>
+									{p.name}
+								</option>
+							))}
+						</select>
+					</div>
+					<div className='space-y-2'>
+						<Label htmlFor='serial-number'>Numéro de série</Label>
+						<Input
+							id='serial-number'
+							value={serial}
+							onChange={(e) => setSerial(e.target.value)}
+						/>
+					</div>
+					<div className='space-y-2'>
+						<Label htmlFor='serial-lot'>Lot</Label>
+						<Input
+							id='serial-lot'
+							value={lot}
+							onChange={(e) => setLot(e.target.value)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={createSerial.isPending}>
+						Enregistrer
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
+
+function ReturnSerialDialog({
+	result,
+	onOpenChange,
+}: {
+	result: SerialLookupResult | null
+	onOpenChange: (open: boolean) => void
+}) {
+	const returnSerial = useReturnSerial()
+	const [reason, setReason] = useState('')
+
+	useEffect(() => {
+		setReason('')
+	}, [result])
+
+	const handleConfirm = async () => {
+		if (!result) return
+		try {
+			await returnSerial.mutateAsync({
+				id: result.unit.id,
+				reason: reason.trim() || undefined,
+			})
+			toast.success(`${result.unit.serial_number} est de nouveau en stock`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={!!result} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-md'>
+				<DialogHeader>
+					<DialogTitle>
+						Remettre en stock — {result?.unit.serial_number}
+					</DialogTitle>
+					<DialogDescription>
+						La vente à {result?.customer_name || 'ce client'} reste dans
+						l’historique de l’unité. Le stock et l’avoir se traitent à part.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-2'>
+					<Label htmlFor='serial-return-reason'>Motif</Label>
+					<Input
+						id='serial-return-reason'
+						value={reason}
+						onChange={(e) => setReason(e.target.value)}
+					/>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleConfirm} disabled={returnSerial.isPending}>
+						Remettre en stock
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
@@ -0,0 +1,341 @@
+// frontend/modules/stock/StockLocationsPage.tsx
+//
+// Les emplacements de stock d'une société : boutique, réserve, atelier. On y
+// choisit l'emplacement par défaut (celui des produits jamais déplacés et des
+// ventes sans caisse attitrée) et l'emplacement que débite chaque caisse.
+// Les quantités elles-mêmes ne s'écrivent pas ici : elles bougent par les
+// ventes, les réceptions, les inventaires et les transferts.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import {
+	Archive,
+	ArchiveRestore,
+	Eye,
+	MapPin,
+	Pencil,
+	Plus,
+	Star,
+	Trash2,
+} from 'lucide-react'
+import { useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { useCashRegisters } from '@/lib/queries/cash'
+import {
+	STOCK_LOCATION_KIND_LABELS,
+	type StockLocation,
+	useDeleteStockLocation,
+	useLocationLevels,
+	useSetCashRegisterLocation,
+	useSetDefaultLocation,
+	useStockLocations,
+	useUpdateStockLocation,
+} from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import { StockLocationDialog } from './components/StockLocationDialog'
+
+const selectClassName =
+	'h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+export function StockLocationsPage() {
+	const { activeCompanyId } = useActiveCompany()
+	const { data: locations, isLoading } = useStockLocations(
+		activeCompanyId ?? undefined,
+	)
+	const { data: registers } = useCashRegisters(activeCompanyId ?? undefined)
+	const setDefault = useSetDefaultLocation()
+	const updateLocation = useUpdateStockLocation()
+	const deleteLocation = useDeleteStockLocation()
+	const setRegisterLocation = useSetCashRegisterLocation()
+
+	const [dialogOpen, setDialogOpen] = useState(false)
+	const [editing, setEditing] = useState<StockLocation | null>(null)
+	const [viewing, setViewing] = useState<StockLocation | null>(null)
+
+	const active = locations?.filter((l) => !l.archived) ?? []
+
+	const run = async (
+		action: () => Promise<unknown>,
+		success: string,
+	): Promise<void> => {
+		try {
+			await action()
+			toast.success(success)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	const openDialog = (location: StockLocation | null) => {
+		setEditing(location)
+		setDialogOpen(true)
+	}
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6 flex items-start justify-between'>
+				<div>
+					<div className='mb-2 flex items-center gap-3'>
+						<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+							<MapPin className='h-6 w-6 text-primary' />
+						</div>
+						<h1 className='font-bold text-3xl'>Emplacements</h1>
+					</div>
+					<p className='text-muted-foreground'>
+						Le stock d’un produit est la somme de ses emplacements. Un produit
+						jamais déplacé est entièrement à l’emplacement par défaut.
+					</p>
+				</div>
+				<Button onClick={() => openDialog(null)}>
+					<Plus className='mr-2 h-4 w-4' />
+					Nouvel emplacement
+				</Button>
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Chargement...
+				</div>
+			) : !locations?.length ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucun emplacement : le stock est suivi sur le seul total des produits
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Nom</TableHead>
+								<TableHead>Code</TableHead>
+								<TableHead>Type</TableHead>
+								<TableHead>Statut</TableHead>
+								<TableHead className='w-[200px]'>Actions</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{locations.map((location) => (
+								<TableRow key={location.id}>
+									<TableCell className='font-medium'>{location.name}</TableCell>
+									<TableCell>{location.code || '—'}</TableCell>
+									<TableCell>
+										{location.kind
+											? STOCK_LOCATION_KIND_LABELS[location.kind]
+											: '—'}
+									</TableCell>
+									<TableCell>
+										{location.is_default ? (
+											<Badge>Par défaut</Badge>
+										) : location.archived ? (
+											<Badge variant='outline'>Archivé</Badge>
+										) : (
+											<Badge variant='secondary'>Actif</Badge>
+										)}
+									</TableCell>
+									<TableCell>
+										<div className='flex gap-1'>
+											<Button
+												variant='ghost'
+												size='icon'
+												title='Voir le stock'
+												onClick={() => setViewing(location)}
+											>
+												<Eye className='h-4 w-4' />
+											</Button>
+											<Button
+												variant='ghost'
+												size='icon'
+												title='Modifier'
+												onClick={() => openDialog(location)}
+											>
+												<Pencil className='h-4 w-4' />
+											</Button>
+											{!location.is_default && !location.archived && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Emplacement par défaut'
+													onClick={() =>
+														run(
+															() => setDefault.mutateAsync(location.id),
+															`${location.name} est l’emplacement par défaut`,
+														)
+													}
+												>
+													<Star className='h-4 w-4' />
+												</Button>
+											)}
+											{!location.is_default && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title={location.archived ? 'Réactiver' : 'Archiver'}
+													onClick={() =>
+														run(
+															() =>
+																updateLocation.mutateAsync({
+																	id: location.id,
+																	data: { archived: !location.archived },
+																}),
+															location.archived
+																? `${location.name} réactivé`
+																: `${location.name} archivé`,
+														)
+													}
+												>
+													{location.archived ? (
+														<ArchiveRestore className='h-4 w-4' />
+													) : (
+														<Archive className='h-4 w-4' />
+													)}
+												</Button>
+											)}
+											{!location.is_default && (
+												<Button
+													variant='ghost'
+													size='icon'
+													title='Supprimer'
+													onClick={() =>
+														run(
+															() => deleteLocation.mutateAsync(location.id),
+															`${location.name} supprimé`,
+														)
+													}
+												>
+													<Trash2 className='h-4 w-4 text-destructive' />
+												</Button>
+											)}
+										</div>
+									</TableCell>
+								</TableRow>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+
+			{!!registers?.length && active.length > 0 && (
+				<div className='mt-8'>
+					<h2 className='mb-2 font-semibold text-xl'>Caisses</h2>
+					<p className='mb-4 text-muted-foreground text-sm'>
+						Les ventes d’une caisse débitent son emplacement ; sans choix, elles
+						débitent l’emplacement par défaut.
+					</p>
+					<div className='space-y-2'>
+						{registers.map((register) => (
+							<div key={register.id} className='flex items-center gap-4'>
+								<span className='w-48 truncate text-sm'>{register.name}</span>
+								<select
+									className={selectClassName}
+									aria-label={`Emplacement de ${register.name}`}
+									value={register.default_location ?? ''}
+									onChange={(e) =>
+										run(
+											() =>
+												setRegisterLocation.mutateAsync({
+													registerId: register.id,
+													locationId: e.target.value,
+												}),
+											`Emplacement de ${register.name} enregistré`,
+										)
+									}
+								>
+									<option value=''>Emplacement par défaut</option>
+									{active.map((l) => (
+										<option key={l.id} value={l.id}>
+											{l.name}
+										</option>
+									))}
+								</select>
+							</div>
+						))}
+					</div>
+				</div>
+			)}
+
+			<StockLocationDialog
+				open={dialogOpen}
+				onOpenChange={setDialogOpen}
+				location={editing}
+			/>
+			<LocationLevelsDialog
+				location={viewing}
+				onOpenChange={(open) => !open && setViewing(null)}
+			/>
+		</div>
+	)
+}
+
+function LocationLevelsDialog({
+	location,
+	onOpenChange,
+}: {
+	location: StockLocation | null
+	onOpenChange: (open: boolean) => void
+}) {
+	const { data, isLoading } = useLocationLevels(location?.id)
+	const levels = data?.levels.filter((l) => l.quantity !== 0) ?? []
+
+	return (
+		<Dialog open={!!location} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-2xl'>
+				<DialogHeader>
+					<DialogTitle>Stock — {location?.name}</DialogTitle>
+					<DialogDescription>
+						{location?.is_default
+							? 'Les produits jamais déplacés sont comptés ici.'
+							: 'Les quantités posées ici par les mouvements de stock.'}
+					</DialogDescription>
+				</DialogHeader>
+				{isLoading ? (
+					<div className='py-8 text-center text-muted-foreground'>
+						Chargement...
+					</div>
+				) : levels.length === 0 ? (
+					<div className='py-8 text-center text-muted-foreground'>
+						Aucun produit à cet emplacement
+					</div>
+				) : (
+					<div className='max-h-[60vh] overflow-y-auto rounded-md border'>
+						<Table>
+							<TableHeader>
+								<TableRow>
+									<TableHead>Produit</TableHead>
+									<TableHead>Référence</TableHead>
+									<TableHead className='text-right'>Quantité</TableHead>
+								</TableRow>
+							</TableHeader>
+							<TableBody>
+								{levels.map((level) => (
+									<TableRow key={level.product_id}>
+										<TableCell>{level.product_name}</TableCell>
+										<TableCell>{level.sku || '—'}</TableCell>
+										<TableCell className='text-right'>
+											{level.quantity}
+										</TableCell>
+									</TableRow>
+								))}
+							</TableBody>
+						</Table>
+					</div>
+				)}
+			</DialogContent>
+		</Dialog>
+	)
+}
@@ -0,0 +1,153 @@
+// frontend/modules/stock/StockTransfersPage.tsx
+//
+// Les transferts entre emplacements : saisie et historique. Un transfert ne
+// se modifie ni ne s'annule ; on le corrige par un transfert inverse, qui
+// laisse la trace des deux mouvements.
+
+import { Button } from '@/components/ui/button'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { ArrowLeftRight, Plus } from 'lucide-react'
+import { Fragment, useState } from 'react'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { useStockLocations, useStockTransfers } from '@/lib/queries/locations'
+import { StockTransferDialog } from './components/StockTransferDialog'
+
+export function StockTransfersPage() {
+	const { activeCompanyId } = useActiveCompany()
+	const { data: transfers, isLoading } = useStockTransfers(
+		activeCompanyId ?? undefined,
+	)
+	const { data: locations } = useStockLocations(activeCompanyId ?? undefined)
+
+	const [createOpen, setCreateOpen] = useState(false)
+	const [expanded, setExpanded] = useState<string | null>(null)
+
+	const canTransfer = (locations?.filter((l) => !l.archived).length ?? 0) >= 2
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6 flex items-start justify-between'>
+				<div>
+					<div className='mb-2 flex items-center gap-3'>
+						<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+							<ArrowLeftRight className='h-6 w-6 text-primary' />
+						</div>
+						<h1 className='font-bold text-3xl'>Transferts de stock</h1>
+					</div>
+					<p className='text-muted-foreground'>
+						Déplacer des produits entre la boutique, la réserve ou l’atelier. Le
+						stock total ne change pas ; chaque emplacement garde sa quantité.
+					</p>
+				</div>
+				<Button onClick={() => setCreateOpen(true)} disabled={!canTransfer}>
+					<Plus className='mr-2 h-4 w-4' />
+					Nouveau transfert
+				</Button>
+			</div>
+
+			{!canTransfer && !isLoading && (
+				<p className='mb-4 text-muted-foreground text-sm'>
+					Il faut au moins deux emplacements actifs pour transférer du stock.
+				</p>
+			)}
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Chargement...
+				</div>
+			) : !transfers?.length ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucun transfert
+				</div>
+			) : (
+				<div className='rounded-md border'>
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Numéro</TableHead>
+								<TableHead>Date</TableHead>
+								<TableHead>Depuis</TableHead>
+								<TableHead>Vers</TableHead>
+								<TableHead className='text-right'>Lignes</TableHead>
+								<TableHead className='text-right'>Quantité</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{transfers.map((transfer) => (
+								<Fragment key={transfer.id}>
+									<TableRow
+										className='cursor-pointer'
+										onClick={() =>
+											setExpanded((prev) =>
+												prev === transfer.id ? null : transfer.id,
+											)
+										}
+									>
+										<TableCell className='font-medium'>
+											{transfer.number}
+										</TableCell>
+										<TableCell>
+											{new Date(transfer.transferred_at).toLocaleString(
+												'fr-FR',
+											)}
+										</TableCell>
+										<TableCell>
+											{transfer.expand?.from_location?.name ?? '—'}
+										</TableCell>
+										<TableCell>
+											{transfer.expand?.to_location?.name ?? '—'}
+										</TableCell>
+										<TableCell className='text-right'>
+											{transfer.lines?.length ?? 0}
+										</TableCell>
+										<TableCell className='text-right'>
+											{transfer.total_quantity}
+										</TableCell>
+									</TableRow>
+									{expanded === transfer.id && (
+										<TableRow>
+											<TableCell colSpan={6} className='bg-muted/40'>
+												<div className='space-y-1 text-sm'>
+													{transfer.lines?.map((line) => (
+														<div
+															key={line.product_id}
+															className='flex justify-between gap-4'
+														>
+															<span className='truncate'>
+																{line.product_name}
+															</span>
+															<span className='text-muted-foreground'>
+																{line.quantity} — source {line.from_before} →{' '}
+																{line.from_after}, destination {line.to_before}{' '}
+																→ {line.to_after}
+															</span>
+														</div>
+													))}
+													{transfer.notes && (
+														<p className='pt-2 text-muted-foreground'>
+															{transfer.notes}
+														</p>
+													)}
+												</div>
+											</TableCell>
+										</TableRow>
+									)}
+								</Fragment>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+
+			<StockTransferDialog open={createOpen} onOpenChange={setCreateOpen} />
+		</div>
+	)
+}
@@ -0,0 +1,309 @@
+// frontend/modules/stock/StockValuationPage.tsx
+//
+// Valorisation du stock à une date (par défaut le dernier 31 décembre) :
+// quantités rejouées depuis le journal, coût moyen pondéré ou FIFO sur les
+// réceptions fournisseur. Export CSV par le serveur, PDF mis en page ici.
+
+import { Badge } from '@/components/ui/badge'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import {
+	Select,
+	SelectContent,
+	SelectItem,
+	SelectTrigger,
+	SelectValue,
+} from '@/components/ui/select'
+import {
+	Table,
+	TableBody,
+	TableCell,
+	TableHead,
+	TableHeader,
+	TableRow,
+} from '@/components/ui/table'
+import { Tabs, TabsContent, TabsList, TabsTrigger } from '@/components/ui/tabs'
+import { pdf } from '@react-pdf/renderer'
+import { Download, FileText, Landmark } from 'lucide-react'
+import { useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	VALUATION_METHOD_LABELS,
+	type ValuationBucket,
+	type ValuationMethod,
+	downloadValuationCsv,
+	useStockValuation,
+} from '@/lib/queries/valuation'
+import { usePocketBase } from '@/lib/use-pocketbase'
+import { StockValuationPDF } from './components/StockValuationPDF'
+
+const fmt = (amount: number) =>
+	new Intl.NumberFormat('fr-FR', { style: 'currency', currency: 'EUR' }).format(
+		amount,
+	)
+
+// Le dernier 31 décembre écoulé : la date d'un bilan civil
+function lastYearEnd(): string {
+	return `${new Date().getFullYear() - 1}-12-31`
+}
+
+function saveBlob(blob: Blob, fileName: string) {
+	const url = URL.createObjectURL(blob)
+	const link = document.createElement('a')
+	link.href = url
+	link.download = fileName
+	link.click()
+	URL.revokeObjectURL(url)
+}
+
+function BucketTable({ buckets }: { buckets: ValuationBucket[] }) {
+	return (
+		<Table>
+			<TableHeader>
+				<TableRow>
+					<TableHead>Libellé</TableHead>
+					<TableHead className='text-right'>Produits</TableHead>
+					<TableHead className='text-right'>Quantité</TableHead>
+					<TableHead className='text-right'>Valeur HT</TableHead>
+				</TableRow>
+			</TableHeader>
+			<TableBody>
+				{buckets.map((b) => (
+					<TableRow key={b.name}>
+						<TableCell className='font-medium'>{b.name}</TableCell>
+						<TableCell className='text-right'>{b.products}</TableCell>
+						<TableCell className='text-right'>
+							{b.quantity.toLocaleString('fr-FR')}
+						</TableCell>
+						<TableCell className='text-right'>{fmt(b.value)}</TableCell>
+					</TableRow>
+				))}
+			</TableBody>
+		</Table>
+	)
+}
+
+export function StockValuationPage() {
+	const pb = usePocketBase()
+	const { activeCompanyId, companies } = useActiveCompany()
+
+	const [at, setAt] = useState(lastYearEnd)
+	const [method, setMethod] = useState<ValuationMethod>('wac')
+
+	const request =
+		activeCompanyId && at ? { company_id: activeCompanyId, at, method } : null
+	const { data: report, isLoading, error } = useStockValuation(request)
+
+	const handleCsv = async () => {
+		if (!request) return
+		try {
+			saveBlob(
+				await downloadValuationCsv(pb, request),
+				`valorisation-stock-${at}.csv`,
+			)
+		} catch (err) {
+			toast.error(pocketbaseErrorMessage(err))
+		}
+	}
+
+	const handlePdf = async () => {
+		if (!report) return
+		try {
+			const companyName = companies.find((c) => c.id === activeCompanyId)?.name
+			const blob = await pdf(
+				<StockValuationPDF report={report} companyName={companyName} />,
+			).toBlob()
+			saveBlob(blob, `valorisation-stock-${report.at}.pdf`)
+		} catch (err) {
+			console.error('Erreur export PDF:', err)
+			toast.error("Erreur lors de l'export PDF")
+		}
+	}
+
+	return (
+		<div className='container mx-auto px-6 py-8'>
+			<div className='mb-6'>
+				<div className='mb-2 flex items-center gap-3'>
+					<div className='flex h-12 w-12 items-center justify-center rounded-lg bg-primary/10'>
+						<Landmark className='h-6 w-6 text-primary' />
+					</div>
+					<h1 className='font-bold text-3xl'>Valorisation du stock</h1>
+				</div>
+				<p className='text-muted-foreground'>
+					Le stock tel qu’il était à la fin de la journée choisie, valorisé au
+					prix des réceptions fournisseur. Les ventilations suivent les
+					rattachements actuels des produits.
+				</p>
+			</div>
+
+			<div className='mb-6 flex items-end gap-4'>
+				<div className='space-y-1'>
+					<Label htmlFor='valuation-at'>Stock au</Label>
+					<Input
+						id='valuation-at'
+						type='date'
+						className='w-44'
+						value={at}
+						onChange={(e) => setAt(e.target.value)}
+					/>
+				</div>
+				<div className='space-y-1'>
+					<Label htmlFor='valuation-method'>Méthode</Label>
+					<Select
+						value={method}
+						onValueChange={(v) => setMethod(v as ValuationMethod)}
+					>
+						<SelectTrigger id='valuation-method' className='w-72'>
+							<SelectValue />
+						</SelectTrigger>
+						<SelectContent>
+							{(Object.keys(VALUATION_METHOD_LABELS) as ValuationMethod[]).map(
+								(m) => (
+									<SelectItem key={m} value={m}>
+										{VALUATION_METHOD_LABELS[m]}
+									</SelectItem>
+								),
+							)}
+						</SelectContent>
+					</Select>
+				</div>
+				<div className='ml-auto flex gap-2'>
+					<Button variant='outline' disabled={!report} onClick={handleCsv}>
+						<Download className='mr-2 h-4 w-4' />
+						CSV
+					</Button>
+					<Button variant='outline' disabled={!report} onClick={handlePdf}>
+						<FileText className='mr-2 h-4 w-4' />
+						PDF
+					</Button>
+				</div>
+			</div>
+
+			{isLoading ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Calcul en cours...
+				</div>
+			) : error ? (
+				<div className='py-12 text-center text-destructive'>
+					{pocketbaseErrorMessage(error)}
+				</div>
+			) : !report?.lines.length ? (
+				<div className='py-12 text-center text-muted-foreground'>
+					Aucun stock à cette date
+				</div>
+			) : (
+				<div className='space-y-6'>
+					<div className='grid grid-cols-3 gap-4'>
+						<Card>
+							<CardHeader className='pb-2'>
+								<CardTitle className='font-normal text-muted-foreground text-sm'>
+									Valeur du stock HT
+								</CardTitle>
+							</CardHeader>
+							<CardContent className='font-bold text-2xl'>
+								{fmt(report.total_value)}
+							</CardContent>
+						</Card>
+						<Card>
+							<CardHeader className='pb-2'>
+								<CardTitle className='font-normal text-muted-foreground text-sm'>
+									Quantité
+								</CardTitle>
+							</CardHeader>
+							<CardContent className='font-bold text-2xl'>
+								{report.total_quantity.toLocaleString('fr-FR')}
+							</CardContent>
+						</Card>
+						<Card>
+							<CardHeader className='pb-2'>
+								<CardTitle className='font-normal text-muted-foreground text-sm'>
+									Produits
+								</CardTitle>
+							</CardHeader>
+							<CardContent className='font-bold text-2xl'>
+								{report.lines.length}
+								{report.negative_products > 0 && (
+									<Badge variant='destructive' className='ml-2 align-middle'>
+										{report.negative_products} en négatif
+									</Badge>
+								)}
+							</CardContent>
+						</Card>
+					</div>
+
+					<Tabs defaultValue='category'>
+						<TabsList>
+							<TabsTrigger value='category'>Par catégorie</TabsTrigger>
+							<TabsTrigger value='brand'>Par marque</TabsTrigger>
+							<TabsTrigger value='supplier'>Par fournisseur</TabsTrigger>
+							<TabsTrigger value='products'>Détail</TabsTrigger>
+						</TabsList>
+						<TabsContent value='category'>
+							<BucketTable buckets={report.by_category} />
+						</TabsContent>
+						<TabsContent value='brand'>
+							<BucketTable buckets={report.by_brand} />
+						</TabsContent>
+						<TabsContent value='supplier'>
+							<BucketTable buckets={report.by_supplier} />
+						</TabsContent>
+						<TabsContent value='products'>
+							<Table>
+								<TableHeader>
+									<TableRow>
+										<TableHead>Produit</TableHead>
+										<TableHead>Catégorie</TableHead>
+										<TableHead className='text-right'>Quantité</TableHead>
+										<TableHead className='text-right'>Coût unitaire</TableHead>
+										<TableHead className='text-right'>Valeur HT</TableHead>
+									</TableRow>
+								</TableHeader>
+								<TableBody>
+									{report.lines.map((line) => (
+										<TableRow key={line.product_id}>
+											<TableCell>
+												<div className='font-medium'>{line.product_name}</div>
+												{line.sku && (
+													<div className='text-muted-foreground text-xs'>
+														{line.sku}
+													</div>
+												)}
+											</TableCell>
+											<TableCell>{line.category}</TableCell>
+											<TableCell className='text-right'>
+												{line.quantity < 0 ? (
+													<Badge variant='destructive'>{line.quantity}</Badge>
+												) : (
+													line.quantity
+												)}
+											</TableCell>
+											<TableCell
+												className='text-right'
+												title={
+													line.cost_source === 'catalog'
+														? 'Sans réception : prix d’achat de la fiche'
+														: undefined
+												}
+											>
+												{fmt(line.unit_cost)}
+												{line.cost_source === 'catalog' && ' *'}
+											</TableCell>
+											<TableCell className='text-right font-medium'>
+												{fmt(line.value)}
+											</TableCell>
+										</TableRow>
+									))}
+								</TableBody>
+							</Table>
+						</TabsContent>
+					</Tabs>
+				</div>
+			)}
+		</div>
+	)
+}
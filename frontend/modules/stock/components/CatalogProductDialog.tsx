@@ -91,6 +91,8 @@ const productSchema = z.object({
 	stock: z.coerce.number().int('Le stock est un entier'),
 	min_stock: z.coerce.number().int().min(0),
 	manage_stock: z.boolean(),
+	serialized: z.boolean(),
+	warranty_months: z.coerce.number().int().min(0),
 	brand: z.string().optional(),
 	supplier: z.string().optional(),
 	categories: z.array(z.string()),
@@ -112,6 +114,8 @@ const EMPTY: ProductFormValues = {
 	stock: 0,
 	min_stock: 0,
 	manage_stock: true,
+	serialized: false,
+	warranty_months: 0,
 	brand: '',
 	supplier: '',
 	categories: [],
@@ -207,6 +211,8 @@ export function CatalogProductDialog({ open, onOpenChange, product }: Props) {
 						stock: product.stock ?? 0,
 						min_stock: product.min_stock ?? 0,
 						manage_stock: product.manage_stock ?? true,
+						serialized: product.serialized ?? false,
+						warranty_months: product.warranty_months ?? 0,
 						brand: product.brand ?? '',
 						supplier: product.supplier ?? '',
 						categories: product.categories ?? [],
@@ -252,6 +258,8 @@ export function CatalogProductDialog({ open, onOpenChange, product }: Props) {
 			stock: data.stock,
 			min_stock: data.min_stock,
 			manage_stock: data.manage_stock,
+			serialized: data.serialized,
+			warranty_months: data.warranty_months,
 			brand: data.brand ?? '',
 			supplier: data.supplier ?? '',
 			categories: data.categories,
@@ -690,6 +698,47 @@ export function CatalogProductDialog({ open, onOpenChange, product }: Props) {
 							/>
 						</div>
 
+						<div className='grid grid-cols-2 gap-4'>
+							<FormField
+								control={form.control}
+								name='serialized'
+								render={({ field }) => (
+									<FormItem className='flex items-center justify-between rounded-lg border p-3'>
+										<div>
+											<FormLabel>Numéro de série</FormLabel>
+											<p className='text-muted-foreground text-xs'>
+												Un numéro par unité, saisi à la réception et choisi en
+												caisse.
+											</p>
+										</div>
+										<FormControl>
+											<Switch
+												checked={field.value}
+												onCheckedChange={field.onChange}
+											/>
+										</FormControl>
+									</FormItem>
+								)}
+							/>
+							<FormField
+								control={form.control}
+								name='warranty_months'
+								render={({ field }) => (
+									<FormItem>
+										<FormLabel>Garantie (mois)</FormLabel>
+										<FormControl>
+											<Input type='number' step='1' min='0' {...field} />
+										</FormControl>
+										<p className='text-muted-foreground text-xs'>
+											0 : la durée de la société, sinon la garantie légale (24
+											mois).
+										</p>
+										<FormMessage />
+									</FormItem>
+								)}
+							/>
+						</div>
+
 						{!isEdit && (
 							<p className='rounded-md border border-amber-500/40 bg-amber-500/5 px-3 py-2 text-xs'>
 								Un produit créé ici n’a pas d’identifiant NeDB (
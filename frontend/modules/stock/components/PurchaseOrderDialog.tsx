@@ -0,0 +1,273 @@
+// frontend/modules/stock/components/PurchaseOrderDialog.tsx
+//
+// Saisie d'une commande fournisseur, en brouillon. Le prix proposé pour une
+// ligne est le prix d'achat actuel du produit ; le numéro CF-YYYY-XXXX est
+// posé par le serveur à la création.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
+import { Loader2, Plus, Trash2 } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	type CatalogProductShape,
+	useCatalogProductSearch,
+} from '@/lib/queries/catalog-products'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	type PurchaseOrderLineInput,
+	useCreatePurchaseOrder,
+} from '@/lib/queries/purchasing'
+import { useSuppliers } from '@/lib/queries/suppliers'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+const fmt = (amount: number) =>
+	new Intl.NumberFormat('fr-FR', { style: 'currency', currency: 'EUR' }).format(
+		amount,
+	)
+
+interface PurchaseOrderDialogProps {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+}
+
+export function PurchaseOrderDialog({
+	open,
+	onOpenChange,
+}: PurchaseOrderDialogProps) {
+	const { activeCompanyId } = useActiveCompany()
+	const { data: suppliers } = useSuppliers({
+		companyId: activeCompanyId ?? undefined,
+	})
+	const createOrder = useCreatePurchaseOrder()
+
+	const [supplierId, setSupplierId] = useState('')
+	const [expectedAt, setExpectedAt] = useState('')
+	const [notes, setNotes] = useState('')
+	const [lines, setLines] = useState<PurchaseOrderLineInput[]>([])
+	const [search, setSearch] = useState('')
+
+	const { items: products } = useCatalogProductSearch({
+		companyId: activeCompanyId ?? undefined,
+		term: search,
+		enabled: open,
+	})
+
+	useEffect(() => {
+		if (open) {
+			setSupplierId('')
+			setExpectedAt('')
+			setNotes('')
+			setLines([])
+			setSearch('')
+		}
+	}, [open])
+
+	const addProduct = (product: CatalogProductShape) => {
+		if (lines.some((l) => l.product === product.id)) {
+			toast.info(`« ${product.name} » est déjà dans la commande`)
+			return
+		}
+		setLines((prev) => [
+			...prev,
+			{
+				product: product.id,
+				product_name: product.name,
+				quantity_ordered: 1,
+				unit_price_ht: product.purchase_price_ht ?? 0,
+			},
+		])
+		setSearch('')
+	}
+
+	const updateLine = (index: number, patch: Partial<PurchaseOrderLineInput>) =>
+		setLines((prev) =>
+			prev.map((l, i) => (i === index ? { ...l, ...patch } : l)),
+		)
+
+	const total = lines.reduce(
+		(sum, l) => sum + l.quantity_ordered * l.unit_price_ht,
+		0,
+	)
+
+	const handleSubmit = async () => {
+		if (!activeCompanyId || !supplierId) {
+			toast.error('Choisissez un fournisseur')
+			return
+		}
+		if (lines.length === 0 || lines.some((l) => l.quantity_ordered <= 0)) {
+			toast.error('Chaque ligne doit avoir une quantité')
+			return
+		}
+		try {
+			const order = await createOrder.mutateAsync({
+				owner_company: activeCompanyId,
+				supplier: supplierId,
+				expected_at: expectedAt || undefined,
+				notes: notes || undefined,
+				lines,
+			})
+			toast.success(`Commande ${order.number} enregistrée en brouillon`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-3xl'>
+				<DialogHeader>
+					<DialogTitle>Nouvelle commande fournisseur</DialogTitle>
+					<DialogDescription>
+						Enregistrée en brouillon : elle reste modifiable jusqu’à son envoi.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='grid grid-cols-2 gap-4'>
+						<div className='space-y-1'>
+							<Label htmlFor='po-supplier'>Fournisseur</Label>
+							<select
+								id='po-supplier'
+								className={selectClassName}
+								value={supplierId}
+								onChange={(e) => setSupplierId(e.target.value)}
+							>
+								<option value=''>-- Sélectionner --</option>
+								{suppliers?.map((s) => (
+									<option key={s.id} value={s.id}>
+										{s.name}
+									</option>
+								))}
+							</select>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='po-expected'>Livraison prévue</Label>
+							<Input
+								id='po-expected'
+								type='date'
+								value={expectedAt}
+								onChange={(e) => setExpectedAt(e.target.value)}
+							/>
+						</div>
+					</div>
+
+					<div className='space-y-1'>
+						<Label htmlFor='po-search'>Ajouter un produit</Label>
+						<Input
+							id='po-search'
+							placeholder='Nom, référence ou code-barres'
+							value={search}
+							onChange={(e) => setSearch(e.target.value)}
+						/>
+						{search.trim() && products.length > 0 && (
+							<div className='max-h-40 overflow-y-auto rounded-md border'>
+								{products.map((p) => (
+									<button
+										key={p.id}
+										type='button'
+										className='flex w-full justify-between px-3 py-1.5 text-left text-sm hover:bg-muted'
+										onClick={() => addProduct(p)}
+									>
+										<span>{p.name}</span>
+										<span className='text-muted-foreground'>
+											stock {p.stock ?? 0}
+										</span>
+									</button>
+								))}
+							</div>
+						)}
+					</div>
+
+					{lines.length > 0 && (
+						<div className='space-y-2'>
+							{lines.map((line, index) => (
+								<div key={line.product} className='flex items-center gap-2'>
+									<span className='flex-1 truncate text-sm'>
+										{line.product_name}
+									</span>
+									<Input
+										className='w-24'
+										type='number'
+										min={0}
+										step='any'
+										aria-label='Quantité'
+										value={line.quantity_ordered}
+										onChange={(e) =>
+											updateLine(index, {
+												quantity_ordered: Number(e.target.value),
+											})
+										}
+									/>
+									<Input
+										className='w-28'
+										type='number'
+										min={0}
+										step='0.01'
+										aria-label='Prix unitaire HT'
+										value={line.unit_price_ht}
+										onChange={(e) =>
+											updateLine(index, {
+												unit_price_ht: Number(e.target.value),
+											})
+										}
+									/>
+									<Button
+										variant='ghost'
+										size='icon'
+										onClick={() =>
+											setLines((prev) => prev.filter((_, i) => i !== index))
+										}
+									>
+										<Trash2 className='h-4 w-4' />
+									</Button>
+								</div>
+							))}
+							<p className='text-right font-medium text-sm'>
+								Total HT : {fmt(total)}
+							</p>
+						</div>
+					)}
+
+					<div className='space-y-1'>
+						<Label htmlFor='po-notes'>Notes</Label>
+						<Textarea
+							id='po-notes'
+							value={notes}
+							onChange={(e) => setNotes(e.target.value)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={createOrder.isPending}>
+						{createOrder.isPending ? (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						) : (
+							<Plus className='mr-2 h-4 w-4' />
+						)}
+						Enregistrer
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
@@ -0,0 +1,278 @@
+// frontend/modules/stock/components/PurchaseReceiptDialog.tsx
+//
+// Réception d'une commande fournisseur. Chaque ligne propose son reste à
+// recevoir et le prix commandé ; le serveur refuse une quantité au-delà du
+// reste, et poste la réception entière ou rien. Une société à plusieurs
+// emplacements choisit celui qui reçoit ; sinon tout entre à l'emplacement
+// par défaut. Une ligne d'un produit suivi demande un numéro de série par
+// unité, scanné ou collé depuis le bon de livraison.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
+import { Loader2, PackageCheck } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useStockLocations } from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	PURCHASE_ORDER_STATUS_LABELS,
+	type PurchaseOrder,
+	remainingQuantity,
+	usePurchaseOrderLines,
+	useReceivePurchaseOrder,
+} from '@/lib/queries/purchasing'
+
+interface ReceiptDraft {
+	quantity: number
+	unitPrice: number
+	serials: string
+	lot: string
+}
+
+/** Un numéro par ligne, ou séparés par des virgules : la douchette envoie
+ *  un retour chariot, le bon de livraison se colle en liste. */
+function parseSerials(text: string): string[] {
+	return text
+		.split(/[\n,;]+/)
+		.map((s) => s.trim())
+		.filter(Boolean)
+}
+
+interface PurchaseReceiptDialogProps {
+	order: PurchaseOrder | null
+	onOpenChange: (open: boolean) => void
+}
+
+export function PurchaseReceiptDialog({
+	order,
+	onOpenChange,
+}: PurchaseReceiptDialogProps) {
+	const { data: lines } = usePurchaseOrderLines(order?.id)
+	const { data: locations = [] } = useStockLocations(order?.owner_company)
+	const receive = useReceivePurchaseOrder()
+	const activeLocations = locations.filter((l) => !l.archived)
+
+	const [drafts, setDrafts] = useState<Record<string, ReceiptDraft>>({})
+	const [deliveryNote, setDeliveryNote] = useState('')
+	const [locationId, setLocationId] = useState('')
+
+	useEffect(() => {
+		if (!lines) return
+		setDrafts(
+			Object.fromEntries(
+				lines.map((l) => [
+					l.id,
+					{
+						quantity: remainingQuantity(l),
+						unitPrice: l.unit_price_ht,
+						serials: '',
+						lot: '',
+					},
+				]),
+			),
+		)
+		setDeliveryNote('')
+		setLocationId('')
+	}, [lines])
+
+	const updateDraft = (lineId: string, patch: Partial<ReceiptDraft>) =>
+		setDrafts((prev) => ({ ...prev, [lineId]: { ...prev[lineId], ...patch } }))
+
+	const handleSubmit = async () => {
+		if (!order || !lines) return
+		const received = lines.filter((l) => (drafts[l.id]?.quantity ?? 0) > 0)
+		if (received.length === 0) {
+			toast.error('Aucune quantité reçue')
+			return
+		}
+		const missing = received.find(
+			(l) =>
+				l.expand?.product?.serialized &&
+				parseSerials(drafts[l.id].serials).length !== drafts[l.id].quantity,
+		)
+		if (missing) {
+			toast.error(
+				`« ${missing.product_name} » : un numéro de série par unité reçue`,
+			)
+			return
+		}
+		const payload = received.map((l) => ({
+			line_id: l.id,
+			quantity: drafts[l.id].quantity,
+			// Le prix n'est envoyé que s'il diffère : sinon la commande fait foi
+			unit_price_ht:
+				drafts[l.id].unitPrice !== l.unit_price_ht
+					? drafts[l.id].unitPrice
+					: undefined,
+			serial_numbers: l.expand?.product?.serialized
+				? parseSerials(drafts[l.id].serials)
+				: undefined,
+			lot_number: drafts[l.id].lot.trim() || undefined,
+		}))
+		try {
+			const result = await receive.mutateAsync({
+				orderId: order.id,
+				input: {
+					lines: payload,
+					delivery_note: deliveryNote || undefined,
+					location_id: locationId || undefined,
+				},
+			})
+			toast.success(
+				`Réception enregistrée — commande ${PURCHASE_ORDER_STATUS_LABELS[result.order.status].toLowerCase()}`,
+			)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={!!order} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-3xl'>
+				<DialogHeader>
+					<DialogTitle>Réception — {order?.number}</DialogTitle>
+					<DialogDescription>
+						Les quantités reçues entrent en stock, et le prix d’achat des
+						produits est mis à jour.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='space-y-1'>
+						<Label htmlFor='receipt-note'>Bon de livraison</Label>
+						<Input
+							id='receipt-note'
+							value={deliveryNote}
+							onChange={(e) => setDeliveryNote(e.target.value)}
+						/>
+					</div>
+
+					{activeLocations.length > 1 && (
+						<div className='space-y-1'>
+							<Label htmlFor='receipt-location'>Emplacement</Label>
+							<select
+								id='receipt-location'
+								className='h-9 w-full rounded-md border border-input bg-background px-3 text-sm'
+								value={locationId}
+								onChange={(e) => setLocationId(e.target.value)}
+							>
+								<option value=''>Emplacement par défaut</option>
+								{activeLocations
+									.filter((l) => !l.is_default)
+									.map((l) => (
+										<option key={l.id} value={l.id}>
+											{l.name}
+										</option>
+									))}
+							</select>
+						</div>
+					)}
+
+					<div className='space-y-2'>
+						{lines?.map((line) => {
+							const remaining = remainingQuantity(line)
+							const draft = drafts[line.id]
+							const serialized =
+								!!line.expand?.product?.serialized && (draft?.quantity ?? 0) > 0
+							return (
+								<div key={line.id} className='space-y-2'>
+									<div className='flex items-center gap-2'>
+										<div className='flex-1 text-sm'>
+											<div className='truncate'>{line.product_name}</div>
+											<div className='text-muted-foreground text-xs'>
+												{line.quantity_received || 0} / {line.quantity_ordered}{' '}
+												reçu(s)
+											</div>
+										</div>
+										<Input
+											className='w-24'
+											type='number'
+											min={0}
+											max={remaining}
+											step='any'
+											aria-label='Quantité reçue'
+											disabled={remaining === 0}
+											value={drafts[line.id]?.quantity ?? 0}
+											onChange={(e) =>
+												updateDraft(line.id, {
+													quantity: Number(e.target.value),
+												})
+											}
+										/>
+										<Input
+											className='w-28'
+											type='number'
+											min={0}
+											step='0.01'
+											aria-label='Prix unitaire HT facturé'
+											disabled={remaining === 0}
+											value={drafts[line.id]?.unitPrice ?? 0}
+											onChange={(e) =>
+												updateDraft(line.id, {
+													unitPrice: Number(e.target.value),
+												})
+											}
+										/>
+										<Input
+											className='w-28'
+											placeholder='Lot'
+											aria-label='Numéro de lot'
+											disabled={remaining === 0}
+											value={draft?.lot ?? ''}
+											onChange={(e) =>
+												updateDraft(line.id, { lot: e.target.value })
+											}
+										/>
+									</div>
+									{serialized && (
+										<div className='space-y-1 pl-2'>
+											<Textarea
+												rows={Math.min(draft.quantity, 4)}
+												placeholder='Un numéro de série par ligne'
+												aria-label={`Numéros de série de ${line.product_name}`}
+												value={draft.serials}
+												onChange={(e) =>
+													updateDraft(line.id, { serials: e.target.value })
+												}
+											/>
+											<p className='text-muted-foreground text-xs'>
+												{parseSerials(draft.serials).length} / {draft.quantity}{' '}
+												numéro(s) de série
+											</p>
+										</div>
+									)}
+								</div>
+							)
+						})}
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={receive.isPending}>
+						{receive.isPending ? (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						) : (
+							<PackageCheck className='mr-2 h-4 w-4' />
+						)}
+						Réceptionner
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
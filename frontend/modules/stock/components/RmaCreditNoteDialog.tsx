@@ -0,0 +1,142 @@
+// frontend/modules/stock/components/RmaCreditNoteDialog.tsx
+//
+// L'avoir fournisseur rattaché au RMA : numéro, montant, date et la pièce
+// elle-même. Il se saisit à toute étape — un fournisseur qui répare peut
+// aussi rembourser le port — sans rien changer au stock.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Loader2 } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import { type Rma, useUpdateRma } from '@/lib/queries/rma'
+
+interface RmaCreditNoteDialogProps {
+	rma: Rma | null
+	onOpenChange: (open: boolean) => void
+}
+
+export function RmaCreditNoteDialog({
+	rma,
+	onOpenChange,
+}: RmaCreditNoteDialogProps) {
+	const updateRma = useUpdateRma()
+
+	const [number, setNumber] = useState('')
+	const [amount, setAmount] = useState('')
+	const [date, setDate] = useState('')
+	const [file, setFile] = useState<File | null>(null)
+
+	useEffect(() => {
+		if (rma) {
+			setNumber(rma.credit_note_number ?? '')
+			setAmount(
+				rma.credit_note_amount_ht ? String(rma.credit_note_amount_ht) : '',
+			)
+			setDate(rma.credit_note_date?.slice(0, 10) ?? '')
+			setFile(null)
+		}
+	}, [rma])
+
+	const handleSubmit = async () => {
+		if (!rma) return
+		if (!number.trim()) {
+			toast.error("Numéro de l'avoir requis")
+			return
+		}
+		try {
+			await updateRma.mutateAsync({
+				id: rma.id,
+				credit_note_number: number.trim(),
+				credit_note_amount_ht: amount ? Number(amount) : 0,
+				credit_note_date: date || '',
+				credit_note_file: file ?? undefined,
+			})
+			toast.success(`Avoir rattaché au RMA ${rma.number}`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={!!rma} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-lg'>
+				<DialogHeader>
+					<DialogTitle>Avoir fournisseur — {rma?.number}</DialogTitle>
+					<DialogDescription>
+						{rma?.expand?.supplier?.name ?? 'Fournisseur non choisi'}
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='grid grid-cols-3 gap-4'>
+						<div className='space-y-1'>
+							<Label htmlFor='cn-number'>N° d’avoir</Label>
+							<Input
+								id='cn-number'
+								value={number}
+								onChange={(e) => setNumber(e.target.value)}
+							/>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='cn-amount'>Montant HT</Label>
+							<Input
+								id='cn-amount'
+								type='number'
+								min={0}
+								step='0.01'
+								value={amount}
+								onChange={(e) => setAmount(e.target.value)}
+							/>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='cn-date'>Date</Label>
+							<Input
+								id='cn-date'
+								type='date'
+								value={date}
+								onChange={(e) => setDate(e.target.value)}
+							/>
+						</div>
+					</div>
+					<div className='space-y-1'>
+						<Label htmlFor='cn-file'>
+							Pièce (PDF ou image)
+							{rma?.credit_note_file ? ' — remplace celle déjà jointe' : ''}
+						</Label>
+						<Input
+							id='cn-file'
+							type='file'
+							accept='application/pdf,image/jpeg,image/png'
+							onChange={(e) => setFile(e.target.files?.[0] ?? null)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={updateRma.isPending}>
+						{updateRma.isPending && (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						)}
+						Enregistrer
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
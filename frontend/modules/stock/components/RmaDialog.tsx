@@ -0,0 +1,357 @@
+// frontend/modules/stock/components/RmaDialog.tsx
+//
+// Ouverture d'un RMA. Une unité suivie se nomme par son numéro de série : le
+// serveur en déduit le produit, et pour un client sa vente. Un article sorti
+// du stock entre au bac RMA dès l'ouverture, celui d'un client reste hors
+// stock ; le numéro RMA-YYYY-XXXX est posé à ce moment-là.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
+import { Loader2, Wrench, X } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	type CatalogProductShape,
+	useCatalogProductSearch,
+} from '@/lib/queries/catalog-products'
+import { useSearchCustomers } from '@/lib/queries/customers'
+import { defaultLocation, useStockLocations } from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	RMA_KIND_LABELS,
+	RMA_ORIGIN_LABELS,
+	type RmaKind,
+	type RmaOrigin,
+	useCreateRma,
+} from '@/lib/queries/rma'
+import { useSuppliers } from '@/lib/queries/suppliers'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+interface RmaDialogProps {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+}
+
+export function RmaDialog({ open, onOpenChange }: RmaDialogProps) {
+	const { activeCompanyId } = useActiveCompany()
+	const companyId = activeCompanyId ?? undefined
+	const { data: locations } = useStockLocations(companyId)
+	const { data: suppliers } = useSuppliers({ companyId })
+	const createRma = useCreateRma()
+
+	const [kind, setKind] = useState<RmaKind>('supplier_return')
+	const [origin, setOrigin] = useState<RmaOrigin>('customer')
+	const [serialNumber, setSerialNumber] = useState('')
+	const [product, setProduct] = useState<CatalogProductShape | null>(null)
+	const [quantity, setQuantity] = useState(1)
+	const [customer, setCustomer] = useState<{ id: string; name: string } | null>(
+		null,
+	)
+	const [supplierId, setSupplierId] = useState('')
+	const [locationId, setLocationId] = useState('')
+	const [fault, setFault] = useState('')
+	const [productSearch, setProductSearch] = useState('')
+	const [customerSearch, setCustomerSearch] = useState('')
+
+	const { items: products } = useCatalogProductSearch({
+		companyId,
+		term: productSearch,
+		enabled: open,
+	})
+	const { data: customerResults } = useSearchCustomers(
+		customerSearch,
+		companyId,
+	)
+
+	// Le bac RMA n'est pas une origine : l'article y entre
+	const sources =
+		locations?.filter((l) => !l.archived && l.kind !== 'rma') ?? []
+	const defaultId = defaultLocation(locations)?.id ?? ''
+
+	useEffect(() => {
+		if (open) {
+			setKind('supplier_return')
+			setOrigin('customer')
+			setSerialNumber('')
+			setProduct(null)
+			setQuantity(1)
+			setCustomer(null)
+			setSupplierId('')
+			setLocationId(defaultId)
+			setFault('')
+			setProductSearch('')
+			setCustomerSearch('')
+		}
+	}, [open, defaultId])
+
+	const chooseProduct = (p: CatalogProductShape) => {
+		setProduct(p)
+		setProductSearch('')
+		// Le fournisseur habituel du produit, s'il n'en a pas été choisi
+		if (!supplierId && p.supplier) setSupplierId(p.supplier)
+	}
+
+	const handleSubmit = async () => {
+		if (!activeCompanyId) return
+		const serial = serialNumber.trim()
+		if (!serial && !product) {
+			toast.error('Nommez le produit ou le numéro de série')
+			return
+		}
+		if (!serial && quantity <= 0) {
+			toast.error('Quantité invalide')
+			return
+		}
+		try {
+			const rma = await createRma.mutateAsync({
+				company_id: activeCompanyId,
+				kind,
+				origin,
+				serial_number: serial || undefined,
+				product_id: serial ? undefined : product?.id,
+				quantity: serial ? 1 : quantity,
+				customer_id: origin === 'customer' ? customer?.id : undefined,
+				supplier_id: supplierId || undefined,
+				location_id: origin === 'stock' ? locationId || undefined : undefined,
+				fault: fault || undefined,
+			})
+			toast.success(`RMA ${rma.number} ouvert`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-2xl'>
+				<DialogHeader>
+					<DialogTitle>Nouveau retour SAV</DialogTitle>
+					<DialogDescription>
+						{origin === 'stock'
+							? 'L’article sort du rayon vers le bac RMA, où il attend son expédition au fournisseur ou au réparateur.'
+							: 'L’article du client n’entre pas dans le stock : le RMA suit seul son expédition et son retour.'}
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='grid grid-cols-2 gap-4'>
+						<div className='space-y-1'>
+							<Label htmlFor='rma-kind'>Type</Label>
+							<select
+								id='rma-kind'
+								className={selectClassName}
+								value={kind}
+								onChange={(e) => setKind(e.target.value as RmaKind)}
+							>
+								{Object.entries(RMA_KIND_LABELS).map(([value, label]) => (
+									<option key={value} value={value}>
+										{label}
+									</option>
+								))}
+							</select>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='rma-origin'>Article rapporté par</Label>
+							<select
+								id='rma-origin'
+								className={selectClassName}
+								value={origin}
+								onChange={(e) => setOrigin(e.target.value as RmaOrigin)}
+							>
+								{Object.entries(RMA_ORIGIN_LABELS).map(([value, label]) => (
+									<option key={value} value={value}>
+										{label}
+									</option>
+								))}
+							</select>
+						</div>
+					</div>
+
+					<div className='space-y-1'>
+						<Label htmlFor='rma-serial'>Numéro de série</Label>
+						<Input
+							id='rma-serial'
+							placeholder='Pour une unité suivie'
+							value={serialNumber}
+							onChange={(e) => setSerialNumber(e.target.value)}
+						/>
+					</div>
+
+					{!serialNumber.trim() && (
+						<div className='space-y-1'>
+							<Label htmlFor='rma-product'>Produit</Label>
+							{product ? (
+								<div className='flex items-center gap-2'>
+									<span className='flex-1 truncate text-sm'>
+										{product.name}
+									</span>
+									<Input
+										className='w-24'
+										type='number'
+										min={0}
+										step='any'
+										aria-label='Quantité'
+										value={quantity}
+										onChange={(e) => setQuantity(Number(e.target.value))}
+									/>
+									<Button
+										variant='ghost'
+										size='icon'
+										onClick={() => setProduct(null)}
+									>
+										<X className='h-4 w-4' />
+									</Button>
+								</div>
+							) : (
+								<>
+									<Input
+										id='rma-product'
+										placeholder='Nom, référence ou code-barres'
+										value={productSearch}
+										onChange={(e) => setProductSearch(e.target.value)}
+									/>
+									{productSearch.trim() && products.length > 0 && (
+										<div className='max-h-40 overflow-y-auto rounded-md border'>
+											{products.map((p) => (
+												<button
+													key={p.id}
+													type='button'
+													className='flex w-full justify-between px-3 py-1.5 text-left text-sm hover:bg-muted'
+													onClick={() => chooseProduct(p)}
+												>
+													<span>{p.name}</span>
+													<span className='text-muted-foreground'>
+														stock {p.stock ?? 0}
+													</span>
+												</button>
+											))}
+										</div>
+									)}
+								</>
+							)}
+						</div>
+					)}
+
+					{origin === 'customer' ? (
+						<div className='space-y-1'>
+							<Label htmlFor='rma-customer'>Client</Label>
+							{customer ? (
+								<div className='flex items-center gap-2'>
+									<span className='flex-1 truncate text-sm'>
+										{customer.name}
+									</span>
+									<Button
+										variant='ghost'
+										size='icon'
+										onClick={() => setCustomer(null)}
+									>
+										<X className='h-4 w-4' />
+									</Button>
+								</div>
+							) : (
+								<>
+									<Input
+										id='rma-customer'
+										placeholder='Déduit de la vente pour une unité suivie'
+										value={customerSearch}
+										onChange={(e) => setCustomerSearch(e.target.value)}
+									/>
+									{(customerResults?.items.length ?? 0) > 0 && (
+										<div className='max-h-40 overflow-y-auto rounded-md border'>
+											{customerResults?.items.map((c) => (
+												<button
+													key={c.id}
+													type='button'
+													className='w-full px-3 py-1.5 text-left text-sm hover:bg-muted'
+													onClick={() => {
+														setCustomer({ id: c.id, name: c.name })
+														setCustomerSearch('')
+													}}
+												>
+													{c.name}
+												</button>
+											))}
+										</div>
+									)}
+								</>
+							)}
+						</div>
+					) : (
+						sources.length > 0 && (
+							<div className='space-y-1'>
+								<Label htmlFor='rma-location'>Sorti de</Label>
+								<select
+									id='rma-location'
+									className={selectClassName}
+									value={locationId}
+									onChange={(e) => setLocationId(e.target.value)}
+								>
+									{sources.map((l) => (
+										<option key={l.id} value={l.id}>
+											{l.name}
+										</option>
+									))}
+								</select>
+							</div>
+						)
+					)}
+
+					<div className='space-y-1'>
+						<Label htmlFor='rma-supplier'>Fournisseur ou réparateur</Label>
+						<select
+							id='rma-supplier'
+							className={selectClassName}
+							value={supplierId}
+							onChange={(e) => setSupplierId(e.target.value)}
+						>
+							<option value=''>Celui du produit</option>
+							{suppliers?.map((s) => (
+								<option key={s.id} value={s.id}>
+									{s.name}
+								</option>
+							))}
+						</select>
+					</div>
+
+					<div className='space-y-1'>
+						<Label htmlFor='rma-fault'>Panne constatée</Label>
+						<Textarea
+							id='rma-fault'
+							value={fault}
+							onChange={(e) => setFault(e.target.value)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={createRma.isPending}>
+						{createRma.isPending ? (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						) : (
+							<Wrench className='mr-2 h-4 w-4' />
+						)}
+						Ouvrir le RMA
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
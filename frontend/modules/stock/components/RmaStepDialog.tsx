@@ -0,0 +1,234 @@
+// frontend/modules/stock/components/RmaStepDialog.tsx
+//
+// Une étape d'un RMA, avec ce qu'elle demande : le numéro d'accord et le
+// suivi à l'expédition, le numéro de l'unité de remplacement, l'avoir
+// fournisseur quand rien ne revient, l'emplacement où remettre un article
+// sorti du stock.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
+import { Loader2 } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useStockLocations } from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+import {
+	RMA_ACTION_LABELS,
+	type Rma,
+	type RmaAction,
+	useRmaStep,
+} from '@/lib/queries/rma'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+interface RmaStepDialogProps {
+	rma: Rma | null
+	action: RmaAction | null
+	onOpenChange: (open: boolean) => void
+}
+
+export function RmaStepDialog({
+	rma,
+	action,
+	onOpenChange,
+}: RmaStepDialogProps) {
+	const open = !!rma && !!action
+	const { data: locations } = useStockLocations(rma?.owner_company)
+	const step = useRmaStep()
+
+	const [note, setNote] = useState('')
+	const [supplierReference, setSupplierReference] = useState('')
+	const [trackingNumber, setTrackingNumber] = useState('')
+	const [replacementSerial, setReplacementSerial] = useState('')
+	const [creditNoteNumber, setCreditNoteNumber] = useState('')
+	const [creditNoteAmount, setCreditNoteAmount] = useState('')
+	const [creditNoteDate, setCreditNoteDate] = useState('')
+	const [locationId, setLocationId] = useState('')
+
+	useEffect(() => {
+		if (rma) {
+			setNote('')
+			setSupplierReference(rma.supplier_reference ?? '')
+			setTrackingNumber(rma.tracking_number ?? '')
+			setReplacementSerial(rma.replacement_serial ?? '')
+			setCreditNoteNumber(rma.credit_note_number ?? '')
+			setCreditNoteAmount(
+				rma.credit_note_amount_ht ? String(rma.credit_note_amount_ht) : '',
+			)
+			setCreditNoteDate(rma.credit_note_date?.slice(0, 10) ?? '')
+			setLocationId(rma.location ?? '')
+		}
+	}, [rma])
+
+	// Remettre en rayon : partout sauf au bac RMA
+	const targets =
+		locations?.filter((l) => !l.archived && l.kind !== 'rma') ?? []
+	const restocks =
+		rma?.origin === 'stock' &&
+		(action === 'return' || action === 'cancel') &&
+		targets.length > 0
+
+	const handleSubmit = async () => {
+		if (!rma || !action) return
+		if (action === 'credited' && !creditNoteNumber.trim()) {
+			toast.error("Numéro de l'avoir fournisseur requis")
+			return
+		}
+		try {
+			const result = await step.mutateAsync({
+				id: rma.id,
+				action,
+				note: note || undefined,
+				location_id: restocks ? locationId || undefined : undefined,
+				supplier_reference: supplierReference || undefined,
+				tracking_number: trackingNumber || undefined,
+				replacement_serial: replacementSerial || undefined,
+				credit_note_number: creditNoteNumber || undefined,
+				credit_note_amount_ht: creditNoteAmount
+					? Number(creditNoteAmount)
+					: undefined,
+				credit_note_date: creditNoteDate || undefined,
+			})
+			toast.success(`RMA ${result.number} : ${RMA_ACTION_LABELS[action]}`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-lg'>
+				<DialogHeader>
+					<DialogTitle>
+						{action ? RMA_ACTION_LABELS[action] : ''} — {rma?.number}
+					</DialogTitle>
+					<DialogDescription>
+						{rma?.quantity} × {rma?.product_name}
+						{rma?.serial_number ? ` (${rma.serial_number})` : ''}
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					{action === 'ship' && (
+						<div className='grid grid-cols-2 gap-4'>
+							<div className='space-y-1'>
+								<Label htmlFor='rma-ref'>N° d’accord fournisseur</Label>
+								<Input
+									id='rma-ref'
+									value={supplierReference}
+									onChange={(e) => setSupplierReference(e.target.value)}
+								/>
+							</div>
+							<div className='space-y-1'>
+								<Label htmlFor='rma-tracking'>N° de suivi</Label>
+								<Input
+									id='rma-tracking'
+									value={trackingNumber}
+									onChange={(e) => setTrackingNumber(e.target.value)}
+								/>
+							</div>
+						</div>
+					)}
+
+					{action === 'replaced' && (
+						<div className='space-y-1'>
+							<Label htmlFor='rma-replacement'>
+								Numéro de série de l’unité de remplacement
+							</Label>
+							<Input
+								id='rma-replacement'
+								value={replacementSerial}
+								onChange={(e) => setReplacementSerial(e.target.value)}
+							/>
+						</div>
+					)}
+
+					{action === 'credited' && (
+						<div className='grid grid-cols-3 gap-4'>
+							<div className='space-y-1'>
+								<Label htmlFor='rma-cn-number'>N° d’avoir</Label>
+								<Input
+									id='rma-cn-number'
+									value={creditNoteNumber}
+									onChange={(e) => setCreditNoteNumber(e.target.value)}
+								/>
+							</div>
+							<div className='space-y-1'>
+								<Label htmlFor='rma-cn-amount'>Montant HT</Label>
+								<Input
+									id='rma-cn-amount'
+									type='number'
+									min={0}
+									step='0.01'
+									value={creditNoteAmount}
+									onChange={(e) => setCreditNoteAmount(e.target.value)}
+								/>
+							</div>
+							<div className='space-y-1'>
+								<Label htmlFor='rma-cn-date'>Date</Label>
+								<Input
+									id='rma-cn-date'
+									type='date'
+									value={creditNoteDate}
+									onChange={(e) => setCreditNoteDate(e.target.value)}
+								/>
+							</div>
+						</div>
+					)}
+
+					{restocks && (
+						<div className='space-y-1'>
+							<Label htmlFor='rma-target'>Remettre à</Label>
+							<select
+								id='rma-target'
+								className={selectClassName}
+								value={locationId}
+								onChange={(e) => setLocationId(e.target.value)}
+							>
+								{targets.map((l) => (
+									<option key={l.id} value={l.id}>
+										{l.name}
+									</option>
+								))}
+							</select>
+						</div>
+					)}
+
+					<div className='space-y-1'>
+						<Label htmlFor='rma-note'>Note</Label>
+						<Textarea
+							id='rma-note'
+							value={note}
+							onChange={(e) => setNote(e.target.value)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={step.isPending}>
+						{step.isPending && (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						)}
+						Valider
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
@@ -0,0 +1,159 @@
+// frontend/modules/stock/components/StockLocationDialog.tsx
+//
+// Création et modification d'un emplacement. L'emplacement par défaut ne se
+// choisit pas ici : le premier créé l'est d'office, ensuite on en change
+// depuis la liste, parce que le serveur doit d'abord figer les quantités que
+// l'ancien portait implicitement.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Loader2 } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	STOCK_LOCATION_KIND_LABELS,
+	type StockLocation,
+	type StockLocationKind,
+	useCreateStockLocation,
+	useUpdateStockLocation,
+} from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+interface StockLocationDialogProps {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+	location?: StockLocation | null
+}
+
+export function StockLocationDialog({
+	open,
+	onOpenChange,
+	location = null,
+}: StockLocationDialogProps) {
+	const isEdit = !!location
+	const { activeCompanyId } = useActiveCompany()
+	const createLocation = useCreateStockLocation()
+	const updateLocation = useUpdateStockLocation()
+
+	const [name, setName] = useState('')
+	const [code, setCode] = useState('')
+	const [kind, setKind] = useState<StockLocationKind | ''>('')
+
+	useEffect(() => {
+		if (!open) return
+		setName(location?.name ?? '')
+		setCode(location?.code ?? '')
+		setKind(location?.kind ?? '')
+	}, [open, location])
+
+	const isPending = createLocation.isPending || updateLocation.isPending
+
+	const handleSubmit = async () => {
+		if (!name.trim()) {
+			toast.error('Le nom est requis')
+			return
+		}
+		// Chaîne vide plutôt qu'undefined : c'est ainsi qu'on efface un champ
+		const input = { name: name.trim(), code: code.trim(), kind }
+		try {
+			if (isEdit && location) {
+				await updateLocation.mutateAsync({ id: location.id, data: input })
+				toast.success('Emplacement modifié')
+			} else {
+				if (!activeCompanyId) {
+					toast.error('Aucune société active')
+					return
+				}
+				await createLocation.mutateAsync({
+					ownerCompany: activeCompanyId,
+					input,
+				})
+				toast.success('Emplacement créé')
+			}
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-md'>
+				<DialogHeader>
+					<DialogTitle>
+						{isEdit ? 'Modifier l’emplacement' : 'Nouvel emplacement'}
+					</DialogTitle>
+					<DialogDescription>
+						Boutique, réserve ou atelier : chaque produit y a sa quantité, et le
+						stock du produit reste leur somme.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='space-y-1'>
+						<Label htmlFor='loc-name'>Nom</Label>
+						<Input
+							id='loc-name'
+							value={name}
+							onChange={(e) => setName(e.target.value)}
+						/>
+					</div>
+					<div className='grid grid-cols-2 gap-4'>
+						<div className='space-y-1'>
+							<Label htmlFor='loc-code'>Code</Label>
+							<Input
+								id='loc-code'
+								value={code}
+								onChange={(e) => setCode(e.target.value)}
+							/>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='loc-kind'>Type</Label>
+							<select
+								id='loc-kind'
+								className={selectClassName}
+								value={kind}
+								onChange={(e) =>
+									setKind(e.target.value as StockLocationKind | '')
+								}
+							>
+								<option value=''>—</option>
+								{Object.entries(STOCK_LOCATION_KIND_LABELS).map(
+									([value, label]) => (
+										<option key={value} value={value}>
+											{label}
+										</option>
+									),
+								)}
+							</select>
+						</div>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={isPending}>
+						{isPending && <Loader2 className='mr-2 h-4 w-4 animate-spin' />}
+						Enregistrer
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
@@ -0,0 +1,282 @@
+// frontend/modules/stock/components/StockTransferDialog.tsx
+//
+// Saisie d'un transfert entre deux emplacements. Le serveur le poste d'un
+// bloc : une ligne qui mettrait la source en négatif refuse tout le bon, et
+// le numéro TR-YYYY-XXXX n'est posé qu'à ce moment-là.
+
+import { Button } from '@/components/ui/button'
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from '@/components/ui/dialog'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { Textarea } from '@/components/ui/textarea'
+import { ArrowLeftRight, Loader2, Trash2 } from 'lucide-react'
+import { useEffect, useMemo, useState } from 'react'
+import { toast } from 'sonner'
+
+import { useActiveCompany } from '@/lib/ActiveCompanyProvider'
+import {
+	type CatalogProductShape,
+	useCatalogProductSearch,
+} from '@/lib/queries/catalog-products'
+import {
+	defaultLocation,
+	useCreateStockTransfer,
+	useLocationLevels,
+	useStockLocations,
+} from '@/lib/queries/locations'
+import { pocketbaseErrorMessage } from '@/lib/queries/pb-error'
+
+const selectClassName =
+	'w-full h-9 rounded-md border border-input bg-background px-3 text-sm'
+
+interface TransferLineDraft {
+	productId: string
+	productName: string
+	quantity: number
+}
+
+interface StockTransferDialogProps {
+	open: boolean
+	onOpenChange: (open: boolean) => void
+}
+
+export function StockTransferDialog({
+	open,
+	onOpenChange,
+}: StockTransferDialogProps) {
+	const { activeCompanyId } = useActiveCompany()
+	const { data: locations } = useStockLocations(activeCompanyId ?? undefined)
+	const createTransfer = useCreateStockTransfer()
+
+	const [fromLocation, setFromLocation] = useState('')
+	const [toLocation, setToLocation] = useState('')
+	const [notes, setNotes] = useState('')
+	const [lines, setLines] = useState<TransferLineDraft[]>([])
+	const [search, setSearch] = useState('')
+
+	const { items: products } = useCatalogProductSearch({
+		companyId: activeCompanyId ?? undefined,
+		term: search,
+		enabled: open,
+	})
+	const { data: sourceLevels } = useLocationLevels(fromLocation || undefined)
+
+	// Ce que la source détient, pour l'afficher à côté de chaque ligne
+	const available = useMemo(
+		() =>
+			new Map(
+				(sourceLevels?.levels ?? []).map((l) => [l.product_id, l.quantity]),
+			),
+		[sourceLevels],
+	)
+
+	const active = locations?.filter((l) => !l.archived) ?? []
+	const defaultId = defaultLocation(locations)?.id ?? ''
+
+	useEffect(() => {
+		if (open) {
+			setFromLocation(defaultId)
+			setToLocation('')
+			setNotes('')
+			setLines([])
+			setSearch('')
+		}
+	}, [open, defaultId])
+
+	const addProduct = (product: CatalogProductShape) => {
+		if (lines.some((l) => l.productId === product.id)) {
+			toast.info(`« ${product.name} » est déjà dans le transfert`)
+			return
+		}
+		setLines((prev) => [
+			...prev,
+			{ productId: product.id, productName: product.name, quantity: 1 },
+		])
+		setSearch('')
+	}
+
+	const updateQuantity = (index: number, quantity: number) =>
+		setLines((prev) =>
+			prev.map((l, i) => (i === index ? { ...l, quantity } : l)),
+		)
+
+	const handleSubmit = async () => {
+		if (!activeCompanyId || !fromLocation || !toLocation) {
+			toast.error('Choisissez les deux emplacements')
+			return
+		}
+		if (fromLocation === toLocation) {
+			toast.error('La source et la destination sont le même emplacement')
+			return
+		}
+		if (lines.length === 0 || lines.some((l) => l.quantity <= 0)) {
+			toast.error('Chaque ligne doit avoir une quantité')
+			return
+		}
+		try {
+			const result = await createTransfer.mutateAsync({
+				company_id: activeCompanyId,
+				from_location: fromLocation,
+				to_location: toLocation,
+				lines: lines.map((l) => ({
+					product_id: l.productId,
+					quantity: l.quantity,
+				})),
+				notes: notes || undefined,
+			})
+			toast.success(`Transfert ${result.transfer.number} enregistré`)
+			onOpenChange(false)
+		} catch (error) {
+			toast.error(pocketbaseErrorMessage(error))
+		}
+	}
+
+	return (
+		<Dialog open={open} onOpenChange={onOpenChange}>
+			<DialogContent className='max-w-3xl'>
+				<DialogHeader>
+					<DialogTitle>Nouveau transfert</DialogTitle>
+					<DialogDescription>
+						Les quantités passent d’un emplacement à l’autre ; le stock total
+						des produits ne change pas.
+					</DialogDescription>
+				</DialogHeader>
+
+				<div className='space-y-4'>
+					<div className='grid grid-cols-2 gap-4'>
+						<div className='space-y-1'>
+							<Label htmlFor='tr-from'>Depuis</Label>
+							<select
+								id='tr-from'
+								className={selectClassName}
+								value={fromLocation}
+								onChange={(e) => setFromLocation(e.target.value)}
+							>
+								<option value=''>-- Sélectionner --</option>
+								{active.map((l) => (
+									<option key={l.id} value={l.id}>
+										{l.name}
+									</option>
+								))}
+							</select>
+						</div>
+						<div className='space-y-1'>
+							<Label htmlFor='tr-to'>Vers</Label>
+							<select
+								id='tr-to'
+								className={selectClassName}
+								value={toLocation}
+								onChange={(e) => setToLocation(e.target.value)}
+							>
+								<option value=''>-- Sélectionner --</option>
+								{active
+									.filter((l) => l.id !== fromLocation)
+									.map((l) => (
+										<option key={l.id} value={l.id}>
+											{l.name}
+										</option>
+									))}
+							</select>
+						</div>
+					</div>
+
+					<div className='space-y-1'>
+						<Label htmlFor='tr-search'>Ajouter un produit</Label>
+						<Input
+							id='tr-search'
+							placeholder='Nom, référence ou code-barres'
+							value={search}
+							onChange={(e) => setSearch(e.target.value)}
+						/>
+						{search.trim() && products.length > 0 && (
+							<div className='max-h-40 overflow-y-auto rounded-md border'>
+								{products.map((p) => (
+									<button
+										key={p.id}
+										type='button'
+										className='flex w-full justify-between px-3 py-1.5 text-left text-sm hover:bg-muted'
+										onClick={() => addProduct(p)}
+									>
+										<span>{p.name}</span>
+										<span className='text-muted-foreground'>
+											{fromLocation
+												? `dispo ${available.get(p.id) ?? 0}`
+												: `stock ${p.stock ?? 0}`}
+										</span>
+									</button>
+								))}
+							</div>
+						)}
+					</div>
+
+					{lines.length > 0 && (
+						<div className='space-y-2'>
+							{lines.map((line, index) => (
+								<div key={line.productId} className='flex items-center gap-2'>
+									<div className='flex-1 text-sm'>
+										<div className='truncate'>{line.productName}</div>
+										{fromLocation && (
+											<div className='text-muted-foreground text-xs'>
+												{available.get(line.productId) ?? 0} disponible(s)
+											</div>
+										)}
+									</div>
+									<Input
+										className='w-24'
+										type='number'
+										min={0}
+										step='any'
+										aria-label='Quantité transférée'
+										value={line.quantity}
+										onChange={(e) =>
+											updateQuantity(index, Number(e.target.value))
+										}
+									/>
+									<Button
+										variant='ghost'
+										size='icon'
+										onClick={() =>
+											setLines((prev) => prev.filter((_, i) => i !== index))
+										}
+									>
+										<Trash2 className='h-4 w-4' />
+									</Button>
+								</div>
+							))}
+						</div>
+					)}
+
+					<div className='space-y-1'>
+						<Label htmlFor='tr-notes'>Notes</Label>
+						<Textarea
+							id='tr-notes'
+							value={notes}
+							onChange={(e) => setNotes(e.target.value)}
+						/>
+					</div>
+				</div>
+
+				<DialogFooter>
+					<Button variant='outline' onClick={() => onOpenChange(false)}>
+						Annuler
+					</Button>
+					<Button onClick={handleSubmit} disabled={createTransfer.isPending}>
+						{createTransfer.isPending ? (
+							<Loader2 className='mr-2 h-4 w-4 animate-spin' />
+						) : (
+							<ArrowLeftRight className='mr-2 h-4 w-4' />
+						)}
+						Transférer
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	)
+}
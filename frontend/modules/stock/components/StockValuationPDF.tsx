@@ -0,0 +1,174 @@
+// frontend/modules/stock/components/StockValuationPDF.tsx
+//
+// Valorisation du stock à une date, pour le dossier de clôture : synthèse,
+// ventilations, puis le détail par produit.
+
+import {
+	VALUATION_METHOD_LABELS,
+	type ValuationBucket,
+	type ValuationReport,
+} from '@/lib/queries/valuation'
+import { Document, Page, StyleSheet, Text, View } from '@react-pdf/renderer'
+
+const s = StyleSheet.create({
+	page: { padding: 30, fontSize: 8, fontFamily: 'Helvetica', color: '#000' },
+	header: {
+		marginBottom: 12,
+		paddingBottom: 8,
+		borderBottom: '2pt solid #000',
+	},
+	title: { fontSize: 16, fontWeight: 'bold', marginBottom: 2 },
+	subtitle: { fontSize: 8, color: '#666' },
+	section: {
+		marginBottom: 10,
+		paddingBottom: 8,
+		borderBottom: '1pt solid #e0e0e0',
+	},
+	sectionTitle: { fontSize: 10, fontWeight: 'bold', marginBottom: 4 },
+	grid: { display: 'flex', flexDirection: 'row', gap: 8 },
+	col: { flex: 1 },
+	label: { color: '#666', fontSize: 8 },
+	value: { fontSize: 11, fontWeight: 'bold' },
+	row: {
+		display: 'flex',
+		flexDirection: 'row',
+		paddingVertical: 2,
+		borderBottom: '1pt solid #eee',
+	},
+	headRow: {
+		display: 'flex',
+		flexDirection: 'row',
+		paddingBottom: 2,
+		borderBottom: '1pt solid #000',
+		fontWeight: 'bold',
+	},
+	cellName: { flex: 3 },
+	cell: { flex: 1, textAlign: 'right' },
+	muted: { color: '#666' },
+	warning: { marginTop: 4, fontSize: 7, color: '#a1470d' },
+	footer: {
+		marginTop: 10,
+		padding: 6,
+		backgroundColor: '#f9f9f9',
+		fontSize: 7,
+		color: '#666',
+	},
+})
+
+const fc = (amount: number) =>
+	new Intl.NumberFormat('fr-FR', { style: 'currency', currency: 'EUR' }).format(
+		amount,
+	)
+
+const fq = (qty: number) => qty.toLocaleString('fr-FR')
+
+function Buckets({
+	title,
+	buckets,
+}: { title: string; buckets: ValuationBucket[] }) {
+	return (
+		<View style={s.section} wrap={false}>
+			<Text style={s.sectionTitle}>{title}</Text>
+			<View style={s.headRow}>
+				<Text style={s.cellName}>Libellé</Text>
+				<Text style={s.cell}>Produits</Text>
+				<Text style={s.cell}>Quantité</Text>
+				<Text style={s.cell}>Valeur HT</Text>
+			</View>
+			{buckets.map((b) => (
+				<View key={b.name} style={s.row}>
+					<Text style={s.cellName}>{b.name}</Text>
+					<Text style={s.cell}>{b.products}</Text>
+					<Text style={s.cell}>{fq(b.quantity)}</Text>
+					<Text style={s.cell}>{fc(b.value)}</Text>
+				</View>
+			))}
+		</View>
+	)
+}
+
+interface StockValuationPDFProps {
+	report: ValuationReport
+	companyName?: string
+}
+
+export function StockValuationPDF({
+	report,
+	companyName,
+}: StockValuationPDFProps) {
+	const at = new Date(report.at).toLocaleDateString('fr-FR')
+
+	return (
+		<Document>
+			<Page size='A4' style={s.page}>
+				<View style={s.header}>
+					<Text style={s.title}>VALORISATION DU STOCK AU {at}</Text>
+					<Text style={s.subtitle}>
+						{companyName ? `${companyName} — ` : ''}
+						{VALUATION_METHOD_LABELS[report.method]} — montants HT
+					</Text>
+				</View>
+
+				<View style={s.section}>
+					<View style={s.grid}>
+						<View style={s.col}>
+							<Text style={s.label}>Produits en stock</Text>
+							<Text style={s.value}>{report.lines.length}</Text>
+						</View>
+						<View style={s.col}>
+							<Text style={s.label}>Quantité</Text>
+							<Text style={s.value}>{fq(report.total_quantity)}</Text>
+						</View>
+						<View style={s.col}>
+							<Text style={s.label}>Valeur du stock</Text>
+							<Text style={s.value}>{fc(report.total_value)}</Text>
+						</View>
+					</View>
+					{report.negative_products > 0 && (
+						<Text style={s.warning}>
+							{report.negative_products} produit(s) en stock négatif, comptés
+							pour zéro.
+						</Text>
+					)}
+				</View>
+
+				<Buckets title='PAR CATÉGORIE' buckets={report.by_category} />
+				<Buckets title='PAR MARQUE' buckets={report.by_brand} />
+				<Buckets title='PAR FOURNISSEUR' buckets={report.by_supplier} />
+
+				<View style={s.section}>
+					<Text style={s.sectionTitle}>DÉTAIL PAR PRODUIT</Text>
+					<View style={s.headRow} fixed>
+						<Text style={s.cellName}>Produit</Text>
+						<Text style={s.cell}>Quantité</Text>
+						<Text style={s.cell}>Coût unit. HT</Text>
+						<Text style={s.cell}>Valeur HT</Text>
+					</View>
+					{report.lines.map((l) => (
+						<View key={l.product_id} style={s.row} wrap={false}>
+							<Text style={s.cellName}>
+								{l.product_name}
+								{l.sku ? <Text style={s.muted}> — {l.sku}</Text> : null}
+								{l.cost_source === 'catalog' ? (
+									<Text style={s.muted}> *</Text>
+								) : null}
+							</Text>
+							<Text style={s.cell}>{fq(l.quantity)}</Text>
+							<Text style={s.cell}>{fc(l.unit_cost)}</Text>
+							<Text style={s.cell}>{fc(l.value)}</Text>
+						</View>
+					))}
+				</View>
+
+				<View style={s.footer}>
+					<Text>
+						Quantités rejouées depuis le journal des mouvements de stock ;
+						coûts tirés des réceptions fournisseur antérieures à la date. *
+						Sans réception : prix d’achat de la fiche produit. Les ventilations
+						suivent les rattachements actuels des produits.
+					</Text>
+				</View>
+			</Page>
+		</Document>
+	)
+}
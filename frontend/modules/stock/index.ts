@@ -1,11 +1,19 @@
 // frontend/modules/stock/index.ts
 import {
+	ArrowLeftRight,
 	Building2,
 	ClipboardList,
 	Database,
+	Hash,
+	Landmark,
+	Lock,
+	MapPin,
 	Package,
+	ShoppingCart,
 	Tags,
+	TrendingDown,
 	Truck,
+	Wrench,
 } from 'lucide-react'
 import type { ModuleManifest } from '../_registry'
 
@@ -46,6 +54,17 @@ export const manifest: ModuleManifest = {
 				{ label: 'Marques', to: '/stock/marques', icon: Building2 },
 				{ label: 'Catégories', to: '/stock/categories', icon: Tags },
 				{ label: 'Fournisseurs', to: '/stock/fournisseurs', icon: Truck },
+				{
+					label: 'Commandes fournisseur',
+					to: '/stock/commandes',
+					icon: ShoppingCart,
+				},
+				{ label: 'Réassort', to: '/stock/reassort', icon: TrendingDown },
+				{ label: 'Emplacements', to: '/stock/emplacements', icon: MapPin },
+				{ label: 'Transferts', to: '/stock/transferts', icon: ArrowLeftRight },
+				{ label: 'Numéros de série', to: '/stock/numeros-serie', icon: Hash },
+				{ label: 'Réservations', to: '/stock/reservations', icon: Lock },
+				{ label: 'Retours SAV', to: '/stock/retours-sav', icon: Wrench },
 			],
 		},
 		{
@@ -58,6 +77,11 @@ export const manifest: ModuleManifest = {
 					to: '/stock/inventaire',
 					icon: ClipboardList,
 				},
+				{
+					label: 'Valorisation du stock',
+					to: '/stock/valorisation',
+					icon: Landmark,
+				},
 			],
 		},
 	],
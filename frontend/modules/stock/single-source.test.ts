@@ -112,7 +112,12 @@ describe('les mouvements de stock ont un seul chemin', () => {
 	it("l'inventaire n'écrit plus dans AppPos", () => {
 		const source = lire('lib/inventory/useInventorySession.ts')
 		expect(source).not.toMatch(/updateAppPosProductStock/)
-		expect(source).toMatch(/setCountedStock/)
+		// Depuis le 18 octobre 2026, les écarts sont appliqués par le serveur à
+		// la clôture (`/api/inventory/:id/complete`), plus par le client.
+		expect(source).not.toMatch(/setCountedStock/)
+		expect(lire('lib/inventory/inventory-pocketbase.ts')).toMatch(
+			/\/api\/inventory\/\$\{sessionId\}\/complete/,
+		)
 	})
 
 	it('le reclassement de retour non plus', () => {
@@ -29,10 +29,18 @@ import { Route as SettingsSmtpImport } from './routes/settings/smtp'
 import { Route as SettingsSecretsImport } from './routes/settings/secrets'
 import { Route as SettingsCompaniesImport } from './routes/settings/companies'
 import { Route as CashConfigImport } from './routes/cash/config'
+import { Route as StockValorisationIndexImport } from './routes/stock/valorisation/index'
+import { Route as StockTransfertsIndexImport } from './routes/stock/transferts/index'
+import { Route as StockRetoursSavIndexImport } from './routes/stock/retours-sav/index'
+import { Route as StockReservationsIndexImport } from './routes/stock/reservations/index'
+import { Route as StockReassortIndexImport } from './routes/stock/reassort/index'
 import { Route as StockProduitsIndexImport } from './routes/stock/produits/index'
+import { Route as StockNumerosSerieIndexImport } from './routes/stock/numeros-serie/index'
 import { Route as StockMarquesIndexImport } from './routes/stock/marques/index'
 import { Route as StockInventaireIndexImport } from './routes/stock/inventaire/index'
 import { Route as StockFournisseursIndexImport } from './routes/stock/fournisseurs/index'
+import { Route as StockEmplacementsIndexImport } from './routes/stock/emplacements/index'
+import { Route as StockCommandesIndexImport } from './routes/stock/commandes/index'
 import { Route as StockCategoriesIndexImport } from './routes/stock/categories/index'
 import { Route as ConnectQuotesIndexImport } from './routes/connect/quotes/index'
 import { Route as ConnectOrdersIndexImport } from './routes/connect/orders/index'
@@ -168,12 +176,48 @@ const CashConfigRoute = CashConfigImport.update({
   getParentRoute: () => rootRoute,
 } as any)
 
+const StockValorisationIndexRoute = StockValorisationIndexImport.update({
+  id: '/stock/valorisation/',
+  path: '/stock/valorisation/',
+  getParentRoute: () => rootRoute,
+} as any)
+
+const StockTransfertsIndexRoute = StockTransfertsIndexImport.update({
+  id: '/stock/transferts/',
+  path: '/stock/transferts/',
+  getParentRoute: () => rootRoute,
+} as any)
+
+const StockRetoursSavIndexRoute = StockRetoursSavIndexImport.update({
+  id: '/stock/retours-sav/',
+  path: '/stock/retours-sav/',
+  getParentRoute: () => rootRoute,
+} as any)
+
+const StockReservationsIndexRoute = StockReservationsIndexImport.update({
+  id: '/stock/reservations/',
+  path: '/stock/reservations/',
+  getParentRoute: () => rootRoute,
+} as any)
+
+const StockReassortIndexRoute = StockReassortIndexImport.update({
+  id: '/stock/reassort/',
+  path: '/stock/reassort/',
+  getParentRoute: () => rootRoute,
+} as any)
+
 const StockProduitsIndexRoute = StockProduitsIndexImport.update({
   id: '/stock/produits/',
   path: '/stock/produits/',
   getParentRoute: () => rootRoute,
 } as any)
 
+const StockNumerosSerieIndexRoute = StockNumerosSerieIndexImport.update({
+  id: '/stock/numeros-serie/',
+  path: '/stock/numeros-serie/',
+  getParentRoute: () => rootRoute,
+} as any)
+
 const StockMarquesIndexRoute = StockMarquesIndexImport.update({
   id: '/stock/marques/',
   path: '/stock/marques/',
@@ -192,12 +236,24 @@ const StockFournisseursIndexRoute = StockFournisseursIndexImport.update({
   getParentRoute: () => rootRoute,
 } as any)
 
+const StockEmplacementsIndexRoute = StockEmplacementsIndexImport.update({
+  id: '/stock/emplacements/',
+  path: '/stock/emplacements/',
+  getParentRoute: () => rootRoute,
+} as any)
+
 const StockCategoriesIndexRoute = StockCategoriesIndexImport.update({
   id: '/stock/categories/',
   path: '/stock/categories/',
   getParentRoute: () => rootRoute,
 } as any)
 
+const StockCommandesIndexRoute = StockCommandesIndexImport.update({
+  id: '/stock/commandes/',
+  path: '/stock/commandes/',
+  getParentRoute: () => rootRoute,
+} as any)
+
 const ConnectQuotesIndexRoute = ConnectQuotesIndexImport.update({
   id: '/connect/quotes/',
   path: '/connect/quotes/',
@@ -563,6 +619,20 @@ declare module '@tanstack/react-router' {
       preLoaderRoute: typeof StockCategoriesIndexImport
       parentRoute: typeof rootRoute
     }
+    '/stock/commandes/': {
+      id: '/stock/commandes/'
+      path: '/stock/commandes'
+      fullPath: '/stock/commandes'
+      preLoaderRoute: typeof StockCommandesIndexImport
+      parentRoute: typeof rootRoute
+    }
+    '/stock/emplacements/': {
+      id: '/stock/emplacements/'
+      path: '/stock/emplacements'
+      fullPath: '/stock/emplacements'
+      preLoaderRoute: typeof StockEmplacementsIndexImport
+      parentRoute: typeof rootRoute
+    }
     '/stock/fournisseurs/': {
       id: '/stock/fournisseurs/'
       path: '/stock/fournisseurs'
@@ -584,6 +654,13 @@ declare module '@tanstack/react-router' {
       preLoaderRoute: typeof StockMarquesIndexImport
       parentRoute: typeof rootRoute
     }
+    '/stock/numeros-serie/': {
+      id: '/stock/numeros-serie/'
+      path: '/stock/numeros-serie'
+      fullPath: '/stock/numeros-serie'
+      preLoaderRoute: typeof StockNumerosSerieIndexImport
+      parentRoute: typeof rootRoute
+    }
     '/stock/produits/': {
       id: '/stock/produits/'
       path: '/stock/produits'
@@ -591,6 +668,41 @@ declare module '@tanstack/react-router' {
       preLoaderRoute: typeof StockProduitsIndexImport
       parentRoute: typeof rootRoute
     }
+    '/stock/reassort/': {
+      id: '/stock/reassort/'
+      path: '/stock/reassort'
+      fullPath: '/stock/reassort'
+      preLoaderRoute: typeof StockReassortIndexImport
+      parentRoute: typeof rootRoute
+    }
+    '/stock/reservations/': {
+      id: '/stock/reservations/'
+      path: '/stock/reservations'
+      fullPath: '/stock/reservations'
+      preLoaderRoute: typeof StockReservationsIndexImport
+      parentRoute: typeof rootRoute
+    }
+    '/stock/retours-sav/': {
+      id: '/stock/retours-sav/'
+      path: '/stock/retours-sav'
+      fullPath: '/stock/retours-sav'
+      preLoaderRoute: typeof StockRetoursSavIndexImport
+      parentRoute: typeof rootRoute
+    }
+    '/stock/transferts/': {
+      id: '/stock/transferts/'
+      path: '/stock/transferts'
+      fullPath: '/stock/transferts'
+      preLoaderRoute: typeof StockTransfertsIndexImport
+      parentRoute: typeof rootRoute
+    }
+    '/stock/valorisation/': {
+      id: '/stock/valorisation/'
+      path: '/stock/valorisation'
+      fullPath: '/stock/valorisation'
+      preLoaderRoute: typeof StockValorisationIndexImport
+      parentRoute: typeof rootRoute
+    }
     '/connect/customers/$customerId/edit': {
       id: '/connect/customers/$customerId/edit'
       path: '/connect/customers/$customerId/edit'
@@ -705,10 +817,18 @@ export interface FileRoutesByFullPath {
   '/connect/orders': typeof ConnectOrdersIndexRoute
   '/connect/quotes': typeof ConnectQuotesIndexRoute
   '/stock/categories': typeof StockCategoriesIndexRoute
+  '/stock/commandes': typeof StockCommandesIndexRoute
+  '/stock/emplacements': typeof StockEmplacementsIndexRoute
   '/stock/fournisseurs': typeof StockFournisseursIndexRoute
   '/stock/inventaire': typeof StockInventaireIndexRoute
   '/stock/marques': typeof StockMarquesIndexRoute
+  '/stock/numeros-serie': typeof StockNumerosSerieIndexRoute
   '/stock/produits': typeof StockProduitsIndexRoute
+  '/stock/reassort': typeof StockReassortIndexRoute
+  '/stock/reservations': typeof StockReservationsIndexRoute
+  '/stock/retours-sav': typeof StockRetoursSavIndexRoute
+  '/stock/transferts': typeof StockTransfertsIndexRoute
+  '/stock/valorisation': typeof StockValorisationIndexRoute
   '/connect/customers/$customerId/edit': typeof ConnectCustomersCustomerIdEditRoute
   '/connect/invoices/$invoiceId/edit': typeof ConnectInvoicesInvoiceIdEditRoute
   '/connect/orders/$orderId/edit': typeof ConnectOrdersOrderIdEditRoute
@@ -754,10 +874,18 @@ export interface FileRoutesByTo {
   '/connect/orders': typeof ConnectOrdersIndexRoute
   '/connect/quotes': typeof ConnectQuotesIndexRoute
   '/stock/categories': typeof StockCategoriesIndexRoute
+  '/stock/commandes': typeof StockCommandesIndexRoute
+  '/stock/emplacements': typeof StockEmplacementsIndexRoute
   '/stock/fournisseurs': typeof StockFournisseursIndexRoute
   '/stock/inventaire': typeof StockInventaireIndexRoute
   '/stock/marques': typeof StockMarquesIndexRoute
+  '/stock/numeros-serie': typeof StockNumerosSerieIndexRoute
   '/stock/produits': typeof StockProduitsIndexRoute
+  '/stock/reassort': typeof StockReassortIndexRoute
+  '/stock/reservations': typeof StockReservationsIndexRoute
+  '/stock/retours-sav': typeof StockRetoursSavIndexRoute
+  '/stock/transferts': typeof StockTransfertsIndexRoute
+  '/stock/valorisation': typeof StockValorisationIndexRoute
   '/connect/customers/$customerId/edit': typeof ConnectCustomersCustomerIdEditRoute
   '/connect/invoices/$invoiceId/edit': typeof ConnectInvoicesInvoiceIdEditRoute
   '/connect/orders/$orderId/edit': typeof ConnectOrdersOrderIdEditRoute
@@ -804,10 +932,18 @@ export interface FileRoutesById {
   '/connect/orders/': typeof ConnectOrdersIndexRoute
   '/connect/quotes/': typeof ConnectQuotesIndexRoute
   '/stock/categories/': typeof StockCategoriesIndexRoute
+  '/stock/commandes/': typeof StockCommandesIndexRoute
+  '/stock/emplacements/': typeof StockEmplacementsIndexRoute
   '/stock/fournisseurs/': typeof StockFournisseursIndexRoute
   '/stock/inventaire/': typeof StockInventaireIndexRoute
   '/stock/marques/': typeof StockMarquesIndexRoute
+  '/stock/numeros-serie/': typeof StockNumerosSerieIndexRoute
   '/stock/produits/': typeof StockProduitsIndexRoute
+  '/stock/reassort/': typeof StockReassortIndexRoute
+  '/stock/reservations/': typeof StockReservationsIndexRoute
+  '/stock/retours-sav/': typeof StockRetoursSavIndexRoute
+  '/stock/transferts/': typeof StockTransfertsIndexRoute
+  '/stock/valorisation/': typeof StockValorisationIndexRoute
   '/connect/customers/$customerId/edit': typeof ConnectCustomersCustomerIdEditRoute
   '/connect/invoices/$invoiceId/edit': typeof ConnectInvoicesInvoiceIdEditRoute
   '/connect/orders/$orderId/edit': typeof ConnectOrdersOrderIdEditRoute
@@ -855,10 +991,18 @@ export interface FileRouteTypes {
     | '/connect/orders'
     | '/connect/quotes'
     | '/stock/categories'
+    | '/stock/commandes'
+    | '/stock/emplacements'
     | '/stock/fournisseurs'
     | '/stock/inventaire'
     | '/stock/marques'
+    | '/stock/numeros-serie'
     | '/stock/produits'
+    | '/stock/reassort'
+    | '/stock/reservations'
+    | '/stock/retours-sav'
+    | '/stock/transferts'
+    | '/stock/valorisation'
     | '/connect/customers/$customerId/edit'
     | '/connect/invoices/$invoiceId/edit'
     | '/connect/orders/$orderId/edit'
@@ -903,10 +1047,18 @@ export interface FileRouteTypes {
     | '/connect/orders'
     | '/connect/quotes'
     | '/stock/categories'
+    | '/stock/commandes'
+    | '/stock/emplacements'
     | '/stock/fournisseurs'
     | '/stock/inventaire'
     | '/stock/marques'
+    | '/stock/numeros-serie'
     | '/stock/produits'
+    | '/stock/reassort'
+    | '/stock/reservations'
+    | '/stock/retours-sav'
+    | '/stock/transferts'
+    | '/stock/valorisation'
     | '/connect/customers/$customerId/edit'
     | '/connect/invoices/$invoiceId/edit'
     | '/connect/orders/$orderId/edit'
@@ -951,10 +1103,18 @@ export interface FileRouteTypes {
     | '/connect/orders/'
     | '/connect/quotes/'
     | '/stock/categories/'
+    | '/stock/commandes/'
+    | '/stock/emplacements/'
     | '/stock/fournisseurs/'
     | '/stock/inventaire/'
     | '/stock/marques/'
+    | '/stock/numeros-serie/'
     | '/stock/produits/'
+    | '/stock/reassort/'
+    | '/stock/reservations/'
+    | '/stock/retours-sav/'
+    | '/stock/transferts/'
+    | '/stock/valorisation/'
     | '/connect/customers/$customerId/edit'
     | '/connect/invoices/$invoiceId/edit'
     | '/connect/orders/$orderId/edit'
@@ -1001,10 +1161,18 @@ export interface RootRouteChildren {
   ConnectOrdersIndexRoute: typeof ConnectOrdersIndexRoute
   ConnectQuotesIndexRoute: typeof ConnectQuotesIndexRoute
   StockCategoriesIndexRoute: typeof StockCategoriesIndexRoute
+  StockCommandesIndexRoute: typeof StockCommandesIndexRoute
+  StockEmplacementsIndexRoute: typeof StockEmplacementsIndexRoute
   StockFournisseursIndexRoute: typeof StockFournisseursIndexRoute
   StockInventaireIndexRoute: typeof StockInventaireIndexRoute
   StockMarquesIndexRoute: typeof StockMarquesIndexRoute
+  StockNumerosSerieIndexRoute: typeof StockNumerosSerieIndexRoute
   StockProduitsIndexRoute: typeof StockProduitsIndexRoute
+  StockReassortIndexRoute: typeof StockReassortIndexRoute
+  StockReservationsIndexRoute: typeof StockReservationsIndexRoute
+  StockRetoursSavIndexRoute: typeof StockRetoursSavIndexRoute
+  StockTransfertsIndexRoute: typeof StockTransfertsIndexRoute
+  StockValorisationIndexRoute: typeof StockValorisationIndexRoute
   ConnectCustomersCustomerIdEditRoute: typeof ConnectCustomersCustomerIdEditRoute
   ConnectInvoicesInvoiceIdEditRoute: typeof ConnectInvoicesInvoiceIdEditRoute
   ConnectOrdersOrderIdEditRoute: typeof ConnectOrdersOrderIdEditRoute
@@ -1050,10 +1218,18 @@ const rootRouteChildren: RootRouteChildren = {
   ConnectOrdersIndexRoute: ConnectOrdersIndexRoute,
   ConnectQuotesIndexRoute: ConnectQuotesIndexRoute,
   StockCategoriesIndexRoute: StockCategoriesIndexRoute,
+  StockCommandesIndexRoute: StockCommandesIndexRoute,
+  StockEmplacementsIndexRoute: StockEmplacementsIndexRoute,
   StockFournisseursIndexRoute: StockFournisseursIndexRoute,
   StockInventaireIndexRoute: StockInventaireIndexRoute,
   StockMarquesIndexRoute: StockMarquesIndexRoute,
+  StockNumerosSerieIndexRoute: StockNumerosSerieIndexRoute,
   StockProduitsIndexRoute: StockProduitsIndexRoute,
+  StockReassortIndexRoute: StockReassortIndexRoute,
+  StockReservationsIndexRoute: StockReservationsIndexRoute,
+  StockRetoursSavIndexRoute: StockRetoursSavIndexRoute,
+  StockTransfertsIndexRoute: StockTransfertsIndexRoute,
+  StockValorisationIndexRoute: StockValorisationIndexRoute,
   ConnectCustomersCustomerIdEditRoute: ConnectCustomersCustomerIdEditRoute,
   ConnectInvoicesInvoiceIdEditRoute: ConnectInvoicesInvoiceIdEditRoute,
   ConnectOrdersOrderIdEditRoute: ConnectOrdersOrderIdEditRoute,
@@ -1111,10 +1287,18 @@ export const routeTree = rootRoute
         "/connect/orders/",
         "/connect/quotes/",
         "/stock/categories/",
+        "/stock/commandes/",
+        "/stock/emplacements/",
         "/stock/fournisseurs/",
         "/stock/inventaire/",
         "/stock/marques/",
+        "/stock/numeros-serie/",
         "/stock/produits/",
+        "/stock/reassort/",
+        "/stock/reservations/",
+        "/stock/retours-sav/",
+        "/stock/transferts/",
+        "/stock/valorisation/",
         "/connect/customers/$customerId/edit",
         "/connect/invoices/$invoiceId/edit",
         "/connect/orders/$orderId/edit",
@@ -1221,6 +1405,12 @@ export const routeTree = rootRoute
     "/stock/categories/": {
       "filePath": "stock/categories/index.tsx"
     },
+    "/stock/commandes/": {
+      "filePath": "stock/commandes/index.tsx"
+    },
+    "/stock/emplacements/": {
+      "filePath": "stock/emplacements/index.tsx"
+    },
     "/stock/fournisseurs/": {
       "filePath": "stock/fournisseurs/index.tsx"
     },
@@ -1230,9 +1420,27 @@ export const routeTree = rootRoute
     "/stock/marques/": {
       "filePath": "stock/marques/index.tsx"
     },
+    "/stock/numeros-serie/": {
+      "filePath": "stock/numeros-serie/index.tsx"
+    },
     "/stock/produits/": {
       "filePath": "stock/produits/index.tsx"
     },
+    "/stock/reassort/": {
+      "filePath": "stock/reassort/index.tsx"
+    },
+    "/stock/reservations/": {
+      "filePath": "stock/reservations/index.tsx"
+    },
+    "/stock/retours-sav/": {
+      "filePath": "stock/retours-sav/index.tsx"
+    },
+    "/stock/transferts/": {
+      "filePath": "stock/transferts/index.tsx"
+    },
+    "/stock/valorisation/": {
+      "filePath": "stock/valorisation/index.tsx"
+    },
     "/connect/customers/$customerId/edit": {
       "filePath": "connect/customers/$customerId/edit.tsx"
     },
@@ -0,0 +1,7 @@
+// frontend/routes/stock/commandes/index.tsx
+import { PurchaseOrdersPage } from '@/modules/stock/PurchaseOrdersPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/commandes/')({
+	component: PurchaseOrdersPage,
+})
@@ -0,0 +1,7 @@
+// frontend/routes/stock/emplacements/index.tsx
+import { StockLocationsPage } from '@/modules/stock/StockLocationsPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/emplacements/')({
+	component: StockLocationsPage,
+})
@@ -0,0 +1,7 @@
+// frontend/routes/stock/numeros-serie/index.tsx
+import { SerialNumbersPage } from '@/modules/stock/SerialNumbersPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/numeros-serie/')({
+	component: SerialNumbersPage,
+})
@@ -0,0 +1,7 @@
+// frontend/routes/stock/reassort/index.tsx
+import { ReplenishmentPage } from '@/modules/stock/ReplenishmentPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/reassort/')({
+	component: ReplenishmentPage,
+})
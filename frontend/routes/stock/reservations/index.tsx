@@ -0,0 +1,7 @@
+// frontend/routes/stock/reservations/index.tsx
+import { ReservationsPage } from '@/modules/stock/ReservationsPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/reservations/')({
+	component: ReservationsPage,
+})
@@ -0,0 +1,7 @@
+// frontend/routes/stock/retours-sav/index.tsx
+import { RmaPage } from '@/modules/stock/RmaPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/retours-sav/')({
+	component: RmaPage,
+})
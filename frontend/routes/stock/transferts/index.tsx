@@ -0,0 +1,7 @@
+// frontend/routes/stock/transferts/index.tsx
+import { StockTransfersPage } from '@/modules/stock/StockTransfersPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/transferts/')({
+	component: StockTransfersPage,
+})
@@ -0,0 +1,7 @@
+// frontend/routes/stock/valorisation/index.tsx
+import { StockValuationPage } from '@/modules/stock/StockValuationPage'
+import { createFileRoute } from '@tanstack/react-router'
+
+export const Route = createFileRoute('/stock/valorisation/')({
+	component: StockValuationPage,
+})
@@ -13,6 +13,7 @@ import (
 	"pocket-react/backend"
 	"pocket-react/backend/hooks"
 	"pocket-react/backend/migrations"
+	"pocket-react/backend/pos"
 	"pocket-react/backend/routes"
 
 	"github.com/joho/godotenv"
@@ -82,6 +83,9 @@ func main() {
 
 	initLogging(baseDir)
 
+	// Version imprimée sur les tickets (mention "Logiciel")
+	pos.SoftwareVersion = currentVersion
+
 	appDataDir := os.Getenv("LOCALAPPDATA")
 	if appDataDir == "" {
 		appDataDir = "."
@@ -99,6 +103,10 @@ func main() {
 
 	hooks.RegisterAllHooks(pb)
 	hooks.RegisterOrderHooks(pb)
+	hooks.RegisterPurchaseOrderHooks(pb)
+	hooks.RegisterStockLocationHooks(pb)
+	hooks.RegisterSerialHooks(pb)
+	hooks.RegisterProductEventHooks(pb)
 	hooks.RegisterCompanyHooks(pb)
 	hooks.RegisterCustomerNumberHook(pb)
 
@@ -185,11 +193,14 @@ func startPocketBaseNoCobra(pb *pocketbase.PocketBase, embeddedAssets embed.FS)
 		routes.RegisterQuoteEmailRoutes(pb, e.Router)
 		routes.RegisterInvoiceEmailRoutes(pb, e.Router)
 		routes.RegisterCashRoutes(pb, e.Router)
+		routes.RegisterCashShiftRoutes(pb, e.Router)
 		routes.RegisterInvoiceRefundRoutes(pb, e.Router)
 		routes.RegisterPosRoutes(pb, e.Router)
+		routes.RegisterPosOfflineRoutes(pb, e.Router)
 		routes.RegisterPosPrintRoutes(pb, e.Router)
 		routes.RegisterScannerRoutes(pb, e.Router)
 		routes.RegisterDisplayRoutes(pb, e.Router)
+		routes.RegisterCustomerScreenRoutes(pb, e.Router)
 		routes.RegisterCompanyManagementRoutes(pb, e.Router)
 		routes.RegisterUserManagementRoutes(pb, e.Router)
 		routes.RegisterPaymentMethodsRoutes(pb, e.Router)
@@ -206,7 +217,18 @@ func startPocketBaseNoCobra(pb *pocketbase.PocketBase, embeddedAssets embed.FS)
 		routes.RegisterPresenceRoutes(pb, e.Router)
 		routes.RegisterSSERoutes(pb, e.Router) // ← AJOUT SSE
 		routes.RegisterStockRoutes(pb, e.Router)
+		routes.RegisterInventoryRoutes(pb, e.Router)
+		routes.RegisterPurchaseRoutes(pb, e.Router)
+		routes.RegisterReplenishmentRoutes(pb, e.Router)
+		routes.RegisterValuationRoutes(pb, e.Router)
+		routes.RegisterLocationRoutes(pb, e.Router)
+		routes.RegisterSerialRoutes(pb, e.Router)
+		routes.RegisterReservationRoutes(pb, e.Router)
+		routes.RegisterRmaRoutes(pb, e.Router)
 		routes.RegisterProductImageRoutes(pb, e.Router)
+		routes.RegisterLoyaltyRoutes(pb, e.Router)
+		routes.RegisterPricingRoutes(pb, e.Router)
+		routes.RegisterLabelRoutes(pb, e.Router)
 
 		// SPA handler (doit rester en dernier)
 		e.Router.GET("/*", StaticSPAHandler(distFS))